@@ -0,0 +1,455 @@
+// An Array is a growable array of values indexed by uint64, which may
+// be sparse: only the indices set take up space. Entries are held in
+// pages, each for a fixed number of consecutive indices, which are
+// created only when an index in them is first set, and found through
+// a tree of directories, like the page tables of virtual memory, which
+// deepens as higher indices are set. An Array therefore suits
+// slot-addressed and column-like data, dense or not, which a hash map
+// would scatter.
+//
+// Setting an index below Len rewrites only its page, and any
+// directories created on the way to it; only Append and setting
+// indices beyond the end rewrite the root.
+package array
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/array/msgpack"
+	"math"
+	"sort"
+)
+
+// The number of indices of each page of a new Array, if no page size
+// is given.
+const DefaultPageSize = 256
+
+const (
+	// Every directory has up to 1<<dirBits children.
+	dirBits = 6
+	fanout  = 1 << dirBits
+)
+
+type Array struct {
+	// The connection used to create this Array object. As with LHash,
+	// you should not use the same Array object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Array.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Array, each page of which holds pageSize
+// indices (DefaultPageSize if pageSize is not positive). This creates
+// a new GoshawkDB Object and initialises it for use as an Array.
+// Larger pages mean fewer Objects for dense data, but more to rewrite
+// on each Set.
+func NewEmptyArray(conn *client.Connection, pageSize int) (*Array, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Root{PageSize: int64(pageSize)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Array{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Array), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create an Array object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func ArrayFromObj(conn *client.Connection, objRef client.ObjectRef) *Array {
+	return &Array{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Array within a single transaction.
+type state struct {
+	objRef client.ObjectRef
+	root   *mp.Root
+	// nil if nothing is set.
+	top *client.ObjectRef
+}
+
+func (a *Array) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(a.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.PageSize < 1 || len(refs) > 1 {
+		return nil, fmt.Errorf("Array root %v is corrupt", obj)
+	}
+	s := &state{objRef: obj, root: root}
+	if len(refs) == 1 {
+		s.top = &refs[0]
+	}
+	return s, nil
+}
+
+func (s *state) write() error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if s.top == nil {
+		return s.objRef.Set(value)
+	}
+	return s.objRef.Set(value, *s.top)
+}
+
+// locate returns the page and the slot within it of idx.
+func (s *state) locate(idx uint64) (uint64, uint32) {
+	pageSize := uint64(s.root.PageSize)
+	return idx / pageSize, uint32(idx % pageSize)
+}
+
+// fits returns whether a tree of the given depth reaches page.
+func fits(page uint64, depth uint32) bool {
+	return depth*dirBits >= 64 || page < 1<<(depth*dirBits)
+}
+
+// child returns the index of the child, of a directory at the given
+// level (1 for those just above the pages), on the path to page.
+func child(page uint64, level uint32) int {
+	return int((page >> ((level - 1) * dirBits)) & (fanout - 1))
+}
+
+// isPlaceholder returns whether objRef stands for a missing child.
+func (s *state) isPlaceholder(objRef client.ObjectRef) bool {
+	return objRef.ReferencesSameAs(s.objRef)
+}
+
+func readPage(objRef client.ObjectRef) (*mp.Page, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	page := new(mp.Page)
+	if _, err = page.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// find returns the position of slot among the entries of page, and
+// whether it is there.
+func find(page *mp.Page, slot uint32) (int, bool) {
+	entries := page.Entries
+	idx := sort.Search(len(entries), func(i int) bool { return entries[i].Slot >= slot })
+	return idx, idx < len(entries) && entries[idx].Slot == slot
+}
+
+// Returns one more than the highest index ever set, which is the index
+// the next Append will set. Unset does not reduce Len.
+func (a *Array) Len() (uint64, error) {
+	res, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.root.Len, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the value at idx, and whether idx is set. Only the
+// directories and page on the path to idx are read.
+func (a *Array) Get(idx uint64) ([]byte, bool, error) {
+	res, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil || s.top == nil || idx >= s.root.Len {
+			return nil, err
+		}
+		pageNum, slot := s.locate(idx)
+		node := *s.top
+		for level := s.root.Depth; level > 0; level-- {
+			refs, err := node.References()
+			if err != nil {
+				return nil, err
+			}
+			c := child(pageNum, level)
+			if c >= len(refs) || s.isPlaceholder(refs[c]) {
+				return nil, nil
+			}
+			node = refs[c]
+		}
+		page, err := readPage(node)
+		if err != nil {
+			return nil, err
+		}
+		if pos, found := find(page, slot); found {
+			return page.Entries[pos].Value, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, false, err
+	} else if res == nil {
+		return nil, false, nil
+	}
+	return res.([]byte), true, nil
+}
+
+// Set the value at idx, growing the Array if idx is not below Len.
+func (a *Array) Set(idx uint64, value []byte) error {
+	_, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.set(txn, idx, value)
+	})
+	return err
+}
+
+// Set value at index Len, returning the index.
+func (a *Array) Append(value []byte) (uint64, error) {
+	res, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx := s.root.Len
+		return idx, s.set(txn, idx, value)
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// set sets the value at idx, rewriting the root only if the tree
+// grows, or idx is beyond the end.
+func (s *state) set(txn *client.Txn, idx uint64, value []byte) error {
+	if idx == math.MaxUint64 {
+		// Len could not be beyond it.
+		return errors.New("Array index out of range")
+	}
+	pageNum, slot := s.locate(idx)
+	rootChanged := false
+	if s.top == nil {
+		s.root.Depth = 0
+		for !fits(pageNum, s.root.Depth) {
+			s.root.Depth++
+		}
+		rootChanged = true
+	}
+	for !fits(pageNum, s.root.Depth) {
+		// deepen the tree, with the old top as the first child.
+		top, err := txn.CreateObject(nil, *s.top)
+		if err != nil {
+			return err
+		}
+		s.top = &top
+		s.root.Depth++
+		rootChanged = true
+	}
+	top, err := s.setUnder(txn, s.top, s.root.Depth, pageNum, slot, value)
+	if err != nil {
+		return err
+	}
+	if s.top == nil {
+		s.top = &top
+	}
+	if idx >= s.root.Len {
+		s.root.Len = idx + 1
+		rootChanged = true
+	}
+	if rootChanged {
+		return s.write()
+	}
+	return nil
+}
+
+// setUnder sets the value at slot of page under node, at the given
+// level (0 for a page), returning node, which is created if nil.
+func (s *state) setUnder(txn *client.Txn, node *client.ObjectRef, level uint32, pageNum uint64, slot uint32, value []byte) (client.ObjectRef, error) {
+	if level == 0 {
+		page := new(mp.Page)
+		if node != nil {
+			var err error
+			if page, err = readPage(*node); err != nil {
+				return client.ObjectRef{}, err
+			}
+		}
+		pos, found := find(page, slot)
+		if !found {
+			page.Entries = append(page.Entries, mp.Entry{})
+			copy(page.Entries[pos+1:], page.Entries[pos:])
+		}
+		page.Entries[pos] = mp.Entry{Slot: slot, Value: value}
+		encoded, err := page.MarshalMsg(nil)
+		if err != nil {
+			return client.ObjectRef{}, err
+		}
+		if node == nil {
+			return txn.CreateObject(encoded)
+		}
+		return *node, node.Set(encoded)
+	}
+
+	var refs []client.ObjectRef
+	if node != nil {
+		var err error
+		if refs, err = node.References(); err != nil {
+			return client.ObjectRef{}, err
+		}
+	}
+	c := child(pageNum, level)
+	var existing *client.ObjectRef
+	if c < len(refs) && !s.isPlaceholder(refs[c]) {
+		existing = &refs[c]
+	}
+	childRef, err := s.setUnder(txn, existing, level-1, pageNum, slot, value)
+	if err != nil {
+		return client.ObjectRef{}, err
+	} else if existing != nil {
+		return *node, nil
+	}
+	for len(refs) <= c {
+		refs = append(refs, s.objRef)
+	}
+	refs[c] = childRef
+	if node == nil {
+		return txn.CreateObject(nil, refs...)
+	}
+	return *node, node.Set(nil, refs...)
+}
+
+// Unset idx, returning whether it was set. Pages and directories left
+// empty are dropped, but Len is unchanged.
+func (a *Array) Unset(idx uint64) (bool, error) {
+	res, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil || s.top == nil || idx >= s.root.Len {
+			return false, err
+		}
+		pageNum, slot := s.locate(idx)
+		removed, empty, err := s.unsetUnder(*s.top, s.root.Depth, pageNum, slot)
+		if err != nil || !empty {
+			return removed, err
+		}
+		s.top = nil
+		return removed, s.write()
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// unsetUnder unsets slot of page under node, at the given level,
+// returning whether it was set, and whether node is left empty, in
+// which case it is not rewritten but should be dropped.
+func (s *state) unsetUnder(node client.ObjectRef, level uint32, pageNum uint64, slot uint32) (bool, bool, error) {
+	if level == 0 {
+		page, err := readPage(node)
+		if err != nil {
+			return false, false, err
+		}
+		pos, found := find(page, slot)
+		if !found {
+			return false, false, nil
+		}
+		page.Entries = append(page.Entries[:pos], page.Entries[pos+1:]...)
+		if len(page.Entries) == 0 {
+			return true, true, nil
+		}
+		encoded, err := page.MarshalMsg(nil)
+		if err != nil {
+			return false, false, err
+		}
+		return true, false, node.Set(encoded)
+	}
+
+	refs, err := node.References()
+	if err != nil {
+		return false, false, err
+	}
+	c := child(pageNum, level)
+	if c >= len(refs) || s.isPlaceholder(refs[c]) {
+		return false, false, nil
+	}
+	removed, empty, err := s.unsetUnder(refs[c], level-1, pageNum, slot)
+	if err != nil || !empty {
+		return removed, false, err
+	}
+	refs[c] = s.objRef
+	for len(refs) > 0 && s.isPlaceholder(refs[len(refs)-1]) {
+		refs = refs[:len(refs)-1]
+	}
+	if len(refs) == 0 {
+		return removed, true, nil
+	}
+	return removed, false, node.Set(nil, refs...)
+}
+
+// Iterate over the indices set in the Array, in index order, with
+// their values. Only pages with entries are read. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case entries may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (a *Array) ForEach(f func(idx uint64, value []byte) error) error {
+	_, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil || s.top == nil {
+			return nil, err
+		}
+		return nil, s.forEach(*s.top, s.root.Depth, 0, f)
+	})
+	return err
+}
+
+// forEach calls f with the entries under node, at the given level,
+// which is the pageNum'th node of its level.
+func (s *state) forEach(node client.ObjectRef, level uint32, pageNum uint64, f func(idx uint64, value []byte) error) error {
+	if level == 0 {
+		page, err := readPage(node)
+		if err != nil {
+			return err
+		}
+		for _, e := range page.Entries {
+			if err = f(pageNum*uint64(s.root.PageSize)+uint64(e.Slot), e.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	refs, err := node.References()
+	if err != nil {
+		return err
+	}
+	for c, ref := range refs {
+		if s.isPlaceholder(ref) {
+			continue
+		}
+		if err = s.forEach(ref, level-1, pageNum*fanout+uint64(c), f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
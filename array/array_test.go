@@ -0,0 +1,146 @@
+package array
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, pageSize int) *Array {
+	c0 := th.CreateConnections(1)[0]
+	a, err := NewEmptyArray(c0.Connection, pageSize)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return a
+}
+
+func assertGet(th *tests.TestHelper, a *Array, idx uint64, expected string, expectedFound bool) {
+	value, found, err := a.Get(idx)
+	if err != nil {
+		th.Fatal(err)
+	} else if found != expectedFound || string(value) != expected {
+		th.Fatalf("Get of %v: expected %v (%v). Got %s (%v)", idx, expected, expectedFound, value, found)
+	}
+}
+
+// assertEntries checks Len, and the entries supplied by ForEach,
+// formatted as "idx:value".
+func assertEntries(th *tests.TestHelper, a *Array, length uint64, expected ...string) {
+	if got, err := a.Len(); err != nil {
+		th.Fatal(err)
+	} else if got != length {
+		th.Fatalf("Expected Len %v. Got %v", length, got)
+	}
+	var got []string
+	if err := a.ForEach(func(idx uint64, value []byte) error {
+		got = append(got, fmt.Sprintf("%v:%s", idx, value))
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("ForEach: expected %v. Got %v", expected, got)
+	}
+}
+
+// depth returns the depth of the tree of directories of a.
+func depth(th *tests.TestHelper, a *Array) (uint32, bool) {
+	res, _, err := a.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := a.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	s := res.(*state)
+	return s.root.Depth, s.top != nil
+}
+
+func TestSetGetAppend(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	a := createEmpty(th, 4)
+	assertEntries(th, a, 0)
+	assertGet(th, a, 0, "", false)
+	for i := 0; i < 6; i++ {
+		if idx, err := a.Append([]byte(fmt.Sprint("v", i))); err != nil {
+			th.Fatal(err)
+		} else if idx != uint64(i) {
+			th.Fatalf("Expected Append at %v. Got %v", i, idx)
+		}
+	}
+	assertEntries(th, a, 6, "0:v0", "1:v1", "2:v2", "3:v3", "4:v4", "5:v5")
+	if d, _ := depth(th, a); d != 1 {
+		th.Fatalf("Expected depth 1. Got %v", d)
+	}
+
+	// far beyond the end, sparsely, deepens the tree.
+	far := uint64(1) << 40
+	for _, idx := range []uint64{far, 1000, 2, 1 << 63} {
+		if err := a.Set(idx, []byte(fmt.Sprint("s", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err := a.Set(3, nil); err != nil {
+		th.Fatal(err)
+	}
+	assertGet(th, a, 2, "s2", true)
+	assertGet(th, a, 3, "", true)
+	assertGet(th, a, 999, "", false)
+	assertGet(th, a, 1000, "s1000", true)
+	assertGet(th, a, far, fmt.Sprint("s", far), true)
+	assertGet(th, a, far+1, "", false)
+	assertEntries(th, a, 1<<63+1, "0:v0", "1:v1", "2:s2", "3:", "4:v4", "5:v5", "1000:s1000",
+		fmt.Sprintf("%v:s%v", far, far), fmt.Sprintf("%v:s%v", uint64(1)<<63, uint64(1)<<63))
+	if d, _ := depth(th, a); d != 11 {
+		th.Fatalf("Expected depth 11. Got %v", d)
+	}
+	if _, err := a.Append(nil); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, a, 1<<63+2, "0:v0", "1:v1", "2:s2", "3:", "4:v4", "5:v5", "1000:s1000",
+		fmt.Sprintf("%v:s%v", far, far), fmt.Sprintf("%v:s%v", uint64(1)<<63, uint64(1)<<63),
+		fmt.Sprintf("%v:", uint64(1)<<63+1))
+}
+
+func TestUnset(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	a := createEmpty(th, 4)
+	for _, idx := range []uint64{1, 2, 100, 5000} {
+		if err := a.Set(idx, []byte(fmt.Sprint(idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		idx     uint64
+		removed bool
+	}{{1, true}, {1, false}, {3, false}, {99, false}, {6000, false}, {100, true}, {2, true}} {
+		if removed, err := a.Unset(test.idx); err != nil {
+			th.Fatal(err)
+		} else if removed != test.removed {
+			th.Fatalf("Unset of %v: expected %v. Got %v", test.idx, test.removed, removed)
+		}
+	}
+	assertEntries(th, a, 5001, "5000:5000")
+	if removed, err := a.Unset(5000); err != nil {
+		th.Fatal(err)
+	} else if !removed {
+		th.Fatal("Expected 5000 to be unset")
+	}
+	assertEntries(th, a, 5001)
+	if _, hasTop := depth(th, a); hasTop {
+		th.Fatal("Expected every page and directory to be dropped")
+	}
+	if err := a.Set(7, []byte("7")); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, a, 5001, "7:7")
+}
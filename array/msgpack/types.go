@@ -0,0 +1,29 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of an Array. Unless nothing
+// has been set, its only reference is to the top of a tree of
+// directories, Depth levels deep, above the pages, each holding the
+// entries for PageSize consecutive indices. Directories have no value:
+// their references are to their children, with the root Object in
+// place of any child without entries, and no trailing placeholders.
+// The top is a page if Depth is 0.
+type Root struct {
+	PageSize int64
+	// One more than the highest index ever set.
+	Len   uint64
+	Depth uint32
+}
+
+// Page is the value of a page Object.
+type Page struct {
+	// The entries set, in slot order.
+	Entries []Entry
+}
+
+type Entry struct {
+	// The index within the page.
+	Slot  uint32
+	Value []byte
+}
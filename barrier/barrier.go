@@ -0,0 +1,146 @@
+// A Barrier lets a fixed number of parties, possibly in different
+// processes, wait for each other: each party Arrives, and none
+// proceeds until all have arrived. The Barrier is then reset for the
+// next generation, so it may be used for each of a series of phases,
+// as a cyclic barrier. A party which only needs to signal its arrival,
+// without waiting for the others, can use Arrive alone, making the
+// Barrier a countdown latch.
+package barrier
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/barrier/msgpack"
+)
+
+type Barrier struct {
+	// The connection used to create this Barrier object. As with
+	// LHash, you should not use the same Barrier object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the state of the
+	// Barrier.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new Barrier for the given number of parties. This
+// creates a new GoshawkDB Object and initialises it for use as a
+// Barrier.
+func NewBarrier(conn *client.Connection, parties int) (*Barrier, error) {
+	if parties < 1 {
+		return nil, errors.New("A Barrier must have at least one party")
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Barrier{Parties: int64(parties)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Barrier{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Barrier), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Barrier object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func BarrierFromObj(conn *client.Connection, objRef client.ObjectRef) *Barrier {
+	return &Barrier{Conn: conn, ObjRef: objRef}
+}
+
+func (b *Barrier) read(txn *client.Txn) (client.ObjectRef, *mp.Barrier, error) {
+	obj, err := txn.GetObject(b.ObjRef)
+	if err != nil {
+		return obj, nil, err
+	}
+	value, err := obj.Value()
+	if err != nil {
+		return obj, nil, err
+	}
+	barrier := new(mp.Barrier)
+	if _, err = barrier.UnmarshalMsg(value); err != nil {
+		return obj, nil, err
+	} else if barrier.Parties < 1 || barrier.Arrived < 0 || barrier.Arrived >= barrier.Parties {
+		return obj, nil, fmt.Errorf("Barrier %v is corrupt", obj)
+	}
+	return obj, barrier, nil
+}
+
+// Returns the number of parties, the number of them which have arrived
+// in the current generation, and the number of generations completed.
+func (b *Barrier) Status() (parties, arrived int, generation uint64, err error) {
+	var barrier *mp.Barrier
+	_, _, err = b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, bar, err := b.read(txn)
+		barrier = bar
+		return nil, err
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return int(barrier.Parties), int(barrier.Arrived), barrier.Generation, nil
+}
+
+// Record the arrival of a party at the Barrier, without waiting for
+// the other parties. Returns the generation the party arrived in: the
+// number of generations completed before it. If this is the last
+// party of the generation to arrive, the generation is completed and
+// the Barrier is reset for the next.
+func (b *Barrier) Arrive() (uint64, error) {
+	res, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, barrier, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		generation := barrier.Generation
+		barrier.Arrived++
+		if barrier.Arrived == barrier.Parties {
+			barrier.Arrived = 0
+			barrier.Generation++
+		}
+		value, err := barrier.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return generation, obj.Set(value)
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Block until the given generation has been completed, i.e. until
+// every party has arrived in it. Blocking is achieved through a retry
+// transaction, so Await blocks the connection of the Barrier and must
+// not be called from within a transaction.
+func (b *Barrier) Await(generation uint64) error {
+	_, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, barrier, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		} else if barrier.Generation <= generation {
+			return client.Retry, nil
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Arrive at the Barrier and then Await the completion of the
+// generation arrived in, returning the generation.
+func (b *Barrier) ArriveAndAwait() (uint64, error) {
+	generation, err := b.Arrive()
+	if err != nil {
+		return 0, err
+	}
+	return generation, b.Await(generation)
+}
@@ -0,0 +1,87 @@
+package barrier
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCountdown(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conns := th.CreateConnections(2)
+	b, err := NewBarrier(conns[0].Connection, 2)
+	if err != nil {
+		th.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- BarrierFromObj(conns[1].Connection, b.ObjRef).Await(0)
+	}()
+	if generation, err := b.Arrive(); err != nil || generation != 0 {
+		th.Fatalf("Expected generation 0. Got %v %v", generation, err)
+	}
+	select {
+	case err := <-done:
+		th.Fatalf("Await returned %v before every party arrived", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if parties, arrived, generation, err := b.Status(); err != nil || parties != 2 || arrived != 1 || generation != 0 {
+		th.Fatalf("Expected 1 of 2 arrived in generation 0. Got %v %v %v %v", arrived, parties, generation, err)
+	}
+	if _, err = b.Arrive(); err != nil {
+		th.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		th.Fatal(err)
+	}
+	if _, arrived, generation, err := b.Status(); err != nil || arrived != 0 || generation != 1 {
+		th.Fatalf("Expected 0 arrived in generation 1. Got %v %v %v", arrived, generation, err)
+	}
+}
+
+func TestCyclic(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conns := th.CreateConnections(3)
+	b, err := NewBarrier(conns[0].Connection, len(conns))
+	if err != nil {
+		th.Fatal(err)
+	}
+	// every party must have finished each phase before any starts the
+	// next.
+	var lock sync.Mutex
+	finished := make([]int, 4)
+	errs := make(chan error, len(conns))
+	for _, conn := range conns {
+		go func(b *Barrier) {
+			for phase := range finished {
+				lock.Lock()
+				if phase > 0 && finished[phase-1] != len(conns) {
+					lock.Unlock()
+					errs <- fmt.Errorf("Phase %v started before phase %v finished", phase, phase-1)
+					return
+				}
+				finished[phase]++
+				lock.Unlock()
+				if generation, err := b.ArriveAndAwait(); err != nil {
+					errs <- err
+					return
+				} else if generation != uint64(phase) {
+					errs <- fmt.Errorf("Expected generation %v. Got %v", phase, generation)
+					return
+				}
+			}
+			errs <- nil
+		}(BarrierFromObj(conn.Connection, b.ObjRef))
+	}
+	for range conns {
+		if err := <-errs; err != nil {
+			th.Fatal(err)
+		}
+	}
+}
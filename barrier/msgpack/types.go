@@ -0,0 +1,13 @@
+package msgpack
+
+//go:generate msgp
+
+// Barrier is the value of a Barrier Object.
+type Barrier struct {
+	// The number of arrivals which complete each generation.
+	Parties int64
+	// The number of arrivals so far in the current generation.
+	Arrived int64
+	// The number of generations completed.
+	Generation uint64
+}
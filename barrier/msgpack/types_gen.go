@@ -0,0 +1,160 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Barrier) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Parties":
+			z.Parties, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Parties")
+				return
+			}
+		case "Arrived":
+			z.Arrived, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Arrived")
+				return
+			}
+		case "Generation":
+			z.Generation, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Generation")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Barrier) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Parties"
+	err = en.Append(0x83, 0xa7, 0x50, 0x61, 0x72, 0x74, 0x69, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Parties)
+	if err != nil {
+		err = msgp.WrapError(err, "Parties")
+		return
+	}
+	// write "Arrived"
+	err = en.Append(0xa7, 0x41, 0x72, 0x72, 0x69, 0x76, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Arrived)
+	if err != nil {
+		err = msgp.WrapError(err, "Arrived")
+		return
+	}
+	// write "Generation"
+	err = en.Append(0xaa, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Generation)
+	if err != nil {
+		err = msgp.WrapError(err, "Generation")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Barrier) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Parties"
+	o = append(o, 0x83, 0xa7, 0x50, 0x61, 0x72, 0x74, 0x69, 0x65, 0x73)
+	o = msgp.AppendInt64(o, z.Parties)
+	// string "Arrived"
+	o = append(o, 0xa7, 0x41, 0x72, 0x72, 0x69, 0x76, 0x65, 0x64)
+	o = msgp.AppendInt64(o, z.Arrived)
+	// string "Generation"
+	o = append(o, 0xaa, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendUint64(o, z.Generation)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Barrier) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Parties":
+			z.Parties, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Parties")
+				return
+			}
+		case "Arrived":
+			z.Arrived, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Arrived")
+				return
+			}
+		case "Generation":
+			z.Generation, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Generation")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Barrier) Msgsize() (s int) {
+	s = 1 + 8 + msgp.Int64Size + 8 + msgp.Int64Size + 11 + msgp.Uint64Size
+	return
+}
@@ -0,0 +1,358 @@
+// A Bitset is a set of non-negative integers, held as a bitmap split
+// into fixed size chunks, each of which is a separate Object. Setting
+// or clearing a bit rewrites only its chunk, so writers to different
+// chunks do not conflict. The root is only rewritten when a chunk is
+// created or dropped: chunks are only created to hold set bits, so a
+// Bitset is best suited to dense sets of small integers, such as
+// feature flags over a range of IDs.
+package bitset
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/bitset/msgpack"
+	"math/bits"
+)
+
+// The number of bits held by each chunk of a new Bitset, if no chunk
+// size is given.
+const DefaultChunkBits = 4096
+
+// ErrChunkBitsDiffer is returned by Or, And and AndNot if the two
+// Bitsets have different chunk sizes.
+var ErrChunkBitsDiffer = errors.New("Bitsets have different chunk sizes")
+
+type Bitset struct {
+	// The connection used to create this Bitset object. As with LHash,
+	// you should not use the same Bitset object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Bitset.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Bitset, each of whose chunks holds
+// chunkBits bits (DefaultChunkBits if chunkBits is not positive),
+// rounded up to a multiple of 64. This creates a new GoshawkDB Object
+// and initialises it for use as a Bitset.
+func NewEmptyBitset(conn *client.Connection, chunkBits int) (*Bitset, error) {
+	if chunkBits <= 0 {
+		chunkBits = DefaultChunkBits
+	}
+	chunkBits = (chunkBits + 63) &^ 63
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Root{ChunkBits: int64(chunkBits)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Bitset{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Bitset), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Bitset object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func BitsetFromObj(conn *client.Connection, objRef client.ObjectRef) *Bitset {
+	return &Bitset{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Bitset within a single transaction.
+type state struct {
+	txn    *client.Txn
+	objRef client.ObjectRef
+	root   *mp.Root
+	chunks []client.ObjectRef
+}
+
+func (b *Bitset) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(b.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.ChunkBits < 64 || root.ChunkBits%64 != 0 {
+		return nil, fmt.Errorf("Bitset root %v is corrupt", obj)
+	}
+	return &state{txn: txn, objRef: obj, root: root, chunks: refs}, nil
+}
+
+func (s *state) write() error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return s.objRef.Set(value, s.chunks...)
+}
+
+// locate returns the index of the chunk holding bit i, and the index
+// of bit i within the chunk.
+func (s *state) locate(i uint64) (int, uint64) {
+	return int(i / uint64(s.root.ChunkBits)), i % uint64(s.root.ChunkBits)
+}
+
+// chunk returns the words of the idx'th chunk, or nil if it does not
+// exist.
+func (s *state) chunk(idx int) ([]uint64, error) {
+	if idx >= len(s.chunks) || s.chunks[idx].ReferencesSameAs(s.objRef) {
+		return nil, nil
+	}
+	value, err := s.chunks[idx].Value()
+	if err != nil {
+		return nil, err
+	}
+	chunk := new(mp.Chunk)
+	if _, err = chunk.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if int64(len(chunk.Words))*64 != s.root.ChunkBits {
+		return nil, fmt.Errorf("Bitset chunk %v is corrupt", s.chunks[idx])
+	}
+	return chunk.Words, nil
+}
+
+// setChunk sets the words of the idx'th chunk, creating the chunk if
+// necessary, and dropping it if no bits are set.
+func (s *state) setChunk(idx int, words []uint64) error {
+	empty := true
+	for _, w := range words {
+		if w != 0 {
+			empty = false
+			break
+		}
+	}
+	exists := idx < len(s.chunks) && !s.chunks[idx].ReferencesSameAs(s.objRef)
+	switch {
+	case empty && !exists:
+		return nil
+	case empty:
+		s.chunks[idx] = s.objRef
+		for len(s.chunks) > 0 && s.chunks[len(s.chunks)-1].ReferencesSameAs(s.objRef) {
+			s.chunks = s.chunks[:len(s.chunks)-1]
+		}
+		return s.write()
+	}
+	value, err := (&mp.Chunk{Words: words}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return s.chunks[idx].Set(value)
+	}
+	chunkObj, err := s.txn.CreateObject(value)
+	if err != nil {
+		return err
+	}
+	for len(s.chunks) <= idx {
+		s.chunks = append(s.chunks, s.objRef)
+	}
+	s.chunks[idx] = chunkObj
+	return s.write()
+}
+
+// update applies f to the word holding bit i, and writes it back if it
+// changed, returning whether the bit was set beforehand.
+func (b *Bitset) update(i uint64, f func(word, mask uint64) uint64) (bool, error) {
+	res, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx, bit := s.locate(i)
+		words, err := s.chunk(idx)
+		if err != nil {
+			return nil, err
+		} else if words == nil {
+			words = make([]uint64, s.root.ChunkBits/64)
+		}
+		mask := uint64(1) << (bit % 64)
+		word := words[bit/64]
+		if updated := f(word, mask); updated != word {
+			words[bit/64] = updated
+			if err = s.setChunk(idx, words); err != nil {
+				return nil, err
+			}
+		}
+		return word&mask != 0, nil
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Set bit i, returning whether it was already set.
+func (b *Bitset) Set(i uint64) (bool, error) {
+	return b.update(i, func(word, mask uint64) uint64 { return word | mask })
+}
+
+// Clear bit i, returning whether it was set.
+func (b *Bitset) Clear(i uint64) (bool, error) {
+	return b.update(i, func(word, mask uint64) uint64 { return word &^ mask })
+}
+
+// Returns whether bit i is set.
+func (b *Bitset) Test(i uint64) (bool, error) {
+	res, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx, bit := s.locate(i)
+		words, err := s.chunk(idx)
+		if err != nil {
+			return nil, err
+		}
+		return words != nil && words[bit/64]&(uint64(1)<<(bit%64)) != 0, nil
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the number of bits set. Every chunk is read.
+func (b *Bitset) Count() (uint64, error) {
+	res, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		count := uint64(0)
+		for idx := range s.chunks {
+			words, err := s.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			for _, w := range words {
+				count += uint64(bits.OnesCount64(w))
+			}
+		}
+		return count, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Iterate over the bits set, in increasing order. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case bits may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (b *Bitset) ForEach(f func(i uint64) error) error {
+	_, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range s.chunks {
+			words, err := s.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			base := uint64(idx) * uint64(s.root.ChunkBits)
+			for wIdx, w := range words {
+				for w != 0 {
+					bit := uint64(bits.TrailingZeros64(w))
+					if err = f(base + uint64(wIdx)*64 + bit); err != nil {
+						return nil, err
+					}
+					w &= w - 1
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Set every bit which is set in other, so that b becomes the union of
+// the two. Both Bitsets must have the same chunk size. The whole
+// operation is a single transaction, reading every chunk of other.
+func (b *Bitset) Or(other *Bitset) error {
+	return b.combine(other, func(w, o uint64) uint64 { return w | o })
+}
+
+// Clear every bit which is not set in other, so that b becomes the
+// intersection of the two. Both Bitsets must have the same chunk size.
+// The whole operation is a single transaction.
+func (b *Bitset) And(other *Bitset) error {
+	return b.combine(other, func(w, o uint64) uint64 { return w & o })
+}
+
+// Clear every bit which is set in other, so that b becomes the
+// difference of the two. Both Bitsets must have the same chunk size.
+// The whole operation is a single transaction.
+func (b *Bitset) AndNot(other *Bitset) error {
+	return b.combine(other, func(w, o uint64) uint64 { return w &^ o })
+}
+
+// combine sets each word of b to f of it and the corresponding word of
+// other, rewriting only the chunks which change.
+func (b *Bitset) combine(other *Bitset, f func(w, o uint64) uint64) error {
+	_, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		o, err := other.read(txn)
+		if err != nil {
+			return nil, err
+		} else if s.root.ChunkBits != o.root.ChunkBits {
+			return nil, ErrChunkBitsDiffer
+		}
+		chunks := len(s.chunks)
+		if len(o.chunks) > chunks {
+			chunks = len(o.chunks)
+		}
+		wordCount := int(s.root.ChunkBits / 64)
+		for idx := 0; idx < chunks; idx++ {
+			words, err := s.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			otherWords, err := o.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			changed := false
+			result := make([]uint64, wordCount)
+			for wIdx := range result {
+				var w, ow uint64
+				if words != nil {
+					w = words[wIdx]
+				}
+				if otherWords != nil {
+					ow = otherWords[wIdx]
+				}
+				result[wIdx] = f(w, ow)
+				changed = changed || result[wIdx] != w
+			}
+			if changed {
+				if err = s.setChunk(idx, result); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
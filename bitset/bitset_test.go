@@ -0,0 +1,134 @@
+package bitset
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, chunkBits int) *Bitset {
+	c0 := th.CreateConnections(1)[0]
+	b, err := NewEmptyBitset(c0.Connection, chunkBits)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return b
+}
+
+func setAll(th *tests.TestHelper, b *Bitset, is ...uint64) {
+	for _, i := range is {
+		if _, err := b.Set(i); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// assertBits checks that exactly the given bits are set.
+func assertBits(th *tests.TestHelper, b *Bitset, expected ...uint64) {
+	var got []uint64
+	if err := b.ForEach(func(i uint64) error {
+		got = append(got, i)
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected bits %v. Got %v", expected, got)
+	}
+	if count, err := b.Count(); err != nil || count != uint64(len(expected)) {
+		th.Fatalf("Expected count %v. Got %v %v", len(expected), count, err)
+	}
+	for _, i := range expected {
+		if set, err := b.Test(i); err != nil || !set {
+			th.Fatalf("Expected bit %v to be set. Got %v %v", i, set, err)
+		}
+	}
+}
+
+func TestSetClearTest(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	b := createEmpty(th, 128)
+	assertBits(th, b)
+	setAll(th, b, 0, 63, 64, 127, 128, 1000)
+	if was, err := b.Set(64); err != nil || !was {
+		th.Fatalf("Expected bit 64 to have been set. Got %v %v", was, err)
+	}
+	assertBits(th, b, 0, 63, 64, 127, 128, 1000)
+	if set, err := b.Test(999); err != nil || set {
+		th.Fatalf("Expected bit 999 to be clear. Got %v %v", set, err)
+	}
+	if set, err := b.Test(100000); err != nil || set {
+		th.Fatalf("Expected bit 100000 to be clear. Got %v %v", set, err)
+	}
+	if was, err := b.Clear(63); err != nil || !was {
+		th.Fatalf("Expected bit 63 to have been set. Got %v %v", was, err)
+	}
+	if was, err := b.Clear(63); err != nil || was {
+		th.Fatalf("Expected bit 63 to have been clear. Got %v %v", was, err)
+	}
+	// clearing the only bit of the last chunk drops the chunk.
+	if _, err := b.Clear(1000); err != nil {
+		th.Fatal(err)
+	}
+	assertBits(th, b, 0, 64, 127, 128)
+	if chunks := chunkCount(th, b); chunks != 2 {
+		th.Fatalf("Expected 2 chunks. Got %v", chunks)
+	}
+}
+
+func chunkCount(th *tests.TestHelper, b *Bitset) int {
+	res, _, err := b.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := b.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return len(s.chunks), nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	return res.(int)
+}
+
+func TestCombine(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	create := func(is ...uint64) *Bitset {
+		b, err := NewEmptyBitset(conn, 64)
+		if err != nil {
+			th.Fatal(err)
+		}
+		setAll(th, b, is...)
+		return b
+	}
+
+	b := create(1, 2, 70, 200)
+	if err := b.Or(create(2, 3, 500)); err != nil {
+		th.Fatal(err)
+	}
+	assertBits(th, b, 1, 2, 3, 70, 200, 500)
+	if err := b.And(create(1, 3, 71, 500, 900)); err != nil {
+		th.Fatal(err)
+	}
+	assertBits(th, b, 1, 3, 500)
+	if err := b.AndNot(create(3, 500)); err != nil {
+		th.Fatal(err)
+	}
+	assertBits(th, b, 1)
+	if chunks := chunkCount(th, b); chunks != 1 {
+		th.Fatalf("Expected 1 chunk. Got %v", chunks)
+	}
+
+	other, err := NewEmptyBitset(conn, 128)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = b.Or(other); err != ErrChunkBitsDiffer {
+		th.Fatalf("Expected ErrChunkBitsDiffer. Got %v", err)
+	}
+}
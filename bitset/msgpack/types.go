@@ -0,0 +1,18 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Bitset. Its references
+// are to the chunks, in order: the nth chunk holds bits n*ChunkBits up
+// to (n+1)*ChunkBits. A chunk holding no set bits need not exist, in
+// which case its reference is to the root itself.
+type Root struct {
+	// The number of bits held by each chunk, which is a multiple of 64.
+	ChunkBits int64
+}
+
+// Chunk is the value of a chunk Object.
+type Chunk struct {
+	// Bit i of the chunk is bit i%64 of word i/64.
+	Words []uint64
+}
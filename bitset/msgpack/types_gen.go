@@ -0,0 +1,249 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Chunk) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Words":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Words")
+				return
+			}
+			if cap(z.Words) >= int(zb0002) {
+				z.Words = (z.Words)[:zb0002]
+			} else {
+				z.Words = make([]uint64, zb0002)
+			}
+			for za0001 := range z.Words {
+				z.Words[za0001], err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Words", za0001)
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Chunk) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Words"
+	err = en.Append(0x81, 0xa5, 0x57, 0x6f, 0x72, 0x64, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Words)))
+	if err != nil {
+		err = msgp.WrapError(err, "Words")
+		return
+	}
+	for za0001 := range z.Words {
+		err = en.WriteUint64(z.Words[za0001])
+		if err != nil {
+			err = msgp.WrapError(err, "Words", za0001)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Chunk) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Words"
+	o = append(o, 0x81, 0xa5, 0x57, 0x6f, 0x72, 0x64, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Words)))
+	for za0001 := range z.Words {
+		o = msgp.AppendUint64(o, z.Words[za0001])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Chunk) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Words":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Words")
+				return
+			}
+			if cap(z.Words) >= int(zb0002) {
+				z.Words = (z.Words)[:zb0002]
+			} else {
+				z.Words = make([]uint64, zb0002)
+			}
+			for za0001 := range z.Words {
+				z.Words[za0001], bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Words", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Chunk) Msgsize() (s int) {
+	s = 1 + 6 + msgp.ArrayHeaderSize + (len(z.Words) * (msgp.Uint64Size))
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunkBits":
+			z.ChunkBits, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkBits")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "ChunkBits"
+	err = en.Append(0x81, 0xa9, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x69, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.ChunkBits)
+	if err != nil {
+		err = msgp.WrapError(err, "ChunkBits")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "ChunkBits"
+	o = append(o, 0x81, 0xa9, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x69, 0x74, 0x73)
+	o = msgp.AppendInt64(o, z.ChunkBits)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunkBits":
+			z.ChunkBits, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkBits")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Root) Msgsize() (s int) {
+	s = 1 + 10 + msgp.Int64Size
+	return
+}
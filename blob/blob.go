@@ -0,0 +1,253 @@
+// A blob is a byte stream too large to hold comfortably in the value
+// of a single GoshawkDB Object. The stream is split into chunks, each
+// the value of its own chunk Object, and an index Object records the
+// length of the stream and references the chunk Objects in order. A
+// blob is written once, with a Writer, and can then be read any number
+// of times, sequentially or at random, with a Reader.
+//
+// A blob is identified by its index Object, which can be referenced
+// from other Objects like any other. Writing a blob within a
+// transaction creates all its Objects within that transaction.
+package blob
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/blob/msgpack"
+	"io"
+)
+
+// The chunk size used if none is given: 64KiB.
+const DefaultChunkSize = 64 * 1024
+
+// ErrClosed is returned by Write once the Writer has been closed.
+var ErrClosed = errors.New("Blob Writer is closed")
+
+// ErrCorrupt is returned by Open and by reads if the index Object
+// does not agree with the chunk Objects.
+var ErrCorrupt = errors.New("Blob index does not match its chunks")
+
+// A Writer creates a blob from the bytes written to it. Each chunk
+// Object is created as soon as it is full; the index Object is only
+// created by Close.
+type Writer struct {
+	// The index Object of the blob, set by Close.
+	ObjRef    client.ObjectRef
+	conn      *client.Connection
+	chunkSize int
+	buf       []byte
+	size      int64
+	chunks    []client.ObjectRef
+	closed    bool
+}
+
+// Create a Writer for a new blob with chunks of chunkSize bytes. If
+// chunkSize is not positive, DefaultChunkSize is used.
+func NewWriter(conn *client.Connection, chunkSize int) *Writer {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &Writer{
+		conn:      conn,
+		chunkSize: chunkSize,
+		buf:       make([]byte, 0, chunkSize),
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	written := 0
+	for len(p) > 0 {
+		n := w.chunkSize - len(w.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		if len(w.buf) == w.chunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// flush creates a chunk Object from the buffered bytes.
+func (w *Writer) flush() error {
+	res, _, err := w.conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject(append([]byte{}, w.buf...))
+	})
+	if err != nil {
+		return err
+	}
+	w.chunks = append(w.chunks, res.(client.ObjectRef))
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Create the last chunk Object, if needed, and the index Object,
+// which is then available as ObjRef. Closing a closed Writer does
+// nothing.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	value, err := (&mp.Header{Size: w.size, ChunkSize: int64(w.chunkSize)}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	res, _, err := w.conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject(value, w.chunks...)
+	})
+	if err != nil {
+		return err
+	}
+	w.ObjRef = res.(client.ObjectRef)
+	w.closed = true
+	w.buf = nil
+	return nil
+}
+
+// Create a blob holding value, returning its index Object.
+func Create(conn *client.Connection, value []byte, chunkSize int) (client.ObjectRef, error) {
+	w := NewWriter(conn, chunkSize)
+	if _, err := w.Write(value); err != nil {
+		return client.ObjectRef{}, err
+	}
+	if err := w.Close(); err != nil {
+		return client.ObjectRef{}, err
+	}
+	return w.ObjRef, nil
+}
+
+// A Reader reads a blob. It implements io.Reader, io.ReaderAt and
+// io.Seeker. Each read runs in its own transaction, unless made from
+// within one.
+type Reader struct {
+	// The index Object of the blob.
+	ObjRef    client.ObjectRef
+	conn      *client.Connection
+	size      int64
+	chunkSize int64
+	chunks    []client.ObjectRef
+	offset    int64
+}
+
+// Open the blob with the given index Object for reading.
+func Open(conn *client.Connection, objRef client.ObjectRef) (*Reader, error) {
+	r := &Reader{ObjRef: objRef, conn: conn}
+	_, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		header := new(mp.Header)
+		if _, err = header.UnmarshalMsg(value); err != nil {
+			return nil, err
+		}
+		if header.Size < 0 || header.ChunkSize <= 0 ||
+			int64(len(refs)) != (header.Size+header.ChunkSize-1)/header.ChunkSize {
+			return nil, ErrCorrupt
+		}
+		r.size, r.chunkSize, r.chunks = header.Size, header.ChunkSize, refs
+		return nil, nil
+	})
+	if err == nil {
+		return r, nil
+	} else {
+		return nil, err
+	}
+}
+
+// Read the whole of the blob with the given index Object.
+func ReadAll(conn *client.Connection, objRef client.ObjectRef) ([]byte, error) {
+	r, err := Open(conn, objRef)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, r.size)
+	if _, err = r.ReadAt(value, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Returns the length of the blob, in bytes.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt reads all the chunks it needs in a single transaction.
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("Negative offset")
+	} else if off >= r.size {
+		return 0, io.EOF
+	}
+	var err error
+	if remaining := r.size - off; int64(len(p)) > remaining {
+		p, err = p[:remaining], io.EOF
+	}
+	res, _, txnErr := r.conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		n := 0
+		for n < len(p) {
+			pos := off + int64(n)
+			chunkIdx := pos / r.chunkSize
+			obj, err := txn.GetObject(r.chunks[chunkIdx])
+			if err != nil {
+				return nil, err
+			}
+			chunk, err := obj.Value()
+			if err != nil {
+				return nil, err
+			}
+			start := pos - chunkIdx*r.chunkSize
+			if start >= int64(len(chunk)) {
+				return nil, ErrCorrupt
+			}
+			n += copy(p[n:], chunk[start:])
+		}
+		return n, nil
+	})
+	if txnErr != nil {
+		return 0, txnErr
+	}
+	return res.(int), err
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("Negative offset")
+	}
+	r.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,76 @@
+package blob
+
+import (
+	"bytes"
+	"goshawkdb.io/tests"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBlob(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+
+	w := NewWriter(conn, 256)
+	// write in pieces which do not line up with the chunks.
+	for rest := content; len(rest) > 0; {
+		n := 333
+		if n > len(rest) {
+			n = len(rest)
+		}
+		if _, err := w.Write(rest[:n]); err != nil {
+			th.Fatal(err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		th.Fatal(err)
+	}
+	if _, err := w.Write([]byte("more")); err != ErrClosed {
+		th.Fatalf("Expected ErrClosed. Got %v", err)
+	}
+
+	r, err := Open(conn, w.ObjRef)
+	if err != nil {
+		th.Fatal(err)
+	} else if r.Size() != int64(len(content)) {
+		th.Fatalf("Expected size %v. Got %v", len(content), r.Size())
+	}
+	if all, err := ioutil.ReadAll(r); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(all, content) {
+		th.Fatal("Read content differs")
+	}
+
+	part := make([]byte, 300)
+	if n, err := r.ReadAt(part, 250); err != nil || n != len(part) {
+		th.Fatalf("ReadAt read %v: %v", n, err)
+	} else if !bytes.Equal(part, content[250:550]) {
+		th.Fatal("ReadAt content differs")
+	}
+	if n, err := r.ReadAt(part, int64(len(content))-10); err != io.EOF || n != 10 {
+		th.Fatalf("Expected a short ReadAt with io.EOF. Got %v: %v", n, err)
+	}
+	if _, err := r.Seek(-5, io.SeekEnd); err != nil {
+		th.Fatal(err)
+	}
+	if tail, err := ioutil.ReadAll(r); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(tail, content[len(content)-5:]) {
+		th.Fatalf("Unexpected tail %s", tail)
+	}
+
+	empty, err := Create(conn, nil, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if value, err := ReadAll(conn, empty); err != nil {
+		th.Fatal(err)
+	} else if len(value) != 0 {
+		th.Fatalf("Expected empty blob. Got %v bytes", len(value))
+	}
+}
@@ -0,0 +1,14 @@
+package msgpack
+
+//go:generate msgp
+
+// Header is the value of the index Object of a blob. The references
+// of the index Object are the chunk Objects, in order, each of which
+// holds ChunkSize bytes of the blob, except the last, which holds the
+// remainder.
+type Header struct {
+	// The length of the blob, in bytes.
+	Size int64
+	// The length of every chunk but the last.
+	ChunkSize int64
+}
@@ -0,0 +1,217 @@
+// A Filter is a Bloom filter: a set of byte strings which may report
+// false positives but never false negatives. Checking a Filter is
+// cheap, so it is suited to avoiding expensive lookups of elements
+// which are not present. Elements cannot be removed.
+//
+// The size of the bit array and the number of bits set for each
+// element are fixed when the Filter is created, and recorded in its
+// root. The array is split into chunks, each the value of its own
+// Object, so adding an element rewrites only the chunks holding its
+// bits, and concurrent adds of different elements rarely conflict.
+package bloom
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	hash "github.com/dchest/siphash"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/bloom/msgpack"
+	"math"
+)
+
+// The number of bytes of each chunk of the bit array.
+const chunkBytes = 4096
+
+type Filter struct {
+	// The connection used to create this Filter object. As with LHash,
+	// you should not use the same Filter object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Filter.
+	ObjRef client.ObjectRef
+}
+
+// Returns the number of bits and hashes for a Filter which will hold
+// about n elements with a false positive rate of about p.
+func OptimalParameters(n int, p float64) (bits uint64, hashes int) {
+	if n < 1 {
+		n = 1
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), int(k)
+}
+
+// Create a brand new empty Filter of the given number of bits, each
+// element of which sets the given number of bits. See
+// OptimalParameters for choosing them. This creates new GoshawkDB
+// Objects and initialises them for use as a Filter.
+func NewEmptyFilter(conn *client.Connection, bits uint64, hashes int) (*Filter, error) {
+	if bits < 1 || hashes < 1 {
+		return nil, errors.New("A Filter must have at least one bit and one hash")
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		size := (bits + 7) / 8
+		chunks := make([]client.ObjectRef, 0, (size+chunkBytes-1)/chunkBytes)
+		for offset := uint64(0); offset < size; offset += chunkBytes {
+			n := size - offset
+			if n > chunkBytes {
+				n = chunkBytes
+			}
+			chunkObj, err := txn.CreateObject(make([]byte, n))
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, chunkObj)
+		}
+		root := &mp.Root{Bits: bits, Hashes: int64(hashes), ChunkBytes: chunkBytes, HashKey: key}
+		value, err := root.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, chunks...)
+		if err != nil {
+			return nil, err
+		}
+		return &Filter{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Filter), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Filter object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func FilterFromObj(conn *client.Connection, objRef client.ObjectRef) *Filter {
+	return &Filter{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Filter within a single transaction.
+type state struct {
+	root   *mp.Root
+	chunks []client.ObjectRef
+	k0     uint64
+	k1     uint64
+}
+
+func (f *Filter) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(f.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Bits < 1 || root.Hashes < 1 || root.ChunkBytes < 1 || len(root.HashKey) != 16 ||
+		uint64(len(refs)) != ((root.Bits+7)/8+uint64(root.ChunkBytes)-1)/uint64(root.ChunkBytes) {
+		return nil, fmt.Errorf("Filter root %v is corrupt", obj)
+	}
+	return &state{
+		root:   root,
+		chunks: refs,
+		k0:     binary.LittleEndian.Uint64(root.HashKey[0:8]),
+		k1:     binary.LittleEndian.Uint64(root.HashKey[8:16]),
+	}, nil
+}
+
+// bits calls f with the chunk, byte within the chunk, and mask of each
+// of the bits for element, stopping if f returns false.
+func (s *state) bits(element []byte, f func(chunk, byteIdx int, mask byte) bool) {
+	h1, h2 := hash.Hash128(s.k0, s.k1, element)
+	h2 |= 1
+	for i := uint64(0); i < uint64(s.root.Hashes); i++ {
+		bit := (h1 + i*h2) % s.root.Bits
+		byteIdx := bit / 8
+		if !f(int(byteIdx/uint64(s.root.ChunkBytes)), int(byteIdx%uint64(s.root.ChunkBytes)), 1<<(bit%8)) {
+			return
+		}
+	}
+}
+
+// Add element to the Filter.
+func (f *Filter) Add(element []byte) error {
+	_, _, err := f.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := f.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		// several of the bits may be in the same chunk, so gather the
+		// changes to each chunk before writing it.
+		changed := make(map[int][]byte)
+		s.bits(element, func(chunk, byteIdx int, mask byte) bool {
+			value, found := changed[chunk]
+			if !found {
+				if value, err = s.chunks[chunk].Value(); err != nil {
+					return false
+				} else if byteIdx >= len(value) {
+					err = fmt.Errorf("Filter chunk %v is corrupt", s.chunks[chunk])
+					return false
+				}
+				value = append([]byte(nil), value...)
+			}
+			if value[byteIdx]&mask == 0 {
+				value[byteIdx] |= mask
+				changed[chunk] = value
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		for chunk, value := range changed {
+			if err = s.chunks[chunk].Set(value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns false if element has definitely not been added to the
+// Filter, and true if it may have been.
+func (f *Filter) MayContain(element []byte) (bool, error) {
+	res, _, err := f.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := f.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		found := true
+		s.bits(element, func(chunk, byteIdx int, mask byte) bool {
+			var value []byte
+			if value, err = s.chunks[chunk].Value(); err != nil {
+				return false
+			} else if byteIdx >= len(value) {
+				err = fmt.Errorf("Filter chunk %v is corrupt", s.chunks[chunk])
+				return false
+			}
+			found = value[byteIdx]&mask != 0
+			return found
+		})
+		if err != nil {
+			return nil, err
+		}
+		return found, nil
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
@@ -0,0 +1,52 @@
+package bloom
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func TestOptimalParameters(t *testing.T) {
+	if bits, hashes := OptimalParameters(1000, 0.01); bits != 9586 || hashes != 7 {
+		t.Fatalf("Expected 9586 bits and 7 hashes. Got %v %v", bits, hashes)
+	}
+}
+
+func TestAddMayContain(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	// more than one chunk.
+	bits, hashes := OptimalParameters(5000, 0.01)
+	f, err := NewEmptyFilter(c0.Connection, bits, hashes)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if found, err := f.MayContain([]byte("a")); err != nil || found {
+		th.Fatalf("Expected an empty Filter to contain nothing. Got %v %v", found, err)
+	}
+	for idx := 0; idx < 5000; idx++ {
+		if err = f.Add([]byte(fmt.Sprintf("present%v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for idx := 0; idx < 5000; idx++ {
+		if found, err := f.MayContain([]byte(fmt.Sprintf("present%v", idx))); err != nil || !found {
+			th.Fatalf("Expected present%v to be found. Got %v %v", idx, found, err)
+		}
+	}
+	falsePositives := 0
+	for idx := 0; idx < 5000; idx++ {
+		found, err := f.MayContain([]byte(fmt.Sprintf("absent%v", idx)))
+		if err != nil {
+			th.Fatal(err)
+		} else if found {
+			falsePositives++
+		}
+	}
+	// 1% expected; allow plenty of slack.
+	if falsePositives > 150 {
+		th.Fatalf("Expected about 50 false positives. Got %v", falsePositives)
+	}
+}
@@ -0,0 +1,18 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Filter. Its references are
+// to the chunks of the bit array, in order. The value of each chunk
+// Object is the raw bytes of its part of the array: bit i of the array
+// is bit i%8 of byte (i/8)%ChunkBytes of chunk (i/8)/ChunkBytes.
+type Root struct {
+	// The number of bits in the array.
+	Bits uint64
+	// The number of bits set for each element.
+	Hashes int64
+	// The number of bytes of each chunk; the last may be shorter.
+	ChunkBytes int64
+	// The 16 byte siphash key.
+	HashKey []byte
+}
@@ -0,0 +1,185 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Bits":
+			z.Bits, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Bits")
+				return
+			}
+		case "Hashes":
+			z.Hashes, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Hashes")
+				return
+			}
+		case "ChunkBytes":
+			z.ChunkBytes, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkBytes")
+				return
+			}
+		case "HashKey":
+			z.HashKey, err = dc.ReadBytes(z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "Bits"
+	err = en.Append(0x84, 0xa4, 0x42, 0x69, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Bits)
+	if err != nil {
+		err = msgp.WrapError(err, "Bits")
+		return
+	}
+	// write "Hashes"
+	err = en.Append(0xa6, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Hashes)
+	if err != nil {
+		err = msgp.WrapError(err, "Hashes")
+		return
+	}
+	// write "ChunkBytes"
+	err = en.Append(0xaa, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.ChunkBytes)
+	if err != nil {
+		err = msgp.WrapError(err, "ChunkBytes")
+		return
+	}
+	// write "HashKey"
+	err = en.Append(0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.HashKey)
+	if err != nil {
+		err = msgp.WrapError(err, "HashKey")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "Bits"
+	o = append(o, 0x84, 0xa4, 0x42, 0x69, 0x74, 0x73)
+	o = msgp.AppendUint64(o, z.Bits)
+	// string "Hashes"
+	o = append(o, 0xa6, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73)
+	o = msgp.AppendInt64(o, z.Hashes)
+	// string "ChunkBytes"
+	o = append(o, 0xaa, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x42, 0x79, 0x74, 0x65, 0x73)
+	o = msgp.AppendInt64(o, z.ChunkBytes)
+	// string "HashKey"
+	o = append(o, 0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.HashKey)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Bits":
+			z.Bits, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Bits")
+				return
+			}
+		case "Hashes":
+			z.Hashes, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Hashes")
+				return
+			}
+		case "ChunkBytes":
+			z.ChunkBytes, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkBytes")
+				return
+			}
+		case "HashKey":
+			z.HashKey, bts, err = msgp.ReadBytesBytes(bts, z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Root) Msgsize() (s int) {
+	s = 1 + 5 + msgp.Uint64Size + 7 + msgp.Int64Size + 11 + msgp.Int64Size + 8 + msgp.BytesPrefixSize + len(z.HashKey)
+	return
+}
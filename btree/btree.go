@@ -0,0 +1,560 @@
+// A BTree is an ordered map from keys to GoshawkDB Objects, with keys
+// ordered by bytes.Compare. It is a B+tree: the entries are held in
+// leaf nodes, and interior nodes hold only the keys which separate
+// their children, each node being its own GoshawkDB Object. Every
+// operation runs in a single transaction, and reads and writes only
+// the nodes on the path from the top of the tree to the leaf holding
+// the key (and their siblings when nodes are split or merged).
+//
+// The API mirrors that of LHash, with the addition of ordered
+// iteration, range scans and order statistics (see Rank and Select).
+// A BTree is an ordered.OrderedMap, as is a SkipList.
+package btree
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/btree/msgpack"
+	"sort"
+)
+
+// defaultOrder is the Order of new BTrees. It is a variable so that
+// tests can exercise splitting and merging with small trees.
+var defaultOrder int64 = 64
+
+type BTree struct {
+	// The connection used to create this BTree object. As with LHash,
+	// you should not use the same BTree object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the BTree.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty BTree. This creates a new GoshawkDB Object
+// and initialises it for use as a BTree.
+func NewEmptyBTree(conn *client.Connection) (*BTree, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		top, err := createNode(txn, &node{leaf: true})
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{Order: defaultOrder}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, top.objRef)
+		if err != nil {
+			return nil, err
+		}
+		return &BTree{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*BTree), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a BTree object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func BTreeFromObj(conn *client.Connection, objRef client.ObjectRef) *BTree {
+	return &BTree{Conn: conn, ObjRef: objRef}
+}
+
+// tree is the state of the BTree within a single transaction.
+type tree struct {
+	txn    *client.Txn
+	objRef client.ObjectRef
+	root   *mp.Root
+	top    *node
+}
+
+func (t *BTree) read(txn *client.Txn) (*tree, error) {
+	obj, err := txn.GetObject(t.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 || root.Order < 4 {
+		return nil, fmt.Errorf("BTree root %v is corrupt", obj)
+	}
+	top, err := readNode(refs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &tree{txn: txn, objRef: obj, root: root, top: top}, nil
+}
+
+func (tr *tree) write() error {
+	value, err := tr.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return tr.objRef.Set(value, tr.top.objRef)
+}
+
+func (tr *tree) order() int {
+	return int(tr.root.Order)
+}
+
+// Returns the number of entries in the BTree.
+func (t *BTree) Size() (int64, error) {
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return tr.root.Size, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Search the BTree for the given key, returning the value Object if
+// found, or nil if not.
+func (t *BTree) Find(key []byte) (*client.ObjectRef, error) {
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		n := tr.top
+		for !n.leaf {
+			if n, err = readNode(n.refs[n.childIndex(key)]); err != nil {
+				return nil, err
+			}
+		}
+		if idx, found := n.search(key); found {
+			return &n.refs[idx], nil
+		}
+		return (*client.ObjectRef)(nil), nil
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Idempotently add the given key and value to the BTree. If the key is
+// already present, its value is updated. If the key already maps to
+// the same Object, nothing is written.
+func (t *BTree) Put(key []byte, value client.ObjectRef) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		s, added, err := tr.insert(tr.top, key, value)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			// the top has split, so the tree grows a level.
+			top, err := createNode(txn, &node{
+				keys:   [][]byte{s.key},
+				refs:   []client.ObjectRef{tr.top.objRef, s.right},
+				counts: []int64{tr.top.count(), s.count},
+			})
+			if err != nil {
+				return nil, err
+			}
+			tr.top = top
+		}
+		if added {
+			tr.root.Size++
+		}
+		if s != nil || added {
+			return nil, tr.write()
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Idempotently remove any entry for the given key.
+func (t *BTree) Remove(key []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		removed, err := tr.remove(tr.top, key)
+		if err != nil || !removed {
+			return nil, err
+		}
+		tr.root.Size--
+		if !tr.top.leaf && len(tr.top.refs) == 1 {
+			// the top has a single child, so the tree shrinks a level.
+			if tr.top, err = readNode(tr.top.refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, tr.write()
+	})
+	return err
+}
+
+// Iterate over the entries in the BTree, in key order. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case entries may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (t *BTree) ForEach(f func(key []byte, value client.ObjectRef) error) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return nil, forEach(tr.top, f)
+	})
+	return err
+}
+
+func forEach(n *node, f func(key []byte, value client.ObjectRef) error) error {
+	if n.leaf {
+		for idx, key := range n.keys {
+			if err := f(key, n.refs[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, childRef := range n.refs {
+		child, err := readNode(childRef)
+		if err != nil {
+			return err
+		}
+		if err = forEach(child, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A node is a leaf or interior node. The refs of a leaf are its
+// values, and of an interior node its children, with counts holding
+// the number of entries under each child.
+type node struct {
+	objRef client.ObjectRef
+	leaf   bool
+	keys   [][]byte
+	refs   []client.ObjectRef
+	counts []int64
+}
+
+func readNode(objRef client.ObjectRef) (*node, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	decoded := new(mp.Node)
+	if _, err = decoded.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	n := &node{objRef: objRef, leaf: decoded.Leaf, keys: decoded.Keys, refs: refs, counts: decoded.Counts}
+	if expected := n.size(); len(refs) != expected {
+		return nil, fmt.Errorf("BTree node %v is corrupt: %v keys but %v references", objRef, len(n.keys), len(refs))
+	} else if !n.leaf && len(n.counts) != expected {
+		return nil, fmt.Errorf("BTree node %v is corrupt: %v children but %v counts", objRef, expected, len(n.counts))
+	}
+	return n, nil
+}
+
+func createNode(txn *client.Txn, n *node) (*node, error) {
+	value, err := n.encode().MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	if n.objRef, err = txn.CreateObject(value, n.refs...); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *node) encode() *mp.Node {
+	return &mp.Node{Leaf: n.leaf, Keys: n.keys, Counts: n.counts}
+}
+
+func (n *node) write() error {
+	value, err := n.encode().MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return n.objRef.Set(value, n.refs...)
+}
+
+// size returns the number of references of the node: its entries, or
+// its children.
+func (n *node) size() int {
+	if n.leaf {
+		return len(n.keys)
+	}
+	return len(n.keys) + 1
+}
+
+// count returns the number of entries under the node.
+func (n *node) count() int64 {
+	if n.leaf {
+		return int64(len(n.keys))
+	}
+	total := int64(0)
+	for _, c := range n.counts {
+		total += c
+	}
+	return total
+}
+
+// search returns the index of the first key of a leaf which is not
+// less than key, and whether it is equal to key.
+func (n *node) search(key []byte) (int, bool) {
+	idx := sort.Search(len(n.keys), func(i int) bool { return bytes.Compare(n.keys[i], key) >= 0 })
+	return idx, idx < len(n.keys) && bytes.Equal(n.keys[idx], key)
+}
+
+// childIndex returns the index of the child of an interior node which
+// would hold key.
+func (n *node) childIndex(key []byte) int {
+	return sort.Search(len(n.keys), func(i int) bool { return bytes.Compare(n.keys[i], key) > 0 })
+}
+
+// A split is the result of splitting a node: the new node to its
+// right, the lowest key within it, and the number of entries under it.
+type split struct {
+	key   []byte
+	right client.ObjectRef
+	count int64
+}
+
+// insert puts key and value in the subtree under n, returning the
+// split of n if it had to be split, and whether the key was added
+// rather than updated.
+func (tr *tree) insert(n *node, key []byte, value client.ObjectRef) (*split, bool, error) {
+	if n.leaf {
+		idx, found := n.search(key)
+		if found {
+			if n.refs[idx].ReferencesSameAs(value) {
+				return nil, false, nil
+			}
+			n.refs[idx] = value
+			return nil, false, n.write()
+		}
+		n.keys = insertKey(n.keys, idx, key)
+		n.refs = insertRef(n.refs, idx, value)
+		s, err := tr.splitIfFull(n)
+		return s, true, err
+	}
+
+	idx := n.childIndex(key)
+	child, err := readNode(n.refs[idx])
+	if err != nil {
+		return nil, false, err
+	}
+	s, added, err := tr.insert(child, key, value)
+	if err != nil || (s == nil && !added) {
+		return nil, added, err
+	}
+	if added {
+		n.counts[idx]++
+	}
+	if s != nil {
+		n.keys = insertKey(n.keys, idx, s.key)
+		n.refs = insertRef(n.refs, idx+1, s.right)
+		n.counts = insertCount(n.counts, idx+1, s.count)
+		n.counts[idx] -= s.count
+	}
+	s, err = tr.splitIfFull(n)
+	return s, added, err
+}
+
+// splitIfFull writes n, first splitting it in two if it has more than
+// Order references.
+func (tr *tree) splitIfFull(n *node) (*split, error) {
+	if n.size() <= tr.order() {
+		return nil, n.write()
+	}
+	right := &node{leaf: n.leaf}
+	var key []byte
+	if n.leaf {
+		mid := len(n.keys) / 2
+		right.keys = append([][]byte{}, n.keys[mid:]...)
+		right.refs = append([]client.ObjectRef{}, n.refs[mid:]...)
+		n.keys, n.refs = n.keys[:mid], n.refs[:mid]
+		key = right.keys[0]
+	} else {
+		// the middle key moves up to the parent.
+		mid := len(n.keys) / 2
+		key = n.keys[mid]
+		right.keys = append([][]byte{}, n.keys[mid+1:]...)
+		right.refs = append([]client.ObjectRef{}, n.refs[mid+1:]...)
+		right.counts = append([]int64{}, n.counts[mid+1:]...)
+		n.keys, n.refs, n.counts = n.keys[:mid], n.refs[:mid+1], n.counts[:mid+1]
+	}
+	if _, err := createNode(tr.txn, right); err != nil {
+		return nil, err
+	}
+	return &split{key: key, right: right.objRef, count: right.count()}, n.write()
+}
+
+// remove removes key from the subtree under n, returning whether it
+// was found. Children left with too few references are rebalanced,
+// but n itself is left for its parent to rebalance.
+func (tr *tree) remove(n *node, key []byte) (bool, error) {
+	if n.leaf {
+		idx, found := n.search(key)
+		if !found {
+			return false, nil
+		}
+		n.keys = deleteKey(n.keys, idx)
+		n.refs = deleteRef(n.refs, idx)
+		return true, n.write()
+	}
+	idx := n.childIndex(key)
+	child, err := readNode(n.refs[idx])
+	if err != nil {
+		return false, err
+	}
+	removed, err := tr.remove(child, key)
+	if err != nil || !removed {
+		return removed, err
+	}
+	n.counts[idx]--
+	if child.size() >= tr.order()/2 {
+		return true, n.write()
+	}
+	return true, tr.rebalance(n, idx, child)
+}
+
+// rebalance restores the minimum size of the idx'th child of n, by
+// moving a reference from a sibling if one can spare it, or else by
+// merging the child with a sibling.
+func (tr *tree) rebalance(n *node, idx int, child *node) error {
+	minSize := tr.order() / 2
+	var left, right *node
+	var err error
+	if idx > 0 {
+		if left, err = readNode(n.refs[idx-1]); err != nil {
+			return err
+		} else if left.size() > minSize {
+			last := len(left.refs) - 1
+			if child.leaf {
+				child.keys = insertKey(child.keys, 0, left.keys[last])
+				n.keys[idx-1] = child.keys[0]
+			} else {
+				child.keys = insertKey(child.keys, 0, n.keys[idx-1])
+				n.keys[idx-1] = left.keys[len(left.keys)-1]
+			}
+			child.refs = insertRef(child.refs, 0, left.refs[last])
+			moved := int64(1)
+			if !child.leaf {
+				moved = left.counts[last]
+				child.counts = insertCount(child.counts, 0, moved)
+				left.counts = left.counts[:last]
+			}
+			n.counts[idx-1] -= moved
+			n.counts[idx] += moved
+			left.keys = left.keys[:len(left.keys)-1]
+			left.refs = left.refs[:last]
+			return writeAll(left, child, n)
+		}
+	}
+	if idx+1 < len(n.refs) {
+		if right, err = readNode(n.refs[idx+1]); err != nil {
+			return err
+		} else if right.size() > minSize {
+			if child.leaf {
+				child.keys = append(child.keys, right.keys[0])
+				n.keys[idx] = right.keys[1]
+			} else {
+				child.keys = append(child.keys, n.keys[idx])
+				n.keys[idx] = right.keys[0]
+			}
+			child.refs = append(child.refs, right.refs[0])
+			moved := int64(1)
+			if !child.leaf {
+				moved = right.counts[0]
+				child.counts = append(child.counts, moved)
+				right.counts = deleteCount(right.counts, 0)
+			}
+			n.counts[idx+1] -= moved
+			n.counts[idx] += moved
+			right.keys = deleteKey(right.keys, 0)
+			right.refs = deleteRef(right.refs, 0)
+			return writeAll(right, child, n)
+		}
+	}
+	if left != nil {
+		merge(n, idx-1, left, child)
+		return writeAll(left, n)
+	}
+	merge(n, idx, child, right)
+	return writeAll(child, n)
+}
+
+// merge moves everything from right into left, its sibling to the
+// left, and removes right from n, their parent.
+func merge(n *node, leftIdx int, left, right *node) {
+	if !left.leaf {
+		left.keys = append(left.keys, n.keys[leftIdx])
+		left.counts = append(left.counts, right.counts...)
+	}
+	left.keys = append(left.keys, right.keys...)
+	left.refs = append(left.refs, right.refs...)
+	n.counts[leftIdx] += n.counts[leftIdx+1]
+	n.keys = deleteKey(n.keys, leftIdx)
+	n.refs = deleteRef(n.refs, leftIdx+1)
+	n.counts = deleteCount(n.counts, leftIdx+1)
+}
+
+func writeAll(nodes ...*node) error {
+	for _, n := range nodes {
+		if err := n.write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertKey(keys [][]byte, idx int, key []byte) [][]byte {
+	keys = append(keys, nil)
+	copy(keys[idx+1:], keys[idx:])
+	keys[idx] = key
+	return keys
+}
+
+func insertRef(refs []client.ObjectRef, idx int, ref client.ObjectRef) []client.ObjectRef {
+	refs = append(refs, client.ObjectRef{})
+	copy(refs[idx+1:], refs[idx:])
+	refs[idx] = ref
+	return refs
+}
+
+func deleteKey(keys [][]byte, idx int) [][]byte {
+	return append(keys[:idx], keys[idx+1:]...)
+}
+
+func deleteRef(refs []client.ObjectRef, idx int) []client.ObjectRef {
+	return append(refs[:idx], refs[idx+1:]...)
+}
+
+func insertCount(counts []int64, idx int, count int64) []int64 {
+	counts = append(counts, 0)
+	copy(counts[idx+1:], counts[idx:])
+	counts[idx] = count
+	return counts
+}
+
+func deleteCount(counts []int64, idx int) []int64 {
+	return append(counts[:idx], counts[idx+1:]...)
+}
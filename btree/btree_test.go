@@ -0,0 +1,258 @@
+package btree
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/ordered"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+var _ ordered.OrderedMap = (*BTree)(nil)
+
+func createEmpty(th *tests.TestHelper) *BTree {
+	c0 := th.CreateConnections(1)[0]
+	t, err := NewEmptyBTree(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return t
+}
+
+func assertSize(th *tests.TestHelper, t *BTree, expected int64) {
+	size, err := t.Size()
+	if err != nil {
+		th.Fatal(err)
+	} else if size != expected {
+		th.Fatalf("Expected size %v. Got %v", expected, size)
+	}
+}
+
+// assertKeys checks that ForEach supplies exactly the given keys, in
+// order.
+func assertKeys(th *tests.TestHelper, t *BTree, expected map[int]bool) {
+	var last []byte
+	count := 0
+	if err := t.ForEach(func(key []byte, value client.ObjectRef) error {
+		if last != nil && bytes.Compare(last, key) >= 0 {
+			return fmt.Errorf("Key %s follows %s", key, last)
+		}
+		var idx int
+		if _, err := fmt.Sscanf(string(key), "key%04d", &idx); err != nil || !expected[idx] {
+			return fmt.Errorf("Unexpected key %s", key)
+		}
+		last = key
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != len(expected) {
+		th.Fatalf("Expected %v keys. Got %v", len(expected), count)
+	}
+}
+
+func TestPutFindRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	// a small order exercises splitting and merging.
+	defer func(order int64) { defaultOrder = order }(defaultOrder)
+	defaultOrder = 4
+	bt := createEmpty(th)
+
+	rng := rand.New(rand.NewSource(0))
+	present := make(map[int]bool)
+	values := make(map[int]client.ObjectRef)
+	for _, idx := range rng.Perm(300) {
+		res, _, err := bt.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			return txn.CreateObject([]byte(fmt.Sprintf("value%v", idx)))
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+		values[idx] = res.(client.ObjectRef)
+		if err = bt.Put([]byte(fmt.Sprintf("key%04d", idx)), values[idx]); err != nil {
+			th.Fatal(err)
+		}
+		present[idx] = true
+	}
+	assertSize(th, bt, 300)
+	assertKeys(th, bt, present)
+
+	for _, idx := range []int{0, 150, 299} {
+		if value, err := bt.Find([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil || !value.ReferencesSameAs(values[idx]) {
+			th.Fatalf("Expected to find value of key %v. Got %v", idx, value)
+		}
+	}
+	if value, err := bt.Find([]byte("missing")); err != nil || value != nil {
+		th.Fatalf("Expected not to find missing key. Got %v %v", value, err)
+	}
+
+	// putting the same value again changes nothing.
+	if err := bt.Put([]byte("key0000"), values[0]); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, bt, 300)
+
+	for _, idx := range rng.Perm(300)[:250] {
+		if err := bt.Remove([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		}
+		delete(present, idx)
+	}
+	if err := bt.Remove([]byte("missing")); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, bt, 50)
+	assertKeys(th, bt, present)
+
+	for idx := range present {
+		if err := bt.Remove([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, bt, 0)
+	assertKeys(th, bt, nil)
+}
+
+func TestRange(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	defer func(order int64) { defaultOrder = order }(defaultOrder)
+	defaultOrder = 4
+	bt := createEmpty(th)
+	// the keys are the even numbers below 100.
+	for idx := 0; idx < 100; idx += 2 {
+		if err := bt.Put([]byte(fmt.Sprintf("key%04d", idx)), bt.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	key := func(idx int) []byte { return []byte(fmt.Sprintf("key%04d", idx)) }
+	check := func(from, to ordered.Bound, reverse bool, expected ...int) {
+		var got []int
+		if err := bt.Range(from, to, reverse, func(key []byte, value client.ObjectRef) error {
+			var idx int
+			if _, err := fmt.Sscanf(string(key), "key%04d", &idx); err != nil {
+				return err
+			}
+			got = append(got, idx)
+			return nil
+		}); err != nil {
+			th.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			th.Fatalf("Range(%q, %q, %v): expected %v. Got %v", from.Key, to.Key, reverse, expected, got)
+		}
+	}
+
+	check(ordered.Inclusive(key(10)), ordered.Inclusive(key(20)), false, 10, 12, 14, 16, 18, 20)
+	check(ordered.Exclusive(key(10)), ordered.Exclusive(key(20)), false, 12, 14, 16, 18)
+	check(ordered.Inclusive(key(9)), ordered.Inclusive(key(13)), false, 10, 12)
+	check(ordered.Inclusive(key(10)), ordered.Exclusive(key(20)), true, 18, 16, 14, 12, 10)
+	check(ordered.Bound{}, ordered.Exclusive(key(6)), false, 0, 2, 4)
+	check(ordered.Exclusive(key(92)), ordered.Bound{}, true, 98, 96, 94)
+	check(ordered.Inclusive(key(20)), ordered.Inclusive(key(10)), false)
+	check(ordered.Exclusive(key(10)), ordered.Exclusive(key(12)), false)
+
+	var all []int
+	for idx := 98; idx >= 0; idx -= 2 {
+		all = append(all, idx)
+	}
+	check(ordered.Bound{}, ordered.Bound{}, true, all...)
+}
+
+func TestFirstLastFloorCeiling(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	defer func(order int64) { defaultOrder = order }(defaultOrder)
+	defaultOrder = 4
+	bt := createEmpty(th)
+	if key, value, err := bt.First(); err != nil || key != nil || value != nil {
+		th.Fatalf("Expected no first entry of an empty BTree. Got %q %v %v", key, value, err)
+	}
+	// the keys are the multiples of 10 from 10 to 500.
+	for idx := 10; idx <= 500; idx += 10 {
+		if err := bt.Put([]byte(fmt.Sprintf("key%04d", idx)), bt.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	check := func(name string, query func() ([]byte, *client.ObjectRef, error), expected string) {
+		key, value, err := query()
+		if err != nil {
+			th.Fatal(err)
+		} else if string(key) != expected || (key == nil) != (value == nil) {
+			th.Fatalf("%v: expected %q. Got %q %v", name, expected, key, value)
+		}
+	}
+	check("First", bt.First, "key0010")
+	check("Last", bt.Last, "key0500")
+	floor := func(key string) func() ([]byte, *client.ObjectRef, error) {
+		return func() ([]byte, *client.ObjectRef, error) { return bt.Floor([]byte(key)) }
+	}
+	ceiling := func(key string) func() ([]byte, *client.ObjectRef, error) {
+		return func() ([]byte, *client.ObjectRef, error) { return bt.Ceiling([]byte(key)) }
+	}
+	check("Floor exact", floor("key0250"), "key0250")
+	check("Floor between", floor("key0255"), "key0250")
+	check("Floor below first", floor("key0005"), "")
+	check("Floor above last", floor("key9999"), "key0500")
+	check("Ceiling exact", ceiling("key0250"), "key0250")
+	check("Ceiling between", ceiling("key0255"), "key0260")
+	check("Ceiling above last", ceiling("key0505"), "")
+	check("Ceiling below first", ceiling(""), "key0010")
+}
+
+func TestRankSelect(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	defer func(order int64) { defaultOrder = order }(defaultOrder)
+	defaultOrder = 4
+	bt := createEmpty(th)
+	rng := rand.New(rand.NewSource(0))
+	present := make(map[int]bool)
+	for _, idx := range rng.Perm(200) {
+		if err := bt.Put([]byte(fmt.Sprintf("key%04d", idx)), bt.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+		present[idx] = true
+	}
+	for _, idx := range rng.Perm(200)[:120] {
+		if err := bt.Remove([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		}
+		delete(present, idx)
+	}
+
+	position := int64(0)
+	for idx := 0; idx < 200; idx++ {
+		key := []byte(fmt.Sprintf("key%04d", idx))
+		if rank, err := bt.Rank(key); err != nil {
+			th.Fatal(err)
+		} else if rank != position {
+			th.Fatalf("Expected rank %v for key %s. Got %v", position, key, rank)
+		}
+		if !present[idx] {
+			continue
+		}
+		if found, value, err := bt.Select(position); err != nil {
+			th.Fatal(err)
+		} else if !bytes.Equal(found, key) || value == nil {
+			th.Fatalf("Expected key %s at position %v. Got %q", key, position, found)
+		}
+		position++
+	}
+	for _, outside := range []int64{-1, position} {
+		if key, value, err := bt.Select(outside); err != nil || key != nil || value != nil {
+			th.Fatalf("Expected nothing at position %v. Got %q %v %v", outside, key, value, err)
+		}
+	}
+}
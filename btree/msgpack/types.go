@@ -0,0 +1,24 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a BTree. Its single
+// reference is to the top node of the tree.
+type Root struct {
+	// The number of entries in the tree.
+	Size int64
+	// The maximum number of entries in a leaf node, and of children of
+	// an interior node. Every node but the top has at least Order/2.
+	Order int64
+}
+
+// Node is the value of a node Object. The references of a leaf node
+// are the value Objects of its keys, in order. The references of an
+// interior node are its children: every key in child i is less than
+// Keys[i], and every key in child i+1 is at least Keys[i].
+type Node struct {
+	Leaf bool
+	Keys [][]byte
+	// The number of entries under each child of an interior node.
+	Counts []int64
+}
@@ -0,0 +1,107 @@
+package btree
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/ordered"
+)
+
+// Iterate over the entries in the BTree with keys between from and
+// to, in key order, or in reverse key order if reverse is true. from
+// is always the lower bound and to the upper bound, whatever the
+// direction. Only the nodes which may hold keys in the range are read.
+// As with ForEach, the iteration is done within a single transaction,
+// which may restart, in which case entries may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (t *BTree) Range(from, to ordered.Bound, reverse bool, f func(key []byte, value client.ObjectRef) error) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return nil, rangeOver(tr.top, from, to, reverse, f)
+	})
+	return err
+}
+
+// Return the entry with the lowest key in the BTree, or a nil key and
+// value if the BTree is empty.
+func (t *BTree) First() ([]byte, *client.ObjectRef, error) {
+	return t.nearest(ordered.Bound{}, ordered.Bound{}, false)
+}
+
+// Return the entry with the highest key in the BTree, or a nil key and
+// value if the BTree is empty.
+func (t *BTree) Last() ([]byte, *client.ObjectRef, error) {
+	return t.nearest(ordered.Bound{}, ordered.Bound{}, true)
+}
+
+// Return the entry with the highest key which is not greater than
+// key, or a nil key and value if there is none. For example, with
+// keys prefixed by a time, the Floor of a time is the latest entry at
+// or before it.
+func (t *BTree) Floor(key []byte) ([]byte, *client.ObjectRef, error) {
+	return t.nearest(ordered.Bound{}, ordered.Inclusive(key), true)
+}
+
+// Return the entry with the lowest key which is not less than key, or
+// a nil key and value if there is none.
+func (t *BTree) Ceiling(key []byte) ([]byte, *client.ObjectRef, error) {
+	return t.nearest(ordered.Inclusive(key), ordered.Bound{}, false)
+}
+
+// errFound stops a Range once nearest has its entry.
+var errFound = errors.New("Found")
+
+// nearest returns the first entry of the Range from from to to.
+func (t *BTree) nearest(from, to ordered.Bound, reverse bool) ([]byte, *client.ObjectRef, error) {
+	var key []byte
+	var value *client.ObjectRef
+	err := t.Range(from, to, reverse, func(k []byte, v client.ObjectRef) error {
+		key, value = k, &v
+		return errFound
+	})
+	if err == errFound {
+		return key, value, nil
+	} else {
+		return nil, nil, err
+	}
+}
+
+func rangeOver(n *node, from, to ordered.Bound, reverse bool, f func(key []byte, value client.ObjectRef) error) error {
+	if n.leaf {
+		for i := range n.keys {
+			idx := i
+			if reverse {
+				idx = len(n.keys) - 1 - i
+			}
+			if key := n.keys[idx]; from.After(key) && to.Before(key) {
+				if err := f(key, n.refs[idx]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	lo, hi := 0, len(n.refs)-1
+	if from.Key != nil {
+		lo = n.childIndex(from.Key)
+	}
+	if to.Key != nil {
+		hi = n.childIndex(to.Key)
+	}
+	for i := lo; i <= hi; i++ {
+		idx := i
+		if reverse {
+			idx = lo + hi - i
+		}
+		child, err := readNode(n.refs[idx])
+		if err != nil {
+			return err
+		}
+		if err = rangeOver(child, from, to, reverse, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package btree
+
+import (
+	"goshawkdb.io/client"
+)
+
+// Return the number of entries in the BTree with keys less than key,
+// which is the position of key in key order, counting from 0, if it
+// is present. Interior nodes record the number of entries under each
+// of their children, so only the nodes on the path to key are read.
+func (t *BTree) Rank(key []byte) (int64, error) {
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tr, err := t.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		rank := int64(0)
+		n := tr.top
+		for !n.leaf {
+			idx := n.childIndex(key)
+			for _, c := range n.counts[:idx] {
+				rank += c
+			}
+			if n, err = readNode(n.refs[idx]); err != nil {
+				return nil, err
+			}
+		}
+		idx, _ := n.search(key)
+		return rank + int64(idx), nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Return the entry at the given position in key order, counting from
+// 0, or a nil key and value if there is no such position. As with
+// Rank, only the nodes on the path to the entry are read.
+func (t *BTree) Select(position int64) ([]byte, *client.ObjectRef, error) {
+	var key []byte
+	var value *client.ObjectRef
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		key, value = nil, nil
+		tr, err := t.read(txn)
+		if err != nil || position < 0 || position >= tr.root.Size {
+			return nil, err
+		}
+		// the transaction may restart, so leave position alone.
+		pos := position
+		n := tr.top
+		for !n.leaf {
+			idx := 0
+			for ; idx < len(n.counts)-1 && pos >= n.counts[idx]; idx++ {
+				pos -= n.counts[idx]
+			}
+			if n, err = readNode(n.refs[idx]); err != nil {
+				return nil, err
+			}
+		}
+		if pos < int64(len(n.keys)) {
+			key, value = n.keys[pos], &n.refs[pos]
+		}
+		return nil, nil
+	})
+	if err == nil {
+		return key, value, nil
+	} else {
+		return nil, nil, err
+	}
+}
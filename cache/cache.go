@@ -0,0 +1,417 @@
+// A Cache holds up to a fixed number of entries, each a value by key,
+// optionally expiring a time to live after they are set. Once the
+// Cache is full, setting a new key evicts an entry chosen by the
+// Cache's Policy: the least recently used, or the least frequently
+// used. Expired entries are treated as absent, and removed when found
+// by Get, or when they are evicted.
+//
+// The entries are held in an LHash by key, and in a BTree in eviction
+// order, so finding the entry to evict reads only the first entry of
+// the BTree. As the eviction order depends on use, every Get which
+// finds an entry rewrites it, and moves it within the BTree: a Get is
+// not a read-only transaction. The numbers of hits, misses and
+// evictions are kept in ShardedCounters, so that counting them does
+// not make every Get conflict with every other.
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/btree"
+	mp "goshawkdb.io/collections/cache/msgpack"
+	"goshawkdb.io/collections/counter"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/collections/ordenc"
+	"goshawkdb.io/collections/ordered"
+	"time"
+)
+
+// A Policy determines which entry a full Cache evicts.
+type Policy int64
+
+const (
+	// Evict the least recently used entry.
+	LRU Policy = iota
+	// Evict the least frequently used entry, and of those, the least
+	// recently used.
+	LFU
+)
+
+func (p Policy) String() string {
+	switch p {
+	case LRU:
+		return "LRU"
+	case LFU:
+		return "LFU"
+	default:
+		return fmt.Sprintf("Policy(%d)", int64(p))
+	}
+}
+
+// The number of stripes of the ShardedCounters of a Cache.
+const statsStripes = 8
+
+// Stats are the statistics of a Cache.
+type Stats struct {
+	// The number of Gets which found an entry.
+	Hits int64
+	// The number of Gets which did not.
+	Misses int64
+	// The number of entries evicted to make room for others.
+	Evictions int64
+	// The number of entries, including any which have expired but not
+	// yet been removed.
+	Size int64
+}
+
+type Cache struct {
+	// The connection used to create this Cache object. As with LHash,
+	// you should not use the same Cache object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Cache.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Cache, holding up to capacity entries,
+// which expire ttl after they are set (never, if ttl is 0), and are
+// evicted according to policy. This creates new GoshawkDB Objects and
+// initialises them for use as a Cache.
+func NewEmptyCache(conn *client.Connection, capacity int, ttl time.Duration, policy Policy) (*Cache, error) {
+	if capacity < 1 {
+		return nil, errors.New("Cache capacity must be positive")
+	} else if ttl < 0 {
+		return nil, errors.New("Cache TTL must not be negative")
+	} else if policy != LRU && policy != LFU {
+		return nil, fmt.Errorf("Unknown Cache policy %v", policy)
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		entries, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		order, err := btree.NewEmptyBTree(conn)
+		if err != nil {
+			return nil, err
+		}
+		refs := []client.ObjectRef{entries.ObjRef, order.ObjRef}
+		for len(refs) < 5 {
+			c, err := counter.NewEmptyShardedCounter(conn, statsStripes)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, c.ObjRef)
+		}
+		value, err := (&mp.Root{Capacity: int64(capacity), TTL: int64(ttl), Policy: int64(policy)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, refs...)
+		if err != nil {
+			return nil, err
+		}
+		return &Cache{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Cache), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Cache object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func CacheFromObj(conn *client.Connection, objRef client.ObjectRef) *Cache {
+	return &Cache{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Cache within a single transaction.
+type state struct {
+	root      *mp.Root
+	entries   *linearhash.LHash
+	order     *btree.BTree
+	hits      *counter.ShardedCounter
+	misses    *counter.ShardedCounter
+	evictions *counter.ShardedCounter
+	now       int64
+}
+
+func (c *Cache) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(c.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Capacity < 1 || len(refs) != 5 {
+		return nil, fmt.Errorf("Cache root %v is corrupt", obj)
+	}
+	return &state{
+		root:      root,
+		entries:   linearhash.LHashFromObj(c.Conn, refs[0]),
+		order:     btree.BTreeFromObj(c.Conn, refs[1]),
+		hits:      counter.ShardedCounterFromObj(c.Conn, refs[2]),
+		misses:    counter.ShardedCounterFromObj(c.Conn, refs[3]),
+		evictions: counter.ShardedCounterFromObj(c.Conn, refs[4]),
+		now:       time.Now().UnixNano(),
+	}, nil
+}
+
+// entry is an entry of the Cache within a single transaction.
+type entry struct {
+	key    []byte
+	objRef client.ObjectRef
+	e      *mp.Entry
+}
+
+// entry returns the entry with the given key, or nil if there is none.
+func (s *state) entry(key []byte) (*entry, error) {
+	objRef, err := s.entries.Find(key)
+	if err != nil || objRef == nil {
+		return nil, err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	e := new(mp.Entry)
+	if _, err = e.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return &entry{key: key, objRef: *objRef, e: e}, nil
+}
+
+func (e *entry) write() error {
+	value, err := e.e.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return e.objRef.Set(value)
+}
+
+func (s *state) expired(e *entry) bool {
+	return e.e.Expiry != 0 && e.e.Expiry <= s.now
+}
+
+// orderKey returns the key of the entry in the BTree in eviction
+// order: for LFU its uses, then, for both policies, its last use, and
+// then its key.
+func (s *state) orderKey(e *entry) []byte {
+	var k []byte
+	if Policy(s.root.Policy) == LFU {
+		k = ordenc.AppendUint64(k, e.e.Uses)
+	}
+	k = ordenc.AppendInt64(k, e.e.LastUse)
+	return append(k, e.key...)
+}
+
+// decodeOrderKey returns the key of the entry whose key in the BTree
+// in eviction order is k.
+func (s *state) decodeOrderKey(k []byte) ([]byte, error) {
+	var err error
+	if Policy(s.root.Policy) == LFU {
+		if _, k, err = ordenc.DecodeUint64(k); err != nil {
+			return nil, err
+		}
+	}
+	_, k, err = ordenc.DecodeInt64(k)
+	return k, err
+}
+
+// remove removes the entry from the Cache.
+func (s *state) remove(e *entry) error {
+	if err := s.order.Remove(s.orderKey(e)); err != nil {
+		return err
+	}
+	return s.entries.Remove(e.key)
+}
+
+// Returns the value of the entry with the given key, and whether
+// there is one which has not expired, counting a hit or a miss
+// accordingly. A hit counts as a use of the entry.
+func (c *Cache) Get(key []byte) ([]byte, bool, error) {
+	res, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		e, err := s.entry(key)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil && s.expired(e) {
+			if err = s.remove(e); err != nil {
+				return nil, err
+			}
+			e = nil
+		}
+		if e == nil {
+			return (*mp.Entry)(nil), s.misses.Incr(1)
+		}
+		oldKey := s.orderKey(e)
+		e.e.Uses++
+		e.e.LastUse = s.now
+		if err = e.write(); err != nil {
+			return nil, err
+		} else if err = s.order.Remove(oldKey); err != nil {
+			return nil, err
+		} else if err = s.order.Put(s.orderKey(e), e.objRef); err != nil {
+			return nil, err
+		}
+		return e.e, s.hits.Incr(1)
+	})
+	if err != nil {
+		return nil, false, err
+	} else if e := res.(*mp.Entry); e != nil {
+		return e.Value, true, nil
+	}
+	return nil, false, nil
+}
+
+// Set the value of the entry with the given key, which expires after
+// the Cache's TTL. If the Cache is full, and has no entry with the
+// key, an entry is evicted to make room.
+func (c *Cache) Set(key, value []byte) error {
+	return c.set(key, value, -1)
+}
+
+// Set the value of the entry with the given key, as with Set, but
+// such that the entry expires after ttl, rather than the Cache's TTL.
+// A ttl of 0 means the entry never expires.
+func (c *Cache) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("Cache TTL must not be negative")
+	}
+	return c.set(key, value, ttl)
+}
+
+// set sets the entry, using the Cache's TTL if ttl is negative.
+func (c *Cache) set(key, value []byte, ttl time.Duration) error {
+	_, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		entryTTL := ttl
+		if entryTTL < 0 {
+			entryTTL = time.Duration(s.root.TTL)
+		}
+		expiry := int64(0)
+		if entryTTL > 0 {
+			expiry = s.now + int64(entryTTL)
+		}
+		e, err := s.entry(key)
+		if err != nil {
+			return nil, err
+		} else if e != nil {
+			if err = s.order.Remove(s.orderKey(e)); err != nil {
+				return nil, err
+			}
+			e.e.Value, e.e.Expiry, e.e.LastUse = value, expiry, s.now
+			if err = e.write(); err != nil {
+				return nil, err
+			}
+			return nil, s.order.Put(s.orderKey(e), e.objRef)
+		}
+		if err = s.makeRoom(); err != nil {
+			return nil, err
+		}
+		e = &entry{key: key, e: &mp.Entry{Value: value, Expiry: expiry, LastUse: s.now}}
+		if e.objRef, err = txn.CreateObject(nil); err != nil {
+			return nil, err
+		} else if err = e.write(); err != nil {
+			return nil, err
+		} else if err = s.entries.Put(key, e.objRef); err != nil {
+			return nil, err
+		}
+		return nil, s.order.Put(s.orderKey(e), e.objRef)
+	})
+	return err
+}
+
+// errFound stops a Range once makeRoom has the entry to evict.
+var errFound = errors.New("Found")
+
+// makeRoom evicts the first entry in eviction order if the Cache is
+// full. An expired entry is not counted as an eviction.
+func (s *state) makeRoom() error {
+	size, err := s.order.Size()
+	if err != nil || size < s.root.Capacity {
+		return err
+	}
+	var key []byte
+	err = s.order.Range(ordered.Bound{}, ordered.Bound{}, false, func(k []byte, v client.ObjectRef) error {
+		key = k
+		return errFound
+	})
+	if err != errFound {
+		return err
+	}
+	if key, err = s.decodeOrderKey(key); err != nil {
+		return err
+	}
+	e, err := s.entry(key)
+	if err != nil {
+		return err
+	} else if e == nil {
+		return fmt.Errorf("Cache eviction order refers to missing key %q", key)
+	}
+	if err = s.remove(e); err != nil || s.expired(e) {
+		return err
+	}
+	return s.evictions.Incr(1)
+}
+
+// Remove the entry with the given key, returning whether there was
+// one which had not expired.
+func (c *Cache) Delete(key []byte) (bool, error) {
+	res, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		e, err := s.entry(key)
+		if err != nil || e == nil {
+			return false, err
+		}
+		return !s.expired(e), s.remove(e)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the Stats of the Cache.
+func (c *Cache) Stats() (Stats, error) {
+	res, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var stats Stats
+		if stats.Hits, err = s.hits.Get(); err != nil {
+			return nil, err
+		} else if stats.Misses, err = s.misses.Get(); err != nil {
+			return nil, err
+		} else if stats.Evictions, err = s.evictions.Get(); err != nil {
+			return nil, err
+		} else if stats.Size, err = s.order.Size(); err != nil {
+			return nil, err
+		}
+		return stats, nil
+	})
+	if err == nil {
+		return res.(Stats), nil
+	} else {
+		return Stats{}, err
+	}
+}
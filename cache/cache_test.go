@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+func createEmpty(th *tests.TestHelper, capacity int, ttl time.Duration, policy Policy) *Cache {
+	c0 := th.CreateConnections(1)[0]
+	c, err := NewEmptyCache(c0.Connection, capacity, ttl, policy)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return c
+}
+
+func set(th *tests.TestHelper, c *Cache, keys ...string) {
+	for _, key := range keys {
+		if err := c.Set([]byte(key), []byte("v"+key)); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// assertPresent checks which of the keys c holds. Each Get counts as a
+// use of the keys found.
+func assertPresent(th *tests.TestHelper, c *Cache, present bool, keys ...string) {
+	for _, key := range keys {
+		value, found, err := c.Get([]byte(key))
+		if err != nil {
+			th.Fatal(err)
+		} else if found != present {
+			th.Fatalf("Key %v: expected found %v. Got %v", key, present, found)
+		} else if found && string(value) != "v"+key {
+			th.Fatalf("Key %v: expected v%v. Got %q", key, key, value)
+		}
+	}
+}
+
+func assertStats(th *tests.TestHelper, c *Cache, expected Stats) {
+	if stats, err := c.Stats(); err != nil {
+		th.Fatal(err)
+	} else if stats != expected {
+		th.Fatalf("Expected %+v. Got %+v", expected, stats)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c := createEmpty(th, 3, 0, LRU)
+	set(th, c, "a", "b", "c")
+	assertPresent(th, c, true, "a")
+	// b is the least recently used.
+	set(th, c, "d")
+	assertPresent(th, c, false, "b")
+	assertPresent(th, c, true, "c", "a", "d")
+	assertStats(th, c, Stats{Hits: 4, Misses: 1, Evictions: 1, Size: 3})
+	// setting an existing key uses it, but evicts nothing.
+	set(th, c, "c")
+	set(th, c, "e")
+	assertPresent(th, c, false, "a")
+	assertPresent(th, c, true, "c", "d", "e")
+	if deleted, err := c.Delete([]byte("d")); err != nil || !deleted {
+		th.Fatalf("Expected to delete d. Got %v, %v", deleted, err)
+	}
+	if deleted, err := c.Delete([]byte("d")); err != nil || deleted {
+		th.Fatalf("Expected nothing to delete. Got %v, %v", deleted, err)
+	}
+	assertStats(th, c, Stats{Hits: 7, Misses: 2, Evictions: 2, Size: 2})
+	if err := c.Set([]byte("empty"), nil); err != nil {
+		th.Fatal(err)
+	} else if value, found, err := c.Get([]byte("empty")); err != nil || !found || len(value) != 0 {
+		th.Fatalf("Expected an empty value. Got %q, %v, %v", value, found, err)
+	}
+}
+
+func TestLFU(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c := createEmpty(th, 3, 0, LFU)
+	set(th, c, "a", "b", "c")
+	assertPresent(th, c, true, "a", "a", "b", "c", "c")
+	// b is used least often, even though a was used less recently.
+	set(th, c, "d")
+	assertPresent(th, c, false, "b")
+	assertPresent(th, c, true, "a", "c")
+	// d has no uses, so is evicted next.
+	set(th, c, "e")
+	assertPresent(th, c, false, "d")
+	assertPresent(th, c, true, "a", "c", "e")
+	assertStats(th, c, Stats{Hits: 10, Misses: 2, Evictions: 2, Size: 3})
+}
+
+func TestTTL(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c := createEmpty(th, 2, 50*time.Millisecond, LRU)
+	set(th, c, "a")
+	if err := c.SetWithTTL([]byte("b"), []byte("vb"), 0); err != nil {
+		th.Fatal(err)
+	}
+	assertPresent(th, c, true, "a", "b")
+	time.Sleep(100 * time.Millisecond)
+	// a has expired, and is removed by the Get.
+	assertPresent(th, c, false, "a")
+	assertPresent(th, c, true, "b")
+	assertStats(th, c, Stats{Hits: 3, Misses: 1, Size: 1})
+	if err := c.SetWithTTL([]byte("c"), []byte("vc"), time.Millisecond); err != nil {
+		th.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assertPresent(th, c, true, "b")
+	// c has expired, so evicting it to make room is not an eviction.
+	set(th, c, "d")
+	assertPresent(th, c, true, "b", "d")
+	assertPresent(th, c, false, "c")
+	assertStats(th, c, Stats{Hits: 6, Misses: 2, Size: 2})
+}
@@ -0,0 +1,27 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Cache. Its references are
+// to the LHash of Entries by key, the BTree of Entries in eviction
+// order, and the ShardedCounters of hits, misses and evictions.
+type Root struct {
+	Capacity int64
+	// The default time to live of Entries, in nanoseconds, or 0 if
+	// they do not expire.
+	TTL    int64
+	Policy int64
+}
+
+// Entry is the value of the Object of an entry of a Cache.
+type Entry struct {
+	Value []byte
+	// When the Entry expires, in nanoseconds since the Unix epoch, or
+	// 0 if it does not expire.
+	Expiry int64
+	// The number of times the Entry has been found by Get.
+	Uses uint64
+	// When the Entry was last set or found, in nanoseconds since the
+	// Unix epoch.
+	LastUse int64
+}
@@ -0,0 +1,338 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Entry) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Value":
+			z.Value, err = dc.ReadBytes(z.Value)
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		case "Expiry":
+			z.Expiry, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Expiry")
+				return
+			}
+		case "Uses":
+			z.Uses, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Uses")
+				return
+			}
+		case "LastUse":
+			z.LastUse, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "LastUse")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Entry) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "Value"
+	err = en.Append(0x84, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Value)
+	if err != nil {
+		err = msgp.WrapError(err, "Value")
+		return
+	}
+	// write "Expiry"
+	err = en.Append(0xa6, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Expiry)
+	if err != nil {
+		err = msgp.WrapError(err, "Expiry")
+		return
+	}
+	// write "Uses"
+	err = en.Append(0xa4, 0x55, 0x73, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Uses)
+	if err != nil {
+		err = msgp.WrapError(err, "Uses")
+		return
+	}
+	// write "LastUse"
+	err = en.Append(0xa7, 0x4c, 0x61, 0x73, 0x74, 0x55, 0x73, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.LastUse)
+	if err != nil {
+		err = msgp.WrapError(err, "LastUse")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Entry) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "Value"
+	o = append(o, 0x84, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	o = msgp.AppendBytes(o, z.Value)
+	// string "Expiry"
+	o = append(o, 0xa6, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79)
+	o = msgp.AppendInt64(o, z.Expiry)
+	// string "Uses"
+	o = append(o, 0xa4, 0x55, 0x73, 0x65, 0x73)
+	o = msgp.AppendUint64(o, z.Uses)
+	// string "LastUse"
+	o = append(o, 0xa7, 0x4c, 0x61, 0x73, 0x74, 0x55, 0x73, 0x65)
+	o = msgp.AppendInt64(o, z.LastUse)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Entry) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Value":
+			z.Value, bts, err = msgp.ReadBytesBytes(bts, z.Value)
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		case "Expiry":
+			z.Expiry, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Expiry")
+				return
+			}
+		case "Uses":
+			z.Uses, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Uses")
+				return
+			}
+		case "LastUse":
+			z.LastUse, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LastUse")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Entry) Msgsize() (s int) {
+	s = 1 + 6 + msgp.BytesPrefixSize + len(z.Value) + 7 + msgp.Int64Size + 5 + msgp.Uint64Size + 8 + msgp.Int64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Capacity":
+			z.Capacity, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Capacity")
+				return
+			}
+		case "TTL":
+			z.TTL, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "TTL")
+				return
+			}
+		case "Policy":
+			z.Policy, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Policy")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Capacity"
+	err = en.Append(0x83, 0xa8, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Capacity)
+	if err != nil {
+		err = msgp.WrapError(err, "Capacity")
+		return
+	}
+	// write "TTL"
+	err = en.Append(0xa3, 0x54, 0x54, 0x4c)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.TTL)
+	if err != nil {
+		err = msgp.WrapError(err, "TTL")
+		return
+	}
+	// write "Policy"
+	err = en.Append(0xa6, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Policy)
+	if err != nil {
+		err = msgp.WrapError(err, "Policy")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Capacity"
+	o = append(o, 0x83, 0xa8, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
+	o = msgp.AppendInt64(o, z.Capacity)
+	// string "TTL"
+	o = append(o, 0xa3, 0x54, 0x54, 0x4c)
+	o = msgp.AppendInt64(o, z.TTL)
+	// string "Policy"
+	o = append(o, 0xa6, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79)
+	o = msgp.AppendInt64(o, z.Policy)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Capacity":
+			z.Capacity, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Capacity")
+				return
+			}
+		case "TTL":
+			z.TTL, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TTL")
+				return
+			}
+		case "Policy":
+			z.Policy, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Policy")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Root) Msgsize() (s int) {
+	s = 1 + 9 + msgp.Int64Size + 4 + msgp.Int64Size + 7 + msgp.Int64Size
+	return
+}
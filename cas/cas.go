@@ -0,0 +1,172 @@
+// A CAS is a content-addressable store: each blob of content is
+// stored once, under the SHA-256 digest of the content, however many
+// times it is Put. Each blob has a reference count, incremented by Put
+// and decremented by Release, and the blob is removed once the count
+// reaches zero.
+//
+// The CAS is an LHash from digest to a small Object holding the
+// reference count, which in turn references the Object holding the
+// content, so that changing the count does not rewrite the content.
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/cas/msgpack"
+	"goshawkdb.io/collections/linearhash"
+)
+
+// ErrNotFound is returned by Release if there is no blob with the
+// given digest.
+var ErrNotFound = errors.New("No blob with that digest in CAS")
+
+// A Digest is the SHA-256 digest of a blob.
+type Digest [sha256.Size]byte
+
+// DigestOf returns the digest of the given content.
+func DigestOf(content []byte) Digest {
+	return Digest(sha256.Sum256(content))
+}
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+type CAS struct {
+	// The LHash holding the blobs, from digest to Blob Object.
+	LHash *linearhash.LHash
+}
+
+// Create a brand new empty CAS.
+func NewEmptyCAS(conn *client.Connection) (*CAS, error) {
+	lh, err := linearhash.NewEmptyLHash(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &CAS{LHash: lh}, nil
+}
+
+// Create a CAS from an existing given GoshawkDB Object. As with
+// LHashFromObj, no initialisation is done.
+func CASFromObj(conn *client.Connection, objRef client.ObjectRef) *CAS {
+	return &CAS{LHash: linearhash.LHashFromObj(conn, objRef)}
+}
+
+// Store the given content, returning its digest. If the content is
+// already stored, only its reference count is incremented.
+func (c *CAS) Put(content []byte) (Digest, error) {
+	digest := DigestOf(content)
+	_, _, err := c.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		blobObj, blob, refs, err := c.blob(digest)
+		if err != nil {
+			return nil, err
+		}
+		if blobObj == nil {
+			contentObj, err := txn.CreateObject(content)
+			if err != nil {
+				return nil, err
+			}
+			value, err := (&mp.Blob{Refs: 1, Size: int64(len(content))}).MarshalMsg(nil)
+			if err != nil {
+				return nil, err
+			}
+			obj, err := txn.CreateObject(value, contentObj)
+			if err != nil {
+				return nil, err
+			}
+			return nil, c.LHash.Put(digest[:], obj)
+		}
+		blob.Refs++
+		value, err := blob.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return nil, blobObj.Set(value, refs...)
+	})
+	return digest, err
+}
+
+// Returns the content with the given digest, or nil if there is none.
+func (c *CAS) Get(digest Digest) ([]byte, error) {
+	res, _, err := c.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		blobObj, _, refs, err := c.blob(digest)
+		if err != nil || blobObj == nil {
+			return []byte(nil), err
+		}
+		return refs[0].Value()
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the reference count of the content with the given digest,
+// which is 0 if there is no such content.
+func (c *CAS) RefCount(digest Digest) (int64, error) {
+	res, _, err := c.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, blob, _, err := c.blob(digest)
+		if err != nil {
+			return nil, err
+		}
+		return blob.Refs, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Decrement the reference count of the content with the given digest,
+// removing the content once the count reaches zero, which is
+// indicated by the result. ErrNotFound is returned if there is no such
+// content.
+func (c *CAS) Release(digest Digest) (bool, error) {
+	res, _, err := c.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		blobObj, blob, refs, err := c.blob(digest)
+		if err != nil {
+			return nil, err
+		} else if blobObj == nil {
+			return nil, ErrNotFound
+		}
+		blob.Refs--
+		if blob.Refs <= 0 {
+			return true, c.LHash.Remove(digest[:])
+		}
+		value, err := blob.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return false, blobObj.Set(value, refs...)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// blob returns the Blob Object for the digest, its decoded value and
+// its references, or a nil Object and an empty Blob if there is none.
+func (c *CAS) blob(digest Digest) (*client.ObjectRef, *mp.Blob, []client.ObjectRef, error) {
+	blob := new(mp.Blob)
+	objRef, err := c.LHash.Find(digest[:])
+	if err != nil || objRef == nil {
+		return nil, blob, nil, err
+	}
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err = blob.UnmarshalMsg(value); err != nil {
+		return nil, nil, nil, err
+	} else if len(refs) != 1 {
+		return nil, nil, nil, fmt.Errorf("CAS blob %v is corrupt: %v references", objRef, len(refs))
+	}
+	return objRef, blob, refs, nil
+}
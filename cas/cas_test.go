@@ -0,0 +1,57 @@
+package cas
+
+import (
+	"bytes"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	d := DigestOf([]byte("abc"))
+	if s := d.String(); s != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("Unexpected digest %v", s)
+	}
+}
+
+func TestCAS(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	c, err := NewEmptyCAS(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	content := []byte("a payload stored many times")
+	var digest Digest
+	for idx := 0; idx < 3; idx++ {
+		if digest, err = c.Put(content); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if size, err := c.LHash.Size(); err != nil {
+		th.Fatal(err)
+	} else if size != 1 {
+		th.Fatalf("Expected the content to be stored once. Got %v entries", size)
+	}
+	if refs, err := c.RefCount(digest); err != nil || refs != 3 {
+		th.Fatalf("Expected 3 references. Got %v, %v", refs, err)
+	}
+	if found, err := c.Get(digest); err != nil || !bytes.Equal(found, content) {
+		th.Fatalf("Expected %q. Got %q, %v", content, found, err)
+	}
+	for idx := 0; idx < 3; idx++ {
+		removed, err := c.Release(digest)
+		if err != nil {
+			th.Fatal(err)
+		} else if removed != (idx == 2) {
+			th.Fatalf("Release %v: unexpected removal %v", idx, removed)
+		}
+	}
+	if found, err := c.Get(digest); err != nil || found != nil {
+		th.Fatalf("Expected no content. Got %q, %v", found, err)
+	}
+	if _, err = c.Release(digest); err != ErrNotFound {
+		th.Fatalf("Expected ErrNotFound. Got %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package msgpack
+
+//go:generate msgp
+
+// Blob is the value of the Object each entry of a CAS points at. Its
+// single reference is to the Object holding the content itself, so
+// that changing the reference count does not rewrite the content.
+type Blob struct {
+	// The number of outstanding Puts of the content, less Releases.
+	Refs int64
+	// The length of the content, in bytes.
+	Size int64
+}
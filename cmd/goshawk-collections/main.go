@@ -0,0 +1,298 @@
+// Command goshawk-collections inspects and maintains the collections
+// of a GoshawkDB cluster from the command line. It connects to a
+// cluster, finds an LHash by starting from a named root Object and
+// following references, and then runs one subcommand against it:
+//
+//	stats               print the shape and contents of the LHash
+//	fsck                check the structure of the LHash
+//	dump FILE           write a dump of the LHash to FILE (- for stdout)
+//	restore FILE        restore a dump from FILE (- for stdin) as a new
+//	                    LHash, and replace the reference to the LHash
+//	                    with a reference to the new one
+//	get KEY             print the value of KEY
+//	put KEY VALUE       set the value of KEY
+//	remove KEY          remove KEY
+//
+// For example:
+//
+//	goshawk-collections -cert user.pem -root myRoot -path 2 stats
+//
+// finds the LHash referenced by the third reference of the root Object
+// named myRoot. With -hex, keys and values are given and printed in
+// hexadecimal.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/linearhash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	cluster := flag.String("cluster", "localhost:7894", "host:port of a server of the cluster")
+	cert := flag.String("cert", "", "file containing the client certificate and key, in PEM")
+	clusterCert := flag.String("clusterCert", "", "file containing the cluster certificate, in PEM (optional)")
+	rootName := flag.String("root", "", "name of the root Object from which to find the LHash")
+	path := flag.String("path", "", "comma separated reference indices to follow from the root Object to the LHash")
+	useHex := flag.Bool("hex", false, "give and print keys and values in hexadecimal")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v [flags] stats|fsck|dump FILE|restore FILE|get KEY|put KEY VALUE|remove KEY\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *cert == "" || *rootName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	indices, err := parsePath(*path)
+	if err != nil {
+		fail(err)
+	}
+	certAndKey, err := ioutil.ReadFile(*cert)
+	if err != nil {
+		fail(err)
+	}
+	var clusterCertPEM []byte
+	if *clusterCert != "" {
+		if clusterCertPEM, err = ioutil.ReadFile(*clusterCert); err != nil {
+			fail(err)
+		}
+	}
+	conn, err := client.NewConnection(*cluster, certAndKey, clusterCertPEM)
+	if err != nil {
+		fail(err)
+	}
+	defer conn.Shutdown()
+
+	c := &command{
+		conn:    conn,
+		root:    *rootName,
+		indices: indices,
+		hex:     *useHex,
+	}
+	if err = c.run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		conn.Shutdown()
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func parsePath(path string) ([]int, error) {
+	if path == "" {
+		return nil, nil
+	}
+	fields := strings.Split(path, ",")
+	indices := make([]int, len(fields))
+	for idx, field := range fields {
+		i, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("Invalid reference index %q in path", field)
+		}
+		indices[idx] = i
+	}
+	return indices, nil
+}
+
+type command struct {
+	conn    *client.Connection
+	root    string
+	indices []int
+	hex     bool
+}
+
+// arity is the number of arguments taken by each subcommand.
+var arity = map[string]int{
+	"stats":   0,
+	"fsck":    0,
+	"dump":    1,
+	"restore": 1,
+	"get":     1,
+	"put":     2,
+	"remove":  1,
+}
+
+func (c *command) run(name string, args []string) error {
+	if n, found := arity[name]; !found {
+		return fmt.Errorf("Unknown subcommand %q", name)
+	} else if len(args) != n {
+		return fmt.Errorf("%v takes %v arguments, not %v", name, n, len(args))
+	}
+	if name == "restore" {
+		return c.restore(args[0])
+	}
+	_, target, _, err := c.locate()
+	if err != nil {
+		return err
+	}
+	lh := linearhash.LHashFromObj(c.conn, target)
+
+	switch name {
+	case "stats":
+		stats, err := lh.Stats()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("size:            %v\n", stats.Size)
+		fmt.Printf("entries:         %v (%v expired, %v inline, %v blobs)\n", stats.Entries, stats.ExpiredEntries, stats.InlineEntries, stats.BlobEntries)
+		fmt.Printf("chains:          %v\n", stats.Chains)
+		fmt.Printf("buckets:         %v (longest chain %v)\n", stats.Buckets, stats.LongestChain)
+		fmt.Printf("utilization:     %.3f of %v slots (threshold %.3f)\n", stats.Utilization, stats.Slots, stats.Threshold)
+		return nil
+
+	case "fsck":
+		problems, err := lh.Fsck()
+		if err != nil {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%v problems found", len(problems))
+		}
+		return nil
+
+	case "dump":
+		w := io.Writer(os.Stdout)
+		if args[0] != "-" {
+			f, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		count, err := lh.Dump(w)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%v entries dumped\n", count)
+		return nil
+
+	case "get":
+		key, err := c.decode(args[0])
+		if err != nil {
+			return err
+		}
+		value, err := lh.FindValue(key)
+		if err != nil {
+			return err
+		} else if value == nil {
+			return errors.New("Not found")
+		}
+		fmt.Println(c.encode(value))
+		return nil
+
+	case "put":
+		key, err := c.decode(args[0])
+		if err != nil {
+			return err
+		}
+		value, err := c.decode(args[1])
+		if err != nil {
+			return err
+		}
+		return lh.PutValue(key, value)
+
+	default: // remove
+		key, err := c.decode(args[0])
+		if err != nil {
+			return err
+		}
+		return lh.Remove(key)
+	}
+}
+
+// locate follows the path from the root Object, returning the Object
+// holding the final reference followed (if any), the index of that
+// reference, and the Object it refers to.
+func (c *command) locate() (parent, target client.ObjectRef, idx int, err error) {
+	_, _, err = c.conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		roots, err := txn.GetRootObjects()
+		if err != nil {
+			return nil, err
+		}
+		obj, found := roots[c.root]
+		if !found {
+			return nil, fmt.Errorf("No root Object named %q", c.root)
+		}
+		for _, i := range c.indices {
+			refs, err := obj.References()
+			if err != nil {
+				return nil, err
+			} else if i >= len(refs) {
+				return nil, fmt.Errorf("Object %v has only %v references", obj, len(refs))
+			}
+			parent, idx = obj, i
+			obj = refs[i]
+		}
+		target = obj
+		return nil, nil
+	})
+	return
+}
+
+// restore loads a dump as a new LHash and points the final reference
+// of the path at it, in a single transaction. The old LHash is left as
+// it is.
+func (c *command) restore(file string) error {
+	if len(c.indices) == 0 {
+		return errors.New("restore needs a path: a root Object cannot be replaced")
+	}
+	// read the whole dump first, as the transaction may restart.
+	var dump []byte
+	var err error
+	if file == "-" {
+		dump, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		dump, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	_, _, err = c.conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		parent, _, idx, err := c.locate()
+		if err != nil {
+			return nil, err
+		}
+		lh, err := linearhash.Restore(c.conn, bytes.NewReader(dump))
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := parent.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		refs[idx] = lh.ObjRef
+		return nil, parent.Set(value, refs...)
+	})
+	return err
+}
+
+func (c *command) decode(s string) ([]byte, error) {
+	if c.hex {
+		return hex.DecodeString(s)
+	}
+	return []byte(s), nil
+}
+
+func (c *command) encode(bs []byte) string {
+	if c.hex {
+		return hex.EncodeToString(bs)
+	}
+	return string(bs)
+}
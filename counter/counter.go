@@ -0,0 +1,120 @@
+// A Counter is a signed 64-bit integer held in a single GoshawkDB
+// Object, which is atomically incremented and read. As every Incr
+// rewrites the same Object, concurrent increments of a Counter are
+// serialised; for high write rates, use a ShardedCounter instead.
+package counter
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/counter/msgpack"
+	"math"
+)
+
+// ErrOverflow is returned by Incr if the new value would not fit in an
+// int64. The Counter is left unchanged.
+var ErrOverflow = errors.New("Counter overflow")
+
+type Counter struct {
+	// The connection used to create this Counter object. As with
+	// LHash, you should not use the same Counter object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the value of the
+	// Counter.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new Counter with value 0. This creates a new
+// GoshawkDB Object and initialises it for use as a Counter.
+func NewEmptyCounter(conn *client.Connection) (*Counter, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Counter{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Counter{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Counter), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Counter object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func CounterFromObj(conn *client.Connection, objRef client.ObjectRef) *Counter {
+	return &Counter{Conn: conn, ObjRef: objRef}
+}
+
+func (c *Counter) read(txn *client.Txn) (client.ObjectRef, *mp.Counter, error) {
+	obj, err := txn.GetObject(c.ObjRef)
+	if err != nil {
+		return obj, nil, err
+	}
+	value, err := obj.Value()
+	if err != nil {
+		return obj, nil, err
+	}
+	counter := new(mp.Counter)
+	if _, err = counter.UnmarshalMsg(value); err != nil {
+		return obj, nil, err
+	}
+	return obj, counter, nil
+}
+
+// Add delta, which may be negative, to the Counter, returning the new
+// value. If called within a transaction, the increment is only made
+// if that transaction commits. ErrOverflow is returned if the new
+// value would be greater than math.MaxInt64 or less than
+// math.MinInt64.
+func (c *Counter) Incr(delta int64) (int64, error) {
+	res, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, counter, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		if counter.Value, err = add(counter.Value, delta); err != nil {
+			return nil, err
+		}
+		value, err := counter.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return counter.Value, obj.Set(value)
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// add returns a+b, or ErrOverflow if that does not fit in an int64.
+func add(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Returns the value of the Counter.
+func (c *Counter) Get() (int64, error) {
+	res, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, counter, err := c.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return counter.Value, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
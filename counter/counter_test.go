@@ -0,0 +1,120 @@
+package counter
+
+import (
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"math"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *Counter {
+	c0 := th.CreateConnections(1)[0]
+	c, err := NewEmptyCounter(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return c
+}
+
+func assertValue(th *tests.TestHelper, c *Counter, expected int64) {
+	if value, err := c.Get(); err != nil || value != expected {
+		th.Fatalf("Expected value %v. Got %v %v", expected, value, err)
+	}
+}
+
+func TestIncr(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c := createEmpty(th)
+	assertValue(th, c, 0)
+	for idx, delta := range []int64{1, 5, -10, 4} {
+		expected := []int64{1, 6, -4, 0}[idx]
+		if value, err := c.Incr(delta); err != nil || value != expected {
+			th.Fatalf("Incr(%v): expected %v. Got %v %v", delta, expected, value, err)
+		}
+	}
+	assertValue(th, c, 0)
+
+	// an Incr within a transaction which fails is not made.
+	_, _, err := c.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if _, err := c.Incr(3); err != nil {
+			return nil, err
+		}
+		return nil, ErrOverflow
+	})
+	if err != ErrOverflow {
+		th.Fatalf("Expected ErrOverflow. Got %v", err)
+	}
+	assertValue(th, c, 0)
+}
+
+func TestOverflow(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c := createEmpty(th)
+	if value, err := c.Incr(math.MaxInt64); err != nil || value != math.MaxInt64 {
+		th.Fatalf("Expected %v. Got %v %v", int64(math.MaxInt64), value, err)
+	}
+	if _, err := c.Incr(1); err != ErrOverflow {
+		th.Fatalf("Expected ErrOverflow. Got %v", err)
+	}
+	assertValue(th, c, math.MaxInt64)
+	if value, err := c.Incr(math.MinInt64); err != nil || value != -1 {
+		th.Fatalf("Expected -1. Got %v %v", value, err)
+	}
+	if value, err := c.Incr(math.MinInt64 + 1); err != nil || value != math.MinInt64 {
+		th.Fatalf("Expected %v. Got %v %v", int64(math.MinInt64), value, err)
+	}
+	if _, err := c.Incr(-1); err != ErrOverflow {
+		th.Fatalf("Expected ErrOverflow. Got %v", err)
+	}
+	assertValue(th, c, math.MinInt64)
+}
+
+func TestShardedCounter(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conns := th.CreateConnections(4)
+	s, err := NewEmptyShardedCounter(conns[0].Connection, 8)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if value, err := s.Get(); err != nil || value != 0 {
+		th.Fatalf("Expected value 0. Got %v %v", value, err)
+	}
+	// concurrent increments from every connection.
+	errs := make(chan error, len(conns))
+	for _, conn := range conns {
+		go func(s *ShardedCounter) {
+			for idx := 0; idx < 50; idx++ {
+				if err := s.Incr(2); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- s.Incr(-1)
+		}(ShardedCounterFromObj(conn.Connection, s.ObjRef))
+	}
+	for range conns {
+		if err := <-errs; err != nil {
+			th.Fatal(err)
+		}
+	}
+	expected := int64(len(conns) * 99)
+	if value, err := s.Get(); err != nil || value != expected {
+		th.Fatalf("Expected value %v. Got %v %v", expected, value, err)
+	}
+
+	// stripes may each be in range while their sum is not.
+	for idx := 0; idx < 4; idx++ {
+		if err = s.Incr(math.MaxInt64 / 2); err != nil && err != ErrOverflow {
+			th.Fatal(err)
+		}
+	}
+	if _, err = s.Get(); err != ErrOverflow {
+		th.Fatalf("Expected ErrOverflow. Got %v", err)
+	}
+}
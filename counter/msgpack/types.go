@@ -0,0 +1,8 @@
+package msgpack
+
+//go:generate msgp
+
+// Counter is the value of a Counter Object.
+type Counter struct {
+	Value int64
+}
@@ -0,0 +1,78 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden test vectors")
+
+// The golden test vectors in testdata/golden.json record the encoding
+// of Counters with values chosen to cover each msgpack integer width
+// and sign, so that implementations in other languages can check their
+// compatibility byte for byte. Regenerate the vectors with
+// go test -run TestGoldenVectors -update, which is necessary whenever
+// the encoding changes.
+type goldenVector struct {
+	// decimal, as JSON numbers cannot hold every int64.
+	Value   string `json:"value"`
+	Counter string `json:"counter"`
+}
+
+var goldenValues = []int64{
+	0, 1, -1, 127, 128, -32, -33, 255, 256, 65535, 65536, -32768, -32769,
+	math.MaxInt32, math.MaxInt32 + 1, math.MinInt32, math.MinInt32 - 1,
+	math.MaxInt64, math.MinInt64,
+}
+
+func TestGoldenVectors(t *testing.T) {
+	path := filepath.Join("testdata", "golden.json")
+	var vectors []*goldenVector
+	for _, value := range goldenValues {
+		bts, err := (&Counter{Value: value}).MarshalMsg(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors = append(vectors, &goldenVector{Value: strconv.FormatInt(value, 10), Counter: hex.EncodeToString(bts)})
+	}
+	if *update {
+		bts, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, append(bts, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden []*goldenVector
+	if err = json.Unmarshal(bts, &golden); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(golden, vectors) {
+		t.Fatalf("Encodings differ from %v; run with -update if the change is intended", path)
+	}
+
+	// the checked in encodings must decode to the recorded values.
+	for _, v := range golden {
+		bts, _ := hex.DecodeString(v.Counter)
+		c := new(Counter)
+		if _, err = c.UnmarshalMsg(bts); err != nil {
+			t.Fatal(err)
+		}
+		if strconv.FormatInt(c.Value, 10) != v.Value {
+			t.Fatalf("%v decoded as %v", v.Counter, c.Value)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package counter
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"math/rand"
+)
+
+// A ShardedCounter spreads its count over several stripes, each of
+// which is a Counter. Each Incr adds to a stripe chosen at random, so
+// concurrent increments mostly write different Objects and do not
+// conflict with each other, unlike increments of a single Counter.
+// The price is that Get must read and sum every stripe. The stripes
+// are referenced by a directory Object, which is only ever read.
+//
+// The number of stripes is fixed when the ShardedCounter is created:
+// a few times the number of concurrent writers is plenty.
+type ShardedCounter struct {
+	// The connection used to create this ShardedCounter object. As
+	// with LHash, you should not use the same ShardedCounter object
+	// from multiple connections.
+	Conn *client.Connection
+	// The underlying directory Object in GoshawkDB.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new ShardedCounter with value 0, spread over the
+// given number of stripes, each of which is a new Counter.
+func NewEmptyShardedCounter(conn *client.Connection, stripes int) (*ShardedCounter, error) {
+	if stripes < 1 {
+		return nil, errors.New("A ShardedCounter must have at least one stripe")
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		refs := make([]client.ObjectRef, stripes)
+		for idx := range refs {
+			c, err := NewEmptyCounter(conn)
+			if err != nil {
+				return nil, err
+			}
+			refs[idx] = c.ObjRef
+		}
+		return txn.CreateObject([]byte{}, refs...)
+	})
+	if err == nil {
+		return ShardedCounterFromObj(conn, res.(client.ObjectRef)), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a ShardedCounter object from an existing given GoshawkDB
+// directory Object. As with LHashFromObj, no initialisation is done.
+func ShardedCounterFromObj(conn *client.Connection, objRef client.ObjectRef) *ShardedCounter {
+	return &ShardedCounter{Conn: conn, ObjRef: objRef}
+}
+
+func (s *ShardedCounter) stripes(txn *client.Txn) ([]client.ObjectRef, error) {
+	obj, err := txn.GetObject(s.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := obj.References()
+	if err != nil {
+		return nil, err
+	} else if len(refs) == 0 {
+		return nil, fmt.Errorf("ShardedCounter directory %v is corrupt", obj)
+	}
+	return refs, nil
+}
+
+// Add delta, which may be negative, to the ShardedCounter. Unlike
+// Counter.Incr, the new value is not returned, as finding it would
+// mean reading every stripe. ErrOverflow is returned if the chosen
+// stripe would overflow; as stripes may be of opposite signs, the sum
+// can overflow without any stripe doing so, which Get reports.
+func (s *ShardedCounter) Incr(delta int64) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		refs, err := s.stripes(txn)
+		if err != nil {
+			return nil, err
+		}
+		_, err = CounterFromObj(s.Conn, refs[rand.Intn(len(refs))]).Incr(delta)
+		return nil, err
+	})
+	return err
+}
+
+// Returns the value of the ShardedCounter: the sum of its stripes.
+// ErrOverflow is returned if the sum does not fit in an int64.
+func (s *ShardedCounter) Get() (int64, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		refs, err := s.stripes(txn)
+		if err != nil {
+			return nil, err
+		}
+		sum := int64(0)
+		for _, objRef := range refs {
+			_, stripe, err := CounterFromObj(s.Conn, objRef).read(txn)
+			if err != nil {
+				return nil, err
+			}
+			if sum, err = add(sum, stripe.Value); err != nil {
+				return nil, err
+			}
+		}
+		return sum, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
@@ -0,0 +1,241 @@
+// A Sketch is a count-min sketch, which estimates how many times each
+// item has been counted, in a fixed amount of space, whatever the
+// number of distinct items. Estimates are never too low, and are too
+// high by at most 2N/width, with probability 1-(1/2)^depth, where N is
+// the total of all counts. So a Sketch is suited to finding hot keys
+// and heavy hitters, whose counts are large compared to that error.
+//
+// Each of the depth rows of counters is a separate Object, so every
+// Incr rewrites every row. Decay scales every counter down, so that a
+// Sketch can track recent frequencies rather than all-time ones.
+package countmin
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	hash "github.com/dchest/siphash"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/countmin/msgpack"
+	"math"
+)
+
+type Sketch struct {
+	// The connection used to create this Sketch object. As with LHash,
+	// you should not use the same Sketch object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Sketch.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Sketch with depth rows of width counters.
+// This creates new GoshawkDB Objects and initialises them for use as a
+// Sketch.
+func NewEmptySketch(conn *client.Connection, width, depth int) (*Sketch, error) {
+	if width < 1 || depth < 1 {
+		return nil, errors.New("A Sketch must have at least one row and one column")
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Row{Counts: make([]uint64, width)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]client.ObjectRef, depth)
+		for idx := range rows {
+			if rows[idx], err = txn.CreateObject(value); err != nil {
+				return nil, err
+			}
+		}
+		if value, err = (&mp.Root{Width: int64(width), HashKey: key}).MarshalMsg(nil); err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, rows...)
+		if err != nil {
+			return nil, err
+		}
+		return &Sketch{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Sketch), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Sketch object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func SketchFromObj(conn *client.Connection, objRef client.ObjectRef) *Sketch {
+	return &Sketch{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Sketch within a single transaction.
+type state struct {
+	root *mp.Root
+	rows []client.ObjectRef
+	k0   uint64
+	k1   uint64
+}
+
+func (sk *Sketch) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(sk.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Width < 1 || len(root.HashKey) != 16 || len(refs) == 0 {
+		return nil, fmt.Errorf("Sketch root %v is corrupt", obj)
+	}
+	return &state{
+		root: root,
+		rows: refs,
+		k0:   binary.LittleEndian.Uint64(root.HashKey[0:8]),
+		k1:   binary.LittleEndian.Uint64(root.HashKey[8:16]),
+	}, nil
+}
+
+func (s *state) row(idx int) (*mp.Row, error) {
+	value, err := s.rows[idx].Value()
+	if err != nil {
+		return nil, err
+	}
+	row := new(mp.Row)
+	if _, err = row.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if int64(len(row.Counts)) != s.root.Width {
+		return nil, fmt.Errorf("Sketch row %v is corrupt", s.rows[idx])
+	}
+	return row, nil
+}
+
+func (s *state) writeRow(idx int, row *mp.Row) error {
+	value, err := row.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return s.rows[idx].Set(value)
+}
+
+// columns returns the column of item in each row.
+func (s *state) columns(item []byte) []uint64 {
+	h1, h2 := hash.Hash128(s.k0, s.k1, item)
+	columns := make([]uint64, len(s.rows))
+	for idx := range columns {
+		columns[idx] = (h1 + uint64(idx)*h2) % uint64(s.root.Width)
+	}
+	return columns
+}
+
+// Count item delta more times, returning the new estimate of its
+// count. Counters saturate rather than overflow.
+func (sk *Sketch) Incr(item []byte, delta uint64) (uint64, error) {
+	res, _, err := sk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := sk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		estimate := uint64(math.MaxUint64)
+		for idx, column := range s.columns(item) {
+			row, err := s.row(idx)
+			if err != nil {
+				return nil, err
+			}
+			count := &row.Counts[column]
+			if *count > math.MaxUint64-delta {
+				*count = math.MaxUint64
+			} else {
+				*count += delta
+			}
+			if *count < estimate {
+				estimate = *count
+			}
+			if err = s.writeRow(idx, row); err != nil {
+				return nil, err
+			}
+		}
+		return estimate, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the estimated count of item: the least of its counters.
+func (sk *Sketch) Estimate(item []byte) (uint64, error) {
+	res, _, err := sk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := sk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		estimate := uint64(math.MaxUint64)
+		for idx, column := range s.columns(item) {
+			row, err := s.row(idx)
+			if err != nil {
+				return nil, err
+			}
+			if count := row.Counts[column]; count < estimate {
+				estimate = count
+			}
+		}
+		return estimate, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Multiply every counter by factor, which must be between 0 and 1,
+// rounding down. Calling Decay periodically, for example halving the
+// counters every hour, makes estimates favour recent counts. Every row
+// is rewritten, in a single transaction.
+func (sk *Sketch) Decay(factor float64) error {
+	if factor < 0 || factor > 1 {
+		return fmt.Errorf("Sketch decay factor must be between 0 and 1. Got %v", factor)
+	}
+	return sk.scale(func(count uint64) uint64 { return uint64(float64(count) * factor) })
+}
+
+// Set every counter to 0. Every row is rewritten, in a single
+// transaction.
+func (sk *Sketch) Reset() error {
+	return sk.scale(func(count uint64) uint64 { return 0 })
+}
+
+func (sk *Sketch) scale(f func(count uint64) uint64) error {
+	_, _, err := sk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := sk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range s.rows {
+			row, err := s.row(idx)
+			if err != nil {
+				return nil, err
+			}
+			for column, count := range row.Counts {
+				row.Counts[column] = f(count)
+			}
+			if err = s.writeRow(idx, row); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+package countmin
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func assertEstimate(th *tests.TestHelper, sk *Sketch, item string, min, max uint64) {
+	if estimate, err := sk.Estimate([]byte(item)); err != nil || estimate < min || estimate > max {
+		th.Fatalf("Expected an estimate for %v between %v and %v. Got %v %v", item, min, max, estimate, err)
+	}
+}
+
+func TestIncrEstimateDecay(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	sk, err := NewEmptySketch(c0.Connection, 200, 4)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, sk, "hot", 0, 0)
+	// one hot item among many cold ones, each counted once.
+	for idx := 0; idx < 200; idx++ {
+		if _, err = sk.Incr([]byte(fmt.Sprintf("cold%v", idx)), 1); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if estimate, err := sk.Incr([]byte("hot"), 1000); err != nil || estimate < 1000 {
+		th.Fatalf("Expected an estimate of at least 1000. Got %v %v", estimate, err)
+	}
+	// within 2N/width, almost surely.
+	assertEstimate(th, sk, "hot", 1000, 1012)
+	assertEstimate(th, sk, "cold0", 1, 13)
+
+	if err = sk.Decay(0.5); err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, sk, "hot", 500, 506)
+	if err = sk.Decay(2); err == nil {
+		th.Fatal("Expected an error decaying by a factor of 2")
+	}
+	if err = sk.Reset(); err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, sk, "hot", 0, 0)
+}
@@ -0,0 +1,17 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Sketch. Its references are
+// to the rows of counters, one per hash function.
+type Root struct {
+	// The number of counters in each row.
+	Width int64
+	// The 16 byte siphash key.
+	HashKey []byte
+}
+
+// Row is the value of a row Object.
+type Row struct {
+	Counts []uint64
+}
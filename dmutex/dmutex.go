@@ -0,0 +1,245 @@
+// A Mutex is a distributed mutual exclusion lock, held by at most one
+// owner at a time. An owner may hold the Mutex under a lease, which
+// expires after a given duration unless renewed, so that the Mutex is
+// not held forever by an owner which has crashed.
+//
+// An owner whose lease has expired may not know it has lost the
+// Mutex, so every acquisition is given a fencing token, greater than
+// that of every earlier acquisition. Pass the token along with each
+// action protected by the Mutex, and have whatever performs the
+// actions reject those carrying a lower token than it has already
+// seen: actions by stale holders are then detected.
+package dmutex
+
+import (
+	"bytes"
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/dmutex/msgpack"
+	"time"
+)
+
+// ErrNotHolder is returned by Unlock if the Mutex is not held by the
+// given owner.
+var ErrNotHolder = errors.New("Mutex is not held by the given owner")
+
+var errEmptyOwner = errors.New("A Mutex owner must not be empty")
+
+type Mutex struct {
+	// The connection used to create this Mutex object. As with LHash,
+	// you should not use the same Mutex object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the state of the
+	// Mutex.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new Mutex, which is not held. This creates a new
+// GoshawkDB Object and initialises it for use as a Mutex.
+func NewMutex(conn *client.Connection) (*Mutex, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Mutex{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Mutex{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Mutex), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Mutex object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func MutexFromObj(conn *client.Connection, objRef client.ObjectRef) *Mutex {
+	return &Mutex{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Mutex within a single transaction.
+type state struct {
+	objRef client.ObjectRef
+	mutex  *mp.Mutex
+	now    int64
+}
+
+func (m *Mutex) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(m.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, err := obj.Value()
+	if err != nil {
+		return nil, err
+	}
+	mutex := new(mp.Mutex)
+	if _, err = mutex.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return &state{objRef: obj, mutex: mutex, now: time.Now().UnixNano()}, nil
+}
+
+func (s *state) write() error {
+	value, err := s.mutex.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return s.objRef.Set(value)
+}
+
+// heldBy returns true if the Mutex is held, and by owner.
+func (s *state) heldBy(owner []byte) bool {
+	return !s.free() && bytes.Equal(s.mutex.Owner, owner)
+}
+
+// free returns true if the Mutex is not held, or its holder's lease
+// has expired.
+func (s *state) free() bool {
+	return len(s.mutex.Owner) == 0 || (s.mutex.Expiry != 0 && s.mutex.Expiry <= s.now)
+}
+
+// tryLock acquires the Mutex for owner, or renews owner's lease if it
+// already holds it, returning true and the fencing token if the Mutex
+// is now held by owner.
+func (s *state) tryLock(owner []byte, ttl time.Duration) (bool, error) {
+	if !s.heldBy(owner) {
+		if !s.free() {
+			return false, nil
+		}
+		s.mutex.Owner = owner
+		s.mutex.Token++
+	}
+	s.mutex.Expiry = 0
+	if ttl > 0 {
+		s.mutex.Expiry = s.now + int64(ttl)
+	}
+	return true, s.write()
+}
+
+// Attempt to acquire the Mutex on behalf of owner, which should
+// uniquely identify the party acquiring it, and must not be empty.
+// Returns true and the fencing token of the acquisition if the Mutex
+// is now held by owner. If ttl is positive, owner holds the Mutex
+// under a lease which expires after ttl, after which anyone may
+// acquire it; a ttl of 0 or less means the lease never expires. If
+// owner already holds the Mutex, its lease is renewed for ttl and the
+// token is unchanged, so call TryLock periodically to keep hold of
+// the Mutex.
+func (m *Mutex) TryLock(owner []byte, ttl time.Duration) (uint64, bool, error) {
+	if len(owner) == 0 {
+		return 0, false, errEmptyOwner
+	}
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		locked, err := s.tryLock(owner, ttl)
+		if err != nil {
+			return nil, err
+		} else if !locked {
+			return uint64(0), nil
+		}
+		return s.mutex.Token, nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	token := res.(uint64)
+	return token, token != 0, nil
+}
+
+// Acquire the Mutex on behalf of owner, as with TryLock, blocking
+// until it is available, and returning the fencing token. While the
+// Mutex is held without a lease, Lock waits with a retry transaction,
+// which wakes as soon as the Mutex is unlocked. That blocks the
+// connection of the Mutex, so Lock must not be called from within a
+// transaction. The expiry of a lease is not a change to the Mutex, so
+// would not wake a retry transaction: while the Mutex is held under a
+// lease, Lock instead sleeps until the lease is due to expire, and
+// then tries again.
+func (m *Mutex) Lock(owner []byte, ttl time.Duration) (uint64, error) {
+	if len(owner) == 0 {
+		return 0, errEmptyOwner
+	}
+	for {
+		var expiry int64
+		res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			expiry = 0
+			s, err := m.read(txn)
+			if err != nil {
+				return nil, err
+			}
+			locked, err := s.tryLock(owner, ttl)
+			if err != nil {
+				return nil, err
+			} else if locked {
+				return s.mutex.Token, nil
+			} else if s.mutex.Expiry == 0 {
+				return client.Retry, nil
+			}
+			expiry = s.mutex.Expiry
+			return uint64(0), nil
+		})
+		if err != nil {
+			return 0, err
+		} else if token := res.(uint64); token != 0 {
+			return token, nil
+		}
+		time.Sleep(time.Until(time.Unix(0, expiry)))
+	}
+}
+
+// Release the Mutex. ErrNotHolder is returned if the Mutex is not
+// held by owner, which includes the case where owner's lease has
+// expired.
+func (m *Mutex) Unlock(owner []byte) error {
+	_, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		} else if !s.heldBy(owner) {
+			return nil, ErrNotHolder
+		}
+		s.mutex.Owner = nil
+		s.mutex.Expiry = 0
+		return nil, s.write()
+	})
+	return err
+}
+
+// Returns the current holder of the Mutex and the fencing token of
+// its acquisition, or a nil owner if the Mutex is not held.
+func (m *Mutex) Holder() ([]byte, uint64, error) {
+	owner, token, _, err := m.Lease()
+	return owner, token, err
+}
+
+// Returns the current holder of the Mutex, the fencing token of its
+// acquisition, and when its lease expires, which is the zero Time if
+// it never does. The owner is nil if the Mutex is not held.
+func (m *Mutex) Lease() ([]byte, uint64, time.Time, error) {
+	var lease mp.Mutex
+	_, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		lease = mp.Mutex{}
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		} else if !s.free() {
+			lease = *s.mutex
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	} else if lease.Expiry == 0 {
+		return lease.Owner, lease.Token, time.Time{}, nil
+	}
+	return lease.Owner, lease.Token, time.Unix(0, lease.Expiry), nil
+}
@@ -0,0 +1,124 @@
+package dmutex
+
+import (
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+func createMutexes(th *tests.TestHelper, count int) []*Mutex {
+	conns := th.CreateConnections(count)
+	m, err := NewMutex(conns[0].Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	mutexes := []*Mutex{m}
+	for _, conn := range conns[1:] {
+		mutexes = append(mutexes, MutexFromObj(conn.Connection, m.ObjRef))
+	}
+	return mutexes
+}
+
+func assertHolder(th *tests.TestHelper, m *Mutex, owner string, token uint64) {
+	if current, t, err := m.Holder(); err != nil || string(current) != owner || t != token {
+		th.Fatalf("Expected holder %q with token %v. Got %q %v %v", owner, token, current, t, err)
+	}
+}
+
+func TestTryLockUnlock(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	m := createMutexes(th, 1)[0]
+	assertHolder(th, m, "", 0)
+	if token, ok, err := m.TryLock([]byte("a"), 0); err != nil || !ok || token != 1 {
+		th.Fatalf("Expected to acquire with token 1. Got %v %v %v", token, ok, err)
+	}
+	if _, ok, err := m.TryLock([]byte("b"), 0); err != nil || ok {
+		th.Fatalf("Expected b not to acquire a held Mutex. Got %v %v", ok, err)
+	}
+	// reacquiring renews, keeping the token.
+	if token, ok, err := m.TryLock([]byte("a"), time.Hour); err != nil || !ok || token != 1 {
+		th.Fatalf("Expected to renew with token 1. Got %v %v %v", token, ok, err)
+	}
+	assertHolder(th, m, "a", 1)
+	if _, _, expiry, err := m.Lease(); err != nil || expiry.Before(time.Now().Add(59*time.Minute)) {
+		th.Fatalf("Expected the lease to expire in an hour. Got %v %v", expiry, err)
+	}
+	if err := m.Unlock([]byte("b")); err != ErrNotHolder {
+		th.Fatalf("Expected ErrNotHolder. Got %v", err)
+	}
+	if err := m.Unlock([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	assertHolder(th, m, "", 0)
+	if err := m.Unlock([]byte("a")); err != ErrNotHolder {
+		th.Fatalf("Expected ErrNotHolder. Got %v", err)
+	}
+	if token, ok, err := m.TryLock([]byte("b"), 0); err != nil || !ok || token != 2 {
+		th.Fatalf("Expected to acquire with token 2. Got %v %v %v", token, ok, err)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	m := createMutexes(th, 1)[0]
+	if _, ok, err := m.TryLock([]byte("a"), 20*time.Millisecond); err != nil || !ok {
+		th.Fatalf("Expected to acquire. Got %v %v", ok, err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	assertHolder(th, m, "", 0)
+	// a stale holder can neither unlock nor act with its old token.
+	if token, ok, err := m.TryLock([]byte("b"), 0); err != nil || !ok || token != 2 {
+		th.Fatalf("Expected to acquire with token 2. Got %v %v %v", token, ok, err)
+	}
+	if err := m.Unlock([]byte("a")); err != ErrNotHolder {
+		th.Fatalf("Expected ErrNotHolder. Got %v", err)
+	}
+	assertHolder(th, m, "b", 2)
+}
+
+func TestLock(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	mutexes := createMutexes(th, 2)
+	if _, err := mutexes[0].Lock([]byte("a"), 0); err != nil {
+		th.Fatal(err)
+	}
+	tokens := make(chan uint64)
+	go func() {
+		token, err := mutexes[1].Lock([]byte("b"), 0)
+		if err != nil {
+			token = 0
+		}
+		tokens <- token
+	}()
+	select {
+	case token := <-tokens:
+		th.Fatalf("Lock returned %v while the Mutex was held", token)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := mutexes[0].Unlock([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	if token := <-tokens; token != 2 {
+		th.Fatalf("Expected token 2. Got %v", token)
+	}
+
+	// a lease which is not renewed expires, and Lock then acquires.
+	if err := mutexes[1].Unlock([]byte("b")); err != nil {
+		th.Fatal(err)
+	}
+	if _, ok, err := mutexes[0].TryLock([]byte("a"), 30*time.Millisecond); err != nil || !ok {
+		th.Fatalf("Expected to acquire. Got %v %v", ok, err)
+	}
+	start := time.Now()
+	if token, err := mutexes[1].Lock([]byte("b"), 0); err != nil || token != 4 {
+		th.Fatalf("Expected token 4. Got %v %v", token, err)
+	} else if time.Since(start) < 20*time.Millisecond {
+		th.Fatal("Lock acquired before the lease expired")
+	}
+}
@@ -0,0 +1,15 @@
+package msgpack
+
+//go:generate msgp
+
+// Mutex is the value of a Mutex Object.
+type Mutex struct {
+	// The current holder, or empty if the Mutex is not held.
+	Owner []byte
+	// When the holder's lease expires, in nanoseconds since the Unix
+	// epoch. 0 means it never expires.
+	Expiry int64
+	// The fencing token of the current or most recent holder, which is
+	// incremented by each acquisition.
+	Token uint64
+}
@@ -0,0 +1,195 @@
+// A DocStore is a collection of documents, each a JSON or msgpack
+// value, by key, with indexes on declared fields of the documents.
+// Fields are named by paths, such as "address.city", and every Put and
+// Delete keeps the indexes consistent with the documents, so that
+// documents can be found by the values of their fields with
+// FindByField.
+//
+// The documents are held in a Table, with an index named after each
+// field path, the extract function of which decodes the documents. As
+// the field paths and the format of the documents are recorded in the
+// DocStore, unlike for a Table, nothing need be declared when opening
+// an existing DocStore.
+package docstore
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/docstore/msgpack"
+	"goshawkdb.io/collections/table"
+)
+
+// A Format is the encoding of the documents of a DocStore.
+type Format int64
+
+const (
+	JSON Format = iota
+	Msgpack
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "JSON"
+	case Msgpack:
+		return "msgpack"
+	default:
+		return fmt.Sprintf("Format(%d)", int64(f))
+	}
+}
+
+type DocStore struct {
+	// The connection used to create this DocStore object. As with
+	// LHash, you should not use the same DocStore object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the DocStore.
+	ObjRef client.ObjectRef
+	// The Table holding the documents. It must not be modified other
+	// than through the DocStore.
+	Table  *table.Table
+	format Format
+}
+
+// Create a brand new empty DocStore, of documents in the given Format,
+// with an index on each of the given field paths. This creates new
+// GoshawkDB Objects and initialises them for use as a DocStore.
+func NewEmptyDocStore(conn *client.Connection, format Format, fields ...string) (*DocStore, error) {
+	defs, err := indexDefs(format, fields)
+	if err != nil {
+		return nil, err
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tbl, err := table.NewEmptyTable(conn, defs...)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{Format: int64(format), Fields: fields}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, tbl.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &DocStore{Conn: conn, ObjRef: objRef, Table: tbl, format: format}, nil
+	})
+	if err == nil {
+		return res.(*DocStore), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a DocStore object from an existing given GoshawkDB Object.
+// As with TableFromObj, the root is read, so that the Table can be
+// opened with the recorded indexes.
+func DocStoreFromObj(conn *client.Connection, objRef client.ObjectRef) (*DocStore, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		root := new(mp.Root)
+		if _, err = root.UnmarshalMsg(value); err != nil {
+			return nil, err
+		} else if len(refs) != 1 {
+			return nil, fmt.Errorf("DocStore root %v is corrupt", obj)
+		}
+		format := Format(root.Format)
+		defs, err := indexDefs(format, root.Fields)
+		if err != nil {
+			return nil, err
+		}
+		tbl, err := table.TableFromObj(conn, refs[0], defs...)
+		if err != nil {
+			return nil, err
+		}
+		return &DocStore{Conn: conn, ObjRef: obj, Table: tbl, format: format}, nil
+	})
+	if err == nil {
+		return res.(*DocStore), nil
+	} else {
+		return nil, err
+	}
+}
+
+// indexDefs returns the definitions of the indexes of the Table for
+// the given field paths.
+func indexDefs(format Format, fields []string) ([]table.IndexDef, error) {
+	if format != JSON && format != Msgpack {
+		return nil, fmt.Errorf("Unknown DocStore format %v", format)
+	}
+	defs := make([]table.IndexDef, len(fields))
+	for idx, path := range fields {
+		steps, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		defs[idx] = table.IndexDef{Name: path, Extract: extractor(format, steps)}
+	}
+	return defs, nil
+}
+
+// Put doc, which must be a valid document in the DocStore's Format, as
+// the document with the given key, replacing any existing document
+// with that key, and updating the indexes.
+func (ds *DocStore) Put(key, doc []byte) error {
+	if _, err := decode(ds.format, doc); err != nil {
+		return fmt.Errorf("Invalid %v document: %v", ds.format, err)
+	}
+	_, _, err := ds.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		old, err := ds.Table.Find(key)
+		if err != nil {
+			return nil, err
+		} else if old == nil {
+			return nil, ds.Table.Insert(key, doc)
+		} else {
+			return nil, ds.Table.Update(key, doc)
+		}
+	})
+	return err
+}
+
+// Returns the document with the given key, or nil if there is none.
+func (ds *DocStore) Get(key []byte) ([]byte, error) {
+	return ds.Table.Find(key)
+}
+
+// Remove the document with the given key, and its index entries,
+// returning whether there was one.
+func (ds *DocStore) Delete(key []byte) (bool, error) {
+	res, _, err := ds.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		old, err := ds.Table.Find(key)
+		if err != nil || old == nil {
+			return false, err
+		}
+		return true, ds.Table.Delete(key)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the documents, with their keys, which have value at the
+// given field path, which must be one of the DocStore's indexed
+// fields. A field which is an array has each of its elements as a
+// value, and any array on the path leads to the values under each of
+// its elements. Numbers are equal if their values are, whatever their
+// types: 1, uint8(1) and 1.0 all find the same documents. Strings and
+// msgpack binary values are also equal if their bytes are. Only nil,
+// booleans, numbers, strings and byte slices are indexed.
+func (ds *DocStore) FindByField(path string, value interface{}) ([]table.Row, error) {
+	derived, ok := fieldKey(value)
+	if !ok {
+		return nil, fmt.Errorf("Values of type %T are not indexed", value)
+	}
+	return ds.Table.FindBy(path, derived)
+}
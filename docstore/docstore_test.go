@@ -0,0 +1,129 @@
+package docstore
+
+import (
+	"fmt"
+	"github.com/tinylib/msgp/msgp"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, format Format, fields ...string) *DocStore {
+	c0 := th.CreateConnections(1)[0]
+	ds, err := NewEmptyDocStore(c0.Connection, format, fields...)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return ds
+}
+
+func put(th *tests.TestHelper, ds *DocStore, key string, doc []byte) {
+	if err := ds.Put([]byte(key), doc); err != nil {
+		th.Fatal(err)
+	}
+}
+
+func assertFindByField(th *tests.TestHelper, ds *DocStore, path string, value interface{}, expected ...string) {
+	rows, err := ds.FindByField(path, value)
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(rows))
+	for idx, row := range rows {
+		got[idx] = string(row.Key)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("FindByField %v=%v: expected %v. Got %v", path, value, expected, got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	if _, err := NewEmptyDocStore(nil, JSON, "a..b"); err == nil {
+		th.Fatal("Expected an error for an invalid field path")
+	}
+	ds := createEmpty(th, JSON, "name", "address.city", "tags", "orders.sku", "age")
+	if err := ds.Put([]byte("x"), []byte("{")); err == nil {
+		th.Fatal("Expected an error for an invalid document")
+	}
+	put(th, ds, "u1", []byte(`{"name": "alice", "address": {"city": "london"}, "tags": ["a", "b"], "age": 30}`))
+	put(th, ds, "u2", []byte(`{"name": "bob", "address": {"city": "paris"}, "orders": [{"sku": 7}, {"sku": "7"}], "age": 30.5}`))
+	put(th, ds, "u3", []byte(`{"name": "carol", "address": {"city": "london"}, "tags": "b", "age": null}`))
+
+	assertFindByField(th, ds, "name", "bob", "u2")
+	assertFindByField(th, ds, "address.city", "london", "u1", "u3")
+	assertFindByField(th, ds, "tags", "b", "u1", "u3")
+	assertFindByField(th, ds, "orders.sku", 7, "u2")
+	assertFindByField(th, ds, "orders.sku", "7", "u2")
+	assertFindByField(th, ds, "orders.sku", 8)
+	assertFindByField(th, ds, "age", uint8(30), "u1")
+	assertFindByField(th, ds, "age", 30.0, "u1")
+	assertFindByField(th, ds, "age", 30.5, "u2")
+	assertFindByField(th, ds, "age", nil, "u3")
+	if _, err := ds.FindByField("nickname", "al"); err == nil {
+		th.Fatal("Expected an error for an unindexed field")
+	}
+	if _, err := ds.FindByField("name", []string{"alice"}); err == nil {
+		th.Fatal("Expected an error for an unindexable value")
+	}
+
+	// replacing and deleting keep the indexes up to date.
+	put(th, ds, "u1", []byte(`{"name": "alice", "address": {"city": "paris"}}`))
+	assertFindByField(th, ds, "address.city", "london", "u3")
+	assertFindByField(th, ds, "address.city", "paris", "u2", "u1")
+	assertFindByField(th, ds, "tags", "a")
+	if deleted, err := ds.Delete([]byte("u2")); err != nil {
+		th.Fatal(err)
+	} else if !deleted {
+		th.Fatal("Expected u2 to be deleted")
+	}
+	if deleted, err := ds.Delete([]byte("u2")); err != nil {
+		th.Fatal(err)
+	} else if deleted {
+		th.Fatal("Expected u2 to be deleted already")
+	}
+	assertFindByField(th, ds, "address.city", "paris", "u1")
+	if doc, err := ds.Get([]byte("u2")); err != nil {
+		th.Fatal(err)
+	} else if doc != nil {
+		th.Fatalf("Expected no document. Got %s", doc)
+	}
+
+	// reopening needs nothing declared.
+	ds2, err := DocStoreFromObj(ds.Conn, ds.ObjRef)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertFindByField(th, ds2, "name", "carol", "u3")
+	if doc, err := ds2.Get([]byte("u3")); err != nil {
+		th.Fatal(err)
+	} else if string(doc) != `{"name": "carol", "address": {"city": "london"}, "tags": "b", "age": null}` {
+		th.Fatalf("Unexpected document %s", doc)
+	}
+}
+
+func TestMsgpack(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	ds := createEmpty(th, Msgpack, "id", "meta.kind")
+	doc := func(id int64, kind []byte) []byte {
+		b := msgp.AppendMapHeader(nil, 2)
+		b = msgp.AppendString(b, "id")
+		b = msgp.AppendInt64(b, id)
+		b = msgp.AppendString(b, "meta")
+		b = msgp.AppendMapHeader(b, 1)
+		b = msgp.AppendString(b, "kind")
+		return msgp.AppendBytes(b, kind)
+	}
+	if err := ds.Put([]byte("x"), append(doc(1, nil), 0)); err == nil {
+		th.Fatal("Expected an error for trailing bytes")
+	}
+	put(th, ds, "d1", doc(1, []byte("a")))
+	put(th, ds, "d2", doc(1<<40, []byte("b")))
+	assertFindByField(th, ds, "id", uint64(1<<40), "d2")
+	assertFindByField(th, ds, "id", 1, "d1")
+	assertFindByField(th, ds, "meta.kind", "b", "d2")
+	assertFindByField(th, ds, "meta.kind", []byte("a"), "d1")
+}
@@ -0,0 +1,162 @@
+package docstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tinylib/msgp/msgp"
+	"goshawkdb.io/collections/index"
+	"goshawkdb.io/collections/ordenc"
+	"math"
+	"strings"
+)
+
+// The tags which start the index keys of field values, so that values
+// of different kinds, such as "1" and 1, are never equal.
+const (
+	tagNil    = 'n'
+	tagBool   = 'b'
+	tagInt    = 'i'
+	tagUint   = 'u'
+	tagFloat  = 'f'
+	tagString = 's'
+)
+
+// parsePath splits a field path into its steps.
+func parsePath(path string) ([]string, error) {
+	steps := strings.Split(path, ".")
+	for _, step := range steps {
+		if step == "" {
+			return nil, fmt.Errorf("Invalid DocStore field path %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// decode decodes a document into maps, slices and scalars.
+func decode(format Format, doc []byte) (interface{}, error) {
+	switch format {
+	case JSON:
+		if !json.Valid(doc) {
+			return nil, errors.New("Malformed JSON")
+		}
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		// keep integers exact.
+		dec.UseNumber()
+		var v interface{}
+		err := dec.Decode(&v)
+		return v, err
+	case Msgpack:
+		v, rest, err := msgp.ReadIntfBytes(doc)
+		if err == nil && len(rest) != 0 {
+			err = fmt.Errorf("%v bytes after msgpack value", len(rest))
+		}
+		return v, err
+	default:
+		return nil, fmt.Errorf("Unknown DocStore format %v", format)
+	}
+}
+
+// extractor returns the function which derives the index keys of the
+// field at the path with the given steps.
+func extractor(format Format, steps []string) index.ExtractFunc {
+	return func(key, doc []byte) ([][]byte, error) {
+		v, err := decode(format, doc)
+		if err != nil {
+			return nil, err
+		}
+		var derived [][]byte
+		collect(v, steps, func(field interface{}) {
+			if k, ok := fieldKey(field); ok {
+				derived = append(derived, k)
+			}
+		})
+		return derived, nil
+	}
+}
+
+// collect calls f with each value at the path with the given steps
+// under v, following every element of any array.
+func collect(v interface{}, steps []string, f func(interface{})) {
+	if elems, ok := v.([]interface{}); ok {
+		for _, elem := range elems {
+			collect(elem, steps, f)
+		}
+	} else if len(steps) == 0 {
+		f(v)
+	} else if fields, ok := v.(map[string]interface{}); ok {
+		if field, found := fields[steps[0]]; found {
+			collect(field, steps[1:], f)
+		}
+	}
+}
+
+// fieldKey returns the index key of a field value, and whether it can
+// be indexed.
+func fieldKey(v interface{}) ([]byte, bool) {
+	switch v := v.(type) {
+	case nil:
+		return []byte{tagNil}, true
+	case bool:
+		if v {
+			return []byte{tagBool, 1}, true
+		}
+		return []byte{tagBool, 0}, true
+	case string:
+		return append([]byte{tagString}, v...), true
+	case []byte:
+		return append([]byte{tagString}, v...), true
+	case int:
+		return intKey(int64(v)), true
+	case int8:
+		return intKey(int64(v)), true
+	case int16:
+		return intKey(int64(v)), true
+	case int32:
+		return intKey(int64(v)), true
+	case int64:
+		return intKey(v), true
+	case uint:
+		return uintKey(uint64(v)), true
+	case uint8:
+		return uintKey(uint64(v)), true
+	case uint16:
+		return uintKey(uint64(v)), true
+	case uint32:
+		return uintKey(uint64(v)), true
+	case uint64:
+		return uintKey(v), true
+	case float32:
+		return floatKey(float64(v)), true
+	case float64:
+		return floatKey(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return intKey(i), true
+		} else if f, err := v.Float64(); err == nil {
+			return floatKey(f), true
+		}
+	}
+	return nil, false
+}
+
+func intKey(v int64) []byte {
+	return ordenc.AppendInt64([]byte{tagInt}, v)
+}
+
+// uintKey and floatKey use the int64 key wherever the value fits, so
+// that numbers of every type are equal if their values are.
+func uintKey(v uint64) []byte {
+	if v <= math.MaxInt64 {
+		return intKey(int64(v))
+	}
+	return ordenc.AppendUint64([]byte{tagUint}, v)
+}
+
+func floatKey(v float64) []byte {
+	if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+		return intKey(int64(v))
+	}
+	return ordenc.AppendFloat64([]byte{tagFloat}, v)
+}
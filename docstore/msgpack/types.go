@@ -0,0 +1,12 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a DocStore. Its only
+// reference is to the root of the Table holding the documents, which
+// has an index named after each of Fields.
+type Root struct {
+	Format int64
+	// The paths of the indexed fields.
+	Fields []string
+}
@@ -0,0 +1,146 @@
+// An EncryptedLHash stores its entries in an underlying LHash such
+// that neither keys nor values are stored in plaintext. Each key is
+// replaced by its HMAC-SHA256, which is used for placement within the
+// LHash, so that Find still needs only a single lookup. The plaintext
+// key and value are then sealed together with AES-256-GCM, with the
+// HMAC as additional data, so that a sealed entry cannot be moved to
+// another key without detection, and the result stored as the value
+// of the entry (see LHash.PutValue).
+//
+// Both the HMAC key and the encryption key are derived from a single
+// secret supplied by the caller, which must be the same for every
+// user of the LHash, and which is never stored. Anyone able to read
+// the underlying Objects learns only the number of entries and the
+// lengths of their values.
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"goshawkdb.io/collections/linearhash"
+	"io"
+)
+
+// ErrShortSecret is returned by NewEncryptedLHash if the secret is
+// shorter than 32 bytes.
+var ErrShortSecret = errors.New("The secret must be at least 32 bytes")
+
+// ErrCorrupt is returned when a sealed entry cannot be opened: it has
+// been tampered with, or was sealed with a different secret.
+var ErrCorrupt = errors.New("Encrypted entry cannot be authenticated")
+
+type EncryptedLHash struct {
+	// The underlying LHash, holding the sealed entries. Using it
+	// directly bypasses the encryption.
+	LHash  *linearhash.LHash
+	macKey []byte
+	aead   cipher.AEAD
+}
+
+// Wrap the given LHash, which should only ever hold entries written
+// through an EncryptedLHash with the same secret.
+func NewEncryptedLHash(lh *linearhash.LHash, secret []byte) (*EncryptedLHash, error) {
+	if len(secret) < 32 {
+		return nil, ErrShortSecret
+	}
+	block, err := aes.NewCipher(derive(secret, "encryption"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedLHash{
+		LHash:  lh,
+		macKey: derive(secret, "placement"),
+		aead:   aead,
+	}, nil
+}
+
+// derive returns a 32 byte key for the given purpose.
+func derive(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("goshawkdb.io/collections/encrypted " + purpose))
+	return mac.Sum(nil)
+}
+
+// Set the value of the entry for the given key.
+func (e *EncryptedLHash) Put(key, value []byte) error {
+	placement := e.place(key)
+	sealed, err := e.seal(placement, key, value)
+	if err != nil {
+		return err
+	}
+	return e.LHash.PutValue(placement, sealed)
+}
+
+// Returns the value of the entry for the given key, or nil if there
+// is no entry.
+func (e *EncryptedLHash) Find(key []byte) ([]byte, error) {
+	placement := e.place(key)
+	sealed, err := e.LHash.FindValue(placement)
+	if err != nil || sealed == nil {
+		return nil, err
+	}
+	_, value, err := e.open(placement, sealed)
+	return value, err
+}
+
+// Idempotently remove any entry for the given key.
+func (e *EncryptedLHash) Remove(key []byte) error {
+	return e.LHash.Remove(e.place(key))
+}
+
+// Iterate over the entries, supplying the plaintext key and value of
+// each. See LHash.ForEachValue.
+func (e *EncryptedLHash) ForEach(f func(key, value []byte) error) error {
+	return e.LHash.ForEachValue(func(placement, sealed []byte) error {
+		key, value, err := e.open(placement, sealed)
+		if err != nil {
+			return err
+		}
+		return f(key, value)
+	})
+}
+
+func (e *EncryptedLHash) place(key []byte) []byte {
+	mac := hmac.New(sha256.New, e.macKey)
+	mac.Write(key)
+	return mac.Sum(nil)
+}
+
+// seal returns the nonce followed by the sealed key and value. The key
+// is sealed with the value so that ForEach can recover it.
+func (e *EncryptedLHash) seal(placement, key, value []byte) ([]byte, error) {
+	nonceSize := e.aead.NonceSize()
+	plaintext := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(key)+len(value))
+	plaintext = plaintext[:binary.PutUvarint(plaintext, uint64(len(key)))]
+	plaintext = append(append(plaintext, key...), value...)
+	sealed := make([]byte, nonceSize, nonceSize+len(plaintext)+e.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, sealed); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(sealed, sealed, plaintext, placement), nil
+}
+
+func (e *EncryptedLHash) open(placement, sealed []byte) (key, value []byte, err error) {
+	nonceSize := e.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, nil, ErrCorrupt
+	}
+	plaintext, err := e.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], placement)
+	if err != nil {
+		return nil, nil, ErrCorrupt
+	}
+	length, n := binary.Uvarint(plaintext)
+	if n <= 0 || uint64(len(plaintext)-n) < length {
+		return nil, nil, ErrCorrupt
+	}
+	return plaintext[n : n+int(length)], plaintext[n+int(length):], nil
+}
@@ -0,0 +1,104 @@
+package encrypted
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+var secret = bytes.Repeat([]byte("s"), 32)
+
+func TestSeal(t *testing.T) {
+	e, err := NewEncryptedLHash(nil, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewEncryptedLHash(nil, secret[:16]); err != ErrShortSecret {
+		t.Fatalf("Expected ErrShortSecret. Got %v", err)
+	}
+	placement := e.place([]byte("alice"))
+	if bytes.Contains(placement, []byte("alice")) || !bytes.Equal(placement, e.place([]byte("alice"))) {
+		t.Fatal("Expected a stable placement which does not reveal the key")
+	}
+	sealed, err := e.seal(placement, []byte("alice"), []byte("secret value"))
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(sealed, []byte("alice")) || bytes.Contains(sealed, []byte("secret value")) {
+		t.Fatal("Sealed entry contains plaintext")
+	}
+	key, value, err := e.open(placement, sealed)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(key) != "alice" || string(value) != "secret value" {
+		t.Fatalf("Unexpected entry %q, %q", key, value)
+	}
+
+	// moving the entry to another key, or changing it, is detected.
+	if _, _, err = e.open(e.place([]byte("bob")), sealed); err != ErrCorrupt {
+		t.Fatalf("Expected ErrCorrupt. Got %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, _, err = e.open(placement, sealed); err != ErrCorrupt {
+		t.Fatalf("Expected ErrCorrupt. Got %v", err)
+	}
+	other, _ := NewEncryptedLHash(nil, bytes.Repeat([]byte("t"), 32))
+	if bytes.Equal(other.place([]byte("alice")), placement) {
+		t.Fatal("Expected placement to depend on the secret")
+	}
+}
+
+func TestEncryptedLHash(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	lh, err := linearhash.NewEmptyLHash(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	e, err := NewEncryptedLHash(lh, secret)
+	if err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 50; idx++ {
+		if err = e.Put([]byte(fmt.Sprint("key", idx)), []byte(fmt.Sprint("value", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if value, err := e.Find([]byte("key7")); err != nil {
+		th.Fatal(err)
+	} else if string(value) != "value7" {
+		th.Fatalf("Expected value7. Got %q", value)
+	}
+	if err = e.Remove([]byte("key7")); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := e.Find([]byte("key7")); err != nil || value != nil {
+		th.Fatalf("Expected no value. Got %q, %v", value, err)
+	}
+	count := 0
+	err = e.ForEach(func(key, value []byte) error {
+		if !bytes.Equal(bytes.Replace(key, []byte("key"), []byte("value"), 1), value) {
+			return fmt.Errorf("Unexpected entry %q, %q", key, value)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	} else if count != 49 {
+		th.Fatalf("Expected 49 entries. Got %v", count)
+	}
+	// the underlying LHash holds no plaintext keys.
+	err = lh.ForEachKey(func(key []byte) error {
+		if bytes.HasPrefix(key, []byte("key")) {
+			return fmt.Errorf("Plaintext key %q stored", key)
+		}
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
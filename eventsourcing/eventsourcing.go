@@ -0,0 +1,319 @@
+// A Store holds event sourced aggregates: the state of each aggregate
+// is not stored directly, but rebuilt by applying its events, in
+// order, which are only ever appended. Each aggregate has its own
+// stream of events, held in a Log, and its version is the number of
+// events appended to it. Appends are checked against the version the
+// writer last loaded, so that concurrent writers, each working from
+// the aggregate as it was when loaded, cannot both succeed.
+//
+// So that loading need not apply every event ever appended, a snapshot
+// of the state of an aggregate at some version can be saved, after
+// which loading starts from the latest snapshot and applies only the
+// events since.
+package eventsourcing
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/eventsourcing/msgpack"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/collections/log"
+)
+
+// A ConflictError is returned by Append when the version of the
+// aggregate is not the version expected.
+type ConflictError struct {
+	AggregateID []byte
+	Expected    uint64
+	Actual      uint64
+}
+
+func (ce *ConflictError) Error() string {
+	return fmt.Sprintf("Version conflict for aggregate %q: expected version %v, found version %v", ce.AggregateID, ce.Expected, ce.Actual)
+}
+
+// An Event is an event of an aggregate, or a snapshot of its state.
+type Event struct {
+	// The version of the aggregate once the event is applied, from 1
+	// for the first event, or the version the snapshot is of.
+	Version uint64
+	Data    []byte
+	// If Snapshot, Data is a snapshot of the aggregate's state, as
+	// given to SaveSnapshot, rather than an event.
+	Snapshot bool
+}
+
+type Store struct {
+	// The connection used to create this Store object. As with LHash,
+	// you should not use the same Store object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Store.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Store, the event Log of each aggregate of
+// which has segments of segmentSize events (log.DefaultSegmentSize if
+// segmentSize is not positive). This creates new GoshawkDB Objects and
+// initialises them for use as a Store.
+func NewEmptyStore(conn *client.Connection, segmentSize int) (*Store, error) {
+	if segmentSize <= 0 {
+		segmentSize = log.DefaultSegmentSize
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		streams, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{SegmentSize: int64(segmentSize)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, streams.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &Store{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Store), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Store object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func StoreFromObj(conn *client.Connection, objRef client.ObjectRef) *Store {
+	return &Store{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Store within a single transaction.
+type state struct {
+	root    *mp.Root
+	streams *linearhash.LHash
+}
+
+func (st *Store) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(st.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.SegmentSize < 1 || len(refs) != 1 {
+		return nil, fmt.Errorf("Store root %v is corrupt", obj)
+	}
+	return &state{root: root, streams: linearhash.LHashFromObj(st.Conn, refs[0])}, nil
+}
+
+// stream is the stream of an aggregate within a single transaction.
+type stream struct {
+	objRef client.ObjectRef
+	s      *mp.Stream
+	log    *log.Log
+	// the root of the snapshot, or objRef if there is none.
+	snapshot client.ObjectRef
+}
+
+// stream returns the stream of the aggregate with the given ID, or nil
+// if nothing has been appended to it.
+func (s *state) stream(conn *client.Connection, aggregateID []byte) (*stream, error) {
+	objRef, err := s.streams.Find(aggregateID)
+	if err != nil || objRef == nil {
+		return nil, err
+	}
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	st := new(mp.Stream)
+	if _, err = st.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 2 {
+		return nil, fmt.Errorf("Store stream %v is corrupt", objRef)
+	}
+	return &stream{objRef: *objRef, s: st, log: log.LogFromObj(conn, refs[0]), snapshot: refs[1]}, nil
+}
+
+func (st *stream) write() error {
+	value, err := st.s.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return st.objRef.Set(value, st.log.ObjRef, st.snapshot)
+}
+
+// version returns the version of the aggregate: the number of events
+// appended to it, or 0 if st is nil.
+func (st *stream) version() (uint64, error) {
+	if st == nil {
+		return 0, nil
+	}
+	return st.log.Next()
+}
+
+// Returns the version of the aggregate with the given ID: the number
+// of events appended to it.
+func (st *Store) Version(aggregateID []byte) (uint64, error) {
+	res, _, err := st.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := st.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := s.stream(st.Conn, aggregateID)
+		if err != nil {
+			return nil, err
+		}
+		return stream.version()
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Append events to the aggregate with the given ID, but only if its
+// version is expectedVersion (0 for an aggregate with no events),
+// returning its new version. On a mismatch, a *ConflictError is
+// returned and nothing is appended: the caller should Load the
+// aggregate again and decide afresh which events to append.
+func (st *Store) Append(aggregateID []byte, expectedVersion uint64, events ...[]byte) (uint64, error) {
+	res, _, err := st.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := st.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := s.stream(st.Conn, aggregateID)
+		if err != nil {
+			return nil, err
+		}
+		version, err := stream.version()
+		if err != nil {
+			return nil, err
+		} else if version != expectedVersion {
+			return nil, &ConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: version}
+		} else if len(events) == 0 {
+			return version, nil
+		}
+		if stream == nil {
+			if stream, err = s.createStream(txn, st.Conn, aggregateID); err != nil {
+				return nil, err
+			}
+		}
+		for _, event := range events {
+			if _, err = stream.log.Append(event); err != nil {
+				return nil, err
+			}
+		}
+		return version + uint64(len(events)), nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// createStream creates the stream of the aggregate with the given ID.
+func (s *state) createStream(txn *client.Txn, conn *client.Connection, aggregateID []byte) (*stream, error) {
+	l, err := log.NewEmptyLog(conn, int(s.root.SegmentSize))
+	if err != nil {
+		return nil, err
+	}
+	objRef, err := txn.CreateObject(nil)
+	if err != nil {
+		return nil, err
+	}
+	stream := &stream{objRef: objRef, s: new(mp.Stream), log: l, snapshot: objRef}
+	if err = stream.write(); err != nil {
+		return nil, err
+	}
+	return stream, s.streams.Put(aggregateID, objRef)
+}
+
+// Load the aggregate with the given ID, by calling apply with its
+// latest snapshot, if there is one, and then with each event since, in
+// order, returning the version of the aggregate, to pass to Append.
+// The snapshot and events are read in a single transaction, but apply
+// is called only once it has committed, so each is supplied exactly
+// once. An error returned by apply stops the loading and is returned.
+func (st *Store) Load(aggregateID []byte, apply func(e Event) error) (uint64, error) {
+	res, _, err := st.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := st.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := s.stream(st.Conn, aggregateID)
+		if err != nil || stream == nil {
+			return []Event(nil), err
+		}
+		var events []Event
+		from := stream.s.SnapshotVersion
+		if from > 0 {
+			snapshot, err := stream.snapshot.Value()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, Event{Version: from, Data: snapshot, Snapshot: true})
+		}
+		entries, err := stream.log.ReadFrom(from, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			events = append(events, Event{Version: e.Offset + 1, Data: e.Value})
+		}
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	version := uint64(0)
+	for _, e := range res.([]Event) {
+		if err = apply(e); err != nil {
+			return 0, err
+		}
+		version = e.Version
+	}
+	return version, nil
+}
+
+// Save snapshot as the state of the aggregate with the given ID at the
+// given version, which must not be beyond its current version, so that
+// Load starts from it. A snapshot of a version no later than that of
+// the latest snapshot is ignored. The events before the snapshot are
+// kept.
+func (st *Store) SaveSnapshot(aggregateID []byte, version uint64, snapshot []byte) error {
+	_, _, err := st.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := st.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := s.stream(st.Conn, aggregateID)
+		if err != nil {
+			return nil, err
+		}
+		current, err := stream.version()
+		if err != nil {
+			return nil, err
+		} else if version > current {
+			return nil, fmt.Errorf("Cannot snapshot aggregate %q at version %v: it is at version %v", aggregateID, version, current)
+		} else if version == 0 || version <= stream.s.SnapshotVersion {
+			return nil, nil
+		}
+		if stream.snapshot, err = txn.CreateObject(snapshot); err != nil {
+			return nil, err
+		}
+		stream.s.SnapshotVersion = version
+		return nil, stream.write()
+	})
+	return err
+}
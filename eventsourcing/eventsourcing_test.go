@@ -0,0 +1,133 @@
+package eventsourcing
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"strconv"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *Store {
+	c0 := th.CreateConnections(1)[0]
+	st, err := NewEmptyStore(c0.Connection, 2)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return st
+}
+
+// account is an aggregate whose events and snapshots are balances and
+// amounts, as decimal strings.
+type account struct {
+	balance  int
+	applied  []string
+	fromSnap bool
+}
+
+func (a *account) apply(e Event) error {
+	n, err := strconv.Atoi(string(e.Data))
+	if err != nil {
+		return err
+	}
+	if e.Snapshot {
+		a.balance, a.fromSnap = n, true
+	} else {
+		a.balance += n
+	}
+	a.applied = append(a.applied, fmt.Sprintf("%v:%s", e.Version, e.Data))
+	return nil
+}
+
+func load(th *tests.TestHelper, st *Store, id string) (*account, uint64) {
+	a := new(account)
+	version, err := st.Load([]byte(id), a.apply)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return a, version
+}
+
+func appendEvents(th *tests.TestHelper, st *Store, id string, expectedVersion uint64, events ...string) uint64 {
+	data := make([][]byte, len(events))
+	for idx, e := range events {
+		data[idx] = []byte(e)
+	}
+	version, err := st.Append([]byte(id), expectedVersion, data...)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return version
+}
+
+func TestAppendLoad(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	st := createEmpty(th)
+	if a, version := load(th, st, "acc1"); version != 0 || len(a.applied) != 0 {
+		th.Fatalf("Expected an empty aggregate. Got version %v, %v", version, a.applied)
+	}
+	if version := appendEvents(th, st, "acc1", 0, "10", "-3"); version != 2 {
+		th.Fatalf("Expected version 2. Got %v", version)
+	}
+	appendEvents(th, st, "acc2", 0, "100")
+	a, version := load(th, st, "acc1")
+	if version != 2 || a.balance != 7 || fmt.Sprint(a.applied) != "[1:10 2:-3]" {
+		th.Fatalf("Unexpected aggregate at version %v: %v %v", version, a.balance, a.applied)
+	}
+
+	// a writer working from an old version conflicts.
+	if version = appendEvents(th, st, "acc1", version, "5"); version != 3 {
+		th.Fatalf("Expected version 3. Got %v", version)
+	}
+	_, err := st.Append([]byte("acc1"), 2, []byte("-100"))
+	if ce, ok := err.(*ConflictError); !ok || ce.Expected != 2 || ce.Actual != 3 {
+		th.Fatalf("Expected a ConflictError. Got %v", err)
+	}
+	if _, err = st.Append([]byte("acc3"), 1, []byte("1")); err == nil {
+		th.Fatal("Expected a ConflictError for a new aggregate")
+	}
+	if version, err = st.Version([]byte("acc1")); err != nil {
+		th.Fatal(err)
+	} else if version != 3 {
+		th.Fatalf("Expected version 3. Got %v", version)
+	}
+	if a, _ = load(th, st, "acc1"); a.balance != 12 {
+		th.Fatalf("Expected balance 12. Got %v", a.balance)
+	}
+	if a, _ = load(th, st, "acc2"); a.balance != 100 {
+		th.Fatalf("Expected balance 100. Got %v", a.balance)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	st := createEmpty(th)
+	version := appendEvents(th, st, "acc", 0, "1", "2", "3", "4")
+	if err := st.SaveSnapshot([]byte("acc"), 5, []byte("10")); err == nil {
+		th.Fatal("Expected an error for a snapshot beyond the current version")
+	}
+	if err := st.SaveSnapshot([]byte("acc"), 3, []byte("6")); err != nil {
+		th.Fatal(err)
+	}
+	a, loaded := load(th, st, "acc")
+	if loaded != version || !a.fromSnap || a.balance != 10 || fmt.Sprint(a.applied) != "[3:6 4:4]" {
+		th.Fatalf("Unexpected aggregate at version %v: %v %v", loaded, a.balance, a.applied)
+	}
+	// an older snapshot is ignored.
+	if err := st.SaveSnapshot([]byte("acc"), 2, []byte("3")); err != nil {
+		th.Fatal(err)
+	}
+	if err := st.SaveSnapshot([]byte("acc"), 4, []byte("10")); err != nil {
+		th.Fatal(err)
+	}
+	if a, loaded = load(th, st, "acc"); loaded != 4 || fmt.Sprint(a.applied) != "[4:10]" {
+		th.Fatalf("Unexpected aggregate at version %v: %v", loaded, a.applied)
+	}
+	appendEvents(th, st, "acc", loaded, "5")
+	if a, loaded = load(th, st, "acc"); loaded != 5 || a.balance != 15 || fmt.Sprint(a.applied) != "[4:10 5:5]" {
+		th.Fatalf("Unexpected aggregate at version %v: %v %v", loaded, a.balance, a.applied)
+	}
+}
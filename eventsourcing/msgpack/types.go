@@ -0,0 +1,21 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Store. Its only reference
+// is to an LHash from each aggregate ID to the aggregate's stream
+// Object.
+type Root struct {
+	// The segment size of the Log of each stream.
+	SegmentSize int64
+}
+
+// Stream is the value of a stream Object. Its first reference is to
+// the Log of the aggregate's events, the offset of each of which is
+// one less than its version. The second is to the latest snapshot, or
+// to the stream Object itself if there is none.
+type Stream struct {
+	// The version of the aggregate the snapshot is of, or 0 if there
+	// is no snapshot.
+	SnapshotVersion uint64
+}
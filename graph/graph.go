@@ -0,0 +1,406 @@
+// A Graph is a set of vertices, identified by byte string keys, and
+// edges between them, either directed or undirected. Vertices and
+// edges may carry properties. Each vertex has its own adjacency
+// Object, which holds its edges, and is found through an LHash from
+// vertex key. Adding or removing an edge rewrites only the adjacency
+// Objects of its two vertices, so changes to different parts of a
+// Graph do not conflict; only adding and removing vertices writes the
+// LHash.
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/graph/msgpack"
+	"goshawkdb.io/collections/linearhash"
+	"sort"
+)
+
+// ErrNoSuchVertex is returned when adding an edge to a vertex which
+// is not in the Graph.
+var ErrNoSuchVertex = errors.New("No such vertex in Graph")
+
+// A Direction selects which edges of a vertex Neighbours returns. In
+// an undirected Graph, every direction selects every edge.
+type Direction int
+
+const (
+	// Edges from the vertex.
+	Out Direction = iota
+	// Edges to the vertex.
+	In
+	// Edges both from and to the vertex.
+	Both
+)
+
+// A Neighbour is a vertex adjacent to another, with the properties of
+// the edge between them.
+type Neighbour struct {
+	Key   []byte
+	Props []byte
+}
+
+type Graph struct {
+	// The connection used to create this Graph object. As with LHash,
+	// you should not use the same Graph object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Graph.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Graph, the edges of which are directed if
+// directed is true. This creates new GoshawkDB Objects and initialises
+// them for use as a Graph.
+func NewEmptyGraph(conn *client.Connection, directed bool) (*Graph, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		vertices, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{Directed: directed}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, vertices.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &Graph{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Graph), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Graph object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func GraphFromObj(conn *client.Connection, objRef client.ObjectRef) *Graph {
+	return &Graph{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Graph within a single transaction.
+type state struct {
+	root     *mp.Root
+	vertices *linearhash.LHash
+}
+
+func (g *Graph) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(g.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 {
+		return nil, fmt.Errorf("Graph root %v is corrupt", obj)
+	}
+	return &state{root: root, vertices: linearhash.LHashFromObj(g.Conn, refs[0])}, nil
+}
+
+// vertex is a vertex within a single transaction.
+type vertex struct {
+	objRef client.ObjectRef
+	adj    *mp.Adjacency
+}
+
+// vertex returns the vertex with the given key, or nil if there is
+// none.
+func (s *state) vertex(key []byte) (*vertex, error) {
+	objRef, err := s.vertices.Find(key)
+	if err != nil || objRef == nil {
+		return nil, err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	adj := new(mp.Adjacency)
+	if _, err = adj.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return &vertex{objRef: *objRef, adj: adj}, nil
+}
+
+// vertexOrErr returns the vertex with the given key, or
+// ErrNoSuchVertex if there is none.
+func (s *state) vertexOrErr(key []byte) (*vertex, error) {
+	v, err := s.vertex(key)
+	if err == nil && v == nil {
+		err = ErrNoSuchVertex
+	}
+	return v, err
+}
+
+func (v *vertex) write() error {
+	value, err := v.adj.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return v.objRef.Set(value)
+}
+
+// edge returns the position of the edge to key among the out edges of
+// v, and whether there is one.
+func (v *vertex) edge(key []byte) (int, bool) {
+	out := v.adj.Out
+	idx := sort.Search(len(out), func(i int) bool { return bytes.Compare(out[i].To, key) >= 0 })
+	return idx, idx < len(out) && bytes.Equal(out[idx].To, key)
+}
+
+// setEdge adds or replaces the out edge to key.
+func (v *vertex) setEdge(key, props []byte) {
+	idx, found := v.edge(key)
+	if !found {
+		v.adj.Out = append(v.adj.Out, mp.Edge{})
+		copy(v.adj.Out[idx+1:], v.adj.Out[idx:])
+	}
+	v.adj.Out[idx] = mp.Edge{To: key, Props: props}
+}
+
+// removeEdge removes the out edge to key, returning whether there was
+// one.
+func (v *vertex) removeEdge(key []byte) bool {
+	idx, found := v.edge(key)
+	if found {
+		v.adj.Out = append(v.adj.Out[:idx], v.adj.Out[idx+1:]...)
+	}
+	return found
+}
+
+// in returns the position of key among the in edges of v, and whether
+// it is there.
+func (v *vertex) in(key []byte) (int, bool) {
+	in := v.adj.In
+	idx := sort.Search(len(in), func(i int) bool { return bytes.Compare(in[i], key) >= 0 })
+	return idx, idx < len(in) && bytes.Equal(in[idx], key)
+}
+
+// Add a vertex with the given key and properties to the Graph. If the
+// vertex already exists, its properties are replaced and its edges
+// are kept.
+func (g *Graph) AddVertex(key, props []byte) error {
+	_, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		v, err := s.vertex(key)
+		if err != nil {
+			return nil, err
+		} else if v != nil {
+			v.adj.Props = props
+			return nil, v.write()
+		}
+		value, err := (&mp.Adjacency{Props: props}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.vertices.Put(key, objRef)
+	})
+	return err
+}
+
+// Returns the properties of the vertex with the given key, and whether
+// the vertex exists.
+func (g *Graph) Vertex(key []byte) ([]byte, bool, error) {
+	var props []byte
+	res, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		props = nil
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		v, err := s.vertex(key)
+		if err != nil || v == nil {
+			return false, err
+		}
+		props = v.adj.Props
+		return true, nil
+	})
+	if err == nil {
+		return props, res.(bool), nil
+	} else {
+		return nil, false, err
+	}
+}
+
+// Remove the vertex with the given key from the Graph, along with all
+// its edges. Removing a vertex which does not exist does nothing.
+func (g *Graph) RemoveVertex(key []byte) error {
+	_, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		v, err := s.vertex(key)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		// the other ends of the edges, less any loop.
+		var others [][]byte
+		for _, e := range v.adj.Out {
+			others = append(others, e.To)
+		}
+		others = append(others, v.adj.In...)
+		for _, other := range others {
+			if bytes.Equal(other, key) {
+				continue
+			}
+			o, err := s.vertexOrErr(other)
+			if err != nil {
+				return nil, err
+			}
+			o.removeEdge(key)
+			if idx, found := o.in(key); found {
+				o.adj.In = append(o.adj.In[:idx], o.adj.In[idx+1:]...)
+			}
+			if err = o.write(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, s.vertices.Remove(key)
+	})
+	return err
+}
+
+// Add an edge from the vertex from to the vertex to, with the given
+// properties, replacing the properties if the edge already exists.
+// ErrNoSuchVertex is returned if either vertex does not exist.
+func (g *Graph) AddEdge(from, to, props []byte) error {
+	_, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		f, err := s.vertexOrErr(from)
+		if err != nil {
+			return nil, err
+		}
+		t := f
+		if !bytes.Equal(from, to) {
+			if t, err = s.vertexOrErr(to); err != nil {
+				return nil, err
+			}
+		}
+		f.setEdge(to, props)
+		if s.root.Directed {
+			if idx, found := t.in(from); !found {
+				t.adj.In = append(t.adj.In, nil)
+				copy(t.adj.In[idx+1:], t.adj.In[idx:])
+				t.adj.In[idx] = from
+			}
+		} else {
+			t.setEdge(from, props)
+		}
+		if err = f.write(); err != nil {
+			return nil, err
+		} else if t != f {
+			err = t.write()
+		}
+		return nil, err
+	})
+	return err
+}
+
+// Remove the edge from the vertex from to the vertex to, returning
+// whether there was one. In an undirected Graph, this is the same as
+// removing the edge from to to from.
+func (g *Graph) RemoveEdge(from, to []byte) (bool, error) {
+	res, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		f, err := s.vertex(from)
+		if err != nil || f == nil || !f.removeEdge(to) {
+			return false, err
+		}
+		t := f
+		if !bytes.Equal(from, to) {
+			if t, err = s.vertexOrErr(to); err != nil {
+				return nil, err
+			}
+		}
+		if s.root.Directed {
+			if idx, found := t.in(from); found {
+				t.adj.In = append(t.adj.In[:idx], t.adj.In[idx+1:]...)
+			}
+		} else {
+			t.removeEdge(from)
+		}
+		if err = f.write(); err != nil {
+			return nil, err
+		} else if t != f {
+			err = t.write()
+		}
+		return true, err
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the neighbours of the vertex with the given key, in the
+// given direction, in increasing order of key, or ErrNoSuchVertex if
+// there is no such vertex. Only the adjacency Object of the vertex is
+// read, other than for the properties of In edges of a directed
+// Graph, which are held by the other vertex. With Both, a vertex with
+// edges in both directions is returned twice, with Out edges first.
+func (g *Graph) Neighbours(key []byte, dir Direction) ([]Neighbour, error) {
+	res, _, err := g.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := g.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		v, err := s.vertexOrErr(key)
+		if err != nil {
+			return nil, err
+		}
+		return s.neighbours(v, key, dir)
+	})
+	if err == nil {
+		return res.([]Neighbour), nil
+	} else {
+		return nil, err
+	}
+}
+
+func (s *state) neighbours(v *vertex, key []byte, dir Direction) ([]Neighbour, error) {
+	var result []Neighbour
+	if !s.root.Directed || dir != In {
+		for _, e := range v.adj.Out {
+			result = append(result, Neighbour{Key: e.To, Props: e.Props})
+		}
+	}
+	if s.root.Directed && dir != Out {
+		for _, from := range v.adj.In {
+			f, err := s.vertexOrErr(from)
+			if err != nil {
+				return nil, err
+			}
+			idx, found := f.edge(key)
+			if !found {
+				return nil, fmt.Errorf("Graph vertex %v is corrupt", v.objRef)
+			}
+			result = append(result, Neighbour{Key: from, Props: f.adj.Out[idx].Props})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"strings"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, directed bool) *Graph {
+	c0 := th.CreateConnections(1)[0]
+	g, err := NewEmptyGraph(c0.Connection, directed)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return g
+}
+
+func addVertices(th *tests.TestHelper, g *Graph, keys ...string) {
+	for _, key := range keys {
+		if err := g.AddVertex([]byte(key), []byte("v"+key)); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// addEdges adds an edge for each "from-to" pair, with properties of
+// the pair itself.
+func addEdges(th *tests.TestHelper, g *Graph, pairs ...string) {
+	for _, pair := range pairs {
+		ends := strings.Split(pair, "-")
+		if err := g.AddEdge([]byte(ends[0]), []byte(ends[1]), []byte(pair)); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// assertNeighbours checks the neighbours of key, formatted as
+// "key:props".
+func assertNeighbours(th *tests.TestHelper, g *Graph, key string, dir Direction, expected ...string) {
+	neighbours, err := g.Neighbours([]byte(key), dir)
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(neighbours))
+	for idx, n := range neighbours {
+		got[idx] = fmt.Sprintf("%s:%s", n.Key, n.Props)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Neighbours of %v (%v): expected %v. Got %v", key, dir, expected, got)
+	}
+}
+
+func TestDirected(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	g := createEmpty(th, true)
+	addVertices(th, g, "a", "b", "c")
+	if err := g.AddEdge([]byte("a"), []byte("z"), nil); err != ErrNoSuchVertex {
+		th.Fatalf("Expected ErrNoSuchVertex. Got %v", err)
+	}
+	addEdges(th, g, "a-b", "a-c", "c-a", "b-b")
+	assertNeighbours(th, g, "a", Out, "b:a-b", "c:a-c")
+	assertNeighbours(th, g, "a", In, "c:c-a")
+	assertNeighbours(th, g, "a", Both, "b:a-b", "c:a-c", "c:c-a")
+	assertNeighbours(th, g, "b", In, "a:a-b", "b:b-b")
+
+	if props, found, err := g.Vertex([]byte("c")); err != nil || !found || string(props) != "vc" {
+		th.Fatalf("Expected vertex c with props vc. Got %s %v %v", props, found, err)
+	}
+	if removed, err := g.RemoveEdge([]byte("b"), []byte("a")); err != nil || removed {
+		th.Fatalf("Expected no edge from b to a. Got %v %v", removed, err)
+	}
+	if removed, err := g.RemoveEdge([]byte("a"), []byte("b")); err != nil || !removed {
+		th.Fatalf("Expected to remove the edge from a to b. Got %v %v", removed, err)
+	}
+	assertNeighbours(th, g, "b", In, "b:b-b")
+
+	if err := g.RemoveVertex([]byte("c")); err != nil {
+		th.Fatal(err)
+	}
+	assertNeighbours(th, g, "a", Both)
+	if _, err := g.Neighbours([]byte("c"), Out); err != ErrNoSuchVertex {
+		th.Fatalf("Expected ErrNoSuchVertex. Got %v", err)
+	}
+	if _, found, err := g.Vertex([]byte("c")); err != nil || found {
+		th.Fatalf("Expected vertex c to be gone. Got %v %v", found, err)
+	}
+}
+
+func TestUndirected(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	g := createEmpty(th, false)
+	addVertices(th, g, "a", "b", "c")
+	addEdges(th, g, "a-b", "c-a", "c-c")
+	assertNeighbours(th, g, "a", Out, "b:a-b", "c:c-a")
+	assertNeighbours(th, g, "a", In, "b:a-b", "c:c-a")
+	assertNeighbours(th, g, "c", Both, "a:c-a", "c:c-c")
+	// adding again replaces the properties, in both directions.
+	if err := g.AddEdge([]byte("b"), []byte("a"), []byte("new")); err != nil {
+		th.Fatal(err)
+	}
+	assertNeighbours(th, g, "a", Out, "b:new", "c:c-a")
+	if removed, err := g.RemoveEdge([]byte("a"), []byte("c")); err != nil || !removed {
+		th.Fatalf("Expected to remove the edge between a and c. Got %v %v", removed, err)
+	}
+	assertNeighbours(th, g, "c", Out, "c:c-c")
+	if err := g.RemoveVertex([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	assertNeighbours(th, g, "b", Out)
+}
+
+// traverse runs t to completion, perTxn vertices at a time, resuming
+// from a Checkpoint after each, and returns the visits formatted as
+// "key@depth".
+func traverse(th *tests.TestHelper, t *Traversal, perTxn int) []string {
+	var got []string
+	for !t.Done() {
+		visits, err := t.Next(perTxn)
+		if err != nil {
+			th.Fatal(err)
+		} else if perTxn > 0 && len(visits) > perTxn {
+			th.Fatalf("Expected at most %v visits. Got %v", perTxn, len(visits))
+		}
+		for _, v := range visits {
+			got = append(got, fmt.Sprintf("%s@%d", v.Key, v.Depth))
+		}
+		checkpoint, err := t.Checkpoint()
+		if err != nil {
+			th.Fatal(err)
+		}
+		if t, err = t.Graph.ResumeTraversal(checkpoint); err != nil {
+			th.Fatal(err)
+		}
+	}
+	return got
+}
+
+func TestTraverse(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	g := createEmpty(th, true)
+	addVertices(th, g, "a", "b", "c", "d", "e", "f")
+	addEdges(th, g, "a-b", "a-c", "b-d", "c-d", "d-e", "f-a")
+
+	for _, test := range []struct {
+		start    string
+		order    Order
+		dir      Direction
+		maxDepth int
+		expected string
+	}{
+		{"a", BreadthFirst, Out, 0, "[a@0 b@1 c@1 d@2 e@3]"},
+		{"a", DepthFirst, Out, 0, "[a@0 b@1 d@2 e@3 c@1]"},
+		{"a", BreadthFirst, Out, 2, "[a@0 b@1 c@1 d@2]"},
+		{"a", DepthFirst, Out, 1, "[a@0 b@1 c@1]"},
+		{"e", BreadthFirst, In, 0, "[e@0 d@1 b@2 c@2 a@3 f@4]"},
+		{"d", BreadthFirst, Both, 1, "[d@0 e@1 b@1 c@1]"},
+		{"z", BreadthFirst, Out, 0, "[]"},
+	} {
+		for _, perTxn := range []int{0, 1, 2} {
+			got := traverse(th, g.NewTraversal([]byte(test.start), test.order, test.dir, test.maxDepth), perTxn)
+			if fmt.Sprint(got) != test.expected {
+				th.Fatalf("Traversal from %v (%v, %v, %v) by %v: expected %v. Got %v",
+					test.start, test.order, test.dir, test.maxDepth, perTxn, test.expected, got)
+			}
+		}
+	}
+
+	// vertices removed before they are visited are skipped.
+	tr := g.NewTraversal([]byte("a"), BreadthFirst, Out, 0)
+	if _, err := tr.Next(1); err != nil {
+		th.Fatal(err)
+	}
+	if err := g.RemoveVertex([]byte("b")); err != nil {
+		th.Fatal(err)
+	}
+	if got := fmt.Sprint(traverse(th, tr, 1)); got != "[c@1 d@2 e@3]" {
+		th.Fatalf("Traversal after removal: expected [c@1 d@2 e@3]. Got %v", got)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	g := createEmpty(th, true)
+	addVertices(th, g, "a", "b", "c", "d", "e", "f")
+	addEdges(th, g, "a-b", "b-c", "c-d", "d-e", "a-d", "f-a")
+
+	for _, test := range []struct {
+		from, to string
+		dir      Direction
+		maxDepth int
+		expected string
+	}{
+		{"a", "e", Out, 0, "[a d e]"},
+		{"a", "c", Out, 0, "[a b c]"},
+		{"a", "a", Out, 0, "[a]"},
+		{"e", "f", In, 0, "[e d a f]"},
+		{"e", "f", In, 2, "[]"},
+		{"a", "f", Out, 0, "[]"},
+		{"f", "e", Both, 3, "[f a d e]"},
+	} {
+		path, err := g.ShortestPath([]byte(test.from), []byte(test.to), test.dir, test.maxDepth, 1)
+		if err != nil {
+			th.Fatal(err)
+		}
+		got := make([]string, len(path))
+		for idx, key := range path {
+			got[idx] = string(key)
+		}
+		if fmt.Sprint(got) != test.expected {
+			th.Fatalf("ShortestPath from %v to %v (%v, %v): expected %v. Got %v",
+				test.from, test.to, test.dir, test.maxDepth, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Graph. Its single
+// reference is to the LHash from vertex key to adjacency Object.
+type Root struct {
+	Directed bool
+}
+
+// Adjacency is the value of the adjacency Object of a vertex.
+type Adjacency struct {
+	// The properties of the vertex.
+	Props []byte
+	// The edges from the vertex, in increasing order of To. For an
+	// undirected Graph, every edge of the vertex.
+	Out []Edge
+	// For a directed Graph, the vertices with edges to the vertex, in
+	// increasing order.
+	In [][]byte
+}
+
+type Edge struct {
+	To    []byte
+	Props []byte
+}
+
+// Checkpoint is the state of a Traversal between transactions.
+type Checkpoint struct {
+	Order     int64
+	Direction int64
+	MaxDepth  int64
+	// The vertices discovered but not yet visited, in the order they
+	// will be visited: from the front for breadth first, from the back
+	// for depth first.
+	Frontier []Visit
+	// For breadth first, every vertex discovered; for depth first,
+	// every vertex visited.
+	Seen []Visit
+}
+
+type Visit struct {
+	Key []byte
+	// The key of the vertex from which this vertex was discovered,
+	// empty for the start vertex.
+	Parent []byte
+	Depth  int64
+}
@@ -0,0 +1,1195 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Adjacency) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Props":
+			z.Props, err = dc.ReadBytes(z.Props)
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+		case "Out":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Out")
+				return
+			}
+			if cap(z.Out) >= int(zb0002) {
+				z.Out = (z.Out)[:zb0002]
+			} else {
+				z.Out = make([]Edge, zb0002)
+			}
+			for za0001 := range z.Out {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Out", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Out", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "To":
+						z.Out[za0001].To, err = dc.ReadBytes(z.Out[za0001].To)
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001, "To")
+							return
+						}
+					case "Props":
+						z.Out[za0001].Props, err = dc.ReadBytes(z.Out[za0001].Props)
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001, "Props")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001)
+							return
+						}
+					}
+				}
+			}
+		case "In":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "In")
+				return
+			}
+			if cap(z.In) >= int(zb0004) {
+				z.In = (z.In)[:zb0004]
+			} else {
+				z.In = make([][]byte, zb0004)
+			}
+			for za0002 := range z.In {
+				z.In[za0002], err = dc.ReadBytes(z.In[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "In", za0002)
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Adjacency) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Props"
+	err = en.Append(0x83, 0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Props)
+	if err != nil {
+		err = msgp.WrapError(err, "Props")
+		return
+	}
+	// write "Out"
+	err = en.Append(0xa3, 0x4f, 0x75, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Out)))
+	if err != nil {
+		err = msgp.WrapError(err, "Out")
+		return
+	}
+	for za0001 := range z.Out {
+		// map header, size 2
+		// write "To"
+		err = en.Append(0x82, 0xa2, 0x54, 0x6f)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Out[za0001].To)
+		if err != nil {
+			err = msgp.WrapError(err, "Out", za0001, "To")
+			return
+		}
+		// write "Props"
+		err = en.Append(0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Out[za0001].Props)
+		if err != nil {
+			err = msgp.WrapError(err, "Out", za0001, "Props")
+			return
+		}
+	}
+	// write "In"
+	err = en.Append(0xa2, 0x49, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.In)))
+	if err != nil {
+		err = msgp.WrapError(err, "In")
+		return
+	}
+	for za0002 := range z.In {
+		err = en.WriteBytes(z.In[za0002])
+		if err != nil {
+			err = msgp.WrapError(err, "In", za0002)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Adjacency) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Props"
+	o = append(o, 0x83, 0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+	o = msgp.AppendBytes(o, z.Props)
+	// string "Out"
+	o = append(o, 0xa3, 0x4f, 0x75, 0x74)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Out)))
+	for za0001 := range z.Out {
+		// map header, size 2
+		// string "To"
+		o = append(o, 0x82, 0xa2, 0x54, 0x6f)
+		o = msgp.AppendBytes(o, z.Out[za0001].To)
+		// string "Props"
+		o = append(o, 0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+		o = msgp.AppendBytes(o, z.Out[za0001].Props)
+	}
+	// string "In"
+	o = append(o, 0xa2, 0x49, 0x6e)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.In)))
+	for za0002 := range z.In {
+		o = msgp.AppendBytes(o, z.In[za0002])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Adjacency) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Props":
+			z.Props, bts, err = msgp.ReadBytesBytes(bts, z.Props)
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+		case "Out":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Out")
+				return
+			}
+			if cap(z.Out) >= int(zb0002) {
+				z.Out = (z.Out)[:zb0002]
+			} else {
+				z.Out = make([]Edge, zb0002)
+			}
+			for za0001 := range z.Out {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Out", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Out", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "To":
+						z.Out[za0001].To, bts, err = msgp.ReadBytesBytes(bts, z.Out[za0001].To)
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001, "To")
+							return
+						}
+					case "Props":
+						z.Out[za0001].Props, bts, err = msgp.ReadBytesBytes(bts, z.Out[za0001].Props)
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001, "Props")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Out", za0001)
+							return
+						}
+					}
+				}
+			}
+		case "In":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "In")
+				return
+			}
+			if cap(z.In) >= int(zb0004) {
+				z.In = (z.In)[:zb0004]
+			} else {
+				z.In = make([][]byte, zb0004)
+			}
+			for za0002 := range z.In {
+				z.In[za0002], bts, err = msgp.ReadBytesBytes(bts, z.In[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "In", za0002)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Adjacency) Msgsize() (s int) {
+	s = 1 + 6 + msgp.BytesPrefixSize + len(z.Props) + 4 + msgp.ArrayHeaderSize
+	for za0001 := range z.Out {
+		s += 1 + 3 + msgp.BytesPrefixSize + len(z.Out[za0001].To) + 6 + msgp.BytesPrefixSize + len(z.Out[za0001].Props)
+	}
+	s += 3 + msgp.ArrayHeaderSize
+	for za0002 := range z.In {
+		s += msgp.BytesPrefixSize + len(z.In[za0002])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Checkpoint) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Order":
+			z.Order, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Order")
+				return
+			}
+		case "Direction":
+			z.Direction, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Direction")
+				return
+			}
+		case "MaxDepth":
+			z.MaxDepth, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "MaxDepth")
+				return
+			}
+		case "Frontier":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Frontier")
+				return
+			}
+			if cap(z.Frontier) >= int(zb0002) {
+				z.Frontier = (z.Frontier)[:zb0002]
+			} else {
+				z.Frontier = make([]Visit, zb0002)
+			}
+			for za0001 := range z.Frontier {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Frontier", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Frontier", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Key":
+						z.Frontier[za0001].Key, err = dc.ReadBytes(z.Frontier[za0001].Key)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Key")
+							return
+						}
+					case "Parent":
+						z.Frontier[za0001].Parent, err = dc.ReadBytes(z.Frontier[za0001].Parent)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Parent")
+							return
+						}
+					case "Depth":
+						z.Frontier[za0001].Depth, err = dc.ReadInt64()
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Depth")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001)
+							return
+						}
+					}
+				}
+			}
+		case "Seen":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Seen")
+				return
+			}
+			if cap(z.Seen) >= int(zb0004) {
+				z.Seen = (z.Seen)[:zb0004]
+			} else {
+				z.Seen = make([]Visit, zb0004)
+			}
+			for za0002 := range z.Seen {
+				var zb0005 uint32
+				zb0005, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Seen", za0002)
+					return
+				}
+				for zb0005 > 0 {
+					zb0005--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Seen", za0002)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Key":
+						z.Seen[za0002].Key, err = dc.ReadBytes(z.Seen[za0002].Key)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Key")
+							return
+						}
+					case "Parent":
+						z.Seen[za0002].Parent, err = dc.ReadBytes(z.Seen[za0002].Parent)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Parent")
+							return
+						}
+					case "Depth":
+						z.Seen[za0002].Depth, err = dc.ReadInt64()
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Depth")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002)
+							return
+						}
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Checkpoint) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 5
+	// write "Order"
+	err = en.Append(0x85, 0xa5, 0x4f, 0x72, 0x64, 0x65, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Order)
+	if err != nil {
+		err = msgp.WrapError(err, "Order")
+		return
+	}
+	// write "Direction"
+	err = en.Append(0xa9, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Direction)
+	if err != nil {
+		err = msgp.WrapError(err, "Direction")
+		return
+	}
+	// write "MaxDepth"
+	err = en.Append(0xa8, 0x4d, 0x61, 0x78, 0x44, 0x65, 0x70, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.MaxDepth)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxDepth")
+		return
+	}
+	// write "Frontier"
+	err = en.Append(0xa8, 0x46, 0x72, 0x6f, 0x6e, 0x74, 0x69, 0x65, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Frontier)))
+	if err != nil {
+		err = msgp.WrapError(err, "Frontier")
+		return
+	}
+	for za0001 := range z.Frontier {
+		// map header, size 3
+		// write "Key"
+		err = en.Append(0x83, 0xa3, 0x4b, 0x65, 0x79)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Frontier[za0001].Key)
+		if err != nil {
+			err = msgp.WrapError(err, "Frontier", za0001, "Key")
+			return
+		}
+		// write "Parent"
+		err = en.Append(0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Frontier[za0001].Parent)
+		if err != nil {
+			err = msgp.WrapError(err, "Frontier", za0001, "Parent")
+			return
+		}
+		// write "Depth"
+		err = en.Append(0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Frontier[za0001].Depth)
+		if err != nil {
+			err = msgp.WrapError(err, "Frontier", za0001, "Depth")
+			return
+		}
+	}
+	// write "Seen"
+	err = en.Append(0xa4, 0x53, 0x65, 0x65, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Seen)))
+	if err != nil {
+		err = msgp.WrapError(err, "Seen")
+		return
+	}
+	for za0002 := range z.Seen {
+		// map header, size 3
+		// write "Key"
+		err = en.Append(0x83, 0xa3, 0x4b, 0x65, 0x79)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Seen[za0002].Key)
+		if err != nil {
+			err = msgp.WrapError(err, "Seen", za0002, "Key")
+			return
+		}
+		// write "Parent"
+		err = en.Append(0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Seen[za0002].Parent)
+		if err != nil {
+			err = msgp.WrapError(err, "Seen", za0002, "Parent")
+			return
+		}
+		// write "Depth"
+		err = en.Append(0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Seen[za0002].Depth)
+		if err != nil {
+			err = msgp.WrapError(err, "Seen", za0002, "Depth")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Checkpoint) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "Order"
+	o = append(o, 0x85, 0xa5, 0x4f, 0x72, 0x64, 0x65, 0x72)
+	o = msgp.AppendInt64(o, z.Order)
+	// string "Direction"
+	o = append(o, 0xa9, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendInt64(o, z.Direction)
+	// string "MaxDepth"
+	o = append(o, 0xa8, 0x4d, 0x61, 0x78, 0x44, 0x65, 0x70, 0x74, 0x68)
+	o = msgp.AppendInt64(o, z.MaxDepth)
+	// string "Frontier"
+	o = append(o, 0xa8, 0x46, 0x72, 0x6f, 0x6e, 0x74, 0x69, 0x65, 0x72)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Frontier)))
+	for za0001 := range z.Frontier {
+		// map header, size 3
+		// string "Key"
+		o = append(o, 0x83, 0xa3, 0x4b, 0x65, 0x79)
+		o = msgp.AppendBytes(o, z.Frontier[za0001].Key)
+		// string "Parent"
+		o = append(o, 0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+		o = msgp.AppendBytes(o, z.Frontier[za0001].Parent)
+		// string "Depth"
+		o = append(o, 0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+		o = msgp.AppendInt64(o, z.Frontier[za0001].Depth)
+	}
+	// string "Seen"
+	o = append(o, 0xa4, 0x53, 0x65, 0x65, 0x6e)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Seen)))
+	for za0002 := range z.Seen {
+		// map header, size 3
+		// string "Key"
+		o = append(o, 0x83, 0xa3, 0x4b, 0x65, 0x79)
+		o = msgp.AppendBytes(o, z.Seen[za0002].Key)
+		// string "Parent"
+		o = append(o, 0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+		o = msgp.AppendBytes(o, z.Seen[za0002].Parent)
+		// string "Depth"
+		o = append(o, 0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+		o = msgp.AppendInt64(o, z.Seen[za0002].Depth)
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Checkpoint) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Order":
+			z.Order, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Order")
+				return
+			}
+		case "Direction":
+			z.Direction, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Direction")
+				return
+			}
+		case "MaxDepth":
+			z.MaxDepth, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxDepth")
+				return
+			}
+		case "Frontier":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Frontier")
+				return
+			}
+			if cap(z.Frontier) >= int(zb0002) {
+				z.Frontier = (z.Frontier)[:zb0002]
+			} else {
+				z.Frontier = make([]Visit, zb0002)
+			}
+			for za0001 := range z.Frontier {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Frontier", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Frontier", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Key":
+						z.Frontier[za0001].Key, bts, err = msgp.ReadBytesBytes(bts, z.Frontier[za0001].Key)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Key")
+							return
+						}
+					case "Parent":
+						z.Frontier[za0001].Parent, bts, err = msgp.ReadBytesBytes(bts, z.Frontier[za0001].Parent)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Parent")
+							return
+						}
+					case "Depth":
+						z.Frontier[za0001].Depth, bts, err = msgp.ReadInt64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001, "Depth")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Frontier", za0001)
+							return
+						}
+					}
+				}
+			}
+		case "Seen":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Seen")
+				return
+			}
+			if cap(z.Seen) >= int(zb0004) {
+				z.Seen = (z.Seen)[:zb0004]
+			} else {
+				z.Seen = make([]Visit, zb0004)
+			}
+			for za0002 := range z.Seen {
+				var zb0005 uint32
+				zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Seen", za0002)
+					return
+				}
+				for zb0005 > 0 {
+					zb0005--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Seen", za0002)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Key":
+						z.Seen[za0002].Key, bts, err = msgp.ReadBytesBytes(bts, z.Seen[za0002].Key)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Key")
+							return
+						}
+					case "Parent":
+						z.Seen[za0002].Parent, bts, err = msgp.ReadBytesBytes(bts, z.Seen[za0002].Parent)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Parent")
+							return
+						}
+					case "Depth":
+						z.Seen[za0002].Depth, bts, err = msgp.ReadInt64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002, "Depth")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Seen", za0002)
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Checkpoint) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Int64Size + 10 + msgp.Int64Size + 9 + msgp.Int64Size + 9 + msgp.ArrayHeaderSize
+	for za0001 := range z.Frontier {
+		s += 1 + 4 + msgp.BytesPrefixSize + len(z.Frontier[za0001].Key) + 7 + msgp.BytesPrefixSize + len(z.Frontier[za0001].Parent) + 6 + msgp.Int64Size
+	}
+	s += 5 + msgp.ArrayHeaderSize
+	for za0002 := range z.Seen {
+		s += 1 + 4 + msgp.BytesPrefixSize + len(z.Seen[za0002].Key) + 7 + msgp.BytesPrefixSize + len(z.Seen[za0002].Parent) + 6 + msgp.Int64Size
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Edge) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "To":
+			z.To, err = dc.ReadBytes(z.To)
+			if err != nil {
+				err = msgp.WrapError(err, "To")
+				return
+			}
+		case "Props":
+			z.Props, err = dc.ReadBytes(z.Props)
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Edge) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "To"
+	err = en.Append(0x82, 0xa2, 0x54, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.To)
+	if err != nil {
+		err = msgp.WrapError(err, "To")
+		return
+	}
+	// write "Props"
+	err = en.Append(0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Props)
+	if err != nil {
+		err = msgp.WrapError(err, "Props")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Edge) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "To"
+	o = append(o, 0x82, 0xa2, 0x54, 0x6f)
+	o = msgp.AppendBytes(o, z.To)
+	// string "Props"
+	o = append(o, 0xa5, 0x50, 0x72, 0x6f, 0x70, 0x73)
+	o = msgp.AppendBytes(o, z.Props)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Edge) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "To":
+			z.To, bts, err = msgp.ReadBytesBytes(bts, z.To)
+			if err != nil {
+				err = msgp.WrapError(err, "To")
+				return
+			}
+		case "Props":
+			z.Props, bts, err = msgp.ReadBytesBytes(bts, z.Props)
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Edge) Msgsize() (s int) {
+	s = 1 + 3 + msgp.BytesPrefixSize + len(z.To) + 6 + msgp.BytesPrefixSize + len(z.Props)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Directed":
+			z.Directed, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Directed")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Directed"
+	err = en.Append(0x81, 0xa8, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Directed)
+	if err != nil {
+		err = msgp.WrapError(err, "Directed")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Directed"
+	o = append(o, 0x81, 0xa8, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x65, 0x64)
+	o = msgp.AppendBool(o, z.Directed)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Directed":
+			z.Directed, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Directed")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Root) Msgsize() (s int) {
+	s = 1 + 9 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Visit) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, err = dc.ReadBytes(z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		case "Parent":
+			z.Parent, err = dc.ReadBytes(z.Parent)
+			if err != nil {
+				err = msgp.WrapError(err, "Parent")
+				return
+			}
+		case "Depth":
+			z.Depth, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Depth")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Visit) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "Key"
+	err = en.Append(0x83, 0xa3, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Key)
+	if err != nil {
+		err = msgp.WrapError(err, "Key")
+		return
+	}
+	// write "Parent"
+	err = en.Append(0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Parent)
+	if err != nil {
+		err = msgp.WrapError(err, "Parent")
+		return
+	}
+	// write "Depth"
+	err = en.Append(0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Depth)
+	if err != nil {
+		err = msgp.WrapError(err, "Depth")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Visit) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "Key"
+	o = append(o, 0x83, 0xa3, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.Key)
+	// string "Parent"
+	o = append(o, 0xa6, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74)
+	o = msgp.AppendBytes(o, z.Parent)
+	// string "Depth"
+	o = append(o, 0xa5, 0x44, 0x65, 0x70, 0x74, 0x68)
+	o = msgp.AppendInt64(o, z.Depth)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Visit) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, bts, err = msgp.ReadBytesBytes(bts, z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		case "Parent":
+			z.Parent, bts, err = msgp.ReadBytesBytes(bts, z.Parent)
+			if err != nil {
+				err = msgp.WrapError(err, "Parent")
+				return
+			}
+		case "Depth":
+			z.Depth, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Depth")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Visit) Msgsize() (s int) {
+	s = 1 + 4 + msgp.BytesPrefixSize + len(z.Key) + 7 + msgp.BytesPrefixSize + len(z.Parent) + 6 + msgp.Int64Size
+	return
+}
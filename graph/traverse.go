@@ -0,0 +1,212 @@
+package graph
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/graph/msgpack"
+)
+
+// An Order is the order in which a Traversal visits vertices.
+type Order int
+
+const (
+	// Visit every vertex at each depth before any at the next.
+	BreadthFirst Order = iota
+	// Visit as deep as possible along each path before backtracking.
+	DepthFirst
+)
+
+// A Visit is a vertex visited by a Traversal.
+type Visit struct {
+	Key []byte
+	// The key of the vertex from which this vertex was reached, nil
+	// for the start vertex.
+	Parent []byte
+	// The number of edges from the start vertex along which this
+	// vertex was reached.
+	Depth int
+}
+
+// A Traversal visits the vertices reachable from a start vertex,
+// following edges in the given Direction. A traversal of a large
+// Graph cannot be done in a single transaction, so Next visits a
+// bounded number of vertices per transaction, and the Traversal holds
+// the frontier of vertices discovered but not yet visited between
+// transactions. The frontier can be saved with Checkpoint, and the
+// Traversal resumed later, even by another process, with
+// ResumeTraversal.
+//
+// As each transaction sees the Graph as it is then, a Traversal is not
+// a snapshot: vertices and edges added or removed during the
+// Traversal may or may not be seen. Vertices removed before they are
+// visited are skipped.
+type Traversal struct {
+	// The Graph being traversed.
+	Graph *Graph
+	state mp.Checkpoint
+	seen  map[string]bool
+}
+
+// Start a Traversal of the Graph, from the vertex with key start, in
+// the given Order and Direction, visiting vertices up to maxDepth
+// edges from start (max of 0 or less means no limit). In DepthFirst
+// order, the depth of a vertex is that of the path by which it was
+// first reached, which may not be the shortest. No transaction is run
+// until Next is called.
+func (g *Graph) NewTraversal(start []byte, order Order, dir Direction, maxDepth int) *Traversal {
+	t := &Traversal{
+		Graph: g,
+		state: mp.Checkpoint{
+			Order:     int64(order),
+			Direction: int64(dir),
+			MaxDepth:  int64(maxDepth),
+			Frontier:  []mp.Visit{{Key: start}},
+		},
+		seen: make(map[string]bool),
+	}
+	if order == BreadthFirst {
+		t.state.Seen = t.state.Frontier
+		t.seen[string(start)] = true
+	}
+	return t
+}
+
+// Resume a Traversal of the Graph from a Checkpoint.
+func (g *Graph) ResumeTraversal(checkpoint []byte) (*Traversal, error) {
+	t := &Traversal{Graph: g, seen: make(map[string]bool)}
+	if _, err := t.state.UnmarshalMsg(checkpoint); err != nil {
+		return nil, err
+	}
+	for _, v := range t.state.Seen {
+		t.seen[string(v.Key)] = true
+	}
+	return t, nil
+}
+
+// Returns the state of the Traversal, from which it can be resumed
+// with ResumeTraversal. The state includes every vertex seen so far.
+func (t *Traversal) Checkpoint() ([]byte, error) {
+	return t.state.MarshalMsg(nil)
+}
+
+// Returns true once every reachable vertex has been visited.
+func (t *Traversal) Done() bool {
+	return len(t.state.Frontier) == 0
+}
+
+// Visit up to max more vertices (all of them if max is 0 or less), in
+// a single transaction, returning them in the order visited. No
+// vertices are returned once the Traversal is Done.
+func (t *Traversal) Next(max int) ([]Visit, error) {
+	var frontier, seen []mp.Visit
+	var added map[string]bool
+	res, _, err := t.Graph.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		// work on copies, as the transaction may restart.
+		frontier = append([]mp.Visit(nil), t.state.Frontier...)
+		seen = nil
+		added = make(map[string]bool)
+		isSeen := func(key []byte) bool { return t.seen[string(key)] || added[string(key)] }
+		markSeen := func(v mp.Visit) {
+			added[string(v.Key)] = true
+			seen = append(seen, v)
+		}
+		s, err := t.Graph.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var visits []Visit
+		for len(frontier) > 0 && (max <= 0 || len(visits) < max) {
+			var v mp.Visit
+			if Order(t.state.Order) == BreadthFirst {
+				v, frontier = frontier[0], frontier[1:]
+			} else {
+				v, frontier = frontier[len(frontier)-1], frontier[:len(frontier)-1]
+				if isSeen(v.Key) {
+					continue
+				}
+			}
+			vtx, err := s.vertex(v.Key)
+			if err != nil {
+				return nil, err
+			} else if vtx == nil {
+				continue
+			}
+			if Order(t.state.Order) == DepthFirst {
+				markSeen(v)
+			}
+			visits = append(visits, Visit{Key: v.Key, Parent: v.Parent, Depth: int(v.Depth)})
+			if t.state.MaxDepth > 0 && v.Depth >= t.state.MaxDepth {
+				continue
+			}
+			neighbours, err := s.neighbours(vtx, v.Key, Direction(t.state.Direction))
+			if err != nil {
+				return nil, err
+			}
+			if Order(t.state.Order) == DepthFirst {
+				// pushed in reverse, so visited in key order.
+				for idx := len(neighbours) - 1; idx >= 0; idx-- {
+					if n := neighbours[idx]; !isSeen(n.Key) {
+						frontier = append(frontier, mp.Visit{Key: n.Key, Parent: v.Key, Depth: v.Depth + 1})
+					}
+				}
+			} else {
+				for _, n := range neighbours {
+					if !isSeen(n.Key) {
+						next := mp.Visit{Key: n.Key, Parent: v.Key, Depth: v.Depth + 1}
+						markSeen(next)
+						frontier = append(frontier, next)
+					}
+				}
+			}
+		}
+		return visits, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.state.Frontier = frontier
+	t.state.Seen = append(t.state.Seen, seen...)
+	for key := range added {
+		t.seen[key] = true
+	}
+	return res.([]Visit), nil
+}
+
+// Returns the keys of the vertices along a path with the fewest edges
+// from the vertex from to the vertex to, following edges in the given
+// Direction, including both from and to, or nil if there is no such
+// path of at most maxDepth edges (max of 0 or less means no limit).
+// The search is breadth first, visiting up to perTxn vertices per
+// transaction (see Traversal.Next).
+func (g *Graph) ShortestPath(from, to []byte, dir Direction, maxDepth, perTxn int) ([][]byte, error) {
+	t := g.NewTraversal(from, BreadthFirst, dir, maxDepth)
+	for !t.Done() {
+		visits, err := t.Next(perTxn)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range visits {
+			if bytes.Equal(v.Key, to) {
+				return t.path(v), nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// path returns the keys from the start of the Traversal to v, by way
+// of the parents of the vertices seen.
+func (t *Traversal) path(v Visit) [][]byte {
+	parents := make(map[string][]byte, len(t.state.Seen))
+	for _, s := range t.state.Seen {
+		parents[string(s.Key)] = s.Parent
+	}
+	path := [][]byte{v.Key}
+	for key := v.Parent; key != nil; key = parents[string(key)] {
+		path = append(path, key)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
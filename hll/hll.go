@@ -0,0 +1,247 @@
+// An HLL is a HyperLogLog sketch, which estimates the number of
+// distinct items added to it, in a fixed amount of space, whatever
+// the number of items. With 2^p registers, the standard error of the
+// estimate is about 1.04/sqrt(2^p): about 1.6% for the default
+// precision of 12, which takes 4KB.
+//
+// The registers are split over a handful of chunk Objects, so an Add
+// rewrites at most one chunk, and only when it raises a register,
+// which becomes rarer as more items are added. Every HLL hashes items
+// the same way, so any two HLLs of the same precision can be merged.
+package hll
+
+import (
+	"errors"
+	"fmt"
+	hash "github.com/dchest/siphash"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/hll/msgpack"
+	"math"
+	"math/bits"
+)
+
+// The precision of a new HLL, if none is given.
+const DefaultPrecision = 12
+
+// The number of chunks a new HLL's registers are split into, unless it
+// has fewer registers.
+const chunkCount = 8
+
+// The siphash key shared by every HLL, so that they can be merged.
+const k0, k1 = 0x736f6d6570736575, 0x646f72616e646f6d
+
+// ErrPrecisionDiffers is returned by Merge if the two HLLs have
+// different precisions.
+var ErrPrecisionDiffers = errors.New("HLLs have different precisions")
+
+type HLL struct {
+	// The connection used to create this HLL object. As with LHash,
+	// you should not use the same HLL object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the HLL.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty HLL with 2^precision registers, where
+// precision is between 4 and 16 (DefaultPrecision if precision is 0).
+// This creates new GoshawkDB Objects and initialises them for use as
+// an HLL.
+func NewEmptyHLL(conn *client.Connection, precision int) (*HLL, error) {
+	if precision == 0 {
+		precision = DefaultPrecision
+	} else if precision < 4 || precision > 16 {
+		return nil, fmt.Errorf("HLL precision must be between 4 and 16. Got %v", precision)
+	}
+	registers := 1 << uint(precision)
+	chunkRegisters := registers / chunkCount
+	if chunkRegisters < 1 {
+		chunkRegisters = 1
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		chunks := make([]client.ObjectRef, registers/chunkRegisters)
+		for idx := range chunks {
+			chunkObj, err := txn.CreateObject(make([]byte, chunkRegisters))
+			if err != nil {
+				return nil, err
+			}
+			chunks[idx] = chunkObj
+		}
+		value, err := (&mp.Root{Precision: int64(precision), ChunkRegisters: int64(chunkRegisters)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, chunks...)
+		if err != nil {
+			return nil, err
+		}
+		return &HLL{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*HLL), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create an HLL object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func HLLFromObj(conn *client.Connection, objRef client.ObjectRef) *HLL {
+	return &HLL{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the HLL within a single transaction.
+type state struct {
+	root   *mp.Root
+	chunks []client.ObjectRef
+}
+
+func (h *HLL) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(h.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Precision < 4 || root.Precision > 16 || root.ChunkRegisters < 1 ||
+		int64(len(refs))*root.ChunkRegisters != 1<<uint(root.Precision) {
+		return nil, fmt.Errorf("HLL root %v is corrupt", obj)
+	}
+	return &state{root: root, chunks: refs}, nil
+}
+
+// chunk returns a copy of the registers of the idx'th chunk.
+func (s *state) chunk(idx int) ([]byte, error) {
+	value, err := s.chunks[idx].Value()
+	if err != nil {
+		return nil, err
+	} else if int64(len(value)) != s.root.ChunkRegisters {
+		return nil, fmt.Errorf("HLL chunk %v is corrupt", s.chunks[idx])
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Add item to the HLL. Adding the same item again has no effect.
+func (h *HLL) Add(item []byte) error {
+	_, _, err := h.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := h.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		// the top bits pick the register; the rest give the rank.
+		x := hash.Hash(k0, k1, item)
+		p := uint(s.root.Precision)
+		register := int64(x >> (64 - p))
+		rank := byte(bits.LeadingZeros64(x<<p|1<<(p-1)) + 1)
+		idx := int(register / s.root.ChunkRegisters)
+		registers, err := s.chunk(idx)
+		if err != nil {
+			return nil, err
+		}
+		if r := &registers[register%s.root.ChunkRegisters]; *r < rank {
+			*r = rank
+			return nil, s.chunks[idx].Set(registers)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns the estimated number of distinct items added to the HLL.
+// Every chunk is read.
+func (h *HLL) Estimate() (uint64, error) {
+	res, _, err := h.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := h.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		m := float64(int64(1) << uint(s.root.Precision))
+		sum, zeros := 0.0, 0
+		for idx := range s.chunks {
+			registers, err := s.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range registers {
+				sum += math.Ldexp(1, -int(r))
+				if r == 0 {
+					zeros++
+				}
+			}
+		}
+		var alpha float64
+		switch m {
+		case 16:
+			alpha = 0.673
+		case 32:
+			alpha = 0.697
+		case 64:
+			alpha = 0.709
+		default:
+			alpha = 0.7213 / (1 + 1.079/m)
+		}
+		estimate := alpha * m * m / sum
+		if estimate <= 2.5*m && zeros > 0 {
+			// linear counting is more accurate for small cardinalities.
+			estimate = m * math.Log(m/float64(zeros))
+		}
+		return uint64(estimate + 0.5), nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Merge other into h, so that h estimates the number of distinct
+// items added to either. Both HLLs must have the same precision. Only
+// the chunks of h which change are rewritten.
+func (h *HLL) Merge(other *HLL) error {
+	_, _, err := h.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := h.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		o, err := other.read(txn)
+		if err != nil {
+			return nil, err
+		} else if s.root.Precision != o.root.Precision {
+			return nil, ErrPrecisionDiffers
+		}
+		// the chunk sizes may differ, so merge register by register.
+		var otherRegisters []byte
+		for idx := range s.chunks {
+			registers, err := s.chunk(idx)
+			if err != nil {
+				return nil, err
+			}
+			changed := false
+			for rIdx := range registers {
+				register := int64(idx)*s.root.ChunkRegisters + int64(rIdx)
+				if register%o.root.ChunkRegisters == 0 {
+					if otherRegisters, err = o.chunk(int(register / o.root.ChunkRegisters)); err != nil {
+						return nil, err
+					}
+				}
+				if r := otherRegisters[register%o.root.ChunkRegisters]; r > registers[rIdx] {
+					registers[rIdx] = r
+					changed = true
+				}
+			}
+			if changed {
+				if err = s.chunks[idx].Set(registers); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
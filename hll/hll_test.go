@@ -0,0 +1,80 @@
+package hll
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"math"
+	"testing"
+)
+
+func assertEstimate(th *tests.TestHelper, h *HLL, expected int) {
+	estimate, err := h.Estimate()
+	if err != nil {
+		th.Fatal(err)
+	}
+	// the standard error at the default precision is 1.6%.
+	if math.Abs(float64(estimate)-float64(expected)) > 0.05*float64(expected)+1 {
+		th.Fatalf("Expected an estimate of about %v. Got %v", expected, estimate)
+	}
+}
+
+func TestAddEstimateMerge(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	a, err := NewEmptyHLL(conn, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	b, err := NewEmptyHLL(conn, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, a, 0)
+	// each item is added twice, to a, and the second half also to b.
+	for repeat := 0; repeat < 2; repeat++ {
+		for idx := 0; idx < 20000; idx++ {
+			item := []byte(fmt.Sprintf("item%v", idx))
+			if err = a.Add(item); err != nil {
+				th.Fatal(err)
+			}
+			if idx >= 10000 {
+				if err = b.Add(item); err != nil {
+					th.Fatal(err)
+				}
+			}
+		}
+	}
+	assertEstimate(th, a, 20000)
+	assertEstimate(th, b, 10000)
+
+	c, err := NewEmptyHLL(conn, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	for idx := 20000; idx < 30000; idx++ {
+		if err = c.Add([]byte(fmt.Sprintf("item%v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err = b.Merge(c); err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, b, 20000)
+	if err = a.Merge(b); err != nil {
+		th.Fatal(err)
+	}
+	assertEstimate(th, a, 30000)
+
+	small, err := NewEmptyHLL(conn, 4)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = a.Merge(small); err != ErrPrecisionDiffers {
+		th.Fatalf("Expected ErrPrecisionDiffers. Got %v", err)
+	}
+	if _, err = NewEmptyHLL(conn, 17); err == nil {
+		th.Fatal("Expected an error creating an HLL of precision 17")
+	}
+}
@@ -0,0 +1,14 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of an HLL. Its references are
+// to the chunks of the registers, in order. The value of each chunk
+// Object is the raw registers, one byte each: register i is byte
+// i%ChunkRegisters of chunk i/ChunkRegisters.
+type Root struct {
+	// The number of registers is 2 to the power of Precision.
+	Precision int64
+	// The number of registers in each chunk.
+	ChunkRegisters int64
+}
@@ -0,0 +1,186 @@
+// An Index is a secondary index over some primary collection: it maps
+// keys derived from each primary entry back to the primary keys of
+// the entries. Indexes are stored in an LHash, with each entry
+// pointing at a postings Object which holds the (msgpack encoded)
+// list of primary keys for that derived key.
+//
+// An Index does not observe the primary collection by itself: the
+// owner of the primary collection must call Insert and Delete from
+// within the same transactions that modify the primary collection,
+// which keeps the index consistent with the primary collection.
+package index
+
+import (
+	"bytes"
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/index/msgpack"
+	"goshawkdb.io/collections/linearhash"
+)
+
+// ErrDuplicate is returned when inserting into a unique Index would
+// map a derived key to a second primary key.
+var ErrDuplicate = errors.New("Derived key is already indexed for a different primary key")
+
+// An ExtractFunc derives the index keys from the primary key and
+// value of an entry. It may return no keys, in which case the entry is
+// not indexed.
+type ExtractFunc func(key, value []byte) ([][]byte, error)
+
+type Index struct {
+	// The LHash holding the index entries, from derived key to
+	// postings Object.
+	LHash *linearhash.LHash
+	// If Unique, each derived key may map to at most one primary key.
+	Unique  bool
+	extract ExtractFunc
+}
+
+// Create a brand new empty Index.
+func NewEmptyIndex(conn *client.Connection, unique bool, extract ExtractFunc) (*Index, error) {
+	lh, err := linearhash.NewEmptyLHash(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{
+		LHash:   lh,
+		Unique:  unique,
+		extract: extract,
+	}, nil
+}
+
+// Create an Index from an existing given GoshawkDB Object. As with
+// LHashFromObj, no initialisation is done. The unique flag and extract
+// function must be the same as used by all other users of the Index.
+func IndexFromObj(conn *client.Connection, objRef client.ObjectRef, unique bool, extract ExtractFunc) *Index {
+	return &Index{
+		LHash:   linearhash.LHashFromObj(conn, objRef),
+		Unique:  unique,
+		extract: extract,
+	}
+}
+
+// Index the primary entry with the given key and value. If the Index
+// is Unique and any of the derived keys is already indexed for a
+// different primary key, ErrDuplicate is returned, and as with any
+// error, the transaction is aborted.
+func (idx *Index) Insert(key, value []byte) error {
+	derived, err := idx.extract(key, value)
+	if err != nil {
+		return err
+	}
+	_, _, err = idx.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		for _, d := range derived {
+			objRef, postings, err := idx.postings(d)
+			if err != nil {
+				return nil, err
+			}
+			if indexOf(*postings, key) != -1 {
+				continue
+			} else if idx.Unique && len(*postings) != 0 {
+				return nil, ErrDuplicate
+			}
+			*postings = append(*postings, key)
+			value, err := postings.MarshalMsg(nil)
+			if err != nil {
+				return nil, err
+			}
+			if objRef == nil {
+				postingsObj, err := txn.CreateObject(value)
+				if err != nil {
+					return nil, err
+				}
+				err = idx.LHash.Put(d, postingsObj)
+			} else {
+				err = objRef.Set(value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Remove the index entries for the primary entry with the given key
+// and value. The value must be the same as was passed to Insert.
+func (idx *Index) Delete(key, value []byte) error {
+	derived, err := idx.extract(key, value)
+	if err != nil {
+		return err
+	}
+	_, _, err = idx.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		for _, d := range derived {
+			objRef, postings, err := idx.postings(d)
+			if err != nil {
+				return nil, err
+			}
+			pos := indexOf(*postings, key)
+			if pos == -1 {
+				continue
+			}
+			*postings = append((*postings)[:pos], (*postings)[pos+1:]...)
+			if len(*postings) == 0 {
+				err = idx.LHash.Remove(d)
+			} else {
+				var value []byte
+				if value, err = postings.MarshalMsg(nil); err == nil {
+					err = objRef.Set(value)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns the primary keys indexed under the given derived key.
+func (idx *Index) Lookup(derived []byte) ([][]byte, error) {
+	res, _, err := idx.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, postings, err := idx.postings(derived)
+		if err != nil {
+			return nil, err
+		}
+		return ([][]byte)(*postings), nil
+	})
+	if err == nil {
+		return res.([][]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the keys derived from the primary entry with the given key
+// and value: the keys under which Insert would index it.
+func (idx *Index) Derive(key, value []byte) ([][]byte, error) {
+	return idx.extract(key, value)
+}
+
+func (idx *Index) postings(derived []byte) (*client.ObjectRef, *mp.Postings, error) {
+	objRef, err := idx.LHash.Find(derived)
+	if err != nil || objRef == nil {
+		return nil, new(mp.Postings), err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, nil, err
+	}
+	postings := new(mp.Postings)
+	if _, err = postings.UnmarshalMsg(value); err != nil {
+		return nil, nil, err
+	}
+	return objRef, postings, nil
+}
+
+func indexOf(postings mp.Postings, key []byte) int {
+	for idx, k := range postings {
+		if bytes.Equal(k, key) {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,92 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+// indexes values by their first byte
+func firstByte(key, value []byte) ([][]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return [][]byte{value[:1]}, nil
+}
+
+func createEmpty(th *tests.TestHelper, unique bool) *Index {
+	c0 := th.CreateConnections(1)[0]
+	idx, err := NewEmptyIndex(c0.Connection, unique, firstByte)
+	if err != nil {
+		th.Fatal(err)
+		return nil
+	}
+	return idx
+}
+
+func assertLookup(th *tests.TestHelper, idx *Index, derived string, expected ...string) {
+	keys, err := idx.Lookup([]byte(derived))
+	if err != nil {
+		th.Fatal(err)
+	}
+	if len(keys) != len(expected) {
+		th.Fatal(fmt.Sprintf("Expected %v keys for %v. Got %v", len(expected), derived, len(keys)))
+	}
+	for i, key := range keys {
+		if !bytes.Equal(key, []byte(expected[i])) {
+			th.Fatal(fmt.Sprintf("Expected key %v for %v. Got %s", expected[i], derived, key))
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	idx := createEmpty(th, false)
+	for _, kv := range [][2]string{{"1", "apple"}, {"2", "avocado"}, {"3", "banana"}} {
+		if err := idx.Insert([]byte(kv[0]), []byte(kv[1])); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertLookup(th, idx, "a", "1", "2")
+	assertLookup(th, idx, "b", "3")
+	assertLookup(th, idx, "c")
+
+	if err := idx.Delete([]byte("1"), []byte("apple")); err != nil {
+		th.Fatal(err)
+	}
+	assertLookup(th, idx, "a", "2")
+}
+
+func TestUniqueIndex(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	idx := createEmpty(th, true)
+	if err := idx.Insert([]byte("1"), []byte("apple")); err != nil {
+		th.Fatal(err)
+	}
+	// re-inserting the same primary key is fine
+	if err := idx.Insert([]byte("1"), []byte("apple")); err != nil {
+		th.Fatal(err)
+	}
+	if err := idx.Insert([]byte("2"), []byte("avocado")); err != ErrDuplicate {
+		th.Fatal(fmt.Sprintf("Expected ErrDuplicate. Got %v", err))
+	}
+	assertLookup(th, idx, "a", "1")
+
+	// a failed insert aborts the whole transaction
+	_, _, err := idx.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := idx.Insert([]byte("3"), []byte("banana")); err != nil {
+			return nil, err
+		}
+		return nil, idx.Insert([]byte("4"), []byte("almond"))
+	})
+	if err != ErrDuplicate {
+		th.Fatal(fmt.Sprintf("Expected ErrDuplicate. Got %v", err))
+	}
+	assertLookup(th, idx, "b")
+}
@@ -0,0 +1,7 @@
+package msgpack
+
+//go:generate msgp
+
+// Postings is the value of an index entry: the primary keys which
+// map to the derived key of the entry.
+type Postings [][]byte
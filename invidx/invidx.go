@@ -0,0 +1,325 @@
+// An InvertedIndex maps tokens, such as the words of some text, to the
+// keys of the documents containing them, so that documents can be
+// found by the tokens they contain. Tokens are mapped to postings
+// Objects, holding the sorted keys of the documents containing them,
+// by one LHash; documents are mapped to the tokens they were indexed
+// with by another, so that a document can be removed without its
+// tokens being given again. The root Object references both LHashes.
+//
+// As with an Index, an InvertedIndex does not observe the collection
+// holding the documents: call Index and Remove from within the same
+// transactions which modify the documents, which keeps the
+// InvertedIndex consistent with them.
+package invidx
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/invidx/msgpack"
+	"goshawkdb.io/collections/linearhash"
+	"sort"
+)
+
+// An Op determines how Query combines the documents containing each
+// token.
+type Op int
+
+const (
+	// The documents containing every token.
+	And Op = iota
+	// The documents containing any of the tokens.
+	Or
+)
+
+type InvertedIndex struct {
+	// The connection used to create this InvertedIndex object. As with
+	// LHash, you should not use the same InvertedIndex object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying root Object in GoshawkDB, which references the
+	// LHash of tokens and then the LHash of documents.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty InvertedIndex. This creates new GoshawkDB
+// Objects and initialises them for use as an InvertedIndex.
+func NewEmptyInvertedIndex(conn *client.Connection) (*InvertedIndex, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		tokens, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(nil, tokens.ObjRef, docs.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &InvertedIndex{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*InvertedIndex), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create an InvertedIndex object from an existing given GoshawkDB
+// Object. As with LHashFromObj, no initialisation is done.
+func InvertedIndexFromObj(conn *client.Connection, objRef client.ObjectRef) *InvertedIndex {
+	return &InvertedIndex{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the InvertedIndex within a single transaction.
+type state struct {
+	txn    *client.Txn
+	tokens *linearhash.LHash
+	docs   *linearhash.LHash
+}
+
+func (ii *InvertedIndex) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(ii.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := obj.References()
+	if err != nil {
+		return nil, err
+	} else if len(refs) != 2 {
+		return nil, fmt.Errorf("InvertedIndex root %v is corrupt", obj)
+	}
+	return &state{
+		txn:    txn,
+		tokens: linearhash.LHashFromObj(ii.Conn, refs[0]),
+		docs:   linearhash.LHashFromObj(ii.Conn, refs[1]),
+	}, nil
+}
+
+// postings returns the postings Object of token, if there is one, and
+// its postings.
+func (s *state) postings(token []byte) (*client.ObjectRef, mp.Postings, error) {
+	objRef, err := s.tokens.Find(token)
+	if err != nil || objRef == nil {
+		return nil, nil, err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, nil, err
+	}
+	var postings mp.Postings
+	if _, err = postings.UnmarshalMsg(value); err != nil {
+		return nil, nil, err
+	}
+	return objRef, postings, nil
+}
+
+// docTokens returns the tokens Object of docKey, if there is one, and
+// its tokens.
+func (s *state) docTokens(docKey []byte) (*client.ObjectRef, mp.Tokens, error) {
+	objRef, err := s.docs.Find(docKey)
+	if err != nil || objRef == nil {
+		return nil, nil, err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, nil, err
+	}
+	var tokens mp.Tokens
+	if _, err = tokens.UnmarshalMsg(value); err != nil {
+		return nil, nil, err
+	}
+	return objRef, tokens, nil
+}
+
+// search returns the position of key in the sorted keys, and whether
+// it is there.
+func search(keys [][]byte, key []byte) (int, bool) {
+	idx := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i], key) >= 0 })
+	return idx, idx < len(keys) && bytes.Equal(keys[idx], key)
+}
+
+// post adds docKey to the postings of token.
+func (s *state) post(token, docKey []byte) error {
+	objRef, postings, err := s.postings(token)
+	if err != nil {
+		return err
+	}
+	idx, found := search(postings, docKey)
+	if found {
+		return nil
+	}
+	postings = append(postings, nil)
+	copy(postings[idx+1:], postings[idx:])
+	postings[idx] = docKey
+	value, err := postings.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if objRef != nil {
+		return objRef.Set(value)
+	}
+	postingsObj, err := s.txn.CreateObject(value)
+	if err != nil {
+		return err
+	}
+	return s.tokens.Put(token, postingsObj)
+}
+
+// unpost removes docKey from the postings of token.
+func (s *state) unpost(token, docKey []byte) error {
+	objRef, postings, err := s.postings(token)
+	if err != nil {
+		return err
+	}
+	idx, found := search(postings, docKey)
+	if !found {
+		return nil
+	} else if len(postings) == 1 {
+		return s.tokens.Remove(token)
+	}
+	postings = append(postings[:idx], postings[idx+1:]...)
+	value, err := postings.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return objRef.Set(value)
+}
+
+// Index the document with the given key under the given tokens,
+// replacing the tokens it was previously indexed under, if any. Only
+// the postings of tokens which are added or removed are rewritten.
+func (ii *InvertedIndex) Index(docKey []byte, tokens [][]byte) error {
+	_, _, err := ii.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ii.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		objRef, old, err := s.docTokens(docKey)
+		if err != nil {
+			return nil, err
+		}
+		var current mp.Tokens
+		for _, token := range tokens {
+			if idx, found := search(current, token); !found {
+				current = append(current, nil)
+				copy(current[idx+1:], current[idx:])
+				current[idx] = token
+			}
+		}
+		for _, token := range old {
+			if _, found := search(current, token); !found {
+				if err = s.unpost(token, docKey); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, token := range current {
+			if _, found := search(old, token); !found {
+				if err = s.post(token, docKey); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(current) == 0 {
+			if objRef == nil {
+				return nil, nil
+			}
+			return nil, s.docs.Remove(docKey)
+		}
+		value, err := current.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		if objRef != nil {
+			return nil, objRef.Set(value)
+		}
+		tokensObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.docs.Put(docKey, tokensObj)
+	})
+	return err
+}
+
+// Remove the document with the given key from the InvertedIndex. This
+// is the same as indexing it with no tokens.
+func (ii *InvertedIndex) Remove(docKey []byte) error {
+	return ii.Index(docKey, nil)
+}
+
+// Returns the keys of the documents containing all (with And) or any
+// (with Or) of the given tokens, in increasing order.
+func (ii *InvertedIndex) Query(tokens [][]byte, op Op) ([][]byte, error) {
+	res, _, err := ii.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ii.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var result [][]byte
+		for idx, token := range tokens {
+			_, postings, err := s.postings(token)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case idx == 0:
+				result = postings
+			case op == And:
+				result = intersect(result, postings)
+			default:
+				result = union(result, postings)
+			}
+			if op == And && len(result) == 0 {
+				break
+			}
+		}
+		return result, nil
+	})
+	if err == nil {
+		return res.([][]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// intersect returns the keys in both a and b, which are sorted.
+func intersect(a, b [][]byte) [][]byte {
+	var result [][]byte
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch c := bytes.Compare(a[i], b[j]); {
+		case c < 0:
+			i++
+		case c > 0:
+			j++
+		default:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		}
+	}
+	return result
+}
+
+// union returns the keys in either a or b, which are sorted.
+func union(a, b [][]byte) [][]byte {
+	result := make([][]byte, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch c := bytes.Compare(a[i], b[j]); {
+		case c < 0:
+			result = append(result, a[i])
+			i++
+		case c > 0:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
@@ -0,0 +1,74 @@
+package invidx
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"strings"
+	"testing"
+)
+
+func index(th *tests.TestHelper, ii *InvertedIndex, docKey, text string) {
+	var tokens [][]byte
+	for _, word := range strings.Fields(text) {
+		tokens = append(tokens, []byte(word))
+	}
+	if err := ii.Index([]byte(docKey), tokens); err != nil {
+		th.Fatal(err)
+	}
+}
+
+func assertQuery(th *tests.TestHelper, ii *InvertedIndex, text string, op Op, expected ...string) {
+	var tokens [][]byte
+	for _, word := range strings.Fields(text) {
+		tokens = append(tokens, []byte(word))
+	}
+	docKeys, err := ii.Query(tokens, op)
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(docKeys))
+	for idx, docKey := range docKeys {
+		got[idx] = string(docKey)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Query %q (%v): expected %v. Got %v", text, op, expected, got)
+	}
+}
+
+func TestIndexQueryRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	ii, err := NewEmptyInvertedIndex(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertQuery(th, ii, "quick", Or)
+	index(th, ii, "d3", "the quick brown fox")
+	index(th, ii, "d1", "the lazy dog")
+	index(th, ii, "d2", "the quick dog dog")
+
+	assertQuery(th, ii, "the", And, "d1", "d2", "d3")
+	assertQuery(th, ii, "quick dog", And, "d2")
+	assertQuery(th, ii, "quick dog", Or, "d1", "d2", "d3")
+	assertQuery(th, ii, "fox lazy", Or, "d1", "d3")
+	assertQuery(th, ii, "fox lazy", And)
+	assertQuery(th, ii, "cat", Or)
+
+	// reindexing replaces the document's tokens.
+	index(th, ii, "d3", "the slow brown cat")
+	assertQuery(th, ii, "quick", Or, "d2")
+	assertQuery(th, ii, "cat the", And, "d3")
+
+	if err = ii.Remove([]byte("d2")); err != nil {
+		th.Fatal(err)
+	}
+	assertQuery(th, ii, "quick", Or)
+	assertQuery(th, ii, "the dog", And, "d1")
+	// removing again does nothing.
+	if err = ii.Remove([]byte("d2")); err != nil {
+		th.Fatal(err)
+	}
+	assertQuery(th, ii, "the", And, "d1", "d3")
+}
@@ -0,0 +1,13 @@
+package msgpack
+
+//go:generate msgp
+
+// Postings is the value of the Object each token of an InvertedIndex
+// points at: the keys of the documents containing the token, in
+// increasing order.
+type Postings [][]byte
+
+// Tokens is the value of the Object each document of an InvertedIndex
+// points at: the distinct tokens the document was indexed with, so
+// that it can be removed without them being given again.
+type Tokens [][]byte
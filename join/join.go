@@ -0,0 +1,153 @@
+// A JoinIndex joins the entries of two primary collections, A and B,
+// such as LHashes or Tables, on a field they share: an entry of A is
+// joined to every entry of B with an equal value of the field, as with
+// a foreign key. ForEachJoined supplies the entries of B joined to an
+// entry of A, and ForEachJoinedReverse those of A joined to an entry
+// of B, each within a single transaction, rather than a lookup per
+// entry.
+//
+// The JoinIndex is an Index of each collection, from the values of the
+// shared field to the keys of the entries. As with an Index, the
+// JoinIndex does not observe the collections by itself: the owner of
+// each collection must call Insert and Delete on the JoinIndex's Index
+// of it, A or B, from within the same transactions that modify the
+// collection.
+package join
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/index"
+)
+
+// A Side is one of the collections joined by a JoinIndex. As the
+// functions cannot be stored in GoshawkDB, every user of a JoinIndex
+// must supply the same Sides.
+type Side struct {
+	// Returns the value of the entry with the given key, or nil if
+	// there is none, such as Table.Find or LHash.FindValue.
+	Find func(key []byte) ([]byte, error)
+	// Derives the values of the shared field from the key and value
+	// of an entry. An entry with several values is joined on each.
+	Extract index.ExtractFunc
+}
+
+type JoinIndex struct {
+	// The connection used to create this JoinIndex object. As with
+	// LHash, you should not use the same JoinIndex object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the JoinIndex.
+	ObjRef client.ObjectRef
+	// The Indexes of collections A and B, from the values of the
+	// shared field to the keys of the entries.
+	A, B  *index.Index
+	sides [2]Side
+}
+
+// Create a brand new empty JoinIndex of the collections a and b. This
+// creates new GoshawkDB Objects and initialises them for use as a
+// JoinIndex. Entries already in the collections must be inserted into
+// A and B before they are joined.
+func NewEmptyJoinIndex(conn *client.Connection, a, b Side) (*JoinIndex, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		idxA, err := index.NewEmptyIndex(conn, false, a.Extract)
+		if err != nil {
+			return nil, err
+		}
+		idxB, err := index.NewEmptyIndex(conn, false, b.Extract)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject([]byte{}, idxA.LHash.ObjRef, idxB.LHash.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &JoinIndex{Conn: conn, ObjRef: objRef, A: idxA, B: idxB, sides: [2]Side{a, b}}, nil
+	})
+	if err == nil {
+		return res.(*JoinIndex), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a JoinIndex object from an existing given GoshawkDB Object,
+// of the collections a and b. As with TableFromObj, the root is read,
+// to find the Indexes.
+func JoinIndexFromObj(conn *client.Connection, objRef client.ObjectRef, a, b Side) (*JoinIndex, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := obj.References()
+		if err != nil {
+			return nil, err
+		} else if len(refs) != 2 {
+			return nil, fmt.Errorf("JoinIndex root %v is corrupt", obj)
+		}
+		return &JoinIndex{
+			Conn:   conn,
+			ObjRef: obj,
+			A:      index.IndexFromObj(conn, refs[0], false, a.Extract),
+			B:      index.IndexFromObj(conn, refs[1], false, b.Extract),
+			sides:  [2]Side{a, b},
+		}, nil
+	})
+	if err == nil {
+		return res.(*JoinIndex), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Call f with the key and value of each entry of B joined to the entry
+// of A with the given key, if there is one. An entry of B joined on
+// several values of the field is supplied once for each. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case entries may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (ji *JoinIndex) ForEachJoined(keyA []byte, f func(keyB, valueB []byte) error) error {
+	return ji.forEachJoined(keyA, ji.sides[0], ji.B, ji.sides[1], f)
+}
+
+// Call f with the key and value of each entry of A joined to the entry
+// of B with the given key, as with ForEachJoined.
+func (ji *JoinIndex) ForEachJoinedReverse(keyB []byte, f func(keyA, valueA []byte) error) error {
+	return ji.forEachJoined(keyB, ji.sides[1], ji.A, ji.sides[0], f)
+}
+
+// forEachJoined calls f with each entry of the other side, indexed by
+// other, joined to the entry of from with the given key.
+func (ji *JoinIndex) forEachJoined(key []byte, from Side, other *index.Index, to Side, f func(key, value []byte) error) error {
+	_, _, err := ji.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := from.Find(key)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		derived, err := from.Extract(key, value)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range derived {
+			keys, err := other.Lookup(d)
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range keys {
+				v, err := to.Find(k)
+				if err != nil {
+					return nil, err
+				} else if v == nil {
+					return nil, fmt.Errorf("JoinIndex refers to missing key %q", k)
+				} else if err = f(k, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package join
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/collections/table"
+	"goshawkdb.io/tests"
+	"sort"
+	"testing"
+)
+
+// customers are joined on their keys, and orders, the values of which
+// are "customer:item", on their customers.
+func customerOf(key, value []byte) ([][]byte, error) {
+	return [][]byte{key}, nil
+}
+
+func orderCustomer(key, value []byte) ([][]byte, error) {
+	idx := bytes.IndexByte(value, ':')
+	if idx < 0 {
+		return nil, fmt.Errorf("Malformed order %q", value)
+	}
+	return [][]byte{value[:idx]}, nil
+}
+
+type fixture struct {
+	customers *linearhash.LHash
+	orders    *table.Table
+	ji        *JoinIndex
+}
+
+func (fx *fixture) addCustomer(th *tests.TestHelper, key, name string) {
+	_, _, err := fx.ji.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := fx.customers.PutValue([]byte(key), []byte(name)); err != nil {
+			return nil, err
+		}
+		return nil, fx.ji.A.Insert([]byte(key), []byte(name))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+func (fx *fixture) addOrder(th *tests.TestHelper, key, value string) {
+	_, _, err := fx.ji.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := fx.orders.Insert([]byte(key), []byte(value)); err != nil {
+			return nil, err
+		}
+		return nil, fx.ji.B.Insert([]byte(key), []byte(value))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+func (fx *fixture) removeOrder(th *tests.TestHelper, key string) {
+	_, _, err := fx.ji.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := fx.orders.Find([]byte(key))
+		if err != nil {
+			return nil, err
+		} else if err = fx.orders.Delete([]byte(key)); err != nil {
+			return nil, err
+		}
+		return nil, fx.ji.B.Delete([]byte(key), value)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+func joined(th *tests.TestHelper, forEach func([]byte, func(k, v []byte) error) error, key string) []string {
+	var got []string
+	err := forEach([]byte(key), func(k, v []byte) error {
+		got = append(got, fmt.Sprintf("%s=%s", k, v))
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestJoin(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	customers, err := linearhash.NewEmptyLHash(conn)
+	if err != nil {
+		th.Fatal(err)
+	}
+	orders, err := table.NewEmptyTable(conn)
+	if err != nil {
+		th.Fatal(err)
+	}
+	a := Side{Find: customers.FindValue, Extract: customerOf}
+	b := Side{Find: orders.Find, Extract: orderCustomer}
+	ji, err := NewEmptyJoinIndex(conn, a, b)
+	if err != nil {
+		th.Fatal(err)
+	}
+	fx := &fixture{customers: customers, orders: orders, ji: ji}
+	fx.addCustomer(th, "c1", "alice")
+	fx.addCustomer(th, "c2", "bob")
+	fx.addOrder(th, "o1", "c1:apples")
+	fx.addOrder(th, "o2", "c2:pears")
+	fx.addOrder(th, "o3", "c1:plums")
+
+	if got := fmt.Sprint(joined(th, ji.ForEachJoined, "c1")); got != "[o1=c1:apples o3=c1:plums]" {
+		th.Fatalf("Unexpected orders of c1: %v", got)
+	}
+	if got := fmt.Sprint(joined(th, ji.ForEachJoinedReverse, "o2")); got != "[c2=bob]" {
+		th.Fatalf("Unexpected customer of o2: %v", got)
+	}
+	if got := joined(th, ji.ForEachJoined, "c3"); len(got) != 0 {
+		th.Fatalf("Expected no orders of c3. Got %v", got)
+	}
+	// an order of a customer who does not exist joins nothing.
+	fx.addOrder(th, "o4", "c3:figs")
+	if got := joined(th, ji.ForEachJoinedReverse, "o4"); len(got) != 0 {
+		th.Fatalf("Expected no customer of o4. Got %v", got)
+	}
+
+	fx.removeOrder(th, "o1")
+	ji2, err := JoinIndexFromObj(conn, ji.ObjRef, a, b)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if got := fmt.Sprint(joined(th, ji2.ForEachJoined, "c1")); got != "[o3=c1:plums]" {
+		th.Fatalf("Unexpected orders of c1: %v", got)
+	}
+}
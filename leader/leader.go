@@ -0,0 +1,142 @@
+// An Election chooses a single leader from a number of candidates.
+// Candidates Campaign to become leader, which blocks until they are
+// elected; the leader then holds a lease, which it must renew by
+// campaigning again before the lease expires, and which it may give up
+// with Resign. Anyone may Observe the Election to learn of each change
+// of leadership.
+//
+// An Election is built on a dmutex.Mutex: the leader is the holder of
+// the Mutex, and each term of leadership is identified by the fencing
+// token of the leader's acquisition of the Mutex. Terms increase with
+// each new leader, so a deposed leader which does not yet know it has
+// lost its lease can be detected by those it acts upon.
+package leader
+
+import (
+	"bytes"
+	"context"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/dmutex"
+	"time"
+)
+
+// ErrNotLeader is returned by Resign if the given candidate is not the
+// leader.
+var ErrNotLeader = dmutex.ErrNotHolder
+
+type Election struct {
+	// The Mutex held by the leader.
+	Mutex *dmutex.Mutex
+}
+
+// A Leadership is a term of leadership of an Election, as observed by
+// Observe. Leader is nil if there is no leader, in which case Term is
+// 0.
+type Leadership struct {
+	Leader []byte
+	Term   uint64
+}
+
+// Create a brand new Election, with no leader.
+func NewElection(conn *client.Connection) (*Election, error) {
+	m, err := dmutex.NewMutex(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &Election{Mutex: m}, nil
+}
+
+// Create an Election from an existing given GoshawkDB Object. As with
+// LHashFromObj, no initialisation is done.
+func ElectionFromObj(conn *client.Connection, objRef client.ObjectRef) *Election {
+	return &Election{Mutex: dmutex.MutexFromObj(conn, objRef)}
+}
+
+// Campaign for candidate to become the leader, blocking until it is,
+// and returning the term of its leadership. The leadership lasts for
+// ttl unless renewed by campaigning again, which returns immediately
+// with the same term while candidate is still leader. A ttl of 0 or
+// less means the leadership lasts until Resign. As with
+// dmutex.Mutex.Lock, Campaign must not be called from within a
+// transaction.
+func (e *Election) Campaign(candidate []byte, ttl time.Duration) (uint64, error) {
+	return e.Mutex.Lock(candidate, ttl)
+}
+
+// Give up the leadership. ErrNotLeader is returned if candidate is not
+// the leader, which includes the case where its lease has expired.
+func (e *Election) Resign(candidate []byte) error {
+	return e.Mutex.Unlock(candidate)
+}
+
+// Returns the current Leadership.
+func (e *Election) Leader() (Leadership, error) {
+	leader, term, err := e.Mutex.Holder()
+	return Leadership{Leader: leader, Term: term}, err
+}
+
+// Observe the Election, delivering the current Leadership on the
+// returned channel, and then each new Leadership: whenever a new
+// leader is elected, and whenever the leader resigns or its lease
+// expires, leaving no leader.
+//
+// Changes are waited for with retry transactions, which block the
+// connection they run on, so conn should be a connection dedicated to
+// observing and not the connection of the Election. The expiry of a
+// lease is not a change to the Election, so while the leader holds a
+// lease, Observe instead sleeps until the lease is due to expire, and
+// then looks again.
+//
+// The channel is closed once ctx is done or if an error occurs. As
+// with LHash.Watch, cancellation is only noticed the next time the
+// Election changes, or a lease is due to expire.
+func (e *Election) Observe(ctx context.Context, conn *client.Connection) (<-chan Leadership, error) {
+	observer := ElectionFromObj(conn, e.Mutex.ObjRef)
+	current, err := observer.Leader()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Leadership, 1)
+	ch <- current
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			var expiry time.Time
+			res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+				leader, term, exp, err := observer.Mutex.Lease()
+				if err != nil {
+					return nil, err
+				}
+				expiry = exp
+				if term != current.Term || !bytes.Equal(leader, current.Leader) {
+					return &Leadership{Leader: leader, Term: term}, nil
+				} else if exp.IsZero() {
+					return client.Retry, nil
+				}
+				return (*Leadership)(nil), nil
+			})
+			if err != nil {
+				return
+			}
+			l := res.(*Leadership)
+			if l == nil {
+				// unchanged, at least until the lease expires.
+				timer := time.NewTimer(time.Until(expiry))
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+				continue
+			}
+			current = *l
+			select {
+			case ch <- current:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
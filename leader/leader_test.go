@@ -0,0 +1,86 @@
+package leader
+
+import (
+	"context"
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+func assertLeadership(th *tests.TestHelper, ch <-chan Leadership, leader string, term uint64) {
+	select {
+	case l, ok := <-ch:
+		if !ok {
+			th.Fatal("Observe channel closed")
+		} else if string(l.Leader) != leader || l.Term != term {
+			th.Fatalf("Expected leader %q in term %v. Got %q in %v", leader, term, l.Leader, l.Term)
+		}
+	case <-time.After(time.Second):
+		th.Fatalf("Expected leader %q in term %v. Got nothing", leader, term)
+	}
+}
+
+func TestCampaignObserve(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conns := th.CreateConnections(3)
+	e, err := NewElection(conns[0].Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := e.Observe(ctx, conns[2].Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertLeadership(th, ch, "", 0)
+
+	if term, err := e.Campaign([]byte("a"), 0); err != nil || term != 1 {
+		th.Fatalf("Expected term 1. Got %v %v", term, err)
+	}
+	assertLeadership(th, ch, "a", 1)
+
+	// b campaigns while a leads, and is elected once a resigns.
+	other := ElectionFromObj(conns[1].Connection, e.Mutex.ObjRef)
+	terms := make(chan uint64, 1)
+	go func() {
+		term, err := other.Campaign([]byte("b"), 30*time.Millisecond)
+		if err != nil {
+			term = 0
+		}
+		terms <- term
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err = e.Resign([]byte("b")); err != ErrNotLeader {
+		th.Fatalf("Expected ErrNotLeader. Got %v", err)
+	}
+	if err = e.Resign([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	if term := <-terms; term != 2 {
+		th.Fatalf("Expected term 2. Got %v", term)
+	}
+	// the resignation may or may not be observed before b is elected.
+	for {
+		select {
+		case l := <-ch:
+			if l.Term == 0 {
+				continue
+			} else if string(l.Leader) != "b" || l.Term != 2 {
+				th.Fatalf("Expected leader b in term 2. Got %q in %v", l.Leader, l.Term)
+			}
+		case <-time.After(time.Second):
+			th.Fatal("Expected leader b in term 2. Got nothing")
+		}
+		break
+	}
+	if l, err := e.Leader(); err != nil || string(l.Leader) != "b" || l.Term != 2 {
+		th.Fatalf("Expected leader b in term 2. Got %v %v", l, err)
+	}
+
+	// b does not renew its lease, so it expires.
+	assertLeadership(th, ch, "", 0)
+	cancel()
+}
@@ -0,0 +1,89 @@
+package linearhash
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	"time"
+)
+
+const (
+	// Splits more frequent than this are taken to mean the LHash is
+	// being written heavily.
+	adaptiveSplitInterval = time.Second
+	// The weight of the newest interval in the moving average of the
+	// time between splits.
+	adaptiveSplitWeight = 8
+)
+
+// Let the utilization threshold of the LHash adapt between min and
+// max. When splits are frequent, the LHash is growing quickly, so the
+// threshold falls towards min: splitting earlier keeps chains short
+// while the LHash is written heavily. When splits are rare, the
+// threshold rises towards max, packing entries more densely. The
+// threshold also falls while the average bucket chain is long. The
+// threshold is only reconsidered when a split occurs, so adaptation
+// costs no additional writes. Setting both min and max to 0 restores
+// the fixed threshold. The setting is stored in the root, so applies
+// to all users of the LHash.
+func (lh *LHash) SetAdaptiveUtilization(min, max float64) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetAdaptiveUtilization(min, max)
+	}
+	if !(min == 0 && max == 0) && !(0 < min && min <= max && max <= 1) {
+		return errors.New("Utilization bounds must satisfy 0 < min <= max <= 1")
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.MinUtilization == min && lh.root.MaxUtilization == max {
+			return nil, nil
+		}
+		lh.root.MinUtilization, lh.root.MaxUtilization = min, max
+		lh.root.Utilization = 0
+		lh.root.LastSplit, lh.root.SplitInterval = 0, 0
+		if max > 0 {
+			lh.root.Utilization = (min + max) / 2
+		}
+		return nil, lh.write()
+	})
+	return err
+}
+
+// adaptUtilization records a split at now and recomputes the
+// utilization threshold, if it is adaptive.
+func (lh *LHash) adaptUtilization(now int64) {
+	root := lh.root
+	if root.MaxUtilization <= 0 {
+		return
+	}
+	if root.LastSplit == 0 {
+		// no history yet, so start from the neutral interval.
+		root.SplitInterval = int64(adaptiveSplitInterval)
+	} else {
+		interval := now - root.LastSplit
+		if interval < 0 {
+			interval = 0
+		}
+		root.SplitInterval += (interval - root.SplitInterval) / adaptiveSplitWeight
+	}
+	root.LastSplit = now
+
+	// pressure towards the minimum: 1 for continuous splitting, 0.5
+	// at one split per adaptiveSplitInterval, approaching 0 as splits
+	// become rare.
+	pressure := float64(adaptiveSplitInterval) / float64(int64(adaptiveSplitInterval)+root.SplitInterval)
+	// an average chain of more than one bucket also adds pressure.
+	if chain := float64(root.BucketCount)/float64(lh.directoryLen()) - 1; chain > pressure {
+		pressure = chain
+	}
+	if pressure > 1 {
+		pressure = 1
+	}
+	root.Utilization = root.MaxUtilization - (root.MaxUtilization-root.MinUtilization)*pressure
+}
@@ -0,0 +1,65 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+)
+
+// Run fun in a transaction in which the root of the LHash is written
+// at most once, however many operations fun performs on batch, which
+// is the state of lh for the duration of the Batch. fun must perform
+// its operations on batch rather than lh: operations on lh would run
+// with separate state, unaware of the unwritten root. Normally
+// every operation which modifies the root (for example, a Put which
+// changes the size) encodes and sets the root afresh. Within a Batch,
+// such operations only modify the in-memory root, which is written
+// when fun returns successfully, or when Flush is called. This saves
+// repeatedly encoding the root, and the root is only written at all
+// if it actually changed. Batches may be nested, in which case the
+// root is written by the outermost. The result of fun is returned.
+func (lh *LHash) Batch(fun func(txn *client.Txn, batch *LHash) (interface{}, error)) (interface{}, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Batch(fun)
+	}
+	if lh.deferWrites {
+		res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+			return fun(txn, lh)
+		})
+		return res, err
+	}
+	lh.deferWrites = true
+	defer func() {
+		lh.deferWrites = false
+		lh.rootDirty = false
+	}()
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		// the transaction may restart, so start afresh each time.
+		lh.rootDirty = false
+		res, err := fun(txn, lh)
+		if err != nil {
+			return nil, err
+		}
+		return res, lh.Flush()
+	})
+	return res, err
+}
+
+// Write the root now if it has been modified within the current
+// Batch. Batch itself flushes when fun returns, so Flush is only
+// needed if fun also reads the root Object directly, and must be
+// called on the batch passed to fun. Outside a Batch, Flush does
+// nothing.
+func (lh *LHash) Flush() error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Flush()
+	}
+	if !lh.rootDirty {
+		return nil
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		lh.rootDirty = false
+		return nil, lh.writeRoot()
+	})
+	return err
+}
@@ -0,0 +1,94 @@
+package linearhash
+
+import (
+	"bytes"
+)
+
+// The head bucket of a chain of more than one bucket carries a Bloom
+// filter of the hashes of every key in the chain, so that looking up
+// an absent key can usually stop at the head rather than walking the
+// whole chain. Filters are maintained as keys are added, and rebuilt
+// when chains are split. Removing a key does not clear its bits, so
+// a filter may over-approximate the keys of its chain, but never
+// under-approximates them. Adding a key to a bucket other than the
+// head may also require the head to be written, to update its filter.
+// A head without a filter (for example, one written before filters
+// were introduced) is simply walked as before, and gains a filter
+// when its chain next grows.
+const (
+	bloomBytes  = 128
+	bloomHashes = 4
+)
+
+// bloomBits calls f with each of the bits of the filter for hash h.
+// All the keys in a chain share the low bits of their hashes, so h is
+// mixed before use.
+func bloomBits(h uint64, f func(byteIdx int, mask byte)) {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h1, h2 := uint32(h), uint32(h>>32)|1
+	for i := uint32(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % (bloomBytes * 8)
+		f(int(bit/8), 1<<(bit%8))
+	}
+}
+
+// bloomMayContain returns false only if b has a filter which shows
+// that no key with hash h is in its chain.
+func (b *bucket) bloomMayContain(h uint64) bool {
+	bloom := b.entries.Bloom
+	if len(bloom) != bloomBytes {
+		return true
+	}
+	found := true
+	bloomBits(h, func(byteIdx int, mask byte) {
+		found = found && bloom[byteIdx]&mask != 0
+	})
+	return found
+}
+
+// bloomAdd adds h to the filter of b, if b has a filter, and returns
+// true iff the filter changed.
+func (b *bucket) bloomAdd(h uint64) bool {
+	bloom := b.entries.Bloom
+	if len(bloom) != bloomBytes {
+		return false
+	}
+	changed := false
+	bloomBits(h, func(byteIdx int, mask byte) {
+		changed = changed || bloom[byteIdx]&mask == 0
+		bloom[byteIdx] |= mask
+	})
+	return changed
+}
+
+// rebuildBloom recomputes the filter of the head bucket b from the
+// keys of its chain, removing the filter if the chain has only one
+// bucket. It returns true iff the filter changed.
+func (b *bucket) rebuildBloom() (bool, error) {
+	bNext, err := b.next()
+	if err != nil {
+		return false, err
+	}
+	old := b.entries.Bloom
+	if bNext == nil {
+		b.entries.Bloom = old[:0]
+		return len(old) != 0, nil
+	}
+	bloom := make([]byte, bloomBytes)
+	for c := b; c != nil; c = bNext {
+		for idx := range c.entries.Keys {
+			if !c.isSlotEmpty(idx) {
+				bloomBits(c.hashAt(idx), func(byteIdx int, mask byte) {
+					bloom[byteIdx] |= mask
+				})
+			}
+		}
+		if bNext, err = c.next(); err != nil {
+			return false, err
+		}
+	}
+	b.entries.Bloom = bloom
+	return !bytes.Equal(old, bloom), nil
+}
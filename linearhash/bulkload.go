@@ -0,0 +1,224 @@
+package linearhash
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"math"
+	"time"
+)
+
+// An Entry is a key and the value Object it maps to.
+type Entry struct {
+	Key   []byte
+	Value client.ObjectRef
+}
+
+// ErrNotEmpty is returned by BulkLoad if the LHash already has
+// entries.
+var ErrNotEmpty = errors.New("LHash is not empty")
+
+// Load the given entries into an empty LHash. Rather than putting
+// each entry in turn, which would split bucket after bucket as the
+// LHash grows, BulkLoad works out how many buckets the LHash will
+// finally need, groups the entries by bucket, and writes every bucket
+// exactly once. If several entries have the same key, the last one
+// wins. All the entries are loaded in a single transaction, so for
+// very large loads the caller should be prepared for that transaction
+// to be large. ErrNotEmpty is returned if the LHash has any entries.
+func (lh *LHash) BulkLoad(entries []Entry) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.BulkLoad(entries)
+	}
+	loads := make([]loadEntry, len(entries))
+	for idx, e := range entries {
+		loads[idx] = loadEntry{key: e.Key, value: e.Value, e: entry{version: 1}}
+	}
+	return lh.load(loads)
+}
+
+// A loadEntry is an entry to be loaded by load, with its attributes.
+// The value of an inline entry is ignored.
+type loadEntry struct {
+	key   []byte
+	value client.ObjectRef
+	e     entry
+}
+
+// load is BulkLoad, but for entries with arbitrary attributes. If the
+// LHash has a maximum size, entries without access times are given the
+// current time.
+func (lh *LHash) load(entries []loadEntry) error {
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if size, err := lh.size(); err != nil {
+			return nil, err
+		} else if size != 0 {
+			return nil, ErrNotEmpty
+		}
+
+		// later entries replace earlier ones with the same key.
+		latest := make(map[string]int, len(entries))
+		for idx, e := range entries {
+			latest[string(e.key)] = idx
+		}
+		if lh.root.MaxSize > 0 && int64(len(latest)) > lh.root.MaxSize {
+			return nil, errors.New("BulkLoad would exceed the maximum size of the LHash")
+		}
+
+		// an empty LHash may still have locks, which will need to
+		// move to their new chains.
+		existing, err := lh.allBucketRefs()
+		if err != nil {
+			return nil, err
+		}
+		locks := make(map[string][]byte)
+		checkpoints := make([]int64, len(existing))
+		for idx, objRef := range existing {
+			head, err := lh.newBucket(objRef)
+			if err != nil {
+				return nil, err
+			}
+			for k, holder := range head.entries.Locks {
+				locks[k] = holder
+			}
+			checkpoints[idx] = head.entries.Checkpoint
+		}
+		if err = lh.shapeFor(int64(len(latest)), existing); err != nil {
+			return nil, err
+		}
+		chainLocks := make([]map[string][]byte, lh.directoryLen())
+		for k, holder := range locks {
+			bIdx := lh.root.BucketIndex(lh.hash([]byte(k)))
+			if chainLocks[bIdx] == nil {
+				chainLocks[bIdx] = make(map[string][]byte)
+			}
+			chainLocks[bIdx][k] = holder
+		}
+
+		chains := make([][]int, lh.directoryLen())
+		hashes := make([]uint64, len(entries))
+		for idx, e := range entries {
+			if latest[string(e.key)] != idx {
+				continue
+			}
+			hashes[idx] = lh.hash(e.key)
+			bIdx := lh.root.BucketIndex(hashes[idx])
+			chains[bIdx] = append(chains[bIdx], idx)
+		}
+
+		now := time.Now().UnixNano()
+		reverse := lh.reverseIndex()
+		capacity := lh.root.BucketCapacity()
+		for bIdx, chain := range chains {
+			// a chain of buckets, each holding up to capacity
+			// entries, with the head at the existing reference if
+			// there is one.
+			count := (len(chain) + capacity - 1) / capacity
+			if count == 0 {
+				count = 1
+			}
+			buckets := make([]*bucket, count)
+			for idx := range buckets {
+				var objRef client.ObjectRef
+				if idx == 0 && bIdx < len(existing) {
+					objRef = existing[bIdx]
+				} else if objRef, err = txn.CreateObject([]byte{}); err != nil {
+					return nil, err
+				}
+				buckets[idx] = lh.newEmptyBucket(objRef)
+				if idx == 0 && bIdx < len(existing) {
+					// the existing head may be shared with a
+					// checkpoint, in which case it is copied.
+					buckets[idx].entries.Checkpoint = checkpoints[bIdx]
+					buckets[idx].chain = uint64(bIdx)
+				}
+			}
+			buckets[0].entries.Locks = chainLocks[bIdx]
+			for slot, idx := range chain {
+				b := buckets[slot/capacity]
+				key, value, e := entries[idx].key, entries[idx].value, entries[idx].e
+				e.hash = hashes[idx]
+				if lh.root.MaxSize > 0 && e.access == 0 {
+					e.access = now
+				}
+				b.entries.Keys[slot%capacity] = key
+				b.setEntry(slot%capacity, e)
+				if e.inline != nil {
+					// inline entries refer to their own bucket.
+					b.refs = append(b.refs, b.objRef)
+				} else {
+					b.refs = append(b.refs, value)
+				}
+				if reverse != nil && e.inline == nil {
+					if err = reverse.reverseAdd(key, value); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for idx, b := range buckets[:count-1] {
+				b.refs[0] = buckets[idx+1].objRef
+			}
+			if _, err = buckets[0].rebuildBloom(); err != nil {
+				return nil, err
+			}
+			for _, b := range buckets {
+				if err = b.write(true); err != nil {
+					return nil, err
+				}
+			}
+			if err = lh.setBucketRef(uint64(bIdx), buckets[0].objRef); err != nil {
+				return nil, err
+			}
+			lh.root.BucketCount += int64(count)
+		}
+		lh.root.Size += int64(len(latest))
+		return nil, lh.write()
+	})
+	return err
+}
+
+// shapeFor replaces the bucket directory of an empty LHash, whose
+// current directory is existing, with one large enough for size
+// entries. Existing directory entries are kept where they fit, and
+// new directory entries are left to be filled in. BucketCount is reset
+// to 0, for the caller to count the buckets it writes.
+func (lh *LHash) shapeFor(size int64, existing []client.ObjectRef) error {
+	// the capacity of buckets depends on the number of chains, so use
+	// the largest capacity which is consistent with the resulting
+	// number of chains.
+	bucketsFor := func(capacity int) int64 {
+		buckets := int64(math.Ceil(float64(size) / (float64(capacity) * lh.root.Threshold())))
+		if buckets < 2 {
+			buckets = 2
+		}
+		return buckets
+	}
+	buckets := bucketsFor(mp.BucketCapacity)
+	for capacity := 2 * mp.BucketCapacity; int64(capacity) <= lh.root.MaxBucketCapacity; capacity *= 2 {
+		if candidate := bucketsFor(capacity); lh.root.BucketCapacityFor(uint64(candidate)) >= capacity {
+			buckets = candidate
+		}
+	}
+	low := uint64(1)
+	for low*2 <= uint64(buckets) {
+		low *= 2
+	}
+	lh.root.MaskLow = low - 1
+	lh.root.MaskHigh = low*2 - 1
+	lh.root.SplitIndex = uint64(buckets) - low
+	lh.root.BucketCount = 0
+	refs := make([]client.ObjectRef, buckets)
+	copy(refs, existing)
+	if lh.root.DirectoryPageSize == 0 {
+		lh.root.DirectoryPageSize = directoryPageSize
+	}
+	return lh.replaceDirectory(refs)
+}
@@ -0,0 +1,47 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+)
+
+// bucketCacheSize bounds the number of buckets cached by a single
+// operation.
+const bucketCacheSize = 256
+
+// bucketCache holds the buckets read or created by the current
+// operation, so that walking the same chain several times (for
+// example, Put searching a chain, then adding to it, then splitting
+// it) reads and decodes each bucket at most once. The cached buckets
+// reflect any changes made to them by the operation, whether or not
+// they have been written yet. The cache only lives as long as one
+// attempt of the outermost operation: see runTransaction.
+type bucketCache map[string]*bucket
+
+func cacheKey(objRef client.ObjectRef) string {
+	return string(*objRef.Id)
+}
+
+func (lh *LHash) cachedBucket(objRef client.ObjectRef) *bucket {
+	return lh.cache[cacheKey(objRef)]
+}
+
+func (lh *LHash) cacheBucket(b *bucket) {
+	if lh.pool.depth == 0 || len(lh.cache) >= bucketCacheSize {
+		return
+	}
+	if lh.cache == nil {
+		lh.cache = make(bucketCache)
+	}
+	lh.cache[cacheKey(b.objRef)] = b
+}
+
+func (lh *LHash) clearCache() {
+	for key := range lh.cache {
+		delete(lh.cache, key)
+	}
+	// the cached buckets keep the references of the buckets they were
+	// copied from, so the copies are forgotten with them.
+	for key := range lh.copies {
+		delete(lh.copies, key)
+	}
+}
@@ -0,0 +1,43 @@
+package linearhash
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	"goshawkdb.io/common"
+)
+
+// ErrReadOnly is returned by operations which would modify the LHash
+// when the LHash has been created from a reference to the root which
+// lacks the write capability.
+var ErrReadOnly = errors.New("LHash root reference does not grant write capability")
+
+// Returns, within txn, a reference to the root Object of the LHash
+// which grants only the read capability. The reference can be stored
+// in other Objects within the same transaction and so shared with
+// other accounts, which can then use LHashFromObj to Find, ForEach
+// and Size, but not Put or Remove: the write operations return
+// ErrReadOnly.
+//
+// Only the root is restricted. GoshawkDB capabilities are carried by
+// references, and the references from the root to the buckets are
+// stored with whatever capabilities the writer of the root had, so
+// the read-only reference is not a security boundary around the
+// buckets, and there is no value-append-only variant: it stops any
+// well-behaved client from modifying the LHash, no more. An account
+// which must not be able to modify the entries should be given a copy
+// of them rather than the LHash itself.
+func (lh *LHash) GrantReadOnly(txn *client.Txn) (client.ObjectRef, error) {
+	obj, err := txn.GetObject(lh.ObjRef)
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	return obj.GrantCapability(common.ReadOnlyCapability), nil
+}
+
+func (lh *LHash) checkWritable() error {
+	if lh.ObjRef.RefCapability().CanWrite() {
+		return nil
+	} else {
+		return ErrReadOnly
+	}
+}
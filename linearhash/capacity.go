@@ -0,0 +1,56 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+// Let the capacity of new buckets grow with the directory, up to
+// maxCapacity slots, which must be 0 (the default, for a fixed
+// capacity) or a power of two no less than the default capacity.
+// Larger buckets mean fewer bucket chains for the same number of
+// entries, keeping the directory of a very large LHash to a
+// manageable size, at the cost of larger bucket Objects. See
+// msgpack.Root.BucketCapacity for how the capacity grows. Existing
+// buckets keep their capacity until their chains are next split. The
+// setting is stored in the root, so applies to all users of the
+// LHash.
+func (lh *LHash) SetMaxBucketCapacity(maxCapacity int) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetMaxBucketCapacity(maxCapacity)
+	}
+	if maxCapacity != 0 && (maxCapacity < mp.BucketCapacity || maxCapacity&(maxCapacity-1) != 0) {
+		return fmt.Errorf("Maximum bucket capacity must be 0 or a power of two of at least %v", mp.BucketCapacity)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.MaxBucketCapacity == int64(maxCapacity) {
+			return nil, nil
+		}
+		lh.root.MaxBucketCapacity = int64(maxCapacity)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// grow extends b to capacity slots, returning true iff it had fewer.
+func (b *bucket) grow(capacity int) bool {
+	keys := b.entries.Keys
+	extra := capacity - len(keys)
+	if extra <= 0 {
+		return false
+	}
+	if len(b.entries.Hashes) == len(keys) {
+		b.entries.Hashes = append(b.entries.Hashes, make([]uint64, extra)...)
+	}
+	b.entries.Keys = append(keys, make([][]byte, extra)...)
+	return true
+}
@@ -0,0 +1,67 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+)
+
+// Set the maximum number of buckets in a bucket chain. Splits are
+// normally driven only by the overall utilization of the LHash, so a
+// chain which many keys happen to hash to can grow long, and
+// operations on those keys slow, whilst utilization stays below the
+// threshold. With a maximum chain length, a Put which grows a chain
+// beyond the maximum forces a split (or, when splits are deferred,
+// flags the root as needing one), even if utilization is below the
+// threshold.
+//
+// The maximum is not a bound on the length of a chain. Linear hashing
+// splits chains in a fixed order, so the forced split is of the chain
+// at the split index, not of the long chain, which is only split once
+// the split index reaches it: that may take as many splits as there
+// are chains, each Put which grows the long chain forcing one more.
+// Splitting a chain only separates keys whose hashes differ in the
+// next bit, so keys whose hashes agree in all the bits in use stay in
+// one chain however many splits occur. The maximum therefore shortens
+// chains which are long by chance, but is no defence against keys
+// chosen to collide: that defence is the hash key of the LHash, which
+// is random and secret. 0 disables the maximum. The setting is stored
+// in the root, so applies to all users of the LHash.
+func (lh *LHash) SetMaxChainLength(length int) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetMaxChainLength(length)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.MaxChainLength == int64(length) {
+			return nil, nil
+		}
+		lh.root.MaxChainLength = int64(length)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// chainTooLong returns true iff the chain with the given head has
+// more buckets than the maximum chain length allows.
+func (lh *LHash) chainTooLong(head *bucket) (bool, error) {
+	if lh.root.MaxChainLength <= 0 {
+		return false, nil
+	}
+	length := int64(0)
+	for b := head; b != nil; length++ {
+		if length >= lh.root.MaxChainLength {
+			return true, nil
+		}
+		var err error
+		if b, err = b.next(); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
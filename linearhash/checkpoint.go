@@ -0,0 +1,227 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+// Take a checkpoint of the LHash, in a single transaction, and return
+// a read-only reference to its root (see GrantReadOnly). Readers can
+// then scan the checkpoint, with LHashFromObj, as a stable image of
+// the LHash at the time of the checkpoint, whilst writers carry on
+// modifying the LHash itself.
+//
+// The checkpoint is a new root and directory which share the buckets
+// of the LHash, so taking one costs a reference per bucket chain,
+// however many entries there are. Thereafter each shared bucket is
+// copied the first time the LHash writes it, and the copy takes its
+// place in the LHash, leaving the original to the checkpoint. The
+// value Objects are shared too: a checkpoint is only stable if values
+// are replaced rather than modified in place, as PutValue does. The
+// checkpoint holds whatever expired entries and locks the buckets held
+// at the time, but not the reverse index. As taking a checkpoint
+// writes the root of the LHash, it needs the write capability.
+func (lh *LHash) Checkpoint() (client.ObjectRef, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Checkpoint()
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		size, err := lh.size()
+		if err != nil {
+			return nil, err
+		}
+		refs, err := lh.allBucketRefs()
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject([]byte{})
+		if err != nil {
+			return nil, err
+		}
+		checkpoint := LHashFromObj(lh.Conn, objRef)
+		checkpoint.root = lh.root.Clone()
+		checkpoint.k0, checkpoint.k1 = lh.k0, lh.k1
+		// without companions, the size is held in the root.
+		checkpoint.root.Size = size
+		checkpoint.root.Companions = nil
+		checkpoint.root.SizeStripes = 0
+		checkpoint.root.DirectoryPages = 0
+		if checkpoint.root.DirectoryPageSize == 0 {
+			checkpoint.root.DirectoryPageSize = directoryPageSize
+		}
+		if err = checkpoint.replaceDirectory(refs); err != nil {
+			return nil, err
+		}
+		if err = checkpoint.writeRoot(); err != nil {
+			return nil, err
+		}
+		// every existing bucket is now shared with the checkpoint.
+		lh.root.CheckpointEpoch++
+		if err = lh.write(); err != nil {
+			return nil, err
+		}
+		return checkpoint.GrantReadOnly(txn)
+	})
+	if err == nil {
+		return res.(client.ObjectRef), nil
+	} else {
+		return client.ObjectRef{}, err
+	}
+}
+
+// A bucketCopy is the copy made of a bucket shared with a checkpoint,
+// and the chain the bucket belongs to.
+type bucketCopy struct {
+	objRef client.ObjectRef
+	chain  uint64
+}
+
+// writeTarget returns the Object to which b is written. That is b
+// itself unless b is shared with a checkpoint, in which case it is a
+// copy, made the first time the current operation writes b. Buckets
+// keep the reference of the original for the rest of the operation,
+// so as to find each other in the cache, and writes translate their
+// references to the copies (see copiedRefs); relinkCopies links the
+// copies into their chains once the operation is done.
+func (b *bucket) writeTarget() (client.ObjectRef, error) {
+	key := cacheKey(b.objRef)
+	if c, found := b.copies[key]; found {
+		return c.objRef, nil
+	} else if b.entries.Checkpoint >= b.root.CheckpointEpoch {
+		return b.objRef, nil
+	}
+	res, _, err := b.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject([]byte{})
+	})
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	objRef := res.(client.ObjectRef)
+	if b.copies == nil {
+		b.LHash.copies = make(map[string]bucketCopy)
+	}
+	b.copies[key] = bucketCopy{objRef: objRef, chain: b.chain}
+	return objRef, nil
+}
+
+// copiedRefs returns refs with every reference to a bucket copied by
+// the current operation replaced by a reference to its copy.
+func (lh *LHash) copiedRefs(refs []client.ObjectRef) []client.ObjectRef {
+	if len(lh.copies) == 0 {
+		return refs
+	}
+	copied := make([]client.ObjectRef, len(refs))
+	for idx, objRef := range refs {
+		if c, found := lh.copies[cacheKey(objRef)]; found {
+			objRef = c.objRef
+		}
+		copied[idx] = objRef
+	}
+	return copied
+}
+
+// relinkCopies links the buckets copied by the current operation into
+// the chains and directory in place of the originals. Buckets written
+// after the buckets they link to were copied already link to the
+// copies; the others, as stored, are rewritten, and are themselves
+// copied if they are shared with a checkpoint, working back from the
+// end of each chain to its head.
+func (lh *LHash) relinkCopies(txn *client.Txn) error {
+	chains := make(map[uint64]bool)
+	for _, c := range lh.copies {
+		chains[c.chain] = true
+	}
+	relinked := false
+	for idx := range chains {
+		head, err := lh.bucketRef(idx)
+		if err != nil {
+			return err
+		}
+		if err = lh.relinkChain(txn, idx, head); err != nil {
+			return err
+		}
+		if c, found := lh.copies[cacheKey(head)]; found {
+			if err = lh.setBucketRef(idx, c.objRef); err != nil {
+				return err
+			}
+			relinked = true
+		}
+	}
+	if relinked {
+		return lh.writeRoot()
+	}
+	return nil
+}
+
+// relinkChain relinks the idx'th chain, as stored, whose head is head.
+func (lh *LHash) relinkChain(txn *client.Txn, idx uint64, head client.ObjectRef) error {
+	type link struct {
+		objRef client.ObjectRef
+		value  []byte
+		refs   []client.ObjectRef
+	}
+	var chain []link
+	objRef := lh.copiedRefs([]client.ObjectRef{head})[0]
+	for {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return err
+		}
+		chain = append(chain, link{objRef: obj, value: value, refs: refs})
+		if refs[0].ReferencesSameAs(obj) {
+			break
+		}
+		objRef = lh.copiedRefs(refs[:1])[0]
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		l := chain[i]
+		if _, found := lh.copies[cacheKey(l.refs[0])]; !found {
+			continue
+		}
+		entries, err := mp.DecodeBucket(l.value)
+		if err != nil {
+			return err
+		}
+		if entries.Checkpoint < lh.root.CheckpointEpoch {
+			// shared, so copy it too, in the current format.
+			err = lh.checkVersion(BucketObject, l.objRef, entries.Version, mp.BucketVersion)
+			if err != nil {
+				return err
+			}
+			copyRef, err := txn.CreateObject([]byte{})
+			if err != nil {
+				return err
+			}
+			if lh.copies == nil {
+				lh.copies = make(map[string]bucketCopy)
+			}
+			lh.copies[cacheKey(l.objRef)] = bucketCopy{objRef: copyRef, chain: idx}
+			entries.Version = mp.BucketVersion
+			entries.Checkpoint = lh.root.CheckpointEpoch
+			if lh.encoder == nil {
+				lh.encoder = mp.NewEncoder()
+			}
+			value, err := lh.encoder.EncodeBucket(nil, entries)
+			if err != nil {
+				return err
+			}
+			l.objRef, l.value = copyRef, value
+		}
+		if err = l.objRef.Set(l.value, lh.copiedRefs(l.refs)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
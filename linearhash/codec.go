@@ -0,0 +1,142 @@
+package linearhash
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// A ValueCodec converts between values of the caller's types and the
+// bytes stored as the values of entries. See Typed.
+type ValueCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v, which is a pointer, as for
+	// json.Unmarshal.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is a ValueCodec using encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgpCodec is a ValueCodec for types with msgp generated methods,
+// such as those of the msgpack packages of this repository: values
+// passed to Marshal must implement msgp.Marshaler, and to Unmarshal,
+// msgp.Unmarshaler.
+type MsgpCodec struct{}
+
+func (MsgpCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(msgp.Marshaler); ok {
+		return m.MarshalMsg(nil)
+	}
+	return nil, fmt.Errorf("MsgpCodec cannot marshal %T", v)
+}
+
+func (MsgpCodec) Unmarshal(data []byte, v interface{}) error {
+	if u, ok := v.(msgp.Unmarshaler); ok {
+		_, err := u.UnmarshalMsg(data)
+		return err
+	}
+	return fmt.Errorf("MsgpCodec cannot unmarshal into %T", v)
+}
+
+// A KeyCodec converts between multi-part keys, such as (tenant,
+// user), and the bytes used as the keys of entries. The encoding must
+// be unambiguous: distinct lists of fields must have distinct
+// encodings.
+type KeyCodec interface {
+	EncodeKey(fields ...[]byte) []byte
+	DecodeKey(key []byte) ([][]byte, error)
+}
+
+// TupleCodec is a KeyCodec which encodes each field as its length, as
+// a uvarint, followed by its bytes. Unlike joining the fields with a
+// separator, this is unambiguous whatever the fields contain. The
+// encoding of the first n fields of a key is a prefix of the encoding
+// of the whole key.
+type TupleCodec struct{}
+
+// ErrBadTuple is returned by TupleCodec.DecodeKey if the key is not
+// the encoding of a tuple.
+var ErrBadTuple = errors.New("Key is not an encoded tuple")
+
+func (TupleCodec) EncodeKey(fields ...[]byte) []byte {
+	length := 0
+	for _, field := range fields {
+		length += binary.MaxVarintLen64 + len(field)
+	}
+	key := make([]byte, 0, length)
+	var buf [binary.MaxVarintLen64]byte
+	for _, field := range fields {
+		key = append(key, buf[:binary.PutUvarint(buf[:], uint64(len(field)))]...)
+		key = append(key, field...)
+	}
+	return key
+}
+
+func (TupleCodec) DecodeKey(key []byte) ([][]byte, error) {
+	var fields [][]byte
+	for len(key) > 0 {
+		length, n := binary.Uvarint(key)
+		if n <= 0 || uint64(len(key)-n) < length {
+			return nil, ErrBadTuple
+		}
+		fields = append(fields, key[n:n+int(length)])
+		key = key[n+int(length):]
+	}
+	return fields, nil
+}
+
+// Typed provides operations on an LHash in terms of the caller's own
+// types, encoding and decoding values with a ValueCodec. Values are
+// stored as PutValue stores them: inline if small enough (see
+// SetInlineThreshold), otherwise in value Objects created by Put. A
+// Typed is as safe for concurrent use as its LHash.
+type Typed struct {
+	LHash *LHash
+	Codec ValueCodec
+}
+
+func NewTyped(lh *LHash, codec ValueCodec) *Typed {
+	return &Typed{LHash: lh, Codec: codec}
+}
+
+// Encode v and set it as the value of the entry for key.
+func (t *Typed) Put(key []byte, v interface{}) error {
+	value, err := t.Codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.LHash.PutValue(key, value)
+}
+
+// Decode the value of the entry for key into v, returning false, and
+// leaving v alone, if there is no entry.
+func (t *Typed) Find(key []byte, v interface{}) (bool, error) {
+	value, err := t.LHash.FindValue(key)
+	if err != nil || value == nil {
+		return false, err
+	}
+	return true, t.Codec.Unmarshal(value, v)
+}
+
+// Iterate over the entries, as ForEachValue does, supplying a decode
+// function which decodes the value of the entry into a pointer, so
+// that the caller can choose what to decode into and need not decode
+// entries it is not interested in.
+func (t *Typed) ForEach(f func(key []byte, decode func(v interface{}) error) error) error {
+	return t.LHash.ForEachValue(func(key []byte, value []byte) error {
+		return f(key, func(v interface{}) error {
+			return t.Codec.Unmarshal(value, v)
+		})
+	})
+}
@@ -0,0 +1,25 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+)
+
+// Companion Objects are additional Objects, such as the root of the
+// reverse index, which are referenced from the root after the bucket
+// directory and identified by name.
+
+func (lh *LHash) companion(name string) (client.ObjectRef, bool) {
+	for idx, companion := range lh.root.Companions {
+		if companion == name {
+			return lh.companions[idx], true
+		}
+	}
+	return client.ObjectRef{}, false
+}
+
+// addCompanion records the companion Object in the root. The caller
+// must write the root.
+func (lh *LHash) addCompanion(name string, objRef client.ObjectRef) {
+	lh.root.Companions = append(lh.root.Companions, name)
+	lh.companions = append(lh.companions, objRef)
+}
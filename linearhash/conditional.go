@@ -0,0 +1,75 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"time"
+)
+
+const anyVersion = -1
+
+// A ConflictError is returned by PutIfVersion when the current
+// version of the entry does not match the expected version.
+type ConflictError struct {
+	Key      []byte
+	Expected uint64
+	Actual   uint64
+}
+
+func (ce *ConflictError) Error() string {
+	return fmt.Sprintf("Version conflict for key %q: expected version %v, found version %v", ce.Key, ce.Expected, ce.Actual)
+}
+
+// Add or update the entry for the given key, but only if the current
+// version of the entry is expectedVersion, returning the new version
+// of the entry. Every Put which changes an entry increments its
+// version; an absent (or expired) entry has version 0. On a mismatch, a
+// *ConflictError is returned and the LHash is not modified. Combined
+// with FindVersion, this allows optimistic concurrency control
+// spanning several transactions.
+func (lh *LHash) PutIfVersion(key []byte, value client.ObjectRef, expectedVersion uint64) (uint64, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.PutIfVersion(key, value, expectedVersion)
+	}
+	return lh.put(key, value, nil, false, 0, int64(expectedVersion))
+}
+
+// As Find, but additionally returns the current version of the
+// entry. If the entry is absent, its version is 0.
+func (lh *LHash) FindVersion(key []byte) (*client.ObjectRef, uint64, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.FindVersion(key)
+	}
+	var version uint64
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		h, bIdx := lh.locate(key)
+		bucket, err := lh.head(bIdx)
+		if err != nil {
+			return nil, err
+		}
+		bFound, idx, err := bucket.findSlotHash(key, h)
+		if err != nil {
+			return nil, err
+		} else if bFound == nil || bFound.isExpired(idx, time.Now().UnixNano()) {
+			version = 0
+			return (*client.ObjectRef)(nil), nil
+		}
+		e := bFound.entryAt(idx)
+		if e.inline != nil {
+			return nil, ErrInlineValue
+		}
+		version = uint64(e.version)
+		return &bFound.refs[idx+1], nil
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), version, nil
+	} else {
+		return nil, 0, err
+	}
+}
@@ -0,0 +1,125 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/blob"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"time"
+)
+
+// The number of entries CopyAcross copies per transaction if no batch
+// size is given.
+const DefaultCopyBatch = 1000
+
+// Copy the LHash to a new LHash created with dst, which is normally a
+// connection to another cluster, and return the new LHash. As with
+// Dump and Restore, the copy has the same hash key and parameters,
+// values are copied by value, and an error is returned if any value
+// Object has references. Unlike Dump and Restore, the entries are
+// copied in batches of about batchSize entries (DefaultCopyBatch if
+// batchSize is not positive), each read in one transaction on the
+// LHash's connection and written in one on dst, so the copy need not
+// fit in a single transaction.
+//
+// Entries are read a bucket chain at a time, so entries put or removed
+// whilst the copy runs may or may not be copied: copy a Checkpoint to
+// copy a consistent image. The versions and access times of entries
+// are not preserved.
+func CopyAcross(lh *LHash, dst *client.Connection, batchSize int) (*LHash, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return CopyAcross(s, dst, batchSize)
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultCopyBatch
+	}
+	var header *mp.DumpHeader
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := lh.populate(); err != nil {
+			return nil, err
+		}
+		header = lh.dumpHeader()
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	copied, err := newFromHeader(dst, header)
+	if err != nil {
+		return nil, err
+	}
+	for chain := uint64(0); ; {
+		// the transaction may restart, so start afresh each time.
+		var entries []*mp.DumpEntry
+		next := chain
+		_, _, err = lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+			entries, next = entries[:0], chain
+			err := lh.populate()
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now().UnixNano()
+			for ; next < lh.directoryLen(); next++ {
+				if len(entries) >= batchSize {
+					return nil, nil
+				}
+				b, err := lh.head(next)
+				for ; err == nil && b != nil; b, err = b.next() {
+					for idx := range b.entries.Keys {
+						if b.isSlotEmpty(idx) || b.isExpired(idx, now) {
+							continue
+						}
+						de, err := lh.dumpEntry(txn, b, idx)
+						if err != nil {
+							return nil, err
+						}
+						entries = append(entries, de)
+					}
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = copied.putEntries(entries); err != nil {
+			return nil, err
+		}
+		if next == chain || len(entries) < batchSize {
+			return copied, nil
+		}
+		chain = next
+	}
+}
+
+// putEntries puts the dumped entries, in a single transaction.
+func (lh *LHash) putEntries(entries []*mp.DumpEntry) error {
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		for _, de := range entries {
+			var err error
+			switch {
+			case de.Inline:
+				// copy, ensuring the inline value is not nil.
+				_, err = lh.put(de.Key, client.ObjectRef{}, append([]byte{}, de.Value...), false, de.Expiry, anyVersion)
+			case de.Blob:
+				var indexObj client.ObjectRef
+				if indexObj, err = blob.Create(lh.Conn, de.Value, 0); err == nil {
+					_, err = lh.put(de.Key, indexObj, nil, true, de.Expiry, anyVersion)
+				}
+			default:
+				var valueObj client.ObjectRef
+				if valueObj, err = txn.CreateObject(de.Value); err == nil {
+					_, err = lh.put(de.Key, valueObj, nil, false, de.Expiry, anyVersion)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
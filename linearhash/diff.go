@@ -0,0 +1,128 @@
+package linearhash
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+	"time"
+)
+
+// A Change is a difference found by Diff.
+type Change int
+
+const (
+	// The key has an entry in b but not in a.
+	Added Change = iota
+	// The key has an entry in a but not in b.
+	Removed
+	// The key has an entry in both, with different values.
+	Changed
+)
+
+func (c Change) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Compare the entries of a and b, in a single transaction, calling f
+// with each key whose entry differs and how it differs, going from a
+// to b. Entries whose values are the same Object are the same;
+// otherwise the values are read and compared byte for byte, whether
+// they are stored inline, as value Objects or as blobs, so a and b
+// need not share value Objects. Expired entries are treated as absent.
+// This reads every entry of both, and every value which is not shared,
+// so is intended for verifying migrations and copies rather than for
+// regular use.
+//
+// a and b must use the same connection. As with ForEach, f is called
+// from within the transaction, which may restart, in which case keys
+// may be supplied again. An error returned by f stops the Diff and is
+// returned.
+func Diff(a, b *LHash, f func(key []byte, change Change) error) error {
+	if s := a.acquire(); s != a {
+		defer a.release(s)
+		return Diff(s, b, f)
+	}
+	if s := b.acquire(); s != b {
+		defer b.release(s)
+		return Diff(a, s, f)
+	}
+	_, _, err := a.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		// keys changed or removed are found from a, keys added from b.
+		err := a.diffFrom(txn, b, func(key []byte, x *bucket, xIdx int, y *bucket, yIdx int) error {
+			if y == nil {
+				return f(key, Removed)
+			} else if same, err := sameEntryValue(txn, x, xIdx, y, yIdx); err != nil || same {
+				return err
+			}
+			return f(key, Changed)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, b.diffFrom(txn, a, func(key []byte, x *bucket, xIdx int, y *bucket, yIdx int) error {
+			if y == nil {
+				return f(key, Added)
+			}
+			return nil
+		})
+	})
+	return err
+}
+
+// diffFrom calls f with the bucket and slot of every unexpired entry
+// of lh, and the bucket and slot holding the same key in other, if
+// there is one.
+func (lh *LHash) diffFrom(txn *client.Txn, other *LHash, f func(key []byte, x *bucket, xIdx int, y *bucket, yIdx int) error) error {
+	err := lh.populate()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+	for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+		b, err := lh.head(idx)
+		for ; err == nil && b != nil; b, err = b.next() {
+			for idx, k := range b.entries.Keys {
+				if b.isSlotEmpty(idx) || b.isExpired(idx, now) {
+					continue
+				}
+				otherB, otherIdx, err := other.lookup(k)
+				if err != nil {
+					return err
+				}
+				if err = f(k, b, idx, otherB, otherIdx); err != nil {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameEntryValue returns true iff the entries in slot xIdx of x and
+// slot yIdx of y have the same value.
+func sameEntryValue(txn *client.Txn, x *bucket, xIdx int, y *bucket, yIdx int) (bool, error) {
+	if !x.isInline(xIdx) && !y.isInline(yIdx) && x.isBlob(xIdx) == y.isBlob(yIdx) &&
+		x.refs[xIdx+1].ReferencesSameAs(y.refs[yIdx+1]) {
+		return true, nil
+	}
+	xValue, err := x.LHash.valueAt(txn, x, xIdx)
+	if err != nil {
+		return false, err
+	}
+	yValue, err := y.LHash.valueAt(txn, y, yIdx)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(xValue, yValue), nil
+}
@@ -0,0 +1,244 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+// The bucket directory (the references to the head of every bucket
+// chain) was originally held in the root Object itself, so every Put
+// which changed the size rewrote the whole directory, however large,
+// and the number of buckets was limited by the number of references
+// one Object can practically hold. The directory is now held in
+// directory page Objects, each holding up to DirectoryPageSize
+// references, leaving the root a small header which references the
+// pages and the companions. Pages are only read when a bucket they
+// reference is needed, and only rewritten when they change, that is
+// on splits and when a chain gets a new head.
+//
+// Roots in the original layout (and those with a single unbounded
+// directory page) are still read, and are converted the next time the
+// root is written.
+
+// directoryPageSize is the number of references held by each
+// directory page of a newly paged directory. It is a variable so that
+// tests can exercise paging with small directories.
+var directoryPageSize int64 = 1024
+
+// A dirPage is a page of the bucket directory. Pages are loaded
+// lazily, as a transaction reads the buckets they reference. In the
+// original layout, the single page is the root itself.
+type dirPage struct {
+	objRef client.ObjectRef
+	refs   []client.ObjectRef
+	loaded bool
+	dirty  bool
+}
+
+// setPages records the directory pages referenced by the root, none
+// of which have been loaded yet.
+func (lh *LHash) setPages(pages []client.ObjectRef) {
+	lh.dir = lh.dir[:0]
+	for _, objRef := range pages {
+		lh.dir = append(lh.dir, dirPage{objRef: objRef})
+	}
+}
+
+// setRootDirectory records a directory held in the root, in the
+// original layout.
+func (lh *LHash) setRootDirectory(refs []client.ObjectRef) {
+	lh.dir = append(lh.dir[:0], dirPage{refs: refs, loaded: true})
+}
+
+// directoryLen returns the number of bucket chains.
+func (lh *LHash) directoryLen() uint64 {
+	return lh.root.MaskLow + 1 + lh.root.SplitIndex
+}
+
+func (lh *LHash) pageOf(idx uint64) (page int, offset int) {
+	if size := uint64(lh.root.DirectoryPageSize); size > 0 {
+		return int(idx / size), int(idx % size)
+	}
+	return 0, int(idx)
+}
+
+func (lh *LHash) loadPage(p int) error {
+	if p >= len(lh.dir) {
+		return fmt.Errorf("LHash root %v is corrupt: directory page %v of %v needed", lh.ObjRef, p, len(lh.dir))
+	}
+	page := &lh.dir[p]
+	if page.loaded {
+		return nil
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(page.objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		header := new(mp.DirectoryPage)
+		if _, err = header.UnmarshalMsg(value); err != nil {
+			return nil, err
+		}
+		if err = lh.checkVersion(DirectoryPageObject, obj, header.Version, mp.DirectoryPageVersion); err != nil {
+			return nil, err
+		}
+		page.objRef = obj
+		// limit the capacity so that appending to the page cannot
+		// modify refs.
+		page.refs = refs[:len(refs):len(refs)]
+		page.loaded = true
+		return nil, nil
+	})
+	return err
+}
+
+// bucketRef returns the head of the idx'th bucket chain, loading its
+// directory page if necessary.
+func (lh *LHash) bucketRef(idx uint64) (client.ObjectRef, error) {
+	p, offset := lh.pageOf(idx)
+	if err := lh.loadPage(p); err != nil {
+		return client.ObjectRef{}, err
+	}
+	if refs := lh.dir[p].refs; offset < len(refs) {
+		return refs[offset], nil
+	}
+	return client.ObjectRef{}, fmt.Errorf("LHash root %v is corrupt: bucket %v is missing from the directory", lh.ObjRef, idx)
+}
+
+// head returns the head bucket of the idx'th bucket chain.
+func (lh *LHash) head(idx uint64) (*bucket, error) {
+	objRef, err := lh.bucketRef(idx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := lh.newBucket(objRef)
+	if err == nil {
+		b.chain = idx
+	}
+	return b, err
+}
+
+// setBucketRef sets the head of the idx'th bucket chain, which may be
+// one beyond the end of the directory. The caller must write the
+// root, which writes the directory.
+func (lh *LHash) setBucketRef(idx uint64, objRef client.ObjectRef) error {
+	p, offset := lh.pageOf(idx)
+	if p == len(lh.dir) {
+		res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+			return txn.CreateObject([]byte{})
+		})
+		if err != nil {
+			return err
+		}
+		lh.dir = append(lh.dir, dirPage{objRef: res.(client.ObjectRef), loaded: true})
+	} else if err := lh.loadPage(p); err != nil {
+		return err
+	}
+	page := &lh.dir[p]
+	if offset == len(page.refs) {
+		page.refs = append(page.refs, objRef)
+	} else {
+		page.refs[offset] = objRef
+	}
+	page.dirty = true
+	return nil
+}
+
+// allBucketRefs returns a copy of the whole directory.
+func (lh *LHash) allBucketRefs() ([]client.ObjectRef, error) {
+	refs := make([]client.ObjectRef, lh.directoryLen())
+	for idx := range refs {
+		objRef, err := lh.bucketRef(uint64(idx))
+		if err != nil {
+			return nil, err
+		}
+		refs[idx] = objRef
+	}
+	return refs, nil
+}
+
+// replaceDirectory replaces the whole directory with refs, paged by
+// the DirectoryPageSize of the root. Existing page Objects are reused
+// where possible.
+func (lh *LHash) replaceDirectory(refs []client.ObjectRef) error {
+	size := int(lh.root.DirectoryPageSize)
+	count := (len(refs) + size - 1) / size
+	if count == 0 {
+		count = 1
+	}
+	paged := lh.root.DirectoryPages > 0
+	pages := make([]dirPage, count)
+	for p := range pages {
+		if paged && p < len(lh.dir) {
+			pages[p].objRef = lh.dir[p].objRef
+		} else {
+			res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+				return txn.CreateObject([]byte{})
+			})
+			if err != nil {
+				return err
+			}
+			pages[p].objRef = res.(client.ObjectRef)
+		}
+		lo, hi := p*size, (p+1)*size
+		if hi > len(refs) {
+			hi = len(refs)
+		}
+		pages[p].refs = refs[lo:hi:hi]
+		pages[p].loaded = true
+		pages[p].dirty = true
+	}
+	lh.dir = pages
+	lh.root.DirectoryPages = int64(count)
+	return nil
+}
+
+// writeDirectory writes every directory page which has changed,
+// first converting the directory to pages of directoryPageSize
+// references if it is not already paged.
+func (lh *LHash) writeDirectory() error {
+	if lh.root.DirectoryPages == 0 || lh.root.DirectoryPageSize == 0 {
+		refs, err := lh.allBucketRefs()
+		if err != nil {
+			return err
+		}
+		lh.root.DirectoryPageSize = directoryPageSize
+		if err = lh.replaceDirectory(refs); err != nil {
+			return err
+		}
+	}
+	lh.root.DirectoryPages = int64(len(lh.dir))
+	var value []byte
+	for p := range lh.dir {
+		page := &lh.dir[p]
+		if !page.dirty {
+			continue
+		}
+		if value == nil {
+			var err error
+			if value, err = (&mp.DirectoryPage{Version: mp.DirectoryPageVersion}).MarshalMsg(nil); err != nil {
+				return err
+			}
+		}
+		if err := page.objRef.Set(value, page.refs...); err != nil {
+			return err
+		}
+		page.dirty = false
+	}
+	return nil
+}
+
+// pageRefs returns the references from the root to the directory
+// pages.
+func (lh *LHash) pageRefs() []client.ObjectRef {
+	refs := make([]client.ObjectRef, len(lh.dir))
+	for p := range lh.dir {
+		refs[p] = lh.dir[p].objRef
+	}
+	return refs
+}
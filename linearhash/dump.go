@@ -0,0 +1,488 @@
+package linearhash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/tinylib/msgp/msgp"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/blob"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"io"
+	"time"
+)
+
+// dumpMagic begins every dump, identifying the stream as a dump of an
+// LHash.
+const dumpMagic = "LHASHDMP"
+
+// ErrDumpCorrupt is returned by Restore if the stream is not a
+// complete dump of an LHash.
+var ErrDumpCorrupt = errors.New("Not a complete LHash dump")
+
+// Write a dump of the LHash to w, and return the number of entries
+// dumped. The dump records the hash key and parameters of the LHash
+// (its metadata, maximum size, size stripes, utilization, bucket
+// capacity, directory page size, and so on) and every unexpired entry
+// with its value and attributes, so that Restore can create an
+// equivalent LHash, for example as a backup or to clone an LHash into
+// another cluster. The format is described in the msgpack package,
+// and is versioned by mp.DumpVersion.
+//
+// Values are dumped by value: values held in value Objects and blobs
+// are read, and an error is returned if any other value Object has
+// references, which cannot be dumped. Locks, companions other than
+// the size stripes and the reverse index, and the state of adaptive
+// utilization are not dumped.
+//
+// As with ExportCSV, the entries are read in a single transaction and
+// only written to w once it has committed, so Dump should not be
+// called from within a transaction.
+func (lh *LHash) Dump(w io.Writer) (int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Dump(w)
+	}
+	count, _, err := lh.dump(w, false, 0)
+	return count, err
+}
+
+// ErrNoChangeTracking is returned by DumpSince if change tracking is
+// not enabled.
+var ErrNoChangeTracking = errors.New("LHash change tracking is not enabled: use EnableChangeTracking")
+
+// Enable change tracking, so that DumpSince can write incremental
+// dumps. Once enabled, every bucket written is stamped with the
+// current epoch, which is advanced by each DumpSince. Enabling change
+// tracking more than once does nothing.
+func (lh *LHash) EnableChangeTracking() error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.EnableChangeTracking()
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.ChangeEpoch > 0 {
+			return nil, nil
+		}
+		lh.root.ChangeEpoch = 1
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Write a dump of the LHash to w, as Dump does, but only dumping the
+// entries of buckets written since the dump which returned the token
+// since. The keys of all other entries are recorded, so that
+// RestoreIncremental can take them from earlier dumps. A since of 0
+// writes a full dump. Returns the token from which the next
+// incremental dump should follow, and the number of entries dumped.
+// ErrNoChangeTracking is returned if change tracking is not enabled
+// (see EnableChangeTracking).
+//
+// Finding the changed buckets reads every bucket, but only the values
+// of entries in changed buckets are read. Advancing the epoch writes
+// the root.
+func (lh *LHash) DumpSince(w io.Writer, since int64) (int64, int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.DumpSince(w, since)
+	}
+	count, token, err := lh.dump(w, true, since)
+	return token, count, err
+}
+
+// dump writes a dump, and if incremental is true, advances the change
+// epoch, returning the new epoch.
+func (lh *LHash) dump(w io.Writer, incremental bool, since int64) (int, int64, error) {
+	var header *mp.DumpHeader
+	var entries []*mp.DumpEntry
+	var keys [][]byte
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		// the transaction may restart, so start afresh each time.
+		entries, keys = entries[:0], keys[:0]
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		header = lh.dumpHeader()
+		epoch := lh.root.ChangeEpoch
+		if incremental {
+			if epoch == 0 {
+				return nil, ErrNoChangeTracking
+			} else if since > epoch {
+				return nil, fmt.Errorf("Dump token %v is ahead of the current epoch %v", since, epoch)
+			}
+			header.Since = since
+		}
+		now := time.Now().UnixNano()
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			b, err := lh.head(idx)
+			for ; err == nil && b != nil; b, err = b.next() {
+				changed := !incremental || since == 0 || b.entries.Epoch >= since
+				for idx, k := range b.entries.Keys {
+					if b.isSlotEmpty(idx) || b.isExpired(idx, now) {
+						continue
+					} else if !changed {
+						keys = append(keys, k)
+						continue
+					}
+					de, err := lh.dumpEntry(txn, b, idx)
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, de)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if incremental {
+			lh.root.ChangeEpoch = epoch + 1
+			header.Epoch = lh.root.ChangeEpoch
+			return nil, lh.write()
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = writeDump(w, header, entries, keys); err != nil {
+		return 0, 0, err
+	}
+	return len(entries), header.Epoch, nil
+}
+
+func writeDump(w io.Writer, header *mp.DumpHeader, entries []*mp.DumpEntry, keys [][]byte) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(dumpMagic); err != nil {
+		return err
+	}
+	if err := writeDumpRecord(bw, mp.DumpHeaderRecord, header); err != nil {
+		return err
+	}
+	for _, de := range entries {
+		if err := writeDumpRecord(bw, mp.DumpEntryRecord, de); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := writeDumpRecord(bw, mp.DumpKeyRecord, &mp.DumpKey{Key: key}); err != nil {
+			return err
+		}
+	}
+	trailer := &mp.DumpTrailer{Count: int64(len(entries)), Keys: int64(len(keys))}
+	if err := writeDumpRecord(bw, mp.DumpTrailerRecord, trailer); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (lh *LHash) dumpHeader() *mp.DumpHeader {
+	_, reverse := lh.companion(reverseCompanion)
+	return &mp.DumpHeader{
+		Version:           mp.DumpVersion,
+		HashKey:           append([]byte{}, lh.root.HashKey...),
+		Meta:              lh.root.Meta,
+		MaxSize:           lh.root.MaxSize,
+		SizeStripes:       lh.root.SizeStripes,
+		DeferSplits:       lh.root.DeferSplits,
+		SortedBuckets:     lh.root.SortedBuckets,
+		InlineThreshold:   lh.root.InlineThreshold,
+		MaxChainLength:    lh.root.MaxChainLength,
+		MinUtilization:    lh.root.MinUtilization,
+		MaxUtilization:    lh.root.MaxUtilization,
+		WriteHeavy:        lh.root.WriteHeavy,
+		MaxBucketCapacity: lh.root.MaxBucketCapacity,
+		DirectoryPageSize: lh.root.DirectoryPageSize,
+		ReverseIndex:      reverse,
+		BlobThreshold:     lh.root.BlobThreshold,
+	}
+}
+
+// dumpEntry returns the entry in slot idx of b, with its value.
+func (lh *LHash) dumpEntry(txn *client.Txn, b *bucket, idx int) (*mp.DumpEntry, error) {
+	e := b.entryAt(idx)
+	de := &mp.DumpEntry{
+		Key:     b.entries.Keys[idx],
+		Value:   e.inline,
+		Inline:  e.inline != nil,
+		Expiry:  e.expiry,
+		Access:  e.access,
+		Version: e.version,
+	}
+	var err error
+	if e.blob {
+		de.Blob = true
+		de.Value, err = blob.ReadAll(lh.Conn, b.refs[idx+1])
+	} else if !de.Inline {
+		de.Value, err = lh.dumpValue(txn, de.Key, b.refs[idx+1])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return de, nil
+}
+
+func (lh *LHash) dumpValue(txn *client.Txn, key []byte, objRef client.ObjectRef) ([]byte, error) {
+	obj, err := txn.GetObject(objRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	} else if len(refs) != 0 {
+		return nil, fmt.Errorf("The value of %q has references, so cannot be dumped", key)
+	}
+	return value, nil
+}
+
+func writeDumpRecord(w *bufio.Writer, kind byte, body msgp.Marshaler) error {
+	bs, err := body.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	var length [binary.MaxVarintLen64]byte
+	if err = w.WriteByte(kind); err != nil {
+		return err
+	} else if _, err = w.Write(length[:binary.PutUvarint(length[:], uint64(len(bs)))]); err != nil {
+		return err
+	}
+	_, err = w.Write(bs)
+	return err
+}
+
+// readDumpRecord reads the next record, which must be of the given
+// kind, into body.
+func readDumpRecord(r *bufio.Reader, kind byte, body msgp.Unmarshaler) error {
+	found, err := r.ReadByte()
+	if err == io.EOF {
+		return ErrDumpCorrupt
+	} else if err != nil {
+		return err
+	} else if found != kind {
+		return fmt.Errorf("Expected dump record %q but found %q", kind, found)
+	}
+	length, err := binary.ReadUvarint(r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrDumpCorrupt
+	} else if err != nil {
+		return err
+	}
+	bs := make([]byte, length)
+	if _, err = io.ReadFull(r, bs); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrDumpCorrupt
+	} else if err != nil {
+		return err
+	}
+	_, err = body.UnmarshalMsg(bs)
+	return err
+}
+
+// Create a new LHash from a dump written by Dump. The new LHash has
+// the same hash key, parameters and entries as the dumped LHash, with
+// each value which was held in a value Object held in a new value
+// Object. The whole dump is read before anything is created, and the
+// LHash is then created and loaded in a single transaction, so either
+// the whole dump is restored or nothing is. ErrDumpCorrupt is
+// returned for a truncated dump.
+func Restore(conn *client.Connection, r io.Reader) (*LHash, error) {
+	header, entries, _, err := readDump(r)
+	if err != nil {
+		return nil, err
+	} else if header.Since != 0 {
+		return nil, errors.New("Dump is incremental: use RestoreIncremental")
+	}
+	return restoreEntries(conn, header, entries)
+}
+
+// Create a new LHash, as Restore does, from a full dump written by
+// DumpSince with a since of 0, followed by incremental dumps written
+// by DumpSince, each following on from the dump before. The result is
+// the LHash as of the last dump.
+func RestoreIncremental(conn *client.Connection, full io.Reader, incrementals ...io.Reader) (*LHash, error) {
+	header, entries, _, err := readDump(full)
+	if err != nil {
+		return nil, err
+	} else if header.Since != 0 {
+		return nil, errors.New("The first dump is incremental, not full")
+	}
+	for _, r := range incrementals {
+		next, nextEntries, keys, err := readDump(r)
+		if err != nil {
+			return nil, err
+		} else if next.Since == 0 || next.Since != header.Epoch {
+			return nil, fmt.Errorf("Incremental dump follows epoch %v, not epoch %v", next.Since, header.Epoch)
+		}
+		previous := make(map[string]*mp.DumpEntry, len(entries))
+		for _, de := range entries {
+			previous[string(de.Key)] = de
+		}
+		for _, key := range keys {
+			de, found := previous[string(key)]
+			if !found {
+				return nil, fmt.Errorf("Incremental dump refers to key %q which is not in the dump it follows", key)
+			}
+			nextEntries = append(nextEntries, de)
+		}
+		header, entries = next, nextEntries
+	}
+	return restoreEntries(conn, header, entries)
+}
+
+// readDump reads the whole of a dump.
+func readDump(r io.Reader) (*mp.DumpHeader, []*mp.DumpEntry, [][]byte, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(dumpMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, []byte(dumpMagic)) {
+		return nil, nil, nil, ErrDumpCorrupt
+	}
+	header := new(mp.DumpHeader)
+	if err := readDumpRecord(br, mp.DumpHeaderRecord, header); err != nil {
+		return nil, nil, nil, err
+	} else if header.Version > mp.DumpVersion {
+		return nil, nil, nil, fmt.Errorf("Dump version %v is newer than the supported version %v", header.Version, mp.DumpVersion)
+	} else if len(header.HashKey) != 16 {
+		return nil, nil, nil, ErrDumpCorrupt
+	}
+	var entries []*mp.DumpEntry
+	var keys [][]byte
+	for {
+		kind, err := br.Peek(1)
+		if err != nil {
+			return nil, nil, nil, ErrDumpCorrupt
+		}
+		switch kind[0] {
+		case mp.DumpEntryRecord:
+			de := new(mp.DumpEntry)
+			if err = readDumpRecord(br, mp.DumpEntryRecord, de); err != nil {
+				return nil, nil, nil, err
+			}
+			entries = append(entries, de)
+			continue
+		case mp.DumpKeyRecord:
+			dk := new(mp.DumpKey)
+			if err = readDumpRecord(br, mp.DumpKeyRecord, dk); err != nil {
+				return nil, nil, nil, err
+			}
+			keys = append(keys, dk.Key)
+			continue
+		}
+		break
+	}
+	trailer := new(mp.DumpTrailer)
+	if err := readDumpRecord(br, mp.DumpTrailerRecord, trailer); err != nil {
+		return nil, nil, nil, err
+	} else if trailer.Count != int64(len(entries)) || trailer.Keys != int64(len(keys)) {
+		return nil, nil, nil, ErrDumpCorrupt
+	}
+	return header, entries, keys, nil
+}
+
+// restoreEntries creates a new LHash from a dump header and entries.
+func restoreEntries(conn *client.Connection, header *mp.DumpHeader, entries []*mp.DumpEntry) (*LHash, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		lh, err := newFromHeader(conn, header)
+		if err != nil {
+			return nil, err
+		}
+		loads := make([]loadEntry, len(entries))
+		for idx, de := range entries {
+			loads[idx] = loadEntry{
+				key: de.Key,
+				e:   entry{expiry: de.Expiry, access: de.Access, version: de.Version},
+			}
+			if de.Inline {
+				loads[idx].e.inline = de.Value
+				if loads[idx].e.inline == nil {
+					loads[idx].e.inline = []byte{}
+				}
+			} else if de.Blob {
+				loads[idx].e.blob = true
+				if loads[idx].value, err = blob.Create(conn, de.Value, 0); err != nil {
+					return nil, err
+				}
+			} else if loads[idx].value, err = txn.CreateObject(de.Value); err != nil {
+				return nil, err
+			}
+		}
+		return lh, lh.load(loads)
+	})
+	if err == nil {
+		return res.(*LHash), nil
+	} else {
+		return nil, err
+	}
+}
+
+// newFromHeader creates a new empty LHash with the hash key and
+// parameters of a dump header.
+func newFromHeader(conn *client.Connection, header *mp.DumpHeader) (*LHash, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		lh, err := NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.restoreRoot(header); err != nil {
+			return nil, err
+		}
+		if header.SizeStripes > 0 {
+			if err = lh.StripeSize(int(header.SizeStripes)); err != nil {
+				return nil, err
+			}
+		}
+		if header.ReverseIndex {
+			if err = lh.EnableReverseIndex(); err != nil {
+				return nil, err
+			}
+		}
+		return lh, nil
+	})
+	if err == nil {
+		return res.(*LHash), nil
+	} else {
+		return nil, err
+	}
+}
+
+// restoreRoot sets the hash key and parameters of a new empty LHash
+// from a dump header.
+func (lh *LHash) restoreRoot(header *mp.DumpHeader) error {
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := lh.populate(); err != nil {
+			return nil, err
+		}
+		lh.root.HashKey = header.HashKey
+		lh.k0 = binary.LittleEndian.Uint64(header.HashKey[0:8])
+		lh.k1 = binary.LittleEndian.Uint64(header.HashKey[8:16])
+		lh.root.Meta = header.Meta
+		lh.root.MaxSize = header.MaxSize
+		lh.root.DeferSplits = header.DeferSplits
+		lh.root.SortedBuckets = header.SortedBuckets
+		lh.root.InlineThreshold = header.InlineThreshold
+		lh.root.MaxChainLength = header.MaxChainLength
+		lh.root.MinUtilization = header.MinUtilization
+		lh.root.MaxUtilization = header.MaxUtilization
+		if header.MaxUtilization > 0 {
+			lh.root.Utilization = (header.MinUtilization + header.MaxUtilization) / 2
+		}
+		lh.root.WriteHeavy = header.WriteHeavy
+		lh.root.MaxBucketCapacity = header.MaxBucketCapacity
+		lh.root.DirectoryPageSize = header.DirectoryPageSize
+		lh.root.BlobThreshold = header.BlobThreshold
+		// carry on tracking changes from the dumped epoch.
+		lh.root.ChangeEpoch = header.Epoch
+		return nil, lh.write()
+	})
+	return err
+}
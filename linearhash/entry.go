@@ -0,0 +1,157 @@
+package linearhash
+
+import (
+	"bytes"
+)
+
+// entry holds the attributes stored in a bucket alongside each key
+// and value. Each attribute is stored in the bucket as a slice
+// parallel to the keys, which is only allocated once some entry has a
+// non-zero value for that attribute.
+//
+// Inline values are stored in the same way, alongside a flag to
+// distinguish an empty inline value from no inline value. The same
+// flag marks entries whose value Object is the index Object of a blob.
+//
+// The hash of the key is the exception: buckets written before
+// hashes were recorded have no hashes at all, so once any hash is
+// set, the hashes of all the keys in the bucket are filled in.
+type entry struct {
+	expiry  int64
+	access  int64
+	version int64
+	hash    uint64
+	// if non-nil, the value of the entry, stored in the bucket
+	inline []byte
+	// if true, the value Object is the index Object of a blob
+	blob bool
+}
+
+func (b *bucket) entryAt(idx int) entry {
+	return entry{
+		expiry:  getAttr(b.entries.Expiries, idx),
+		access:  getAttr(b.entries.Accesses, idx),
+		version: getAttr(b.entries.Versions, idx),
+		hash:    b.hashAt(idx),
+		inline:  b.inlineAt(idx),
+		blob:    b.isBlob(idx),
+	}
+}
+
+// setEntry returns true iff any attribute of the slot was changed.
+func (b *bucket) setEntry(idx int, e entry) bool {
+	changed := setAttr(&b.entries.Expiries, len(b.entries.Keys), idx, e.expiry)
+	changed = setAttr(&b.entries.Accesses, len(b.entries.Keys), idx, e.access) || changed
+	changed = setAttr(&b.entries.Versions, len(b.entries.Keys), idx, e.version) || changed
+	changed = b.setHash(idx, e.hash) || changed
+	changed = b.setInline(idx, e.inline, e.blob) || changed
+	return changed
+}
+
+// The values of the Inlined flags.
+const (
+	flagInline = 1
+	flagBlob   = 2
+)
+
+func (b *bucket) isInline(idx int) bool {
+	return getAttr(b.entries.Inlined, idx) == flagInline
+}
+
+func (b *bucket) isBlob(idx int) bool {
+	return getAttr(b.entries.Inlined, idx) == flagBlob
+}
+
+func (b *bucket) inlineAt(idx int) []byte {
+	if !b.isInline(idx) {
+		return nil
+	} else if value := getValue(b.entries.Values, idx); value != nil {
+		return value
+	} else {
+		return []byte{}
+	}
+}
+
+func (b *bucket) setInline(idx int, value []byte, blob bool) bool {
+	flag := int64(0)
+	if value != nil {
+		flag = flagInline
+	} else if blob {
+		flag = flagBlob
+	}
+	changed := setAttr(&b.entries.Inlined, len(b.entries.Keys), idx, flag)
+	if !bytes.Equal(getValue(b.entries.Values, idx), value) {
+		if idx >= len(b.entries.Values) {
+			values := make([][]byte, len(b.entries.Keys))
+			copy(values, b.entries.Values)
+			b.entries.Values = values
+		}
+		b.entries.Values[idx] = value
+		changed = true
+	}
+	return changed
+}
+
+func getValue(values [][]byte, idx int) []byte {
+	if idx < len(values) {
+		return values[idx]
+	}
+	return nil
+}
+
+// hashAt returns the hash of the key in slot idx, computing it if the
+// bucket does not record hashes.
+func (b *bucket) hashAt(idx int) uint64 {
+	if len(b.entries.Hashes) == len(b.entries.Keys) {
+		return b.entries.Hashes[idx]
+	}
+	return b.hash(b.entries.Keys[idx])
+}
+
+func (b *bucket) setHash(idx int, h uint64) bool {
+	if len(b.entries.Hashes) != len(b.entries.Keys) {
+		hashes := b.entries.Hashes[:0]
+		for slot, k := range b.entries.Keys {
+			if b.isSlotEmpty(slot) {
+				hashes = append(hashes, 0)
+			} else {
+				hashes = append(hashes, b.hash(k))
+			}
+		}
+		b.entries.Hashes = hashes
+	}
+	if b.entries.Hashes[idx] == h {
+		return false
+	}
+	b.entries.Hashes[idx] = h
+	return true
+}
+
+func getAttr(attrs []int64, idx int) int64 {
+	if idx < len(attrs) {
+		return attrs[idx]
+	}
+	return 0
+}
+
+func setAttr(attrs *[]int64, capacity, idx int, value int64) bool {
+	if getAttr(*attrs, idx) == value {
+		return false
+	}
+	if idx >= len(*attrs) {
+		if cap(*attrs) >= capacity {
+			// reuse the space left over from a recycled bucket
+			grown := (*attrs)[:capacity]
+			for i := len(*attrs); i < capacity; i++ {
+				grown[i] = 0
+			}
+			*attrs = grown
+		} else {
+			grown := make([]int64, capacity)
+			copy(grown, *attrs)
+			*attrs = grown
+		}
+	}
+	(*attrs)[idx] = value
+	return true
+}
@@ -0,0 +1,34 @@
+package linearhash
+
+import (
+	"time"
+)
+
+type sizeReading struct {
+	size int64
+	at   time.Time
+}
+
+// Returns the size of the LHash as last read through this LHash
+// object, by Size or SizeRefresh, and the time at which it was read,
+// without running a transaction. This is intended for dashboards and
+// the like, which can tolerate a stale answer but should not add load
+// to the root. If the size has never been read, the time is zero.
+// SizeEstimate never runs a transaction, so may be called even from
+// within a callback.
+func (lh *LHash) SizeEstimate() (int64, time.Time) {
+	if reading, ok := lh.handle().sizeRead.Load().(sizeReading); ok {
+		return reading.size, reading.at
+	}
+	return 0, time.Time{}
+}
+
+// Reads the size of the LHash, as Size does, updating the value
+// returned by SizeEstimate.
+func (lh *LHash) SizeRefresh() (int64, error) {
+	return lh.Size()
+}
+
+func (lh *LHash) recordSize(size int64) {
+	lh.handle().sizeRead.Store(sizeReading{size: size, at: time.Now()})
+}
@@ -0,0 +1,83 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	"time"
+)
+
+// Idempotently add the given key and value to the LHash, as with Put,
+// but such that the entry expires at the given time. Once expired,
+// the entry is treated as absent by Find and ForEach, though it still
+// occupies space (and is included in Size) until it is removed,
+// either lazily by Find, or by SweepExpired. Putting the same key
+// again replaces the expiry time; a zero expiry means the entry never
+// expires.
+func (lh *LHash) PutWithExpiry(key []byte, value client.ObjectRef, expiry time.Time) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.PutWithExpiry(key, value, expiry)
+	}
+	expiryNanos := int64(0)
+	if !expiry.IsZero() {
+		expiryNanos = expiry.UnixNano()
+	}
+	_, err := lh.put(key, value, nil, false, expiryNanos, anyVersion)
+	return err
+}
+
+// Remove up to limit expired entries from the LHash, returning the
+// number of entries removed. Each call is a single transaction, so
+// limit bounds the size of the transaction: call SweepExpired
+// repeatedly until it returns fewer than limit to reclaim all expired
+// entries. A limit of 0 or less means no limit.
+func (lh *LHash) SweepExpired(limit int) (int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SweepExpired(limit)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		now := time.Now().UnixNano()
+		var expired [][]byte
+		full := func() bool { return limit > 0 && len(expired) == limit }
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			b, err := lh.head(idx)
+			for ; err == nil && b != nil && !full(); b, err = b.next() {
+				for idx, k := range b.entries.Keys {
+					if full() {
+						break
+					} else if !b.isSlotEmpty(idx) && b.isExpired(idx, now) {
+						expired = append(expired, k)
+					}
+				}
+			}
+			if err != nil {
+				return nil, err
+			} else if full() {
+				break
+			}
+		}
+		for _, k := range expired {
+			if err = lh.Remove(k); err != nil {
+				return nil, err
+			}
+		}
+		return len(expired), nil
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
+
+func (b *bucket) isExpired(idx int, now int64) bool {
+	expiry := getAttr(b.entries.Expiries, idx)
+	return expiry != 0 && expiry <= now
+}
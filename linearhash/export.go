@@ -0,0 +1,90 @@
+package linearhash
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"goshawkdb.io/client"
+	"io"
+	"strconv"
+)
+
+// An ExportEncoding determines how ExportCSV writes keys and values.
+type ExportEncoding int
+
+const (
+	// Keys and values are written as they are. Only suitable where
+	// they are known to be text.
+	ExportRaw ExportEncoding = iota
+	// Keys and values are written as lower case hexadecimal.
+	ExportHex
+	// Keys and values are written in standard base64, with padding.
+	ExportBase64
+)
+
+// ExportOptions control the output of ExportCSV. The zero value writes
+// comma separated rows of raw keys and values, without a header.
+type ExportOptions struct {
+	// The field delimiter. 0 means ','. Use '\t' for TSV.
+	Comma rune
+	// The encoding of keys and values.
+	Encoding ExportEncoding
+	// If true, the first row names the columns.
+	Header bool
+}
+
+// Write every unexpired entry of the LHash to w as CSV, one row per
+// entry, with columns key, value-length and value, and return the
+// number of entries written. The value is the value of the entry,
+// whether stored inline or as a value Object (so this reads every
+// value Object), and value-length is its length in bytes before
+// encoding. Rows are in no particular order.
+//
+// The entries are read in a single transaction and only written to w
+// once it has committed, so the rows are a consistent snapshot, at the
+// cost of holding them all in memory. For the same reason, ExportCSV
+// should not be called from within a transaction, which could restart
+// after the rows have been written.
+func (lh *LHash) ExportCSV(w io.Writer, opts ExportOptions) (int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.ExportCSV(w, opts)
+	}
+	var encode func([]byte) string
+	switch opts.Encoding {
+	case ExportRaw:
+		encode = func(bs []byte) string { return string(bs) }
+	case ExportHex:
+		encode = hex.EncodeToString
+	case ExportBase64:
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return 0, fmt.Errorf("Unknown export encoding %v", opts.Encoding)
+	}
+	var rows [][]string
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		// the transaction may restart, so start afresh each time.
+		rows = rows[:0]
+		return nil, lh.ForEachValue(func(key []byte, value []byte) error {
+			rows = append(rows, []string{encode(key), strconv.Itoa(len(value)), encode(value)})
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	if opts.Header {
+		if err = cw.Write([]string{"key", "value-length", "value"}); err != nil {
+			return 0, err
+		}
+	}
+	if err = cw.WriteAll(rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,200 @@
+package linearhash
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/blob"
+	"time"
+)
+
+// ErrInlineValue is returned by operations which return value Objects
+// (such as Find) when the entry's value is stored inline in its
+// bucket, and so there is no value Object. Use FindValue instead.
+var ErrInlineValue = errors.New("LHash entry has an inline value: use FindValue")
+
+// Set the size, in bytes, of the largest value which PutValue stores
+// inline, in the bucket holding the entry, rather than as a separate
+// Object. Inline values save creating an Object for each entry and
+// reading it back again, which is worthwhile for tiny values such as
+// counters and flags, but make every bucket larger. A threshold of 0
+// (the default) disables inline values. Changing the threshold does
+// not affect existing entries. The setting is stored in the root, so
+// applies to all users of the LHash.
+func (lh *LHash) SetInlineThreshold(threshold int) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetInlineThreshold(threshold)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if threshold < 0 {
+			threshold = 0
+		}
+		if lh.root.InlineThreshold == int64(threshold) {
+			return nil, nil
+		}
+		lh.root.InlineThreshold = int64(threshold)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Set the size, in bytes, above which PutValue stores values as
+// chunked blobs (see the blob package) rather than as single Objects,
+// so that no Object holds a huge value. A threshold of 0 (the default)
+// disables blobs. As with SetInlineThreshold, changing the threshold
+// does not affect existing entries, and the setting is stored in the
+// root.
+func (lh *LHash) SetBlobThreshold(threshold int) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetBlobThreshold(threshold)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if threshold < 0 {
+			threshold = 0
+		}
+		if lh.root.BlobThreshold == int64(threshold) {
+			return nil, nil
+		}
+		lh.root.BlobThreshold = int64(threshold)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Idempotently set the value of the entry for the given key. If value
+// is no larger than the inline threshold (see SetInlineThreshold), it
+// is stored inline; if it is larger than the blob threshold (see
+// SetBlobThreshold), it is stored as a blob; otherwise a new Object is
+// created to hold it. Entries put with PutValue should be read with
+// FindValue and ForEachValue: Find returns the index Object of a blob
+// entry.
+func (lh *LHash) PutValue(key []byte, value []byte) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.PutValue(key, value)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if threshold := lh.root.InlineThreshold; threshold > 0 && int64(len(value)) <= threshold {
+			// copy, ensuring the inline value is not nil.
+			_, err = lh.put(key, client.ObjectRef{}, append([]byte{}, value...), false, 0, anyVersion)
+			return nil, err
+		}
+		if threshold := lh.root.BlobThreshold; threshold > 0 && int64(len(value)) > threshold {
+			indexObj, err := blob.Create(lh.Conn, value, 0)
+			if err != nil {
+				return nil, err
+			}
+			_, err = lh.put(key, indexObj, nil, true, 0, anyVersion)
+			return nil, err
+		}
+		valueObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		_, err = lh.put(key, valueObj, nil, false, 0, anyVersion)
+		return nil, err
+	})
+	return err
+}
+
+// Returns the value of the entry for the given key, whether stored
+// inline, as a value Object or as a blob, or nil if there is no entry.
+// As with Find, expired entries are treated as absent.
+func (lh *LHash) FindValue(key []byte) ([]byte, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.FindValue(key)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, inline, isBlob, err := lh.find(key)
+		if err != nil || value == nil {
+			return inline, err
+		} else if isBlob {
+			return blob.ReadAll(lh.Conn, *value)
+		}
+		return readValue(txn, *value)
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Iterate over the entries in the LHash, as ForEach does, but
+// supplying the value of each entry, whether stored inline, as a
+// value Object or as a blob, rather than a reference to the value Object. Note
+// that this reads every value Object.
+func (lh *LHash) ForEachValue(f func(key []byte, value []byte) error) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.ForEachValue(f)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UnixNano()
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			b, err := lh.head(idx)
+			for ; err == nil && b != nil; b, err = b.next() {
+				for idx, k := range b.entries.Keys {
+					if b.isSlotEmpty(idx) || b.isExpired(idx, now) {
+						continue
+					}
+					value, err := lh.valueAt(txn, b, idx)
+					if err != nil {
+						return nil, err
+					}
+					if err = f(k, value); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// valueAt returns the value of the entry in slot idx of b, however it
+// is stored.
+func (lh *LHash) valueAt(txn *client.Txn, b *bucket, idx int) ([]byte, error) {
+	if value := b.inlineAt(idx); value != nil {
+		return value, nil
+	} else if b.isBlob(idx) {
+		return blob.ReadAll(lh.Conn, b.refs[idx+1])
+	}
+	return readValue(txn, b.refs[idx+1])
+}
+
+func readValue(txn *client.Txn, objRef client.ObjectRef) ([]byte, error) {
+	obj, err := txn.GetObject(objRef)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Value()
+}
@@ -13,11 +13,13 @@ package linearhash
 import (
 	"bytes"
 	"encoding/binary"
-	// "fmt"
+	"fmt"
 	hash "github.com/dchest/siphash"
 	"goshawkdb.io/client"
 	mp "goshawkdb.io/collections/linearhash/msgpack"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,16 +28,55 @@ type LHash struct {
 	// GoshawkDB, objects are scoped to connections so you should not
 	// use the same LHash object from multiple connections. You can
 	// have multiple LHash objects for the same underlying set of
-	// GoshawkDB objects.
+	// GoshawkDB objects. An LHash object may be used by several
+	// goroutines at once (see acquire).
 	Conn *client.Connection
 	// The underlying Object in GoshawkDB which holds the root data for
 	// the LHash.
 	ObjRef client.ObjectRef
+	// If non-nil, Upgrade is called whenever a root or bucket Object
+	// is read which was written using an older format version than
+	// the current one. Objects are always rewritten in the current
+	// format, so this is an opportunity to log or veto (by returning
+	// an error) the upgrade before it happens. An unchanged root is
+	// only decoded once per LHash object, so Upgrade is not
+	// necessarily called on every operation.
+	Upgrade func(kind string, objRef client.ObjectRef, version uint64) error
+	// If true, root and bucket Objects are checked against the current
+	// format as they are decoded, and an error describing the problem
+	// is returned if they have unknown fields or fields of the wrong
+	// type, which are otherwise ignored. Strict decoding is off by
+	// default as it prevents this code reading Objects written by
+	// newer versions.
+	Strict bool
 	root   *mp.Root
 	value  []byte
-	refs   []client.ObjectRef
-	k0     uint64
-	k1     uint64
+	// the pages of the bucket directory
+	dir []dirPage
+	// references to companion Objects, named by root.Companions
+	companions []client.ObjectRef
+	k0         uint64
+	k1         uint64
+	// encoding and decoding state reused between operations
+	rootRaw *mp.RootRaw
+	encoder *mp.Encoder
+	pool    bucketPool
+	cache   bucketCache
+	// the buckets copied by the current operation, as they were
+	// shared with a checkpoint (see Checkpoint)
+	copies map[string]bucketCopy
+	// the last size read, for SizeEstimate
+	sizeRead atomic.Value
+	memo     *hashMemo
+	memoSize int
+	// set within a Batch, and whilst the root has unwritten changes
+	deferWrites bool
+	rootDirty   bool
+	// the handle of which this is a session, if it is one, else the
+	// idle sessions of this handle
+	shared       *LHash
+	sessionsLock sync.Mutex
+	sessions     []*LHash
 }
 
 // Create a brand new empty LHash. This creates a new GoshawkDB Object
@@ -52,9 +93,11 @@ func NewEmptyLHash(conn *client.Connection) (*LHash, error) {
 		key := make([]byte, 16)
 		rng.Read(key)
 		lh.root = mp.NewRoot(key)
+		lh.k0 = binary.LittleEndian.Uint64(key[0:8])
+		lh.k1 = binary.LittleEndian.Uint64(key[8:16])
 
 		refs := make([]client.ObjectRef, lh.root.BucketCount)
-		lh.refs = refs
+		lh.setRootDirectory(refs)
 		for idx := range refs {
 			objRef, err := txn.CreateObject([]byte{})
 			if err != nil {
@@ -88,7 +131,12 @@ func LHashFromObj(conn *client.Connection, objRef client.ObjectRef) *LHash {
 }
 
 func (lh *LHash) populate() error {
-	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	if lh.rootDirty {
+		// within a Batch, the in-memory root has been modified and
+		// not yet written, so it is the current root.
+		return nil
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		obj, err := txn.GetObject(lh.ObjRef)
 		if err != nil {
 			return nil, err
@@ -99,29 +147,78 @@ func (lh *LHash) populate() error {
 			return nil, err
 		}
 		// fmt.Println("read ->", value)
-		rootraw := new(mp.RootRaw)
-		_, err = rootraw.UnmarshalMsg(value)
-		if err != nil {
-			return nil, err
+		// lh.value is the encoding of lh.root as last read or
+		// written, so if the root is unchanged, whether within this
+		// transaction or since an earlier one, there is no need to
+		// decode it again.
+		if lh.root == nil || !bytes.Equal(value, lh.value) {
+			if err = lh.decodeRoot(obj, value); err != nil {
+				return nil, err
+			}
+		}
+		if len(refs) < int(lh.root.DirectoryPages)+len(lh.root.Companions) {
+			return nil, fmt.Errorf("LHash root %v is corrupt: %v directory pages and %v companions but only %v references",
+				obj, lh.root.DirectoryPages, len(lh.root.Companions), len(refs))
+		}
+		directory := len(refs) - len(lh.root.Companions)
+		lh.companions = refs[directory:]
+		if lh.root.DirectoryPages > 0 {
+			lh.setPages(refs[:directory])
+		} else {
+			// the original layout, with the directory in the root.
+			// Limit the capacity so that appending to the directory
+			// cannot overwrite the companions.
+			lh.setRootDirectory(refs[:directory:directory])
 		}
-		lh.root = rootraw.ToRoot()
-		lh.value = value
-		lh.refs = refs
-		lh.k0 = binary.LittleEndian.Uint64(lh.root.HashKey[0:8])
-		lh.k1 = binary.LittleEndian.Uint64(lh.root.HashKey[8:16])
 		// fmt.Printf("read %#v, %v %v\n", lh.root, lh.k0, lh.k1)
 		return nil, nil
 	})
 	if err != nil {
 		lh.root = nil
 		lh.value = nil
-		lh.refs = nil
+		lh.dir = lh.dir[:0]
+		lh.companions = nil
 		lh.k0 = 0
 		lh.k1 = 0
 	}
 	return err
 }
 
+func (lh *LHash) decodeRoot(obj client.ObjectRef, value []byte) error {
+	rootraw := lh.rootRaw
+	if rootraw == nil {
+		rootraw = new(mp.RootRaw)
+		lh.rootRaw = rootraw
+	} else {
+		rootraw.Reset()
+	}
+	if lh.Strict {
+		if err := mp.CheckRoot(value); err != nil {
+			return fmt.Errorf("LHash root %v is not in the current format: %v", obj, err)
+		}
+	}
+	if _, err := rootraw.UnmarshalMsg(value); err != nil {
+		return err
+	}
+	root := rootraw.ToRoot()
+	if err := lh.checkVersion(RootObject, obj, root.Version, mp.RootVersion); err != nil {
+		return err
+	}
+	if len(root.HashKey) != 16 {
+		return fmt.Errorf("LHash root %v has no valid hash key", obj)
+	}
+	lh.root = root
+	// copy the value as write reuses lh.value as its buffer.
+	lh.value = append(lh.value[:0], value...)
+	k0 := binary.LittleEndian.Uint64(lh.root.HashKey[0:8])
+	k1 := binary.LittleEndian.Uint64(lh.root.HashKey[8:16])
+	if lh.memo != nil && (k0 != lh.k0 || k1 != lh.k1) {
+		lh.memo.clear()
+	}
+	lh.k0, lh.k1 = k0, k1
+	return nil
+}
+
 func (lh *LHash) hash(key []byte) uint64 {
 	return hash.Hash(lh.k0, lh.k1, key)
 }
@@ -129,18 +226,21 @@ func (lh *LHash) hash(key []byte) uint64 {
 // Search the LHash for the given key. The key is hashed using the
 // SipHash algorithm, and comparison between keys is done with
 // bytes.Equal. If no matching key is found, a nil ObjectRef is
-// returned.
+// returned. Entries which have expired are treated as absent, and are
+// removed if the LHash is writable. If the LHash has a maximum size,
+// the last access time of the entry is updated. Use Peek to search
+// without any possibility of writing.
 func (lh *LHash) Find(key []byte) (*client.ObjectRef, error) {
-	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
-		err := lh.populate()
-		if err != nil {
-			return nil, err
-		}
-		bucket, err := lh.newBucket(lh.refs[lh.root.BucketIndex(lh.hash(key))])
-		if err != nil {
-			return nil, err
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Find(key)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, inline, _, err := lh.find(key)
+		if err == nil && inline != nil {
+			return nil, ErrInlineValue
 		}
-		return bucket.find(key)
+		return value, err
 	})
 	if err == nil {
 		return res.(*client.ObjectRef), nil
@@ -149,75 +249,253 @@ func (lh *LHash) Find(key []byte) (*client.ObjectRef, error) {
 	}
 }
 
+// find returns either the value or the inline value of the entry for
+// key, or neither if there is no such entry. blob is true if the value
+// is the index Object of a blob.
+func (lh *LHash) find(key []byte) (value *client.ObjectRef, inline []byte, blob bool, err error) {
+	err = lh.populate()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	h, bIdx := lh.locate(key)
+	bucket, err := lh.head(bIdx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	now := time.Now().UnixNano()
+	value, inline, blob, expired, err := bucket.find(key, h, now)
+	if err != nil || lh.checkWritable() != nil {
+		return value, inline, blob, err
+	} else if expired {
+		return value, inline, blob, lh.Remove(key)
+	} else if (value != nil || inline != nil) && lh.root.MaxSize > 0 {
+		return value, inline, blob, bucket.touch(key, now)
+	}
+	return value, inline, blob, nil
+}
+
 // Idempotently add the given key and value to the LHash. The key is
 // hashed using the SipHash algorithm, and comparison between keys is
 // done with bytes.Equal. If a matching key is found, the
-// corresponding value is updated.
+// corresponding value is updated. If the key already maps to the
+// same Object, nothing is written. ErrReadOnly is returned if the
+// LHash was created from a read-only reference.
 func (lh *LHash) Put(key []byte, value client.ObjectRef) error {
-	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Put(key, value)
+	}
+	_, err := lh.put(key, value, nil, false, 0, anyVersion)
+	return err
+}
+
+// put returns the version of the entry after the put. If inline is
+// non-nil, it is stored as the value of the entry and value is
+// ignored. If blob is true, value is the index Object of a blob. If
+// expected is not anyVersion, then the put only occurs if
+// the current version of the entry matches expected.
+func (lh *LHash) put(key []byte, value client.ObjectRef, inline []byte, blob bool, expiry int64, expected int64) (uint64, error) {
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		err := lh.populate()
 		if err != nil {
 			return nil, err
 		}
-		bucket, err := lh.newBucket(lh.refs[lh.root.BucketIndex(lh.hash(key))])
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		h, bIdx := lh.locate(key)
+		bucket, err := lh.head(bIdx)
 		if err != nil {
 			return nil, err
 		}
-		_, added, chainDelta, err := bucket.put(key, value)
+		now := time.Now().UnixNano()
+		e := entry{expiry: expiry, hash: h, inline: inline, blob: blob && inline == nil}
+		if lh.root.MaxSize > 0 {
+			e.access = now
+		}
+		var valueOld *client.ObjectRef
+		unchanged, adding := false, false
+		if bFound, idx, err := bucket.findSlotHash(key, h); err != nil {
+			return nil, err
+		} else if bFound == nil {
+			adding = true
+		} else {
+			eOld := bFound.entryAt(idx)
+			if eOld.inline == nil {
+				valueOld = &bFound.refs[idx+1]
+			}
+			if !bFound.isExpired(idx, now) {
+				e.version = eOld.version
+				if inline == nil {
+					unchanged = valueOld != nil && valueOld.ReferencesSameAs(value) && eOld.blob == e.blob
+				} else {
+					unchanged = eOld.inline != nil && bytes.Equal(eOld.inline, inline)
+				}
+				unchanged = unchanged && eOld.expiry == e.expiry &&
+					(lh.root.MaxSize == 0 || now-eOld.access < lruAccessGranularity)
+			}
+		}
+		if expected != anyVersion && expected != e.version {
+			return nil, &ConflictError{Key: key, Expected: uint64(expected), Actual: uint64(e.version)}
+		}
+		if unchanged {
+			// nothing to write, so avoid adding the bucket (and
+			// anything else) to the transaction's write set.
+			return uint64(e.version), nil
+		}
+		e.version++
+		valueNew := &value
+		if inline != nil {
+			valueNew = nil
+		}
+		if err = lh.updateReverse(key, valueOld, valueNew); err != nil {
+			return nil, err
+		}
+		// add to the filter before the put, in case the put writes
+		// the head bucket anyway.
+		bloomChanged := adding && bucket.bloomAdd(h)
+		_, added, chainDelta, err := bucket.put(key, value, e)
 		if err != nil {
 			return nil, err
 		}
+		if added && chainDelta > 0 && !bloomChanged {
+			if bloomChanged, err = bucket.rebuildBloom(); err != nil {
+				return nil, err
+			}
+		}
+		// the put only encodes the head bucket if the key went into
+		// it, so otherwise the filter must be written here.
+		if bloomChanged && bucket.slotOf(key, h) == -1 {
+			if err = bucket.write(true); err != nil {
+				return nil, err
+			}
+		}
 		// fmt.Printf("(%v) Put %v, added:%v; chainDelta:%v\n", lh.root.Size, key, added, chainDelta)
 		if added || chainDelta != 0 {
+			rootChanged := chainDelta != 0
 			if added {
-				lh.root.Size++
+				changed, err := lh.adjustSize(bIdx, 1)
+				if err != nil {
+					return nil, err
+				}
+				rootChanged = rootChanged || changed
 			}
 			lh.root.BucketCount += chainDelta
-			if lh.root.NeedsSplit() {
+			size, err := lh.sizeEstimate(bIdx)
+			if err != nil {
+				return nil, err
+			}
+			needsSplit := lh.root.NeedsSplitAt(size)
+			if !needsSplit && chainDelta > 0 {
+				if needsSplit, err = lh.chainTooLong(bucket); err != nil {
+					return nil, err
+				}
+			}
+			if !needsSplit {
+				// nothing to do
+			} else if lh.root.DeferSplits {
+				if !lh.root.SplitPending {
+					lh.root.SplitPending = true
+					rootChanged = true
+				}
+			} else {
 				err = lh.split()
 				if err != nil {
 					return nil, err
 				}
+				rootChanged = true
+			}
+			if rootChanged {
+				if err = lh.write(); err != nil {
+					return nil, err
+				}
+			}
+			if added && lh.root.MaxSize > 0 {
+				if size, err = lh.size(); err != nil {
+					return nil, err
+				} else if size > lh.root.MaxSize {
+					if err = lh.evict(size-lh.root.MaxSize, key); err != nil {
+						return nil, err
+					}
+				}
 			}
-			return nil, lh.write()
 		}
-		return nil, nil
+		return uint64(e.version), nil
 	})
-	return err
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
 }
 
 // Idempotently remove any matching entry from the LHash. The key is
 // hashed using the SipHash algorithm, and comparison between keys is
-// done with bytes.Equal.
+// done with bytes.Equal. ErrReadOnly is returned if the LHash was
+// created from a read-only reference.
 func (lh *LHash) Remove(key []byte) error {
-	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Remove(key)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		err := lh.populate()
 		if err != nil {
 			return nil, err
 		}
-		idx := lh.root.BucketIndex(lh.hash(key))
-		bucket, err := lh.newBucket(lh.refs[idx])
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		h, idx := lh.locate(key)
+		bucket, err := lh.head(idx)
 		if err != nil {
 			return nil, err
 		}
-		bNew, removed, chainDelta, err := bucket.remove(key)
+		if lh.reverseIndex() != nil {
+			if bFound, slot, err := bucket.findSlotHash(key, h); err != nil {
+				return nil, err
+			} else if bFound != nil && bFound.entryAt(slot).inline == nil {
+				if err = lh.updateReverse(key, &bFound.refs[slot+1], nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+		bNew, removed, chainDelta, err := bucket.remove(key, h)
 		if err != nil {
 			return nil, err
 		}
 		if removed || chainDelta != 0 {
+			rootChanged := chainDelta != 0
 			if bNew == nil { // must keep old bucket even though it's empty
 				err = bucket.write(true)
 				if err != nil {
 					return nil, err
 				}
 			} else if bNew != bucket {
-				lh.refs[idx] = bNew.objRef
+				// the head of the chain has changed, so any locks
+				// must move to the new head.
+				if len(bucket.entries.Locks) != 0 {
+					bNew.entries.Locks = bucket.entries.Locks
+					if err = bNew.write(true); err != nil {
+						return nil, err
+					}
+				}
+				if err = lh.setBucketRef(idx, bNew.objRef); err != nil {
+					return nil, err
+				}
+				rootChanged = true
 			}
 			if removed {
-				lh.root.Size--
+				changed, err := lh.adjustSize(idx, -1)
+				if err != nil {
+					return nil, err
+				}
+				rootChanged = rootChanged || changed
+			}
+			if rootChanged {
+				lh.root.BucketCount += chainDelta
+				return nil, lh.write()
 			}
-			lh.root.BucketCount += chainDelta
-			return nil, lh.write()
 		}
 		return nil, nil
 	})
@@ -225,24 +503,31 @@ func (lh *LHash) Remove(key []byte) error {
 }
 
 // Iterate over the entries in the LHash. Iteration order is
-// undefined. Also note that as usual, the transaction in which the
-// iteration is occurring may need to restart one or more times in
-// which case the callback may be invoked several times for the same
-// entry. To detect this, call ForEach from within a transaction of
-// your own. Iteration will stop as soon as the callback returns a
-// non-nil error, which will also abort the transaction.
+// undefined and expired entries are skipped, as are entries with
+// inline values (see ForEachValue). Also note that as usual,
+// the transaction in which the iteration is occurring may need to
+// restart one or more times in which case the callback may be invoked
+// several times for the same entry. To detect this, call ForEach from
+// within a transaction of your own. Iteration will stop as soon as
+// the callback returns a non-nil error, which will also abort the
+// transaction.
 func (lh *LHash) ForEach(f func([]byte, client.ObjectRef) error) error {
-	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.ForEach(f)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		err := lh.populate()
 		if err != nil {
 			return nil, err
 		}
-		for _, objRef := range lh.refs {
-			bucket, err := lh.newBucket(objRef)
+		now := time.Now().UnixNano()
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			bucket, err := lh.head(idx)
 			if err != nil {
 				return nil, err
 			}
-			err = bucket.forEach(f)
+			err = bucket.forEach(f, now)
 			if err != nil {
 				return nil, err
 			}
@@ -252,45 +537,150 @@ func (lh *LHash) ForEach(f func([]byte, client.ObjectRef) error) error {
 	return err
 }
 
-// Returns the number of entries in the LHash.
+// Iterate over the keys in the LHash, as ForEach does, but without
+// the values. Unlike ForEach, keys of entries with inline values are
+// included. This is cheaper than ForEach where only the keys are
+// needed, for example to export or audit them.
+func (lh *LHash) ForEachKey(f func([]byte) error) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.ForEachKey(f)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UnixNano()
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			b, err := lh.head(idx)
+			for ; err == nil && b != nil; b, err = b.next() {
+				for idx, k := range b.entries.Keys {
+					if b.isSlotEmpty(idx) || b.isExpired(idx, now) {
+						continue
+					}
+					if err = f(k); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns the number of entries in the LHash. This includes entries
+// which have expired but have not yet been removed by Find or
+// SweepExpired. If the size is striped (see StripeSize), this reads
+// every counter. See also SizeEstimate.
 func (lh *LHash) Size() (int64, error) {
-	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Size()
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		err := lh.populate()
 		if err != nil {
 			return nil, err
 		}
-		return lh.root.Size, nil
+		return lh.size()
 	})
 	if err == nil {
+		lh.recordSize(res.(int64))
 		return res.(int64), nil
 	} else {
 		return -1, err
 	}
 }
 
+// Returns a copy of the value of the named metadata entry stored in
+// the root of the LHash, or nil if there is no such entry. Metadata is
+// intended for small amounts of descriptive data such as schema
+// versions or owner information.
+func (lh *LHash) GetMeta(name string) ([]byte, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.GetMeta(name)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if value, found := lh.root.Meta[name]; found {
+			// the root is cached, so must not be modified by the caller.
+			return append([]byte{}, value...), nil
+		}
+		return []byte(nil), nil
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Sets the named metadata entry stored in the root of the LHash. If
+// value is nil, the entry is removed. As the metadata is stored in
+// the root, every operation on the LHash reads it: keep it small.
+func (lh *LHash) SetMeta(name string, value []byte) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetMeta(name, value)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if valueOld, found := lh.root.Meta[name]; found == (value != nil) && bytes.Equal(valueOld, value) {
+			return nil, nil
+		} else if value == nil {
+			delete(lh.root.Meta, name)
+		} else {
+			lh.root.Meta[name] = append([]byte{}, value...)
+		}
+		return nil, lh.write()
+	})
+	return err
+}
+
 func (lh *LHash) split() error {
 	sOld := lh.root.SplitIndex
-	b, err := lh.newBucket(lh.refs[sOld])
+	b, err := lh.head(sOld)
 	if err != nil {
 		return err
 	}
-	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
 		return txn.CreateObject([]byte{})
 	})
 	if err != nil {
 		return err
 	}
 	bNew := lh.newEmptyBucket(res.(client.ObjectRef))
-	lh.refs = append(lh.refs, bNew.objRef)
+	buckets := lh.directoryLen() + 1
+	if err = lh.setBucketRef(buckets-1, bNew.objRef); err != nil {
+		return err
+	}
 
 	lh.root.BucketCount++
 	lh.root.SplitIndex++
-	if 2*lh.root.SplitIndex == uint64(len(lh.refs)) {
+	if 2*lh.root.SplitIndex == buckets {
 		// we've split everything
 		lh.root.SplitIndex = 0
 		lh.root.MaskLow = lh.root.MaskHigh
 		lh.root.MaskHigh = lh.root.MaskHigh*2 + 1
 	}
+	lh.adaptUtilization(time.Now().UnixNano())
+
+	locks := lh.splitLocks(b, bNew, sOld)
 
 	var bPrev, bNext *bucket
 	for ; b != nil; b = bNext {
@@ -299,18 +689,20 @@ func (lh *LHash) split() error {
 			return err
 		}
 		emptied := true
-		for idx, k := range ([][]byte)(*b.entries) {
+		for idx, k := range b.entries.Keys {
 			if b.isSlotEmpty(idx) {
 				continue
-			} else if lh.root.BucketIndex(lh.hash(k)) == sOld {
+			} else if lh.root.BucketIndex(b.hashAt(idx)) == sOld {
 				emptied = false
 			} else {
-				_, _, chainDelta, err := bNew.put(k, b.refs[idx+1])
+				_, _, chainDelta, err := bNew.put(k, b.refs[idx+1], b.entryAt(idx))
 				if err != nil {
 					return err
 				}
 				lh.root.BucketCount += chainDelta
-				([][]byte)(*b.entries)[idx] = nil
+				b.entries.Keys[idx] = nil
+				b.entries.Sorted = false
+				b.setEntry(idx, entry{})
 				b.refs[idx+1] = b.objRef
 			}
 		}
@@ -319,6 +711,7 @@ func (lh *LHash) split() error {
 				if bPrev == nil {
 					// we have to keep b here, and there's no next,
 					// so we have to write out b.
+					b.entries.Locks = locks
 					b.tidyRefTail()
 					err = b.write(true)
 					if err != nil {
@@ -333,7 +726,9 @@ func (lh *LHash) split() error {
 			} else { // there is a next
 				lh.root.BucketCount--
 				if bPrev == nil {
-					lh.refs[sOld] = bNext.objRef
+					if err = lh.setBucketRef(sOld, bNext.objRef); err != nil {
+						return err
+					}
 				} else {
 					bPrev.refs[0] = bNext.objRef
 				}
@@ -345,6 +740,8 @@ func (lh *LHash) split() error {
 				if err != nil {
 					return err
 				}
+			} else { // b is the new head of the chain
+				b.entries.Locks = locks
 			}
 			bPrev = b
 		}
@@ -355,17 +752,48 @@ func (lh *LHash) split() error {
 			return err
 		}
 	}
+	head, err := lh.head(sOld)
+	if err != nil {
+		return err
+	}
+	// the head of the old chain grows to the current capacity, so that
+	// existing chains catch up as the directory grows.
+	grown := head.grow(lh.root.BucketCapacity())
+	if changed, err := head.rebuildBloom(); err != nil {
+		return err
+	} else if changed || grown {
+		if err = head.write(true); err != nil {
+			return err
+		}
+	}
+	if _, err = bNew.rebuildBloom(); err != nil {
+		return err
+	}
 	return bNew.write(true)
 }
 
-func (lh *LHash) write() (err error) {
+// write writes the root (and the bucket directory), unless a Batch
+// is deferring the write to its end.
+func (lh *LHash) write() error {
+	if lh.deferWrites {
+		lh.rootDirty = true
+		return nil
+	}
+	return lh.writeRoot()
+}
+
+func (lh *LHash) writeRoot() (err error) {
+	if err = lh.writeDirectory(); err != nil {
+		return
+	}
 	lh.value, err = lh.root.UpdateRaw().MarshalMsg(lh.value[:0])
 	if err != nil {
 		return
 	}
 	// fmt.Println("write ->", lh.value)
 	// fmt.Printf("write %#v, %v %v\n", lh.root, lh.k0, lh.k1)
-	return lh.ObjRef.Set(lh.value, lh.refs...)
+	refs := append(lh.pageRefs(), lh.companions...)
+	return lh.ObjRef.Set(lh.value, refs...)
 }
 
 type bucket struct {
@@ -374,14 +802,21 @@ type bucket struct {
 	entries *mp.Bucket
 	value   []byte
 	refs    []client.ObjectRef
+	// the index of the chain the bucket was reached through, if it was
+	// read rather than created by the current operation
+	chain uint64
 }
 
 func (lh *LHash) newBucket(objRef client.ObjectRef) (*bucket, error) {
+	if b := lh.cachedBucket(objRef); b != nil {
+		return b, nil
+	}
 	b := &bucket{
 		LHash:  lh,
 		objRef: objRef,
 	}
 	if err := b.populate(); err == nil {
+		lh.cacheBucket(b)
 		return b, nil
 	} else {
 		return nil, err
@@ -389,14 +824,17 @@ func (lh *LHash) newBucket(objRef client.ObjectRef) (*bucket, error) {
 }
 
 func (lh *LHash) newEmptyBucket(objRef client.ObjectRef) *bucket {
-	nextKeys := make([][]byte, mp.BucketCapacity)
-	return &bucket{
+	b := &bucket{
 		LHash:   lh,
 		objRef:  objRef,
-		entries: (*mp.Bucket)(&nextKeys),
+		entries: lh.pool.get(),
 		value:   nil,
 		refs:    []client.ObjectRef{objRef},
 	}
+	b.entries.Checkpoint = lh.root.CheckpointEpoch
+	b.grow(lh.root.BucketCapacity())
+	lh.cacheBucket(b)
+	return b
 }
 
 func (b *bucket) populate() error {
@@ -410,8 +848,16 @@ func (b *bucket) populate() error {
 		if err != nil {
 			return nil, err
 		}
-		entries := new(mp.Bucket)
-		_, err = entries.UnmarshalMsg(value)
+		if b.Strict {
+			if err = mp.CheckBucket(value); err != nil {
+				return nil, fmt.Errorf("LHash bucket %v is not in the current format: %v", obj, err)
+			}
+		}
+		entries := b.pool.get()
+		if err = mp.DecodeBucketInto(entries, value); err != nil {
+			return nil, err
+		}
+		err = b.checkVersion(BucketObject, b.objRef, entries.Version, mp.BucketVersion)
 		if err != nil {
 			return nil, err
 		}
@@ -428,56 +874,113 @@ func (b *bucket) populate() error {
 	return err
 }
 
-func (b *bucket) find(key []byte) (*client.ObjectRef, error) {
-	for idx, k := range ([][]byte)(*b.entries) {
-		if b.isSlotEmpty(idx) {
-			continue
-		} else if bytes.Equal(key, k) {
-			return &b.refs[idx+1], nil
-		}
+func (b *bucket) find(key []byte, h uint64, now int64) (value *client.ObjectRef, inline []byte, blob, expired bool, err error) {
+	bFound, idx, err := b.findSlotHash(key, h)
+	if err != nil || bFound == nil {
+		return nil, nil, false, false, err
+	} else if bFound.isExpired(idx, now) {
+		return nil, nil, false, true, nil
+	} else if inline = bFound.inlineAt(idx); inline != nil {
+		return nil, inline, false, false, nil
+	} else {
+		return &bFound.refs[idx+1], nil, bFound.isBlob(idx), false, nil
+	}
+}
+
+// findSlot searches the chain starting at b for key, returning the
+// bucket and slot index containing key, or a nil bucket if key is
+// not found.
+func (b *bucket) findSlot(key []byte) (*bucket, int, error) {
+	return b.findSlotHash(key, b.hash(key))
+}
+
+func (b *bucket) findSlotHash(key []byte, h uint64) (*bucket, int, error) {
+	// only head buckets have filters.
+	if !b.bloomMayContain(h) {
+		return nil, 0, nil
+	}
+	if slot := b.slotOf(key, h); slot != -1 {
+		return b, slot, nil
 	}
 
 	if bNext, err := b.next(); err != nil {
-		return nil, err
+		return nil, 0, err
 	} else if bNext != nil {
-		return bNext.find(key)
+		return bNext.findSlotHash(key, h)
 	} else {
-		return nil, nil
+		return nil, 0, nil
 	}
 }
 
-func (b *bucket) put(key []byte, value client.ObjectRef) (bNew *bucket, added bool, chainDelta int64, err error) {
+// slotOf returns the index of the slot in b (and not in the rest of
+// the chain) containing key, whose hash is h, or -1.
+func (b *bucket) slotOf(key []byte, h uint64) int {
+	if b.entries.Sorted {
+		return b.searchSlot(key, h)
+	}
+	for idx := range b.entries.Keys {
+		if !b.isSlotEmpty(idx) && b.matches(idx, key, h) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// matches returns true iff the non-empty slot idx holds key, whose
+// hash is h. If the bucket records the hashes of its keys then only
+// keys with equal hashes need comparing.
+func (b *bucket) matches(idx int, key []byte, h uint64) bool {
+	if hashes := b.entries.Hashes; len(hashes) == len(b.entries.Keys) && hashes[idx] != h {
+		return false
+	}
+	return bytes.Equal(key, b.entries.Keys[idx])
+}
+
+func (b *bucket) put(key []byte, value client.ObjectRef, e entry) (bNew *bucket, added bool, chainDelta int64, err error) {
+	if e.inline != nil {
+		// inline entries refer to their own bucket.
+		value = b.objRef
+	}
+	if idx := b.slotOf(key, e.hash); idx != -1 {
+		sameValue := b.refs[idx+1].ReferencesSameAs(value)
+		b.refs[idx+1] = value
+		// if we didn't change any keys or entry attributes then
+		// we don't need to serialize, and if we also didn't
+		// change the value then we don't need to write at all.
+		if changed := b.setEntry(idx, e); changed {
+			err = b.write(true)
+		} else if !sameValue {
+			err = b.write(false)
+		}
+		if err == nil {
+			return b, false, 0, nil
+		} else {
+			return
+		}
+	}
+
+	// key is not in this bucket, so if there's a hole for it, we
+	// can use it.
 	slot := -1
-	for idx, k := range ([][]byte)(*b.entries) {
+	for idx := range b.entries.Keys {
 		if b.isSlotEmpty(idx) {
-			if slot == -1 {
-				// we've found a hole for it, let's use it. But we can
-				// only use it if we're sure it's not already in this
-				// bucket.
-				slot = idx
-			}
-		} else if bytes.Equal(key, k) {
-			b.refs[idx+1] = value
-			// we didn't change any keys so don't need to serialize
-			err = b.write(false)
-			if err == nil {
-				return b, false, 0, nil
-			} else {
-				return
-			}
+			slot = idx
+			break
 		}
 	}
 
 	if slot == -1 {
-		return b.putInNext(key, value)
+		return b.putInNext(key, value, e)
 
 	} else {
-		return b.putInSlot(key, value, slot)
+		return b.putInSlot(key, value, e, slot)
 	}
 }
 
-func (b *bucket) putInSlot(key []byte, value client.ObjectRef, slot int) (bNew *bucket, added bool, chainDelta int64, err error) {
-	(*b.entries)[slot] = key
+func (b *bucket) putInSlot(key []byte, value client.ObjectRef, e entry, slot int) (bNew *bucket, added bool, chainDelta int64, err error) {
+	b.entries.Keys[slot] = key
+	b.entries.Sorted = false
+	b.setEntry(slot, e)
 	slot++
 	if slot == len(b.refs) {
 		b.refs = append(b.refs, value)
@@ -491,7 +994,7 @@ func (b *bucket) putInSlot(key []byte, value client.ObjectRef, slot int) (bNew *
 
 	} else if next != nil {
 		removed := false
-		next, removed, chainDelta, err = next.remove(key)
+		next, removed, chainDelta, err = next.remove(key, e.hash)
 		if err != nil {
 			return
 		}
@@ -515,14 +1018,14 @@ func (b *bucket) putInSlot(key []byte, value client.ObjectRef, slot int) (bNew *
 	}
 }
 
-func (b *bucket) putInNext(key []byte, value client.ObjectRef) (bNew *bucket, added bool, chainDelta int64, err error) {
+func (b *bucket) putInNext(key []byte, value client.ObjectRef, e entry) (bNew *bucket, added bool, chainDelta int64, err error) {
 	var next *bucket
 	if next, err = b.next(); err != nil {
 		return
 
 	} else if next != nil {
 		// next cannot change here
-		_, added, chainDelta, err = next.put(key, value)
+		_, added, chainDelta, err = next.put(key, value, e)
 		if err != nil {
 			return
 		}
@@ -537,7 +1040,7 @@ func (b *bucket) putInNext(key []byte, value client.ObjectRef) (bNew *bucket, ad
 			return
 		}
 		bNext := b.newEmptyBucket(res.(client.ObjectRef))
-		bNext, added, chainDelta, err = bNext.put(key, value)
+		bNext, added, chainDelta, err = bNext.put(key, value, e)
 		if err != nil {
 			return
 		}
@@ -552,23 +1055,18 @@ func (b *bucket) putInNext(key []byte, value client.ObjectRef) (bNew *bucket, ad
 	}
 }
 
-func (b *bucket) remove(key []byte) (bNew *bucket, removed bool, chainDelta int64, err error) {
-	slot := -1
-	for idx, k := range ([][]byte)(*b.entries) {
-		if b.isSlotEmpty(idx) {
-			continue
-		} else if bytes.Equal(key, k) {
-			slot = idx
-			break
-		}
+func (b *bucket) remove(key []byte, h uint64) (bNew *bucket, removed bool, chainDelta int64, err error) {
+	if !b.bloomMayContain(h) {
+		return b, false, 0, nil
 	}
+	slot := b.slotOf(key, h)
 
 	if slot == -1 {
 		var next *bucket
 		if next, err = b.next(); err != nil {
 			return
 		} else if next != nil {
-			next, removed, chainDelta, err = next.remove(key)
+			next, removed, chainDelta, err = next.remove(key, h)
 			if err != nil {
 				return
 			}
@@ -588,10 +1086,15 @@ func (b *bucket) remove(key []byte) (bNew *bucket, removed bool, chainDelta int6
 		}
 
 	} else {
-		(*b.entries)[slot] = nil
+		b.entries.Keys[slot] = nil
+		b.entries.Sorted = false
+		b.setEntry(slot, entry{})
 		slot++
 		b.refs[slot] = b.objRef
-		b.tidyRefTail()
+		if !b.root.WriteHeavy {
+			// in write-heavy mode, this is left to Compact.
+			b.tidyRefTail()
+		}
 		if len(b.refs) == 1 { // we're empty; don't need to write us, just disconnect us.
 			var next *bucket
 			next, err = b.next()
@@ -611,9 +1114,9 @@ func (b *bucket) remove(key []byte) (bNew *bucket, removed bool, chainDelta int6
 	}
 }
 
-func (b *bucket) forEach(f func([]byte, client.ObjectRef) error) error {
-	for idx, k := range ([][]byte)(*b.entries) {
-		if b.isSlotEmpty(idx) {
+func (b *bucket) forEach(f func([]byte, client.ObjectRef) error, now int64) error {
+	for idx, k := range b.entries.Keys {
+		if b.isSlotEmpty(idx) || b.isExpired(idx, now) || b.isInline(idx) {
 			continue
 		}
 		if err := f(k, b.refs[idx+1]); err != nil {
@@ -623,7 +1126,7 @@ func (b *bucket) forEach(f func([]byte, client.ObjectRef) error) error {
 	if bNext, err := b.next(); err != nil {
 		return err
 	} else if bNext != nil {
-		return bNext.forEach(f)
+		return bNext.forEach(f, now)
 	} else {
 		return nil
 	}
@@ -631,29 +1134,54 @@ func (b *bucket) forEach(f func([]byte, client.ObjectRef) error) error {
 
 func (b *bucket) tidyRefTail() {
 	idx := len(b.refs) - 1
-	for ; idx > 0 && b.objRef.ReferencesSameAs(b.refs[idx]); idx-- {
+	for ; idx > 0 && b.isSlotEmpty(idx-1); idx-- {
 	}
 	b.refs = b.refs[:idx+1]
 }
 
 func (b *bucket) write(updateEntries bool) (err error) {
+	if epoch := b.root.ChangeEpoch; epoch > 0 && b.entries.Epoch != epoch {
+		// stamp the bucket as changed in the current epoch.
+		b.entries.Epoch = epoch
+		updateEntries = true
+	}
+	objRef, err := b.writeTarget()
+	if err != nil {
+		return err
+	} else if b.entries.Checkpoint != b.root.CheckpointEpoch {
+		b.entries.Checkpoint = b.root.CheckpointEpoch
+		updateEntries = true
+	}
 	if updateEntries {
-		b.value, err = b.entries.MarshalMsg(b.value[:0])
+		if b.root.SortedBuckets {
+			b.sortSlots()
+		}
+		b.entries.Version = mp.BucketVersion
+		if b.encoder == nil {
+			b.LHash.encoder = mp.NewEncoder()
+		}
+		b.value, err = b.encoder.EncodeBucket(b.value[:0], b.entries)
 		if err != nil {
 			return err
 		}
 	}
-	return b.objRef.Set(b.value, b.refs...)
+	return objRef.Set(b.value, b.copiedRefs(b.refs)...)
 }
 
 func (b *bucket) next() (*bucket, error) {
 	if b.refs[0].ReferencesSameAs(b.objRef) {
 		return nil, nil
-	} else {
-		return b.newBucket(b.refs[0])
 	}
+	n, err := b.newBucket(b.refs[0])
+	if err == nil {
+		n.chain = b.chain
+	}
+	return n, err
 }
 
+// isSlotEmpty returns true iff slot idx holds no entry. The reference
+// of an empty slot is to the bucket itself; inline entries also refer
+// to the bucket itself, but are flagged as inline.
 func (b *bucket) isSlotEmpty(idx int) bool {
-	return idx+1 >= len(b.refs) || b.refs[idx+1].ReferencesSameAs(b.objRef)
+	return idx+1 >= len(b.refs) || (b.refs[idx+1].ReferencesSameAs(b.objRef) && !b.isInline(idx))
 }
@@ -1,8 +1,14 @@
 package linearhash
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
 	"goshawkdb.io/tests"
 	"math/rand"
 	"testing"
@@ -19,6 +25,16 @@ func createEmpty(th *tests.TestHelper) *LHash {
 	return lh
 }
 
+// stateOf returns the session most recently used by operations on lh,
+// for inspecting the state those operations left behind. As sessions
+// are reused most recently released first, after operations made one
+// at a time this is the session all of them ran on.
+func stateOf(lh *LHash) *LHash {
+	s := lh.acquire()
+	lh.release(s)
+	return s
+}
+
 func assertSize(th *tests.TestHelper, lh *LHash, expected int64) {
 	size, err := lh.Size()
 	if err != nil {
@@ -248,3 +264,2288 @@ func TestSoak(t *testing.T) {
 		}
 	}
 }
+
+func TestWatch(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	c1 := th.CreateConnections(1)[0]
+	key := []byte("watched")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := lh.Watch(ctx, c1.Connection, key)
+	if err != nil {
+		th.Fatal(err)
+	}
+
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("hello"))
+		if err != nil {
+			return nil, err
+		}
+		return valueObj, lh.Put(key, valueObj)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	valueObj := res.(client.ObjectRef)
+	if value, ok := <-ch; !ok || value == nil || !value.ReferencesSameAs(valueObj) {
+		th.Fatalf("Expected to observe Put of %v. Got %v", valueObj, value)
+	}
+
+	if err = lh.Remove(key); err != nil {
+		th.Fatal(err)
+	}
+	if value, ok := <-ch; !ok || value != nil {
+		th.Fatalf("Expected to observe Remove. Got %v", value)
+	}
+}
+
+func TestWatchAll(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	c1 := th.CreateConnections(1)[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := lh.WatchAll(ctx, c1.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+
+	_, _, err = lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("hello"))
+		if err != nil {
+			return nil, err
+		}
+		return nil, lh.Put([]byte("a"), valueObj)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if event, ok := <-ch; !ok || string(event.Key) != "a" || event.Value == nil {
+		th.Fatalf("Expected to observe Put of a. Got %v", event)
+	}
+
+	if err = lh.Remove([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	if event, ok := <-ch; !ok || string(event.Key) != "a" || event.Value != nil {
+		th.Fatalf("Expected to observe Remove of a. Got %v", event)
+	}
+
+	// the puts split buckets, but entries which move between buckets
+	// must not be reported again.
+	for idx := 0; idx < 200; idx++ {
+		if err = lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	seen := make(map[string]bool)
+	for len(seen) < 200 {
+		event, ok := <-ch
+		if !ok || event.Value == nil || seen[string(event.Key)] {
+			th.Fatalf("Expected to observe each Put once. Got %v", event)
+		}
+		seen[string(event.Key)] = true
+	}
+	if err = lh.Remove([]byte("7")); err != nil {
+		th.Fatal(err)
+	}
+	if event, ok := <-ch; !ok || string(event.Key) != "7" || event.Value != nil {
+		th.Fatalf("Expected to observe Remove of 7. Got %v", event)
+	}
+}
+
+func TestGrantReadOnly(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("hello"))
+		if err != nil {
+			return nil, err
+		}
+		return nil, lh.Put([]byte("a"), valueObj)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return lh.GrantReadOnly(txn)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	ro := LHashFromObj(lh.Conn, res.(client.ObjectRef))
+	assertSize(th, ro, 1)
+	if value, err := ro.Find([]byte("a")); err != nil {
+		th.Fatal(err)
+	} else if value == nil {
+		th.Fatal("Failed to find entry via read-only reference")
+	}
+	if err = ro.Remove([]byte("a")); err != ErrReadOnly {
+		th.Fatalf("Expected ErrReadOnly from Remove. Got %v", err)
+	}
+	assertSize(th, lh, 1)
+}
+
+func TestMeta(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if value, err := lh.GetMeta("schema"); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatalf("Expected no metadata. Got %v", value)
+	}
+	if err := lh.SetMeta("schema", []byte("v2")); err != nil {
+		th.Fatal(err)
+	}
+
+	lh2 := LHashFromObj(lh.Conn, lh.ObjRef)
+	if value, err := lh2.GetMeta("schema"); err != nil {
+		th.Fatal(err)
+	} else if string(value) != "v2" {
+		th.Fatalf("Expected metadata v2. Got %v", value)
+	} else {
+		// modifying the result must not modify the cached root.
+		value[1] = '3'
+	}
+	if value, err := lh2.GetMeta("schema"); err != nil {
+		th.Fatal(err)
+	} else if string(value) != "v2" {
+		th.Fatalf("Expected metadata v2 after modifying a copy. Got %v", value)
+	}
+	if err := lh2.SetMeta("schema", nil); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := lh.GetMeta("schema"); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatalf("Expected metadata to be removed. Got %v", value)
+	}
+	assertSize(th, lh, 0)
+}
+
+func TestExpiry(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	expiry := time.Now().Add(500 * time.Millisecond)
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		for idx := 0; idx < 16; idx++ {
+			valueObj, err := txn.CreateObject([]byte("hello"))
+			if err != nil {
+				return nil, err
+			}
+			key := []byte(fmt.Sprintf("%v", idx))
+			if idx%2 == 0 {
+				err = lh.PutWithExpiry(key, valueObj, expiry)
+			} else {
+				err = lh.Put(key, valueObj)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if value, err := lh.Find([]byte("0")); err != nil {
+		th.Fatal(err)
+	} else if value == nil {
+		th.Fatal("Entry expired too soon")
+	}
+
+	time.Sleep(time.Until(expiry))
+	if value, err := lh.Find([]byte("0")); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatal("Expected expired entry to be absent")
+	}
+	assertSize(th, lh, 15)
+
+	removed, err := lh.SweepExpired(4)
+	if err != nil {
+		th.Fatal(err)
+	} else if removed != 4 {
+		th.Fatalf("Expected to sweep 4 entries. Swept %v", removed)
+	}
+	removed, err = lh.SweepExpired(10)
+	if err != nil {
+		th.Fatal(err)
+	} else if removed != 3 {
+		th.Fatalf("Expected to sweep 3 entries. Swept %v", removed)
+	}
+	assertSize(th, lh, 8)
+}
+
+func TestMaxSize(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetMaxSize(8); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 20; idx++ {
+		key := []byte(fmt.Sprintf("%v", idx))
+		_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			valueObj, err := txn.CreateObject(key)
+			if err != nil {
+				return nil, err
+			}
+			return nil, lh.Put(key, valueObj)
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 8)
+	if value, err := lh.Find([]byte("19")); err != nil {
+		th.Fatal(err)
+	} else if value == nil {
+		th.Fatal("Most recently put entry was evicted")
+	}
+
+	if err := lh.SetMaxSize(4); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 4)
+}
+
+func TestLockKey(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	key := []byte("doc")
+	if locked, err := lh.LockKey(key, []byte("alice")); err != nil {
+		th.Fatal(err)
+	} else if !locked {
+		th.Fatal("Failed to lock unlocked key")
+	}
+	if locked, err := lh.LockKey(key, []byte("bob")); err != nil {
+		th.Fatal(err)
+	} else if locked {
+		th.Fatal("Locked key which is already locked")
+	}
+	if err := lh.UnlockKey(key, []byte("bob")); err != ErrNotLockHolder {
+		th.Fatalf("Expected ErrNotLockHolder. Got %v", err)
+	}
+	if holder, err := lh.LockHolder(key); err != nil {
+		th.Fatal(err)
+	} else if string(holder) != "alice" {
+		th.Fatalf("Expected lock holder alice. Got %v", holder)
+	}
+	if err := lh.UnlockKey(key, []byte("alice")); err != nil {
+		th.Fatal(err)
+	}
+	if err := lh.Lock(key, []byte("bob")); err != nil {
+		th.Fatal(err)
+	}
+	if holder, err := lh.LockHolder(key); err != nil {
+		th.Fatal(err)
+	} else if string(holder) != "bob" {
+		th.Fatalf("Expected lock holder bob. Got %v", holder)
+	}
+}
+
+func TestPutIfVersion(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	key := []byte("doc")
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject([]byte("hello"))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	valueObj := res.(client.ObjectRef)
+
+	if version, err := lh.PutIfVersion(key, valueObj, 0); err != nil {
+		th.Fatal(err)
+	} else if version != 1 {
+		th.Fatalf("Expected version 1. Got %v", version)
+	}
+	// putting the same value again changes nothing, so neither
+	// does the version.
+	if err = lh.Put(key, valueObj); err != nil {
+		th.Fatal(err)
+	}
+	if _, version, err := lh.FindVersion(key); err != nil {
+		th.Fatal(err)
+	} else if version != 1 {
+		th.Fatalf("Expected no-op Put to leave version 1. Got %v", version)
+	}
+	res, _, err = lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject([]byte("world"))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = lh.Put(key, res.(client.ObjectRef)); err != nil {
+		th.Fatal(err)
+	}
+	if _, err = lh.PutIfVersion(key, valueObj, 1); err == nil {
+		th.Fatal("Expected version conflict")
+	} else if ce, ok := err.(*ConflictError); !ok || ce.Actual != 2 {
+		th.Fatalf("Expected ConflictError with actual version 2. Got %v", err)
+	}
+	if value, version, err := lh.FindVersion(key); err != nil {
+		th.Fatal(err)
+	} else if value == nil || version != 2 {
+		th.Fatalf("Expected to find version 2. Got %v %v", value, version)
+	}
+}
+
+func TestReverseIndex(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("shared"))
+		if err != nil {
+			return nil, err
+		}
+		return valueObj, lh.Put([]byte("a"), valueObj)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	valueObj := res.(client.ObjectRef)
+	if err = lh.EnableReverseIndex(); err != nil {
+		th.Fatal(err)
+	}
+	if err = lh.Put([]byte("b"), valueObj); err != nil {
+		th.Fatal(err)
+	}
+	if keys, err := lh.KeysFor(valueObj); err != nil {
+		th.Fatal(err)
+	} else if len(keys) != 2 {
+		th.Fatalf("Expected 2 keys referring to value. Got %v", len(keys))
+	}
+	if err = lh.Remove([]byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	if err = lh.Remove([]byte("b")); err != nil {
+		th.Fatal(err)
+	}
+	if keys, err := lh.KeysFor(valueObj); err != nil {
+		th.Fatal(err)
+	} else if len(keys) != 0 {
+		th.Fatalf("Expected no keys referring to value. Got %v", len(keys))
+	}
+	assertSize(th, lh, 0)
+}
+
+func TestFindMany(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	keys := make([][]byte, 0, 200)
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		for idx := 0; idx < 100; idx++ {
+			key := []byte(fmt.Sprintf("%v", idx))
+			valueObj, err := txn.CreateObject(key)
+			if err != nil {
+				return nil, err
+			}
+			if err = lh.Put(key, valueObj); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 200; idx++ {
+		keys = append(keys, []byte(fmt.Sprintf("%v", idx)))
+	}
+	results, err := lh.FindMany(keys)
+	if err != nil {
+		th.Fatal(err)
+	}
+	for idx, result := range results {
+		if idx < 100 && result == nil {
+			th.Fatalf("Failed to find entry for %v", idx)
+		} else if idx >= 100 && result != nil {
+			th.Fatalf("Found unexpected entry for %v", idx)
+		}
+	}
+}
+
+func TestRootCache(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh1 := createEmpty(th)
+	lh2 := LHashFromObj(lh1.Conn, lh1.ObjRef)
+	assertSize(th, lh1, 0)
+	assertSize(th, lh2, 0)
+
+	_, _, err := lh1.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		for idx := 0; idx < 10; idx++ {
+			if err = lh1.Put([]byte(fmt.Sprintf("%v", idx)), valueObj); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	// lh2 has cached the empty root, and must notice it has changed.
+	assertSize(th, lh2, 10)
+	if err = lh2.Remove([]byte("0")); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh1, 9)
+}
+
+func TestShardedLHash(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	s, err := NewEmptyShardedLHash(c0.Connection, 4)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if shards, err := s.Shards(); err != nil {
+		th.Fatal(err)
+	} else if shards != 4 {
+		th.Fatalf("Expected 4 shards. Got %v", shards)
+	}
+	_, _, err = s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		for idx := 0; idx < 100; idx++ {
+			if err = s.Put([]byte(fmt.Sprintf("%v", idx)), valueObj); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if size, err := s.Size(); err != nil {
+		th.Fatal(err)
+	} else if size != 100 {
+		th.Fatalf("Expected size 100. Got %v", size)
+	}
+	count := 0
+	if err = s.ForEach(func(key []byte, value client.ObjectRef) error {
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != 100 {
+		th.Fatalf("Expected to iterate over 100 entries. Got %v", count)
+	}
+
+	s2 := ShardedLHashFromObj(s.Conn, s.ObjRef)
+	if value, err := s2.Find([]byte("42")); err != nil {
+		th.Fatal(err)
+	} else if value == nil {
+		th.Fatal("Failed to find entry 42")
+	}
+	if err = s2.Remove([]byte("42")); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := s.Find([]byte("42")); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatal("Found removed entry 42")
+	}
+}
+
+func TestStripeSize(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.StripeSize(4); err != nil {
+		th.Fatal(err)
+	}
+	if err := lh.StripeSize(8); err == nil {
+		th.Fatal("Expected error when changing the number of stripes")
+	}
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		for idx := 0; idx < 500; idx++ {
+			if err = lh.Put([]byte(fmt.Sprintf("%v", idx)), valueObj); err != nil {
+				return nil, err
+			}
+		}
+		for idx := 0; idx < 100; idx++ {
+			if err = lh.Remove([]byte(fmt.Sprintf("%v", idx))); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 400)
+	assertSize(th, LHashFromObj(lh.Conn, lh.ObjRef), 400)
+}
+
+func TestDeferSplits(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetDeferSplits(true); err != nil {
+		th.Fatal(err)
+	}
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		for idx := 0; idx < 500; idx++ {
+			if err = lh.Put([]byte(fmt.Sprintf("%v", idx)), valueObj); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	bucketsBefore := int(stateOf(lh).directoryLen())
+	if bucketsBefore != 2 {
+		th.Fatalf("Expected no splits to have happened. Got %v buckets", bucketsBefore)
+	}
+	splits := 0
+	for {
+		split, err := lh.Maintain()
+		if err != nil {
+			th.Fatal(err)
+		} else if !split {
+			break
+		}
+		splits++
+	}
+	if splits == 0 {
+		th.Fatal("Expected Maintain to split buckets")
+	}
+	if split, err := lh.Maintain(); err != nil {
+		th.Fatal(err)
+	} else if split {
+		th.Fatal("Expected nothing further to maintain")
+	}
+	assertSize(th, lh, 500)
+	for idx := 0; idx < 500; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry %v after maintenance", idx)
+		}
+	}
+}
+
+func TestMaintainBatch(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetDeferSplits(true); err != nil {
+		th.Fatal(err)
+	}
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		for idx := 0; idx < 1000; idx++ {
+			if err = lh.Put([]byte(fmt.Sprintf("%v", idx)), valueObj); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if splits, err := lh.MaintainBatch(0); err != nil {
+		th.Fatal(err)
+	} else if splits < 2 {
+		th.Fatalf("Expected several splits in one batch. Got %v", splits)
+	}
+	if s := stateOf(lh); s.root.SplitPending || s.root.ChainsNeedSplitAt(s.root.Size) {
+		th.Fatal("Expected the batch to bring the chains back below the threshold")
+	}
+	if splits, err := lh.MaintainBatch(0); err != nil {
+		th.Fatal(err)
+	} else if splits != 0 {
+		th.Fatalf("Expected nothing further to maintain. Got %v splits", splits)
+	}
+	assertSize(th, lh, 1000)
+}
+
+func TestSortedBuckets(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetSortedBuckets(true); err != nil {
+		th.Fatal(err)
+	}
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		for idx := 0; idx < 300; idx++ {
+			key := []byte(fmt.Sprintf("%v", idx))
+			valueObj, err := txn.CreateObject(key)
+			if err != nil {
+				return nil, err
+			}
+			if err = lh.Put(key, valueObj); err != nil {
+				return nil, err
+			}
+		}
+		for idx := 0; idx < 300; idx += 3 {
+			if err := lh.Remove([]byte(fmt.Sprintf("%v", idx))); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 200)
+	for idx := 0; idx < 300; idx++ {
+		key := []byte(fmt.Sprintf("%v", idx))
+		value, err := lh.Find(key)
+		if err != nil {
+			th.Fatal(err)
+		} else if idx%3 == 0 && value != nil {
+			th.Fatalf("Found removed entry %v", idx)
+		} else if idx%3 != 0 {
+			if value == nil {
+				th.Fatalf("Failed to find entry %v", idx)
+			} else if v, err := value.Value(); err != nil {
+				th.Fatal(err)
+			} else if !bytes.Equal(v, key) {
+				th.Fatalf("Entry %v has wrong value %s", idx, v)
+			}
+		}
+	}
+}
+
+func TestInlineValues(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(8); err != nil {
+		th.Fatal(err)
+	}
+	small, large := []byte("small"), []byte("a much larger value")
+	for idx := 0; idx < 100; idx++ {
+		value := small
+		if idx%2 == 0 {
+			value = large
+		}
+		if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), value); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err := lh.PutValue([]byte("empty"), []byte{}); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 101)
+
+	if value, err := lh.FindValue([]byte("1")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, small) {
+		th.Fatalf("Expected inline value %s. Got %s", small, value)
+	}
+	if value, err := lh.FindValue([]byte("2")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, large) {
+		th.Fatalf("Expected value %s. Got %s", large, value)
+	}
+	if value, err := lh.FindValue([]byte("empty")); err != nil {
+		th.Fatal(err)
+	} else if value == nil || len(value) != 0 {
+		th.Fatalf("Expected empty inline value. Got %v", value)
+	}
+	if _, err := lh.Find([]byte("1")); err != ErrInlineValue {
+		th.Fatalf("Expected ErrInlineValue from Find. Got %v", err)
+	}
+	if value, err := lh.Find([]byte("2")); err != nil || value == nil {
+		th.Fatalf("Expected to Find value Object. Got %v %v", value, err)
+	}
+
+	count := 0
+	if err := lh.ForEachValue(func(key, value []byte) error {
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != 101 {
+		th.Fatalf("Expected ForEachValue to visit 101 entries. Got %v", count)
+	}
+
+	if err := lh.Remove([]byte("1")); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := lh.FindValue([]byte("1")); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatalf("Found removed inline value %s", value)
+	}
+	assertSize(th, lh, 100)
+}
+
+func TestBlobValues(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetBlobThreshold(1024); err != nil {
+		th.Fatal(err)
+	}
+	huge := bytes.Repeat([]byte("0123456789abcdef"), 10000)
+	if err := lh.PutValue([]byte("huge"), huge); err != nil {
+		th.Fatal(err)
+	}
+	if err := lh.PutValue([]byte("small"), []byte("small")); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := lh.FindValue([]byte("huge")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, huge) {
+		th.Fatalf("Expected blob value of %v bytes. Got %v bytes", len(huge), len(value))
+	}
+	if value, err := lh.FindValue([]byte("small")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, []byte("small")) {
+		th.Fatalf("Expected value small. Got %s", value)
+	}
+	if stats, err := lh.Stats(); err != nil {
+		th.Fatal(err)
+	} else if stats.BlobEntries != 1 {
+		th.Fatalf("Expected 1 blob entry. Got %v", stats.BlobEntries)
+	}
+
+	found := 0
+	if err := lh.ForEachValue(func(key, value []byte) error {
+		if string(key) == "huge" && bytes.Equal(value, huge) {
+			found++
+		}
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if found != 1 {
+		th.Fatal("ForEachValue did not supply the blob value")
+	}
+
+	var dump bytes.Buffer
+	if _, err := lh.Dump(&dump); err != nil {
+		th.Fatal(err)
+	}
+	restored, err := Restore(lh.Conn, &dump)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if value, err := restored.FindValue([]byte("huge")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, huge) {
+		th.Fatal("Restored blob value differs")
+	}
+
+	// overwriting with a small value clears the blob flag.
+	if err := lh.PutValue([]byte("huge"), []byte("tiny")); err != nil {
+		th.Fatal(err)
+	}
+	if value, err := lh.FindValue([]byte("huge")); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(value, []byte("tiny")) {
+		th.Fatalf("Expected value tiny. Got %v bytes", len(value))
+	}
+}
+
+func TestPeekContains(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("value"))
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.Put([]byte("present"), valueObj); err != nil {
+			return nil, err
+		}
+		return valueObj, lh.PutWithExpiry([]byte("expired"), valueObj, time.Now().Add(-time.Second))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	valueObj := res.(client.ObjectRef)
+	if value, err := lh.Peek([]byte("present")); err != nil {
+		th.Fatal(err)
+	} else if value == nil || !value.ReferencesSameAs(valueObj) {
+		th.Fatalf("Expected to Peek value. Got %v", value)
+	}
+	for key, expected := range map[string]bool{"present": true, "expired": false, "absent": false} {
+		if found, err := lh.Contains([]byte(key)); err != nil {
+			th.Fatal(err)
+		} else if found != expected {
+			th.Fatalf("Expected Contains(%v) to be %v", key, expected)
+		}
+	}
+	// Peek and Contains must not have removed the expired entry.
+	assertSize(th, lh, 2)
+}
+
+func TestSizeEstimate(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if _, at := lh.SizeEstimate(); !at.IsZero() {
+		th.Fatal("Expected no size estimate before reading the size")
+	}
+	if err := lh.PutValue([]byte("a"), []byte("a")); err != nil {
+		th.Fatal(err)
+	}
+	if size, err := lh.SizeRefresh(); err != nil {
+		th.Fatal(err)
+	} else if size != 1 {
+		th.Fatalf("Expected size 1. Got %v", size)
+	}
+	if size, at := lh.SizeEstimate(); size != 1 || at.IsZero() {
+		th.Fatalf("Expected size estimate of 1. Got %v at %v", size, at)
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		entries := make([]Entry, 0, 2001)
+		for idx := 0; idx < 2000; idx++ {
+			key := []byte(fmt.Sprintf("%v", idx))
+			valueObj, err := txn.CreateObject(key)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, Entry{Key: key, Value: valueObj})
+		}
+		// a duplicate key: the later entry wins.
+		entries = append(entries, Entry{Key: []byte("0"), Value: entries[1].Value})
+		return nil, lh.BulkLoad(entries)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 2000)
+	for idx := 0; idx < 2000; idx++ {
+		key := []byte(fmt.Sprintf("%v", idx))
+		value, err := lh.Find(key)
+		if err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry for %v", idx)
+		}
+		expected := key
+		if idx == 0 {
+			expected = []byte("1")
+		}
+		if bs, err := value.Value(); err != nil {
+			th.Fatal(err)
+		} else if !bytes.Equal(bs, expected) {
+			th.Fatalf("Wrong value for %v: %s", idx, bs)
+		}
+	}
+	if err = lh.Put([]byte("2000"), lh.ObjRef); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 2001)
+	if err = lh.BulkLoad(nil); err != ErrNotEmpty {
+		th.Fatalf("Expected ErrNotEmpty. Got %v", err)
+	}
+}
+
+func TestHashMemo(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	lh.SetHashMemo(16)
+	// the memoized bucket indices must follow the LHash as it splits.
+	for round := 0; round < 3; round++ {
+		for idx := 0; idx < 200; idx++ {
+			key := []byte(fmt.Sprintf("%v", idx%32))
+			if round == 0 && idx >= 32 {
+				key = []byte(fmt.Sprintf("%v", idx))
+			}
+			if err := lh.Put(key, lh.ObjRef); err != nil {
+				th.Fatal(err)
+			}
+			if value, err := lh.Find(key); err != nil {
+				th.Fatal(err)
+			} else if value == nil {
+				th.Fatalf("Failed to find entry for %s", key)
+			}
+		}
+	}
+	assertSize(th, lh, 200)
+	if memo := stateOf(lh).memo; memo.order.Len() != 16 || len(memo.entries) != 16 {
+		th.Fatalf("Expected 16 memoized keys. Got %v", memo.order.Len())
+	}
+	lh.SetHashMemo(0)
+	if stateOf(lh).memo != nil {
+		th.Fatal("Expected the memo to be disabled")
+	}
+}
+
+func TestMaxChainLength(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lhCapped := createEmpty(th)
+	if err := lhCapped.SetMaxChainLength(1); err != nil {
+		th.Fatal(err)
+	}
+	lhPlain := LHashFromObj(lhCapped.Conn, createEmpty(th).ObjRef)
+	for idx := 0; idx < 1000; idx++ {
+		key := []byte(fmt.Sprintf("%v", idx))
+		if err := lhCapped.Put(key, lhCapped.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+		if err := lhPlain.Put(key, lhPlain.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lhCapped, 1000)
+	assertSize(th, lhPlain, 1000)
+	// overflowing any chain forces a split, so the capped LHash
+	// must have split more.
+	capped, plain := stateOf(lhCapped).directoryLen(), stateOf(lhPlain).directoryLen()
+	if capped <= plain {
+		th.Fatalf("Expected more chains with a maximum chain length. Got %v and %v", capped, plain)
+	}
+	for idx := 0; idx < 1000; idx++ {
+		if value, err := lhCapped.Find([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry for %v", idx)
+		}
+	}
+}
+
+func TestAdaptiveUtilization(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetAdaptiveUtilization(0.9, 0.5); err == nil {
+		th.Fatal("Expected an error for inverted bounds")
+	}
+	if err := lh.SetAdaptiveUtilization(0.5, 0.9); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 1000; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 1000)
+	// splitting continuously should have pulled the threshold below
+	// the midpoint.
+	if u := stateOf(lh).root.Utilization; u < 0.5 || u >= 0.7 {
+		th.Fatalf("Expected utilization between 0.5 and 0.7. Got %v", u)
+	}
+	if err := lh.SetAdaptiveUtilization(0, 0); err != nil {
+		th.Fatal(err)
+	}
+	if u := stateOf(lh).root.Threshold(); u != 0.75 {
+		th.Fatalf("Expected the fixed threshold to be restored. Got %v", u)
+	}
+}
+
+func TestBloomBits(t *testing.T) {
+	b := &bucket{entries: mp.NewBucket()}
+	if b.bloomAdd(1) || !b.bloomMayContain(1) || !b.bloomMayContain(2) {
+		t.Fatal("A bucket without a filter must admit every hash")
+	}
+	b.entries.Bloom = make([]byte, bloomBytes)
+	for h := uint64(0); h < 64; h++ {
+		b.bloomAdd(h << 32)
+	}
+	rejected := 0
+	for h := uint64(0); h < 1024; h++ {
+		if h < 64 && !b.bloomMayContain(h<<32) {
+			t.Fatalf("Filter lost hash %v", h<<32)
+		} else if h >= 64 && !b.bloomMayContain(h<<32) {
+			rejected++
+		}
+	}
+	if rejected < 800 {
+		t.Fatalf("Filter rejected only %v of 960 absent hashes", rejected)
+	}
+}
+
+func TestChainBloom(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	// without splits, the two chains grow long.
+	if err := lh.SetDeferSplits(true); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 500; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for idx := 0; idx < 1000; idx++ {
+		if found, err := lh.Contains([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if found != (idx < 500) {
+			th.Fatalf("Contains %v returned %v", idx, found)
+		}
+	}
+	rejected := 0
+	s := stateOf(lh)
+	for idx := 500; idx < 1000; idx++ {
+		h, bIdx := s.locate([]byte(fmt.Sprintf("%v", idx)))
+		head, err := s.head(bIdx)
+		if err != nil {
+			th.Fatal(err)
+		} else if len(head.entries.Bloom) != bloomBytes {
+			th.Fatal("Expected the head of a long chain to have a filter")
+		} else if !head.bloomMayContain(h) {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		th.Fatal("Expected the filters to reject some absent keys")
+	}
+	// splitting rebuilds the filters.
+	if _, err := lh.MaintainBatch(0); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 500; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry for %v after splitting", idx)
+		}
+	}
+}
+
+func TestDirectoryPage(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	for idx := 0; idx < 200; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	rootRefs := func() int {
+		res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			obj, err := txn.GetObject(lh.ObjRef)
+			if err != nil {
+				return nil, err
+			}
+			_, refs, err := obj.ValueReferences()
+			return len(refs), err
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+		return res.(int)
+	}
+	if n := rootRefs(); n != 1 || stateOf(lh).root.DirectoryPages != 1 || stateOf(lh).directoryLen() < 4 {
+		th.Fatalf("Expected the root to reference just the directory page. Got %v references", n)
+	}
+
+	// rewrite the root in the original layout, with the directory in
+	// the root, which must still be readable and is converted by the
+	// next write.
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := lh.populate(); err != nil {
+			return nil, err
+		}
+		refs, err := lh.allBucketRefs()
+		if err != nil {
+			return nil, err
+		}
+		lh.root.DirectoryPages = 0
+		lh.root.DirectoryPageSize = 0
+		value, err := lh.root.UpdateRaw().MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return nil, lh.ObjRef.Set(value, refs...)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	legacy := LHashFromObj(lh.Conn, lh.ObjRef)
+	assertSize(th, legacy, 200)
+	if n := rootRefs(); uint64(n) != stateOf(legacy).directoryLen() || stateOf(legacy).root.DirectoryPages != 0 {
+		th.Fatalf("Expected the root to reference the buckets. Got %v references", n)
+	}
+	if err = legacy.Put([]byte("200"), lh.ObjRef); err != nil {
+		th.Fatal(err)
+	}
+	if n := rootRefs(); n != 1 {
+		th.Fatalf("Expected the root to have been converted. Got %v references", n)
+	}
+	for idx := 0; idx <= 200; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry for %v", idx)
+		}
+	}
+}
+
+func TestPagedDirectory(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	defer func(size int64) { directoryPageSize = size }(directoryPageSize)
+	directoryPageSize = 4
+
+	lh := createEmpty(th)
+	for idx := 0; idx < 1000; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 1000)
+	s := stateOf(lh)
+	if pages := s.root.DirectoryPages; pages < 2 || uint64(pages) != (s.directoryLen()+3)/4 {
+		th.Fatalf("Expected the directory of %v buckets to be paged by 4. Got %v pages", s.directoryLen(), pages)
+	}
+	for idx := 0; idx < 1000; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find entry for %v", idx)
+		}
+		// only the page referencing the key's chain is read.
+		loaded := 0
+		for _, page := range stateOf(lh).dir {
+			if page.loaded {
+				loaded++
+			}
+		}
+		if loaded != 1 {
+			th.Fatalf("Expected Find to load 1 directory page. Got %v", loaded)
+		}
+	}
+	count := 0
+	if err := lh.ForEach(func(key []byte, value client.ObjectRef) error {
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != 1000 {
+		th.Fatalf("Expected ForEach to visit 1000 entries. Got %v", count)
+	}
+}
+
+func TestMaintainer(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetDeferSplits(true); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 500; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-time.Minute)
+	for idx := 500; idx < 510; idx++ {
+		if err := lh.PutWithExpiry([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef, past); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 510)
+
+	lh.Strict = true
+	m := NewMaintainer(th.CreateConnections(1)[0].Connection, lh)
+	if !m.collections[0].Strict {
+		th.Fatal("Expected the Maintainer to take the Strict setting of the LHash")
+	}
+	// negative limits disable each kind of work.
+	m.MaxSplits, m.ExpiryLimit, m.CompactLimit = -1, -1, -1
+	if err := m.Sweep(); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 510)
+	if !stateOf(lh).root.SplitPending {
+		th.Fatal("Expected the deferred splits to remain")
+	}
+
+	// and 0 means no limit.
+	m.MaxSplits, m.ExpiryLimit, m.CompactLimit = 0, 0, 0
+	if err := m.Sweep(); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, 500)
+	if s := stateOf(lh); s.root.SplitPending || s.root.NeedsSplit() {
+		th.Fatal("Expected the sweep to have performed all the deferred splits")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Run(ctx); err != context.Canceled {
+		th.Fatalf("Expected Run to stop when cancelled. Got %v", err)
+	}
+}
+
+func TestReadCache(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	key := []byte("cached")
+	rc, err := NewReadCache(lh, th.CreateConnections(1)[0].Connection, 16, true)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if value, err := rc.Find(key); err != nil || value != nil {
+		th.Fatalf("Expected to find nothing. Got %v, %v", value, err)
+	}
+
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		valueObj, err := txn.CreateObject([]byte("hello"))
+		if err != nil {
+			return nil, err
+		}
+		return valueObj, lh.Put(key, valueObj)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	valueObj := res.(client.ObjectRef)
+
+	// the absent entry is dropped from the cache once the watcher
+	// notices the Put.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		value, err := rc.Find(key)
+		if err != nil {
+			th.Fatal(err)
+		} else if value != nil && value.ReferencesSameAs(valueObj) {
+			break
+		} else if time.Now().After(deadline) {
+			th.Fatalf("Expected the cache to observe Put of %v. Got %v", valueObj, value)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if value, err := rc.FindValue(key); err != nil || string(value) != "hello" {
+		th.Fatalf("Expected to find cached value. Got %q, %v", value, err)
+	}
+
+	// changing the value Object also invalidates the entry.
+	_, _, err = lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(valueObj)
+		if err != nil {
+			return nil, err
+		}
+		return nil, obj.Set([]byte("goodbye"))
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	for {
+		value, err := rc.FindValue(key)
+		if err != nil {
+			th.Fatal(err)
+		} else if string(value) == "goodbye" {
+			break
+		} else if time.Now().After(deadline) {
+			th.Fatalf("Expected the cache to observe the new value. Got %q", value)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = rc.Close(); err != nil {
+		th.Fatal(err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	_, err := lh.Batch(func(txn *client.Txn, batch *LHash) (interface{}, error) {
+		for idx := 0; idx < 200; idx++ {
+			if err := batch.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if s := stateOf(lh); s.rootDirty || s.deferWrites {
+		th.Fatal("Expected the Batch to have written the root")
+	}
+	assertSize(th, lh, 200)
+
+	// a fresh handle sees the batch, so the root was written.
+	other := LHashFromObj(lh.Conn, lh.ObjRef)
+	assertSize(th, other, 200)
+	for idx := 0; idx < 200; idx++ {
+		if value, err := other.Find([]byte(fmt.Sprintf("%v", idx))); err != nil || value == nil {
+			th.Fatalf("Expected to find %v. Got %v, %v", idx, value, err)
+		}
+	}
+
+	// a failed batch writes nothing.
+	failure := errors.New("failure")
+	_, err = lh.Batch(func(txn *client.Txn, batch *LHash) (interface{}, error) {
+		if err := batch.Put([]byte("absent"), lh.ObjRef); err != nil {
+			return nil, err
+		}
+		return nil, failure
+	})
+	if err != failure {
+		th.Fatalf("Expected the batch to fail. Got %v", err)
+	}
+	assertSize(th, lh, 200)
+}
+
+func TestWriteHeavy(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetWriteHeavy(true); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 300; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 300)
+	bucketCount := stateOf(lh).root.BucketCount
+	for idx := 0; idx < 300; idx++ {
+		if err := lh.Remove([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 0)
+	if stateOf(lh).root.BucketCount != bucketCount {
+		th.Fatalf("Expected removal to leave all %v buckets in place. Got %v", bucketCount, stateOf(lh).root.BucketCount)
+	}
+
+	compacted, err := lh.Compact(0)
+	if err != nil {
+		th.Fatal(err)
+	} else if compacted == 0 {
+		th.Fatal("Expected Compact to change some chains")
+	}
+	assertSize(th, lh, 0)
+	if chains := int64(stateOf(lh).directoryLen()); stateOf(lh).root.BucketCount != chains {
+		th.Fatalf("Expected Compact to leave only the %v head buckets. Got %v", chains, stateOf(lh).root.BucketCount)
+	}
+	if compacted, err = lh.Compact(0); err != nil || compacted != 0 {
+		th.Fatalf("Expected nothing left to compact. Got %v, %v", compacted, err)
+	}
+
+	for idx := 0; idx < 300; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for idx := 0; idx < 300; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("%v", idx))); err != nil || value == nil {
+			th.Fatalf("Expected to find %v. Got %v, %v", idx, value, err)
+		}
+	}
+	assertSize(th, lh, 300)
+}
+
+func TestForEachKey(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(16); err != nil {
+		th.Fatal(err)
+	}
+	expected := make(map[string]bool)
+	for idx := 0; idx < 100; idx++ {
+		key := fmt.Sprintf("%v", idx)
+		expected[key] = true
+		if err := lh.Put([]byte(key), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	expected["inline"] = true
+	if err := lh.PutValue([]byte("inline"), []byte("value")); err != nil {
+		th.Fatal(err)
+	}
+	if err := lh.PutWithExpiry([]byte("expired"), lh.ObjRef, time.Now().Add(-time.Minute)); err != nil {
+		th.Fatal(err)
+	}
+
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		seen := make(map[string]bool)
+		err := lh.ForEachKey(func(key []byte) error {
+			if !expected[string(key)] || seen[string(key)] {
+				return fmt.Errorf("ForEachKey yielded unexpected key %q", key)
+			}
+			seen[string(key)] = true
+			return nil
+		})
+		if err == nil && len(seen) != len(expected) {
+			err = fmt.Errorf("ForEachKey yielded %v keys; expected %v", len(seen), len(expected))
+		}
+		return nil, err
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+func TestMaxBucketCapacity(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetMaxBucketCapacity(100); err == nil {
+		th.Fatal("Expected a capacity which is not a power of two to be rejected")
+	}
+	if err := lh.SetMaxBucketCapacity(256); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 300; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	// far fewer chains than BucketCapacityChains, so the capacity is
+	// unchanged.
+	if c := stateOf(lh).root.BucketCapacity(); c != mp.BucketCapacity {
+		th.Fatalf("Expected capacity %v. Got %v", mp.BucketCapacity, c)
+	}
+
+	// a bucket grows to a larger capacity, keeping its entries.
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := lh.populate(); err != nil {
+			return nil, err
+		}
+		b, err := lh.head(0)
+		if err != nil {
+			return nil, err
+		}
+		if !b.grow(2*mp.BucketCapacity) || len(b.entries.Keys) != 2*mp.BucketCapacity || b.grow(mp.BucketCapacity) {
+			return nil, fmt.Errorf("Expected bucket to grow to %v slots. Got %v", 2*mp.BucketCapacity, len(b.entries.Keys))
+		}
+		for idx := range b.entries.Keys {
+			if !b.isSlotEmpty(idx) && b.hashAt(idx) != lh.hash(b.entries.Keys[idx]) {
+				return nil, fmt.Errorf("Hash of slot %v not preserved", idx)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+func TestConcurrentHandle(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	const goroutines, perGoroutine = 8, 50
+	errs := make(chan error, goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			for idx := 0; idx < perGoroutine; idx++ {
+				key := []byte(fmt.Sprintf("%v.%v", g, idx))
+				if err := lh.Put(key, lh.ObjRef); err != nil {
+					errs <- err
+					return
+				}
+				if value, err := lh.Find(key); err != nil {
+					errs <- err
+					return
+				} else if value == nil {
+					errs <- fmt.Errorf("Failed to find entry for %s", key)
+					return
+				}
+			}
+			errs <- nil
+		}(g)
+	}
+	for g := 0; g < goroutines; g++ {
+		if err := <-errs; err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, goroutines*perGoroutine)
+	if n := len(lh.sessions); n < 1 || n > goroutines {
+		th.Fatalf("Expected between 1 and %v idle sessions. Got %v", goroutines, n)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	lh := LHashFromObj(nil, client.ObjectRef{})
+	s := lh.acquire()
+	if s == lh || s.acquire() != s || s.handle() != lh {
+		t.Fatal("Expected a session of lh, which is its own session")
+	}
+	other := lh.acquire()
+	if other == s {
+		t.Fatal("Expected nested operations to have separate sessions")
+	}
+	lh.release(other)
+	lh.release(s)
+	if lh.acquire() != s {
+		t.Fatal("Expected the most recently released session to be reused")
+	}
+	lh.release(s)
+	lh.SetHashMemo(4)
+	if s := lh.acquire(); s.memo == nil || s.memo.capacity != 4 {
+		t.Fatal("Expected sessions to adopt the hash memo of the handle")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	}
+	expected := map[string]string{"a": "1", "b": "a longer value", "c": ""}
+	for key, value := range expected {
+		if err := lh.PutValue([]byte(key), []byte(value)); err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	n, err := lh.ExportCSV(buf, ExportOptions{Comma: '\t', Encoding: ExportHex, Header: true})
+	if err != nil {
+		th.Fatal(err)
+	} else if n != len(expected) {
+		th.Fatalf("Expected %v rows. Got %v", len(expected), n)
+	}
+	r := csv.NewReader(buf)
+	r.Comma = '\t'
+	rows, err := r.ReadAll()
+	if err != nil {
+		th.Fatal(err)
+	} else if len(rows) != len(expected)+1 || rows[0][0] != "key" {
+		th.Fatalf("Expected a header and %v rows. Got %v", len(expected), rows)
+	}
+	for _, row := range rows[1:] {
+		key, err := hex.DecodeString(row[0])
+		if err != nil {
+			th.Fatal(err)
+		}
+		value, err := hex.DecodeString(row[2])
+		if err != nil {
+			th.Fatal(err)
+		}
+		if expectedValue, found := expected[string(key)]; !found || expectedValue != string(value) || row[1] != fmt.Sprint(len(value)) {
+			th.Fatalf("Unexpected row %v", row)
+		}
+	}
+}
+
+func TestDumpRestore(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	} else if err = lh.SetMeta("schema", []byte("v1")); err != nil {
+		th.Fatal(err)
+	}
+	expected := make(map[string]string)
+	for idx := 0; idx < 200; idx++ {
+		key, value := fmt.Sprintf("key%v", idx), string(bytes.Repeat([]byte("v"), idx%8))
+		expected[key] = value
+		if err := lh.PutValue([]byte(key), []byte(value)); err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	n, err := lh.Dump(buf)
+	if err != nil {
+		th.Fatal(err)
+	} else if n != len(expected) {
+		th.Fatalf("Expected %v entries dumped. Got %v", len(expected), n)
+	}
+	dump := buf.Bytes()
+
+	if _, err = Restore(lh.Conn, bytes.NewReader(dump[:len(dump)-1])); err != ErrDumpCorrupt {
+		th.Fatalf("Expected ErrDumpCorrupt restoring a truncated dump. Got %v", err)
+	}
+	restored, err := Restore(lh.Conn, bytes.NewReader(dump))
+	if err != nil {
+		th.Fatal(err)
+	}
+	if meta, err := restored.GetMeta("schema"); err != nil {
+		th.Fatal(err)
+	} else if string(meta) != "v1" {
+		th.Fatalf("Expected restored metadata v1. Got %q", meta)
+	}
+	if !bytes.Equal(stateOf(restored).root.HashKey, stateOf(lh).root.HashKey) {
+		th.Fatal("Restored LHash has a different hash key")
+	}
+	if size, err := restored.Size(); err != nil {
+		th.Fatal(err)
+	} else if size != int64(len(expected)) {
+		th.Fatalf("Expected restored size %v. Got %v", len(expected), size)
+	}
+	for key, value := range expected {
+		if found, err := restored.FindValue([]byte(key)); err != nil {
+			th.Fatal(err)
+		} else if string(found) != value {
+			th.Fatalf("Expected %q for %v. Got %q", value, key, found)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	lh.Strict = true
+	for idx := 0; idx < 100; idx++ {
+		key := []byte(fmt.Sprintf("key%v", idx))
+		if err := lh.PutValue(key, key); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, lh, 100)
+
+	// rename a field, as a newer version might add one.
+	_, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(lh.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		return nil, obj.Set(bytes.Replace(value, []byte("WriteHeavy"), []byte("WriteHeavX"), 1), refs...)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if _, err = lh.Size(); err == nil {
+		th.Fatal("Expected strict decoding to reject an unknown field")
+	}
+	lh.Strict = false
+	assertSize(th, lh, 100)
+}
+
+func TestStatsFsck(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 300; idx++ {
+		key := []byte(fmt.Sprintf("key%v", idx))
+		if err := lh.PutValue(key, key[:idx%6]); err != nil {
+			th.Fatal(err)
+		}
+	}
+	stats, err := lh.Stats()
+	if err != nil {
+		th.Fatal(err)
+	} else if stats.Size != 300 || stats.Entries != 300 || stats.InlineEntries == 0 || stats.InlineEntries == 300 {
+		th.Fatalf("Unexpected stats %#v", stats)
+	} else if stats.Buckets != stateOf(lh).root.BucketCount || stats.Chains < 2 || stats.Utilization <= 0 {
+		th.Fatalf("Unexpected shape %#v", stats)
+	}
+	if problems, err := lh.Fsck(); err != nil {
+		th.Fatal(err)
+	} else if problems != nil {
+		th.Fatalf("Unexpected problems %v", problems)
+	}
+
+	// corrupt the recorded size.
+	_, err = lh.Batch(func(txn *client.Txn, batch *LHash) (interface{}, error) {
+		if err := batch.populate(); err != nil {
+			return nil, err
+		}
+		batch.root.Size++
+		return nil, batch.write()
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if problems, err := lh.Fsck(); err != nil {
+		th.Fatal(err)
+	} else if len(problems) != 1 {
+		th.Fatalf("Expected one problem. Got %v", problems)
+	}
+}
+
+func TestToMapFromMap(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	}
+	m := map[string][]byte{"a": []byte("1"), "b": []byte("a longer value"), "c": []byte{}}
+	if err := lh.FromMap(m); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, lh, int64(len(m)))
+
+	if _, err := lh.ToMap(len(m) - 1); err == nil {
+		th.Fatal("Expected ToMap to refuse a map over its limit")
+	} else if tooLarge, ok := err.(*TooLargeError); !ok || tooLarge.Size != int64(len(m)) {
+		th.Fatalf("Expected TooLargeError. Got %v", err)
+	}
+	found, err := lh.ToMap(len(m))
+	if err != nil {
+		th.Fatal(err)
+	} else if len(found) != len(m) {
+		th.Fatalf("Expected %v entries. Got %v", len(m), found)
+	}
+	for key, value := range m {
+		if !bytes.Equal(found[key], value) {
+			th.Fatalf("Expected %q for %v. Got %q", value, key, found[key])
+		}
+	}
+}
+
+func TestValueCodec(t *testing.T) {
+	type account struct {
+		Owner   string
+		Balance int64
+	}
+	bts, err := JSONCodec{}.Marshal(&account{Owner: "alice", Balance: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := new(account)
+	if err = (JSONCodec{}).Unmarshal(bts, a); err != nil {
+		t.Fatal(err)
+	} else if a.Owner != "alice" || a.Balance != 10 {
+		t.Fatalf("Unexpected decoding %#v", a)
+	}
+
+	// account has no msgp methods, but the msgpack types do.
+	if _, err = (MsgpCodec{}).Marshal(a); err == nil {
+		t.Fatal("Expected MsgpCodec to refuse a type without msgp methods")
+	}
+	if bts, err = (MsgpCodec{}).Marshal(&mp.DumpTrailer{Count: 7}); err != nil {
+		t.Fatal(err)
+	}
+	trailer := new(mp.DumpTrailer)
+	if err = (MsgpCodec{}).Unmarshal(bts, trailer); err != nil {
+		t.Fatal(err)
+	} else if trailer.Count != 7 {
+		t.Fatalf("Unexpected decoding %#v", trailer)
+	}
+}
+
+func TestTyped(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	type account struct {
+		Owner   string
+		Balance int64
+	}
+	typed := NewTyped(createEmpty(th), JSONCodec{})
+	for idx := 0; idx < 20; idx++ {
+		if err := typed.Put([]byte(fmt.Sprint(idx)), &account{Owner: fmt.Sprint("owner", idx), Balance: int64(idx)}); err != nil {
+			th.Fatal(err)
+		}
+	}
+	a := new(account)
+	if found, err := typed.Find([]byte("7"), a); err != nil {
+		th.Fatal(err)
+	} else if !found || a.Owner != "owner7" || a.Balance != 7 {
+		th.Fatalf("Unexpected account %#v", a)
+	}
+	if found, err := typed.Find([]byte("missing"), a); err != nil || found {
+		th.Fatalf("Expected no account. Got %v, %v", found, err)
+	}
+	total := int64(0)
+	err := typed.ForEach(func(key []byte, decode func(interface{}) error) error {
+		a := new(account)
+		if err := decode(a); err != nil {
+			return err
+		}
+		total += a.Balance
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	} else if total != 190 {
+		th.Fatalf("Expected a total balance of 190. Got %v", total)
+	}
+}
+
+func TestTupleCodec(t *testing.T) {
+	codec := KeyCodec(TupleCodec{})
+	// with concatenation, these would both be "a|b|c".
+	k1 := codec.EncodeKey([]byte("a|b"), []byte("c"))
+	k2 := codec.EncodeKey([]byte("a"), []byte("b|c"))
+	if bytes.Equal(k1, k2) {
+		t.Fatal("Distinct tuples have the same encoding")
+	}
+	fields, err := codec.DecodeKey(k1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fields) != 2 || string(fields[0]) != "a|b" || string(fields[1]) != "c" {
+		t.Fatalf("Unexpected fields %q", fields)
+	}
+	if fields, err = codec.DecodeKey(codec.EncodeKey([]byte{}, nil)); err != nil || len(fields) != 2 {
+		t.Fatalf("Expected two empty fields. Got %q, %v", fields, err)
+	}
+	if !bytes.HasPrefix(k1, codec.EncodeKey([]byte("a|b"))) {
+		t.Fatal("Expected the encoding of a leading field to be a prefix")
+	}
+	if _, err = codec.DecodeKey(k1[:len(k1)-1]); err != ErrBadTuple {
+		t.Fatalf("Expected ErrBadTuple. Got %v", err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 50; idx++ {
+		if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("value %v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err := lh.PutValue([]byte("tiny"), []byte("t")); err != nil {
+		th.Fatal(err)
+	}
+	objRef, err := lh.Checkpoint()
+	if err != nil {
+		th.Fatal(err)
+	}
+
+	// the checkpoint shares the buckets until the LHash writes them.
+	heads := func(objRef client.ObjectRef) []client.ObjectRef {
+		res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			fresh := LHashFromObj(lh.Conn, objRef)
+			if err := fresh.populate(); err != nil {
+				return nil, err
+			}
+			return fresh.allBucketRefs()
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+		return res.([]client.ObjectRef)
+	}
+	before := heads(objRef)
+	if live := heads(lh.ObjRef); len(live) != len(before) {
+		th.Fatalf("Expected %v chains. Got %v", len(before), len(live))
+	} else {
+		for idx := range live {
+			if !live[idx].ReferencesSameAs(before[idx]) {
+				th.Fatalf("Expected chain %v to be shared", idx)
+			}
+		}
+	}
+
+	// modify the live LHash after the checkpoint.
+	if err = lh.PutValue([]byte("0"), []byte("changed")); err != nil {
+		th.Fatal(err)
+	}
+	changed := stateOf(lh).bucketIndex([]byte("0"))
+	for idx, head := range heads(lh.ObjRef) {
+		if shared := head.ReferencesSameAs(before[idx]); shared == (uint64(idx) == changed) {
+			th.Fatalf("Expected only chain %v to be copied. Chain %v shared: %v", changed, idx, shared)
+		}
+	}
+	if err = lh.Remove([]byte("1")); err != nil {
+		th.Fatal(err)
+	} else if err = lh.PutValue([]byte("new"), []byte("new")); err != nil {
+		th.Fatal(err)
+	}
+
+	checkpoint := LHashFromObj(lh.Conn, objRef)
+	assertSize(th, checkpoint, 51)
+	if value, err := checkpoint.FindValue([]byte("0")); err != nil {
+		th.Fatal(err)
+	} else if string(value) != "value 0" {
+		th.Fatalf("Expected the checkpointed value. Got %s", value)
+	}
+	if value, err := checkpoint.FindValue([]byte("1")); err != nil || value == nil {
+		th.Fatalf("Expected the removed entry in the checkpoint. Got %v %v", value, err)
+	}
+	if value, err := checkpoint.FindValue([]byte("tiny")); err != nil || string(value) != "t" {
+		th.Fatalf("Expected the inline entry in the checkpoint. Got %s %v", value, err)
+	}
+	if found, err := checkpoint.Contains([]byte("new")); err != nil || found {
+		th.Fatalf("Expected no new entry in the checkpoint. Got %v %v", found, err)
+	}
+	if err = checkpoint.PutValue([]byte("x"), []byte("x")); err != ErrReadOnly {
+		th.Fatalf("Expected ErrReadOnly writing to the checkpoint. Got %v", err)
+	}
+
+	// grow long chains, take a second checkpoint part way through,
+	// then remove entries from the middle of the chains and split
+	// them.
+	if err = lh.SetDeferSplits(true); err != nil {
+		th.Fatal(err)
+	}
+	var second *LHash
+	for idx := 50; idx < 300; idx++ {
+		if idx == 150 {
+			if objRef, err = lh.Checkpoint(); err != nil {
+				th.Fatal(err)
+			}
+			second = LHashFromObj(lh.Conn, objRef)
+			// the latest entries are at the ends of the chains.
+			for idx := 100; idx < 150; idx++ {
+				if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("again %v", idx))); err != nil {
+					th.Fatal(err)
+				}
+			}
+		}
+		if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("value %v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for idx := 2; idx < 20; idx++ {
+		if err := lh.Remove([]byte(fmt.Sprintf("%v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if splits, err := lh.MaintainBatch(100); err != nil {
+		th.Fatal(err)
+	} else if splits == 0 {
+		th.Fatal("Expected deferred splits")
+	}
+	values := func(lh *LHash) map[string]string {
+		values := make(map[string]string)
+		if err := lh.ForEachValue(func(key []byte, value []byte) error {
+			values[string(key)] = string(value)
+			return nil
+		}); err != nil {
+			th.Fatal(err)
+		}
+		return values
+	}
+	expected := map[string]string{"tiny": "t"}
+	for idx := 0; idx < 50; idx++ {
+		expected[fmt.Sprintf("%v", idx)] = fmt.Sprintf("value %v", idx)
+	}
+	if got := values(checkpoint); fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected the checkpoint to be unchanged. Got %v", got)
+	}
+	expected["0"] = "changed"
+	expected["new"] = "new"
+	delete(expected, "1")
+	for idx := 50; idx < 150; idx++ {
+		expected[fmt.Sprintf("%v", idx)] = fmt.Sprintf("value %v", idx)
+	}
+	if got := values(second); fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected the second checkpoint to be unchanged. Got %v", got)
+	}
+	for idx := 1; idx < 300; idx++ {
+		if key := fmt.Sprintf("%v", idx); idx < 20 {
+			delete(expected, key)
+		} else if idx >= 100 && idx < 150 {
+			expected[key] = fmt.Sprintf("again %v", idx)
+		} else {
+			expected[key] = fmt.Sprintf("value %v", idx)
+		}
+	}
+	if got := values(lh); fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected %v. Got %v", expected, got)
+	}
+	for _, l := range []*LHash{lh, checkpoint, second} {
+		if problems, err := l.Fsck(); err != nil {
+			th.Fatal(err)
+		} else if problems != nil {
+			th.Fatalf("Unexpected problems %v", problems)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	a := createEmpty(th)
+	b, err := NewEmptyLHash(a.Conn)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err := b.SetInlineThreshold(16); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 20; idx++ {
+		key, value := []byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("value %v", idx))
+		if err := a.PutValue(key, value); err != nil {
+			th.Fatal(err)
+		}
+		// b holds the same values, but inline.
+		if idx != 3 {
+			if err := b.PutValue(key, value); err != nil {
+				th.Fatal(err)
+			}
+		}
+	}
+	if err := b.PutValue([]byte("5"), []byte("changed")); err != nil {
+		th.Fatal(err)
+	} else if err = b.PutValue([]byte("extra"), []byte("extra")); err != nil {
+		th.Fatal(err)
+	}
+
+	changes := make(map[string]Change)
+	if err := Diff(a, b, func(key []byte, change Change) error {
+		changes[string(key)] = change
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	}
+	expected := map[string]Change{"3": Removed, "5": Changed, "extra": Added}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		th.Fatalf("Expected changes %v. Got %v", expected, changes)
+	}
+
+	count := 0
+	if err := Diff(a, a, func(key []byte, change Change) error {
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != 0 {
+		th.Fatalf("Expected no changes between an LHash and itself. Got %v", count)
+	}
+}
+
+func TestCopyAcross(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	if err := lh.SetInlineThreshold(4); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 100; idx++ {
+		if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("value %v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err := lh.PutValue([]byte("tiny"), []byte("t")); err != nil {
+		th.Fatal(err)
+	}
+	dst := th.CreateConnections(1)[0].Connection
+	copied, err := CopyAcross(lh, dst, 7)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, copied, 101)
+
+	// compare from the destination's side.
+	original := LHashFromObj(dst, lh.ObjRef)
+	if err = Diff(original, copied, func(key []byte, change Change) error {
+		return fmt.Errorf("Key %s %v by the copy", key, change)
+	}); err != nil {
+		th.Fatal(err)
+	}
+}
+
+func TestIncrementalDump(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	var full bytes.Buffer
+	if _, _, err := lh.DumpSince(&full, 0); err != ErrNoChangeTracking {
+		th.Fatalf("Expected ErrNoChangeTracking. Got %v", err)
+	}
+	if err := lh.EnableChangeTracking(); err != nil {
+		th.Fatal(err)
+	}
+	for idx := 0; idx < 200; idx++ {
+		if err := lh.PutValue([]byte(fmt.Sprintf("%v", idx)), []byte(fmt.Sprintf("value %v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	full.Reset()
+	token, count, err := lh.DumpSince(&full, 0)
+	if err != nil {
+		th.Fatal(err)
+	} else if count != 200 {
+		th.Fatalf("Expected a full dump of 200 entries. Got %v", count)
+	}
+
+	if err = lh.PutValue([]byte("7"), []byte("changed")); err != nil {
+		th.Fatal(err)
+	} else if err = lh.Remove([]byte("8")); err != nil {
+		th.Fatal(err)
+	}
+	var incremental bytes.Buffer
+	if _, count, err = lh.DumpSince(&incremental, token); err != nil {
+		th.Fatal(err)
+	} else if count >= 199 {
+		th.Fatalf("Expected an incremental dump of fewer entries. Got %v", count)
+	}
+
+	if _, err = Restore(lh.Conn, bytes.NewReader(incremental.Bytes())); err == nil {
+		th.Fatal("Expected Restore of an incremental dump to fail")
+	}
+	restored, err := RestoreIncremental(lh.Conn, &full, &incremental)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = Diff(lh, restored, func(key []byte, change Change) error {
+		return fmt.Errorf("Key %s %v by the restore", key, change)
+	}); err != nil {
+		th.Fatal(err)
+	}
+}
+
+// putKeys puts n keys, each referencing the root, for the benchmarks.
+func putKeys(th *tests.TestHelper, lh *LHash, n int) [][]byte {
+	keys := make([][]byte, n)
+	for idx := range keys {
+		keys[idx] = []byte(fmt.Sprintf("%v", idx))
+		if err := lh.Put(keys[idx], lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func BenchmarkPut(b *testing.B) {
+	th := tests.NewTestHelper(b)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	keys := putKeys(th, lh, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := lh.Put(keys[i%len(keys)], lh.ObjRef); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	th := tests.NewTestHelper(b)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	keys := putKeys(th, lh, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if value, err := lh.Find(keys[i%len(keys)]); err != nil {
+			b.Fatal(err)
+		} else if value == nil {
+			b.Fatal("Failed to find entry")
+		}
+	}
+}
+
+func TestNestedOperations(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	lh := createEmpty(th)
+	for idx := 0; idx < 200; idx++ {
+		if err := lh.Put([]byte(fmt.Sprintf("%v", idx)), lh.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+	// replacing each key from within ForEach, which splits buckets,
+	// must not disturb the buckets ForEach is iterating over. ForEach
+	// may or may not also visit the replacements.
+	visited := 0
+	err := lh.ForEach(func(key []byte, value client.ObjectRef) error {
+		if bytes.HasPrefix(key, []byte("moved.")) {
+			return nil
+		}
+		visited++
+		if err := lh.Remove(key); err != nil {
+			return err
+		}
+		return lh.Put(append([]byte("moved."), key...), value)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if visited != 200 {
+		th.Fatalf("Expected ForEach to visit 200 keys. Got %v", visited)
+	}
+	assertSize(th, lh, 200)
+	for idx := 0; idx < 200; idx++ {
+		if value, err := lh.Find([]byte(fmt.Sprintf("moved.%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil {
+			th.Fatalf("Failed to find moved entry %v", idx)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package linearhash
+
+import (
+	"bytes"
+	"errors"
+	"goshawkdb.io/client"
+)
+
+// ErrNotLockHolder is returned by UnlockKey if the key is not locked
+// by the given holder.
+var ErrNotLockHolder = errors.New("Key is not locked by the given holder")
+
+// Attempt to acquire the advisory lock for the given key on behalf of
+// holder, which should uniquely identify the party acquiring the
+// lock. Returns true if the lock is now held by holder, which
+// includes the case where holder already held the lock. Locks are
+// independent of the entries: a key may be locked whether or not it
+// is present in the LHash, and locks are not consulted by Put, Remove
+// or any other operation. Lock records are stored in the head bucket
+// of the chain the key hashes to.
+func (lh *LHash) LockKey(key, holder []byte) (bool, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.LockKey(key, holder)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		return lh.tryLock(key, holder)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Acquire the advisory lock for the given key on behalf of holder,
+// blocking until the lock is available. Blocking is achieved through
+// a retry transaction, so Lock blocks the connection of the LHash and
+// must not be called from within a transaction.
+func (lh *LHash) Lock(key, holder []byte) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Lock(key, holder)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		locked, err := lh.tryLock(key, holder)
+		if err != nil {
+			return nil, err
+		} else if !locked {
+			return client.Retry, nil
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Release the advisory lock for the given key. ErrNotLockHolder is
+// returned if the lock is not currently held by holder.
+func (lh *LHash) UnlockKey(key, holder []byte) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.UnlockKey(key, holder)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		head, err := lh.lockBucket(key)
+		if err != nil {
+			return nil, err
+		}
+		if current, found := head.entries.Locks[string(key)]; !found || !bytes.Equal(current, holder) {
+			return nil, ErrNotLockHolder
+		}
+		delete(head.entries.Locks, string(key))
+		return nil, head.write(true)
+	})
+	return err
+}
+
+// Returns the current holder of the advisory lock for the given key,
+// or nil if the key is not locked.
+func (lh *LHash) LockHolder(key []byte) ([]byte, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.LockHolder(key)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		head, err := lh.head(lh.bucketIndex(key))
+		if err != nil {
+			return nil, err
+		}
+		return head.entries.Locks[string(key)], nil
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+func (lh *LHash) tryLock(key, holder []byte) (bool, error) {
+	head, err := lh.lockBucket(key)
+	if err != nil {
+		return false, err
+	}
+	if current, found := head.entries.Locks[string(key)]; found {
+		return bytes.Equal(current, holder), nil
+	}
+	if head.entries.Locks == nil {
+		head.entries.Locks = make(map[string][]byte)
+	}
+	head.entries.Locks[string(key)] = holder
+	return true, head.write(true)
+}
+
+func (lh *LHash) lockBucket(key []byte) (*bucket, error) {
+	err := lh.populate()
+	if err != nil {
+		return nil, err
+	}
+	if err = lh.checkWritable(); err != nil {
+		return nil, err
+	}
+	return lh.head(lh.bucketIndex(key))
+}
+
+// splitLocks moves the locks in the head bucket b of the chain being
+// split for which the key now hashes to bNew into bNew, and removes
+// the remaining locks from b, returning them so they can be placed in
+// whichever bucket becomes the head of the chain.
+func (lh *LHash) splitLocks(b, bNew *bucket, sOld uint64) map[string][]byte {
+	var locks map[string][]byte
+	for k, holder := range b.entries.Locks {
+		if lh.root.BucketIndex(lh.hash([]byte(k))) == sOld {
+			if locks == nil {
+				locks = make(map[string][]byte)
+			}
+			locks[k] = holder
+		} else {
+			if bNew.entries.Locks == nil {
+				bNew.entries.Locks = make(map[string][]byte)
+			}
+			bNew.entries.Locks[k] = holder
+		}
+	}
+	b.entries.Locks = nil
+	return locks
+}
@@ -0,0 +1,146 @@
+package linearhash
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+	"math/rand"
+	"time"
+)
+
+const (
+	// The minimum number of non-empty bucket chains examined to find
+	// the entry to evict.
+	lruSampleChains = 4
+	// Accesses within this duration of the recorded last access do
+	// not cause the access time to be rewritten.
+	lruAccessGranularity = int64(time.Second)
+)
+
+// Set the maximum number of entries in the LHash. If maxSize is
+// greater than 0, then whenever a Put would cause the LHash to exceed
+// maxSize entries, approximately-least-recently-used entries are
+// evicted. Accesses are recorded by Put and Find, which means that in
+// this mode Find modifies the bucket containing the entry (though
+// only when the recorded access time is more than a second old).
+// Eviction samples a few bucket chains and evicts the least recently
+// used entry amongst them, preferring expired entries. If the LHash
+// currently contains more than maxSize entries, entries are evicted
+// immediately. A maxSize of 0 removes the limit.
+func (lh *LHash) SetMaxSize(maxSize int64) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetMaxSize(maxSize)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if maxSize < 0 {
+			maxSize = 0
+		}
+		if lh.root.MaxSize == maxSize {
+			return nil, nil
+		}
+		lh.root.MaxSize = maxSize
+		if err = lh.write(); err != nil {
+			return nil, err
+		}
+		if maxSize == 0 {
+			return nil, nil
+		} else if size, err := lh.size(); err != nil {
+			return nil, err
+		} else if size > maxSize {
+			return nil, lh.evict(size-maxSize, nil)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns the maximum number of entries in the LHash, or 0 if there
+// is no limit.
+func (lh *LHash) MaxSize() (int64, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.MaxSize()
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		return lh.root.MaxSize, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return -1, err
+	}
+}
+
+// evict removes count entries, never choosing the exclude key.
+func (lh *LHash) evict(count int64, exclude []byte) error {
+	for ; count > 0; count-- {
+		victim, err := lh.chooseVictim(exclude)
+		if err != nil {
+			return err
+		} else if victim == nil {
+			return nil
+		}
+		if err = lh.Remove(victim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lh *LHash) chooseVictim(exclude []byte) ([]byte, error) {
+	var victim []byte
+	oldest := int64(0)
+	now := time.Now().UnixNano()
+	chains := lh.directoryLen()
+	start := uint64(rand.Int63n(int64(chains)))
+	sampled := 0
+	for offset := uint64(0); offset < chains && sampled < lruSampleChains; offset++ {
+		b, err := lh.head((start + offset) % chains)
+		empty := true
+		for ; err == nil && b != nil; b, err = b.next() {
+			for idx, k := range b.entries.Keys {
+				if b.isSlotEmpty(idx) || (exclude != nil && bytes.Equal(k, exclude)) {
+					continue
+				}
+				empty = false
+				if b.isExpired(idx, now) {
+					return k, nil
+				} else if access := b.entryAt(idx).access; victim == nil || access < oldest {
+					victim, oldest = k, access
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		} else if !empty {
+			sampled++
+		}
+	}
+	return victim, nil
+}
+
+// touch records an access to the entry for key at now.
+func (b *bucket) touch(key []byte, now int64) error {
+	bFound, idx, err := b.findSlot(key)
+	if err != nil || bFound == nil {
+		return err
+	}
+	e := bFound.entryAt(idx)
+	if now-e.access < lruAccessGranularity {
+		return nil
+	}
+	e.access = now
+	bFound.setEntry(idx, e)
+	return bFound.write(true)
+}
@@ -0,0 +1,106 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+)
+
+// Set whether splitting of buckets is deferred. Normally, a Put which
+// takes the LHash over its utilization threshold splits a bucket
+// chain within the same transaction, which makes that Put much more
+// expensive than usual and more likely to need to restart. With
+// deferred splitting, such a Put instead just flags the root as
+// needing a split, and the split is done later by Maintain, in its
+// own transaction. Until then, bucket chains grow longer than usual.
+// The setting is stored in the root, so applies to all users of the
+// LHash.
+func (lh *LHash) SetDeferSplits(deferSplits bool) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetDeferSplits(deferSplits)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.DeferSplits == deferSplits {
+			return nil, nil
+		}
+		lh.root.DeferSplits = deferSplits
+		if !deferSplits {
+			lh.root.SplitPending = false
+		}
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Perform a deferred split, if one is needed. Maintain is intended to
+// be called periodically, or from a goroutine of its own, when splits
+// are deferred (see SetDeferSplits). It splits at most one bucket
+// chain, and returns true if it did so. If the LHash still needs
+// splitting after that, the root remains flagged and Maintain should
+// be called again.
+//
+// Maintain checks the flag in the root before doing anything else, so
+// when there is nothing to do, calling it is cheap and does not write
+// anything.
+func (lh *LHash) Maintain() (bool, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Maintain()
+	}
+	splits, err := lh.MaintainBatch(1)
+	return splits > 0, err
+}
+
+// As Maintain, but performs up to maxSplits splits in a single
+// transaction, stopping early once the LHash is back below its
+// utilization threshold, and returns the number of splits performed.
+// Whilst splits are deferred, chains grow overflow buckets instead, so
+// the threshold is measured against the number of chains (see
+// mp.Root.ChainsNeedSplitAt). At least one split is performed
+// whenever the root is flagged, as the flag may have been set by an
+// over-long chain (see SetMaxChainLength) rather than by utilization.
+// A maxSplits of 0 or less means no limit. After a bulk load has
+// pushed the LHash far past its threshold, this reaches the steady
+// state with far fewer transactions than repeated calls to Maintain,
+// at the cost of each transaction being larger.
+func (lh *LHash) MaintainBatch(maxSplits int) (int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.MaintainBatch(maxSplits)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if !lh.root.SplitPending {
+			return 0, nil
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		size, err := lh.size()
+		if err != nil {
+			return nil, err
+		}
+		splits := 0
+		for ; (splits == 0 || lh.root.ChainsNeedSplitAt(size)) && (maxSplits <= 0 || splits < maxSplits); splits++ {
+			if err = lh.split(); err != nil {
+				return nil, err
+			}
+		}
+		lh.root.SplitPending = lh.root.ChainsNeedSplitAt(size)
+		return splits, lh.write()
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
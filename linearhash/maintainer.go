@@ -0,0 +1,131 @@
+package linearhash
+
+import (
+	"context"
+	"goshawkdb.io/client"
+	"math/rand"
+	"time"
+)
+
+// A Maintainer performs the deferred maintenance of a set of LHashes
+// in the background: splits deferred by SetDeferSplits, the removal
+// of expired entries, and the compaction of LHashes in write-heavy
+// mode (see SetWriteHeavy). Without a Maintainer, that work is done
+// synchronously, by whichever Find or Put happens to encounter it, or
+// not at all. The LHash does not (yet) shrink, so there is no such
+// work to do.
+//
+// Each sweep visits every LHash in turn, performing at most MaxSplits
+// splits, removing at most ExpiryLimit expired entries and compacting
+// at most CompactLimit bucket chains of each, so the transactions of
+// a sweep are bounded in size. For each of these limits, 0 means no
+// limit and a negative limit means that work is not done. Sweeps are
+// Interval apart, randomly adjusted by up to Jitter of Interval, so
+// that several Maintainers of the same LHashes do not keep colliding.
+// The fields should be set before calling Run.
+type Maintainer struct {
+	// The time between sweeps.
+	Interval time.Duration
+	// The fraction of Interval, between 0 and 1, by which each pause
+	// between sweeps is randomly lengthened or shortened.
+	Jitter float64
+	// The maximum number of splits performed on each LHash per sweep.
+	// 0 means no limit. If negative, no splits are performed.
+	MaxSplits int
+	// The maximum number of expired entries removed from each LHash
+	// per sweep. 0 means no limit. If negative, no expired entries are
+	// removed.
+	ExpiryLimit int
+	// The maximum number of bucket chains of each LHash compacted per
+	// sweep. 0 means no limit. If negative, no chains are compacted.
+	CompactLimit int
+	// If non-nil, OnError is called with any error encountered during
+	// a sweep of lh, and Run continues. Otherwise, Run returns the
+	// error.
+	OnError func(lh *LHash, err error)
+
+	conn        *client.Connection
+	collections []*LHash
+	rng         *rand.Rand
+}
+
+// Create a Maintainer for the given LHashes. Maintenance transactions
+// are run on conn, which should not be the connection of any of the
+// LHashes, so that maintenance does not block their users. Each LHash
+// is maintained through a handle of its own on conn, which takes the
+// Upgrade and Strict settings of the LHash as given: Upgrade may then
+// be called from the goroutine running the Maintainer. The
+// Maintainer sweeps every second, with 10% jitter, performing up to
+// 16 splits, removing up to 64 expired entries and compacting up to
+// 16 bucket chains of each LHash per sweep.
+func NewMaintainer(conn *client.Connection, collections ...*LHash) *Maintainer {
+	m := &Maintainer{
+		Interval:     time.Second,
+		Jitter:       0.1,
+		MaxSplits:    16,
+		ExpiryLimit:  64,
+		CompactLimit: 16,
+		conn:         conn,
+		collections:  make([]*LHash, len(collections)),
+		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for idx, lh := range collections {
+		m.collections[idx] = LHashFromObj(conn, lh.ObjRef)
+		m.collections[idx].Upgrade, m.collections[idx].Strict = lh.Upgrade, lh.Strict
+	}
+	return m
+}
+
+// Sweep every LHash once, now.
+func (m *Maintainer) Sweep() error {
+	for _, lh := range m.collections {
+		if err := m.sweep(lh); err != nil {
+			if m.OnError == nil {
+				return err
+			}
+			m.OnError(lh, err)
+		}
+	}
+	return nil
+}
+
+func (m *Maintainer) sweep(lh *LHash) error {
+	if m.MaxSplits >= 0 {
+		if _, err := lh.MaintainBatch(m.MaxSplits); err != nil {
+			return err
+		}
+	}
+	if m.ExpiryLimit >= 0 {
+		if _, err := lh.SweepExpired(m.ExpiryLimit); err != nil {
+			return err
+		}
+	}
+	if m.CompactLimit >= 0 {
+		if _, err := lh.Compact(m.CompactLimit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep repeatedly until ctx is done, returning ctx.Err(), or until a
+// sweep fails and there is no OnError.
+func (m *Maintainer) Run(ctx context.Context) error {
+	for {
+		if err := m.Sweep(); err != nil {
+			return err
+		}
+		timer := time.NewTimer(m.pause())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *Maintainer) pause() time.Duration {
+	jitter := m.Jitter * (2*m.rng.Float64() - 1)
+	return time.Duration(float64(m.Interval) * (1 + jitter))
+}
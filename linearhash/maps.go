@@ -0,0 +1,71 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+)
+
+// A TooLargeError is returned by ToMap if the LHash has more entries than
+// the limit given.
+type TooLargeError struct {
+	Size  int64
+	Limit int
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("LHash has %v entries, more than the limit of %v", e.Size, e.Limit)
+}
+
+// Returns the unexpired entries of the LHash as a map from keys to
+// values, whether the values are stored inline or as value Objects
+// (see ForEachValue). This is a convenience for tests, small
+// configuration maps and migrations, where the whole LHash comfortably
+// fits in memory. So that it cannot be used by mistake on a large
+// LHash, ToMap returns a *TooLargeError, without reading any entries,
+// if the LHash has more than limit entries.
+func (lh *LHash) ToMap(limit int) (map[string][]byte, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.ToMap(limit)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		size, err := lh.Size()
+		if err != nil {
+			return nil, err
+		} else if size > int64(limit) {
+			return nil, &TooLargeError{Size: size, Limit: limit}
+		}
+		m := make(map[string][]byte, size)
+		err = lh.ForEachValue(func(key []byte, value []byte) error {
+			m[string(key)] = value
+			return nil
+		})
+		return m, err
+	})
+	if err == nil {
+		return res.(map[string][]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Set the value of the entry for each key of m, as PutValue does, in a
+// single transaction. Existing entries for other keys are left alone.
+// As the whole map is written in one transaction, FromMap is, like
+// ToMap, intended for small maps; use BulkLoad to load a large number
+// of entries into an empty LHash.
+func (lh *LHash) FromMap(m map[string][]byte) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.FromMap(m)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		for key, value := range m {
+			if err := lh.PutValue([]byte(key), value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,104 @@
+package linearhash
+
+import (
+	"container/list"
+)
+
+// hashMemo is a small LRU cache from keys to their hashes and bucket
+// indices, for handles whose callers repeatedly operate on the same
+// keys. A bucket index is only valid for the shape of the LHash it was
+// computed for, so each memoized index records that shape, and is
+// recomputed (from the memoized hash) if the LHash has split since.
+type hashMemo struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type hashMemoEntry struct {
+	key        string
+	hash       uint64
+	bucketIdx  uint64
+	splitIndex uint64
+	maskLow    uint64
+}
+
+// Keep the hashes and bucket indices of up to size recently used keys
+// in memory, so that operating on those keys again need not rehash
+// them. This trades memory for CPU, so is disabled by default. A size
+// of 0 disables the memo. The memo belongs to this handle and is not
+// stored in the LHash; each session of the handle (see acquire) keeps
+// a memo of this size.
+func (lh *LHash) SetHashMemo(size int) {
+	if lh.shared == nil {
+		lh.sessionsLock.Lock()
+		defer lh.sessionsLock.Unlock()
+	}
+	lh.setHashMemo(size)
+}
+
+func (lh *LHash) setHashMemo(size int) {
+	lh.memoSize = size
+	if size <= 0 {
+		lh.memo = nil
+		return
+	}
+	if lh.memo == nil {
+		lh.memo = &hashMemo{
+			entries: make(map[string]*list.Element, size),
+			order:   list.New(),
+		}
+	}
+	lh.memo.capacity = size
+	lh.memo.trim()
+}
+
+// locate returns the hash of key and the index of the bucket chain in
+// which it belongs, using the memo if there is one.
+func (lh *LHash) locate(key []byte) (h uint64, bucketIdx uint64) {
+	m := lh.memo
+	if m == nil {
+		h = lh.hash(key)
+		return h, lh.root.BucketIndex(h)
+	}
+	if elem, found := m.entries[string(key)]; found {
+		m.order.MoveToFront(elem)
+		e := elem.Value.(*hashMemoEntry)
+		if e.splitIndex != lh.root.SplitIndex || e.maskLow != lh.root.MaskLow {
+			e.bucketIdx = lh.root.BucketIndex(e.hash)
+			e.splitIndex, e.maskLow = lh.root.SplitIndex, lh.root.MaskLow
+		}
+		return e.hash, e.bucketIdx
+	}
+	h = lh.hash(key)
+	e := &hashMemoEntry{
+		key:        string(key),
+		hash:       h,
+		bucketIdx:  lh.root.BucketIndex(h),
+		splitIndex: lh.root.SplitIndex,
+		maskLow:    lh.root.MaskLow,
+	}
+	m.entries[e.key] = m.order.PushFront(e)
+	m.trim()
+	return h, e.bucketIdx
+}
+
+func (m *hashMemo) trim() {
+	for m.order.Len() > m.capacity {
+		elem := m.order.Back()
+		m.order.Remove(elem)
+		delete(m.entries, elem.Value.(*hashMemoEntry).key)
+	}
+}
+
+func (m *hashMemo) clear() {
+	for key := range m.entries {
+		delete(m.entries, key)
+	}
+	m.order.Init()
+}
+
+func (lh *LHash) bucketIndex(key []byte) uint64 {
+	_, bucketIdx := lh.locate(key)
+	return bucketIdx
+}
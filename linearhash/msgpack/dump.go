@@ -0,0 +1,76 @@
+package msgpack
+
+//go:generate msgp
+
+// The current version of the dump format. See DumpHeader.
+const DumpVersion = 3
+
+// A dump of an LHash is a stream of records, each of which is a kind
+// byte, the uvarint length of the body, and the msgpack encoded body.
+// The first record is a DumpHeader, followed by a DumpEntry record for
+// each entry, and finally a DumpTrailer record. An incremental dump
+// also has a DumpKey record for each entry unchanged since the dump it
+// follows, after the DumpEntry records.
+const (
+	DumpHeaderRecord  = 'H'
+	DumpEntryRecord   = 'E'
+	DumpKeyRecord     = 'K'
+	DumpTrailerRecord = 'T'
+)
+
+// A DumpHeader records the hash key and parameters of the dumped
+// LHash, so that the restored LHash is equivalent.
+type DumpHeader struct {
+	Version           uint64
+	HashKey           []byte
+	Meta              map[string][]byte
+	MaxSize           int64
+	SizeStripes       int64
+	DeferSplits       bool
+	SortedBuckets     bool
+	InlineThreshold   int64
+	MaxChainLength    int64
+	MinUtilization    float64
+	MaxUtilization    float64
+	WriteHeavy        bool
+	MaxBucketCapacity int64
+	DirectoryPageSize int64
+	ReverseIndex      bool
+	// Added in version 2.
+	BlobThreshold int64
+	// For an incremental dump, the epoch of the dump it follows, else
+	// 0. Added in version 3.
+	Since int64
+	// For a dump made with change tracking, the epoch from which the
+	// next incremental dump follows, else 0. Added in version 3.
+	Epoch int64
+}
+
+// A DumpEntry is a single entry of the dumped LHash. Value is the
+// value of the entry, whether inline, the value of its value Object,
+// or the content of its blob. The attributes are as for Bucket
+// entries.
+type DumpEntry struct {
+	Key    []byte
+	Value  []byte
+	Inline bool
+	// Added in version 2.
+	Blob    bool
+	Expiry  int64
+	Access  int64
+	Version int64
+}
+
+// A DumpTrailer ends a dump, recording the number of entries, so that
+// a truncated dump can be detected.
+type DumpTrailer struct {
+	Count int64
+	// The number of DumpKey records. Added in version 3.
+	Keys int64
+}
+
+// A DumpKey is the key of an entry which is unchanged since the dump
+// an incremental dump follows, and so is not dumped again.
+type DumpKey struct {
+	Key []byte
+}
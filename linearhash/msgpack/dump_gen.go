@@ -0,0 +1,1090 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *DumpEntry) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, err = dc.ReadBytes(z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		case "Value":
+			z.Value, err = dc.ReadBytes(z.Value)
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		case "Inline":
+			z.Inline, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Inline")
+				return
+			}
+		case "Blob":
+			z.Blob, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Blob")
+				return
+			}
+		case "Expiry":
+			z.Expiry, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Expiry")
+				return
+			}
+		case "Access":
+			z.Access, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Access")
+				return
+			}
+		case "Version":
+			z.Version, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *DumpEntry) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 7
+	// write "Key"
+	err = en.Append(0x87, 0xa3, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Key)
+	if err != nil {
+		err = msgp.WrapError(err, "Key")
+		return
+	}
+	// write "Value"
+	err = en.Append(0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Value)
+	if err != nil {
+		err = msgp.WrapError(err, "Value")
+		return
+	}
+	// write "Inline"
+	err = en.Append(0xa6, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Inline)
+	if err != nil {
+		err = msgp.WrapError(err, "Inline")
+		return
+	}
+	// write "Blob"
+	err = en.Append(0xa4, 0x42, 0x6c, 0x6f, 0x62)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Blob)
+	if err != nil {
+		err = msgp.WrapError(err, "Blob")
+		return
+	}
+	// write "Expiry"
+	err = en.Append(0xa6, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Expiry)
+	if err != nil {
+		err = msgp.WrapError(err, "Expiry")
+		return
+	}
+	// write "Access"
+	err = en.Append(0xa6, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Access)
+	if err != nil {
+		err = msgp.WrapError(err, "Access")
+		return
+	}
+	// write "Version"
+	err = en.Append(0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Version)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DumpEntry) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 7
+	// string "Key"
+	o = append(o, 0x87, 0xa3, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.Key)
+	// string "Value"
+	o = append(o, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	o = msgp.AppendBytes(o, z.Value)
+	// string "Inline"
+	o = append(o, 0xa6, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65)
+	o = msgp.AppendBool(o, z.Inline)
+	// string "Blob"
+	o = append(o, 0xa4, 0x42, 0x6c, 0x6f, 0x62)
+	o = msgp.AppendBool(o, z.Blob)
+	// string "Expiry"
+	o = append(o, 0xa6, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79)
+	o = msgp.AppendInt64(o, z.Expiry)
+	// string "Access"
+	o = append(o, 0xa6, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73)
+	o = msgp.AppendInt64(o, z.Access)
+	// string "Version"
+	o = append(o, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendInt64(o, z.Version)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DumpEntry) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, bts, err = msgp.ReadBytesBytes(bts, z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		case "Value":
+			z.Value, bts, err = msgp.ReadBytesBytes(bts, z.Value)
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		case "Inline":
+			z.Inline, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Inline")
+				return
+			}
+		case "Blob":
+			z.Blob, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Blob")
+				return
+			}
+		case "Expiry":
+			z.Expiry, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Expiry")
+				return
+			}
+		case "Access":
+			z.Access, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Access")
+				return
+			}
+		case "Version":
+			z.Version, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DumpEntry) Msgsize() (s int) {
+	s = 1 + 4 + msgp.BytesPrefixSize + len(z.Key) + 6 + msgp.BytesPrefixSize + len(z.Value) + 7 + msgp.BoolSize + 5 + msgp.BoolSize + 7 + msgp.Int64Size + 7 + msgp.Int64Size + 8 + msgp.Int64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *DumpHeader) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "HashKey":
+			z.HashKey, err = dc.ReadBytes(z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		case "Meta":
+			var zb0002 uint32
+			zb0002, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Meta")
+				return
+			}
+			if z.Meta == nil {
+				z.Meta = make(map[string][]byte, zb0002)
+			} else if len(z.Meta) > 0 {
+				for key := range z.Meta {
+					delete(z.Meta, key)
+				}
+			}
+			for zb0002 > 0 {
+				zb0002--
+				var za0001 string
+				var za0002 []byte
+				za0001, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Meta")
+					return
+				}
+				za0002, err = dc.ReadBytes(za0002)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta", za0001)
+					return
+				}
+				z.Meta[za0001] = za0002
+			}
+		case "MaxSize":
+			z.MaxSize, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "MaxSize")
+				return
+			}
+		case "SizeStripes":
+			z.SizeStripes, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "SizeStripes")
+				return
+			}
+		case "DeferSplits":
+			z.DeferSplits, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "DeferSplits")
+				return
+			}
+		case "SortedBuckets":
+			z.SortedBuckets, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "SortedBuckets")
+				return
+			}
+		case "InlineThreshold":
+			z.InlineThreshold, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "InlineThreshold")
+				return
+			}
+		case "MaxChainLength":
+			z.MaxChainLength, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "MaxChainLength")
+				return
+			}
+		case "MinUtilization":
+			z.MinUtilization, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "MinUtilization")
+				return
+			}
+		case "MaxUtilization":
+			z.MaxUtilization, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "MaxUtilization")
+				return
+			}
+		case "WriteHeavy":
+			z.WriteHeavy, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "WriteHeavy")
+				return
+			}
+		case "MaxBucketCapacity":
+			z.MaxBucketCapacity, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "MaxBucketCapacity")
+				return
+			}
+		case "DirectoryPageSize":
+			z.DirectoryPageSize, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPageSize")
+				return
+			}
+		case "ReverseIndex":
+			z.ReverseIndex, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "ReverseIndex")
+				return
+			}
+		case "BlobThreshold":
+			z.BlobThreshold, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "BlobThreshold")
+				return
+			}
+		case "Since":
+			z.Since, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Since")
+				return
+			}
+		case "Epoch":
+			z.Epoch, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Epoch")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *DumpHeader) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 18
+	// write "Version"
+	err = en.Append(0xde, 0x0, 0x12, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Version)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	// write "HashKey"
+	err = en.Append(0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.HashKey)
+	if err != nil {
+		err = msgp.WrapError(err, "HashKey")
+		return
+	}
+	// write "Meta"
+	err = en.Append(0xa4, 0x4d, 0x65, 0x74, 0x61)
+	if err != nil {
+		return
+	}
+	err = en.WriteMapHeader(uint32(len(z.Meta)))
+	if err != nil {
+		err = msgp.WrapError(err, "Meta")
+		return
+	}
+	for za0001, za0002 := range z.Meta {
+		err = en.WriteString(za0001)
+		if err != nil {
+			err = msgp.WrapError(err, "Meta")
+			return
+		}
+		err = en.WriteBytes(za0002)
+		if err != nil {
+			err = msgp.WrapError(err, "Meta", za0001)
+			return
+		}
+	}
+	// write "MaxSize"
+	err = en.Append(0xa7, 0x4d, 0x61, 0x78, 0x53, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.MaxSize)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxSize")
+		return
+	}
+	// write "SizeStripes"
+	err = en.Append(0xab, 0x53, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x69, 0x70, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.SizeStripes)
+	if err != nil {
+		err = msgp.WrapError(err, "SizeStripes")
+		return
+	}
+	// write "DeferSplits"
+	err = en.Append(0xab, 0x44, 0x65, 0x66, 0x65, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.DeferSplits)
+	if err != nil {
+		err = msgp.WrapError(err, "DeferSplits")
+		return
+	}
+	// write "SortedBuckets"
+	err = en.Append(0xad, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.SortedBuckets)
+	if err != nil {
+		err = msgp.WrapError(err, "SortedBuckets")
+		return
+	}
+	// write "InlineThreshold"
+	err = en.Append(0xaf, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.InlineThreshold)
+	if err != nil {
+		err = msgp.WrapError(err, "InlineThreshold")
+		return
+	}
+	// write "MaxChainLength"
+	err = en.Append(0xae, 0x4d, 0x61, 0x78, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.MaxChainLength)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxChainLength")
+		return
+	}
+	// write "MinUtilization"
+	err = en.Append(0xae, 0x4d, 0x69, 0x6e, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.MinUtilization)
+	if err != nil {
+		err = msgp.WrapError(err, "MinUtilization")
+		return
+	}
+	// write "MaxUtilization"
+	err = en.Append(0xae, 0x4d, 0x61, 0x78, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.MaxUtilization)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxUtilization")
+		return
+	}
+	// write "WriteHeavy"
+	err = en.Append(0xaa, 0x57, 0x72, 0x69, 0x74, 0x65, 0x48, 0x65, 0x61, 0x76, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.WriteHeavy)
+	if err != nil {
+		err = msgp.WrapError(err, "WriteHeavy")
+		return
+	}
+	// write "MaxBucketCapacity"
+	err = en.Append(0xb1, 0x4d, 0x61, 0x78, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.MaxBucketCapacity)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxBucketCapacity")
+		return
+	}
+	// write "DirectoryPageSize"
+	err = en.Append(0xb1, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.DirectoryPageSize)
+	if err != nil {
+		err = msgp.WrapError(err, "DirectoryPageSize")
+		return
+	}
+	// write "ReverseIndex"
+	err = en.Append(0xac, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.ReverseIndex)
+	if err != nil {
+		err = msgp.WrapError(err, "ReverseIndex")
+		return
+	}
+	// write "BlobThreshold"
+	err = en.Append(0xad, 0x42, 0x6c, 0x6f, 0x62, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.BlobThreshold)
+	if err != nil {
+		err = msgp.WrapError(err, "BlobThreshold")
+		return
+	}
+	// write "Since"
+	err = en.Append(0xa5, 0x53, 0x69, 0x6e, 0x63, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Since)
+	if err != nil {
+		err = msgp.WrapError(err, "Since")
+		return
+	}
+	// write "Epoch"
+	err = en.Append(0xa5, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Epoch)
+	if err != nil {
+		err = msgp.WrapError(err, "Epoch")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DumpHeader) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 18
+	// string "Version"
+	o = append(o, 0xde, 0x0, 0x12, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendUint64(o, z.Version)
+	// string "HashKey"
+	o = append(o, 0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.HashKey)
+	// string "Meta"
+	o = append(o, 0xa4, 0x4d, 0x65, 0x74, 0x61)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Meta)))
+	for za0001, za0002 := range z.Meta {
+		o = msgp.AppendString(o, za0001)
+		o = msgp.AppendBytes(o, za0002)
+	}
+	// string "MaxSize"
+	o = append(o, 0xa7, 0x4d, 0x61, 0x78, 0x53, 0x69, 0x7a, 0x65)
+	o = msgp.AppendInt64(o, z.MaxSize)
+	// string "SizeStripes"
+	o = append(o, 0xab, 0x53, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x69, 0x70, 0x65, 0x73)
+	o = msgp.AppendInt64(o, z.SizeStripes)
+	// string "DeferSplits"
+	o = append(o, 0xab, 0x44, 0x65, 0x66, 0x65, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x73)
+	o = msgp.AppendBool(o, z.DeferSplits)
+	// string "SortedBuckets"
+	o = append(o, 0xad, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73)
+	o = msgp.AppendBool(o, z.SortedBuckets)
+	// string "InlineThreshold"
+	o = append(o, 0xaf, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	o = msgp.AppendInt64(o, z.InlineThreshold)
+	// string "MaxChainLength"
+	o = append(o, 0xae, 0x4d, 0x61, 0x78, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt64(o, z.MaxChainLength)
+	// string "MinUtilization"
+	o = append(o, 0xae, 0x4d, 0x69, 0x6e, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendFloat64(o, z.MinUtilization)
+	// string "MaxUtilization"
+	o = append(o, 0xae, 0x4d, 0x61, 0x78, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendFloat64(o, z.MaxUtilization)
+	// string "WriteHeavy"
+	o = append(o, 0xaa, 0x57, 0x72, 0x69, 0x74, 0x65, 0x48, 0x65, 0x61, 0x76, 0x79)
+	o = msgp.AppendBool(o, z.WriteHeavy)
+	// string "MaxBucketCapacity"
+	o = append(o, 0xb1, 0x4d, 0x61, 0x78, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
+	o = msgp.AppendInt64(o, z.MaxBucketCapacity)
+	// string "DirectoryPageSize"
+	o = append(o, 0xb1, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65)
+	o = msgp.AppendInt64(o, z.DirectoryPageSize)
+	// string "ReverseIndex"
+	o = append(o, 0xac, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	o = msgp.AppendBool(o, z.ReverseIndex)
+	// string "BlobThreshold"
+	o = append(o, 0xad, 0x42, 0x6c, 0x6f, 0x62, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	o = msgp.AppendInt64(o, z.BlobThreshold)
+	// string "Since"
+	o = append(o, 0xa5, 0x53, 0x69, 0x6e, 0x63, 0x65)
+	o = msgp.AppendInt64(o, z.Since)
+	// string "Epoch"
+	o = append(o, 0xa5, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	o = msgp.AppendInt64(o, z.Epoch)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DumpHeader) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "HashKey":
+			z.HashKey, bts, err = msgp.ReadBytesBytes(bts, z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		case "Meta":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Meta")
+				return
+			}
+			if z.Meta == nil {
+				z.Meta = make(map[string][]byte, zb0002)
+			} else if len(z.Meta) > 0 {
+				for key := range z.Meta {
+					delete(z.Meta, key)
+				}
+			}
+			for zb0002 > 0 {
+				var za0001 string
+				var za0002 []byte
+				zb0002--
+				za0001, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta")
+					return
+				}
+				za0002, bts, err = msgp.ReadBytesBytes(bts, za0002)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta", za0001)
+					return
+				}
+				z.Meta[za0001] = za0002
+			}
+		case "MaxSize":
+			z.MaxSize, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxSize")
+				return
+			}
+		case "SizeStripes":
+			z.SizeStripes, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SizeStripes")
+				return
+			}
+		case "DeferSplits":
+			z.DeferSplits, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DeferSplits")
+				return
+			}
+		case "SortedBuckets":
+			z.SortedBuckets, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SortedBuckets")
+				return
+			}
+		case "InlineThreshold":
+			z.InlineThreshold, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "InlineThreshold")
+				return
+			}
+		case "MaxChainLength":
+			z.MaxChainLength, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxChainLength")
+				return
+			}
+		case "MinUtilization":
+			z.MinUtilization, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MinUtilization")
+				return
+			}
+		case "MaxUtilization":
+			z.MaxUtilization, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxUtilization")
+				return
+			}
+		case "WriteHeavy":
+			z.WriteHeavy, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WriteHeavy")
+				return
+			}
+		case "MaxBucketCapacity":
+			z.MaxBucketCapacity, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxBucketCapacity")
+				return
+			}
+		case "DirectoryPageSize":
+			z.DirectoryPageSize, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPageSize")
+				return
+			}
+		case "ReverseIndex":
+			z.ReverseIndex, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ReverseIndex")
+				return
+			}
+		case "BlobThreshold":
+			z.BlobThreshold, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BlobThreshold")
+				return
+			}
+		case "Since":
+			z.Since, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Since")
+				return
+			}
+		case "Epoch":
+			z.Epoch, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Epoch")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DumpHeader) Msgsize() (s int) {
+	s = 3 + 8 + msgp.Uint64Size + 8 + msgp.BytesPrefixSize + len(z.HashKey) + 5 + msgp.MapHeaderSize
+	if z.Meta != nil {
+		for za0001, za0002 := range z.Meta {
+			_ = za0002
+			s += msgp.StringPrefixSize + len(za0001) + msgp.BytesPrefixSize + len(za0002)
+		}
+	}
+	s += 8 + msgp.Int64Size + 12 + msgp.Int64Size + 12 + msgp.BoolSize + 14 + msgp.BoolSize + 16 + msgp.Int64Size + 15 + msgp.Int64Size + 15 + msgp.Float64Size + 15 + msgp.Float64Size + 11 + msgp.BoolSize + 18 + msgp.Int64Size + 18 + msgp.Int64Size + 13 + msgp.BoolSize + 14 + msgp.Int64Size + 6 + msgp.Int64Size + 6 + msgp.Int64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *DumpKey) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, err = dc.ReadBytes(z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *DumpKey) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Key"
+	err = en.Append(0x81, 0xa3, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Key)
+	if err != nil {
+		err = msgp.WrapError(err, "Key")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DumpKey) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Key"
+	o = append(o, 0x81, 0xa3, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.Key)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DumpKey) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Key":
+			z.Key, bts, err = msgp.ReadBytesBytes(bts, z.Key)
+			if err != nil {
+				err = msgp.WrapError(err, "Key")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DumpKey) Msgsize() (s int) {
+	s = 1 + 4 + msgp.BytesPrefixSize + len(z.Key)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *DumpTrailer) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Count":
+			z.Count, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "Keys":
+			z.Keys, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z DumpTrailer) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Count"
+	err = en.Append(0x82, 0xa5, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Count)
+	if err != nil {
+		err = msgp.WrapError(err, "Count")
+		return
+	}
+	// write "Keys"
+	err = en.Append(0xa4, 0x4b, 0x65, 0x79, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Keys)
+	if err != nil {
+		err = msgp.WrapError(err, "Keys")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z DumpTrailer) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Count"
+	o = append(o, 0x82, 0xa5, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt64(o, z.Count)
+	// string "Keys"
+	o = append(o, 0xa4, 0x4b, 0x65, 0x79, 0x73)
+	o = msgp.AppendInt64(o, z.Keys)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DumpTrailer) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Count":
+			z.Count, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "Keys":
+			z.Keys, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z DumpTrailer) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Int64Size + 5 + msgp.Int64Size
+	return
+}
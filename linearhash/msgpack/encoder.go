@@ -0,0 +1,39 @@
+package msgpack
+
+import (
+	"bytes"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// An Encoder encodes Buckets by streaming them through a msgp.Writer
+// into a buffer which is reused between encodings, rather than with
+// MarshalMsg. MarshalMsg first ensures its output can hold Msgsize
+// bytes, which is an upper bound that can be far larger than the
+// actual encoding (every integer is assumed to need 9 bytes, for
+// example), and so over-allocates badly for large Buckets. An Encoder
+// only copies the actual encoding to its output. An Encoder is not
+// safe for concurrent use.
+type Encoder struct {
+	buf bytes.Buffer
+	w   *msgp.Writer
+}
+
+func NewEncoder() *Encoder {
+	e := new(Encoder)
+	e.w = msgp.NewWriter(&e.buf)
+	return e
+}
+
+// EncodeBucket appends the encoding of b to dst, returning the
+// extended buffer, in the manner of MarshalMsg.
+func (e *Encoder) EncodeBucket(dst []byte, b *Bucket) ([]byte, error) {
+	e.buf.Reset()
+	e.w.Reset(&e.buf)
+	if err := b.EncodeMsg(e.w); err != nil {
+		return dst, err
+	}
+	if err := e.w.Flush(); err != nil {
+		return dst, err
+	}
+	return append(dst, e.buf.Bytes()...), nil
+}
@@ -0,0 +1,216 @@
+package msgpack
+
+import (
+	"fmt"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// The generated decoders skip map keys they do not know, and some
+// tolerate types other than the one written, which is what allows
+// older code to read newer encodings, but which also hides corruption
+// and version skew. CheckRoot and CheckBucket are the strict
+// alternative: they check an encoding field by field against the
+// current format, without decoding it.
+
+type fieldKind int
+
+const (
+	// a msgpack integer, signed or unsigned
+	kindInt fieldKind = iota
+	// an integer or a float, as accepted by msgp.Number
+	kindNumber
+	kindBool
+	// binary, or nil
+	kindBytes
+	kindString
+	// arrays of the above
+	kindInts
+	kindBytesList
+	kindStrings
+	// a map from strings to binary
+	kindBytesMap
+)
+
+var fieldKindNames = []string{
+	kindInt:       "an integer",
+	kindNumber:    "a number",
+	kindBool:      "a bool",
+	kindBytes:     "binary",
+	kindString:    "a string",
+	kindInts:      "an array of integers",
+	kindBytesList: "an array of binary",
+	kindStrings:   "an array of strings",
+	kindBytesMap:  "a map of strings to binary",
+}
+
+var rootFieldKinds = map[string]fieldKind{
+	"Version":           kindNumber,
+	"Size":              kindNumber,
+	"BucketCount":       kindNumber,
+	"SplitIndex":        kindNumber,
+	"MaskHigh":          kindNumber,
+	"MaskLow":           kindNumber,
+	"HashKey":           kindBytes,
+	"Meta":              kindBytesMap,
+	"MaxSize":           kindNumber,
+	"Companions":        kindStrings,
+	"SizeStripes":       kindNumber,
+	"DeferSplits":       kindBool,
+	"SplitPending":      kindBool,
+	"SortedBuckets":     kindBool,
+	"InlineThreshold":   kindNumber,
+	"MaxChainLength":    kindNumber,
+	"MinUtilization":    kindNumber,
+	"MaxUtilization":    kindNumber,
+	"Utilization":       kindNumber,
+	"LastSplit":         kindNumber,
+	"SplitInterval":     kindNumber,
+	"DirectoryPages":    kindNumber,
+	"DirectoryPageSize": kindNumber,
+	"WriteHeavy":        kindBool,
+	"MaxBucketCapacity": kindNumber,
+	"BlobThreshold":     kindNumber,
+	"ChangeEpoch":       kindNumber,
+	"CheckpointEpoch":   kindNumber,
+}
+
+var bucketFieldKinds = map[string]fieldKind{
+	"Version":    kindInt,
+	"Keys":       kindBytesList,
+	"Expiries":   kindInts,
+	"Accesses":   kindInts,
+	"Locks":      kindBytesMap,
+	"Versions":   kindInts,
+	"Hashes":     kindInts,
+	"Sorted":     kindBool,
+	"Inlined":    kindInts,
+	"Values":     kindBytesList,
+	"Bloom":      kindBytes,
+	"Epoch":      kindInt,
+	"Checkpoint": kindInt,
+}
+
+// CheckRoot returns a descriptive error if bts is not a Root in the
+// current encoding: if it has any field which the current format does
+// not have, or any field of the wrong type, or trailing bytes.
+func CheckRoot(bts []byte) error {
+	return checkFields("Root", rootFieldKinds, bts)
+}
+
+// CheckBucket is CheckRoot for Buckets. The legacy (version 0)
+// encoding of Buckets is also accepted, as DecodeBucket accepts it.
+func CheckBucket(bts []byte) error {
+	if msgp.NextType(bts) == msgp.ArrayType {
+		rest, err := checkKind(kindBytesList, bts)
+		if err != nil {
+			return fmt.Errorf("Legacy Bucket: %v", err)
+		}
+		return checkTrailing("Legacy Bucket", rest)
+	}
+	return checkFields("Bucket", bucketFieldKinds, bts)
+}
+
+func checkFields(name string, kinds map[string]fieldKind, bts []byte) error {
+	if t := msgp.NextType(bts); t != msgp.MapType {
+		return fmt.Errorf("%v is %v, not a map", name, t)
+	}
+	count, bts, err := msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+	seen := make(map[string]bool, count)
+	for ; count > 0; count-- {
+		var field string
+		if field, bts, err = msgp.ReadStringBytes(bts); err != nil {
+			return fmt.Errorf("%v has a field name which is not a string: %v", name, err)
+		}
+		kind, known := kinds[field]
+		if !known {
+			return fmt.Errorf("%v has unknown field %q", name, field)
+		} else if seen[field] {
+			return fmt.Errorf("%v has field %q more than once", name, field)
+		}
+		seen[field] = true
+		if bts, err = checkKind(kind, bts); err != nil {
+			return fmt.Errorf("%v field %q: %v", name, field, err)
+		}
+	}
+	return checkTrailing(name, bts)
+}
+
+func checkTrailing(name string, bts []byte) error {
+	if len(bts) != 0 {
+		return fmt.Errorf("%v is followed by %v unexpected bytes", name, len(bts))
+	}
+	return nil
+}
+
+// checkKind checks that the next value in bts is of the given kind,
+// returning the bytes following it.
+func checkKind(kind fieldKind, bts []byte) ([]byte, error) {
+	var elem fieldKind
+	switch kind {
+	case kindInts:
+		elem = kindInt
+	case kindBytesList:
+		elem = kindBytes
+	case kindStrings:
+		elem = kindString
+	case kindBytesMap:
+		if t := msgp.NextType(bts); t != msgp.MapType {
+			return nil, fmt.Errorf("found %v, expected %v", t, fieldKindNames[kind])
+		}
+		count, bts, err := msgp.ReadMapHeaderBytes(bts)
+		if err != nil {
+			return nil, err
+		}
+		for ; count > 0; count-- {
+			if _, bts, err = msgp.ReadStringBytes(bts); err != nil {
+				return nil, fmt.Errorf("%v in %v", err, fieldKindNames[kind])
+			}
+			if bts, err = checkKind(kindBytes, bts); err != nil {
+				return nil, fmt.Errorf("%v in %v", err, fieldKindNames[kind])
+			}
+		}
+		return bts, nil
+	default:
+		if !kindAccepts(kind, msgp.NextType(bts)) {
+			return nil, fmt.Errorf("found %v, expected %v", msgp.NextType(bts), fieldKindNames[kind])
+		}
+		return msgp.Skip(bts)
+	}
+	if t := msgp.NextType(bts); t != msgp.ArrayType {
+		return nil, fmt.Errorf("found %v, expected %v", t, fieldKindNames[kind])
+	}
+	count, bts, err := msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return nil, err
+	}
+	for idx := 0; idx < int(count); idx++ {
+		t := msgp.NextType(bts)
+		if !kindAccepts(elem, t) {
+			return nil, fmt.Errorf("element %v is %v, expected %v", idx, t, fieldKindNames[kind])
+		}
+		if bts, err = msgp.Skip(bts); err != nil {
+			return nil, err
+		}
+	}
+	return bts, nil
+}
+
+func kindAccepts(kind fieldKind, t msgp.Type) bool {
+	switch kind {
+	case kindInt:
+		return t == msgp.IntType || t == msgp.UintType
+	case kindNumber:
+		return t == msgp.IntType || t == msgp.UintType || t == msgp.Float64Type || t == msgp.Float32Type
+	case kindBool:
+		return t == msgp.BoolType
+	case kindBytes:
+		return t == msgp.BinType || t == msgp.NilType
+	case kindString:
+		return t == msgp.StrType
+	default:
+		return false
+	}
+}
@@ -6,51 +6,217 @@ package msgpack
 
 import (
 	"github.com/tinylib/msgp/msgp"
+	"strconv"
+)
+
+// The current versions of the Root and Bucket encodings. Roots
+// without a Version field, and Buckets encoded as a bare array of
+// keys, are version 0.
+const (
+	RootVersion          = 16
+	BucketVersion        = 12
+	DirectoryVersion     = 1
+	DirectoryPageVersion = 1
 )
 
 func NewRoot(hashKey []byte) *Root {
 	return &Root{
 		raw:         new(RootRaw),
+		Version:     RootVersion,
 		Size:        0,
 		BucketCount: 2,
 		SplitIndex:  0,
 		MaskHigh:    3,
 		MaskLow:     1,
 		HashKey:     hashKey,
+		Meta:        make(map[string][]byte),
 	}
 }
 
 type Root struct {
 	raw         *RootRaw
+	Version     uint64
 	Size        int64
 	BucketCount int64
 	SplitIndex  uint64
 	MaskHigh    uint64
 	MaskLow     uint64
 	HashKey     []byte
+	// Arbitrary user metadata, e.g. schema versions or owner
+	// information.
+	Meta map[string][]byte
+	// If greater than 0, the maximum number of entries, beyond which
+	// the least recently used entries are evicted. Added in version 2.
+	MaxSize int64
+	// Names of companion Objects. The references of the root Object
+	// are the bucket directory followed by one reference for each
+	// companion. Added in version 3.
+	Companions []string
+	// If greater than 0, the number of striped size counters, which
+	// are companions named by SizeStripeName. The number of entries is
+	// then Size plus the sum of the counters. Added in version 4.
+	SizeStripes int64
+	// If true, Puts do not split buckets, but instead set
+	// SplitPending, leaving the split to a separate maintenance
+	// transaction. Added in version 5.
+	DeferSplits  bool
+	SplitPending bool
+	// If true, Buckets are written Sorted. Added in version 6.
+	SortedBuckets bool
+	// Values of at most this many bytes are stored inline in Buckets
+	// by PutValue. 0 disables inline values. Added in version 7.
+	InlineThreshold int64
+	// If greater than 0, the maximum number of Buckets in a chain.
+	// Adding a Bucket to a chain which is already this long forces a
+	// split, regardless of utilization. Added in version 8.
+	MaxChainLength int64
+	// If MaxUtilization is greater than 0, the utilization threshold
+	// adapts between MinUtilization and MaxUtilization, and is
+	// currently Utilization. LastSplit is the time of the last split,
+	// in nanoseconds since the Unix epoch, and SplitInterval the
+	// moving average of the time between splits, in nanoseconds.
+	// Added in version 9.
+	MinUtilization float64
+	MaxUtilization float64
+	Utilization    float64
+	LastSplit      int64
+	SplitInterval  int64
+	// If greater than 0, the bucket directory is held in this many
+	// directory page Objects, whose references are the Buckets, and
+	// the references of the root Object begin with the pages rather
+	// than the Buckets. Added in version 10.
+	DirectoryPages int64
+	// If greater than 0, the number of references held by each
+	// directory page; otherwise there is a single page. Added in
+	// version 11.
+	DirectoryPageSize int64
+	// If true, removing entries neither trims the empty slots at the
+	// end of a Bucket nor unlinks emptied Buckets from their chains;
+	// that is left to maintenance. Added in version 12.
+	WriteHeavy bool
+	// If greater than BucketCapacity, new Buckets are created with
+	// more slots as the directory grows, up to this many. See
+	// BucketCapacity. Added in version 13.
+	MaxBucketCapacity int64
+	// If positive, PutValue stores values larger than this many bytes
+	// as chunked blobs rather than as single Objects. Added in version
+	// 14.
+	BlobThreshold int64
+	// If positive, change tracking is enabled, and every Bucket
+	// written is stamped with this epoch, which is advanced by each
+	// incremental dump (see LHash.DumpSince). Added in version 15.
+	ChangeEpoch int64
+	// The number of checkpoints taken which may share Buckets with
+	// the LHash: a Bucket with a lower Checkpoint is copied rather
+	// than modified (see LHash.Checkpoint). Added in version 16.
+	CheckpointEpoch int64
+}
+
+// SizeStripeName returns the companion name of the idx'th size
+// counter.
+func SizeStripeName(idx int64) string {
+	return "size." + strconv.FormatInt(idx, 10)
 }
 
 func (r *Root) UpdateRaw() *RootRaw {
 	raw := r.raw
+	r.Version = RootVersion
+	raw.Version.AsUint(r.Version)
 	raw.Size.AsInt(r.Size)
 	raw.BucketCount.AsInt(r.BucketCount)
 	raw.SplitIndex.AsUint(r.SplitIndex)
 	raw.MaskHigh.AsUint(r.MaskHigh)
 	raw.MaskLow.AsUint(r.MaskLow)
 	raw.HashKey = r.HashKey
+	raw.Meta = r.Meta
+	raw.MaxSize.AsInt(r.MaxSize)
+	raw.Companions = r.Companions
+	raw.SizeStripes.AsInt(r.SizeStripes)
+	raw.DeferSplits = r.DeferSplits
+	raw.SplitPending = r.SplitPending
+	raw.SortedBuckets = r.SortedBuckets
+	raw.InlineThreshold.AsInt(r.InlineThreshold)
+	raw.MaxChainLength.AsInt(r.MaxChainLength)
+	raw.MinUtilization.AsFloat64(r.MinUtilization)
+	raw.MaxUtilization.AsFloat64(r.MaxUtilization)
+	raw.Utilization.AsFloat64(r.Utilization)
+	raw.LastSplit.AsInt(r.LastSplit)
+	raw.SplitInterval.AsInt(r.SplitInterval)
+	raw.DirectoryPages.AsInt(r.DirectoryPages)
+	raw.DirectoryPageSize.AsInt(r.DirectoryPageSize)
+	raw.WriteHeavy = r.WriteHeavy
+	raw.MaxBucketCapacity.AsInt(r.MaxBucketCapacity)
+	raw.BlobThreshold.AsInt(r.BlobThreshold)
+	raw.ChangeEpoch.AsInt(r.ChangeEpoch)
+	raw.CheckpointEpoch.AsInt(r.CheckpointEpoch)
 	return raw
 }
 
+// Clone returns a copy of r which can be modified and encoded
+// independently of r.
+func (r *Root) Clone() *Root {
+	c := *r
+	c.raw = new(RootRaw)
+	c.HashKey = append([]byte{}, r.HashKey...)
+	c.Meta = make(map[string][]byte, len(r.Meta))
+	for k, v := range r.Meta {
+		c.Meta[k] = append([]byte{}, v...)
+	}
+	c.Companions = append([]string(nil), r.Companions...)
+	return &c
+}
+
 type RootRaw struct {
-	Size        msgp.Number
-	BucketCount msgp.Number
-	SplitIndex  msgp.Number
-	MaskHigh    msgp.Number
-	MaskLow     msgp.Number
-	HashKey     []byte
+	Version           msgp.Number
+	Size              msgp.Number
+	BucketCount       msgp.Number
+	SplitIndex        msgp.Number
+	MaskHigh          msgp.Number
+	MaskLow           msgp.Number
+	HashKey           []byte
+	Meta              map[string][]byte
+	MaxSize           msgp.Number
+	Companions        []string
+	SizeStripes       msgp.Number
+	DeferSplits       bool
+	SplitPending      bool
+	SortedBuckets     bool
+	InlineThreshold   msgp.Number
+	MaxChainLength    msgp.Number
+	MinUtilization    msgp.Number
+	MaxUtilization    msgp.Number
+	Utilization       msgp.Number
+	LastSplit         msgp.Number
+	SplitInterval     msgp.Number
+	DirectoryPages    msgp.Number
+	DirectoryPageSize msgp.Number
+	WriteHeavy        bool
+	MaxBucketCapacity msgp.Number
+	BlobThreshold     msgp.Number
+	ChangeEpoch       msgp.Number
+	CheckpointEpoch   msgp.Number
+}
+
+// Reset clears rr so that it can be reused for decoding, retaining
+// its HashKey buffer and Meta map.
+func (rr *RootRaw) Reset() {
+	for name := range rr.Meta {
+		delete(rr.Meta, name)
+	}
+	*rr = RootRaw{
+		HashKey:    rr.HashKey[:0],
+		Meta:       rr.Meta,
+		Companions: rr.Companions[:0],
+	}
 }
 
 func (rr *RootRaw) ToRoot() *Root {
+	vU, wasUint := rr.Version.Uint()
+	if !wasUint {
+		v, _ := rr.Version.Int()
+		vU = uint64(v)
+	}
+
 	size, wasInt := rr.Size.Int()
 	if !wasInt {
 		sizeU, _ := rr.Size.Uint()
@@ -81,22 +247,262 @@ func (rr *RootRaw) ToRoot() *Root {
 		mlU = uint64(ml)
 	}
 
+	maxSize, wasInt := rr.MaxSize.Int()
+	if !wasInt {
+		maxSizeU, _ := rr.MaxSize.Uint()
+		maxSize = int64(maxSizeU)
+	}
+
+	stripes, wasInt := rr.SizeStripes.Int()
+	if !wasInt {
+		stripesU, _ := rr.SizeStripes.Uint()
+		stripes = int64(stripesU)
+	}
+
+	inline, wasInt := rr.InlineThreshold.Int()
+	if !wasInt {
+		inlineU, _ := rr.InlineThreshold.Uint()
+		inline = int64(inlineU)
+	}
+
+	maxChain, wasInt := rr.MaxChainLength.Int()
+	if !wasInt {
+		maxChainU, _ := rr.MaxChainLength.Uint()
+		maxChain = int64(maxChainU)
+	}
+
+	lastSplit, wasInt := rr.LastSplit.Int()
+	if !wasInt {
+		lastSplitU, _ := rr.LastSplit.Uint()
+		lastSplit = int64(lastSplitU)
+	}
+
+	interval, wasInt := rr.SplitInterval.Int()
+	if !wasInt {
+		intervalU, _ := rr.SplitInterval.Uint()
+		interval = int64(intervalU)
+	}
+
+	pages, wasInt := rr.DirectoryPages.Int()
+	if !wasInt {
+		pagesU, _ := rr.DirectoryPages.Uint()
+		pages = int64(pagesU)
+	}
+
+	pageSize, wasInt := rr.DirectoryPageSize.Int()
+	if !wasInt {
+		pageSizeU, _ := rr.DirectoryPageSize.Uint()
+		pageSize = int64(pageSizeU)
+	}
+
+	maxBucketCapacity, wasInt := rr.MaxBucketCapacity.Int()
+	if !wasInt {
+		maxBucketCapacityU, _ := rr.MaxBucketCapacity.Uint()
+		maxBucketCapacity = int64(maxBucketCapacityU)
+	}
+
+	blobThreshold, wasInt := rr.BlobThreshold.Int()
+	if !wasInt {
+		blobThresholdU, _ := rr.BlobThreshold.Uint()
+		blobThreshold = int64(blobThresholdU)
+	}
+
+	changeEpoch, wasInt := rr.ChangeEpoch.Int()
+	if !wasInt {
+		changeEpochU, _ := rr.ChangeEpoch.Uint()
+		changeEpoch = int64(changeEpochU)
+	}
+
+	checkpointEpoch, wasInt := rr.CheckpointEpoch.Int()
+	if !wasInt {
+		checkpointEpochU, _ := rr.CheckpointEpoch.Uint()
+		checkpointEpoch = int64(checkpointEpochU)
+	}
+
+	minU, _ := rr.MinUtilization.Float()
+	maxU, _ := rr.MaxUtilization.Float()
+	util, _ := rr.Utilization.Float()
+
+	meta := rr.Meta
+	if meta == nil {
+		meta = make(map[string][]byte)
+	}
+
 	return &Root{
-		raw:         rr,
-		Size:        size,
-		BucketCount: bc,
-		SplitIndex:  siU,
-		MaskHigh:    mhU,
-		MaskLow:     mlU,
-		HashKey:     rr.HashKey,
+		raw:               rr,
+		Version:           vU,
+		Size:              size,
+		BucketCount:       bc,
+		SplitIndex:        siU,
+		MaskHigh:          mhU,
+		MaskLow:           mlU,
+		HashKey:           rr.HashKey,
+		Meta:              meta,
+		MaxSize:           maxSize,
+		Companions:        rr.Companions,
+		SizeStripes:       stripes,
+		DeferSplits:       rr.DeferSplits,
+		SplitPending:      rr.SplitPending,
+		SortedBuckets:     rr.SortedBuckets,
+		InlineThreshold:   inline,
+		MaxChainLength:    maxChain,
+		MinUtilization:    minU,
+		MaxUtilization:    maxU,
+		Utilization:       util,
+		LastSplit:         lastSplit,
+		SplitInterval:     interval,
+		DirectoryPages:    pages,
+		DirectoryPageSize: pageSize,
+		WriteHeavy:        rr.WriteHeavy,
+		MaxBucketCapacity: maxBucketCapacity,
+		BlobThreshold:     blobThreshold,
+		ChangeEpoch:       changeEpoch,
+		CheckpointEpoch:   checkpointEpoch,
+	}
+}
+
+type Bucket struct {
+	Version uint64
+	Keys    [][]byte
+	// Expiry times of the entries, in nanoseconds since the Unix
+	// epoch, with 0 meaning the entry never expires. If shorter than
+	// Keys, the missing entries never expire. Added in version 2.
+	Expiries []int64
+	// Last access times of the entries, in nanoseconds since the Unix
+	// epoch. Only maintained when the Root has a MaxSize. Added in
+	// version 3.
+	Accesses []int64
+	// Advisory locks, from key to lock holder, for keys which hash to
+	// this chain. Only present in the head Bucket of a chain. Added in
+	// version 4.
+	Locks map[string][]byte
+	// Versions of the entries: each Put which changes an entry
+	// increments its version. Added in version 5.
+	Versions []int64
+	// SipHash hashcodes of the keys, so that keys need only be
+	// compared when their hashes are equal, and so that splitting
+	// need not rehash keys. If shorter than Keys, no hashes are
+	// recorded. Added in version 6.
+	Hashes []uint64
+	// If true, the entries occupy the first slots, with no empty
+	// slots between them, and are ordered by hash and then by key, so
+	// can be binary searched. Added in version 7.
+	Sorted bool
+	// Flags (1 for inline) and values of entries whose values are
+	// stored inline rather than as separate Objects. The reference of
+	// an inline entry is to the Bucket itself. Added in version 8. A
+	// flag of 2 marks an entry whose value Object is the index Object
+	// of a chunked blob (see the blob package), with no inline value.
+	// Added in version 10.
+	Inlined []int64
+	Values  [][]byte
+	// A Bloom filter of the hashes of all the keys in the chain. Only
+	// present in the head Bucket of a chain of more than one Bucket.
+	// Added in version 9.
+	Bloom []byte
+	// The ChangeEpoch of the Root when the Bucket was last written,
+	// or 0 if change tracking was not enabled. Added in version 11.
+	Epoch int64
+	// The CheckpointEpoch of the Root when the Bucket was created or
+	// last copied. Added in version 12.
+	Checkpoint int64
+}
+
+// Directory is the root of a sharded LHash. Its references are the
+// roots of the shards. HashKey is used to choose the shard for each
+// key, and is independent of the hash keys of the shards themselves.
+type Directory struct {
+	Version uint64
+	HashKey []byte
+}
+
+// DirectoryPage is a page of the bucket directory of an LHash. Its
+// references are the heads of the bucket chains.
+type DirectoryPage struct {
+	Version uint64
+}
+
+// KeyList is a list of keys, as used by the values of the reverse
+// index.
+type KeyList [][]byte
+
+// LegacyBucket is the version 0 encoding of a Bucket: just the array
+// of keys.
+type LegacyBucket [][]byte
+
+func NewBucket() *Bucket {
+	return &Bucket{
+		Version: BucketVersion,
+		Keys:    make([][]byte, BucketCapacity),
+	}
+}
+
+// Reset makes b equivalent to a Bucket returned by NewBucket, but
+// retains the capacity of its slices so that it can be reused. Keys
+// themselves are never reused as they may still be referenced
+// elsewhere, and nor are inline values.
+func (b *Bucket) Reset() {
+	keys := b.Keys[:cap(b.Keys)]
+	for idx := range keys {
+		keys[idx] = nil
+	}
+	values := b.Values[:cap(b.Values)]
+	for idx := range values {
+		values[idx] = nil
+	}
+	if len(keys) < BucketCapacity {
+		keys = make([][]byte, BucketCapacity)
+	}
+	*b = Bucket{
+		Version:  BucketVersion,
+		Keys:     keys[:BucketCapacity],
+		Expiries: b.Expiries[:0],
+		Accesses: b.Accesses[:0],
+		Versions: b.Versions[:0],
+		Hashes:   b.Hashes[:0],
+		Inlined:  b.Inlined[:0],
+		Values:   values[:0],
+		Bloom:    b.Bloom[:0],
 	}
 }
 
-type Bucket [][]byte
+// DecodeBucket decodes a Bucket from either the current encoding or
+// the legacy (version 0) encoding.
+func DecodeBucket(bts []byte) (*Bucket, error) {
+	b := new(Bucket)
+	if err := DecodeBucketInto(b, bts); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// DecodeBucketInto is like DecodeBucket, but decodes into b, reusing
+// its slices where possible. b should either be new or have been
+// Reset.
+func DecodeBucketInto(b *Bucket, bts []byte) error {
+	b.Version = 0
+	if msgp.NextType(bts) == msgp.ArrayType {
+		legacy := LegacyBucket(b.Keys)
+		if _, err := legacy.UnmarshalMsg(bts); err != nil {
+			return err
+		}
+		b.Keys = ([][]byte)(legacy)
+		return nil
+	}
+	_, err := b.UnmarshalMsg(bts)
+	return err
+}
 
 const (
+	// The number of slots in a Bucket, unless the Root has a greater
+	// MaxBucketCapacity. The number of slots of each Bucket is
+	// recorded in its encoding, as the length of its Keys.
 	BucketCapacity    = 64
 	UtilizationFactor = 0.75
+	// The number of bucket chains at which the capacity of new
+	// Buckets first doubles. It doubles again each time the number of
+	// chains quadruples.
+	BucketCapacityChains = 1024
 )
 
 func (r *Root) BucketIndex(key uint64) uint64 {
@@ -107,6 +513,52 @@ func (r *Root) BucketIndex(key uint64) uint64 {
 	}
 }
 
+// Threshold returns the current utilization threshold: Utilization if
+// it is set, and otherwise UtilizationFactor.
+func (r *Root) Threshold() float64 {
+	if r.Utilization > 0 {
+		return r.Utilization
+	}
+	return UtilizationFactor
+}
+
 func (r *Root) NeedsSplit() bool {
-	return (float64(r.Size) / float64(BucketCapacity*r.BucketCount)) > UtilizationFactor
+	return r.NeedsSplitAt(r.Size)
+}
+
+// NeedsSplitAt is as NeedsSplit, but for the given number of entries
+// rather than Size.
+func (r *Root) NeedsSplitAt(size int64) bool {
+	return (float64(size) / float64(int64(r.BucketCapacity())*r.BucketCount)) > r.Threshold()
+}
+
+// ChainsNeedSplitAt is as NeedsSplitAt, but measures the utilization
+// of the bucket chains alone, ignoring their overflow buckets. Whilst
+// splits are deferred, Puts add overflow buckets rather than
+// splitting, and as BucketCount counts those too, NeedsSplitAt barely
+// rises past the threshold however long the chains grow. Deferred
+// splits are therefore due for as long as ChainsNeedSplitAt is true.
+func (r *Root) ChainsNeedSplitAt(size int64) bool {
+	return (float64(size) / float64(int64(r.BucketCapacity())*int64(r.MaskLow+1+r.SplitIndex))) > r.Threshold()
+}
+
+// BucketCapacity returns the number of slots of new Buckets: this is
+// BucketCapacity, doubling once there are BucketCapacityChains bucket
+// chains and again each time the number of chains quadruples, for as
+// long as the result is at most MaxBucketCapacity. Larger Buckets keep
+// the directory of a very large LHash to a manageable size. Existing
+// Buckets keep their capacity, but the head of a chain grows to the
+// current capacity when the chain is split.
+func (r *Root) BucketCapacity() int {
+	return r.BucketCapacityFor(r.MaskLow + 1 + r.SplitIndex)
+}
+
+// BucketCapacityFor is as BucketCapacity, but for the given number of
+// bucket chains.
+func (r *Root) BucketCapacityFor(chains uint64) int {
+	capacity := int64(BucketCapacity)
+	for ; chains >= BucketCapacityChains && 2*capacity <= r.MaxBucketCapacity; chains /= 4 {
+		capacity *= 2
+	}
+	return int(capacity)
 }
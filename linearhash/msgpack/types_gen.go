@@ -1,8 +1,6 @@
 package msgpack
 
-// NOTE: THIS FILE WAS PRODUCED BY THE
-// MSGP CODE GENERATION TOOL (github.com/tinylib/msgp)
-// DO NOT EDIT
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
 
 import (
 	"github.com/tinylib/msgp/msgp"
@@ -10,192 +8,1669 @@ import (
 
 // DecodeMsg implements msgp.Decodable
 func (z *Bucket) DecodeMsg(dc *msgp.Reader) (err error) {
-	var zbai uint32
-	zbai, err = dc.ReadArrayHeader()
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "Keys":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+			if cap(z.Keys) >= int(zb0002) {
+				z.Keys = (z.Keys)[:zb0002]
+			} else {
+				z.Keys = make([][]byte, zb0002)
+			}
+			for za0001 := range z.Keys {
+				z.Keys[za0001], err = dc.ReadBytes(z.Keys[za0001])
+				if err != nil {
+					err = msgp.WrapError(err, "Keys", za0001)
+					return
+				}
+			}
+		case "Expiries":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Expiries")
+				return
+			}
+			if cap(z.Expiries) >= int(zb0003) {
+				z.Expiries = (z.Expiries)[:zb0003]
+			} else {
+				z.Expiries = make([]int64, zb0003)
+			}
+			for za0002 := range z.Expiries {
+				z.Expiries[za0002], err = dc.ReadInt64()
+				if err != nil {
+					err = msgp.WrapError(err, "Expiries", za0002)
+					return
+				}
+			}
+		case "Accesses":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Accesses")
+				return
+			}
+			if cap(z.Accesses) >= int(zb0004) {
+				z.Accesses = (z.Accesses)[:zb0004]
+			} else {
+				z.Accesses = make([]int64, zb0004)
+			}
+			for za0003 := range z.Accesses {
+				z.Accesses[za0003], err = dc.ReadInt64()
+				if err != nil {
+					err = msgp.WrapError(err, "Accesses", za0003)
+					return
+				}
+			}
+		case "Locks":
+			var zb0005 uint32
+			zb0005, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Locks")
+				return
+			}
+			if z.Locks == nil {
+				z.Locks = make(map[string][]byte, zb0005)
+			} else if len(z.Locks) > 0 {
+				for key := range z.Locks {
+					delete(z.Locks, key)
+				}
+			}
+			for zb0005 > 0 {
+				zb0005--
+				var za0004 string
+				var za0005 []byte
+				za0004, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Locks")
+					return
+				}
+				za0005, err = dc.ReadBytes(za0005)
+				if err != nil {
+					err = msgp.WrapError(err, "Locks", za0004)
+					return
+				}
+				z.Locks[za0004] = za0005
+			}
+		case "Versions":
+			var zb0006 uint32
+			zb0006, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Versions")
+				return
+			}
+			if cap(z.Versions) >= int(zb0006) {
+				z.Versions = (z.Versions)[:zb0006]
+			} else {
+				z.Versions = make([]int64, zb0006)
+			}
+			for za0006 := range z.Versions {
+				z.Versions[za0006], err = dc.ReadInt64()
+				if err != nil {
+					err = msgp.WrapError(err, "Versions", za0006)
+					return
+				}
+			}
+		case "Hashes":
+			var zb0007 uint32
+			zb0007, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Hashes")
+				return
+			}
+			if cap(z.Hashes) >= int(zb0007) {
+				z.Hashes = (z.Hashes)[:zb0007]
+			} else {
+				z.Hashes = make([]uint64, zb0007)
+			}
+			for za0007 := range z.Hashes {
+				z.Hashes[za0007], err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Hashes", za0007)
+					return
+				}
+			}
+		case "Sorted":
+			z.Sorted, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Sorted")
+				return
+			}
+		case "Inlined":
+			var zb0008 uint32
+			zb0008, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Inlined")
+				return
+			}
+			if cap(z.Inlined) >= int(zb0008) {
+				z.Inlined = (z.Inlined)[:zb0008]
+			} else {
+				z.Inlined = make([]int64, zb0008)
+			}
+			for za0008 := range z.Inlined {
+				z.Inlined[za0008], err = dc.ReadInt64()
+				if err != nil {
+					err = msgp.WrapError(err, "Inlined", za0008)
+					return
+				}
+			}
+		case "Values":
+			var zb0009 uint32
+			zb0009, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0009) {
+				z.Values = (z.Values)[:zb0009]
+			} else {
+				z.Values = make([][]byte, zb0009)
+			}
+			for za0009 := range z.Values {
+				z.Values[za0009], err = dc.ReadBytes(z.Values[za0009])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0009)
+					return
+				}
+			}
+		case "Bloom":
+			z.Bloom, err = dc.ReadBytes(z.Bloom)
+			if err != nil {
+				err = msgp.WrapError(err, "Bloom")
+				return
+			}
+		case "Epoch":
+			z.Epoch, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Epoch")
+				return
+			}
+		case "Checkpoint":
+			z.Checkpoint, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Checkpoint")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Bucket) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 13
+	// write "Version"
+	err = en.Append(0x8d, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Version)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	// write "Keys"
+	err = en.Append(0xa4, 0x4b, 0x65, 0x79, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Keys)))
+	if err != nil {
+		err = msgp.WrapError(err, "Keys")
+		return
+	}
+	for za0001 := range z.Keys {
+		err = en.WriteBytes(z.Keys[za0001])
+		if err != nil {
+			err = msgp.WrapError(err, "Keys", za0001)
+			return
+		}
+	}
+	// write "Expiries"
+	err = en.Append(0xa8, 0x45, 0x78, 0x70, 0x69, 0x72, 0x69, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Expiries)))
+	if err != nil {
+		err = msgp.WrapError(err, "Expiries")
+		return
+	}
+	for za0002 := range z.Expiries {
+		err = en.WriteInt64(z.Expiries[za0002])
+		if err != nil {
+			err = msgp.WrapError(err, "Expiries", za0002)
+			return
+		}
+	}
+	// write "Accesses"
+	err = en.Append(0xa8, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Accesses)))
+	if err != nil {
+		err = msgp.WrapError(err, "Accesses")
+		return
+	}
+	for za0003 := range z.Accesses {
+		err = en.WriteInt64(z.Accesses[za0003])
+		if err != nil {
+			err = msgp.WrapError(err, "Accesses", za0003)
+			return
+		}
+	}
+	// write "Locks"
+	err = en.Append(0xa5, 0x4c, 0x6f, 0x63, 0x6b, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteMapHeader(uint32(len(z.Locks)))
+	if err != nil {
+		err = msgp.WrapError(err, "Locks")
+		return
+	}
+	for za0004, za0005 := range z.Locks {
+		err = en.WriteString(za0004)
+		if err != nil {
+			err = msgp.WrapError(err, "Locks")
+			return
+		}
+		err = en.WriteBytes(za0005)
+		if err != nil {
+			err = msgp.WrapError(err, "Locks", za0004)
+			return
+		}
+	}
+	// write "Versions"
+	err = en.Append(0xa8, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Versions)))
+	if err != nil {
+		err = msgp.WrapError(err, "Versions")
+		return
+	}
+	for za0006 := range z.Versions {
+		err = en.WriteInt64(z.Versions[za0006])
+		if err != nil {
+			err = msgp.WrapError(err, "Versions", za0006)
+			return
+		}
+	}
+	// write "Hashes"
+	err = en.Append(0xa6, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Hashes)))
+	if err != nil {
+		err = msgp.WrapError(err, "Hashes")
+		return
+	}
+	for za0007 := range z.Hashes {
+		err = en.WriteUint64(z.Hashes[za0007])
+		if err != nil {
+			err = msgp.WrapError(err, "Hashes", za0007)
+			return
+		}
+	}
+	// write "Sorted"
+	err = en.Append(0xa6, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Sorted)
+	if err != nil {
+		err = msgp.WrapError(err, "Sorted")
+		return
+	}
+	// write "Inlined"
+	err = en.Append(0xa7, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Inlined)))
+	if err != nil {
+		err = msgp.WrapError(err, "Inlined")
+		return
+	}
+	for za0008 := range z.Inlined {
+		err = en.WriteInt64(z.Inlined[za0008])
+		if err != nil {
+			err = msgp.WrapError(err, "Inlined", za0008)
+			return
+		}
+	}
+	// write "Values"
+	err = en.Append(0xa6, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Values)))
+	if err != nil {
+		err = msgp.WrapError(err, "Values")
+		return
+	}
+	for za0009 := range z.Values {
+		err = en.WriteBytes(z.Values[za0009])
+		if err != nil {
+			err = msgp.WrapError(err, "Values", za0009)
+			return
+		}
+	}
+	// write "Bloom"
+	err = en.Append(0xa5, 0x42, 0x6c, 0x6f, 0x6f, 0x6d)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Bloom)
+	if err != nil {
+		err = msgp.WrapError(err, "Bloom")
+		return
+	}
+	// write "Epoch"
+	err = en.Append(0xa5, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Epoch)
+	if err != nil {
+		err = msgp.WrapError(err, "Epoch")
+		return
+	}
+	// write "Checkpoint"
+	err = en.Append(0xaa, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Checkpoint)
+	if err != nil {
+		err = msgp.WrapError(err, "Checkpoint")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Bucket) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 13
+	// string "Version"
+	o = append(o, 0x8d, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendUint64(o, z.Version)
+	// string "Keys"
+	o = append(o, 0xa4, 0x4b, 0x65, 0x79, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Keys)))
+	for za0001 := range z.Keys {
+		o = msgp.AppendBytes(o, z.Keys[za0001])
+	}
+	// string "Expiries"
+	o = append(o, 0xa8, 0x45, 0x78, 0x70, 0x69, 0x72, 0x69, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Expiries)))
+	for za0002 := range z.Expiries {
+		o = msgp.AppendInt64(o, z.Expiries[za0002])
+	}
+	// string "Accesses"
+	o = append(o, 0xa8, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Accesses)))
+	for za0003 := range z.Accesses {
+		o = msgp.AppendInt64(o, z.Accesses[za0003])
+	}
+	// string "Locks"
+	o = append(o, 0xa5, 0x4c, 0x6f, 0x63, 0x6b, 0x73)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Locks)))
+	for za0004, za0005 := range z.Locks {
+		o = msgp.AppendString(o, za0004)
+		o = msgp.AppendBytes(o, za0005)
+	}
+	// string "Versions"
+	o = append(o, 0xa8, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Versions)))
+	for za0006 := range z.Versions {
+		o = msgp.AppendInt64(o, z.Versions[za0006])
+	}
+	// string "Hashes"
+	o = append(o, 0xa6, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Hashes)))
+	for za0007 := range z.Hashes {
+		o = msgp.AppendUint64(o, z.Hashes[za0007])
+	}
+	// string "Sorted"
+	o = append(o, 0xa6, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64)
+	o = msgp.AppendBool(o, z.Sorted)
+	// string "Inlined"
+	o = append(o, 0xa7, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x64)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Inlined)))
+	for za0008 := range z.Inlined {
+		o = msgp.AppendInt64(o, z.Inlined[za0008])
+	}
+	// string "Values"
+	o = append(o, 0xa6, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Values)))
+	for za0009 := range z.Values {
+		o = msgp.AppendBytes(o, z.Values[za0009])
+	}
+	// string "Bloom"
+	o = append(o, 0xa5, 0x42, 0x6c, 0x6f, 0x6f, 0x6d)
+	o = msgp.AppendBytes(o, z.Bloom)
+	// string "Epoch"
+	o = append(o, 0xa5, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	o = msgp.AppendInt64(o, z.Epoch)
+	// string "Checkpoint"
+	o = append(o, 0xaa, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74)
+	o = msgp.AppendInt64(o, z.Checkpoint)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Bucket) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "Keys":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+			if cap(z.Keys) >= int(zb0002) {
+				z.Keys = (z.Keys)[:zb0002]
+			} else {
+				z.Keys = make([][]byte, zb0002)
+			}
+			for za0001 := range z.Keys {
+				z.Keys[za0001], bts, err = msgp.ReadBytesBytes(bts, z.Keys[za0001])
+				if err != nil {
+					err = msgp.WrapError(err, "Keys", za0001)
+					return
+				}
+			}
+		case "Expiries":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Expiries")
+				return
+			}
+			if cap(z.Expiries) >= int(zb0003) {
+				z.Expiries = (z.Expiries)[:zb0003]
+			} else {
+				z.Expiries = make([]int64, zb0003)
+			}
+			for za0002 := range z.Expiries {
+				z.Expiries[za0002], bts, err = msgp.ReadInt64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Expiries", za0002)
+					return
+				}
+			}
+		case "Accesses":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Accesses")
+				return
+			}
+			if cap(z.Accesses) >= int(zb0004) {
+				z.Accesses = (z.Accesses)[:zb0004]
+			} else {
+				z.Accesses = make([]int64, zb0004)
+			}
+			for za0003 := range z.Accesses {
+				z.Accesses[za0003], bts, err = msgp.ReadInt64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Accesses", za0003)
+					return
+				}
+			}
+		case "Locks":
+			var zb0005 uint32
+			zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Locks")
+				return
+			}
+			if z.Locks == nil {
+				z.Locks = make(map[string][]byte, zb0005)
+			} else if len(z.Locks) > 0 {
+				for key := range z.Locks {
+					delete(z.Locks, key)
+				}
+			}
+			for zb0005 > 0 {
+				var za0004 string
+				var za0005 []byte
+				zb0005--
+				za0004, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Locks")
+					return
+				}
+				za0005, bts, err = msgp.ReadBytesBytes(bts, za0005)
+				if err != nil {
+					err = msgp.WrapError(err, "Locks", za0004)
+					return
+				}
+				z.Locks[za0004] = za0005
+			}
+		case "Versions":
+			var zb0006 uint32
+			zb0006, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Versions")
+				return
+			}
+			if cap(z.Versions) >= int(zb0006) {
+				z.Versions = (z.Versions)[:zb0006]
+			} else {
+				z.Versions = make([]int64, zb0006)
+			}
+			for za0006 := range z.Versions {
+				z.Versions[za0006], bts, err = msgp.ReadInt64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Versions", za0006)
+					return
+				}
+			}
+		case "Hashes":
+			var zb0007 uint32
+			zb0007, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Hashes")
+				return
+			}
+			if cap(z.Hashes) >= int(zb0007) {
+				z.Hashes = (z.Hashes)[:zb0007]
+			} else {
+				z.Hashes = make([]uint64, zb0007)
+			}
+			for za0007 := range z.Hashes {
+				z.Hashes[za0007], bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Hashes", za0007)
+					return
+				}
+			}
+		case "Sorted":
+			z.Sorted, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Sorted")
+				return
+			}
+		case "Inlined":
+			var zb0008 uint32
+			zb0008, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Inlined")
+				return
+			}
+			if cap(z.Inlined) >= int(zb0008) {
+				z.Inlined = (z.Inlined)[:zb0008]
+			} else {
+				z.Inlined = make([]int64, zb0008)
+			}
+			for za0008 := range z.Inlined {
+				z.Inlined[za0008], bts, err = msgp.ReadInt64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Inlined", za0008)
+					return
+				}
+			}
+		case "Values":
+			var zb0009 uint32
+			zb0009, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0009) {
+				z.Values = (z.Values)[:zb0009]
+			} else {
+				z.Values = make([][]byte, zb0009)
+			}
+			for za0009 := range z.Values {
+				z.Values[za0009], bts, err = msgp.ReadBytesBytes(bts, z.Values[za0009])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0009)
+					return
+				}
+			}
+		case "Bloom":
+			z.Bloom, bts, err = msgp.ReadBytesBytes(bts, z.Bloom)
+			if err != nil {
+				err = msgp.WrapError(err, "Bloom")
+				return
+			}
+		case "Epoch":
+			z.Epoch, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Epoch")
+				return
+			}
+		case "Checkpoint":
+			z.Checkpoint, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Checkpoint")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Bucket) Msgsize() (s int) {
+	s = 1 + 8 + msgp.Uint64Size + 5 + msgp.ArrayHeaderSize
+	for za0001 := range z.Keys {
+		s += msgp.BytesPrefixSize + len(z.Keys[za0001])
+	}
+	s += 9 + msgp.ArrayHeaderSize + (len(z.Expiries) * (msgp.Int64Size)) + 9 + msgp.ArrayHeaderSize + (len(z.Accesses) * (msgp.Int64Size)) + 6 + msgp.MapHeaderSize
+	if z.Locks != nil {
+		for za0004, za0005 := range z.Locks {
+			_ = za0005
+			s += msgp.StringPrefixSize + len(za0004) + msgp.BytesPrefixSize + len(za0005)
+		}
+	}
+	s += 9 + msgp.ArrayHeaderSize + (len(z.Versions) * (msgp.Int64Size)) + 7 + msgp.ArrayHeaderSize + (len(z.Hashes) * (msgp.Uint64Size)) + 7 + msgp.BoolSize + 8 + msgp.ArrayHeaderSize + (len(z.Inlined) * (msgp.Int64Size)) + 7 + msgp.ArrayHeaderSize
+	for za0009 := range z.Values {
+		s += msgp.BytesPrefixSize + len(z.Values[za0009])
+	}
+	s += 6 + msgp.BytesPrefixSize + len(z.Bloom) + 6 + msgp.Int64Size + 11 + msgp.Int64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Directory) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "HashKey":
+			z.HashKey, err = dc.ReadBytes(z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Directory) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Version"
+	err = en.Append(0x82, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Version)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	// write "HashKey"
+	err = en.Append(0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.HashKey)
+	if err != nil {
+		err = msgp.WrapError(err, "HashKey")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Directory) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Version"
+	o = append(o, 0x82, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendUint64(o, z.Version)
+	// string "HashKey"
+	o = append(o, 0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	o = msgp.AppendBytes(o, z.HashKey)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Directory) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "HashKey":
+			z.HashKey, bts, err = msgp.ReadBytesBytes(bts, z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Directory) Msgsize() (s int) {
+	s = 1 + 8 + msgp.Uint64Size + 8 + msgp.BytesPrefixSize + len(z.HashKey)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *DirectoryPage) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z DirectoryPage) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Version"
+	err = en.Append(0x81, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Version)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z DirectoryPage) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Version"
+	o = append(o, 0x81, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendUint64(o, z.Version)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DirectoryPage) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z DirectoryPage) Msgsize() (s int) {
+	s = 1 + 8 + msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *KeyList) DecodeMsg(dc *msgp.Reader) (err error) {
+	var zb0002 uint32
+	zb0002, err = dc.ReadArrayHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(KeyList, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], err = dc.ReadBytes((*z)[zb0001])
+		if err != nil {
+			err = msgp.WrapError(err, zb0001)
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z KeyList) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteArrayHeader(uint32(len(z)))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0003 := range z {
+		err = en.WriteBytes(z[zb0003])
+		if err != nil {
+			err = msgp.WrapError(err, zb0003)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z KeyList) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendArrayHeader(o, uint32(len(z)))
+	for zb0003 := range z {
+		o = msgp.AppendBytes(o, z[zb0003])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *KeyList) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var zb0002 uint32
+	zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(KeyList, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], bts, err = msgp.ReadBytesBytes(bts, (*z)[zb0001])
+		if err != nil {
+			err = msgp.WrapError(err, zb0001)
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z KeyList) Msgsize() (s int) {
+	s = msgp.ArrayHeaderSize
+	for zb0003 := range z {
+		s += msgp.BytesPrefixSize + len(z[zb0003])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *LegacyBucket) DecodeMsg(dc *msgp.Reader) (err error) {
+	var zb0002 uint32
+	zb0002, err = dc.ReadArrayHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(LegacyBucket, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], err = dc.ReadBytes((*z)[zb0001])
+		if err != nil {
+			err = msgp.WrapError(err, zb0001)
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z LegacyBucket) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteArrayHeader(uint32(len(z)))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0003 := range z {
+		err = en.WriteBytes(z[zb0003])
+		if err != nil {
+			err = msgp.WrapError(err, zb0003)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z LegacyBucket) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendArrayHeader(o, uint32(len(z)))
+	for zb0003 := range z {
+		o = msgp.AppendBytes(o, z[zb0003])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *LegacyBucket) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var zb0002 uint32
+	zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(LegacyBucket, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], bts, err = msgp.ReadBytesBytes(bts, (*z)[zb0001])
+		if err != nil {
+			err = msgp.WrapError(err, zb0001)
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z LegacyBucket) Msgsize() (s int) {
+	s = msgp.ArrayHeaderSize
+	for zb0003 := range z {
+		s += msgp.BytesPrefixSize + len(z[zb0003])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *RootRaw) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Version":
+			err = z.Version.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "Size":
+			err = z.Size.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "BucketCount":
+			err = z.BucketCount.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "BucketCount")
+				return
+			}
+		case "SplitIndex":
+			err = z.SplitIndex.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "SplitIndex")
+				return
+			}
+		case "MaskHigh":
+			err = z.MaskHigh.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaskHigh")
+				return
+			}
+		case "MaskLow":
+			err = z.MaskLow.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaskLow")
+				return
+			}
+		case "HashKey":
+			z.HashKey, err = dc.ReadBytes(z.HashKey)
+			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		case "Meta":
+			var zb0002 uint32
+			zb0002, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Meta")
+				return
+			}
+			if z.Meta == nil {
+				z.Meta = make(map[string][]byte, zb0002)
+			} else if len(z.Meta) > 0 {
+				for key := range z.Meta {
+					delete(z.Meta, key)
+				}
+			}
+			for zb0002 > 0 {
+				zb0002--
+				var za0001 string
+				var za0002 []byte
+				za0001, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Meta")
+					return
+				}
+				za0002, err = dc.ReadBytes(za0002)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta", za0001)
+					return
+				}
+				z.Meta[za0001] = za0002
+			}
+		case "MaxSize":
+			err = z.MaxSize.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxSize")
+				return
+			}
+		case "Companions":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Companions")
+				return
+			}
+			if cap(z.Companions) >= int(zb0003) {
+				z.Companions = (z.Companions)[:zb0003]
+			} else {
+				z.Companions = make([]string, zb0003)
+			}
+			for za0003 := range z.Companions {
+				z.Companions[za0003], err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Companions", za0003)
+					return
+				}
+			}
+		case "SizeStripes":
+			err = z.SizeStripes.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "SizeStripes")
+				return
+			}
+		case "DeferSplits":
+			z.DeferSplits, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "DeferSplits")
+				return
+			}
+		case "SplitPending":
+			z.SplitPending, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "SplitPending")
+				return
+			}
+		case "SortedBuckets":
+			z.SortedBuckets, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "SortedBuckets")
+				return
+			}
+		case "InlineThreshold":
+			err = z.InlineThreshold.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "InlineThreshold")
+				return
+			}
+		case "MaxChainLength":
+			err = z.MaxChainLength.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxChainLength")
+				return
+			}
+		case "MinUtilization":
+			err = z.MinUtilization.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MinUtilization")
+				return
+			}
+		case "MaxUtilization":
+			err = z.MaxUtilization.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxUtilization")
+				return
+			}
+		case "Utilization":
+			err = z.Utilization.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Utilization")
+				return
+			}
+		case "LastSplit":
+			err = z.LastSplit.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "LastSplit")
+				return
+			}
+		case "SplitInterval":
+			err = z.SplitInterval.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "SplitInterval")
+				return
+			}
+		case "DirectoryPages":
+			err = z.DirectoryPages.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPages")
+				return
+			}
+		case "DirectoryPageSize":
+			err = z.DirectoryPageSize.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPageSize")
+				return
+			}
+		case "WriteHeavy":
+			z.WriteHeavy, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "WriteHeavy")
+				return
+			}
+		case "MaxBucketCapacity":
+			err = z.MaxBucketCapacity.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxBucketCapacity")
+				return
+			}
+		case "BlobThreshold":
+			err = z.BlobThreshold.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "BlobThreshold")
+				return
+			}
+		case "ChangeEpoch":
+			err = z.ChangeEpoch.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "ChangeEpoch")
+				return
+			}
+		case "CheckpointEpoch":
+			err = z.CheckpointEpoch.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "CheckpointEpoch")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *RootRaw) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 28
+	// write "Version"
+	err = en.Append(0xde, 0x0, 0x1c, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = z.Version.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
+	// write "Size"
+	err = en.Append(0xa4, 0x53, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = z.Size.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Size")
+		return
+	}
+	// write "BucketCount"
+	err = en.Append(0xab, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = z.BucketCount.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "BucketCount")
+		return
+	}
+	// write "SplitIndex"
+	err = en.Append(0xaa, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	if err != nil {
+		return
+	}
+	err = z.SplitIndex.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "SplitIndex")
+		return
+	}
+	// write "MaskHigh"
+	err = en.Append(0xa8, 0x4d, 0x61, 0x73, 0x6b, 0x48, 0x69, 0x67, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.MaskHigh.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MaskHigh")
+		return
+	}
+	// write "MaskLow"
+	err = en.Append(0xa7, 0x4d, 0x61, 0x73, 0x6b, 0x4c, 0x6f, 0x77)
+	if err != nil {
+		return
+	}
+	err = z.MaskLow.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MaskLow")
+		return
+	}
+	// write "HashKey"
+	err = en.Append(0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.HashKey)
+	if err != nil {
+		err = msgp.WrapError(err, "HashKey")
+		return
+	}
+	// write "Meta"
+	err = en.Append(0xa4, 0x4d, 0x65, 0x74, 0x61)
+	if err != nil {
+		return
+	}
+	err = en.WriteMapHeader(uint32(len(z.Meta)))
+	if err != nil {
+		err = msgp.WrapError(err, "Meta")
+		return
+	}
+	for za0001, za0002 := range z.Meta {
+		err = en.WriteString(za0001)
+		if err != nil {
+			err = msgp.WrapError(err, "Meta")
+			return
+		}
+		err = en.WriteBytes(za0002)
+		if err != nil {
+			err = msgp.WrapError(err, "Meta", za0001)
+			return
+		}
+	}
+	// write "MaxSize"
+	err = en.Append(0xa7, 0x4d, 0x61, 0x78, 0x53, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = z.MaxSize.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxSize")
+		return
+	}
+	// write "Companions"
+	err = en.Append(0xaa, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x69, 0x6f, 0x6e, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Companions)))
+	if err != nil {
+		err = msgp.WrapError(err, "Companions")
+		return
+	}
+	for za0003 := range z.Companions {
+		err = en.WriteString(z.Companions[za0003])
+		if err != nil {
+			err = msgp.WrapError(err, "Companions", za0003)
+			return
+		}
+	}
+	// write "SizeStripes"
+	err = en.Append(0xab, 0x53, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x69, 0x70, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.SizeStripes.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "SizeStripes")
+		return
+	}
+	// write "DeferSplits"
+	err = en.Append(0xab, 0x44, 0x65, 0x66, 0x65, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.DeferSplits)
+	if err != nil {
+		err = msgp.WrapError(err, "DeferSplits")
+		return
+	}
+	// write "SplitPending"
+	err = en.Append(0xac, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.SplitPending)
+	if err != nil {
+		err = msgp.WrapError(err, "SplitPending")
+		return
+	}
+	// write "SortedBuckets"
+	err = en.Append(0xad, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.SortedBuckets)
+	if err != nil {
+		err = msgp.WrapError(err, "SortedBuckets")
+		return
+	}
+	// write "InlineThreshold"
+	err = en.Append(0xaf, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	if err != nil {
+		return
+	}
+	err = z.InlineThreshold.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "InlineThreshold")
+		return
+	}
+	// write "MaxChainLength"
+	err = en.Append(0xae, 0x4d, 0x61, 0x78, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68)
 	if err != nil {
 		return
 	}
-	if cap((*z)) >= int(zbai) {
-		(*z) = (*z)[:zbai]
-	} else {
-		(*z) = make(Bucket, zbai)
+	err = z.MaxChainLength.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxChainLength")
+		return
 	}
-	for zbzg := range *z {
-		(*z)[zbzg], err = dc.ReadBytes((*z)[zbzg])
-		if err != nil {
-			return
-		}
+	// write "MinUtilization"
+	err = en.Append(0xae, 0x4d, 0x69, 0x6e, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
 	}
-	return
-}
-
-// EncodeMsg implements msgp.Encodable
-func (z Bucket) EncodeMsg(en *msgp.Writer) (err error) {
-	err = en.WriteArrayHeader(uint32(len(z)))
+	err = z.MinUtilization.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "MinUtilization")
 		return
 	}
-	for zcmr := range z {
-		err = en.WriteBytes(z[zcmr])
-		if err != nil {
-			return
-		}
+	// write "MaxUtilization"
+	err = en.Append(0xae, 0x4d, 0x61, 0x78, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
 	}
-	return
-}
-
-// MarshalMsg implements msgp.Marshaler
-func (z Bucket) MarshalMsg(b []byte) (o []byte, err error) {
-	o = msgp.Require(b, z.Msgsize())
-	o = msgp.AppendArrayHeader(o, uint32(len(z)))
-	for zcmr := range z {
-		o = msgp.AppendBytes(o, z[zcmr])
+	err = z.MaxUtilization.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxUtilization")
+		return
 	}
-	return
-}
-
-// UnmarshalMsg implements msgp.Unmarshaler
-func (z *Bucket) UnmarshalMsg(bts []byte) (o []byte, err error) {
-	var zwht uint32
-	zwht, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	// write "Utilization"
+	err = en.Append(0xab, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
 	if err != nil {
 		return
 	}
-	if cap((*z)) >= int(zwht) {
-		(*z) = (*z)[:zwht]
-	} else {
-		(*z) = make(Bucket, zwht)
+	err = z.Utilization.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Utilization")
+		return
 	}
-	for zajw := range *z {
-		(*z)[zajw], bts, err = msgp.ReadBytesBytes(bts, (*z)[zajw])
-		if err != nil {
-			return
-		}
+	// write "LastSplit"
+	err = en.Append(0xa9, 0x4c, 0x61, 0x73, 0x74, 0x53, 0x70, 0x6c, 0x69, 0x74)
+	if err != nil {
+		return
 	}
-	o = bts
-	return
-}
-
-// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
-func (z Bucket) Msgsize() (s int) {
-	s = msgp.ArrayHeaderSize
-	for zhct := range z {
-		s += msgp.BytesPrefixSize + len(z[zhct])
+	err = z.LastSplit.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "LastSplit")
+		return
 	}
-	return
-}
-
-// DecodeMsg implements msgp.Decodable
-func (z *RootRaw) DecodeMsg(dc *msgp.Reader) (err error) {
-	var field []byte
-	_ = field
-	var zcua uint32
-	zcua, err = dc.ReadMapHeader()
+	// write "SplitInterval"
+	err = en.Append(0xad, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c)
 	if err != nil {
 		return
 	}
-	for zcua > 0 {
-		zcua--
-		field, err = dc.ReadMapKeyPtr()
-		if err != nil {
-			return
-		}
-		switch msgp.UnsafeString(field) {
-		case "Size":
-			err = z.Size.DecodeMsg(dc)
-			if err != nil {
-				return
-			}
-		case "BucketCount":
-			err = z.BucketCount.DecodeMsg(dc)
-			if err != nil {
-				return
-			}
-		case "SplitIndex":
-			err = z.SplitIndex.DecodeMsg(dc)
-			if err != nil {
-				return
-			}
-		case "MaskHigh":
-			err = z.MaskHigh.DecodeMsg(dc)
-			if err != nil {
-				return
-			}
-		case "MaskLow":
-			err = z.MaskLow.DecodeMsg(dc)
-			if err != nil {
-				return
-			}
-		case "HashKey":
-			z.HashKey, err = dc.ReadBytes(z.HashKey)
-			if err != nil {
-				return
-			}
-		default:
-			err = dc.Skip()
-			if err != nil {
-				return
-			}
-		}
+	err = z.SplitInterval.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "SplitInterval")
+		return
 	}
-	return
-}
-
-// EncodeMsg implements msgp.Encodable
-func (z *RootRaw) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 6
-	// write "Size"
-	err = en.Append(0x86, 0xa4, 0x53, 0x69, 0x7a, 0x65)
+	// write "DirectoryPages"
+	err = en.Append(0xae, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x73)
 	if err != nil {
-		return err
+		return
 	}
-	err = z.Size.EncodeMsg(en)
+	err = z.DirectoryPages.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "DirectoryPages")
 		return
 	}
-	// write "BucketCount"
-	err = en.Append(0xab, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	// write "DirectoryPageSize"
+	err = en.Append(0xb1, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65)
 	if err != nil {
-		return err
+		return
 	}
-	err = z.BucketCount.EncodeMsg(en)
+	err = z.DirectoryPageSize.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "DirectoryPageSize")
 		return
 	}
-	// write "SplitIndex"
-	err = en.Append(0xaa, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	// write "WriteHeavy"
+	err = en.Append(0xaa, 0x57, 0x72, 0x69, 0x74, 0x65, 0x48, 0x65, 0x61, 0x76, 0x79)
 	if err != nil {
-		return err
+		return
 	}
-	err = z.SplitIndex.EncodeMsg(en)
+	err = en.WriteBool(z.WriteHeavy)
 	if err != nil {
+		err = msgp.WrapError(err, "WriteHeavy")
 		return
 	}
-	// write "MaskHigh"
-	err = en.Append(0xa8, 0x4d, 0x61, 0x73, 0x6b, 0x48, 0x69, 0x67, 0x68)
+	// write "MaxBucketCapacity"
+	err = en.Append(0xb1, 0x4d, 0x61, 0x78, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
 	if err != nil {
-		return err
+		return
 	}
-	err = z.MaskHigh.EncodeMsg(en)
+	err = z.MaxBucketCapacity.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "MaxBucketCapacity")
 		return
 	}
-	// write "MaskLow"
-	err = en.Append(0xa7, 0x4d, 0x61, 0x73, 0x6b, 0x4c, 0x6f, 0x77)
+	// write "BlobThreshold"
+	err = en.Append(0xad, 0x42, 0x6c, 0x6f, 0x62, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
 	if err != nil {
-		return err
+		return
 	}
-	err = z.MaskLow.EncodeMsg(en)
+	err = z.BlobThreshold.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "BlobThreshold")
 		return
 	}
-	// write "HashKey"
-	err = en.Append(0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
+	// write "ChangeEpoch"
+	err = en.Append(0xab, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x70, 0x6f, 0x63, 0x68)
 	if err != nil {
-		return err
+		return
 	}
-	err = en.WriteBytes(z.HashKey)
+	err = z.ChangeEpoch.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "ChangeEpoch")
+		return
+	}
+	// write "CheckpointEpoch"
+	err = en.Append(0xaf, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.CheckpointEpoch.EncodeMsg(en)
 	if err != nil {
+		err = msgp.WrapError(err, "CheckpointEpoch")
 		return
 	}
 	return
@@ -204,40 +1679,182 @@ func (z *RootRaw) EncodeMsg(en *msgp.Writer) (err error) {
 // MarshalMsg implements msgp.Marshaler
 func (z *RootRaw) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 6
+	// map header, size 28
+	// string "Version"
+	o = append(o, 0xde, 0x0, 0x1c, 0xa7, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o, err = z.Version.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Version")
+		return
+	}
 	// string "Size"
-	o = append(o, 0x86, 0xa4, 0x53, 0x69, 0x7a, 0x65)
+	o = append(o, 0xa4, 0x53, 0x69, 0x7a, 0x65)
 	o, err = z.Size.MarshalMsg(o)
 	if err != nil {
+		err = msgp.WrapError(err, "Size")
 		return
 	}
 	// string "BucketCount"
 	o = append(o, 0xab, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74)
 	o, err = z.BucketCount.MarshalMsg(o)
 	if err != nil {
+		err = msgp.WrapError(err, "BucketCount")
 		return
 	}
 	// string "SplitIndex"
 	o = append(o, 0xaa, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78)
 	o, err = z.SplitIndex.MarshalMsg(o)
 	if err != nil {
+		err = msgp.WrapError(err, "SplitIndex")
 		return
 	}
 	// string "MaskHigh"
 	o = append(o, 0xa8, 0x4d, 0x61, 0x73, 0x6b, 0x48, 0x69, 0x67, 0x68)
 	o, err = z.MaskHigh.MarshalMsg(o)
 	if err != nil {
+		err = msgp.WrapError(err, "MaskHigh")
 		return
 	}
 	// string "MaskLow"
 	o = append(o, 0xa7, 0x4d, 0x61, 0x73, 0x6b, 0x4c, 0x6f, 0x77)
 	o, err = z.MaskLow.MarshalMsg(o)
 	if err != nil {
+		err = msgp.WrapError(err, "MaskLow")
 		return
 	}
 	// string "HashKey"
 	o = append(o, 0xa7, 0x48, 0x61, 0x73, 0x68, 0x4b, 0x65, 0x79)
 	o = msgp.AppendBytes(o, z.HashKey)
+	// string "Meta"
+	o = append(o, 0xa4, 0x4d, 0x65, 0x74, 0x61)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Meta)))
+	for za0001, za0002 := range z.Meta {
+		o = msgp.AppendString(o, za0001)
+		o = msgp.AppendBytes(o, za0002)
+	}
+	// string "MaxSize"
+	o = append(o, 0xa7, 0x4d, 0x61, 0x78, 0x53, 0x69, 0x7a, 0x65)
+	o, err = z.MaxSize.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxSize")
+		return
+	}
+	// string "Companions"
+	o = append(o, 0xaa, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x69, 0x6f, 0x6e, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Companions)))
+	for za0003 := range z.Companions {
+		o = msgp.AppendString(o, z.Companions[za0003])
+	}
+	// string "SizeStripes"
+	o = append(o, 0xab, 0x53, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x69, 0x70, 0x65, 0x73)
+	o, err = z.SizeStripes.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "SizeStripes")
+		return
+	}
+	// string "DeferSplits"
+	o = append(o, 0xab, 0x44, 0x65, 0x66, 0x65, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x73)
+	o = msgp.AppendBool(o, z.DeferSplits)
+	// string "SplitPending"
+	o = append(o, 0xac, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67)
+	o = msgp.AppendBool(o, z.SplitPending)
+	// string "SortedBuckets"
+	o = append(o, 0xad, 0x53, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73)
+	o = msgp.AppendBool(o, z.SortedBuckets)
+	// string "InlineThreshold"
+	o = append(o, 0xaf, 0x49, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	o, err = z.InlineThreshold.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "InlineThreshold")
+		return
+	}
+	// string "MaxChainLength"
+	o = append(o, 0xae, 0x4d, 0x61, 0x78, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o, err = z.MaxChainLength.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxChainLength")
+		return
+	}
+	// string "MinUtilization"
+	o = append(o, 0xae, 0x4d, 0x69, 0x6e, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.MinUtilization.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MinUtilization")
+		return
+	}
+	// string "MaxUtilization"
+	o = append(o, 0xae, 0x4d, 0x61, 0x78, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.MaxUtilization.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxUtilization")
+		return
+	}
+	// string "Utilization"
+	o = append(o, 0xab, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.Utilization.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Utilization")
+		return
+	}
+	// string "LastSplit"
+	o = append(o, 0xa9, 0x4c, 0x61, 0x73, 0x74, 0x53, 0x70, 0x6c, 0x69, 0x74)
+	o, err = z.LastSplit.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "LastSplit")
+		return
+	}
+	// string "SplitInterval"
+	o = append(o, 0xad, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c)
+	o, err = z.SplitInterval.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "SplitInterval")
+		return
+	}
+	// string "DirectoryPages"
+	o = append(o, 0xae, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x73)
+	o, err = z.DirectoryPages.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "DirectoryPages")
+		return
+	}
+	// string "DirectoryPageSize"
+	o = append(o, 0xb1, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x50, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65)
+	o, err = z.DirectoryPageSize.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "DirectoryPageSize")
+		return
+	}
+	// string "WriteHeavy"
+	o = append(o, 0xaa, 0x57, 0x72, 0x69, 0x74, 0x65, 0x48, 0x65, 0x61, 0x76, 0x79)
+	o = msgp.AppendBool(o, z.WriteHeavy)
+	// string "MaxBucketCapacity"
+	o = append(o, 0xb1, 0x4d, 0x61, 0x78, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79)
+	o, err = z.MaxBucketCapacity.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MaxBucketCapacity")
+		return
+	}
+	// string "BlobThreshold"
+	o = append(o, 0xad, 0x42, 0x6c, 0x6f, 0x62, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64)
+	o, err = z.BlobThreshold.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "BlobThreshold")
+		return
+	}
+	// string "ChangeEpoch"
+	o = append(o, 0xab, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	o, err = z.ChangeEpoch.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "ChangeEpoch")
+		return
+	}
+	// string "CheckpointEpoch"
+	o = append(o, 0xaf, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x45, 0x70, 0x6f, 0x63, 0x68)
+	o, err = z.CheckpointEpoch.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "CheckpointEpoch")
+		return
+	}
 	return
 }
 
@@ -245,51 +1862,229 @@ func (z *RootRaw) MarshalMsg(b []byte) (o []byte, err error) {
 func (z *RootRaw) UnmarshalMsg(bts []byte) (o []byte, err error) {
 	var field []byte
 	_ = field
-	var zxhx uint32
-	zxhx, bts, err = msgp.ReadMapHeaderBytes(bts)
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
 	if err != nil {
+		err = msgp.WrapError(err)
 		return
 	}
-	for zxhx > 0 {
-		zxhx--
+	for zb0001 > 0 {
+		zb0001--
 		field, bts, err = msgp.ReadMapKeyZC(bts)
 		if err != nil {
+			err = msgp.WrapError(err)
 			return
 		}
 		switch msgp.UnsafeString(field) {
+		case "Version":
+			bts, err = z.Version.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
 		case "Size":
 			bts, err = z.Size.UnmarshalMsg(bts)
 			if err != nil {
+				err = msgp.WrapError(err, "Size")
 				return
 			}
 		case "BucketCount":
 			bts, err = z.BucketCount.UnmarshalMsg(bts)
 			if err != nil {
+				err = msgp.WrapError(err, "BucketCount")
 				return
 			}
 		case "SplitIndex":
 			bts, err = z.SplitIndex.UnmarshalMsg(bts)
 			if err != nil {
+				err = msgp.WrapError(err, "SplitIndex")
 				return
 			}
 		case "MaskHigh":
 			bts, err = z.MaskHigh.UnmarshalMsg(bts)
 			if err != nil {
+				err = msgp.WrapError(err, "MaskHigh")
 				return
 			}
 		case "MaskLow":
 			bts, err = z.MaskLow.UnmarshalMsg(bts)
 			if err != nil {
+				err = msgp.WrapError(err, "MaskLow")
 				return
 			}
 		case "HashKey":
 			z.HashKey, bts, err = msgp.ReadBytesBytes(bts, z.HashKey)
 			if err != nil {
+				err = msgp.WrapError(err, "HashKey")
+				return
+			}
+		case "Meta":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Meta")
+				return
+			}
+			if z.Meta == nil {
+				z.Meta = make(map[string][]byte, zb0002)
+			} else if len(z.Meta) > 0 {
+				for key := range z.Meta {
+					delete(z.Meta, key)
+				}
+			}
+			for zb0002 > 0 {
+				var za0001 string
+				var za0002 []byte
+				zb0002--
+				za0001, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta")
+					return
+				}
+				za0002, bts, err = msgp.ReadBytesBytes(bts, za0002)
+				if err != nil {
+					err = msgp.WrapError(err, "Meta", za0001)
+					return
+				}
+				z.Meta[za0001] = za0002
+			}
+		case "MaxSize":
+			bts, err = z.MaxSize.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxSize")
+				return
+			}
+		case "Companions":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Companions")
+				return
+			}
+			if cap(z.Companions) >= int(zb0003) {
+				z.Companions = (z.Companions)[:zb0003]
+			} else {
+				z.Companions = make([]string, zb0003)
+			}
+			for za0003 := range z.Companions {
+				z.Companions[za0003], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Companions", za0003)
+					return
+				}
+			}
+		case "SizeStripes":
+			bts, err = z.SizeStripes.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SizeStripes")
+				return
+			}
+		case "DeferSplits":
+			z.DeferSplits, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DeferSplits")
+				return
+			}
+		case "SplitPending":
+			z.SplitPending, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SplitPending")
+				return
+			}
+		case "SortedBuckets":
+			z.SortedBuckets, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SortedBuckets")
+				return
+			}
+		case "InlineThreshold":
+			bts, err = z.InlineThreshold.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "InlineThreshold")
+				return
+			}
+		case "MaxChainLength":
+			bts, err = z.MaxChainLength.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxChainLength")
+				return
+			}
+		case "MinUtilization":
+			bts, err = z.MinUtilization.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MinUtilization")
+				return
+			}
+		case "MaxUtilization":
+			bts, err = z.MaxUtilization.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxUtilization")
+				return
+			}
+		case "Utilization":
+			bts, err = z.Utilization.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Utilization")
+				return
+			}
+		case "LastSplit":
+			bts, err = z.LastSplit.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LastSplit")
+				return
+			}
+		case "SplitInterval":
+			bts, err = z.SplitInterval.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SplitInterval")
+				return
+			}
+		case "DirectoryPages":
+			bts, err = z.DirectoryPages.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPages")
+				return
+			}
+		case "DirectoryPageSize":
+			bts, err = z.DirectoryPageSize.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DirectoryPageSize")
+				return
+			}
+		case "WriteHeavy":
+			z.WriteHeavy, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WriteHeavy")
+				return
+			}
+		case "MaxBucketCapacity":
+			bts, err = z.MaxBucketCapacity.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MaxBucketCapacity")
+				return
+			}
+		case "BlobThreshold":
+			bts, err = z.BlobThreshold.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BlobThreshold")
+				return
+			}
+		case "ChangeEpoch":
+			bts, err = z.ChangeEpoch.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ChangeEpoch")
+				return
+			}
+		case "CheckpointEpoch":
+			bts, err = z.CheckpointEpoch.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CheckpointEpoch")
 				return
 			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
+				err = msgp.WrapError(err)
 				return
 			}
 		}
@@ -300,6 +2095,17 @@ func (z *RootRaw) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *RootRaw) Msgsize() (s int) {
-	s = 1 + 5 + z.Size.Msgsize() + 12 + z.BucketCount.Msgsize() + 11 + z.SplitIndex.Msgsize() + 9 + z.MaskHigh.Msgsize() + 8 + z.MaskLow.Msgsize() + 8 + msgp.BytesPrefixSize + len(z.HashKey)
+	s = 3 + 8 + z.Version.Msgsize() + 5 + z.Size.Msgsize() + 12 + z.BucketCount.Msgsize() + 11 + z.SplitIndex.Msgsize() + 9 + z.MaskHigh.Msgsize() + 8 + z.MaskLow.Msgsize() + 8 + msgp.BytesPrefixSize + len(z.HashKey) + 5 + msgp.MapHeaderSize
+	if z.Meta != nil {
+		for za0001, za0002 := range z.Meta {
+			_ = za0002
+			s += msgp.StringPrefixSize + len(za0001) + msgp.BytesPrefixSize + len(za0002)
+		}
+	}
+	s += 8 + z.MaxSize.Msgsize() + 11 + msgp.ArrayHeaderSize
+	for za0003 := range z.Companions {
+		s += msgp.StringPrefixSize + len(z.Companions[za0003])
+	}
+	s += 12 + z.SizeStripes.Msgsize() + 12 + msgp.BoolSize + 13 + msgp.BoolSize + 14 + msgp.BoolSize + 16 + z.InlineThreshold.Msgsize() + 15 + z.MaxChainLength.Msgsize() + 15 + z.MinUtilization.Msgsize() + 15 + z.MaxUtilization.Msgsize() + 12 + z.Utilization.Msgsize() + 10 + z.LastSplit.Msgsize() + 14 + z.SplitInterval.Msgsize() + 15 + z.DirectoryPages.Msgsize() + 18 + z.DirectoryPageSize.Msgsize() + 11 + msgp.BoolSize + 18 + z.MaxBucketCapacity.Msgsize() + 14 + z.BlobThreshold.Msgsize() + 12 + z.ChangeEpoch.Msgsize() + 16 + z.CheckpointEpoch.Msgsize()
 	return
 }
@@ -0,0 +1,468 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	hash "github.com/dchest/siphash"
+	"github.com/tinylib/msgp/msgp"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden test vectors")
+
+func TestDecodeLegacyBucket(t *testing.T) {
+	legacy := LegacyBucket{[]byte("a"), nil, []byte("c")}
+	bts, err := legacy.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := DecodeBucket(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != 0 {
+		t.Fatalf("Expected legacy bucket to be version 0. Got %v", b.Version)
+	}
+	if len(b.Keys) != 3 || !bytes.Equal(b.Keys[0], legacy[0]) || !bytes.Equal(b.Keys[2], legacy[2]) {
+		t.Fatalf("Legacy keys not preserved: %v", b.Keys)
+	}
+}
+
+func TestDecodeBucket(t *testing.T) {
+	b := NewBucket()
+	b.Keys[1] = []byte("b")
+	bts, err := b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := DecodeBucket(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b2.Version != BucketVersion {
+		t.Fatalf("Expected bucket version %v. Got %v", BucketVersion, b2.Version)
+	}
+	if len(b2.Keys) != BucketCapacity || !bytes.Equal(b2.Keys[1], b.Keys[1]) {
+		t.Fatalf("Keys not preserved: %v", b2.Keys)
+	}
+}
+
+func TestRootVersion(t *testing.T) {
+	r := NewRoot(make([]byte, 16))
+	bts, err := r.UpdateRaw().MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := new(RootRaw)
+	if _, err = rr.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if v := rr.ToRoot().Version; v != RootVersion {
+		t.Fatalf("Expected root version %v. Got %v", RootVersion, v)
+	}
+}
+
+func TestDecodeBucketInto(t *testing.T) {
+	b := NewBucket()
+	b.Keys[1] = []byte("b")
+	b.Expiries = make([]int64, BucketCapacity)
+	b.Expiries[1] = 42
+	b.Locks = map[string][]byte{"b": []byte("holder")}
+	bts, err := b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2 := NewBucket()
+	if err = DecodeBucketInto(b2, bts); err != nil {
+		t.Fatal(err)
+	}
+	key := b2.Keys[1]
+	b2.Reset()
+	if b2.Version != BucketVersion || len(b2.Keys) != BucketCapacity || len(b2.Expiries) != 0 || b2.Locks != nil {
+		t.Fatalf("Reset bucket not empty: %#v", b2)
+	}
+	// decoding a bucket without expiries must not see the old ones
+	b.Keys[1] = []byte("c")
+	b.Expiries = nil
+	b.Locks = nil
+	if bts, err = b.MarshalMsg(bts[:0]); err != nil {
+		t.Fatal(err)
+	}
+	if err = DecodeBucketInto(b2, bts); err != nil {
+		t.Fatal(err)
+	}
+	if len(b2.Expiries) != 0 || len(b2.Locks) != 0 || !bytes.Equal(b2.Keys[1], []byte("c")) {
+		t.Fatalf("Stale state survived reuse: %#v", b2)
+	}
+	if !bytes.Equal(key, []byte("b")) {
+		t.Fatalf("Key from reused bucket was overwritten: %v", key)
+	}
+}
+
+func benchmarkBucket(b *testing.B) []byte {
+	bucket := NewBucket()
+	bucket.Versions = make([]int64, BucketCapacity)
+	for idx := range bucket.Keys {
+		bucket.Keys[idx] = []byte{byte(idx)}
+		bucket.Versions[idx] = int64(idx)
+	}
+	bts, err := bucket.MarshalMsg(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return bts
+}
+
+func BenchmarkDecodeBucketNew(b *testing.B) {
+	bts := benchmarkBucket(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeBucket(bts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBucketReuse(b *testing.B) {
+	bts := benchmarkBucket(b)
+	bucket := NewBucket()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bucket.Reset()
+		if err := DecodeBucketInto(bucket, bts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncodeBucket(t *testing.T) {
+	b := NewBucket()
+	b.Keys[1] = []byte("b")
+	b.Locks = map[string][]byte{"b": []byte("holder")}
+	expected, err := b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEncoder()
+	prefix := []byte("prefix")
+	bts, err := e.EncodeBucket(prefix, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bts[:len(prefix)], prefix) || !bytes.Equal(bts[len(prefix):], expected) {
+		t.Fatalf("Encoding differs from MarshalMsg: %v vs %v", bts, expected)
+	}
+	// the encoder must not retain the previous output.
+	b.Keys[1] = []byte("c")
+	if _, err = e.EncodeBucket(nil, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bts[len(prefix):], expected) {
+		t.Fatal("Encoding was overwritten by the next encoding")
+	}
+}
+
+// largeKeyBucket returns a full Bucket of large keys with small
+// attributes, for which Msgsize overestimates the size of the
+// encoding.
+func largeKeyBucket() *Bucket {
+	bucket := NewBucket()
+	bucket.Expiries = make([]int64, BucketCapacity)
+	bucket.Accesses = make([]int64, BucketCapacity)
+	bucket.Versions = make([]int64, BucketCapacity)
+	bucket.Inlined = make([]int64, BucketCapacity)
+	for idx := range bucket.Keys {
+		bucket.Keys[idx] = bytes.Repeat([]byte{byte(idx)}, 256)
+		bucket.Versions[idx] = 1
+	}
+	return bucket
+}
+
+func BenchmarkMarshalLargeBucket(b *testing.B) {
+	bucket := largeKeyBucket()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := bucket.MarshalMsg(nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncoderLargeBucket(b *testing.B) {
+	bucket := largeKeyBucket()
+	e := NewEncoder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.EncodeBucket(nil, bucket); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncoderLargeBucketReuse(b *testing.B) {
+	bucket := largeKeyBucket()
+	e := NewEncoder()
+	var bts []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if bts, err = e.EncodeBucket(bts[:0], bucket); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBucketCapacity(t *testing.T) {
+	r := NewRoot(make([]byte, 16))
+	if c := r.BucketCapacityFor(1 << 20); c != BucketCapacity {
+		t.Fatalf("Expected a fixed capacity of %v. Got %v", BucketCapacity, c)
+	}
+	r.MaxBucketCapacity = 256
+	for _, test := range []struct {
+		chains   uint64
+		capacity int
+	}{
+		{2, 64}, {BucketCapacityChains - 1, 64}, {BucketCapacityChains, 128},
+		{4*BucketCapacityChains - 1, 128}, {4 * BucketCapacityChains, 256}, {1 << 30, 256},
+	} {
+		if c := r.BucketCapacityFor(test.chains); c != test.capacity {
+			t.Fatalf("Expected capacity %v for %v chains. Got %v", test.capacity, test.chains, c)
+		}
+	}
+
+	bts, err := r.UpdateRaw().MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := new(RootRaw)
+	if _, err = rr.UnmarshalMsg(bts); err != nil {
+		t.Fatal(err)
+	}
+	if m := rr.ToRoot().MaxBucketCapacity; m != 256 {
+		t.Fatalf("MaxBucketCapacity not preserved: %v", m)
+	}
+}
+
+// The golden test vectors in testdata/golden.json record, for fixture
+// LHashes with fixed hash keys and known contents, the encoding of
+// their roots and buckets and the hashcode, bucket index and slot of
+// each key, so that implementations in other languages can check
+// their compatibility byte for byte. The Buckets are laid out as
+// BulkLoad would: each key in the next free slot of the head of its
+// chain, with version 1. Regenerate the vectors with
+// go test -run TestGoldenVectors -update, which is necessary whenever
+// an encoding changes.
+type goldenVector struct {
+	Name    string        `json:"name"`
+	HashKey string        `json:"hashKey"`
+	Root    string        `json:"root"`
+	Buckets []string      `json:"buckets"`
+	Entries []goldenEntry `json:"entries"`
+}
+
+type goldenEntry struct {
+	Key string `json:"key"`
+	// hex, as JSON numbers cannot hold every uint64.
+	Hash   string `json:"hash"`
+	Bucket uint64 `json:"bucket"`
+	Slot   int    `json:"slot"`
+}
+
+type goldenFixture struct {
+	name    string
+	chains  int
+	keys    []string
+	meta    map[string][]byte
+	maxSize int64
+}
+
+var goldenFixtures = []goldenFixture{
+	{name: "empty", chains: 2},
+	{name: "small", chains: 2, keys: []string{"a", "b", "c", "", "hello", "world"}},
+	{name: "split", chains: 5, keys: []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta"},
+		meta: map[string][]byte{"schema": []byte("v1")}, maxSize: 100},
+}
+
+func (f goldenFixture) generate() (*goldenVector, error) {
+	hashKey := make([]byte, 16)
+	for idx := range hashKey {
+		hashKey[idx] = byte(idx)
+	}
+	k0, k1 := binary.LittleEndian.Uint64(hashKey[0:8]), binary.LittleEndian.Uint64(hashKey[8:16])
+
+	r := NewRoot(hashKey)
+	low := uint64(1)
+	for low*2 <= uint64(f.chains) {
+		low *= 2
+	}
+	r.MaskLow, r.MaskHigh, r.SplitIndex = low-1, low*2-1, uint64(f.chains)-low
+	r.BucketCount = int64(f.chains)
+	r.Size = int64(len(f.keys))
+	r.MaxSize = f.maxSize
+	for name, value := range f.meta {
+		r.Meta[name] = value
+	}
+	v := &goldenVector{Name: f.name, HashKey: hex.EncodeToString(hashKey)}
+	bts, err := r.UpdateRaw().MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	v.Root = hex.EncodeToString(bts)
+
+	buckets := make([]*Bucket, f.chains)
+	for idx := range buckets {
+		buckets[idx] = NewBucket()
+	}
+	for _, key := range f.keys {
+		h := hash.Hash(k0, k1, []byte(key))
+		bIdx := r.BucketIndex(h)
+		b := buckets[bIdx]
+		if b.Hashes == nil {
+			b.Hashes = make([]uint64, len(b.Keys))
+			b.Versions = make([]int64, len(b.Keys))
+		}
+		slot := 0
+		for b.Versions[slot] != 0 {
+			slot++
+		}
+		b.Keys[slot], b.Hashes[slot], b.Versions[slot] = []byte(key), h, 1
+		v.Entries = append(v.Entries, goldenEntry{
+			Key:    hex.EncodeToString([]byte(key)),
+			Hash:   strconv.FormatUint(h, 16),
+			Bucket: bIdx,
+			Slot:   slot,
+		})
+	}
+	e := NewEncoder()
+	for _, b := range buckets {
+		if bts, err = e.EncodeBucket(nil, b); err != nil {
+			return nil, err
+		}
+		v.Buckets = append(v.Buckets, hex.EncodeToString(bts))
+	}
+	return v, nil
+}
+
+func TestGoldenVectors(t *testing.T) {
+	path := filepath.Join("testdata", "golden.json")
+	var vectors []*goldenVector
+	for _, f := range goldenFixtures {
+		v, err := f.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors = append(vectors, v)
+	}
+	if *update {
+		bts, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, append(bts, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden []*goldenVector
+	if err = json.Unmarshal(bts, &golden); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(golden, vectors) {
+		t.Fatalf("Encodings differ from %v; run with -update if the change is intended", path)
+	}
+
+	// the checked in encodings must decode to the recorded entries.
+	for _, v := range golden {
+		bts, _ := hex.DecodeString(v.Root)
+		rr := new(RootRaw)
+		if _, err = rr.UnmarshalMsg(bts); err != nil {
+			t.Fatal(err)
+		}
+		if r := rr.ToRoot(); r.Size != int64(len(v.Entries)) || r.BucketCount != int64(len(v.Buckets)) {
+			t.Fatalf("%v: root does not match its entries: %#v", v.Name, r)
+		}
+		for _, entry := range v.Entries {
+			bts, _ = hex.DecodeString(v.Buckets[entry.Bucket])
+			b, err := DecodeBucket(bts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, _ := hex.DecodeString(entry.Key)
+			if !bytes.Equal(b.Keys[entry.Slot], key) || strconv.FormatUint(b.Hashes[entry.Slot], 16) != entry.Hash {
+				t.Fatalf("%v: bucket %v slot %v does not hold %q", v.Name, entry.Bucket, entry.Slot, key)
+			}
+		}
+	}
+}
+
+func TestCheckStrict(t *testing.T) {
+	r := NewRoot(make([]byte, 16))
+	r.Companions = []string{"reverse"}
+	r.Meta["owner"] = []byte("ops")
+	root, err := r.UpdateRaw().MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = CheckRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	b := NewBucket()
+	b.Keys[0] = []byte("a")
+	b.Hashes = []uint64{1 << 63}
+	b.Locks = map[string][]byte{"a": []byte("holder")}
+	bucket, err := b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = CheckBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := LegacyBucket{[]byte("a"), nil}.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = CheckBucket(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	// an extra field: decoding ignores it, strict checking does not.
+	count, rest, err := msgp.ReadMapHeaderBytes(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := msgp.AppendMapHeader(nil, count+1)
+	extra = msgp.AppendString(extra, "Hashkey")
+	extra = msgp.AppendBytes(extra, make([]byte, 16))
+	extra = append(extra, rest...)
+	if _, err = new(RootRaw).UnmarshalMsg(extra); err != nil {
+		t.Fatal(err)
+	} else if err = CheckRoot(extra); err == nil || !strings.Contains(err.Error(), `"Hashkey"`) {
+		t.Fatalf("Expected an unknown field error. Got %v", err)
+	}
+
+	wrongType := msgp.AppendMapHeader(nil, 2)
+	wrongType = msgp.AppendString(wrongType, "Version")
+	wrongType = msgp.AppendUint64(wrongType, BucketVersion)
+	wrongType = msgp.AppendString(wrongType, "Sorted")
+	wrongType = msgp.AppendInt64(wrongType, 1)
+	if err = CheckBucket(wrongType); err == nil || !strings.Contains(err.Error(), `"Sorted"`) {
+		t.Fatalf("Expected a wrong type error. Got %v", err)
+	}
+	if err = CheckBucket(append(bucket, 0xc0)); err == nil {
+		t.Fatal("Expected an error for trailing bytes")
+	}
+}
@@ -0,0 +1,98 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+// bucketPoolSize bounds both the number of decoded buckets tracked
+// during an operation and the number kept for reuse afterwards, so
+// that iterating over a large LHash does not pin every bucket in
+// memory.
+const bucketPoolSize = 32
+
+// bucketPool recycles the decoded contents of buckets between
+// operations on the same LHash handle, which saves reallocating the
+// key and attribute slices of every bucket read by hot Put and Find
+// loops. Buckets handed out during an operation are only returned to
+// the pool once the outermost operation finishes, as nested
+// operations (for example, Find removing an expired entry) may still
+// be using them.
+type bucketPool struct {
+	depth int
+	live  []*mp.Bucket
+	free  []*mp.Bucket
+}
+
+func (p *bucketPool) get() *mp.Bucket {
+	var b *mp.Bucket
+	if l := len(p.free); l > 0 {
+		b = p.free[l-1]
+		p.free[l-1] = nil
+		p.free = p.free[:l-1]
+		b.Reset()
+	} else {
+		b = mp.NewBucket()
+	}
+	if p.depth > 0 && len(p.live) < bucketPoolSize {
+		p.live = append(p.live, b)
+	}
+	return b
+}
+
+func (p *bucketPool) recycle() {
+	for idx, b := range p.live {
+		if len(p.free) < bucketPoolSize {
+			p.free = append(p.free, b)
+		}
+		p.live[idx] = nil
+	}
+	p.live = p.live[:0]
+}
+
+// runTransaction runs fun in a transaction on the connection of lh,
+// recycling the buckets used once the outermost such transaction
+// returns. All operations on lh which read buckets must go through
+// runTransaction rather than using lh.Conn directly.
+//
+// If the transaction fails, the in-memory root and buckets may have
+// been modified without being written, so the cached root is
+// invalidated and will be decoded afresh by the next populate, and
+// the bucket cache is cleared. For the same reason, the bucket cache
+// is cleared each time the outermost transaction is (re)started, and
+// the cached root is invalidated each time it is restarted.
+//
+// Once fun succeeds, the outermost transaction links any buckets
+// copied by fun, as they were shared with a checkpoint, into their
+// chains (see relinkCopies).
+func (lh *LHash) runTransaction(fun func(*client.Txn) (interface{}, error)) (interface{}, *client.Stats, error) {
+	lh.pool.depth++
+	defer func() {
+		lh.pool.depth--
+		if lh.pool.depth == 0 {
+			lh.clearCache()
+			lh.pool.recycle()
+		}
+	}()
+	outermost := lh.pool.depth == 1
+	attempts := 0
+	res, stats, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if outermost {
+			if attempts > 0 {
+				lh.value = nil
+			}
+			attempts++
+			lh.clearCache()
+		}
+		res, err := fun(txn)
+		if err == nil && outermost && len(lh.copies) > 0 {
+			err = lh.relinkCopies(txn)
+		}
+		return res, err
+	})
+	if err != nil {
+		lh.value = nil
+		lh.clearCache()
+	}
+	return res, stats, err
+}
@@ -0,0 +1,86 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	"time"
+)
+
+// loadChain reads every bucket in the idx'th chain, following the
+// next links eagerly.
+func (lh *LHash) loadChain(idx uint64) ([]*bucket, error) {
+	b, err := lh.head(idx)
+	if err != nil {
+		return nil, err
+	}
+	chain := []*bucket{b}
+	for {
+		if b, err = b.next(); err != nil {
+			return nil, err
+		} else if b == nil {
+			return chain, nil
+		}
+		chain = append(chain, b)
+	}
+}
+
+// Search the LHash for each of the given keys, as with Find, but
+// within a single transaction and reading each bucket chain at most
+// once, no matter how many of the keys hash to it. The result has the
+// same length as keys, with nil for each key not found. Unlike Find,
+// expired entries are not removed. As with Find, ErrInlineValue is
+// returned if any of the entries has an inline value.
+func (lh *LHash) FindMany(keys [][]byte) ([]*client.ObjectRef, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.FindMany(keys)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		// group the keys by bucket index so that each chain is
+		// loaded once.
+		groups := make(map[uint64][]int)
+		hashes := make([]uint64, len(keys))
+		for idx, key := range keys {
+			h, bIdx := lh.locate(key)
+			hashes[idx] = h
+			groups[bIdx] = append(groups[bIdx], idx)
+		}
+		now := time.Now().UnixNano()
+		results := make([]*client.ObjectRef, len(keys))
+		for bIdx, group := range groups {
+			chain, err := lh.loadChain(bIdx)
+			if err != nil {
+				return nil, err
+			}
+			for _, idx := range group {
+				if results[idx], err = findInChain(chain, keys[idx], hashes[idx], now); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return results, nil
+	})
+	if err == nil {
+		return res.([]*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+func findInChain(chain []*bucket, key []byte, h uint64, now int64) (*client.ObjectRef, error) {
+	for _, b := range chain {
+		if slot := b.slotOf(key, h); slot != -1 {
+			if b.isExpired(slot, now) {
+				return nil, nil
+			} else if b.isInline(slot) {
+				return nil, ErrInlineValue
+			}
+			value := b.refs[slot+1]
+			return &value, nil
+		}
+	}
+	return nil, nil
+}
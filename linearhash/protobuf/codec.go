@@ -0,0 +1,402 @@
+// Package protobuf encodes the persisted state of an LHash, the Root
+// and Bucket records of the msgpack package, in the protobuf wire
+// format defined by lhash.proto. LHash itself always persists msgpack;
+// this codec is for tooling which is standardized on protobuf, which
+// can transcode the values of LHash Objects with TranscodeRoot and
+// TranscodeBucket and then decode them with code generated from
+// lhash.proto in any language.
+//
+// The wire format is written directly, without the protobuf runtime,
+// as proto3 would: fields with zero values are omitted, and repeated
+// numbers are packed. Unmarshalling accepts packed and unpacked
+// repeated numbers, and skips unknown fields.
+package protobuf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"math"
+	"sort"
+)
+
+// Wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// ErrTruncated is returned when unmarshalling runs out of input.
+var ErrTruncated = errors.New("Truncated protobuf message")
+
+// MarshalRoot appends the protobuf encoding of r to dst, returning the
+// extended buffer.
+func MarshalRoot(dst []byte, r *mp.Root) []byte {
+	e := encoder(dst)
+	e.uint(1, r.Version)
+	e.int(2, r.Size)
+	e.int(3, r.BucketCount)
+	e.uint(4, r.SplitIndex)
+	e.uint(5, r.MaskHigh)
+	e.uint(6, r.MaskLow)
+	e.bytes(7, r.HashKey)
+	e.stringMap(8, r.Meta)
+	e.int(9, r.MaxSize)
+	for _, name := range r.Companions {
+		e.element(10, []byte(name))
+	}
+	e.int(11, r.SizeStripes)
+	e.bool(12, r.DeferSplits)
+	e.bool(13, r.SplitPending)
+	e.bool(14, r.SortedBuckets)
+	e.int(15, r.InlineThreshold)
+	e.int(16, r.MaxChainLength)
+	e.double(17, r.MinUtilization)
+	e.double(18, r.MaxUtilization)
+	e.double(19, r.Utilization)
+	e.int(20, r.LastSplit)
+	e.int(21, r.SplitInterval)
+	e.int(22, r.DirectoryPages)
+	e.int(23, r.DirectoryPageSize)
+	e.bool(24, r.WriteHeavy)
+	e.int(25, r.MaxBucketCapacity)
+	e.int(26, r.BlobThreshold)
+	e.int(27, r.ChangeEpoch)
+	e.int(28, r.CheckpointEpoch)
+	return []byte(e)
+}
+
+// UnmarshalRoot decodes a Root from its protobuf encoding.
+func UnmarshalRoot(bts []byte) (*mp.Root, error) {
+	r := &mp.Root{Meta: make(map[string][]byte)}
+	err := decode(bts, func(field, wire int, v uint64, bs []byte) error {
+		switch field {
+		case 1:
+			r.Version = v
+		case 2:
+			r.Size = int64(v)
+		case 3:
+			r.BucketCount = int64(v)
+		case 4:
+			r.SplitIndex = v
+		case 5:
+			r.MaskHigh = v
+		case 6:
+			r.MaskLow = v
+		case 7:
+			r.HashKey = bs
+		case 8:
+			return decodeMapEntry(r.Meta, bs)
+		case 9:
+			r.MaxSize = int64(v)
+		case 10:
+			r.Companions = append(r.Companions, string(bs))
+		case 11:
+			r.SizeStripes = int64(v)
+		case 12:
+			r.DeferSplits = v != 0
+		case 13:
+			r.SplitPending = v != 0
+		case 14:
+			r.SortedBuckets = v != 0
+		case 15:
+			r.InlineThreshold = int64(v)
+		case 16:
+			r.MaxChainLength = int64(v)
+		case 17:
+			r.MinUtilization = math.Float64frombits(v)
+		case 18:
+			r.MaxUtilization = math.Float64frombits(v)
+		case 19:
+			r.Utilization = math.Float64frombits(v)
+		case 20:
+			r.LastSplit = int64(v)
+		case 21:
+			r.SplitInterval = int64(v)
+		case 22:
+			r.DirectoryPages = int64(v)
+		case 23:
+			r.DirectoryPageSize = int64(v)
+		case 24:
+			r.WriteHeavy = v != 0
+		case 25:
+			r.MaxBucketCapacity = int64(v)
+		case 26:
+			r.BlobThreshold = int64(v)
+		case 27:
+			r.ChangeEpoch = int64(v)
+		case 28:
+			r.CheckpointEpoch = int64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// MarshalBucket appends the protobuf encoding of b to dst, returning
+// the extended buffer.
+func MarshalBucket(dst []byte, b *mp.Bucket) []byte {
+	e := encoder(dst)
+	e.uint(1, b.Version)
+	for _, key := range b.Keys {
+		e.element(2, key)
+	}
+	e.packedInts(3, b.Expiries)
+	e.packedInts(4, b.Accesses)
+	e.stringMap(5, b.Locks)
+	e.packedInts(6, b.Versions)
+	if len(b.Hashes) != 0 {
+		var packed encoder
+		for _, h := range b.Hashes {
+			packed.varint(h)
+		}
+		e.element(7, packed)
+	}
+	e.bool(8, b.Sorted)
+	e.packedInts(9, b.Inlined)
+	for _, value := range b.Values {
+		e.element(10, value)
+	}
+	e.bytes(11, b.Bloom)
+	e.int(12, b.Epoch)
+	e.int(13, b.Checkpoint)
+	return []byte(e)
+}
+
+// UnmarshalBucket decodes a Bucket from its protobuf encoding.
+func UnmarshalBucket(bts []byte) (*mp.Bucket, error) {
+	b := new(mp.Bucket)
+	err := decode(bts, func(field, wire int, v uint64, bs []byte) error {
+		switch field {
+		case 1:
+			b.Version = v
+		case 2:
+			b.Keys = append(b.Keys, bs)
+		case 3:
+			return appendInts(&b.Expiries, wire, v, bs)
+		case 4:
+			return appendInts(&b.Accesses, wire, v, bs)
+		case 5:
+			if b.Locks == nil {
+				b.Locks = make(map[string][]byte)
+			}
+			return decodeMapEntry(b.Locks, bs)
+		case 6:
+			return appendInts(&b.Versions, wire, v, bs)
+		case 7:
+			if wire != wireBytes {
+				b.Hashes = append(b.Hashes, v)
+				return nil
+			}
+			for len(bs) > 0 {
+				h, n := binary.Uvarint(bs)
+				if n <= 0 {
+					return ErrTruncated
+				}
+				b.Hashes, bs = append(b.Hashes, h), bs[n:]
+			}
+		case 8:
+			b.Sorted = v != 0
+		case 9:
+			return appendInts(&b.Inlined, wire, v, bs)
+		case 10:
+			b.Values = append(b.Values, bs)
+		case 11:
+			b.Bloom = bs
+		case 12:
+			b.Epoch = int64(v)
+		case 13:
+			b.Checkpoint = int64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// TranscodeRoot converts the msgpack encoding of a Root, as stored in
+// the root Object of an LHash, to its protobuf encoding.
+func TranscodeRoot(value []byte) ([]byte, error) {
+	raw := new(mp.RootRaw)
+	if _, err := raw.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return MarshalRoot(nil, raw.ToRoot()), nil
+}
+
+// TranscodeBucket converts the msgpack encoding of a Bucket, in either
+// the current or the legacy encoding, to its protobuf encoding.
+func TranscodeBucket(value []byte) ([]byte, error) {
+	b, err := mp.DecodeBucket(value)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalBucket(nil, b), nil
+}
+
+type encoder []byte
+
+func (e *encoder) varint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	*e = append(*e, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func (e *encoder) tag(field, wire int) {
+	e.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (e *encoder) uint(field int, v uint64) {
+	if v != 0 {
+		e.tag(field, wireVarint)
+		e.varint(v)
+	}
+}
+
+func (e *encoder) int(field int, v int64) {
+	e.uint(field, uint64(v))
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.uint(field, 1)
+	}
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v != 0 {
+		e.tag(field, wireFixed64)
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		*e = append(*e, buf[:]...)
+	}
+}
+
+// bytes writes a singular bytes field, which is omitted if empty.
+func (e *encoder) bytes(field int, v []byte) {
+	if len(v) != 0 {
+		e.element(field, v)
+	}
+}
+
+// element writes one element of a repeated bytes field (or a nested
+// message), even if empty.
+func (e *encoder) element(field int, v []byte) {
+	e.tag(field, wireBytes)
+	e.varint(uint64(len(v)))
+	*e = append(*e, v...)
+}
+
+func (e *encoder) packedInts(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+	var packed encoder
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	e.element(field, packed)
+}
+
+// stringMap writes a map<string, bytes> field, in key order so that
+// the encoding is deterministic.
+func (e *encoder) stringMap(field int, m map[string][]byte) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry encoder
+		entry.bytes(1, []byte(k))
+		entry.bytes(2, m[k])
+		e.element(field, entry)
+	}
+}
+
+// decode calls f for each field of the message in bts. For varint and
+// fixed fields, v is the value; for length delimited fields, bs is.
+func decode(bts []byte, f func(field, wire int, v uint64, bs []byte) error) error {
+	for len(bts) > 0 {
+		tag, n := binary.Uvarint(bts)
+		if n <= 0 {
+			return ErrTruncated
+		}
+		bts = bts[n:]
+		field, wire := int(tag>>3), int(tag&7)
+		var v uint64
+		var bs []byte
+		switch wire {
+		case wireVarint:
+			if v, n = binary.Uvarint(bts); n <= 0 {
+				return ErrTruncated
+			}
+			bts = bts[n:]
+		case wireFixed64:
+			if len(bts) < 8 {
+				return ErrTruncated
+			}
+			v, bts = binary.LittleEndian.Uint64(bts), bts[8:]
+		case wireFixed32:
+			if len(bts) < 4 {
+				return ErrTruncated
+			}
+			v, bts = uint64(binary.LittleEndian.Uint32(bts)), bts[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(bts)
+			if n <= 0 || uint64(len(bts)-n) < length {
+				return ErrTruncated
+			}
+			bs, bts = bts[n:n+int(length):n+int(length)], bts[n+int(length):]
+		default:
+			return fmt.Errorf("Unsupported protobuf wire type %v", wire)
+		}
+		if err := f(field, wire, v, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeMapEntry(m map[string][]byte, bts []byte) error {
+	var key string
+	var value []byte
+	err := decode(bts, func(field, wire int, v uint64, bs []byte) error {
+		switch field {
+		case 1:
+			key = string(bs)
+		case 2:
+			value = bs
+		}
+		return nil
+	})
+	if err == nil {
+		if value == nil {
+			value = []byte{}
+		}
+		m[key] = value
+	}
+	return err
+}
+
+func appendInts(dst *[]int64, wire int, v uint64, bs []byte) error {
+	if wire != wireBytes {
+		*dst = append(*dst, int64(v))
+		return nil
+	}
+	for len(bs) > 0 {
+		v, n := binary.Uvarint(bs)
+		if n <= 0 {
+			return ErrTruncated
+		}
+		*dst, bs = append(*dst, int64(v)), bs[n:]
+	}
+	return nil
+}
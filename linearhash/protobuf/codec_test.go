@@ -0,0 +1,88 @@
+package protobuf
+
+import (
+	"bytes"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"reflect"
+	"testing"
+)
+
+func TestRootRoundTrip(t *testing.T) {
+	r := mp.NewRoot([]byte("0123456789abcdef"))
+	r.Size = 42
+	r.Meta["owner"] = []byte("ops")
+	r.Meta["empty"] = []byte{}
+	r.Companions = []string{"reverse", mp.SizeStripeName(0)}
+	r.SizeStripes = 1
+	r.MinUtilization, r.MaxUtilization, r.Utilization = 0.5, 0.9, 0.7
+	r.LastSplit = -1
+	r.WriteHeavy = true
+	r.MaxBucketCapacity = 256
+
+	bts := MarshalRoot(nil, r)
+	decoded, err := UnmarshalRoot(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalRoot(nil, decoded), bts) || decoded.Size != 42 || decoded.LastSplit != -1 ||
+		decoded.Utilization != 0.7 || !decoded.WriteHeavy || len(decoded.Companions) != 2 ||
+		!bytes.Equal(decoded.Meta["owner"], []byte("ops")) || decoded.Meta["empty"] == nil {
+		t.Fatalf("Root did not round trip: %#v", decoded)
+	}
+
+	// field 1 (version) as a varint, then field 2 (size) as a varint.
+	if bts := MarshalRoot(nil, &mp.Root{Version: 1, Size: 2}); !bytes.Equal(bts, []byte{0x08, 0x01, 0x10, 0x02}) {
+		t.Fatalf("Unexpected encoding %x", bts)
+	}
+	if _, err := UnmarshalRoot(bts[:len(bts)-1]); err != ErrTruncated {
+		t.Fatalf("Expected ErrTruncated. Got %v", err)
+	}
+}
+
+func TestBucketRoundTrip(t *testing.T) {
+	b := mp.NewBucket()
+	b.Keys[0], b.Keys[3] = []byte("a"), []byte("b")
+	b.Expiries = []int64{0, 0, 0, 17}
+	b.Versions = []int64{1, 0, 0, 2}
+	b.Hashes = make([]uint64, len(b.Keys))
+	b.Hashes[0], b.Hashes[3] = 1<<63, 5
+	b.Inlined = []int64{1}
+	b.Values = [][]byte{[]byte("x")}
+	b.Locks = map[string][]byte{"a": []byte("holder")}
+	b.Bloom = []byte{0xff}
+
+	value, err := b.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bts, err := TranscodeBucket(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalBucket(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Keys) != len(b.Keys) {
+		t.Fatalf("Expected %v slots. Got %v", len(b.Keys), len(decoded.Keys))
+	}
+	for idx, key := range b.Keys {
+		if !bytes.Equal(decoded.Keys[idx], key) {
+			t.Fatalf("Slot %v: expected key %q. Got %q", idx, key, decoded.Keys[idx])
+		}
+	}
+	if decoded.Version != b.Version || !reflect.DeepEqual(decoded.Expiries, b.Expiries) ||
+		!reflect.DeepEqual(decoded.Versions, b.Versions) || !reflect.DeepEqual(decoded.Hashes, b.Hashes) ||
+		!reflect.DeepEqual(decoded.Inlined, b.Inlined) || !reflect.DeepEqual(decoded.Values, b.Values) ||
+		!reflect.DeepEqual(decoded.Locks, b.Locks) || !bytes.Equal(decoded.Bloom, b.Bloom) {
+		t.Fatalf("Bucket did not round trip: %#v", decoded)
+	}
+
+	// unpacked repeated numbers are accepted too: field 3, varint 17.
+	unpacked, err := UnmarshalBucket([]byte{0x18, 0x11})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(unpacked.Expiries, []int64{17}) {
+		t.Fatalf("Expected expiries [17]. Got %v", unpacked.Expiries)
+	}
+}
@@ -0,0 +1,328 @@
+package linearhash
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"github.com/tinylib/msgp/msgp"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/blob"
+	"sync"
+	"time"
+)
+
+// A ReadCache is an in-process cache of the entries of an LHash, for
+// workloads which repeatedly read the same keys. A cached entry is
+// returned without any transaction at all. The cache is kept coherent
+// by a background retry transaction, on a connection dedicated to the
+// ReadCache, which reads every cached entry (and so the root,
+// directory pages and bucket chains leading to it) and is restarted
+// by GoshawkDB whenever any of those Objects changes, at which point
+// changed entries are dropped from the cache.
+//
+// Writes are made to the LHash as usual, and are unaffected by the
+// cache. The cache does, however, lag behind writes: until the
+// background transaction has been restarted and has dropped an entry,
+// the old entry may still be returned. The results of a ReadCache are
+// therefore those of some recent committed state of the LHash, and
+// it must not be used from within a transaction, whose own reads and
+// writes it would not reflect.
+//
+// A cache miss reads the entry in a transaction on the connection of
+// the LHash, which also writes a small private Object so as to
+// restart the background transaction, which must begin watching the
+// new entry. A ReadCache is safe for concurrent use.
+type ReadCache struct {
+	reader  *LHash
+	watcher *LHash
+	nudge   client.ObjectRef
+	values  bool
+	fillMu  sync.Mutex
+
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	closed   bool
+	err      error
+	done     chan struct{}
+}
+
+type readCacheEntry struct {
+	key    string
+	value  *client.ObjectRef
+	inline bool
+	// if true, value is the index Object of a blob
+	blob bool
+	// the inline value, or the value if values are cached
+	bytes  []byte
+	expiry int64
+	// set once the transaction which read the entry has committed;
+	// only committed entries are returned.
+	committed bool
+}
+
+// Create a ReadCache of up to capacity entries of lh. The coherence
+// transaction runs on watchConn, which it blocks, so watchConn must be
+// dedicated to this ReadCache. If cacheValues is true then the values
+// of entries (the contents of their value Objects) are cached too,
+// for FindValue, and the cache is also kept coherent with changes to
+// the value Objects. Close the ReadCache to stop the background
+// transaction.
+func NewReadCache(lh *LHash, watchConn *client.Connection, capacity int, cacheValues bool) (*ReadCache, error) {
+	if capacity < 1 {
+		return nil, errors.New("ReadCache capacity must be at least 1")
+	}
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return txn.CreateObject(msgp.AppendInt64(nil, 0))
+	})
+	if err != nil {
+		return nil, err
+	}
+	rc := &ReadCache{
+		reader:   LHashFromObj(lh.Conn, lh.ObjRef),
+		watcher:  LHashFromObj(watchConn, lh.ObjRef),
+		nudge:    res.(client.ObjectRef),
+		values:   cacheValues,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		done:     make(chan struct{}),
+	}
+	go rc.watch()
+	return rc, nil
+}
+
+// Search for the given key, as Peek does, but returning the cached
+// entry if there is one. ErrInlineValue is returned for entries whose
+// values are inline.
+func (rc *ReadCache) Find(key []byte) (*client.ObjectRef, error) {
+	e, err := rc.get(key)
+	if err != nil {
+		return nil, err
+	} else if e.inline {
+		return nil, ErrInlineValue
+	} else if e.value == nil {
+		return nil, nil
+	}
+	value := *e.value
+	return &value, nil
+}
+
+// Search for the given key, as FindValue does, but returning the
+// cached value if there is one. Unless the ReadCache caches values,
+// only inline values are cached, and other values are read afresh.
+func (rc *ReadCache) FindValue(key []byte) ([]byte, error) {
+	e, err := rc.get(key)
+	if err != nil || e.value == nil && !e.inline {
+		return nil, err
+	} else if e.inline || rc.values {
+		return e.bytes, nil
+	}
+	res, _, err := rc.reader.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if e.blob {
+			return blob.ReadAll(rc.reader.Conn, *e.value)
+		}
+		return readValue(txn, *e.value)
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Stop the background transaction and empty the cache. Close returns
+// any error which stopped the background transaction early.
+func (rc *ReadCache) Close() error {
+	rc.mu.Lock()
+	alreadyClosed := rc.closed
+	rc.closed = true
+	rc.mu.Unlock()
+	if !alreadyClosed {
+		// restart the background transaction so that it notices.
+		if _, _, err := rc.reader.Conn.RunTransaction(rc.poke); err != nil {
+			return err
+		}
+	}
+	<-rc.done
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = make(map[string]*list.Element)
+	rc.order.Init()
+	return rc.err
+}
+
+func (rc *ReadCache) get(key []byte) (*readCacheEntry, error) {
+	now := time.Now().UnixNano()
+	rc.mu.Lock()
+	if rc.err != nil {
+		defer rc.mu.Unlock()
+		return nil, rc.err
+	}
+	if elem, found := rc.entries[string(key)]; found {
+		e := elem.Value.(*readCacheEntry)
+		if e.committed && (e.expiry == 0 || e.expiry > now) {
+			rc.order.MoveToFront(elem)
+			rc.mu.Unlock()
+			return e, nil
+		}
+	}
+	rc.mu.Unlock()
+	return rc.fill(key)
+}
+
+// fill reads the entry for key, caching it. The entry is added to the
+// cache before the transaction commits, so that the background
+// transaction, restarted by the commit, is sure to see it. Should the
+// background transaction find the entry already out of date, it
+// removes it, and then the entry is not cached once committed.
+func (rc *ReadCache) fill(key []byte) (*readCacheEntry, error) {
+	rc.fillMu.Lock()
+	defer rc.fillMu.Unlock()
+	var e *readCacheEntry
+	_, _, err := rc.reader.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		var err error
+		if e, err = rc.reader.readEntry(txn, key, rc.values); err != nil {
+			return nil, err
+		}
+		rc.add(e)
+		return rc.poke(txn)
+	})
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	elem, found := rc.entries[string(key)]
+	if err != nil {
+		if found && elem.Value.(*readCacheEntry) == e {
+			rc.remove(elem)
+		}
+		return nil, err
+	}
+	if found && elem.Value.(*readCacheEntry) == e {
+		e.committed = true
+	}
+	return e, nil
+}
+
+// poke writes the nudge Object, restarting the background
+// transaction.
+func (rc *ReadCache) poke(txn *client.Txn) (interface{}, error) {
+	obj, err := txn.GetObject(rc.nudge)
+	if err != nil {
+		return nil, err
+	}
+	value, err := obj.Value()
+	if err != nil {
+		return nil, err
+	}
+	count, _, err := msgp.ReadInt64Bytes(value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, obj.Set(msgp.AppendInt64(nil, count+1))
+}
+
+func (rc *ReadCache) add(e *readCacheEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if elem, found := rc.entries[e.key]; found {
+		rc.remove(elem)
+	}
+	rc.entries[e.key] = rc.order.PushFront(e)
+	for rc.order.Len() > rc.capacity {
+		rc.remove(rc.order.Back())
+	}
+}
+
+func (rc *ReadCache) remove(elem *list.Element) {
+	rc.order.Remove(elem)
+	delete(rc.entries, elem.Value.(*readCacheEntry).key)
+}
+
+// readEntry reads the entry for key, without modifying the LHash.
+func (lh *LHash) readEntry(txn *client.Txn, key []byte, withValue bool) (*readCacheEntry, error) {
+	e := &readCacheEntry{key: string(key)}
+	bFound, idx, err := lh.lookup(key)
+	if err != nil || bFound == nil {
+		return e, err
+	}
+	e.expiry = bFound.entryAt(idx).expiry
+	if inline := bFound.inlineAt(idx); inline != nil {
+		// copy, as the bucket may be reused.
+		e.inline, e.bytes = true, append([]byte{}, inline...)
+		return e, nil
+	}
+	value := bFound.refs[idx+1]
+	e.value, e.blob = &value, bFound.isBlob(idx)
+	if withValue {
+		if e.blob {
+			e.bytes, err = blob.ReadAll(lh.Conn, value)
+		} else {
+			e.bytes, err = readValue(txn, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (e *readCacheEntry) sameAs(other *readCacheEntry) bool {
+	return sameValue(e.value, other.value) && e.inline == other.inline && e.blob == other.blob &&
+		e.expiry == other.expiry && bytes.Equal(e.bytes, other.bytes)
+}
+
+// watch runs the background transaction until the ReadCache is
+// closed: it rereads every cached entry, removing those which have
+// changed, and if none have, waits for any of the Objects read to
+// change.
+func (rc *ReadCache) watch() {
+	defer close(rc.done)
+	for {
+		res, _, err := rc.watcher.runTransaction(func(txn *client.Txn) (interface{}, error) {
+			if _, err := readValue(txn, rc.nudge); err != nil {
+				return nil, err
+			}
+			rc.mu.Lock()
+			if rc.closed {
+				rc.mu.Unlock()
+				return []*readCacheEntry(nil), nil
+			}
+			cached := make([]*readCacheEntry, 0, len(rc.entries))
+			for elem := rc.order.Front(); elem != nil; elem = elem.Next() {
+				cached = append(cached, elem.Value.(*readCacheEntry))
+			}
+			rc.mu.Unlock()
+			var stale []*readCacheEntry
+			for _, e := range cached {
+				current, err := rc.watcher.readEntry(txn, []byte(e.key), rc.values)
+				if err != nil {
+					return nil, err
+				} else if !current.sameAs(e) {
+					stale = append(stale, e)
+				}
+			}
+			if len(stale) == 0 {
+				return client.Retry, nil
+			}
+			return stale, nil
+		})
+		rc.mu.Lock()
+		if err != nil {
+			rc.err = err
+			rc.entries = make(map[string]*list.Element)
+			rc.order.Init()
+		} else {
+			for _, e := range res.([]*readCacheEntry) {
+				if elem, found := rc.entries[e.key]; found && elem.Value.(*readCacheEntry) == e {
+					rc.remove(elem)
+				}
+			}
+		}
+		closed := rc.closed || err != nil
+		rc.mu.Unlock()
+		if closed {
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	"time"
+)
+
+// Operations which are guaranteed never to write. Find may write: it
+// removes expired entries and, when the LHash has a maximum size,
+// records accesses. A transaction which only reads commits more
+// cheaply, and never conflicts with other readers.
+
+// Search the LHash for the given key, as Find does, but without ever
+// modifying the LHash: expired entries are treated as absent but are
+// not removed, and accesses are not recorded.
+func (lh *LHash) Peek(key []byte) (*client.ObjectRef, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Peek(key)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		bFound, idx, err := lh.lookup(key)
+		if err != nil || bFound == nil {
+			return (*client.ObjectRef)(nil), err
+		} else if bFound.isInline(idx) {
+			return nil, ErrInlineValue
+		}
+		return &bFound.refs[idx+1], nil
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns true iff the LHash contains an unexpired entry for the given
+// key, whether its value is inline or not. Contains never modifies the
+// LHash.
+func (lh *LHash) Contains(key []byte) (bool, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Contains(key)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		bFound, _, err := lh.lookup(key)
+		return bFound != nil, err
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// lookup returns the bucket and slot holding the unexpired entry for
+// key, or a nil bucket. It never writes.
+func (lh *LHash) lookup(key []byte) (*bucket, int, error) {
+	if err := lh.populate(); err != nil {
+		return nil, 0, err
+	}
+	h, bIdx := lh.locate(key)
+	bucket, err := lh.head(bIdx)
+	if err != nil {
+		return nil, 0, err
+	}
+	bFound, idx, err := bucket.findSlotHash(key, h)
+	if err != nil || bFound == nil || bFound.isExpired(idx, time.Now().UnixNano()) {
+		return nil, 0, err
+	}
+	return bFound, idx, nil
+}
@@ -0,0 +1,181 @@
+package linearhash
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+const reverseCompanion = "reverse"
+
+// Enable the reverse index, which maps each value Object to the keys
+// whose entries point at it. Once enabled, the reverse index is
+// maintained by every Put and Remove (by all users of the LHash), and
+// can be queried with KeysFor. Enabling the reverse index on a
+// non-empty LHash indexes all the existing entries, in a single
+// transaction. Enabling an already enabled reverse index does
+// nothing.
+func (lh *LHash) EnableReverseIndex() error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.EnableReverseIndex()
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if _, found := lh.companion(reverseCompanion); found {
+			return nil, nil
+		}
+		reverse, err := NewEmptyLHash(lh.Conn)
+		if err != nil {
+			return nil, err
+		}
+		err = lh.ForEach(func(key []byte, value client.ObjectRef) error {
+			return reverse.reverseAdd(key, value)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// ForEach repopulates, so only now modify the root.
+		if err = lh.populate(); err != nil {
+			return nil, err
+		}
+		lh.addCompanion(reverseCompanion, reverse.ObjRef)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Returns the keys of the entries which point at the given value
+// Object. The reverse index must have been enabled with
+// EnableReverseIndex, otherwise nil is returned.
+func (lh *LHash) KeysFor(value client.ObjectRef) ([][]byte, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.KeysFor(value)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		reverse := lh.reverseIndex()
+		if reverse == nil {
+			return [][]byte(nil), nil
+		}
+		_, keys, err := reverse.reverseKeys(value)
+		if err != nil {
+			return nil, err
+		}
+		return ([][]byte)(*keys), nil
+	})
+	if err == nil {
+		return res.([][]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// reverseIndex returns the reverse index, or nil if it is not
+// enabled. lh must be populated.
+func (lh *LHash) reverseIndex() *LHash {
+	if objRef, found := lh.companion(reverseCompanion); found {
+		return LHashFromObj(lh.Conn, objRef)
+	}
+	return nil
+}
+
+// updateReverse changes the reverse index to reflect key pointing at
+// valueNew rather than valueOld. Either may be nil.
+func (lh *LHash) updateReverse(key []byte, valueOld, valueNew *client.ObjectRef) error {
+	reverse := lh.reverseIndex()
+	if reverse == nil || sameValue(valueOld, valueNew) {
+		return nil
+	}
+	if valueOld != nil {
+		if err := reverse.reverseRemove(key, *valueOld); err != nil {
+			return err
+		}
+	}
+	if valueNew != nil {
+		return reverse.reverseAdd(key, *valueNew)
+	}
+	return nil
+}
+
+func reverseKey(value client.ObjectRef) []byte {
+	return []byte(*value.Id)
+}
+
+// The following are called on the reverse index itself.
+
+func (lh *LHash) reverseKeys(value client.ObjectRef) (*client.ObjectRef, *mp.KeyList, error) {
+	keys := new(mp.KeyList)
+	objRef, err := lh.Find(reverseKey(value))
+	if err != nil || objRef == nil {
+		return nil, keys, err
+	}
+	bs, err := objRef.Value()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = keys.UnmarshalMsg(bs)
+	return objRef, keys, err
+}
+
+func (lh *LHash) reverseAdd(key []byte, value client.ObjectRef) error {
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		objRef, keys, err := lh.reverseKeys(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range *keys {
+			if bytes.Equal(k, key) {
+				return nil, nil
+			}
+		}
+		*keys = append(*keys, key)
+		bs, err := keys.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		if objRef != nil {
+			return nil, objRef.Set(bs)
+		}
+		keysObj, err := txn.CreateObject(bs)
+		if err != nil {
+			return nil, err
+		}
+		return nil, lh.Put(reverseKey(value), keysObj)
+	})
+	return err
+}
+
+func (lh *LHash) reverseRemove(key []byte, value client.ObjectRef) error {
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		objRef, keys, err := lh.reverseKeys(value)
+		if err != nil || objRef == nil {
+			return nil, err
+		}
+		for idx, k := range *keys {
+			if bytes.Equal(k, key) {
+				*keys = append((*keys)[:idx], (*keys)[idx+1:]...)
+				break
+			}
+		}
+		if len(*keys) == 0 {
+			return nil, lh.Remove(reverseKey(value))
+		}
+		bs, err := keys.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return nil, objRef.Set(bs)
+	})
+	return err
+}
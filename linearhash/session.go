@@ -0,0 +1,61 @@
+package linearhash
+
+// An LHash handle may be shared between goroutines. Operations keep a
+// good deal of state on the handle between and during transactions:
+// the decoded root, the loaded directory pages, the bucket cache and
+// pool, the hash memo. So that concurrent operations, and operations
+// nested within others (for example, called from the callback of
+// ForEach), do not share that state, each exported operation on a
+// handle checks out a session: a private handle holding its own copy
+// of the state, which the operation then runs on. Sessions are
+// returned to the handle when operations finish, and reused, so a
+// handle used by a single goroutine keeps the benefit of its state
+// from one operation to the next, and a handle shared by n goroutines
+// keeps up to n sessions.
+//
+// Callbacks (of ForEach and friends) may call operations on the same
+// handle, which then run in a separate session, within the same
+// transaction. Batch is the exception: it passes its session to its
+// callback, which must use that instead.
+
+// acquire returns the session an operation on lh should run on: lh
+// itself if lh is already a session, otherwise a session checked out
+// from lh, which must be returned with release.
+func (lh *LHash) acquire() *LHash {
+	if lh.shared != nil {
+		return lh
+	}
+	lh.sessionsLock.Lock()
+	defer lh.sessionsLock.Unlock()
+	var s *LHash
+	if l := len(lh.sessions); l > 0 {
+		s = lh.sessions[l-1]
+		lh.sessions[l-1] = nil
+		lh.sessions = lh.sessions[:l-1]
+	} else {
+		s = &LHash{shared: lh}
+	}
+	s.Conn, s.ObjRef, s.Upgrade, s.Strict = lh.Conn, lh.ObjRef, lh.Upgrade, lh.Strict
+	if s.memoSize != lh.memoSize {
+		s.setHashMemo(lh.memoSize)
+	}
+	return s
+}
+
+// release returns s, acquired from lh, to lh for reuse.
+func (lh *LHash) release(s *LHash) {
+	if s == lh {
+		return
+	}
+	lh.sessionsLock.Lock()
+	defer lh.sessionsLock.Unlock()
+	lh.sessions = append(lh.sessions, s)
+}
+
+// handle returns the handle of which lh is a session, or lh itself.
+func (lh *LHash) handle() *LHash {
+	if lh.shared != nil {
+		return lh.shared
+	}
+	return lh
+}
@@ -0,0 +1,229 @@
+package linearhash
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	hash "github.com/dchest/siphash"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// A ShardedLHash spreads its entries over several independent LHash
+// shards. Every Put and Remove on an LHash writes its root, so
+// concurrent writers to an LHash always conflict with each other;
+// writers to different shards of a ShardedLHash do not. The shards
+// are referenced by a directory Object, which is only read, never
+// written, by Find, Put and Remove.
+//
+// The number of shards is fixed when the ShardedLHash is created. As
+// with LHash, a ShardedLHash object may be shared between goroutines.
+type ShardedLHash struct {
+	// The connection used to create this ShardedLHash object. The
+	// same restrictions apply as for LHash.
+	Conn *client.Connection
+	// The underlying directory Object in GoshawkDB.
+	ObjRef client.ObjectRef
+	// lock protects the directory state below, which populate
+	// replaces.
+	lock   sync.Mutex
+	shards []*LHash
+	k0     uint64
+	k1     uint64
+}
+
+// Create a brand new empty ShardedLHash with the given number of
+// shards, each of which is a new empty LHash.
+func NewEmptyShardedLHash(conn *client.Connection, shards int) (*ShardedLHash, error) {
+	if shards < 1 {
+		return nil, errors.New("A ShardedLHash must have at least one shard")
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		refs := make([]client.ObjectRef, shards)
+		for idx := range refs {
+			lh, err := NewEmptyLHash(conn)
+			if err != nil {
+				return nil, err
+			}
+			refs[idx] = lh.ObjRef
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		key := make([]byte, 16)
+		rng.Read(key)
+		dir := &mp.Directory{Version: mp.DirectoryVersion, HashKey: key}
+		value, err := dir.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		return txn.CreateObject(value, refs...)
+	})
+	if err == nil {
+		return ShardedLHashFromObj(conn, res.(client.ObjectRef)), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a ShardedLHash object from an existing given GoshawkDB
+// directory Object. As with LHashFromObj, no initialisation is done.
+func ShardedLHashFromObj(conn *client.Connection, objRef client.ObjectRef) *ShardedLHash {
+	return &ShardedLHash{
+		Conn:   conn,
+		ObjRef: objRef,
+	}
+}
+
+func (s *ShardedLHash) populate() error {
+	s.lock.Lock()
+	objRef, existing := s.ObjRef, s.shards
+	s.lock.Unlock()
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		dir := new(mp.Directory)
+		if _, err = dir.UnmarshalMsg(value); err != nil {
+			return nil, err
+		}
+		if dir.Version > mp.DirectoryVersion {
+			return nil, &VersionError{Kind: DirectoryObject, ObjRef: obj, Version: dir.Version, Supported: mp.DirectoryVersion}
+		}
+		if len(dir.HashKey) != 16 || len(refs) == 0 {
+			return nil, fmt.Errorf("ShardedLHash directory %v is corrupt", obj)
+		}
+		// keep the existing shard objects where possible so that
+		// their cached state survives between operations.
+		shards := make([]*LHash, len(refs))
+		for idx, objRef := range refs {
+			if idx < len(existing) && existing[idx].ObjRef.ReferencesSameAs(objRef) {
+				shards[idx] = existing[idx]
+			} else {
+				shards[idx] = LHashFromObj(s.Conn, objRef)
+			}
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.ObjRef = obj
+		s.k0 = binary.LittleEndian.Uint64(dir.HashKey[0:8])
+		s.k1 = binary.LittleEndian.Uint64(dir.HashKey[8:16])
+		s.shards = shards
+		return nil, nil
+	})
+	return err
+}
+
+func (s *ShardedLHash) shard(key []byte) *LHash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.shards[hash.Hash(s.k0, s.k1, key)%uint64(len(s.shards))]
+}
+
+func (s *ShardedLHash) allShards() []*LHash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.shards
+}
+
+// Search the ShardedLHash for the given key. See LHash.Find.
+func (s *ShardedLHash) Find(key []byte) (*client.ObjectRef, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		return s.shard(key).Find(key)
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Idempotently add the given key and value to the
+// ShardedLHash. See LHash.Put.
+func (s *ShardedLHash) Put(key []byte, value client.ObjectRef) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		return nil, s.shard(key).Put(key, value)
+	})
+	return err
+}
+
+// Idempotently remove any matching entry from the
+// ShardedLHash. See LHash.Remove.
+func (s *ShardedLHash) Remove(key []byte) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		return nil, s.shard(key).Remove(key)
+	})
+	return err
+}
+
+// Iterate over the entries of every shard in turn. See LHash.ForEach.
+func (s *ShardedLHash) ForEach(f func([]byte, client.ObjectRef) error) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		for _, shard := range s.allShards() {
+			if err := shard.ForEach(f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Returns the number of entries in the ShardedLHash, which is the sum
+// of the sizes of the shards. This reads the root of every shard, so
+// a transaction which calls Size conflicts with writers to every
+// shard.
+func (s *ShardedLHash) Size() (int64, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		total := int64(0)
+		for _, shard := range s.allShards() {
+			size, err := shard.Size()
+			if err != nil {
+				return nil, err
+			}
+			total += size
+		}
+		return total, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return -1, err
+	}
+}
+
+// Returns the number of shards.
+func (s *ShardedLHash) Shards() (int, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		if err := s.populate(); err != nil {
+			return nil, err
+		}
+		return len(s.allShards()), nil
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
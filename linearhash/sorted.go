@@ -0,0 +1,93 @@
+package linearhash
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+	"sort"
+)
+
+// Set whether buckets are kept sorted. By default, entries are placed
+// in the first free slot of a bucket, and finding a key requires
+// scanning every slot of every bucket in its chain. In sorted mode,
+// each bucket is compacted and sorted (by key hash, then by key) when
+// it is written, so that lookups can binary search it instead. This
+// makes writes slightly more expensive, and is most worthwhile with
+// large keys. Buckets written before sorted mode was enabled are
+// sorted as they are next written. The setting is stored in the root,
+// so applies to all users of the LHash.
+func (lh *LHash) SetSortedBuckets(sorted bool) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetSortedBuckets(sorted)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.SortedBuckets == sorted {
+			return nil, nil
+		}
+		lh.root.SortedBuckets = sorted
+		return nil, lh.write()
+	})
+	return err
+}
+
+// searchSlot binary searches a sorted bucket for key, whose hash is h,
+// returning its slot index or -1.
+func (b *bucket) searchSlot(key []byte, h uint64) int {
+	hashes, keys := b.entries.Hashes, b.entries.Keys
+	n := len(b.refs) - 1
+	idx := sort.Search(n, func(idx int) bool {
+		return hashes[idx] > h || (hashes[idx] == h && bytes.Compare(keys[idx], key) >= 0)
+	})
+	if idx < n && hashes[idx] == h && bytes.Equal(keys[idx], key) {
+		return idx
+	}
+	return -1
+}
+
+type sortableSlot struct {
+	key   []byte
+	value client.ObjectRef
+	e     entry
+}
+
+// sortSlots compacts and sorts the entries of the bucket. The
+// references of the bucket are replaced rather than rearranged in
+// place, as callers may hold pointers to values within them.
+func (b *bucket) sortSlots() {
+	if b.entries.Sorted {
+		return
+	}
+	slots := make([]sortableSlot, 0, len(b.entries.Keys))
+	for idx, k := range b.entries.Keys {
+		if !b.isSlotEmpty(idx) {
+			slots = append(slots, sortableSlot{key: k, value: b.refs[idx+1], e: b.entryAt(idx)})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if hi, hj := slots[i].e.hash, slots[j].e.hash; hi != hj {
+			return hi < hj
+		}
+		return bytes.Compare(slots[i].key, slots[j].key) < 0
+	})
+	refs := make([]client.ObjectRef, len(slots)+1)
+	refs[0] = b.refs[0]
+	for idx := range b.entries.Keys {
+		if idx < len(slots) {
+			b.entries.Keys[idx] = slots[idx].key
+			b.setEntry(idx, slots[idx].e)
+			refs[idx+1] = slots[idx].value
+		} else {
+			b.entries.Keys[idx] = nil
+			b.setEntry(idx, entry{})
+		}
+	}
+	b.refs = refs
+	b.entries.Sorted = true
+}
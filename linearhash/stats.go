@@ -0,0 +1,151 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"time"
+)
+
+// Stats describes the shape and contents of an LHash, as found by
+// walking every bucket chain.
+type Stats struct {
+	// The number of entries, as recorded in the root (see Size).
+	Size int64
+	// The number of entries found, including expired entries which
+	// have not yet been removed.
+	Entries        int64
+	ExpiredEntries int64
+	InlineEntries  int64
+	BlobEntries    int64
+	// The number of bucket chains, and of buckets, found.
+	Chains  int64
+	Buckets int64
+	// The length, in buckets, of the longest chain.
+	LongestChain int
+	// The number of slots in all the buckets, and the fraction of them
+	// holding entries.
+	Slots       int64
+	Utilization float64
+	// The current utilization threshold (see SetAdaptiveUtilization).
+	Threshold float64
+}
+
+// Walk every bucket chain of the LHash, in a single transaction, and
+// report its shape and contents. This reads every bucket, so is
+// intended for operators and tests rather than for regular use.
+func (lh *LHash) Stats() (*Stats, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Stats()
+	}
+	stats := new(Stats)
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		*stats = Stats{}
+		return nil, lh.walk(stats, nil)
+	})
+	if err == nil {
+		return stats, nil
+	} else {
+		return nil, err
+	}
+}
+
+// Check the structure of the LHash, in a single transaction, and
+// return a description of each problem found: entries in the wrong
+// chain, recorded hashes which do not match their keys, keys which
+// appear more than once, and a size or bucket count in the root which
+// does not match the buckets. A nil result means no problems were
+// found. The error result is only for failures to read the LHash.
+func (lh *LHash) Fsck() ([]string, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Fsck()
+	}
+	var problems []string
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		problems = problems[:0]
+		stats := new(Stats)
+		err := lh.walk(stats, func(problem string) {
+			problems = append(problems, problem)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if stats.Entries != stats.Size {
+			problems = append(problems, fmt.Sprintf("The root records %v entries, but %v were found", stats.Size, stats.Entries))
+		}
+		if stats.Buckets != lh.root.BucketCount {
+			problems = append(problems, fmt.Sprintf("The root records %v buckets, but %v were found", lh.root.BucketCount, stats.Buckets))
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	} else if len(problems) == 0 {
+		return nil, nil
+	}
+	return problems, nil
+}
+
+// walk accumulates stats over every bucket chain, reporting any
+// structural problems found to problem, if it is non-nil.
+func (lh *LHash) walk(stats *Stats, problem func(string)) error {
+	err := lh.populate()
+	if err != nil {
+		return err
+	}
+	if stats.Size, err = lh.size(); err != nil {
+		return err
+	}
+	stats.Threshold = lh.root.Threshold()
+	now := time.Now().UnixNano()
+	for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+		stats.Chains++
+		chain := 0
+		seen := make(map[string]bool)
+		b, err := lh.head(idx)
+		for ; err == nil && b != nil; b, err = b.next() {
+			chain++
+			stats.Buckets++
+			stats.Slots += int64(len(b.entries.Keys))
+			for slot, k := range b.entries.Keys {
+				if b.isSlotEmpty(slot) {
+					continue
+				}
+				stats.Entries++
+				if b.isExpired(slot, now) {
+					stats.ExpiredEntries++
+				}
+				if b.isInline(slot) {
+					stats.InlineEntries++
+				} else if b.isBlob(slot) {
+					stats.BlobEntries++
+				}
+				if problem == nil {
+					continue
+				}
+				h := b.hash(k)
+				if bIdx := lh.root.BucketIndex(h); bIdx != idx {
+					problem(fmt.Sprintf("Key %q is in chain %v but belongs in chain %v", k, idx, bIdx))
+				}
+				if b.hashAt(slot) != h {
+					problem(fmt.Sprintf("Key %q has recorded hash %x but hashes to %x", k, b.hashAt(slot), h))
+				}
+				if seen[string(k)] {
+					problem(fmt.Sprintf("Key %q appears more than once in chain %v", k, idx))
+				}
+				seen[string(k)] = true
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if chain > stats.LongestChain {
+			stats.LongestChain = chain
+		}
+	}
+	if stats.Slots > 0 {
+		stats.Utilization = float64(stats.Entries) / float64(stats.Slots)
+	}
+	return nil
+}
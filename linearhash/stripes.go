@@ -0,0 +1,128 @@
+package linearhash
+
+import (
+	"errors"
+	"github.com/tinylib/msgp/msgp"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/linearhash/msgpack"
+)
+
+// Stripe the size counter of the LHash over the given number of
+// separate counter Objects. Ordinarily, every Put which adds an entry
+// and every Remove which removes one must write the root, to update
+// the size, so all such transactions conflict with one another. Once
+// the size is striped, each bucket chain updates one of the counters
+// instead, and the root is only written when the shape of the LHash
+// changes (for example, when a bucket is split). Transactions adding
+// or removing entries in chains using different counters then no
+// longer conflict.
+//
+// The cost is that Size must read every counter, and that the
+// decision to split is based on an estimate of the size from a
+// single counter. If the LHash has a maximum size, every Put which
+// adds an entry reads every counter, so striping is of little benefit
+// in that case.
+//
+// The number of stripes cannot be changed once set.
+func (lh *LHash) StripeSize(stripes int) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.StripeSize(stripes)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if int64(stripes) == lh.root.SizeStripes {
+			return nil, nil
+		} else if lh.root.SizeStripes != 0 {
+			return nil, errors.New("The size of the LHash is already striped")
+		} else if stripes < 1 {
+			return nil, errors.New("The size must be striped over at least one counter")
+		}
+		zero := msgp.AppendInt64(nil, 0)
+		for idx := int64(0); idx < int64(stripes); idx++ {
+			counter, err := txn.CreateObject(zero)
+			if err != nil {
+				return nil, err
+			}
+			lh.addCompanion(mp.SizeStripeName(idx), counter)
+		}
+		lh.root.SizeStripes = int64(stripes)
+		return nil, lh.write()
+	})
+	return err
+}
+
+// size returns the exact number of entries in the LHash.
+func (lh *LHash) size() (int64, error) {
+	size := lh.root.Size
+	for idx := int64(0); idx < lh.root.SizeStripes; idx++ {
+		count, err := lh.stripeCount(idx)
+		if err != nil {
+			return 0, err
+		}
+		size += count
+	}
+	return size, nil
+}
+
+// sizeEstimate returns an estimate of the number of entries in the
+// LHash, reading only the counter used by the given bucket chain.
+func (lh *LHash) sizeEstimate(bucketIdx uint64) (int64, error) {
+	if lh.root.SizeStripes == 0 {
+		return lh.root.Size, nil
+	}
+	count, err := lh.stripeCount(int64(bucketIdx % uint64(lh.root.SizeStripes)))
+	if err != nil {
+		return 0, err
+	}
+	return lh.root.Size + count*lh.root.SizeStripes, nil
+}
+
+// adjustSize adds delta to the size of the LHash on behalf of the
+// given bucket chain. The result is true iff the root was modified,
+// in which case the caller must write it.
+func (lh *LHash) adjustSize(bucketIdx uint64, delta int64) (bool, error) {
+	if delta == 0 {
+		return false, nil
+	} else if lh.root.SizeStripes == 0 {
+		lh.root.Size += delta
+		return true, nil
+	}
+	stripe := int64(bucketIdx % uint64(lh.root.SizeStripes))
+	count, err := lh.stripeCount(stripe)
+	if err != nil {
+		return false, err
+	}
+	counter, _ := lh.companion(mp.SizeStripeName(stripe))
+	return false, counter.Set(msgp.AppendInt64(nil, count+delta))
+}
+
+func (lh *LHash) stripeCount(stripe int64) (int64, error) {
+	res, _, err := lh.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		counter, found := lh.companion(mp.SizeStripeName(stripe))
+		if !found {
+			return nil, errors.New("LHash root is missing a size counter")
+		}
+		counter, err := txn.GetObject(counter)
+		if err != nil {
+			return nil, err
+		}
+		value, err := counter.Value()
+		if err != nil {
+			return nil, err
+		}
+		count, _, err := msgp.ReadInt64Bytes(value)
+		return count, err
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
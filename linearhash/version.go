@@ -0,0 +1,45 @@
+package linearhash
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+)
+
+// The kinds of Object which make up an LHash, as reported in
+// VersionErrors and to the Upgrade hook.
+const (
+	RootObject          = "root"
+	BucketObject        = "bucket"
+	DirectoryObject     = "directory"
+	DirectoryPageObject = "directory page"
+)
+
+// A VersionError is returned when an LHash Object has been written
+// using a newer format version than this implementation supports.
+type VersionError struct {
+	Kind      string
+	ObjRef    client.ObjectRef
+	Version   uint64
+	Supported uint64
+}
+
+func (ve *VersionError) Error() string {
+	return fmt.Sprintf("LHash %s %v has format version %v; only versions up to %v are supported",
+		ve.Kind, ve.ObjRef, ve.Version, ve.Supported)
+}
+
+func (lh *LHash) checkVersion(kind string, objRef client.ObjectRef, version, supported uint64) error {
+	switch {
+	case version > supported:
+		return &VersionError{
+			Kind:      kind,
+			ObjRef:    objRef,
+			Version:   version,
+			Supported: supported,
+		}
+	case version < supported && lh.Upgrade != nil:
+		return lh.Upgrade(kind, objRef, version)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,274 @@
+package linearhash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"goshawkdb.io/client"
+	"time"
+)
+
+// Watch observes the entry for the given key, delivering the new
+// value on the returned channel each time the entry is created,
+// updated (i.e. the key is set to point at a different Object) or
+// removed. Removal is signalled by a nil value. The value current at
+// the time Watch is called is not delivered.
+//
+// Watching is implemented with retry transactions: each time the
+// objects making up the path to the key (the root, the bucket
+// directory and the bucket chain owning the key) are modified, the
+// entry is re-examined, with Peek, so watching never modifies the
+// LHash. Such transactions block the connection they run on, so conn
+// should be a connection dedicated to watching and not the connection
+// of lh.
+//
+// The channel is closed once ctx is done or if an error occurs. Note
+// that a retry transaction cannot be interrupted, so cancellation is
+// only noticed the next time the watched objects change.
+func (lh *LHash) Watch(ctx context.Context, conn *client.Connection, key []byte) (<-chan *client.ObjectRef, error) {
+	watcher := LHashFromObj(conn, lh.ObjRef)
+	current, err := watcher.Peek(key)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *client.ObjectRef, 1)
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+				value, err := watcher.Peek(key)
+				if err != nil {
+					return nil, err
+				}
+				if sameValue(current, value) {
+					return client.Retry, nil
+				}
+				return value, nil
+			})
+			if err != nil {
+				return
+			}
+			if value := res.(*client.ObjectRef); value == nil {
+				current = nil
+			} else {
+				valueCopy := *value
+				current = &valueCopy
+			}
+			select {
+			case ch <- current:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// An Event describes a change to a single entry of an LHash, as
+// observed by WatchAll. For removals, Value is nil.
+type Event struct {
+	Key   []byte
+	Value *client.ObjectRef
+}
+
+// WatchAll observes the whole LHash, delivering an Event on the
+// returned channel for every entry which is put (created or changed
+// to point at a different Object) or removed. Entries already present
+// at the time WatchAll is called are not delivered.
+//
+// The watcher keeps a snapshot of every bucket Object in memory: its
+// encoding, its references and the entries decoded from them. Each
+// time any of the root, directory or bucket Objects are modified, the
+// retry transaction wakes up, and only the buckets whose encoding or
+// references differ from the snapshot are decoded again and diffed
+// against it. Entries which move between buckets, as happens on
+// splits, produce no Events. The snapshot still holds every entry, so
+// WatchAll suits maintaining in-process caches of the LHash. As with
+// Watch, conn should be a connection dedicated to watching, and
+// cancellation of ctx is only noticed on the next change.
+func (lh *LHash) WatchAll(ctx context.Context, conn *client.Connection) (<-chan Event, error) {
+	watcher := LHashFromObj(conn, lh.ObjRef)
+	snapshot, _, err := watcher.snapshot(nil)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Event, 16)
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+				current, events, err := watcher.snapshot(snapshot)
+				if err != nil {
+					return nil, err
+				}
+				if len(events) == 0 {
+					return client.Retry, nil
+				} else {
+					return &watchAllResult{snapshot: current, events: events}, nil
+				}
+			})
+			if err != nil {
+				return
+			}
+			result := res.(*watchAllResult)
+			snapshot = result.snapshot
+			for _, event := range result.events {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+type watchAllResult struct {
+	snapshot bucketSnapshots
+	events   []Event
+}
+
+// bucketSnapshots holds the snapshot of every bucket Object of an
+// LHash, by cacheKey.
+type bucketSnapshots map[string]*bucketSnapshot
+
+// A bucketSnapshot is a bucket Object as last read by WatchAll, with
+// the unexpired entries with values which it then held.
+type bucketSnapshot struct {
+	value   []byte
+	refs    []client.ObjectRef
+	entries map[string]client.ObjectRef
+}
+
+// unchanged returns true iff the bucket Object still has the given
+// encoding and references.
+func (bs *bucketSnapshot) unchanged(value []byte, refs []client.ObjectRef) bool {
+	if !bytes.Equal(bs.value, value) || len(bs.refs) != len(refs) {
+		return false
+	}
+	for idx, objRef := range refs {
+		if !bs.refs[idx].ReferencesSameAs(objRef) {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshot reads every bucket Object of the LHash, reusing the
+// snapshots in old of those which are unchanged, and returns the new
+// snapshots together with the Events which turn old into them.
+func (lh *LHash) snapshot(old bucketSnapshots) (bucketSnapshots, []Event, error) {
+	var current bucketSnapshots
+	var events []Event
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		// the transaction may restart, so start afresh each time.
+		current = make(bucketSnapshots, len(old))
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UnixNano()
+		// the entries of the buckets which have changed, before and
+		// after.
+		before, after := make(map[string]client.ObjectRef), make(map[string]client.ObjectRef)
+		for idx := uint64(0); idx < lh.directoryLen(); idx++ {
+			objRef, err := lh.bucketRef(idx)
+			for err == nil {
+				var obj client.ObjectRef
+				if obj, err = txn.GetObject(objRef); err != nil {
+					break
+				}
+				var value []byte
+				var refs []client.ObjectRef
+				if value, refs, err = obj.ValueReferences(); err != nil {
+					break
+				} else if len(refs) == 0 {
+					err = fmt.Errorf("LHash bucket %v is corrupt: it has no references", obj)
+					break
+				}
+				bKey := cacheKey(obj)
+				bs := old[bKey]
+				if bs == nil || !bs.unchanged(value, refs) {
+					if bs, err = lh.snapshotBucket(obj, now); err != nil {
+						break
+					}
+				}
+				current[bKey] = bs
+				if refs[0].ReferencesSameAs(obj) {
+					break
+				}
+				objRef = refs[0]
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		for bKey, bs := range old {
+			if current[bKey] != bs {
+				for key, value := range bs.entries {
+					before[key] = value
+				}
+			}
+		}
+		for bKey, bs := range current {
+			if old[bKey] != bs {
+				for key, value := range bs.entries {
+					after[key] = value
+				}
+			}
+		}
+		events = diffEntries(before, after)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, events, nil
+}
+
+// snapshotBucket decodes the bucket Object obj into a new snapshot.
+func (lh *LHash) snapshotBucket(obj client.ObjectRef, now int64) (*bucketSnapshot, error) {
+	b := &bucket{LHash: lh, objRef: obj}
+	if err := b.populate(); err != nil {
+		return nil, err
+	}
+	bs := &bucketSnapshot{
+		value:   append([]byte{}, b.value...),
+		refs:    append([]client.ObjectRef{}, b.refs...),
+		entries: make(map[string]client.ObjectRef),
+	}
+	for idx, k := range b.entries.Keys {
+		if !b.isSlotEmpty(idx) && !b.isExpired(idx, now) && !b.isInline(idx) {
+			bs.entries[string(k)] = b.refs[idx+1]
+		}
+	}
+	return bs, nil
+}
+
+func diffEntries(old, current map[string]client.ObjectRef) []Event {
+	var events []Event
+	for key, value := range current {
+		if valueOld, found := old[key]; !found || !valueOld.ReferencesSameAs(value) {
+			valueCopy := value
+			events = append(events, Event{Key: []byte(key), Value: &valueCopy})
+		}
+	}
+	for key := range old {
+		if _, found := current[key]; !found {
+			events = append(events, Event{Key: []byte(key)})
+		}
+	}
+	return events
+}
+
+func sameValue(a, b *client.ObjectRef) bool {
+	switch {
+	case a == nil && b == nil:
+		return true
+	case a == nil || b == nil:
+		return false
+	default:
+		return a.ReferencesSameAs(*b)
+	}
+}
@@ -0,0 +1,153 @@
+package linearhash
+
+import (
+	"goshawkdb.io/client"
+	"math/rand"
+)
+
+// Set whether the LHash is tuned for write-heavy workloads. Normally,
+// removing an entry trims any empty slots from the end of its bucket,
+// and unlinks the bucket from its chain if it becomes empty. Where
+// removed slots are soon refilled, that work is wasted, and each
+// trimmed or unlinked bucket is rewritten again when it is refilled.
+// In write-heavy mode, removal simply empties the slot, and the
+// tidying is left to Compact, typically run by a Maintainer. The
+// setting is stored in the root, so applies to all users of the
+// LHash.
+func (lh *LHash) SetWriteHeavy(writeHeavy bool) error {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.SetWriteHeavy(writeHeavy)
+	}
+	_, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		if lh.root.WriteHeavy == writeHeavy {
+			return nil, nil
+		}
+		lh.root.WriteHeavy = writeHeavy
+		return nil, lh.write()
+	})
+	return err
+}
+
+// Tidy up to maxChains bucket chains, starting from a random chain, as
+// removal would have done had the LHash not been in write-heavy mode:
+// empty slots are trimmed from the ends of buckets, and empty buckets
+// other than the head are unlinked from their chains. A maxChains of
+// 0 or less means every chain. Returns the number of chains changed.
+// Compact does nothing unless the LHash is in write-heavy mode (see
+// SetWriteHeavy).
+func (lh *LHash) Compact(maxChains int) (int, error) {
+	if s := lh.acquire(); s != lh {
+		defer lh.release(s)
+		return s.Compact(maxChains)
+	}
+	res, _, err := lh.runTransaction(func(txn *client.Txn) (interface{}, error) {
+		err := lh.populate()
+		if err != nil {
+			return nil, err
+		}
+		if !lh.root.WriteHeavy {
+			return 0, nil
+		}
+		if err = lh.checkWritable(); err != nil {
+			return nil, err
+		}
+		chains := lh.directoryLen()
+		limit := uint64(maxChains)
+		if maxChains <= 0 || limit > chains {
+			limit = chains
+		}
+		start := uint64(rand.Int63n(int64(chains)))
+		bucketCount := lh.root.BucketCount
+		compacted := 0
+		for offset := uint64(0); offset < limit; offset++ {
+			changed, err := lh.compactChain((start + offset) % chains)
+			if err != nil {
+				return nil, err
+			} else if changed {
+				compacted++
+			}
+		}
+		if lh.root.BucketCount != bucketCount {
+			err = lh.write()
+		}
+		return compacted, err
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
+
+// compactChain tidies the idx'th bucket chain, returning true iff it
+// changed.
+func (lh *LHash) compactChain(idx uint64) (bool, error) {
+	head, err := lh.head(idx)
+	if err != nil {
+		return false, err
+	}
+	var dirty []*bucket
+	markDirty := func(b *bucket) {
+		for _, d := range dirty {
+			if d == b {
+				return
+			}
+		}
+		dirty = append(dirty, b)
+	}
+	tidy := func(b *bucket) {
+		l := len(b.refs)
+		if b.tidyRefTail(); len(b.refs) != l {
+			markDirty(b)
+		}
+	}
+	tidy(head)
+	unlinked := false
+	for bPrev := head; ; {
+		b, err := bPrev.next()
+		if err != nil {
+			return false, err
+		} else if b == nil {
+			break
+		}
+		tidy(b)
+		if len(b.refs) > 1 {
+			bPrev = b
+			continue
+		}
+		// b is empty, so unlink it.
+		if l := len(dirty); l > 0 && dirty[l-1] == b {
+			dirty = dirty[:l-1]
+		}
+		if b.refs[0].ReferencesSameAs(b.objRef) {
+			bPrev.refs[0] = bPrev.objRef
+		} else {
+			bPrev.refs[0] = b.refs[0]
+		}
+		lh.root.BucketCount--
+		markDirty(bPrev)
+		unlinked = true
+	}
+	bloomChanged := false
+	if unlinked {
+		if bloomChanged, err = head.rebuildBloom(); err != nil {
+			return false, err
+		} else if bloomChanged {
+			markDirty(head)
+		}
+	}
+	for _, b := range dirty {
+		if err = b.write(b == head && bloomChanged); err != nil {
+			return false, err
+		}
+	}
+	return len(dirty) > 0, nil
+}
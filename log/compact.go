@@ -0,0 +1,154 @@
+package log
+
+import (
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/log/msgpack"
+)
+
+// The most segments TruncateBefore drops in a single transaction.
+const truncateBatch = 16
+
+// Remove every entry with an offset before offset. Whole segments are
+// dropped, up to a bounded number per transaction, so a large
+// truncation is done as a series of transactions, each of which leaves
+// the Log consistent. Offsets are never reused: entries appended
+// afterwards carry on from where they would have. Truncating beyond
+// the end of the Log removes every entry.
+func (l *Log) TruncateBefore(offset uint64) error {
+	for {
+		res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			s, err := l.read(txn)
+			if err != nil {
+				return nil, err
+			}
+			next, err := s.next()
+			if err != nil {
+				return nil, err
+			}
+			target := offset
+			if target > next {
+				target = next
+			}
+			if target <= s.root.Start {
+				return true, nil
+			}
+			// never drop the last segment: it is the one appended to.
+			for dropped := 0; len(s.segments) > 1 && s.base(1) <= target; dropped++ {
+				if dropped == truncateBatch {
+					s.root.Start = s.root.Base
+					return false, s.write()
+				}
+				segment, err := readSegment(s.segments[0])
+				if err != nil {
+					return nil, err
+				}
+				if err = s.unindex(segment.Entries); err != nil {
+					return nil, err
+				}
+				s.segments = s.segments[1:]
+				s.root.Base += uint64(s.root.SegmentSize)
+			}
+			segmentObj := s.segments[0]
+			segment, err := readSegment(segmentObj)
+			if err != nil {
+				return nil, err
+			}
+			kept := 0
+			for kept < len(segment.Entries) && segment.Entries[kept].Offset < target {
+				kept++
+			}
+			if kept > 0 {
+				if err = s.unindex(segment.Entries[:kept]); err != nil {
+					return nil, err
+				}
+				segment.Entries = segment.Entries[kept:]
+				if err = writeSegment(segmentObj, segment); err != nil {
+					return nil, err
+				}
+			}
+			s.root.Start = target
+			return true, s.write()
+		})
+		if err != nil {
+			return err
+		} else if res.(bool) {
+			return nil
+		}
+	}
+}
+
+// Compact the Log, removing every keyed entry for which a later entry
+// with the same key has been appended. Entries without keys are never
+// removed by compaction. To bound the size of each transaction, up to
+// maxSegments segments (max of 0 or less means no limit) are compacted
+// per call, starting with the segment spanning from. Compact returns
+// the offset to continue from, which is 0 once the end of the Log has
+// been reached, so that repeatedly calling Compact with the offset it
+// last returned cycles through the whole Log. The number of entries
+// removed is also returned.
+func (l *Log) Compact(from uint64, maxSegments int) (uint64, int, error) {
+	var removed int
+	res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		removed = 0
+		s, err := l.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx := s.segmentOf(from)
+		for compacted := 0; idx < len(s.segments) && (maxSegments <= 0 || compacted < maxSegments); compacted++ {
+			segmentObj := s.segments[idx]
+			segment, err := readSegment(segmentObj)
+			if err != nil {
+				return nil, err
+			}
+			entries := segment.Entries[:0]
+			for _, e := range segment.Entries {
+				if len(e.Key) > 0 {
+					latest, found, err := s.latest(e.Key)
+					if err != nil {
+						return nil, err
+					} else if !found || latest != e.Offset {
+						continue
+					}
+				}
+				entries = append(entries, e)
+			}
+			if len(entries) < len(segment.Entries) {
+				removed += len(segment.Entries) - len(entries)
+				segment.Entries = entries
+				if err = writeSegment(segmentObj, segment); err != nil {
+					return nil, err
+				}
+			}
+			idx++
+		}
+		if idx >= len(s.segments) {
+			return uint64(0), nil
+		}
+		return s.base(idx), nil
+	})
+	if err == nil {
+		return res.(uint64), removed, nil
+	} else {
+		return 0, 0, err
+	}
+}
+
+// unindex removes from the key index each key whose latest entry is
+// one of entries, which are being truncated.
+func (s *state) unindex(entries []mp.Entry) error {
+	for _, e := range entries {
+		if len(e.Key) == 0 {
+			continue
+		}
+		latest, found, err := s.latest(e.Key)
+		if err != nil {
+			return err
+		} else if found && latest == e.Offset {
+			if err = s.index.Remove(e.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
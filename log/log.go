@@ -0,0 +1,305 @@
+// A Log is an append-only sequence of entries, each assigned the next
+// of a monotonically increasing sequence of offsets, starting from 0.
+// Readers track their own position, reading forwards from any offset
+// with ReadFrom, which makes a Log the basis of change data capture,
+// topics and event sourcing.
+//
+// Entries are stored in segment Objects, each spanning a fixed number
+// of offsets, so appending rewrites only the last segment, and the
+// root only when a new segment is started. Entries may have keys, in
+// which case the Log can be compacted to just the latest entry with
+// each key (see Compact), and it can be truncated (see TruncateBefore),
+// so that a Log need not grow without bound.
+package log
+
+import (
+	"encoding/binary"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/linearhash"
+	mp "goshawkdb.io/collections/log/msgpack"
+)
+
+// The number of offsets spanned by each segment of a new Log, if no
+// segment size is given.
+const DefaultSegmentSize = 256
+
+// An Entry is an entry of a Log, with its offset.
+type Entry struct {
+	Offset uint64
+	// Empty if the entry was appended without a key.
+	Key   []byte
+	Value []byte
+}
+
+type Log struct {
+	// The connection used to create this Log object. As with LHash,
+	// you should not use the same Log object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Log.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Log, each of whose segments spans
+// segmentSize offsets (DefaultSegmentSize if segmentSize is not
+// positive). This creates new GoshawkDB Objects and initialises them
+// for use as a Log. Larger segments mean fewer Objects, but more to
+// rewrite on each Append.
+func NewEmptyLog(conn *client.Connection, segmentSize int) (*Log, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		index, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		segment, err := createSegment(txn, 0)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{SegmentSize: int64(segmentSize)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, index.ObjRef, segment)
+		if err != nil {
+			return nil, err
+		}
+		return &Log{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Log), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Log object from an existing given GoshawkDB Object. As with
+// LHashFromObj, no initialisation is done.
+func LogFromObj(conn *client.Connection, objRef client.ObjectRef) *Log {
+	return &Log{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Log within a single transaction.
+type state struct {
+	objRef   client.ObjectRef
+	root     *mp.Root
+	index    *linearhash.LHash
+	segments []client.ObjectRef
+}
+
+func (l *Log) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(l.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.SegmentSize < 1 || len(refs) < 2 {
+		return nil, fmt.Errorf("Log root %v is corrupt", obj)
+	}
+	index := linearhash.LHashFromObj(l.Conn, refs[0])
+	return &state{objRef: obj, root: root, index: index, segments: refs[1:]}, nil
+}
+
+func (s *state) write() error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	refs := append([]client.ObjectRef{s.index.ObjRef}, s.segments...)
+	return s.objRef.Set(value, refs...)
+}
+
+// latest returns the offset of the latest entry with key, and whether
+// there is one.
+func (s *state) latest(key []byte) (uint64, bool, error) {
+	value, err := s.index.FindValue(key)
+	if err != nil || len(value) != 8 {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(value), true, nil
+}
+
+// next returns the offset the next entry appended will have.
+func (s *state) next() (uint64, error) {
+	segment, err := readSegment(s.segments[len(s.segments)-1])
+	if err != nil {
+		return 0, err
+	}
+	return segment.Next, nil
+}
+
+// base returns the first offset spanned by the idx'th segment.
+func (s *state) base(idx int) uint64 {
+	return s.root.Base + uint64(idx)*uint64(s.root.SegmentSize)
+}
+
+// segmentOf returns the index of the segment spanning offset, which
+// may be beyond the last segment.
+func (s *state) segmentOf(offset uint64) int {
+	if offset < s.root.Base {
+		return 0
+	}
+	return int((offset - s.root.Base) / uint64(s.root.SegmentSize))
+}
+
+func createSegment(txn *client.Txn, next uint64) (client.ObjectRef, error) {
+	value, err := (&mp.Segment{Next: next}).MarshalMsg(nil)
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	return txn.CreateObject(value)
+}
+
+func readSegment(objRef client.ObjectRef) (*mp.Segment, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	segment := new(mp.Segment)
+	if _, err = segment.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func writeSegment(objRef client.ObjectRef, segment *mp.Segment) error {
+	value, err := segment.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return objRef.Set(value)
+}
+
+// Append value to the Log, returning its offset.
+func (l *Log) Append(value []byte) (uint64, error) {
+	return l.AppendKeyed(nil, value)
+}
+
+// Append value to the Log with the given key, returning its offset.
+// When the Log is compacted, only the latest entry with each key is
+// kept. A nil or empty key is no key, as for Append.
+func (l *Log) AppendKeyed(key, value []byte) (uint64, error) {
+	res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := l.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		last := len(s.segments) - 1
+		segmentObj := s.segments[last]
+		segment, err := readSegment(segmentObj)
+		if err != nil {
+			return nil, err
+		}
+		if segment.Next-s.base(last) >= uint64(s.root.SegmentSize) {
+			// the last segment is full, so start another.
+			if segmentObj, err = createSegment(txn, segment.Next); err != nil {
+				return nil, err
+			}
+			s.segments = append(s.segments, segmentObj)
+			if err = s.write(); err != nil {
+				return nil, err
+			}
+			segment = &mp.Segment{Next: segment.Next}
+		}
+		offset := segment.Next
+		segment.Entries = append(segment.Entries, mp.Entry{Offset: offset, Key: key, Value: value})
+		segment.Next++
+		if err = writeSegment(segmentObj, segment); err != nil {
+			return nil, err
+		}
+		if len(key) > 0 {
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], offset)
+			if err = s.index.PutValue(key, buf[:]); err != nil {
+				return nil, err
+			}
+		}
+		return offset, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the offset the next entry appended to the Log will have,
+// which is the number of entries ever appended.
+func (l *Log) Next() (uint64, error) {
+	res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := l.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.next()
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the offset before which entries have been truncated (see
+// TruncateBefore), or 0 if the Log has not been truncated.
+func (l *Log) Start() (uint64, error) {
+	res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := l.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.root.Start, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Read up to max entries (all of them if max is 0 or less), in offset
+// order, starting with the entry at offset, or the first after it if
+// there is no such entry, which may be because it has been truncated
+// or compacted away. Only the segments holding the entries are
+// read. To read onwards, call ReadFrom again with one more than the
+// offset of the last entry returned. No entries are returned if there
+// are none at or after offset.
+func (l *Log) ReadFrom(offset uint64, max int) ([]Entry, error) {
+	res, _, err := l.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := l.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var entries []Entry
+		full := func() bool { return max > 0 && len(entries) == max }
+		for idx := s.segmentOf(offset); idx < len(s.segments) && !full(); idx++ {
+			segment, err := readSegment(s.segments[idx])
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range segment.Entries {
+				if full() {
+					break
+				} else if e.Offset >= offset {
+					entries = append(entries, Entry{Offset: e.Offset, Key: e.Key, Value: e.Value})
+				}
+			}
+		}
+		return entries, nil
+	})
+	if err == nil {
+		return res.([]Entry), nil
+	} else {
+		return nil, err
+	}
+}
@@ -0,0 +1,207 @@
+package log
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, segmentSize int) *Log {
+	c0 := th.CreateConnections(1)[0]
+	l, err := NewEmptyLog(c0.Connection, segmentSize)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return l
+}
+
+// assertEntries checks that the entries have consecutive offsets from
+// first, and the values appended at those offsets.
+func assertEntries(th *tests.TestHelper, entries []Entry, first uint64, count int) {
+	if len(entries) != count {
+		th.Fatalf("Expected %v entries. Got %v", count, len(entries))
+	}
+	for idx, e := range entries {
+		offset := first + uint64(idx)
+		if e.Offset != offset || string(e.Value) != fmt.Sprintf("entry%v", offset) {
+			th.Fatalf("Expected entry%v at offset %v. Got %s at %v", offset, offset, e.Value, e.Offset)
+		}
+	}
+}
+
+func TestAppendReadFrom(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	l := createEmpty(th, 4)
+	if entries, err := l.ReadFrom(0, 10); err != nil || len(entries) != 0 {
+		th.Fatalf("Expected no entries in an empty Log. Got %v %v", entries, err)
+	}
+	for idx := 0; idx < 10; idx++ {
+		if offset, err := l.Append([]byte(fmt.Sprintf("entry%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if offset != uint64(idx) {
+			th.Fatalf("Expected offset %v. Got %v", idx, offset)
+		}
+	}
+	if next, err := l.Next(); err != nil || next != 10 {
+		th.Fatalf("Expected next offset 10. Got %v %v", next, err)
+	}
+
+	entries, err := l.ReadFrom(0, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 0, 10)
+	// reading across segment boundaries, a batch at a time.
+	for offset := uint64(1); offset < 10; offset += 3 {
+		entries, err := l.ReadFrom(offset, 3)
+		if err != nil {
+			th.Fatal(err)
+		}
+		assertEntries(th, entries, offset, 3)
+	}
+	if entries, err = l.ReadFrom(8, 5); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 8, 2)
+	if entries, err = l.ReadFrom(10, 5); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 10, 0)
+	if entries, err = l.ReadFrom(100, 5); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 100, 0)
+}
+
+func TestTruncateBefore(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	l := createEmpty(th, 4)
+	// enough segments that truncation takes several transactions.
+	count := 4*truncateBatch*2 + 6
+	for idx := 0; idx < count; idx++ {
+		if _, err := l.Append([]byte(fmt.Sprintf("entry%v", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err := l.TruncateBefore(6); err != nil {
+		th.Fatal(err)
+	}
+	entries, err := l.ReadFrom(0, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 6, count-6)
+
+	truncate := uint64(count - 3)
+	if err = l.TruncateBefore(truncate); err != nil {
+		th.Fatal(err)
+	}
+	if start, err := l.Start(); err != nil || start != truncate {
+		th.Fatalf("Expected start %v. Got %v %v", truncate, start, err)
+	}
+	if entries, err = l.ReadFrom(0, 0); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, truncate, 3)
+	// truncating backwards does nothing.
+	if err = l.TruncateBefore(2); err != nil {
+		th.Fatal(err)
+	}
+	if entries, err = l.ReadFrom(0, 0); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, truncate, 3)
+
+	// truncating beyond the end removes everything, but offsets carry on.
+	if err = l.TruncateBefore(uint64(count + 100)); err != nil {
+		th.Fatal(err)
+	}
+	if start, err := l.Start(); err != nil || start != uint64(count) {
+		th.Fatalf("Expected start %v. Got %v %v", count, start, err)
+	}
+	if entries, err = l.ReadFrom(0, 0); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, 0, 0)
+	if offset, err := l.Append([]byte(fmt.Sprintf("entry%v", count))); err != nil || offset != uint64(count) {
+		th.Fatalf("Expected offset %v. Got %v %v", count, offset, err)
+	}
+	if entries, err = l.ReadFrom(0, 0); err != nil {
+		th.Fatal(err)
+	}
+	assertEntries(th, entries, uint64(count), 1)
+}
+
+func TestCompact(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	l := createEmpty(th, 4)
+	// key0..key2, each appended 5 times, interleaved with unkeyed entries.
+	for idx := 0; idx < 20; idx++ {
+		value := []byte(fmt.Sprintf("entry%v", idx))
+		var err error
+		if idx%4 == 3 {
+			_, err = l.Append(value)
+		} else {
+			_, err = l.AppendKeyed([]byte(fmt.Sprintf("key%v", idx%3)), value)
+		}
+		if err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	total := 0
+	from := uint64(0)
+	for {
+		next, removed, err := l.Compact(from, 2)
+		if err != nil {
+			th.Fatal(err)
+		}
+		total += removed
+		if next == 0 {
+			break
+		} else if next <= from {
+			th.Fatalf("Expected Compact to make progress from %v. Got %v", from, next)
+		}
+		from = next
+	}
+
+	entries, err := l.ReadFrom(0, 0)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if total+len(entries) != 20 {
+		th.Fatalf("Removed %v entries and kept %v, of 20", total, len(entries))
+	}
+	latest := make(map[string]uint64)
+	for idx := 0; idx < 20; idx++ {
+		if idx%4 != 3 {
+			latest[fmt.Sprintf("key%v", idx%3)] = uint64(idx)
+		}
+	}
+	keyed := 0
+	for _, e := range entries {
+		if e.Offset%4 == 3 {
+			if len(e.Key) != 0 {
+				th.Fatalf("Expected no key at offset %v. Got %s", e.Offset, e.Key)
+			}
+			continue
+		}
+		keyed++
+		if latest[string(e.Key)] != e.Offset || string(e.Value) != fmt.Sprintf("entry%v", e.Offset) {
+			th.Fatalf("Expected only the latest entry for %s. Got %s at %v", e.Key, e.Value, e.Offset)
+		}
+	}
+	if keyed != len(latest) {
+		th.Fatalf("Expected %v keyed entries. Got %v", len(latest), keyed)
+	}
+	// compacting again removes nothing.
+	if next, removed, err := l.Compact(0, 0); err != nil || next != 0 || removed != 0 {
+		th.Fatalf("Expected nothing more to compact. Got %v %v %v", next, removed, err)
+	}
+}
@@ -0,0 +1,33 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Log. Its first reference
+// is to the key index: an LHash from each key to the offset of the
+// latest entry with that key. The rest are the segments of the Log,
+// oldest first. Each segment but the last spans exactly SegmentSize
+// offsets, so the segment holding an offset can be found without
+// reading any other segment.
+type Root struct {
+	// The number of offsets spanned by each segment.
+	SegmentSize int64
+	// The first offset spanned by the first segment.
+	Base uint64
+	// The offset before which entries have been truncated.
+	Start uint64
+}
+
+// Segment is the value of a segment Object.
+type Segment struct {
+	// The offset the next entry appended to the segment would have.
+	Next uint64
+	// The entries of the segment, in offset order.
+	Entries []Entry
+}
+
+type Entry struct {
+	Offset uint64
+	// Empty if the entry has no key.
+	Key   []byte
+	Value []byte
+}
@@ -0,0 +1,217 @@
+// Package modelcheck checks a collection against a simple model of
+// it, such as a Go map or slice, by applying random sequences of
+// operations to both, and checking after each operation that they
+// agree. A collection which passes many such runs, under different
+// seeds, is unlikely to have bugs which the operations can reach.
+//
+// When a run fails, the sequence of operations is shrunk: operations
+// are removed from it, and the remainder replayed against a fresh
+// collection and model, for as long as the remainder still fails.
+// The failure then reports the seed and a short sequence of
+// operations which reproduces it, rather than the hundreds of
+// operations of the original run.
+//
+// Every collection needs only supply a System, which applies an
+// operation to the collection and to its model, and a generator of
+// operations, to be checked with Run. As operations are replayed
+// after others have been removed, each operation must be applicable
+// to any state of the collection: for example, removing a key which
+// is not present must be legal, and must agree with the model.
+package modelcheck
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// An Op is an operation which a System can apply to its collection
+// and model. Ops should be plain values: replaying the same Op must
+// always do the same thing.
+type Op interface {
+	// Describes the operation, for reporting failures.
+	String() string
+}
+
+// A System is a collection under test, together with its model.
+type System interface {
+	// Applies op to both the collection and the model, returning an
+	// error if they disagree on its result.
+	Apply(op Op) error
+	// Checks that the whole collection agrees with the model,
+	// returning an error if it does not. Check is called after every
+	// Op.
+	Check() error
+}
+
+// A Spec is what Run needs to check a collection.
+type Spec struct {
+	// Creates a fresh System, with an empty collection and model. New
+	// is called for every run and every replay while shrinking. An
+	// error from New stops Run, and is returned as is.
+	New func() (System, error)
+	// Generates the next Op to apply to sys, which is the System
+	// returned by New, so Gen may type-assert it to look at the
+	// model, for example to pick keys which are present.
+	Gen func(rng *rand.Rand, sys System) Op
+}
+
+// A Config controls how much checking Run does. The zero Config is
+// usable.
+type Config struct {
+	// The seed of the first run. Each further run uses the next seed.
+	// If 0, a seed is picked from the time.
+	Seed int64
+	// The number of runs, each from a fresh System. Defaults to 10.
+	Runs int
+	// The number of Ops in each run. Defaults to 100.
+	Ops int
+	// The most replays made to shrink a failing run. Defaults to
+	// 1000.
+	MaxReplays int
+	// If not nil, called with the seed of each run, and with the
+	// progress of shrinking. For example, TestHelper.Logf.
+	Logf func(format string, args ...interface{})
+}
+
+// A Failure is returned by Run when a System fails.
+type Failure struct {
+	// The seed of the failing run.
+	Seed int64
+	// The shrunk sequence of Ops which reproduces the failure from a
+	// fresh System.
+	Ops []Op
+	// The number of Ops in the failing run before it was shrunk.
+	Original int
+	// The error returned by the System for the last of Ops.
+	Err error
+}
+
+func (f *Failure) Error() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "Model check failed with seed %v: %v\nReproduced by %v of %v ops:", f.Seed, f.Err, len(f.Ops), f.Original)
+	for idx, op := range f.Ops {
+		fmt.Fprintf(buf, "\n  %v: %v", idx, op)
+	}
+	return buf.String()
+}
+
+// Check spec with random sequences of Ops, as controlled by cfg.
+// Returns a *Failure if a System fails.
+func Run(spec Spec, cfg Config) error {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if cfg.Runs <= 0 {
+		cfg.Runs = 10
+	}
+	if cfg.Ops <= 0 {
+		cfg.Ops = 100
+	}
+	if cfg.MaxReplays <= 0 {
+		cfg.MaxReplays = 1000
+	}
+	for run := 0; run < cfg.Runs; run++ {
+		seed := cfg.Seed + int64(run)
+		cfg.logf("Model check run %v with seed %v", run, seed)
+		ops, err := generate(spec, rand.New(rand.NewSource(seed)), cfg.Ops)
+		if err != nil {
+			return err
+		} else if ops == nil {
+			continue
+		}
+		failure, err := shrink(spec, cfg, ops)
+		if err != nil {
+			return err
+		}
+		failure.Seed = seed
+		return failure
+	}
+	return nil
+}
+
+// generate applies n random Ops to a fresh System. If the System
+// fails, the Ops up to and including the one which failed are
+// returned.
+func generate(spec Spec, rng *rand.Rand, n int) ([]Op, error) {
+	sys, err := spec.New()
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]Op, 0, n)
+	for len(ops) < n {
+		op := spec.Gen(rng, sys)
+		ops = append(ops, op)
+		if apply(sys, op) != nil {
+			return ops, nil
+		}
+	}
+	return nil, nil
+}
+
+func apply(sys System, op Op) error {
+	if err := sys.Apply(op); err != nil {
+		return err
+	}
+	return sys.Check()
+}
+
+// replay applies ops to a fresh System, returning the index of the Op
+// which failed, and its error, or -1 if none did.
+func replay(spec Spec, ops []Op) (int, error, error) {
+	sys, err := spec.New()
+	if err != nil {
+		return 0, nil, err
+	}
+	for idx, op := range ops {
+		if err := apply(sys, op); err != nil {
+			return idx, err, nil
+		}
+	}
+	return -1, nil, nil
+}
+
+// shrink removes ever smaller chunks of ops for as long as what
+// remains still fails, until removing any single Op makes it pass,
+// or cfg.MaxReplays is reached.
+func shrink(spec Spec, cfg Config, ops []Op) (*Failure, error) {
+	failed, failure, err := replay(spec, ops)
+	if err != nil {
+		return nil, err
+	} else if failed < 0 {
+		return nil, fmt.Errorf("Model check failure of %v ops did not reproduce: the System is not deterministic", len(ops))
+	}
+	original := len(ops)
+	ops = ops[:failed+1]
+	replays := 1
+	for chunk := len(ops) / 2; chunk > 0 && replays < cfg.MaxReplays; {
+		removed := false
+		for start := 0; start+chunk <= len(ops) && replays < cfg.MaxReplays; {
+			candidate := make([]Op, 0, len(ops)-chunk)
+			candidate = append(append(candidate, ops[:start]...), ops[start+chunk:]...)
+			replays++
+			idx, e, err := replay(spec, candidate)
+			if err != nil {
+				return nil, err
+			} else if idx < 0 {
+				start += chunk
+			} else {
+				ops, failure, removed = candidate[:idx+1], e, true
+			}
+		}
+		cfg.logf("Model check shrunk to %v ops after %v replays", len(ops), replays)
+		if chunk > len(ops)/2 {
+			chunk = len(ops) / 2
+		} else if !removed || chunk > 1 {
+			chunk /= 2
+		}
+	}
+	return &Failure{Ops: ops, Original: original, Err: failure}, nil
+}
+
+func (cfg Config) logf(format string, args ...interface{}) {
+	if cfg.Logf != nil {
+		cfg.Logf(format, args...)
+	}
+}
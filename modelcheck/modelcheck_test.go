@@ -0,0 +1,107 @@
+package modelcheck
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+type setOp struct {
+	add bool
+	key int
+}
+
+func (op setOp) String() string {
+	if op.add {
+		return fmt.Sprintf("add(%v)", op.key)
+	}
+	return fmt.Sprintf("remove(%v)", op.key)
+}
+
+// sliceSet is a set of ints held in a slice, checked against a map. If
+// buggy, adding a key which is present adds it again, so removing it
+// leaves it present.
+type sliceSet struct {
+	buggy bool
+	keys  []int
+	model map[int]bool
+}
+
+func (s *sliceSet) Apply(op Op) error {
+	o := op.(setOp)
+	found := -1
+	for idx, k := range s.keys {
+		if k == o.key {
+			found = idx
+			break
+		}
+	}
+	if o.add {
+		if found < 0 || s.buggy {
+			s.keys = append(s.keys, o.key)
+		}
+		s.model[o.key] = true
+	} else {
+		if found >= 0 {
+			s.keys = append(s.keys[:found], s.keys[found+1:]...)
+		}
+		if (found >= 0) != s.model[o.key] {
+			return fmt.Errorf("Expected %v to be present: %v", o.key, s.model[o.key])
+		}
+		delete(s.model, o.key)
+	}
+	return nil
+}
+
+func (s *sliceSet) Check() error {
+	if len(s.keys) != len(s.model) {
+		return fmt.Errorf("Expected %v keys. Got %v", len(s.model), len(s.keys))
+	}
+	return nil
+}
+
+func setSpec(buggy bool) Spec {
+	return Spec{
+		New: func() (System, error) {
+			return &sliceSet{buggy: buggy, model: make(map[int]bool)}, nil
+		},
+		Gen: func(rng *rand.Rand, sys System) Op {
+			return setOp{add: rng.Intn(3) != 0, key: rng.Intn(50)}
+		},
+	}
+}
+
+func TestPass(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	if err := Run(setSpec(false), Config{Seed: 1, Logf: th.Logf}); err != nil {
+		th.Fatal(err)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	err := Run(setSpec(true), Config{Seed: 1, Ops: 500, Logf: th.Logf})
+	failure, ok := err.(*Failure)
+	if !ok {
+		th.Fatalf("Expected a Failure. Got %v", err)
+	}
+	th.Log(failure)
+	// the shortest failure adds a key twice, and then fails Check.
+	if len(failure.Ops) != 2 {
+		th.Fatalf("Expected failure to shrink to 2 ops. Got %v of %v", len(failure.Ops), failure.Original)
+	}
+	first, second := failure.Ops[0].(setOp), failure.Ops[1].(setOp)
+	if !first.add || !second.add || first.key != second.key {
+		th.Fatalf("Expected the same key to be added twice. Got %v, %v", first, second)
+	}
+	// the failure must reproduce from its seed.
+	err = Run(setSpec(true), Config{Seed: failure.Seed, Runs: 1, Ops: 500})
+	if again, ok := err.(*Failure); !ok || again.Seed != failure.Seed || len(again.Ops) != len(failure.Ops) {
+		th.Fatalf("Expected failure to reproduce. Got %v", err)
+	}
+}
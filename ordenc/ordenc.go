@@ -0,0 +1,153 @@
+// Package ordenc encodes values as bytes such that comparing the
+// encodings byte-wise (with bytes.Compare) gives the same order as
+// comparing the values themselves. Keys encoded this way can be kept
+// in order by ordered collections, and keys sharing a leading value
+// share an encoded prefix, which is useful for partitioning by prefix.
+//
+// Every encoding is self-delimiting, so a tuple of values is encoded
+// by simply appending the encodings of its elements in turn, and
+// tuples then compare element by element. Nothing records the types
+// of the values, so the decoder must know the types in the tuple.
+package ordenc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrShort is returned when decoding runs out of input.
+var ErrShort = errors.New("Encoded value is truncated")
+
+// Bytes and strings are terminated by 0x00 0x01, and any 0x00 within
+// them is escaped as 0x00 0xFF, so a shorter value which is a prefix
+// of a longer one sorts first.
+const (
+	escape     = 0x00
+	terminator = 0x01
+	escaped    = 0xFF
+)
+
+// AppendUint64 appends the encoding of v to dst: 8 bytes, big endian.
+func AppendUint64(dst []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(dst, buf[:]...)
+}
+
+// DecodeUint64 decodes a uint64 from the start of b, returning the
+// rest of b.
+func DecodeUint64(b []byte) (uint64, []byte, error) {
+	if len(b) < 8 {
+		return 0, nil, ErrShort
+	}
+	return binary.BigEndian.Uint64(b), b[8:], nil
+}
+
+// AppendInt64 appends the encoding of v to dst: that of a uint64 with
+// the sign bit flipped, so that negative numbers sort first.
+func AppendInt64(dst []byte, v int64) []byte {
+	return AppendUint64(dst, uint64(v)^(1<<63))
+}
+
+func DecodeInt64(b []byte) (int64, []byte, error) {
+	u, rest, err := DecodeUint64(b)
+	return int64(u ^ (1 << 63)), rest, err
+}
+
+// AppendFloat64 appends the encoding of v to dst. Positive numbers
+// have their sign bit flipped, and negative numbers all their bits,
+// so that the order is that of the numbers, with -0 before +0. NaNs
+// sort after +Inf if their sign bit is clear and before -Inf if not.
+func AppendFloat64(dst []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits ^= 1 << 63
+	}
+	return AppendUint64(dst, bits)
+}
+
+func DecodeFloat64(b []byte) (float64, []byte, error) {
+	bits, rest, err := DecodeUint64(b)
+	if bits&(1<<63) != 0 {
+		bits ^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits), rest, err
+}
+
+// AppendBytes appends the encoding of v to dst: v with each 0x00
+// escaped, followed by a terminator.
+func AppendBytes(dst []byte, v []byte) []byte {
+	for _, c := range v {
+		if c == escape {
+			dst = append(dst, escape, escaped)
+		} else {
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, escape, terminator)
+}
+
+// DecodeBytes decodes a byte slice from the start of b, returning the
+// rest of b. The result is a new slice.
+func DecodeBytes(b []byte) ([]byte, []byte, error) {
+	v := []byte{}
+	for idx := 0; idx < len(b); idx++ {
+		if b[idx] != escape {
+			v = append(v, b[idx])
+			continue
+		}
+		if idx+1 == len(b) {
+			break
+		}
+		switch b[idx+1] {
+		case terminator:
+			return v, b[idx+2:], nil
+		case escaped:
+			v = append(v, escape)
+			idx++
+		default:
+			return nil, nil, fmt.Errorf("Invalid escape 0x%02x in encoded bytes", b[idx+1])
+		}
+	}
+	return nil, nil, ErrShort
+}
+
+func AppendString(dst []byte, v string) []byte {
+	return AppendBytes(dst, []byte(v))
+}
+
+func DecodeString(b []byte) (string, []byte, error) {
+	v, rest, err := DecodeBytes(b)
+	return string(v), rest, err
+}
+
+// Tuple encodes its arguments in turn. Each must be an int, int64,
+// uint64, float64, string or []byte; an int is encoded as an int64.
+func Tuple(values ...interface{}) ([]byte, error) {
+	var dst []byte
+	for idx, value := range values {
+		switch v := value.(type) {
+		case int:
+			dst = AppendInt64(dst, int64(v))
+		case int64:
+			dst = AppendInt64(dst, v)
+		case uint64:
+			dst = AppendUint64(dst, v)
+		case float64:
+			dst = AppendFloat64(dst, v)
+		case string:
+			dst = AppendString(dst, v)
+		case []byte:
+			dst = AppendBytes(dst, v)
+		default:
+			return nil, fmt.Errorf("Cannot encode element %v of type %T", idx, value)
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,98 @@
+package ordenc
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestInt64Order(t *testing.T) {
+	values := []int64{math.MinInt64, -1 << 40, -2, -1, 0, 1, 2, 1 << 40, math.MaxInt64}
+	for idx := 0; idx < 100; idx++ {
+		values = append(values, rand.Int63()-rand.Int63())
+	}
+	checkOrder(t, len(values), func(i, j int) bool { return values[i] < values[j] },
+		func(i int) []byte { return AppendInt64(nil, values[i]) })
+	for _, v := range values {
+		if decoded, rest, err := DecodeInt64(AppendInt64(nil, v)); err != nil || decoded != v || len(rest) != 0 {
+			t.Fatalf("%v did not round trip: %v %v %v", v, decoded, rest, err)
+		}
+	}
+}
+
+func TestFloat64Order(t *testing.T) {
+	values := []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, 0,
+		math.SmallestNonzeroFloat64, 0.5, 1, math.MaxFloat64, math.Inf(1)}
+	for idx := 0; idx < 100; idx++ {
+		values = append(values, rand.NormFloat64()*1e6)
+	}
+	checkOrder(t, len(values), func(i, j int) bool { return values[i] < values[j] },
+		func(i int) []byte { return AppendFloat64(nil, values[i]) })
+	for _, v := range values {
+		if decoded, _, err := DecodeFloat64(AppendFloat64(nil, v)); err != nil || decoded != v {
+			t.Fatalf("%v did not round trip: %v %v", v, decoded, err)
+		}
+	}
+}
+
+func TestBytesOrder(t *testing.T) {
+	values := [][]byte{{}, {0}, {0, 0}, {0, 1}, {0, 0xff}, {1}, []byte("a"), []byte("a\x00b"), []byte("ab"), {0xff}}
+	checkOrder(t, len(values), func(i, j int) bool { return bytes.Compare(values[i], values[j]) < 0 },
+		func(i int) []byte { return AppendBytes(nil, values[i]) })
+	for _, v := range values {
+		decoded, rest, err := DecodeBytes(append(AppendBytes(nil, v), 42))
+		if err != nil || !bytes.Equal(decoded, v) || !bytes.Equal(rest, []byte{42}) {
+			t.Fatalf("%q did not round trip: %q %v %v", v, decoded, rest, err)
+		}
+	}
+	if _, _, err := DecodeBytes([]byte("abc")); err != ErrShort {
+		t.Fatalf("Expected ErrShort. Got %v", err)
+	}
+}
+
+func TestTupleOrder(t *testing.T) {
+	type tuple struct {
+		s string
+		i int64
+	}
+	tuples := []tuple{{"", 5}, {"a", -1}, {"a", 3}, {"a\x00", -7}, {"ab", 0}, {"b", math.MinInt64}}
+	checkOrder(t, len(tuples), func(i, j int) bool {
+		return tuples[i].s < tuples[j].s || tuples[i].s == tuples[j].s && tuples[i].i < tuples[j].i
+	}, func(i int) []byte {
+		key, err := Tuple(tuples[i].s, tuples[i].i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	})
+	key, _ := Tuple("tenant", 42, 1.5, uint64(7), []byte{0})
+	s, rest, _ := DecodeString(key)
+	i, rest, _ := DecodeInt64(rest)
+	f, rest, _ := DecodeFloat64(rest)
+	u, rest, _ := DecodeUint64(rest)
+	b, rest, err := DecodeBytes(rest)
+	if err != nil || s != "tenant" || i != 42 || f != 1.5 || u != 7 || !bytes.Equal(b, []byte{0}) || len(rest) != 0 {
+		t.Fatalf("Tuple did not round trip: %v %v %v %v %v %v %v", s, i, f, u, b, rest, err)
+	}
+	if _, err = Tuple(int32(1)); err == nil {
+		t.Fatal("Expected an error encoding an int32")
+	}
+}
+
+// checkOrder sorts indices by less, and checks that the encodings
+// are in the same order.
+func checkOrder(t *testing.T, n int, less func(i, j int) bool, encode func(i int) []byte) {
+	indices := make([]int, n)
+	for idx := range indices {
+		indices[idx] = idx
+	}
+	sort.Slice(indices, func(a, b int) bool { return less(indices[a], indices[b]) })
+	for idx := 1; idx < n; idx++ {
+		i, j := indices[idx-1], indices[idx]
+		if cmp := bytes.Compare(encode(i), encode(j)); cmp > 0 || cmp == 0 && less(i, j) {
+			t.Fatalf("Encodings of elements %v and %v are out of order", i, j)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package ordered defines the interface shared by the ordered map
+// collections of this repository, such as btree and skiplist, so that
+// callers can switch between them.
+package ordered
+
+import (
+	"bytes"
+	"goshawkdb.io/client"
+)
+
+// An OrderedMap is a map from keys to GoshawkDB Objects, with keys
+// ordered by bytes.Compare. Each method runs in a single transaction.
+type OrderedMap interface {
+	// Size returns the number of entries.
+	Size() (int64, error)
+	// Find returns the value of key, or nil if it is not present.
+	Find(key []byte) (*client.ObjectRef, error)
+	// Put idempotently adds key and value, or updates the value of
+	// key if it is already present.
+	Put(key []byte, value client.ObjectRef) error
+	// Remove idempotently removes any entry for key.
+	Remove(key []byte) error
+	// ForEach calls f with every entry, in key order, stopping at and
+	// returning any error returned by f.
+	ForEach(f func(key []byte, value client.ObjectRef) error) error
+	// Range calls f with every entry with a key between from, the
+	// lower bound, and to, the upper bound, in key order or in
+	// reverse key order, stopping at and returning any error returned
+	// by f.
+	Range(from, to Bound, reverse bool, f func(key []byte, value client.ObjectRef) error) error
+	// First returns the entry with the lowest key, or a nil key and
+	// value if there are no entries.
+	First() ([]byte, *client.ObjectRef, error)
+	// Last returns the entry with the highest key, or a nil key and
+	// value if there are no entries.
+	Last() ([]byte, *client.ObjectRef, error)
+	// Floor returns the entry with the highest key which is not
+	// greater than key, or a nil key and value if there is none.
+	Floor(key []byte) ([]byte, *client.ObjectRef, error)
+	// Ceiling returns the entry with the lowest key which is not less
+	// than key, or a nil key and value if there is none.
+	Ceiling(key []byte) ([]byte, *client.ObjectRef, error)
+}
+
+// A Bound is one end of a range of keys given to Range. A Bound with a
+// nil Key is unbounded, so the zero Bound includes every key.
+type Bound struct {
+	Key []byte
+	// If Exclusive, Key itself is outside the range.
+	Exclusive bool
+}
+
+// Inclusive returns the Bound which includes key.
+func Inclusive(key []byte) Bound {
+	return Bound{Key: key}
+}
+
+// Exclusive returns the Bound which excludes key.
+func Exclusive(key []byte) Bound {
+	return Bound{Key: key, Exclusive: true}
+}
+
+// After returns true iff key is within the range starting at the
+// lower bound b.
+func (b Bound) After(key []byte) bool {
+	if b.Key == nil {
+		return true
+	}
+	cmp := bytes.Compare(key, b.Key)
+	return cmp > 0 || (cmp == 0 && !b.Exclusive)
+}
+
+// Before returns true iff key is within the range ending at the upper
+// bound b.
+func (b Bound) Before(key []byte) bool {
+	if b.Key == nil {
+		return true
+	}
+	cmp := bytes.Compare(key, b.Key)
+	return cmp < 0 || (cmp == 0 && !b.Exclusive)
+}
@@ -0,0 +1,31 @@
+package ordered
+
+import (
+	"testing"
+)
+
+func TestBound(t *testing.T) {
+	key := func(s string) []byte { return []byte(s) }
+	cases := []struct {
+		b             Bound
+		key           string
+		after, before bool
+	}{
+		{Bound{}, "", true, true},
+		{Bound{}, "m", true, true},
+		{Inclusive(key("m")), "m", true, true},
+		{Exclusive(key("m")), "m", false, false},
+		{Inclusive(key("m")), "a", false, true},
+		{Exclusive(key("m")), "z", true, false},
+		{Inclusive(key("")), "", true, true},
+		{Exclusive(key("")), "", false, false},
+	}
+	for _, c := range cases {
+		if after := c.b.After(key(c.key)); after != c.after {
+			t.Fatalf("Bound %q (exclusive: %v) After(%q): expected %v", c.b.Key, c.b.Exclusive, c.key, c.after)
+		}
+		if before := c.b.Before(key(c.key)); before != c.before {
+			t.Fatalf("Bound %q (exclusive: %v) Before(%q): expected %v", c.b.Key, c.b.Exclusive, c.key, c.before)
+		}
+	}
+}
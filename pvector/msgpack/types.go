@@ -0,0 +1,15 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a version of a Vector. Its
+// only reference, unless the Vector is empty, is to the top node of
+// the tree. Nodes have no value: the references of a leaf are to the
+// Objects holding the values of the elements, and those of a branch
+// are to its children. Every node but the last at each level is full.
+type Root struct {
+	// The number of elements.
+	Count uint64
+	// The number of levels of branches above the leaves.
+	Depth uint32
+}
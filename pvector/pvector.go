@@ -0,0 +1,313 @@
+// A Vector is an immutable sequence of values, indexed from 0. Set and
+// Append do not modify a Vector, but return a new version of it,
+// which shares all but O(log n) of its Objects with the old one, so
+// readers can hold on to old versions, unchanged and cheap to keep,
+// while writers carry on.
+//
+// The values are held in a tree, each node of which has up to 32
+// children, with the values at the leaves, so that the path to an
+// element follows from its index (a radix balanced tree). Get reads
+// only the nodes on the path to the element, and Set and Append copy
+// only those nodes.
+package pvector
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/pvector/msgpack"
+)
+
+// ErrOutOfRange is returned when the index given is not that of an
+// element of the Vector.
+var ErrOutOfRange = errors.New("Index out of range for Vector")
+
+const (
+	// Every node has up to 1<<bits children.
+	bits  = 5
+	width = 1 << bits
+	mask  = width - 1
+)
+
+type Vector struct {
+	// The connection used to create this Vector object. As with LHash,
+	// you should not use the same Vector object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// this version of the Vector.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Vector. This creates a new GoshawkDB Object
+// and initialises it for use as a Vector.
+func NewEmptyVector(conn *client.Connection) (*Vector, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return createVersion(txn, conn, &mp.Root{})
+	})
+	if err == nil {
+		return res.(*Vector), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Vector object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func VectorFromObj(conn *client.Connection, objRef client.ObjectRef) *Vector {
+	return &Vector{Conn: conn, ObjRef: objRef}
+}
+
+// version is a version of the Vector within a single transaction.
+type version struct {
+	root *mp.Root
+	// nil if the Vector is empty.
+	top *client.ObjectRef
+}
+
+func (v *Vector) read(txn *client.Txn) (*version, error) {
+	obj, err := txn.GetObject(v.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if (root.Count == 0) != (len(refs) == 0) || len(refs) > 1 || root.Depth*bits >= 64 {
+		return nil, fmt.Errorf("Vector root %v is corrupt", obj)
+	}
+	ver := &version{root: root}
+	if len(refs) == 1 {
+		ver.top = &refs[0]
+	}
+	return ver, nil
+}
+
+// createVersion creates the root Object of a new version of the
+// Vector, with the given root and top node.
+func createVersion(txn *client.Txn, conn *client.Connection, root *mp.Root, top ...client.ObjectRef) (*Vector, error) {
+	value, err := root.MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	objRef, err := txn.CreateObject(value, top...)
+	if err != nil {
+		return nil, err
+	}
+	return &Vector{Conn: conn, ObjRef: objRef}, nil
+}
+
+// capacity returns the number of elements the tree can hold without
+// growing another level.
+func (ver *version) capacity() uint64 {
+	if (ver.root.Depth+1)*bits >= 64 {
+		return ^uint64(0)
+	}
+	return 1 << ((ver.root.Depth + 1) * bits)
+}
+
+// slot returns the index of the child of a node at the given level
+// (0 for leaves) on the path to the element at idx.
+func slot(idx uint64, level uint32) int {
+	return int((idx >> (level * bits)) & mask)
+}
+
+// Returns the number of elements in the Vector.
+func (v *Vector) Len() (uint64, error) {
+	res, _, err := v.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		ver, err := v.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return ver.root.Count, nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the value of the element at idx, or ErrOutOfRange if there
+// is no such element.
+func (v *Vector) Get(idx uint64) ([]byte, error) {
+	res, _, err := v.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		ver, err := v.read(txn)
+		if err != nil {
+			return nil, err
+		} else if idx >= ver.root.Count {
+			return nil, ErrOutOfRange
+		}
+		node := *ver.top
+		for level := ver.root.Depth; ; level-- {
+			refs, err := node.References()
+			if err != nil {
+				return nil, err
+			}
+			s := slot(idx, level)
+			if s >= len(refs) {
+				return nil, fmt.Errorf("Vector node %v is corrupt", node)
+			}
+			node = refs[s]
+			if level == 0 {
+				return node.Value()
+			}
+		}
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns a new version of the Vector, with the value of the element
+// at idx replaced by value, or ErrOutOfRange if there is no such
+// element. This version of the Vector is unchanged.
+func (v *Vector) Set(idx uint64, value []byte) (*Vector, error) {
+	res, _, err := v.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		ver, err := v.read(txn)
+		if err != nil {
+			return nil, err
+		} else if idx >= ver.root.Count {
+			return nil, ErrOutOfRange
+		}
+		valueObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		top, err := setPath(txn, *ver.top, ver.root.Depth, idx, valueObj)
+		if err != nil {
+			return nil, err
+		}
+		return createVersion(txn, v.Conn, ver.root, top)
+	})
+	if err == nil {
+		return res.(*Vector), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns a new version of the Vector, with value appended. This
+// version of the Vector is unchanged.
+func (v *Vector) Append(value []byte) (*Vector, error) {
+	res, _, err := v.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		ver, err := v.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		valueObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		root := &mp.Root{Count: ver.root.Count + 1, Depth: ver.root.Depth}
+		var top client.ObjectRef
+		switch {
+		case ver.top == nil:
+			top, err = txn.CreateObject(nil, valueObj)
+		case ver.root.Count == ver.capacity():
+			// the tree is full, so grow a level above it.
+			var path client.ObjectRef
+			if path, err = newPath(txn, ver.root.Depth, valueObj); err == nil {
+				top, err = txn.CreateObject(nil, *ver.top, path)
+				root.Depth++
+			}
+		default:
+			top, err = setPath(txn, *ver.top, ver.root.Depth, ver.root.Count, valueObj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return createVersion(txn, v.Conn, root, top)
+	})
+	if err == nil {
+		return res.(*Vector), nil
+	} else {
+		return nil, err
+	}
+}
+
+// setPath returns a copy of node, at the given level, with the element
+// at idx set to valueObj. idx may be one beyond the last element, in
+// which case the nodes missing from its path are created.
+func setPath(txn *client.Txn, node client.ObjectRef, level uint32, idx uint64, valueObj client.ObjectRef) (client.ObjectRef, error) {
+	refs, err := node.References()
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	refs = append([]client.ObjectRef(nil), refs...)
+	s := slot(idx, level)
+	var child client.ObjectRef
+	switch {
+	case s > len(refs):
+		return client.ObjectRef{}, fmt.Errorf("Vector node %v is corrupt", node)
+	case level == 0:
+		child = valueObj
+	case s == len(refs):
+		child, err = newPath(txn, level-1, valueObj)
+	default:
+		child, err = setPath(txn, refs[s], level-1, idx, valueObj)
+	}
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	if s == len(refs) {
+		refs = append(refs, child)
+	} else {
+		refs[s] = child
+	}
+	return txn.CreateObject(nil, refs...)
+}
+
+// newPath returns a new node, at the given level, holding only
+// valueObj.
+func newPath(txn *client.Txn, level uint32, valueObj client.ObjectRef) (client.ObjectRef, error) {
+	node, err := txn.CreateObject(nil, valueObj)
+	for ; err == nil && level > 0; level-- {
+		node, err = txn.CreateObject(nil, node)
+	}
+	return node, err
+}
+
+// Iterate over the elements of the Vector, in index order. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case elements may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (v *Vector) ForEach(f func(idx uint64, value []byte) error) error {
+	_, _, err := v.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		ver, err := v.read(txn)
+		if err != nil || ver.top == nil {
+			return nil, err
+		}
+		idx := uint64(0)
+		return nil, forEach(*ver.top, ver.root.Depth, &idx, f)
+	})
+	return err
+}
+
+func forEach(node client.ObjectRef, level uint32, idx *uint64, f func(idx uint64, value []byte) error) error {
+	refs, err := node.References()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if level > 0 {
+			err = forEach(ref, level-1, idx, f)
+		} else {
+			var value []byte
+			if value, err = ref.Value(); err == nil {
+				err = f(*idx, value)
+				*idx++
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package pvector
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *Vector {
+	c0 := th.CreateConnections(1)[0]
+	v, err := NewEmptyVector(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return v
+}
+
+// assertValues checks the length of v, and that the value of each
+// element idx is values[idx], both with Get and with ForEach.
+func assertValues(th *tests.TestHelper, v *Vector, values []string) {
+	if length, err := v.Len(); err != nil {
+		th.Fatal(err)
+	} else if length != uint64(len(values)) {
+		th.Fatalf("Expected length %v. Got %v", len(values), length)
+	}
+	for idx, expected := range values {
+		if value, err := v.Get(uint64(idx)); err != nil {
+			th.Fatal(err)
+		} else if string(value) != expected {
+			th.Fatalf("Get of %v: expected %v. Got %s", idx, expected, value)
+		}
+	}
+	if _, err := v.Get(uint64(len(values))); err != ErrOutOfRange {
+		th.Fatalf("Expected ErrOutOfRange. Got %v", err)
+	}
+	count := 0
+	if err := v.ForEach(func(idx uint64, value []byte) error {
+		if idx != uint64(count) || string(value) != values[idx] {
+			return fmt.Errorf("ForEach: expected %v at %v. Got %s at %v", values[count], count, value, idx)
+		}
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != len(values) {
+		th.Fatalf("ForEach: expected %v elements. Got %v", len(values), count)
+	}
+}
+
+func TestAppendGetSet(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	v := createEmpty(th)
+	assertValues(th, v, nil)
+	if _, err := v.Set(0, nil); err != ErrOutOfRange {
+		th.Fatalf("Expected ErrOutOfRange. Got %v", err)
+	}
+
+	// enough to need three levels, keeping some versions on the way.
+	var values []string
+	versions := make(map[int]*Vector)
+	for idx := 0; idx < width*width+width+1; idx++ {
+		versions[idx] = v
+		value := fmt.Sprint(idx)
+		next, err := v.Append([]byte(value))
+		if err != nil {
+			th.Fatal(err)
+		}
+		v = next
+		values = append(values, value)
+	}
+	assertValues(th, v, values)
+	for _, length := range []int{0, 1, width, width + 1, width * width} {
+		assertValues(th, versions[length], values[:length])
+	}
+
+	// setting in one version leaves the others alone.
+	old := versions[width+1]
+	for _, idx := range []uint64{0, width - 1, width} {
+		next, err := old.Set(idx, []byte("set"))
+		if err != nil {
+			th.Fatal(err)
+		}
+		expected := append([]string(nil), values[:width+1]...)
+		expected[idx] = "set"
+		assertValues(th, next, expected)
+		assertValues(th, old, values[:width+1])
+	}
+	last := uint64(len(values) - 1)
+	next, err := v.Set(last, []byte("set"))
+	if err != nil {
+		th.Fatal(err)
+	}
+	values[last] = "set"
+	assertValues(th, next, values)
+	if value, err := v.Get(last); err != nil {
+		th.Fatal(err)
+	} else if string(value) != fmt.Sprint(last) {
+		th.Fatalf("Expected old version to be unchanged. Got %s", value)
+	}
+}
@@ -0,0 +1,10 @@
+package msgpack
+
+//go:generate msgp
+
+// Entry is the value of the Object each name in a Registry maps to.
+// Its single reference is to the root Object of the named collection.
+type Entry struct {
+	// The kind of the collection, as given to Create or Add.
+	Kind string
+}
@@ -0,0 +1,240 @@
+// A Registry maps names to collections, so that applications can find
+// their collections by name rather than by Object references held in
+// configuration. Each name maps to the root Object of a collection and
+// a kind, such as KindLHash, recording what sort of collection it is.
+//
+// The Registry is an LHash from name to a small entry Object holding
+// the kind, which in turn references the root Object of the
+// collection. The Registry itself is normally found from a GoshawkDB
+// root Object (see RegistryFromRoot).
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/cas"
+	"goshawkdb.io/collections/linearhash"
+	mp "goshawkdb.io/collections/registry/msgpack"
+	"sync"
+)
+
+var (
+	// ErrExists is returned by Create and Add if the name is already
+	// registered.
+	ErrExists = errors.New("Name already exists in Registry")
+	// ErrNotFound is returned by Open if the name is not registered.
+	ErrNotFound = errors.New("Name not found in Registry")
+)
+
+// The kinds of collection which Create can create without any
+// further registration.
+const (
+	KindLHash = "lhash"
+	KindCAS   = "cas"
+)
+
+// A CreateFunc creates a new empty collection, returning its root
+// Object.
+type CreateFunc func(conn *client.Connection) (client.ObjectRef, error)
+
+var (
+	kindsLock sync.RWMutex
+	kinds     = map[string]CreateFunc{
+		KindLHash: func(conn *client.Connection) (client.ObjectRef, error) {
+			lh, err := linearhash.NewEmptyLHash(conn)
+			if err != nil {
+				return client.ObjectRef{}, err
+			}
+			return lh.ObjRef, nil
+		},
+		KindCAS: func(conn *client.Connection) (client.ObjectRef, error) {
+			c, err := cas.NewEmptyCAS(conn)
+			if err != nil {
+				return client.ObjectRef{}, err
+			}
+			return c.LHash.ObjRef, nil
+		},
+	}
+)
+
+// Register the function used by Create to create collections of the
+// given kind, replacing any function already registered for it. Kinds
+// whose collections need more than a connection to create (such as
+// Tables, which need their index definitions) are better created
+// directly and then registered with Add.
+func RegisterKind(kind string, create CreateFunc) {
+	kindsLock.Lock()
+	defer kindsLock.Unlock()
+	kinds[kind] = create
+}
+
+// An Entry is a registered collection.
+type Entry struct {
+	Name string
+	Kind string
+	// The root Object of the collection.
+	ObjRef client.ObjectRef
+}
+
+type Registry struct {
+	// The LHash holding the names, from name to entry Object.
+	LHash *linearhash.LHash
+}
+
+// Create a brand new empty Registry.
+func NewEmptyRegistry(conn *client.Connection) (*Registry, error) {
+	lh, err := linearhash.NewEmptyLHash(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{LHash: lh}, nil
+}
+
+// Create a Registry from an existing given GoshawkDB Object. As with
+// LHashFromObj, no initialisation is done.
+func RegistryFromObj(conn *client.Connection, objRef client.ObjectRef) *Registry {
+	return &Registry{LHash: linearhash.LHashFromObj(conn, objRef)}
+}
+
+// Find the Registry referenced by the first reference of the GoshawkDB
+// root Object with the given name. If the root Object has no
+// references, a new empty Registry is created and the root Object is
+// set to reference it.
+func RegistryFromRoot(conn *client.Connection, rootName string) (*Registry, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		roots, err := txn.GetRootObjects()
+		if err != nil {
+			return nil, err
+		}
+		root, found := roots[rootName]
+		if !found {
+			return nil, fmt.Errorf("No root Object named %q", rootName)
+		}
+		value, refs, err := root.ValueReferences()
+		if err != nil {
+			return nil, err
+		} else if len(refs) > 0 {
+			return RegistryFromObj(conn, refs[0]), nil
+		}
+		r, err := NewEmptyRegistry(conn)
+		if err != nil {
+			return nil, err
+		}
+		return r, root.Set(value, r.LHash.ObjRef)
+	})
+	if err == nil {
+		return res.(*Registry), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a new empty collection of the given kind, registered under
+// the given name. ErrExists is returned if the name is already
+// registered, and an error if no CreateFunc is registered for the
+// kind.
+func (r *Registry) Create(name, kind string) (*Entry, error) {
+	kindsLock.RLock()
+	create, found := kinds[kind]
+	kindsLock.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("Unknown collection kind %q", kind)
+	}
+	res, _, err := r.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		objRef, err := create(r.LHash.Conn)
+		if err != nil {
+			return nil, err
+		}
+		return r.add(txn, name, kind, objRef)
+	})
+	if err == nil {
+		return res.(*Entry), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Register an existing collection, with the given root Object and
+// kind, under the given name. ErrExists is returned if the name is
+// already registered.
+func (r *Registry) Add(name, kind string, objRef client.ObjectRef) (*Entry, error) {
+	res, _, err := r.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		return r.add(txn, name, kind, objRef)
+	})
+	if err == nil {
+		return res.(*Entry), nil
+	} else {
+		return nil, err
+	}
+}
+
+func (r *Registry) add(txn *client.Txn, name, kind string, objRef client.ObjectRef) (*Entry, error) {
+	if found, err := r.LHash.Contains([]byte(name)); err != nil {
+		return nil, err
+	} else if found {
+		return nil, ErrExists
+	}
+	value, err := (&mp.Entry{Kind: kind}).MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	entryObj, err := txn.CreateObject(value, objRef)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.LHash.Put([]byte(name), entryObj); err != nil {
+		return nil, err
+	}
+	return &Entry{Name: name, Kind: kind, ObjRef: objRef}, nil
+}
+
+// Returns the collection registered under the given name.
+// ErrNotFound is returned if there is none.
+func (r *Registry) Open(name string) (*Entry, error) {
+	res, _, err := r.LHash.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		entryObj, err := r.LHash.Find([]byte(name))
+		if err != nil {
+			return nil, err
+		} else if entryObj == nil {
+			return nil, ErrNotFound
+		}
+		return entryOf(name, *entryObj)
+	})
+	if err == nil {
+		return res.(*Entry), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Idempotently remove the given name from the Registry. The
+// collection itself is not modified.
+func (r *Registry) Delete(name string) error {
+	return r.LHash.Remove([]byte(name))
+}
+
+// Iterate over the registered collections, in no particular order.
+func (r *Registry) ForEach(f func(*Entry) error) error {
+	return r.LHash.ForEach(func(key []byte, entryObj client.ObjectRef) error {
+		e, err := entryOf(string(key), entryObj)
+		if err != nil {
+			return err
+		}
+		return f(e)
+	})
+}
+
+func entryOf(name string, entryObj client.ObjectRef) (*Entry, error) {
+	value, refs, err := entryObj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	e := new(mp.Entry)
+	if _, err = e.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 {
+		return nil, fmt.Errorf("Registry entry for %q is corrupt: %v references", name, len(refs))
+	}
+	return &Entry{Name: name, Kind: e.Kind, ObjRef: refs[0]}, nil
+}
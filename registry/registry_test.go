@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	r, err := NewEmptyRegistry(conn)
+	if err != nil {
+		th.Fatal(err)
+	}
+	users, err := r.Create("users", KindLHash)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if _, err = r.Create("users", KindLHash); err != ErrExists {
+		th.Fatalf("Expected ErrExists. Got %v", err)
+	}
+	if _, err = r.Create("other", "no such kind"); err == nil {
+		th.Fatal("Expected an error creating an unknown kind")
+	}
+	if _, err = r.Create("blobs", KindCAS); err != nil {
+		th.Fatal(err)
+	}
+
+	// write through one reference, and read through the opened one.
+	if err = linearhash.LHashFromObj(conn, users.ObjRef).PutValue([]byte("alice"), []byte("admin")); err != nil {
+		th.Fatal(err)
+	}
+	opened, err := r.Open("users")
+	if err != nil {
+		th.Fatal(err)
+	} else if opened.Kind != KindLHash {
+		th.Fatalf("Expected kind %v. Got %v", KindLHash, opened.Kind)
+	}
+	if value, err := linearhash.LHashFromObj(conn, opened.ObjRef).FindValue([]byte("alice")); err != nil {
+		th.Fatal(err)
+	} else if string(value) != "admin" {
+		th.Fatalf("Expected value admin. Got %s", value)
+	}
+
+	if _, err = r.Add("users-again", KindLHash, opened.ObjRef); err != nil {
+		th.Fatal(err)
+	}
+	kinds := make(map[string]string)
+	if err = r.ForEach(func(e *Entry) error {
+		kinds[e.Name] = e.Kind
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if len(kinds) != 3 || kinds["blobs"] != KindCAS || kinds["users-again"] != KindLHash {
+		th.Fatalf("Unexpected entries %v", kinds)
+	}
+
+	if err = r.Delete("users"); err != nil {
+		th.Fatal(err)
+	}
+	if _, err = r.Open("users"); err != ErrNotFound {
+		th.Fatalf("Expected ErrNotFound. Got %v", err)
+	}
+}
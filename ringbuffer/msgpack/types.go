@@ -0,0 +1,20 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a RingBuffer. Its references
+// are its slots, each either an entry Object, whose value is the
+// value appended, or the root itself if the slot is empty.
+type Root struct {
+	// The number of slots.
+	Capacity int64
+	// What Append does when the RingBuffer is full: 0 to overwrite
+	// the oldest entry, 1 to reject the new entry.
+	Mode int64
+	// The sequence number of the next entry appended. The entry with
+	// sequence number s is held in slot s % Capacity.
+	Next uint64
+	// The number of entries held, which are those with the Count
+	// sequence numbers before Next.
+	Count int64
+}
@@ -0,0 +1,261 @@
+// A RingBuffer holds the most recent values appended to it, up to a
+// fixed capacity, for retaining recent events without unbounded
+// growth. Once it is full, each Append either overwrites the oldest
+// entry or is rejected, according to the RingBuffer's Mode.
+//
+// The root Object references one entry Object per slot, so appending
+// creates one Object and rewrites only the root, whatever the
+// capacity. Every entry is given a sequence number, starting from 0,
+// in the order in which they are appended.
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/ringbuffer/msgpack"
+)
+
+// A Mode determines what Append does when the RingBuffer is full.
+type Mode int64
+
+const (
+	// Overwrite the oldest entry.
+	OverwriteOldest Mode = iota
+	// Reject the new entry with ErrFull.
+	RejectWhenFull
+)
+
+// ErrFull is returned by Append when a RingBuffer in RejectWhenFull
+// mode is full.
+var ErrFull = errors.New("RingBuffer is full")
+
+type RingBuffer struct {
+	// The connection used to create this RingBuffer object. As with
+	// LHash, you should not use the same RingBuffer object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the RingBuffer.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty RingBuffer with the given number of slots
+// and Mode. This creates a new GoshawkDB Object and initialises it for
+// use as a RingBuffer.
+func NewEmptyRingBuffer(conn *client.Connection, capacity int, mode Mode) (*RingBuffer, error) {
+	if capacity < 1 {
+		return nil, errors.New("RingBuffer capacity must be at least 1")
+	} else if mode != OverwriteOldest && mode != RejectWhenFull {
+		return nil, fmt.Errorf("Unknown RingBuffer mode %v", mode)
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		root := &mp.Root{Capacity: int64(capacity), Mode: int64(mode)}
+		value, err := root.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		// every slot starts empty, referring to the root.
+		slots := make([]client.ObjectRef, capacity)
+		for idx := range slots {
+			slots[idx] = objRef
+		}
+		if err = objRef.Set(value, slots...); err != nil {
+			return nil, err
+		}
+		return &RingBuffer{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*RingBuffer), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a RingBuffer object from an existing given GoshawkDB Object.
+// As with LHashFromObj, no initialisation is done.
+func RingBufferFromObj(conn *client.Connection, objRef client.ObjectRef) *RingBuffer {
+	return &RingBuffer{Conn: conn, ObjRef: objRef}
+}
+
+// ring is the state of the RingBuffer within a single transaction.
+type ring struct {
+	objRef client.ObjectRef
+	root   *mp.Root
+	slots  []client.ObjectRef
+}
+
+func (rb *RingBuffer) read(txn *client.Txn) (*ring, error) {
+	obj, err := txn.GetObject(rb.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Capacity < 1 || int64(len(refs)) != root.Capacity || root.Count < 0 || root.Count > root.Capacity {
+		return nil, fmt.Errorf("RingBuffer root %v is corrupt", obj)
+	}
+	return &ring{objRef: obj, root: root, slots: refs}, nil
+}
+
+func (r *ring) write() error {
+	value, err := r.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return r.objRef.Set(value, r.slots...)
+}
+
+// slot returns the index of the slot holding the entry with the given
+// sequence number.
+func (r *ring) slot(seq uint64) int {
+	return int(seq % uint64(r.root.Capacity))
+}
+
+// Returns the number of slots of the RingBuffer.
+func (rb *RingBuffer) Capacity() (int, error) {
+	res, _, err := rb.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		r, err := rb.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return int(r.root.Capacity), nil
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the number of entries in the RingBuffer.
+func (rb *RingBuffer) Size() (int64, error) {
+	res, _, err := rb.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		r, err := rb.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return r.root.Count, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Append value to the RingBuffer, returning its sequence number. If
+// the RingBuffer is full, the oldest entry is overwritten, or, in
+// RejectWhenFull mode, ErrFull is returned.
+func (rb *RingBuffer) Append(value []byte) (uint64, error) {
+	res, _, err := rb.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		r, err := rb.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		if r.root.Count == r.root.Capacity {
+			if Mode(r.root.Mode) == RejectWhenFull {
+				return nil, ErrFull
+			}
+			r.root.Count--
+		}
+		entry, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		seq := r.root.Next
+		r.slots[r.slot(seq)] = entry
+		r.root.Next++
+		r.root.Count++
+		return seq, r.write()
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Remove up to n of the oldest entries, returning the number removed.
+// In RejectWhenFull mode, this makes room for further entries once the
+// oldest have been dealt with.
+func (rb *RingBuffer) RemoveOldest(n int) (int, error) {
+	res, _, err := rb.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		r, err := rb.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		removed := 0
+		for ; removed < n && r.root.Count > 0; removed++ {
+			r.slots[r.slot(r.root.Next-uint64(r.root.Count))] = r.objRef
+			r.root.Count--
+		}
+		if removed == 0 {
+			return 0, nil
+		}
+		return removed, r.write()
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the values of the n most recent entries, or of every entry
+// if there are fewer than n, newest first.
+func (rb *RingBuffer) Latest(n int) ([][]byte, error) {
+	var values [][]byte
+	err := rb.forEachNewestFirst(n, func(seq uint64, value []byte) error {
+		values = append(values, value)
+		return nil
+	}, func() { values = values[:0] })
+	if err == nil {
+		return values, nil
+	} else {
+		return nil, err
+	}
+}
+
+// Iterate over the entries in the RingBuffer, newest first, with their
+// sequence numbers. As with LHash.ForEach, the iteration is done
+// within a single transaction, which may restart, in which case
+// entries may be supplied again. An error returned by f stops the
+// iteration and is returned.
+func (rb *RingBuffer) ForEachNewestFirst(f func(seq uint64, value []byte) error) error {
+	return rb.forEachNewestFirst(-1, f, func() {})
+}
+
+// forEachNewestFirst calls f with up to n of the newest entries, or
+// all of them if n is negative, calling restart at the start of each
+// run of the transaction.
+func (rb *RingBuffer) forEachNewestFirst(n int, f func(seq uint64, value []byte) error, restart func()) error {
+	_, _, err := rb.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		restart()
+		r, err := rb.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		for idx := int64(0); idx < r.root.Count && (n < 0 || idx < int64(n)); idx++ {
+			seq := r.root.Next - 1 - uint64(idx)
+			value, err := r.slots[r.slot(seq)].Value()
+			if err != nil {
+				return nil, err
+			}
+			if err = f(seq, value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,94 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, capacity int, mode Mode) *RingBuffer {
+	c0 := th.CreateConnections(1)[0]
+	rb, err := NewEmptyRingBuffer(c0.Connection, capacity, mode)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return rb
+}
+
+func assertLatest(th *tests.TestHelper, rb *RingBuffer, n int, expected ...string) {
+	values, err := rb.Latest(n)
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(values))
+	for idx, value := range values {
+		got[idx] = string(value)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Latest(%v): expected %v. Got %v", n, expected, got)
+	}
+}
+
+func TestOverwriteOldest(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	rb := createEmpty(th, 3, OverwriteOldest)
+	assertLatest(th, rb, 5)
+	for idx := 0; idx < 5; idx++ {
+		if seq, err := rb.Append([]byte(fmt.Sprintf("event%v", idx))); err != nil {
+			th.Fatal(err)
+		} else if seq != uint64(idx) {
+			th.Fatalf("Expected sequence number %v. Got %v", idx, seq)
+		}
+	}
+	if size, err := rb.Size(); err != nil || size != 3 {
+		th.Fatalf("Expected size 3. Got %v %v", size, err)
+	}
+	assertLatest(th, rb, 5, "event4", "event3", "event2")
+	assertLatest(th, rb, 2, "event4", "event3")
+
+	var seqs []uint64
+	if err := rb.ForEachNewestFirst(func(seq uint64, value []byte) error {
+		if string(value) != fmt.Sprintf("event%v", seq) {
+			return fmt.Errorf("Entry %v has value %s", seq, value)
+		}
+		seqs = append(seqs, seq)
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if fmt.Sprint(seqs) != "[4 3 2]" {
+		th.Fatalf("Expected sequence numbers 4, 3 and 2. Got %v", seqs)
+	}
+}
+
+func TestRejectWhenFull(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	if _, err := NewEmptyRingBuffer(th.CreateConnections(1)[0].Connection, 0, RejectWhenFull); err == nil {
+		th.Fatal("Expected an error creating a RingBuffer without capacity")
+	}
+	rb := createEmpty(th, 2, RejectWhenFull)
+	for _, value := range []string{"a", "b"} {
+		if _, err := rb.Append([]byte(value)); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if _, err := rb.Append([]byte("c")); err != ErrFull {
+		th.Fatalf("Expected ErrFull. Got %v", err)
+	}
+	assertLatest(th, rb, 2, "b", "a")
+
+	if removed, err := rb.RemoveOldest(1); err != nil || removed != 1 {
+		th.Fatalf("Expected to remove 1 entry. Got %v %v", removed, err)
+	}
+	if seq, err := rb.Append([]byte("c")); err != nil || seq != 2 {
+		th.Fatalf("Expected to append c as entry 2. Got %v %v", seq, err)
+	}
+	assertLatest(th, rb, 3, "c", "b")
+	if removed, err := rb.RemoveOldest(5); err != nil || removed != 2 {
+		th.Fatalf("Expected to remove 2 entries. Got %v %v", removed, err)
+	}
+	assertLatest(th, rb, 3)
+}
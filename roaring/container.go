@@ -0,0 +1,139 @@
+package roaring
+
+import (
+	"fmt"
+	mp "goshawkdb.io/collections/roaring/msgpack"
+	"math/bits"
+)
+
+// A ContainerKind is the representation of a container.
+type ContainerKind int64
+
+const (
+	// A sorted array of values, for sparse containers.
+	ArrayContainer ContainerKind = iota
+	// A bitmap of all 65536 possible values, for dense containers.
+	BitmapContainer
+	// A sorted array of runs of consecutive values, for clustered
+	// containers.
+	RunContainer
+)
+
+func (k ContainerKind) String() string {
+	switch k {
+	case ArrayContainer:
+		return "Array"
+	case BitmapContainer:
+		return "Bitmap"
+	case RunContainer:
+		return "Run"
+	default:
+		return fmt.Sprintf("ContainerKind(%d)", int64(k))
+	}
+}
+
+const bitmapWords = 1 << 16 / 64
+
+// A bitmap is the working form of a container, whatever its encoding.
+type bitmap [bitmapWords]uint64
+
+func (b *bitmap) test(v uint16) bool {
+	return b[v/64]&(uint64(1)<<(v%64)) != 0
+}
+
+func (b *bitmap) cardinality() int {
+	count := 0
+	for _, w := range b {
+		count += bits.OnesCount64(w)
+	}
+	return count
+}
+
+// runs returns the number of runs of consecutive values.
+func (b *bitmap) runs() int {
+	runs := 0
+	prev := uint64(0)
+	for _, w := range b {
+		// a run starts at each set bit whose predecessor is clear.
+		runs += bits.OnesCount64(w &^ (w<<1 | prev>>63))
+		prev = w
+	}
+	return runs
+}
+
+func (b *bitmap) forEach(f func(v uint16) error) error {
+	for idx, w := range b {
+		for w != 0 {
+			if err := f(uint16(idx*64 + bits.TrailingZeros64(w))); err != nil {
+				return err
+			}
+			w &= w - 1
+		}
+	}
+	return nil
+}
+
+// decode returns the bitmap of the values held by c.
+func decode(c *mp.Container) (*bitmap, error) {
+	b := new(bitmap)
+	switch ContainerKind(c.Kind) {
+	case ArrayContainer:
+		for _, v := range c.Values {
+			b[v/64] |= uint64(1) << (v % 64)
+		}
+	case BitmapContainer:
+		if len(c.Words) != bitmapWords {
+			return nil, fmt.Errorf("Bitmap container has %v words", len(c.Words))
+		}
+		copy(b[:], c.Words)
+	case RunContainer:
+		if len(c.Runs)%2 != 0 {
+			return nil, fmt.Errorf("Run container has %v run bounds", len(c.Runs))
+		}
+		for idx := 0; idx < len(c.Runs); idx += 2 {
+			for v := int(c.Runs[idx]); v <= int(c.Runs[idx])+int(c.Runs[idx+1]); v++ {
+				b[v/64] |= uint64(1) << uint(v%64)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unknown container kind %v", c.Kind)
+	}
+	return b, nil
+}
+
+// encode returns b in whichever representation is smallest: 2 bytes
+// per value for an array, 4 bytes per run for runs, or 8KB for a
+// bitmap.
+func encode(b *bitmap) *mp.Container {
+	cardinality := b.cardinality()
+	runs := b.runs()
+	c := &mp.Container{Cardinality: int64(cardinality)}
+	switch {
+	case runs*4 < cardinality*2 && runs*4 < bitmapWords*8:
+		c.Kind = int64(RunContainer)
+		c.Runs = make([]uint16, 0, runs*2)
+		start, last := -1, -1
+		b.forEach(func(v uint16) error {
+			if int(v) != last+1 || start < 0 {
+				if start >= 0 {
+					c.Runs = append(c.Runs, uint16(start), uint16(last-start))
+				}
+				start = int(v)
+			}
+			last = int(v)
+			return nil
+		})
+		c.Runs = append(c.Runs, uint16(start), uint16(last-start))
+	case cardinality*2 <= bitmapWords*8:
+		c.Kind = int64(ArrayContainer)
+		c.Values = make([]uint16, 0, cardinality)
+		b.forEach(func(v uint16) error {
+			c.Values = append(c.Values, v)
+			return nil
+		})
+	default:
+		c.Kind = int64(BitmapContainer)
+		c.Words = append([]uint64(nil), b[:]...)
+	}
+	return c
+}
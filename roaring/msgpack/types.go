@@ -0,0 +1,28 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Bitmap. Its references are
+// to the containers, in the same order as Keys.
+type Root struct {
+	// The high bits (i >> 16) shared by the values held by each
+	// container, in increasing order.
+	Keys []uint64
+}
+
+// Container is the value of a container Object, which holds the low 16
+// bits of each value with its key. Exactly one of Values, Words and
+// Runs is used, according to Kind.
+type Container struct {
+	Kind int64
+	// The number of values held.
+	Cardinality int64
+	// For an array container, the values, in increasing order.
+	Values []uint16
+	// For a bitmap container, 1024 words: value v is bit v%64 of word
+	// v/64.
+	Words []uint64
+	// For a run container, pairs of the first value of each run and the
+	// length of the run less 1, in increasing order.
+	Runs []uint16
+}
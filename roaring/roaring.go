@@ -0,0 +1,374 @@
+// A Bitmap is a compressed set of non-negative integers, in the style
+// of a Roaring bitmap: values are grouped by their high bits into
+// containers of up to 65536 values, each of which is a separate Object
+// encoded as whichever of a sorted array, a bitmap or a list of runs
+// is smallest. So, unlike a bitset.Bitset, a Bitmap stays small
+// whether the set is sparse, dense or clustered, whatever the range of
+// its values.
+//
+// Setting or clearing a value rewrites only its container, unless a
+// container has to be created or dropped, which rewrites the root.
+// Bitmap has the same API as bitset.Bitset, and Stats reports how the
+// containers are encoded.
+package roaring
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/roaring/msgpack"
+	"sort"
+)
+
+type Bitmap struct {
+	// The connection used to create this Bitmap object. As with LHash,
+	// you should not use the same Bitmap object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Bitmap.
+	ObjRef client.ObjectRef
+}
+
+// Stats describes the containers of a Bitmap.
+type Stats struct {
+	// The number of containers of each kind.
+	Containers map[ContainerKind]int
+	// The number of values held in containers of each kind.
+	Cardinality map[ContainerKind]uint64
+	// The total size of the encoded containers, in bytes.
+	Bytes int
+}
+
+// Create a brand new empty Bitmap. This creates a new GoshawkDB Object
+// and initialises it for use as a Bitmap.
+func NewEmptyBitmap(conn *client.Connection) (*Bitmap, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Root{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Bitmap{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Bitmap), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Bitmap object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func BitmapFromObj(conn *client.Connection, objRef client.ObjectRef) *Bitmap {
+	return &Bitmap{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Bitmap within a single transaction.
+type state struct {
+	txn        *client.Txn
+	objRef     client.ObjectRef
+	root       *mp.Root
+	containers []client.ObjectRef
+}
+
+func (bm *Bitmap) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(bm.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(root.Keys) != len(refs) {
+		return nil, fmt.Errorf("Bitmap root %v is corrupt", obj)
+	}
+	return &state{txn: txn, objRef: obj, root: root, containers: refs}, nil
+}
+
+func (s *state) write() error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return s.objRef.Set(value, s.containers...)
+}
+
+// find returns the index of the container with the given key, and
+// whether there is one; if not, the index is where it would go.
+func (s *state) find(key uint64) (int, bool) {
+	idx := sort.Search(len(s.root.Keys), func(i int) bool { return s.root.Keys[i] >= key })
+	return idx, idx < len(s.root.Keys) && s.root.Keys[idx] == key
+}
+
+func readContainer(objRef client.ObjectRef) (*mp.Container, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	c := new(mp.Container)
+	if _, err = c.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// bitmap returns the values of the idx'th container.
+func (s *state) bitmap(idx int) (*bitmap, error) {
+	c, err := readContainer(s.containers[idx])
+	if err != nil {
+		return nil, err
+	}
+	return decode(c)
+}
+
+// setBitmap sets the values of the container with the given key,
+// creating, rewriting or dropping the container as necessary.
+func (s *state) setBitmap(key uint64, b *bitmap) error {
+	idx, found := s.find(key)
+	if b.cardinality() == 0 {
+		if !found {
+			return nil
+		}
+		s.root.Keys = append(s.root.Keys[:idx], s.root.Keys[idx+1:]...)
+		s.containers = append(s.containers[:idx], s.containers[idx+1:]...)
+		return s.write()
+	}
+	value, err := encode(b).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if found {
+		return s.containers[idx].Set(value)
+	}
+	containerObj, err := s.txn.CreateObject(value)
+	if err != nil {
+		return err
+	}
+	s.root.Keys = append(s.root.Keys, 0)
+	copy(s.root.Keys[idx+1:], s.root.Keys[idx:])
+	s.root.Keys[idx] = key
+	s.containers = append(s.containers, containerObj)
+	copy(s.containers[idx+1:], s.containers[idx:])
+	s.containers[idx] = containerObj
+	return s.write()
+}
+
+// update sets or clears value i, returning whether it was set
+// beforehand.
+func (bm *Bitmap) update(i uint64, set bool) (bool, error) {
+	res, _, err := bm.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := bm.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		key, v := i>>16, uint16(i)
+		b := new(bitmap)
+		if idx, found := s.find(key); found {
+			if b, err = s.bitmap(idx); err != nil {
+				return nil, err
+			}
+		}
+		was := b.test(v)
+		if was == set {
+			return was, nil
+		}
+		b[v/64] ^= uint64(1) << (v % 64)
+		return was, s.setBitmap(key, b)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Add i to the Bitmap, returning whether it was already present.
+func (bm *Bitmap) Set(i uint64) (bool, error) {
+	return bm.update(i, true)
+}
+
+// Remove i from the Bitmap, returning whether it was present.
+func (bm *Bitmap) Clear(i uint64) (bool, error) {
+	return bm.update(i, false)
+}
+
+// Returns whether i is in the Bitmap.
+func (bm *Bitmap) Test(i uint64) (bool, error) {
+	res, _, err := bm.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := bm.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx, found := s.find(i >> 16)
+		if !found {
+			return false, nil
+		}
+		b, err := s.bitmap(idx)
+		if err != nil {
+			return nil, err
+		}
+		return b.test(uint16(i)), nil
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the number of values in the Bitmap. Every container is
+// read, but only its recorded cardinality is decoded.
+func (bm *Bitmap) Count() (uint64, error) {
+	stats, err := bm.Stats()
+	if err != nil {
+		return 0, err
+	}
+	count := uint64(0)
+	for _, c := range stats.Cardinality {
+		count += c
+	}
+	return count, nil
+}
+
+// Returns statistics about the containers of the Bitmap. Every
+// container is read.
+func (bm *Bitmap) Stats() (*Stats, error) {
+	res, _, err := bm.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := bm.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		stats := &Stats{
+			Containers:  make(map[ContainerKind]int),
+			Cardinality: make(map[ContainerKind]uint64),
+		}
+		for _, containerObj := range s.containers {
+			value, err := containerObj.Value()
+			if err != nil {
+				return nil, err
+			}
+			c := new(mp.Container)
+			if _, err = c.UnmarshalMsg(value); err != nil {
+				return nil, err
+			}
+			kind := ContainerKind(c.Kind)
+			stats.Containers[kind]++
+			stats.Cardinality[kind] += uint64(c.Cardinality)
+			stats.Bytes += len(value)
+		}
+		return stats, nil
+	})
+	if err == nil {
+		return res.(*Stats), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Iterate over the values in the Bitmap, in increasing order. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case values may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (bm *Bitmap) ForEach(f func(i uint64) error) error {
+	_, _, err := bm.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := bm.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		for idx, key := range s.root.Keys {
+			b, err := s.bitmap(idx)
+			if err != nil {
+				return nil, err
+			}
+			if err = b.forEach(func(v uint16) error { return f(key<<16 | uint64(v)) }); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Add every value in other, so that bm becomes the union of the two.
+// The whole operation is a single transaction, reading every container
+// of other.
+func (bm *Bitmap) Or(other *Bitmap) error {
+	return bm.combine(other, func(w, o uint64) uint64 { return w | o })
+}
+
+// Remove every value not in other, so that bm becomes the
+// intersection of the two. The whole operation is a single
+// transaction.
+func (bm *Bitmap) And(other *Bitmap) error {
+	return bm.combine(other, func(w, o uint64) uint64 { return w & o })
+}
+
+// Remove every value in other, so that bm becomes the difference of
+// the two. The whole operation is a single transaction.
+func (bm *Bitmap) AndNot(other *Bitmap) error {
+	return bm.combine(other, func(w, o uint64) uint64 { return w &^ o })
+}
+
+// combine sets each word of bm to f of it and the corresponding word
+// of other, rewriting only the containers which change.
+func (bm *Bitmap) combine(other *Bitmap, f func(w, o uint64) uint64) error {
+	_, _, err := bm.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := bm.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		o, err := other.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		// the union of the keys of both, as setBitmap changes s.root.Keys.
+		var keys []uint64
+		for i, j := 0, 0; i < len(s.root.Keys) || j < len(o.root.Keys); {
+			switch {
+			case j == len(o.root.Keys) || (i < len(s.root.Keys) && s.root.Keys[i] < o.root.Keys[j]):
+				keys = append(keys, s.root.Keys[i])
+				i++
+			case i == len(s.root.Keys) || o.root.Keys[j] < s.root.Keys[i]:
+				keys = append(keys, o.root.Keys[j])
+				j++
+			default:
+				keys = append(keys, s.root.Keys[i])
+				i, j = i+1, j+1
+			}
+		}
+		empty := new(bitmap)
+		for _, key := range keys {
+			b, ob := empty, empty
+			if idx, found := s.find(key); found {
+				if b, err = s.bitmap(idx); err != nil {
+					return nil, err
+				}
+			}
+			if idx, found := o.find(key); found {
+				if ob, err = o.bitmap(idx); err != nil {
+					return nil, err
+				}
+			}
+			result := new(bitmap)
+			changed := false
+			for idx := range result {
+				result[idx] = f(b[idx], ob[idx])
+				changed = changed || result[idx] != b[idx]
+			}
+			if changed {
+				if err = s.setBitmap(key, result); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,137 @@
+package roaring
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, conn *client.Connection) *Bitmap {
+	bm, err := NewEmptyBitmap(conn)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return bm
+}
+
+func setAll(th *tests.TestHelper, bm *Bitmap, is ...uint64) {
+	for _, i := range is {
+		if _, err := bm.Set(i); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// assertValues checks that exactly the given values are present.
+func assertValues(th *tests.TestHelper, bm *Bitmap, expected ...uint64) {
+	var got []uint64
+	if err := bm.ForEach(func(i uint64) error {
+		got = append(got, i)
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected values %v. Got %v", expected, got)
+	}
+	if count, err := bm.Count(); err != nil || count != uint64(len(expected)) {
+		th.Fatalf("Expected count %v. Got %v %v", len(expected), count, err)
+	}
+	for _, i := range expected {
+		if present, err := bm.Test(i); err != nil || !present {
+			th.Fatalf("Expected %v to be present. Got %v %v", i, present, err)
+		}
+	}
+}
+
+func TestSetClearTest(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	bm := createEmpty(th, th.CreateConnections(1)[0].Connection)
+	assertValues(th, bm)
+	setAll(th, bm, 1<<40, 1, 70000, 65535, 65536)
+	if was, err := bm.Set(70000); err != nil || !was {
+		th.Fatalf("Expected 70000 to have been present. Got %v %v", was, err)
+	}
+	assertValues(th, bm, 1, 65535, 65536, 70000, 1<<40)
+	if present, err := bm.Test(2); err != nil || present {
+		th.Fatalf("Expected 2 to be absent. Got %v %v", present, err)
+	}
+	if was, err := bm.Clear(1 << 40); err != nil || !was {
+		th.Fatalf("Expected 1<<40 to have been present. Got %v %v", was, err)
+	}
+	if was, err := bm.Clear(1 << 40); err != nil || was {
+		th.Fatalf("Expected 1<<40 to have been absent. Got %v %v", was, err)
+	}
+	assertValues(th, bm, 1, 65535, 65536, 70000)
+	if stats, err := bm.Stats(); err != nil || stats.Containers[ArrayContainer] != 2 {
+		th.Fatalf("Expected 2 array containers. Got %v %v", stats, err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sparse, dense, clustered := new(bitmap), new(bitmap), new(bitmap)
+	for idx := 0; idx < 100; idx++ {
+		v := rng.Intn(1 << 16)
+		sparse[v/64] |= 1 << uint(v%64)
+	}
+	for v := 0; v < 1<<16; v += 2 {
+		dense[v/64] |= 1 << uint(v%64)
+	}
+	for v := 1000; v < 20000; v++ {
+		if v%5000 != 0 {
+			clustered[v/64] |= 1 << uint(v%64)
+		}
+	}
+	for _, test := range []struct {
+		b    *bitmap
+		kind ContainerKind
+	}{{sparse, ArrayContainer}, {dense, BitmapContainer}, {clustered, RunContainer}} {
+		c := encode(test.b)
+		if ContainerKind(c.Kind) != test.kind || c.Cardinality != int64(test.b.cardinality()) {
+			t.Fatalf("Expected %v container of %v values. Got %v of %v", test.kind, test.b.cardinality(), ContainerKind(c.Kind), c.Cardinality)
+		}
+		b, err := decode(c)
+		if err != nil {
+			t.Fatal(err)
+		} else if *b != *test.b {
+			t.Fatalf("%v container did not decode to its values", test.kind)
+		}
+	}
+	if runs := clustered.runs(); runs != 4 {
+		t.Fatalf("Expected 4 runs. Got %v", runs)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	create := func(is ...uint64) *Bitmap {
+		bm := createEmpty(th, conn)
+		setAll(th, bm, is...)
+		return bm
+	}
+
+	bm := create(1, 2, 70000, 1<<33)
+	if err := bm.Or(create(2, 3, 1<<20)); err != nil {
+		th.Fatal(err)
+	}
+	assertValues(th, bm, 1, 2, 3, 70000, 1<<20, 1<<33)
+	if err := bm.And(create(1, 3, 70001, 1<<20, 1<<34)); err != nil {
+		th.Fatal(err)
+	}
+	assertValues(th, bm, 1, 3, 1<<20)
+	if err := bm.AndNot(create(3, 1<<20)); err != nil {
+		th.Fatal(err)
+	}
+	assertValues(th, bm, 1)
+	if stats, err := bm.Stats(); err != nil || len(stats.Containers) != 1 || stats.Containers[ArrayContainer] != 1 {
+		th.Fatalf("Expected 1 array container. Got %v %v", stats, err)
+	}
+}
@@ -0,0 +1,19 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Rope. Its only reference
+// is to the top node of the index.
+type Root struct {
+	// The most bytes held by each chunk.
+	ChunkSize int64
+}
+
+// Node is the value of a node of the index. Its references are to its
+// children: chunk Objects, the values of which are the bytes of the
+// Rope, if Leaf, and otherwise further nodes.
+type Node struct {
+	Leaf bool
+	// The number of bytes under each child.
+	Sizes []uint64
+}
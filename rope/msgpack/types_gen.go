@@ -0,0 +1,274 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Node) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Leaf":
+			z.Leaf, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Leaf")
+				return
+			}
+		case "Sizes":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Sizes")
+				return
+			}
+			if cap(z.Sizes) >= int(zb0002) {
+				z.Sizes = (z.Sizes)[:zb0002]
+			} else {
+				z.Sizes = make([]uint64, zb0002)
+			}
+			for za0001 := range z.Sizes {
+				z.Sizes[za0001], err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Sizes", za0001)
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Node) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Leaf"
+	err = en.Append(0x82, 0xa4, 0x4c, 0x65, 0x61, 0x66)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Leaf)
+	if err != nil {
+		err = msgp.WrapError(err, "Leaf")
+		return
+	}
+	// write "Sizes"
+	err = en.Append(0xa5, 0x53, 0x69, 0x7a, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Sizes)))
+	if err != nil {
+		err = msgp.WrapError(err, "Sizes")
+		return
+	}
+	for za0001 := range z.Sizes {
+		err = en.WriteUint64(z.Sizes[za0001])
+		if err != nil {
+			err = msgp.WrapError(err, "Sizes", za0001)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Node) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Leaf"
+	o = append(o, 0x82, 0xa4, 0x4c, 0x65, 0x61, 0x66)
+	o = msgp.AppendBool(o, z.Leaf)
+	// string "Sizes"
+	o = append(o, 0xa5, 0x53, 0x69, 0x7a, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Sizes)))
+	for za0001 := range z.Sizes {
+		o = msgp.AppendUint64(o, z.Sizes[za0001])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Node) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Leaf":
+			z.Leaf, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Leaf")
+				return
+			}
+		case "Sizes":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Sizes")
+				return
+			}
+			if cap(z.Sizes) >= int(zb0002) {
+				z.Sizes = (z.Sizes)[:zb0002]
+			} else {
+				z.Sizes = make([]uint64, zb0002)
+			}
+			for za0001 := range z.Sizes {
+				z.Sizes[za0001], bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Sizes", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Node) Msgsize() (s int) {
+	s = 1 + 5 + msgp.BoolSize + 6 + msgp.ArrayHeaderSize + (len(z.Sizes) * (msgp.Uint64Size))
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunkSize":
+			z.ChunkSize, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkSize")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "ChunkSize"
+	err = en.Append(0x81, 0xa9, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.ChunkSize)
+	if err != nil {
+		err = msgp.WrapError(err, "ChunkSize")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "ChunkSize"
+	o = append(o, 0x81, 0xa9, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x69, 0x7a, 0x65)
+	o = msgp.AppendInt64(o, z.ChunkSize)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunkSize":
+			z.ChunkSize, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ChunkSize")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Root) Msgsize() (s int) {
+	s = 1 + 10 + msgp.Int64Size
+	return
+}
@@ -0,0 +1,568 @@
+// A Rope is a large sequence of bytes which can be edited in place:
+// bytes can be inserted, deleted and read at any offset, without
+// reading or rewriting the whole sequence, which suits documents
+// edited by many collaborators.
+//
+// The bytes are held in chunk Objects, of up to a fixed size, which
+// are the leaves of a B-tree like index, each node of which records
+// the number of bytes under each of its children. Finding an offset
+// reads only the nodes on the path to its chunk, and an edit rewrites
+// only the chunks it touches and the nodes above them. Deleting merges
+// chunks and nodes left less than half full with their neighbours, so
+// the index stays shallow.
+package rope
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/rope/msgpack"
+)
+
+// ErrOutOfRange is returned when an offset or length given extends
+// beyond the end of the Rope.
+var ErrOutOfRange = errors.New("Range extends beyond the end of the Rope")
+
+// The size of the chunks of a new Rope, if no chunk size is given.
+const DefaultChunkSize = 4096
+
+// The most children of each node of the index.
+const order = 64
+
+type Rope struct {
+	// The connection used to create this Rope object. As with LHash,
+	// you should not use the same Rope object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Rope.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Rope, holding up to chunkSize bytes in each
+// chunk (DefaultChunkSize if chunkSize is not positive). This creates
+// new GoshawkDB Objects and initialises them for use as a Rope.
+// Larger chunks mean fewer Objects, but more to rewrite on each edit.
+func NewEmptyRope(conn *client.Connection, chunkSize int) (*Rope, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		top, err := createNode(txn, &mp.Node{Leaf: true})
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{ChunkSize: int64(chunkSize)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, top)
+		if err != nil {
+			return nil, err
+		}
+		return &Rope{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Rope), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Rope object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func RopeFromObj(conn *client.Connection, objRef client.ObjectRef) *Rope {
+	return &Rope{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Rope within a single transaction.
+type state struct {
+	objRef client.ObjectRef
+	root   *mp.Root
+	top    *node
+}
+
+func (r *Rope) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(r.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.ChunkSize < 1 || len(refs) != 1 {
+		return nil, fmt.Errorf("Rope root %v is corrupt", obj)
+	}
+	top, err := readNode(refs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &state{objRef: obj, root: root, top: top}, nil
+}
+
+// setTop makes n the top node of the index.
+func (s *state) setTop(n *node) error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	s.top = n
+	return s.objRef.Set(value, n.objRef)
+}
+
+// node is a node of the index within a single transaction.
+type node struct {
+	objRef client.ObjectRef
+	n      *mp.Node
+	refs   []client.ObjectRef
+}
+
+// entry is a child of a node, with the number of bytes under it.
+type entry struct {
+	objRef client.ObjectRef
+	size   uint64
+}
+
+func createNode(txn *client.Txn, n *mp.Node, refs ...client.ObjectRef) (client.ObjectRef, error) {
+	value, err := n.MarshalMsg(nil)
+	if err != nil {
+		return client.ObjectRef{}, err
+	}
+	return txn.CreateObject(value, refs...)
+}
+
+func readNode(objRef client.ObjectRef) (*node, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	n := new(mp.Node)
+	if _, err = n.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != len(n.Sizes) {
+		return nil, fmt.Errorf("Rope node %v is corrupt", objRef)
+	}
+	return &node{objRef: objRef, n: n, refs: refs}, nil
+}
+
+func (n *node) write() error {
+	value, err := n.n.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return n.objRef.Set(value, n.refs...)
+}
+
+// size returns the number of bytes under n.
+func (n *node) size() uint64 {
+	size := uint64(0)
+	for _, s := range n.n.Sizes {
+		size += s
+	}
+	return size
+}
+
+// locate returns the index of the child of n holding offset, and the
+// offset within that child. An offset at the end of n is located at
+// the end of the last child.
+func (n *node) locate(offset uint64) (int, uint64) {
+	last := len(n.n.Sizes) - 1
+	for idx, size := range n.n.Sizes {
+		if offset < size || idx == last {
+			return idx, offset
+		}
+		offset -= size
+	}
+	return 0, offset
+}
+
+// splice replaces drop children of n, from the idx'th, with entries.
+func (n *node) splice(idx, drop int, entries []entry) {
+	refs := append([]client.ObjectRef(nil), n.refs[:idx]...)
+	sizes := append([]uint64(nil), n.n.Sizes[:idx]...)
+	for _, e := range entries {
+		refs = append(refs, e.objRef)
+		sizes = append(sizes, e.size)
+	}
+	n.refs = append(refs, n.refs[idx+drop:]...)
+	n.n.Sizes = append(sizes, n.n.Sizes[idx+drop:]...)
+}
+
+// splitIfFull writes n, first splitting it into as many nodes as
+// needed to have no more than order children each, and returns the
+// entries for them, starting with n itself.
+func (n *node) splitIfFull(txn *client.Txn) ([]entry, error) {
+	count := len(n.refs)
+	parts := (count + order - 1) / order
+	if parts <= 1 {
+		return []entry{{objRef: n.objRef, size: n.size()}}, n.write()
+	}
+	refs, sizes := n.refs, n.n.Sizes
+	var entries []entry
+	for part := 0; part < parts; part++ {
+		lo, hi := part*count/parts, (part+1)*count/parts
+		piece := &node{objRef: n.objRef, n: &mp.Node{Leaf: n.n.Leaf, Sizes: sizes[lo:hi]}, refs: refs[lo:hi]}
+		if part == 0 {
+			if err := piece.write(); err != nil {
+				return nil, err
+			}
+		} else {
+			objRef, err := createNode(txn, piece.n, piece.refs...)
+			if err != nil {
+				return nil, err
+			}
+			piece.objRef = objRef
+		}
+		entries = append(entries, entry{objRef: piece.objRef, size: piece.size()})
+	}
+	return entries, nil
+}
+
+// chunks divides data into as few chunks of no more than ChunkSize
+// bytes as possible, of as near equal size as possible.
+func (s *state) chunks(data []byte) [][]byte {
+	size := int(s.root.ChunkSize)
+	count := (len(data) + size - 1) / size
+	pieces := make([][]byte, count)
+	for idx := range pieces {
+		pieces[idx] = data[idx*len(data)/count : (idx+1)*len(data)/count]
+	}
+	return pieces
+}
+
+// Returns the number of bytes in the Rope.
+func (r *Rope) Len() (uint64, error) {
+	res, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.top.size(), nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Insert data into the Rope at offset, which may be the end of the
+// Rope, moving the bytes from offset onwards along.
+func (r *Rope) Insert(offset uint64, data []byte) error {
+	return r.insert(&offset, data)
+}
+
+// Append data to the end of the Rope.
+func (r *Rope) Append(data []byte) error {
+	return r.insert(nil, data)
+}
+
+// insert inserts data at offset, or at the end of the Rope if offset
+// is nil.
+func (r *Rope) insert(offset *uint64, data []byte) error {
+	_, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		at := s.top.size()
+		if offset != nil {
+			if *offset > at {
+				return nil, ErrOutOfRange
+			}
+			at = *offset
+		}
+		if len(data) == 0 {
+			return nil, nil
+		}
+		entries, err := s.insert(txn, s.top, at, data)
+		if err != nil || len(entries) == 1 {
+			return nil, err
+		}
+		// the top node split, so grow a level above it.
+		top := &node{n: &mp.Node{}}
+		top.splice(0, 0, entries)
+		if top.objRef, err = createNode(txn, top.n, top.refs...); err != nil {
+			return nil, err
+		}
+		return nil, s.setTop(top)
+	})
+	return err
+}
+
+// insert inserts data at offset under n, returning the entries which
+// replace n in its parent.
+func (s *state) insert(txn *client.Txn, n *node, offset uint64, data []byte) ([]entry, error) {
+	if !n.n.Leaf {
+		idx, offset := n.locate(offset)
+		child, err := readNode(n.refs[idx])
+		if err != nil {
+			return nil, err
+		}
+		entries, err := s.insert(txn, child, offset, data)
+		if err != nil {
+			return nil, err
+		}
+		n.splice(idx, 1, entries)
+		return n.splitIfFull(txn)
+	}
+
+	var entries []entry
+	pieces := s.chunks(data)
+	idx, drop := 0, 0
+	if len(n.refs) > 0 {
+		var offsetInChunk uint64
+		idx, offsetInChunk = n.locate(offset)
+		drop = 1
+		chunk := n.refs[idx]
+		value, err := chunk.Value()
+		if err != nil {
+			return nil, err
+		}
+		spliced := make([]byte, 0, len(value)+len(data))
+		spliced = append(append(append(spliced, value[:offsetInChunk]...), data...), value[offsetInChunk:]...)
+		pieces = s.chunks(spliced)
+		// the chunk itself keeps the first piece.
+		if err = chunk.Set(pieces[0]); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{objRef: chunk, size: uint64(len(pieces[0]))})
+		pieces = pieces[1:]
+	}
+	for _, piece := range pieces {
+		chunk, err := txn.CreateObject(piece)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{objRef: chunk, size: uint64(len(piece))})
+	}
+	n.splice(idx, drop, entries)
+	return n.splitIfFull(txn)
+}
+
+// Delete count bytes from the Rope, starting at offset, moving the
+// bytes after them back.
+func (r *Rope) Delete(offset, count uint64) error {
+	_, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		size := s.top.size()
+		if offset > size || count > size-offset {
+			return nil, ErrOutOfRange
+		} else if count == 0 {
+			return nil, nil
+		}
+		if err = s.remove(s.top, offset, count); err != nil {
+			return nil, err
+		}
+		// shrink the index while the top node has just one child.
+		top := s.top
+		for !top.n.Leaf && len(top.refs) == 1 {
+			if top, err = readNode(top.refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		if !top.n.Leaf && len(top.refs) == 0 {
+			top.n.Leaf = true
+			if err = top.write(); err != nil {
+				return nil, err
+			}
+		}
+		if top != s.top {
+			return nil, s.setTop(top)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// remove removes count bytes from offset under n.
+func (s *state) remove(n *node, offset, count uint64) error {
+	var refs []client.ObjectRef
+	var sizes []uint64
+	// the children cut, or next to those removed, which may now be
+	// worth merging with their neighbours.
+	var touched []bool
+	dropped := false
+	start, end := uint64(0), offset+count
+	for idx, size := range n.n.Sizes {
+		child := n.refs[idx]
+		childStart := start
+		start += size
+		if start <= offset || childStart >= end {
+			refs, sizes, touched = append(refs, child), append(sizes, size), append(touched, dropped)
+			dropped = false
+			continue
+		}
+		lo, hi := uint64(0), size
+		if offset > childStart {
+			lo = offset - childStart
+		}
+		if end < start {
+			hi = end - childStart
+		}
+		if lo == 0 && hi == size {
+			// the whole child goes.
+			if len(touched) > 0 {
+				touched[len(touched)-1] = true
+			}
+			dropped = true
+			continue
+		}
+		if n.n.Leaf {
+			value, err := child.Value()
+			if err != nil {
+				return err
+			}
+			if err = child.Set(append(append([]byte(nil), value[:lo]...), value[hi:]...)); err != nil {
+				return err
+			}
+		} else {
+			grandchild, err := readNode(child)
+			if err != nil {
+				return err
+			}
+			if err = s.remove(grandchild, lo, hi-lo); err != nil {
+				return err
+			}
+		}
+		refs, sizes, touched = append(refs, child), append(sizes, size-(hi-lo)), append(touched, true)
+		dropped = false
+	}
+	n.refs, n.n.Sizes = refs, sizes
+	for idx := 0; idx+1 < len(n.refs); {
+		merged := false
+		if touched[idx] || touched[idx+1] {
+			var err error
+			if merged, err = s.merge(n, idx); err != nil {
+				return err
+			}
+		}
+		if merged {
+			touched = append(touched[:idx+1], touched[idx+2:]...)
+			touched[idx] = true
+		} else {
+			idx++
+		}
+	}
+	return n.write()
+}
+
+// merge merges the idx'th child of n with the next, if one of them is
+// less than half full and both fit in one, so that deleting does not
+// leave many small chunks and nodes. It returns whether they were
+// merged.
+func (s *state) merge(n *node, idx int) (bool, error) {
+	if n.n.Leaf {
+		a, b := n.n.Sizes[idx], n.n.Sizes[idx+1]
+		size := uint64(s.root.ChunkSize)
+		if a+b > size || (a >= size/2 && b >= size/2) {
+			return false, nil
+		}
+		first, err := n.refs[idx].Value()
+		if err != nil {
+			return false, err
+		}
+		second, err := n.refs[idx+1].Value()
+		if err != nil {
+			return false, err
+		}
+		if err = n.refs[idx].Set(append(append([]byte(nil), first...), second...)); err != nil {
+			return false, err
+		}
+	} else {
+		first, err := readNode(n.refs[idx])
+		if err != nil {
+			return false, err
+		}
+		second, err := readNode(n.refs[idx+1])
+		if err != nil {
+			return false, err
+		}
+		a, b := len(first.refs), len(second.refs)
+		if a+b > order || (a >= order/2 && b >= order/2) {
+			return false, nil
+		}
+		first.refs = append(first.refs, second.refs...)
+		first.n.Sizes = append(first.n.Sizes, second.n.Sizes...)
+		// the children either side of the seam may merge too.
+		if a > 0 && b > 0 {
+			if _, err = s.merge(first, a-1); err != nil {
+				return false, err
+			}
+		}
+		if err = first.write(); err != nil {
+			return false, err
+		}
+	}
+	n.n.Sizes[idx] += n.n.Sizes[idx+1]
+	n.splice(idx+1, 1, nil)
+	return true, nil
+}
+
+// Returns count bytes of the Rope, starting at offset. Only the
+// chunks holding those bytes are read.
+func (r *Rope) Slice(offset, count uint64) ([]byte, error) {
+	res, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		size := s.top.size()
+		if offset > size || count > size-offset {
+			return nil, ErrOutOfRange
+		}
+		buf := make([]byte, 0, count)
+		return slice(s.top, offset, count, buf)
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// slice appends count bytes from offset under n to buf.
+func slice(n *node, offset, count uint64, buf []byte) ([]byte, error) {
+	start, end := uint64(0), offset+count
+	for idx, size := range n.n.Sizes {
+		childStart := start
+		start += size
+		if start <= offset {
+			continue
+		} else if childStart >= end {
+			break
+		}
+		lo, hi := uint64(0), size
+		if offset > childStart {
+			lo = offset - childStart
+		}
+		if end < start {
+			hi = end - childStart
+		}
+		if n.n.Leaf {
+			value, err := n.refs[idx].Value()
+			if err != nil {
+				return nil, err
+			} else if uint64(len(value)) != size {
+				return nil, fmt.Errorf("Rope chunk %v is corrupt", n.refs[idx])
+			}
+			buf = append(buf, value[lo:hi]...)
+		} else {
+			child, err := readNode(n.refs[idx])
+			if err != nil {
+				return nil, err
+			}
+			if buf, err = slice(child, lo, hi-lo, buf); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,190 @@
+package rope
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, chunkSize int) *Rope {
+	c0 := th.CreateConnections(1)[0]
+	r, err := NewEmptyRope(c0.Connection, chunkSize)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return r
+}
+
+// assertContents checks that r holds exactly expected, and that every
+// node records the right sizes and every chunk is within the chunk
+// size.
+func assertContents(th *tests.TestHelper, r *Rope, expected []byte) {
+	if length, err := r.Len(); err != nil {
+		th.Fatal(err)
+	} else if length != uint64(len(expected)) {
+		th.Fatalf("Expected length %v. Got %v", len(expected), length)
+	}
+	if got, err := r.Slice(0, uint64(len(expected))); err != nil {
+		th.Fatal(err)
+	} else if !bytes.Equal(got, expected) {
+		th.Fatalf("Expected %q. Got %q", expected, got)
+	}
+	_, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		_, err = check(s.top, uint64(s.root.ChunkSize))
+		return nil, err
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+// check returns the number of bytes under n, checking the sizes n
+// records.
+func check(n *node, chunkSize uint64) (uint64, error) {
+	if len(n.refs) > order {
+		return 0, fmt.Errorf("Node has %v children", len(n.refs))
+	}
+	for idx, ref := range n.refs {
+		var size uint64
+		if n.n.Leaf {
+			value, err := ref.Value()
+			if err != nil {
+				return 0, err
+			}
+			size = uint64(len(value))
+			if size == 0 || size > chunkSize {
+				return 0, fmt.Errorf("Chunk has %v bytes", size)
+			}
+		} else {
+			child, err := readNode(ref)
+			if err != nil {
+				return 0, err
+			}
+			if size, err = check(child, chunkSize); err != nil {
+				return 0, err
+			}
+		}
+		if size != n.n.Sizes[idx] {
+			return 0, fmt.Errorf("Child has %v bytes, but node records %v", size, n.n.Sizes[idx])
+		}
+	}
+	return n.size(), nil
+}
+
+// depth returns the number of levels of the index of r.
+func depth(th *tests.TestHelper, r *Rope) int {
+	res, _, err := r.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := r.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		depth := 1
+		for n := s.top; !n.n.Leaf; depth++ {
+			if n, err = readNode(n.refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		return depth, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	return res.(int)
+}
+
+func TestInsertDeleteSlice(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	r := createEmpty(th, 4)
+	assertContents(th, r, nil)
+	if err := r.Insert(1, []byte("a")); err != ErrOutOfRange {
+		th.Fatalf("Expected ErrOutOfRange. Got %v", err)
+	}
+	if err := r.Append([]byte("hello world")); err != nil {
+		th.Fatal(err)
+	}
+	if err := r.Insert(5, []byte(",")); err != nil {
+		th.Fatal(err)
+	}
+	if err := r.Insert(0, []byte(">> ")); err != nil {
+		th.Fatal(err)
+	}
+	assertContents(th, r, []byte(">> hello, world"))
+	if got, err := r.Slice(3, 5); err != nil {
+		th.Fatal(err)
+	} else if string(got) != "hello" {
+		th.Fatalf("Expected hello. Got %q", got)
+	}
+	if _, err := r.Slice(10, 6); err != ErrOutOfRange {
+		th.Fatalf("Expected ErrOutOfRange. Got %v", err)
+	}
+	if err := r.Delete(8, 2); err != nil {
+		th.Fatal(err)
+	}
+	assertContents(th, r, []byte(">> helloworld"))
+	if err := r.Delete(0, 13); err != nil {
+		th.Fatal(err)
+	}
+	assertContents(th, r, nil)
+
+	// enough edits for several levels of the index, against a model.
+	rng := rand.New(rand.NewSource(0))
+	var model []byte
+	for i := 0; i < 400; i++ {
+		offset := uint64(rng.Intn(len(model) + 1))
+		if len(model) > 0 && rng.Intn(3) == 0 {
+			count := uint64(rng.Intn(len(model) - int(offset) + 1))
+			if err := r.Delete(offset, count); err != nil {
+				th.Fatal(err)
+			}
+			model = append(model[:offset], model[offset+count:]...)
+		} else {
+			data := bytes.Repeat([]byte{byte('a' + i%26)}, 1+rng.Intn(40))
+			if err := r.Insert(offset, data); err != nil {
+				th.Fatal(err)
+			}
+			model = append(model[:offset], append(data, model[offset:]...)...)
+		}
+		if i%50 == 0 {
+			assertContents(th, r, model)
+		}
+	}
+	assertContents(th, r, model)
+	// at 4 bytes a chunk, this needs three levels.
+	for i := 0; i < 4000; i++ {
+		data := []byte(fmt.Sprintf("%05d:", i))
+		if err := r.Append(data); err != nil {
+			th.Fatal(err)
+		}
+		model = append(model, data...)
+	}
+	assertContents(th, r, model)
+	if depth := depth(th, r); depth != 3 {
+		th.Fatalf("Expected depth 3. Got %v", depth)
+	}
+	if err := r.Delete(10, uint64(len(model)-20)); err != nil {
+		th.Fatal(err)
+	}
+	model = append(model[:10], model[len(model)-10:]...)
+	assertContents(th, r, model)
+	if depth := depth(th, r); depth != 1 {
+		th.Fatalf("Expected depth 1. Got %v", depth)
+	}
+	for i := 0; i < 20; i++ {
+		offset := uint64(rng.Intn(len(model) + 1))
+		count := uint64(rng.Intn(len(model) - int(offset) + 1))
+		if got, err := r.Slice(offset, count); err != nil {
+			th.Fatal(err)
+		} else if !bytes.Equal(got, model[offset:offset+count]) {
+			th.Fatalf("Slice of %v from %v: expected %q. Got %q", count, offset, model[offset:offset+count], got)
+		}
+	}
+}
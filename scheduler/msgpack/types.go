@@ -0,0 +1,38 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Scheduler. Its references
+// are to the LHash of Jobs by name, and to the BTree of Jobs by when
+// they are next due.
+type Root struct {
+	// The number of Runs kept in the history of each Job.
+	HistorySize int64
+}
+
+// Job is the value of the Object of a job. Its only reference is to
+// the RingBuffer of its Runs.
+type Job struct {
+	Schedule string
+	Payload  []byte
+	// When the job is next to run, in nanoseconds since the Unix epoch.
+	NextRun int64
+	// The worker which has claimed the run, or empty if it is not
+	// claimed.
+	Owner []byte
+	// When the claim expires, in nanoseconds since the Unix epoch.
+	Expiry int64
+	// Incremented by each claim.
+	Token uint64
+}
+
+// Run is the record of a run of a job, in the history of the job.
+type Run struct {
+	Scheduled int64
+	Claimed   int64
+	Finished  int64
+	Owner     []byte
+	Token     uint64
+	// The error the run failed with, or empty if it succeeded.
+	Error string
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule determines when a job runs.
+type Schedule interface {
+	// Returns the first time the job runs after the given time, or the
+	// zero Time if it never does.
+	Next(after time.Time) time.Time
+}
+
+// The schedules which have names.
+var named = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse a Schedule from its spec, which is either "@every" and a
+// duration, such as "@every 90s", to run at that interval, or a cron
+// expression of five fields: minute (0-59), hour (0-23), day of the
+// month (1-31), month (1-12) and day of the week (0-6, from Sunday,
+// with 7 also Sunday). Each field is a comma separated list of "*",
+// numbers and ranges, such as "1-5", each optionally followed by a
+// step, such as "*/15". As with cron, if both the day of the month and
+// the day of the week are restricted, a day matching either runs. The
+// names @yearly, @annually, @monthly, @weekly, @daily and @hourly may
+// also be used. Times are in the location of the time given to Next.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if cron, found := named[spec]; found {
+		spec = cron
+	} else if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid schedule %q: %v", spec, err)
+		} else if d <= 0 {
+			return nil, fmt.Errorf("Invalid schedule %q: the interval must be positive", spec)
+		}
+		return every(d), nil
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Invalid schedule %q: expected 5 fields, found %v", spec, len(fields))
+	}
+	c := new(cron)
+	for idx, f := range []struct {
+		bits     *bits
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.dom, 1, 31},
+		{&c.month, 1, 12},
+		{&c.dow, 0, 7},
+	} {
+		if err := f.bits.parse(fields[idx], f.min, f.max); err != nil {
+			return nil, fmt.Errorf("Invalid schedule %q: %v", spec, err)
+		}
+	}
+	if c.dow.has(7) {
+		c.dow.set |= 1
+	}
+	return c, nil
+}
+
+// every is a Schedule which runs at a fixed interval.
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// bits is the set of values of a field of a cron expression.
+type bits struct {
+	set uint64
+	// whether the field is "*", without a step.
+	star bool
+}
+
+func (b *bits) has(v int) bool {
+	return b.set&(1<<uint(v)) != 0
+}
+
+func (b *bits) parse(field string, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.IndexByte(part, '/'); idx >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[idx+1:]); err != nil || step < 1 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:idx]
+		}
+		lo, hi := min, max
+		if part == "*" {
+			b.star = b.star || step == 1
+		} else {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step != 1 {
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return fmt.Errorf("%q is out of the range %v-%v", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			b.set |= 1 << uint(v)
+		}
+	}
+	return nil
+}
+
+// cron is a Schedule of a cron expression.
+type cron struct {
+	minute, hour, dom, month, dow bits
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.dom.star || c.dow.star {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// a schedule which matches no time, such as "0 0 30 2 *", gives up
+	// once every day of a leap cycle has been tried.
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		if !c.month.has(int(m)) {
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		} else if !c.dayMatches(t) {
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		} else if !c.hour.has(t.Hour()) {
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		} else if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
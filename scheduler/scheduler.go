@@ -0,0 +1,522 @@
+// A Scheduler holds jobs which run on Schedules, such as cron
+// expressions, and coordinates any number of workers running them:
+// each run of a job is claimed by one worker, under a lease, and the
+// worker records the outcome in the job's history when it completes
+// the run. Should a worker crash, its lease expires, and the run is
+// claimed by another.
+//
+// The jobs are held in an LHash by name, and in a BTree ordered by
+// when they are next due: the time of their next run, or, once
+// claimed, when the claim expires. So claiming reads only the first
+// entry of the BTree. The history of each job is kept in a
+// RingBuffer, so only the most recent runs are kept.
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/btree"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/collections/ordenc"
+	"goshawkdb.io/collections/ordered"
+	"goshawkdb.io/collections/ringbuffer"
+	mp "goshawkdb.io/collections/scheduler/msgpack"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned if there is no job with the given name.
+	ErrNotFound = errors.New("No such job in Scheduler")
+	// ErrLeaseLost is returned by Renew and Complete if the run has
+	// since been claimed again, by this worker or another.
+	ErrLeaseLost = errors.New("Job has been claimed again")
+)
+
+// The number of Runs kept in the history of each job if no size is
+// given.
+const DefaultHistorySize = 16
+
+// A Job is a job of a Scheduler.
+type Job struct {
+	Name     string
+	Schedule string
+	Payload  []byte
+	// When the job is next to run. If the run is claimed, this is the
+	// time it was due.
+	NextRun time.Time
+	// The worker which has claimed the run, or nil if it is not claimed.
+	Owner []byte
+	// When the claim expires, if the run is claimed.
+	Expiry time.Time
+}
+
+func newJob(name string, job *mp.Job) *Job {
+	j := &Job{Name: name, Schedule: job.Schedule, Payload: job.Payload, NextRun: time.Unix(0, job.NextRun), Owner: job.Owner}
+	if len(job.Owner) != 0 {
+		j.Expiry = time.Unix(0, job.Expiry)
+	}
+	return j
+}
+
+// A Claim is a run of a job claimed by a worker. Pass it to Renew and
+// Complete.
+type Claim struct {
+	Name    string
+	Payload []byte
+	// When the run was due.
+	Scheduled time.Time
+	// When the run was claimed.
+	Claimed time.Time
+	Owner   []byte
+	// Greater than that of every earlier claim of the job, so that, as
+	// with the fencing tokens of a Mutex, actions taken by workers
+	// whose claims have been superseded can be detected.
+	Token  uint64
+	Expiry time.Time
+}
+
+// A Run is the record of a completed run of a job.
+type Run struct {
+	Scheduled time.Time
+	Claimed   time.Time
+	Finished  time.Time
+	Owner     []byte
+	Token     uint64
+	// The error the run failed with, or empty if it succeeded.
+	Error string
+}
+
+type Scheduler struct {
+	// The connection used to create this Scheduler object. As with
+	// LHash, you should not use the same Scheduler object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Scheduler.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Scheduler, keeping the given number of
+// Runs in the history of each job (DefaultHistorySize if historySize
+// is not positive). This creates new GoshawkDB Objects and initialises
+// them for use as a Scheduler.
+func NewEmptyScheduler(conn *client.Connection, historySize int) (*Scheduler, error) {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		jobs, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		due, err := btree.NewEmptyBTree(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{HistorySize: int64(historySize)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, jobs.ObjRef, due.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &Scheduler{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Scheduler), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Scheduler object from an existing given GoshawkDB Object.
+// As with LHashFromObj, no initialisation is done.
+func SchedulerFromObj(conn *client.Connection, objRef client.ObjectRef) *Scheduler {
+	return &Scheduler{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the Scheduler within a single transaction.
+type state struct {
+	root *mp.Root
+	jobs *linearhash.LHash
+	due  *btree.BTree
+}
+
+func (s *Scheduler) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(s.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.HistorySize < 1 || len(refs) != 2 {
+		return nil, fmt.Errorf("Scheduler root %v is corrupt", obj)
+	}
+	return &state{root: root, jobs: linearhash.LHashFromObj(s.Conn, refs[0]), due: btree.BTreeFromObj(s.Conn, refs[1])}, nil
+}
+
+// job is a job within a single transaction.
+type job struct {
+	name    string
+	objRef  client.ObjectRef
+	j       *mp.Job
+	history *ringbuffer.RingBuffer
+}
+
+// job returns the job with the given name, or nil if there is none.
+func (s *state) job(conn *client.Connection, name string) (*job, error) {
+	objRef, err := s.jobs.Find([]byte(name))
+	if err != nil || objRef == nil {
+		return nil, err
+	}
+	return readJob(conn, name, *objRef)
+}
+
+func readJob(conn *client.Connection, name string, objRef client.ObjectRef) (*job, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	j := new(mp.Job)
+	if _, err = j.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 {
+		return nil, fmt.Errorf("Scheduler job %v is corrupt", objRef)
+	}
+	return &job{name: name, objRef: objRef, j: j, history: ringbuffer.RingBufferFromObj(conn, refs[0])}, nil
+}
+
+func (j *job) write() error {
+	value, err := j.j.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return j.objRef.Set(value, j.history.ObjRef)
+}
+
+// dueKey returns the key of the job in the BTree of jobs by when they
+// are due: the time, then the name.
+func (j *job) dueKey() []byte {
+	due := j.j.NextRun
+	if len(j.j.Owner) != 0 {
+		due = j.j.Expiry
+	}
+	return append(ordenc.AppendInt64(nil, due), j.name...)
+}
+
+// update writes j, moving it within the BTree of jobs by when they are
+// due from the given key.
+func (s *state) update(j *job, oldKey []byte) error {
+	if err := j.write(); err != nil {
+		return err
+	}
+	if newKey := j.dueKey(); !bytes.Equal(oldKey, newKey) {
+		if err := s.due.Remove(oldKey); err != nil {
+			return err
+		}
+		return s.due.Put(newKey, j.objRef)
+	}
+	return nil
+}
+
+// next returns the time of the next run of a job with the given
+// schedule after the given time.
+func next(spec string, after time.Time) (int64, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return 0, err
+	}
+	t := schedule.Next(after)
+	if t.IsZero() {
+		return 0, fmt.Errorf("Schedule %q never runs", spec)
+	}
+	return t.UnixNano(), nil
+}
+
+// Add a job with the given name, schedule and payload, or replace the
+// schedule and payload of an existing job, in which case its history
+// is kept. The job next runs when its schedule first runs after now,
+// unless the run is currently claimed, in which case the next run
+// after that is from the new schedule. Returns an error if the
+// schedule cannot be parsed by ParseSchedule, or never runs.
+func (s *Scheduler) Put(name, schedule string, payload []byte) error {
+	nextRun, err := next(schedule, time.Now())
+	if err != nil {
+		return err
+	}
+	_, _, err = s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.job(s.Conn, name)
+		if err != nil {
+			return nil, err
+		} else if j != nil {
+			oldKey := j.dueKey()
+			j.j.Schedule, j.j.Payload = schedule, payload
+			if len(j.j.Owner) == 0 {
+				j.j.NextRun = nextRun
+			}
+			return nil, st.update(j, oldKey)
+		}
+		history, err := ringbuffer.NewEmptyRingBuffer(s.Conn, int(st.root.HistorySize), ringbuffer.OverwriteOldest)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(nil)
+		if err != nil {
+			return nil, err
+		}
+		j = &job{name: name, objRef: objRef, j: &mp.Job{Schedule: schedule, Payload: payload, NextRun: nextRun}, history: history}
+		if err = j.write(); err != nil {
+			return nil, err
+		} else if err = st.jobs.Put([]byte(name), objRef); err != nil {
+			return nil, err
+		}
+		return nil, st.due.Put(j.dueKey(), objRef)
+	})
+	return err
+}
+
+// Remove the job with the given name, and its history, returning
+// whether there was one. A claim of a run of the job can then be
+// neither renewed nor completed.
+func (s *Scheduler) Remove(name string) (bool, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.job(s.Conn, name)
+		if err != nil || j == nil {
+			return false, err
+		}
+		if err = st.due.Remove(j.dueKey()); err != nil {
+			return nil, err
+		}
+		return true, st.jobs.Remove([]byte(name))
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the job with the given name, or nil if there is none.
+func (s *Scheduler) Get(name string) (*Job, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.job(s.Conn, name)
+		if err != nil || j == nil {
+			return (*Job)(nil), err
+		}
+		return newJob(name, j.j), nil
+	})
+	if err == nil {
+		return res.(*Job), nil
+	} else {
+		return nil, err
+	}
+}
+
+// errFound stops a Range once Claim has its job.
+var errFound = errors.New("Found")
+
+// Claim the run of a job which is due by now on behalf of owner, which
+// should uniquely identify the worker, under a lease which expires
+// after lease. Returns nil if no run is due. A run is due if its time
+// has come and it is not claimed, or if its claim has expired, in
+// which case it is claimed again, and the earlier claim can no longer
+// be renewed or completed. The run with the earliest such time is
+// claimed. The worker should run the job, renewing the claim as
+// needed, and then Complete it.
+func (s *Scheduler) Claim(owner []byte, now time.Time, lease time.Duration) (*Claim, error) {
+	if len(owner) == 0 {
+		return nil, errors.New("A Scheduler owner must not be empty")
+	} else if lease <= 0 {
+		return nil, errors.New("A Scheduler lease must be positive")
+	}
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var key []byte
+		var objRef client.ObjectRef
+		// every key due by now is before this.
+		hi := ordered.Exclusive(ordenc.AppendInt64(nil, now.UnixNano()+1))
+		err = st.due.Range(ordered.Bound{}, hi, false, func(k []byte, v client.ObjectRef) error {
+			key, objRef = k, v
+			return errFound
+		})
+		if err != errFound {
+			return (*Claim)(nil), err
+		}
+		_, name, err := ordenc.DecodeInt64(key)
+		if err != nil {
+			return nil, err
+		}
+		j, err := readJob(s.Conn, string(name), objRef)
+		if err != nil {
+			return nil, err
+		}
+		j.j.Owner, j.j.Expiry = owner, now.Add(lease).UnixNano()
+		j.j.Token++
+		if err = st.update(j, key); err != nil {
+			return nil, err
+		}
+		return &Claim{
+			Name:      j.name,
+			Payload:   j.j.Payload,
+			Scheduled: time.Unix(0, j.j.NextRun),
+			Claimed:   now,
+			Owner:     owner,
+			Token:     j.j.Token,
+			Expiry:    time.Unix(0, j.j.Expiry),
+		}, nil
+	})
+	if err == nil {
+		return res.(*Claim), nil
+	} else {
+		return nil, err
+	}
+}
+
+// claimed returns the job of the given claim, or ErrNotFound or
+// ErrLeaseLost if the claim is no longer current. A claim which has
+// expired, but which has not been claimed again, is still current.
+func (st *state) claimed(conn *client.Connection, c *Claim) (*job, error) {
+	j, err := st.job(conn, c.Name)
+	if err != nil {
+		return nil, err
+	} else if j == nil {
+		return nil, ErrNotFound
+	} else if j.j.Token != c.Token || !bytes.Equal(j.j.Owner, c.Owner) {
+		return nil, ErrLeaseLost
+	}
+	return j, nil
+}
+
+// Extend the lease of the claim to expire after lease from now.
+// Returns ErrLeaseLost if the run has been claimed again, and
+// ErrNotFound if the job has been removed.
+func (s *Scheduler) Renew(c *Claim, now time.Time, lease time.Duration) error {
+	if lease <= 0 {
+		return errors.New("A Scheduler lease must be positive")
+	}
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.claimed(s.Conn, c)
+		if err != nil {
+			return nil, err
+		}
+		oldKey := j.dueKey()
+		j.j.Expiry = now.Add(lease).UnixNano()
+		return time.Unix(0, j.j.Expiry), st.update(j, oldKey)
+	})
+	if err == nil {
+		c.Expiry = res.(time.Time)
+	}
+	return err
+}
+
+// Complete the run of the claim, at now, recording it in the history
+// of the job, with the error the run failed with, if runErr is not
+// nil. The job then next runs when its schedule first runs after now.
+// Returns ErrLeaseLost if the run has been claimed again, and
+// ErrNotFound if the job has been removed, in which case nothing is
+// recorded.
+func (s *Scheduler) Complete(c *Claim, now time.Time, runErr error) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.claimed(s.Conn, c)
+		if err != nil {
+			return nil, err
+		}
+		run := &mp.Run{
+			Scheduled: j.j.NextRun,
+			Claimed:   c.Claimed.UnixNano(),
+			Finished:  now.UnixNano(),
+			Owner:     c.Owner,
+			Token:     c.Token,
+		}
+		if runErr != nil {
+			run.Error = runErr.Error()
+		}
+		value, err := run.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = j.history.Append(value); err != nil {
+			return nil, err
+		}
+		oldKey := j.dueKey()
+		if j.j.NextRun, err = next(j.j.Schedule, now); err != nil {
+			return nil, err
+		}
+		j.j.Owner, j.j.Expiry = nil, 0
+		return nil, st.update(j, oldKey)
+	})
+	return err
+}
+
+// Returns up to n of the most recent Runs of the job with the given
+// name, newest first, or ErrNotFound if there is no such job.
+func (s *Scheduler) History(name string, n int) ([]Run, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		st, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		j, err := st.job(s.Conn, name)
+		if err != nil {
+			return nil, err
+		} else if j == nil {
+			return nil, ErrNotFound
+		}
+		values, err := j.history.Latest(n)
+		if err != nil {
+			return nil, err
+		}
+		runs := make([]Run, len(values))
+		for idx, value := range values {
+			run := new(mp.Run)
+			if _, err = run.UnmarshalMsg(value); err != nil {
+				return nil, err
+			}
+			runs[idx] = Run{
+				Scheduled: time.Unix(0, run.Scheduled),
+				Claimed:   time.Unix(0, run.Claimed),
+				Finished:  time.Unix(0, run.Finished),
+				Owner:     run.Owner,
+				Token:     run.Token,
+				Error:     run.Error,
+			}
+		}
+		return runs, nil
+	})
+	if err == nil {
+		return res.([]Run), nil
+	} else {
+		return nil, err
+	}
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"errors"
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// 2021-03-15 was a Monday.
+	after := time.Date(2021, 3, 15, 10, 7, 30, 0, time.UTC)
+	for _, c := range []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"@every 90s", after.Add(90 * time.Second)},
+		{"* * * * *", time.Date(2021, 3, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2021, 3, 15, 11, 5, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2021, 3, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 6 * * 6,7", time.Date(2021, 3, 20, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either the 1st or a Friday.
+		{"0 0 1 * 5", time.Date(2021, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		schedule, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := schedule.Next(after); !next.Equal(c.expected) {
+			t.Fatalf("Schedule %q: expected %v. Got %v", c.spec, c.expected, next)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every -1s", "@every soon"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("Expected an error for schedule %q", spec)
+		}
+	}
+}
+
+func TestClaimComplete(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	s, err := NewEmptyScheduler(c0.Connection, 2)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = s.Put("never", "0 0 30 2 *", nil); err == nil {
+		th.Fatal("Expected an error for a schedule which never runs")
+	}
+	if err = s.Put("report", "@every 1h", []byte("r")); err != nil {
+		th.Fatal(err)
+	} else if err = s.Put("cleanup", "@every 2h", []byte("c")); err != nil {
+		th.Fatal(err)
+	}
+	now := time.Now()
+	if c, err := s.Claim([]byte("w1"), now, time.Minute); err != nil || c != nil {
+		th.Fatalf("Expected nothing due. Got %v, %v", c, err)
+	}
+
+	// only report is due.
+	now = now.Add(90 * time.Minute)
+	c1, err := s.Claim([]byte("w1"), now, time.Minute)
+	if err != nil {
+		th.Fatal(err)
+	} else if c1 == nil || c1.Name != "report" || string(c1.Payload) != "r" {
+		th.Fatalf("Expected a claim of report. Got %v", c1)
+	}
+	if c, err := s.Claim([]byte("w2"), now, time.Minute); err != nil || c != nil {
+		th.Fatalf("Expected nothing due. Got %v, %v", c, err)
+	}
+	if err = s.Renew(c1, now, 10*time.Minute); err != nil {
+		th.Fatal(err)
+	}
+	job, err := s.Get("report")
+	if err != nil {
+		th.Fatal(err)
+	} else if string(job.Owner) != "w1" || !job.Expiry.Equal(c1.Expiry) {
+		th.Fatalf("Expected report claimed by w1 until %v. Got %v", c1.Expiry, job)
+	}
+
+	// w1 stalls: once its lease expires, w2 claims the run again.
+	now = now.Add(15 * time.Minute)
+	c2, err := s.Claim([]byte("w2"), now, time.Minute)
+	if err != nil {
+		th.Fatal(err)
+	} else if c2 == nil || c2.Name != "report" || c2.Token <= c1.Token || !c2.Scheduled.Equal(c1.Scheduled) {
+		th.Fatalf("Expected a fresh claim of the same run of report. Got %v", c2)
+	}
+	if err = s.Complete(c1, now, nil); err != ErrLeaseLost {
+		th.Fatalf("Expected ErrLeaseLost. Got %v", err)
+	}
+	if err = s.Complete(c2, now, errors.New("disk full")); err != nil {
+		th.Fatal(err)
+	}
+	if job, err = s.Get("report"); err != nil {
+		th.Fatal(err)
+	} else if job.Owner != nil || !job.NextRun.Equal(now.Add(time.Hour)) {
+		th.Fatalf("Expected report unclaimed and next due at %v. Got %v", now.Add(time.Hour), job)
+	}
+
+	// now cleanup is due, and then report again.
+	now = now.Add(time.Hour)
+	for _, name := range []string{"cleanup", "report"} {
+		c, err := s.Claim([]byte("w1"), now, time.Minute)
+		if err != nil {
+			th.Fatal(err)
+		} else if c == nil || c.Name != name {
+			th.Fatalf("Expected a claim of %v. Got %v", name, c)
+		}
+		if err = s.Complete(c, now, nil); err != nil {
+			th.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+	runs, err := s.History("report", 10)
+	if err != nil {
+		th.Fatal(err)
+	} else if len(runs) != 2 || runs[0].Error != "" || runs[1].Error != "disk full" || string(runs[1].Owner) != "w2" {
+		th.Fatalf("Expected a successful run after the failed run. Got %v", runs)
+	}
+	if runs, err = s.History("cleanup", 10); err != nil {
+		th.Fatal(err)
+	} else if len(runs) != 1 {
+		th.Fatalf("Expected 1 run. Got %v", runs)
+	}
+
+	// replacing a job keeps its history.
+	if err = s.Put("report", "@every 24h", []byte("r2")); err != nil {
+		th.Fatal(err)
+	} else if job, err = s.Get("report"); err != nil || job.Schedule != "@every 24h" || string(job.Payload) != "r2" {
+		th.Fatalf("Expected the new report. Got %v, %v", job, err)
+	}
+	if runs, err = s.History("report", 10); err != nil || len(runs) != 2 {
+		th.Fatalf("Expected 2 runs. Got %v, %v", runs, err)
+	}
+	if removed, err := s.Remove("report"); err != nil || !removed {
+		th.Fatalf("Expected to remove report. Got %v, %v", removed, err)
+	}
+	if _, err = s.History("report", 10); err != ErrNotFound {
+		th.Fatalf("Expected ErrNotFound. Got %v", err)
+	}
+	if c, err := s.Claim([]byte("w1"), now.Add(48*time.Hour), time.Minute); err != nil || c == nil || c.Name != "cleanup" {
+		th.Fatalf("Expected a claim of cleanup. Got %v, %v", c, err)
+	}
+}
@@ -0,0 +1,9 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of a Sequence Object.
+type Root struct {
+	// The next ID to be handed out.
+	Next uint64
+}
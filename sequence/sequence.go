@@ -0,0 +1,147 @@
+// A Sequence hands out unique IDs, in increasing order, starting from
+// 0. Each ID handed out by Next is greater than every ID handed out
+// before it. As every Next writes the same Object, concurrent callers
+// are serialised; for high throughput, an Allocator leases a block of
+// IDs at a time and hands them out without further transactions.
+package sequence
+
+import (
+	"errors"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/sequence/msgpack"
+	"math"
+	"sync"
+)
+
+// ErrExhausted is returned if there are not enough IDs left in the
+// Sequence.
+var ErrExhausted = errors.New("Sequence exhausted")
+
+var errBlockSize = errors.New("A Sequence block must hold at least one ID")
+
+type Sequence struct {
+	// The connection used to create this Sequence object. As with
+	// LHash, you should not use the same Sequence object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the state of the
+	// Sequence.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new Sequence, the first ID of which will be 0. This
+// creates a new GoshawkDB Object and initialises it for use as a
+// Sequence.
+func NewEmptySequence(conn *client.Connection) (*Sequence, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Root{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Sequence{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*Sequence), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Sequence object from an existing given GoshawkDB Object.
+// As with LHashFromObj, no initialisation is done.
+func SequenceFromObj(conn *client.Connection, objRef client.ObjectRef) *Sequence {
+	return &Sequence{Conn: conn, ObjRef: objRef}
+}
+
+// Returns the next ID.
+func (s *Sequence) Next() (uint64, error) {
+	return s.NextBlock(1)
+}
+
+// Lease a block of n consecutive IDs, returning the first of them.
+// The caller may then use every ID from the first up to but not
+// including first+n. The IDs are greater than every ID leased before.
+// If called within a transaction, the IDs are only leased if that
+// transaction commits.
+func (s *Sequence) NextBlock(n int) (uint64, error) {
+	if n < 1 {
+		return 0, errBlockSize
+	}
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(s.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		value, err := obj.Value()
+		if err != nil {
+			return nil, err
+		}
+		root := new(mp.Root)
+		if _, err = root.UnmarshalMsg(value); err != nil {
+			return nil, err
+		}
+		first := root.Next
+		if first > math.MaxUint64-uint64(n) {
+			return nil, ErrExhausted
+		}
+		root.Next += uint64(n)
+		if value, err = root.MarshalMsg(nil); err != nil {
+			return nil, err
+		}
+		return first, obj.Set(value)
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// An Allocator hands out IDs from a Sequence, leasing a block of them
+// at a time, so that only one in every block of calls to Next runs a
+// transaction. The IDs handed out are unique across every Allocator
+// and caller of the Sequence, and increasing from any one Allocator,
+// but not across Allocators: an Allocator may still be handing out
+// its block after another has leased a later one. IDs left in a block
+// when an Allocator is discarded are never handed out.
+//
+// An Allocator may be shared between goroutines. It must not be used
+// within a transaction: were the transaction to abort, the lease would
+// be undone but the IDs of the block might still be handed out.
+type Allocator struct {
+	seq       *Sequence
+	blockSize int
+	lock      sync.Mutex
+	next      uint64
+	remaining int
+}
+
+// Create an Allocator leasing blocks of blockSize IDs from seq.
+func NewAllocator(seq *Sequence, blockSize int) (*Allocator, error) {
+	if blockSize < 1 {
+		return nil, errBlockSize
+	}
+	return &Allocator{seq: seq, blockSize: blockSize}, nil
+}
+
+// Returns the next ID, leasing a new block if the current one is used
+// up.
+func (a *Allocator) Next() (uint64, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.remaining == 0 {
+		first, err := a.seq.NextBlock(a.blockSize)
+		if err != nil {
+			return 0, err
+		}
+		a.next, a.remaining = first, a.blockSize
+	}
+	id := a.next
+	a.next++
+	a.remaining--
+	return id, nil
+}
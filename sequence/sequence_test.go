@@ -0,0 +1,89 @@
+package sequence
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *Sequence {
+	c0 := th.CreateConnections(1)[0]
+	s, err := NewEmptySequence(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return s
+}
+
+func TestNext(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	s := createEmpty(th)
+	for expected := uint64(0); expected < 5; expected++ {
+		if id, err := s.Next(); err != nil || id != expected {
+			th.Fatalf("Expected ID %v. Got %v %v", expected, id, err)
+		}
+	}
+	if first, err := s.NextBlock(10); err != nil || first != 5 {
+		th.Fatalf("Expected block from 5. Got %v %v", first, err)
+	}
+	if id, err := s.Next(); err != nil || id != 15 {
+		th.Fatalf("Expected ID 15. Got %v %v", id, err)
+	}
+	if _, err := s.NextBlock(0); err == nil {
+		th.Fatal("Expected an error leasing an empty block")
+	}
+}
+
+func TestAllocator(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conns := th.CreateConnections(3)
+	s, err := NewEmptySequence(conns[0].Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	ids := make(chan uint64, 300)
+	errs := make(chan error, len(conns))
+	for _, conn := range conns {
+		a, err := NewAllocator(SequenceFromObj(conn.Connection, s.ObjRef), 7)
+		if err != nil {
+			th.Fatal(err)
+		}
+		go func() {
+			last := int64(-1)
+			for idx := 0; idx < 100; idx++ {
+				id, err := a.Next()
+				if err != nil {
+					errs <- err
+					return
+				} else if int64(id) <= last {
+					errs <- fmt.Errorf("IDs from an Allocator went backwards: %v after %v", id, last)
+					return
+				}
+				last = int64(id)
+				ids <- id
+			}
+			errs <- nil
+		}()
+	}
+	for range conns {
+		if err := <-errs; err != nil {
+			th.Fatal(err)
+		}
+	}
+	close(ids)
+	seen := make(map[uint64]bool)
+	for id := range ids {
+		if seen[id] {
+			th.Fatalf("ID %v handed out twice", id)
+		}
+		seen[id] = true
+	}
+	// 100 IDs from each Allocator is 15 blocks of 7 each.
+	if id, err := s.Next(); err != nil || id != uint64(len(conns)*15*7) {
+		th.Fatalf("Expected ID %v. Got %v %v", len(conns)*15*7, id, err)
+	}
+}
@@ -0,0 +1,19 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a SkipList. Its single
+// reference is to the head node, which holds no entry.
+type Root struct {
+	// The number of entries in the list.
+	Size int64
+}
+
+// Node is the value of a node Object. The first reference of a node is
+// the value Object of its key, and the rest are to the next node at
+// each level of the list, lowest first, with a reference to the node
+// itself at the end of a level. The head node has no key, and its
+// first reference is to itself.
+type Node struct {
+	Key []byte
+}
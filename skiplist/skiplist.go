@@ -0,0 +1,450 @@
+// A SkipList is an ordered map from keys to GoshawkDB Objects, with
+// keys ordered by bytes.Compare, and with the same API as BTree: both
+// are ordered.OrderedMaps. Each entry is a node Object of its own,
+// linked to the next node at each of a random number of levels. A
+// lookup reads about 4*log4(n) nodes, many more than a BTree reads,
+// but adding or removing an entry writes only the node's immediate
+// predecessors (about 1.33 nodes on average) whatever the size of the
+// list, rather than a whole path of the tree, so concurrent writers
+// of different keys rarely conflict over anything but the root. This
+// suits write-heavy workloads.
+//
+// The nodes are only linked forwards, so iterating in reverse key
+// order reads the range forwards and then supplies it backwards.
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/ordered"
+	mp "goshawkdb.io/collections/skiplist/msgpack"
+	"math/rand"
+)
+
+// The maximum number of levels of a SkipList. Each level links a
+// quarter of the nodes of the level below, so this is plenty.
+const maxLevel = 32
+
+type SkipList struct {
+	// The connection used to create this SkipList object. As with
+	// LHash, you should not use the same SkipList object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the SkipList.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty SkipList. This creates a new GoshawkDB
+// Object and initialises it for use as a SkipList.
+func NewEmptySkipList(conn *client.Connection) (*SkipList, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := (&mp.Node{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		head, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		// the head has a single, empty, level.
+		if err = head.Set(value, head, head); err != nil {
+			return nil, err
+		}
+		if value, err = (&mp.Root{}).MarshalMsg(nil); err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, head)
+		if err != nil {
+			return nil, err
+		}
+		return &SkipList{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*SkipList), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a SkipList object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func SkipListFromObj(conn *client.Connection, objRef client.ObjectRef) *SkipList {
+	return &SkipList{Conn: conn, ObjRef: objRef}
+}
+
+// list is the state of the SkipList within a single transaction.
+type list struct {
+	txn    *client.Txn
+	objRef client.ObjectRef
+	root   *mp.Root
+	head   *node
+}
+
+func (s *SkipList) read(txn *client.Txn) (*list, error) {
+	obj, err := txn.GetObject(s.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 {
+		return nil, fmt.Errorf("SkipList root %v is corrupt", obj)
+	}
+	head, err := readNode(refs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &list{txn: txn, objRef: obj, root: root, head: head}, nil
+}
+
+func (l *list) write() error {
+	value, err := l.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return l.objRef.Set(value, l.head.objRef)
+}
+
+// seek returns, for each level of the list, the last node at that
+// level whose key satisfies before, or the head if there is none. The
+// keys satisfying before must precede all the others.
+func (l *list) seek(before func(key []byte) bool) ([]*node, error) {
+	update := make([]*node, l.head.height())
+	x := l.head
+	for level := len(update) - 1; level >= 0; level-- {
+		for {
+			next, err := x.next(level)
+			if err != nil {
+				return nil, err
+			} else if next == nil || !before(next.key) {
+				break
+			}
+			x = next
+		}
+		update[level] = x
+	}
+	return update, nil
+}
+
+// seekBelow is seek for the keys less than key.
+func (l *list) seekBelow(key []byte) ([]*node, error) {
+	return l.seek(func(k []byte) bool { return bytes.Compare(k, key) < 0 })
+}
+
+// Returns the number of entries in the SkipList.
+func (s *SkipList) Size() (int64, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return l.root.Size, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Search the SkipList for the given key, returning the value Object if
+// found, or nil if not.
+func (s *SkipList) Find(key []byte) (*client.ObjectRef, error) {
+	res, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		update, err := l.seekBelow(key)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := update[0].next(0); err != nil {
+			return nil, err
+		} else if n != nil && bytes.Equal(n.key, key) {
+			return &n.refs[0], nil
+		}
+		return (*client.ObjectRef)(nil), nil
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Idempotently add the given key and value to the SkipList. If the key
+// is already present, its value is updated. If the key already maps
+// to the same Object, nothing is written.
+func (s *SkipList) Put(key []byte, value client.ObjectRef) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		update, err := l.seekBelow(key)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := update[0].next(0); err != nil {
+			return nil, err
+		} else if n != nil && bytes.Equal(n.key, key) {
+			if n.refs[0].ReferencesSameAs(value) {
+				return nil, nil
+			}
+			n.refs[0] = value
+			return nil, n.write()
+		}
+
+		height := randomHeight()
+		for l.head.height() < height {
+			// the head gains an empty level.
+			l.head.refs = append(l.head.refs, l.head.objRef)
+			update = append(update, l.head)
+		}
+		// the node must exist before it can refer to itself.
+		objRef, err := txn.CreateObject([]byte{})
+		if err != nil {
+			return nil, err
+		}
+		n := &node{objRef: objRef, key: key, refs: make([]client.ObjectRef, height+1)}
+		n.refs[0] = value
+		for level, prev := range update[:height] {
+			n.refs[level+1] = prev.refs[level+1]
+			if prev.refs[level+1].ReferencesSameAs(prev.objRef) {
+				n.refs[level+1] = n.objRef
+			}
+			prev.refs[level+1] = n.objRef
+		}
+		if err = n.write(); err != nil {
+			return nil, err
+		} else if err = writeAll(update[:height]); err != nil {
+			return nil, err
+		}
+		l.root.Size++
+		return nil, l.write()
+	})
+	return err
+}
+
+// Idempotently remove any entry for the given key.
+func (s *SkipList) Remove(key []byte) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		update, err := l.seekBelow(key)
+		if err != nil {
+			return nil, err
+		}
+		n, err := update[0].next(0)
+		if err != nil || n == nil || !bytes.Equal(n.key, key) {
+			return nil, err
+		}
+		for level, prev := range update[:n.height()] {
+			prev.refs[level+1] = n.refs[level+1]
+			if n.refs[level+1].ReferencesSameAs(n.objRef) {
+				prev.refs[level+1] = prev.objRef
+			}
+		}
+		if err = writeAll(update[:n.height()]); err != nil {
+			return nil, err
+		}
+		l.root.Size--
+		return nil, l.write()
+	})
+	return err
+}
+
+// Iterate over the entries in the SkipList, in key order. As with
+// LHash.ForEach, the iteration is done within a single transaction,
+// which may restart, in which case entries may be supplied again. An
+// error returned by f stops the iteration and is returned.
+func (s *SkipList) ForEach(f func(key []byte, value client.ObjectRef) error) error {
+	return s.Range(ordered.Bound{}, ordered.Bound{}, false, f)
+}
+
+// Iterate over the entries in the SkipList with keys between from and
+// to, in key order, or in reverse key order if reverse is true. from
+// is always the lower bound and to the upper bound, whatever the
+// direction. In reverse, the entries of the whole range are read
+// before f is first called. Otherwise, as for ForEach.
+func (s *SkipList) Range(from, to ordered.Bound, reverse bool, f func(key []byte, value client.ObjectRef) error) error {
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		update, err := l.seek(func(k []byte) bool { return !from.After(k) })
+		if err != nil {
+			return nil, err
+		}
+		var nodes []*node
+		n, err := update[0].next(0)
+		for ; err == nil && n != nil && to.Before(n.key); n, err = n.next(0) {
+			if reverse {
+				nodes = append(nodes, n)
+			} else if err = f(n.key, n.refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		for idx := len(nodes) - 1; idx >= 0; idx-- {
+			if err = f(nodes[idx].key, nodes[idx].refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Return the entry with the lowest key in the SkipList, or a nil key
+// and value if the SkipList is empty.
+func (s *SkipList) First() ([]byte, *client.ObjectRef, error) {
+	return s.nearest(func(l *list) (*node, error) {
+		return l.head.next(0)
+	})
+}
+
+// Return the entry with the highest key in the SkipList, or a nil key
+// and value if the SkipList is empty.
+func (s *SkipList) Last() ([]byte, *client.ObjectRef, error) {
+	return s.nearest(func(l *list) (*node, error) {
+		update, err := l.seek(func(k []byte) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		return update[0], nil
+	})
+}
+
+// Return the entry with the highest key which is not greater than
+// key, or a nil key and value if there is none. For example, with
+// keys prefixed by a time, the Floor of a time is the latest entry at
+// or before it.
+func (s *SkipList) Floor(key []byte) ([]byte, *client.ObjectRef, error) {
+	return s.nearest(func(l *list) (*node, error) {
+		update, err := l.seek(func(k []byte) bool { return bytes.Compare(k, key) <= 0 })
+		if err != nil {
+			return nil, err
+		}
+		return update[0], nil
+	})
+}
+
+// Return the entry with the lowest key which is not less than key, or
+// a nil key and value if there is none.
+func (s *SkipList) Ceiling(key []byte) ([]byte, *client.ObjectRef, error) {
+	return s.nearest(func(l *list) (*node, error) {
+		update, err := l.seekBelow(key)
+		if err != nil {
+			return nil, err
+		}
+		return update[0].next(0)
+	})
+}
+
+// nearest returns the entry of the node found by find, which returns
+// nil or the head if there is no such entry.
+func (s *SkipList) nearest(find func(l *list) (*node, error)) ([]byte, *client.ObjectRef, error) {
+	var key []byte
+	var value *client.ObjectRef
+	_, _, err := s.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		key, value = nil, nil
+		l, err := s.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		n, err := find(l)
+		if err != nil {
+			return nil, err
+		} else if n != nil && n != l.head {
+			key, value = n.key, &n.refs[0]
+		}
+		return nil, nil
+	})
+	if err == nil {
+		return key, value, nil
+	} else {
+		return nil, nil, err
+	}
+}
+
+// randomHeight returns the number of levels of a new node.
+func randomHeight() int {
+	height := 1
+	for height < maxLevel && rand.Intn(4) == 0 {
+		height++
+	}
+	return height
+}
+
+// A node is an entry of the list, or its head. refs[0] is the value of
+// the entry, and refs[level+1] the next node at each level.
+type node struct {
+	objRef client.ObjectRef
+	key    []byte
+	refs   []client.ObjectRef
+}
+
+func readNode(objRef client.ObjectRef) (*node, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	decoded := new(mp.Node)
+	if _, err = decoded.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) < 2 {
+		return nil, fmt.Errorf("SkipList node %v is corrupt: %v references", objRef, len(refs))
+	}
+	return &node{objRef: objRef, key: decoded.Key, refs: refs}, nil
+}
+
+func (n *node) write() error {
+	value, err := (&mp.Node{Key: n.key}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return n.objRef.Set(value, n.refs...)
+}
+
+// height returns the number of levels the node is linked into.
+func (n *node) height() int {
+	return len(n.refs) - 1
+}
+
+// next returns the next node at the given level, or nil at the end of
+// the level.
+func (n *node) next(level int) (*node, error) {
+	if ref := n.refs[level+1]; ref.ReferencesSameAs(n.objRef) {
+		return nil, nil
+	} else {
+		return readNode(ref)
+	}
+}
+
+// writeAll writes the nodes of update, each of which may be repeated
+// at consecutive levels, once each.
+func writeAll(update []*node) error {
+	for idx, n := range update {
+		if idx > 0 && update[idx-1] == n {
+			continue
+		}
+		if err := n.write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/ordered"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+var _ ordered.OrderedMap = (*SkipList)(nil)
+
+func createEmpty(th *tests.TestHelper) *SkipList {
+	c0 := th.CreateConnections(1)[0]
+	s, err := NewEmptySkipList(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return s
+}
+
+func assertSize(th *tests.TestHelper, s *SkipList, expected int64) {
+	size, err := s.Size()
+	if err != nil {
+		th.Fatal(err)
+	} else if size != expected {
+		th.Fatalf("Expected size %v. Got %v", expected, size)
+	}
+}
+
+// assertKeys checks that ForEach supplies exactly the given keys, in
+// order.
+func assertKeys(th *tests.TestHelper, s *SkipList, expected map[int]bool) {
+	var last []byte
+	count := 0
+	if err := s.ForEach(func(key []byte, value client.ObjectRef) error {
+		if last != nil && bytes.Compare(last, key) >= 0 {
+			return fmt.Errorf("Key %s follows %s", key, last)
+		}
+		var idx int
+		if _, err := fmt.Sscanf(string(key), "key%04d", &idx); err != nil || !expected[idx] {
+			return fmt.Errorf("Unexpected key %s", key)
+		}
+		last = key
+		count++
+		return nil
+	}); err != nil {
+		th.Fatal(err)
+	} else if count != len(expected) {
+		th.Fatalf("Expected %v keys. Got %v", len(expected), count)
+	}
+}
+
+func TestPutFindRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	sl := createEmpty(th)
+	rng := rand.New(rand.NewSource(0))
+	present := make(map[int]bool)
+	values := make(map[int]client.ObjectRef)
+	for _, idx := range rng.Perm(300) {
+		res, _, err := sl.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			return txn.CreateObject([]byte(fmt.Sprintf("value%v", idx)))
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+		values[idx] = res.(client.ObjectRef)
+		if err = sl.Put([]byte(fmt.Sprintf("key%04d", idx)), values[idx]); err != nil {
+			th.Fatal(err)
+		}
+		present[idx] = true
+	}
+	assertSize(th, sl, 300)
+	assertKeys(th, sl, present)
+
+	for _, idx := range []int{0, 150, 299} {
+		if value, err := sl.Find([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		} else if value == nil || !value.ReferencesSameAs(values[idx]) {
+			th.Fatalf("Expected to find value of key %v. Got %v", idx, value)
+		}
+	}
+	if value, err := sl.Find([]byte("missing")); err != nil || value != nil {
+		th.Fatalf("Expected not to find missing key. Got %v %v", value, err)
+	}
+
+	// updating a value does not add an entry.
+	if err := sl.Put([]byte("key0000"), values[1]); err != nil {
+		th.Fatal(err)
+	} else if value, err := sl.Find([]byte("key0000")); err != nil || value == nil || !value.ReferencesSameAs(values[1]) {
+		th.Fatalf("Expected the updated value. Got %v %v", value, err)
+	}
+	assertSize(th, sl, 300)
+
+	for _, idx := range rng.Perm(300)[:250] {
+		if err := sl.Remove([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		}
+		delete(present, idx)
+	}
+	if err := sl.Remove([]byte("missing")); err != nil {
+		th.Fatal(err)
+	}
+	assertSize(th, sl, 50)
+	assertKeys(th, sl, present)
+
+	for idx := range present {
+		if err := sl.Remove([]byte(fmt.Sprintf("key%04d", idx))); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertSize(th, sl, 0)
+	assertKeys(th, sl, nil)
+}
+
+func TestRange(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	sl := createEmpty(th)
+	if key, value, err := sl.Last(); err != nil || key != nil || value != nil {
+		th.Fatalf("Expected no last entry of an empty SkipList. Got %q %v %v", key, value, err)
+	}
+	// the keys are the even numbers below 100.
+	for idx := 0; idx < 100; idx += 2 {
+		if err := sl.Put([]byte(fmt.Sprintf("key%04d", idx)), sl.ObjRef); err != nil {
+			th.Fatal(err)
+		}
+	}
+
+	key := func(idx int) []byte { return []byte(fmt.Sprintf("key%04d", idx)) }
+	check := func(from, to ordered.Bound, reverse bool, expected ...int) {
+		var got []int
+		if err := sl.Range(from, to, reverse, func(key []byte, value client.ObjectRef) error {
+			var idx int
+			if _, err := fmt.Sscanf(string(key), "key%04d", &idx); err != nil {
+				return err
+			}
+			got = append(got, idx)
+			return nil
+		}); err != nil {
+			th.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			th.Fatalf("Range(%q, %q, %v): expected %v. Got %v", from.Key, to.Key, reverse, expected, got)
+		}
+	}
+	check(ordered.Inclusive(key(10)), ordered.Inclusive(key(20)), false, 10, 12, 14, 16, 18, 20)
+	check(ordered.Exclusive(key(10)), ordered.Exclusive(key(20)), false, 12, 14, 16, 18)
+	check(ordered.Inclusive(key(10)), ordered.Exclusive(key(20)), true, 18, 16, 14, 12, 10)
+	check(ordered.Bound{}, ordered.Exclusive(key(6)), false, 0, 2, 4)
+	check(ordered.Exclusive(key(92)), ordered.Bound{}, true, 98, 96, 94)
+	check(ordered.Inclusive(key(20)), ordered.Inclusive(key(10)), false)
+
+	nearest := func(name string, k []byte, value *client.ObjectRef, err error, expected string) {
+		if err != nil {
+			th.Fatal(err)
+		} else if string(k) != expected || (k == nil) != (value == nil) {
+			th.Fatalf("%v: expected %q. Got %q %v", name, expected, k, value)
+		}
+	}
+	k, v, err := sl.First()
+	nearest("First", k, v, err, "key0000")
+	k, v, err = sl.Last()
+	nearest("Last", k, v, err, "key0098")
+	k, v, err = sl.Floor(key(51))
+	nearest("Floor", k, v, err, "key0050")
+	k, v, err = sl.Floor([]byte("key"))
+	nearest("Floor below first", k, v, err, "")
+	k, v, err = sl.Ceiling(key(51))
+	nearest("Ceiling", k, v, err, "key0052")
+	k, v, err = sl.Ceiling(key(98))
+	nearest("Ceiling exact", k, v, err, "key0098")
+	k, v, err = sl.Ceiling(key(99))
+	nearest("Ceiling above last", k, v, err, "")
+}
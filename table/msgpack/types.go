@@ -0,0 +1,15 @@
+package msgpack
+
+//go:generate msgp
+
+// TableRoot is the value of the root Object of a Table. The
+// references of the root Object are the primary LHash root, followed
+// by the roots of the indexes in the same order as Indexes.
+type TableRoot struct {
+	Indexes []IndexRecord
+}
+
+type IndexRecord struct {
+	Name   string
+	Unique bool
+}
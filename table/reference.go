@@ -0,0 +1,121 @@
+package table
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// An OnDelete determines what deleting a row does to the rows which
+// reference it.
+type OnDelete int
+
+const (
+	// Fail the delete with ErrReferenced.
+	Restrict OnDelete = iota
+	// Delete the referencing rows too.
+	Cascade
+)
+
+var (
+	// ErrReferenced is returned by Delete if the row is referenced by
+	// rows of a Table with a Restrict Reference to it.
+	ErrReferenced = errors.New("Row is referenced by rows of another Table")
+	// ErrMissingReference is returned by Insert and Update if the row
+	// references a row which does not exist.
+	ErrMissingReference = errors.New("Referenced row does not exist")
+)
+
+// A Reference declares that rows of the From Table reference rows of
+// the To Table: the index of From with the given name derives, from
+// each row of From, the keys of the rows of To which it references.
+type Reference struct {
+	From     *Table
+	Index    string
+	To       *Table
+	OnDelete OnDelete
+}
+
+// cascade is the rows of a Table to be deleted along with a row they
+// reference.
+type cascade struct {
+	from *Table
+	keys [][]byte
+}
+
+// Declare that the rows of t reference rows of target, which may be t
+// itself: the index of t with the given name derives, from each row,
+// the keys of the rows of target which it references. Thereafter,
+// within the same transaction as each modification, inserting or
+// updating a row of t which references a row of target which does not
+// exist fails with ErrMissingReference, and deleting a row of target
+// which is referenced by rows of t either deletes them too, if
+// onDelete is Cascade, or fails with ErrReferenced, if it is Restrict.
+// Rows already in t are not checked. As with IndexDefs, References are
+// not stored in GoshawkDB: every user of the Tables must declare the
+// same References, between Table objects of the same connection.
+func (t *Table) AddReference(indexName string, target *Table, onDelete OnDelete) error {
+	if _, found := t.indexes[indexName]; !found {
+		return fmt.Errorf("Table has no index named %v", indexName)
+	} else if target.Conn != t.Conn {
+		return errors.New("Referenced Table must be of the same connection")
+	} else if onDelete != Restrict && onDelete != Cascade {
+		return fmt.Errorf("Unknown OnDelete %v", onDelete)
+	}
+	ref := &Reference{From: t, Index: indexName, To: target, OnDelete: onDelete}
+	t.references = append(t.references, ref)
+	target.referrers = append(target.referrers, ref)
+	return nil
+}
+
+// checkReferences returns ErrMissingReference if the row with the
+// given key and value references a row which does not exist. A row
+// may reference itself.
+func (t *Table) checkReferences(key, value []byte) error {
+	for _, ref := range t.references {
+		derived, err := t.indexes[ref.Index].Derive(key, value)
+		if err != nil {
+			return err
+		}
+		for _, d := range derived {
+			if ref.To == t && bytes.Equal(d, key) {
+				continue
+			}
+			objRef, err := ref.To.Primary.Find(d)
+			if err != nil {
+				return err
+			} else if objRef == nil {
+				return ErrMissingReference
+			}
+		}
+	}
+	return nil
+}
+
+// referencing returns the rows to delete along with the row with the
+// given key, or ErrReferenced if a row references it which restricts
+// its deletion. A row referencing itself is ignored.
+func (t *Table) referencing(key []byte) ([]cascade, error) {
+	var cascades []cascade
+	for _, ref := range t.referrers {
+		keys, err := ref.From.indexes[ref.Index].Lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		if ref.From == t {
+			for idx, k := range keys {
+				if bytes.Equal(k, key) {
+					keys = append(keys[:idx], keys[idx+1:]...)
+					break
+				}
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		} else if ref.OnDelete == Restrict {
+			return nil, ErrReferenced
+		}
+		cascades = append(cascades, cascade{from: ref.From, keys: keys})
+	}
+	return cascades, nil
+}
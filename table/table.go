@@ -0,0 +1,326 @@
+// A Table is a primary LHash, from key to value, plus a declared set
+// of secondary indexes which are kept consistent with the primary
+// LHash: every modification through the Table updates the primary
+// LHash and all the indexes within a single transaction.
+//
+// The values of a Table are byte slices: the Table creates and
+// manages the GoshawkDB Objects holding them.
+//
+// A Table may also declare that its rows reference rows of another
+// Table, through one of its indexes: the References are then enforced
+// within the same transactions as the modifications.
+package table
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/index"
+	"goshawkdb.io/collections/linearhash"
+	mp "goshawkdb.io/collections/table/msgpack"
+)
+
+var (
+	// ErrExists is returned by Insert if the key is already present.
+	ErrExists = errors.New("Key already exists in Table")
+	// ErrNotFound is returned by Update and Delete if the key is not
+	// present.
+	ErrNotFound = errors.New("Key not found in Table")
+)
+
+// An IndexDef declares a secondary index of a Table. As the Extract
+// function cannot be stored in GoshawkDB, every user of a Table must
+// supply the same IndexDefs.
+type IndexDef struct {
+	Name    string
+	Unique  bool
+	Extract index.ExtractFunc
+}
+
+// A Row is a key and value from the primary LHash of a Table.
+type Row struct {
+	Key   []byte
+	Value []byte
+}
+
+type Table struct {
+	// The connection used to create this Table object.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Table.
+	ObjRef client.ObjectRef
+	// The primary LHash, from key to value Object.
+	Primary *linearhash.LHash
+	indexes map[string]*index.Index
+	// the References from this Table to others, and from others to
+	// this Table.
+	references []*Reference
+	referrers  []*Reference
+}
+
+// Create a brand new empty Table with the given indexes.
+func NewEmptyTable(conn *client.Connection, defs ...IndexDef) (*Table, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		primary, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		t := &Table{
+			Conn:    conn,
+			Primary: primary,
+			indexes: make(map[string]*index.Index, len(defs)),
+		}
+		root := &mp.TableRoot{Indexes: make([]mp.IndexRecord, len(defs))}
+		refs := []client.ObjectRef{primary.ObjRef}
+		for i, def := range defs {
+			if _, found := t.indexes[def.Name]; found {
+				return nil, fmt.Errorf("Duplicate index name: %v", def.Name)
+			}
+			idx, err := index.NewEmptyIndex(conn, def.Unique, def.Extract)
+			if err != nil {
+				return nil, err
+			}
+			t.indexes[def.Name] = idx
+			root.Indexes[i] = mp.IndexRecord{Name: def.Name, Unique: def.Unique}
+			refs = append(refs, idx.LHash.ObjRef)
+		}
+		value, err := root.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		t.ObjRef, err = txn.CreateObject(value, refs...)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	})
+	if err == nil {
+		return res.(*Table), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Table object from an existing given GoshawkDB Object. The
+// defs must declare exactly the indexes the Table was created with.
+func TableFromObj(conn *client.Connection, objRef client.ObjectRef, defs ...IndexDef) (*Table, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		root := new(mp.TableRoot)
+		if _, err = root.UnmarshalMsg(value); err != nil {
+			return nil, err
+		}
+		if len(refs) != len(root.Indexes)+1 {
+			return nil, fmt.Errorf("Table root %v is corrupt: %v indexes but %v references", obj, len(root.Indexes), len(refs))
+		}
+		if len(defs) != len(root.Indexes) {
+			return nil, fmt.Errorf("Table has %v indexes but %v were declared", len(root.Indexes), len(defs))
+		}
+		t := &Table{
+			Conn:    conn,
+			ObjRef:  obj,
+			Primary: linearhash.LHashFromObj(conn, refs[0]),
+			indexes: make(map[string]*index.Index, len(defs)),
+		}
+		for _, def := range defs {
+			found := false
+			for i, record := range root.Indexes {
+				if record.Name == def.Name {
+					if record.Unique != def.Unique {
+						return nil, fmt.Errorf("Index %v declared with Unique=%v, but created with Unique=%v", def.Name, def.Unique, record.Unique)
+					}
+					t.indexes[def.Name] = index.IndexFromObj(conn, refs[i+1], def.Unique, def.Extract)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Table has no index named %v", def.Name)
+			}
+		}
+		return t, nil
+	})
+	if err == nil {
+		return res.(*Table), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Add a new row to the Table. Returns ErrExists if the key is already
+// present, index.ErrDuplicate if a unique index is violated, or
+// ErrMissingReference if the row references a row which does not
+// exist.
+func (t *Table) Insert(key, value []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		objRef, err := t.Primary.Find(key)
+		if err != nil {
+			return nil, err
+		} else if objRef != nil {
+			return nil, ErrExists
+		} else if err = t.checkReferences(key, value); err != nil {
+			return nil, err
+		}
+		valueObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		if err = t.Primary.Put(key, valueObj); err != nil {
+			return nil, err
+		}
+		for _, idx := range t.indexes {
+			if err = idx.Insert(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Replace the value of an existing row. Returns ErrNotFound if the key
+// is not present, index.ErrDuplicate if a unique index is violated, or
+// ErrMissingReference if the row would reference a row which does not
+// exist.
+func (t *Table) Update(key, value []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		objRef, old, err := t.find(key)
+		if err != nil {
+			return nil, err
+		} else if objRef == nil {
+			return nil, ErrNotFound
+		} else if err = t.checkReferences(key, value); err != nil {
+			return nil, err
+		}
+		for _, idx := range t.indexes {
+			if err = idx.Delete(key, old); err != nil {
+				return nil, err
+			}
+		}
+		if err = objRef.Set(value); err != nil {
+			return nil, err
+		}
+		for _, idx := range t.indexes {
+			if err = idx.Insert(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Remove a row from the Table. Returns ErrNotFound if the key is not
+// present. If rows of other Tables reference the row, then according
+// to their References, either they are deleted too, or ErrReferenced
+// is returned and nothing is deleted.
+func (t *Table) Delete(key []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		found, err := t.delete(key)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return nil, err
+	})
+	return err
+}
+
+// delete removes the row with the given key, and any rows referencing
+// it which cascade, returning whether the row was present.
+func (t *Table) delete(key []byte) (bool, error) {
+	objRef, old, err := t.find(key)
+	if err != nil || objRef == nil {
+		return false, err
+	}
+	cascades, err := t.referencing(key)
+	if err != nil {
+		return false, err
+	}
+	for _, idx := range t.indexes {
+		if err = idx.Delete(key, old); err != nil {
+			return false, err
+		}
+	}
+	if err = t.Primary.Remove(key); err != nil {
+		return false, err
+	}
+	// the row is gone before the cascades, so a cycle of references
+	// ends here.
+	for _, c := range cascades {
+		for _, k := range c.keys {
+			if _, err = c.from.delete(k); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// Returns the value of the row with the given key, or nil if there is
+// no such row.
+func (t *Table) Find(key []byte) ([]byte, error) {
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		_, value, err := t.find(key)
+		return value, err
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the rows for which the named index contains the given
+// derived key.
+func (t *Table) FindBy(indexName string, derived []byte) ([]Row, error) {
+	idx, found := t.indexes[indexName]
+	if !found {
+		return nil, fmt.Errorf("Table has no index named %v", indexName)
+	}
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		keys, err := idx.Lookup(derived)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]Row, 0, len(keys))
+		for _, key := range keys {
+			_, value, err := t.find(key)
+			if err != nil {
+				return nil, err
+			} else if value == nil {
+				return nil, fmt.Errorf("Index %v refers to missing key %q", indexName, key)
+			}
+			rows = append(rows, Row{Key: key, Value: value})
+		}
+		return rows, nil
+	})
+	if err == nil {
+		return res.([]Row), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the named index, or nil if there is no such index.
+func (t *Table) Index(name string) *index.Index {
+	return t.indexes[name]
+}
+
+func (t *Table) find(key []byte) (*client.ObjectRef, []byte, error) {
+	objRef, err := t.Primary.Find(key)
+	if err != nil || objRef == nil {
+		return nil, nil, err
+	}
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, nil, err
+	}
+	return objRef, value, nil
+}
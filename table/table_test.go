@@ -0,0 +1,176 @@
+package table
+
+import (
+	"fmt"
+	"goshawkdb.io/collections/index"
+	"goshawkdb.io/tests"
+	"strings"
+	"testing"
+)
+
+// rows are "name,email"
+func field(n int) index.ExtractFunc {
+	return func(key, value []byte) ([][]byte, error) {
+		fields := strings.Split(string(value), ",")
+		if len(fields) <= n {
+			return nil, nil
+		}
+		return [][]byte{[]byte(fields[n])}, nil
+	}
+}
+
+var defs = []IndexDef{
+	{Name: "name", Unique: false, Extract: field(0)},
+	{Name: "email", Unique: true, Extract: field(1)},
+}
+
+func assertFindBy(th *tests.TestHelper, t *Table, indexName, derived string, expected ...string) {
+	rows, err := t.FindBy(indexName, []byte(derived))
+	if err != nil {
+		th.Fatal(err)
+	}
+	if len(rows) != len(expected) {
+		th.Fatal(fmt.Sprintf("Expected %v rows for %v=%v. Got %v", len(expected), indexName, derived, len(rows)))
+	}
+	for i, row := range rows {
+		if string(row.Key) != expected[i] {
+			th.Fatal(fmt.Sprintf("Expected row %v for %v=%v. Got %s", expected[i], indexName, derived, row.Key))
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	tbl, err := NewEmptyTable(c0.Connection, defs...)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = tbl.Insert([]byte("u1"), []byte("alice,alice@example.com")); err != nil {
+		th.Fatal(err)
+	}
+	if err = tbl.Insert([]byte("u2"), []byte("bob,bob@example.com")); err != nil {
+		th.Fatal(err)
+	}
+	if err = tbl.Insert([]byte("u1"), []byte("carol,carol@example.com")); err != ErrExists {
+		th.Fatal(fmt.Sprintf("Expected ErrExists. Got %v", err))
+	}
+	if err = tbl.Insert([]byte("u3"), []byte("mallory,bob@example.com")); err != index.ErrDuplicate {
+		th.Fatal(fmt.Sprintf("Expected ErrDuplicate. Got %v", err))
+	}
+	assertFindBy(th, tbl, "email", "bob@example.com", "u2")
+
+	if err = tbl.Update([]byte("u2"), []byte("alice,bob@example.org")); err != nil {
+		th.Fatal(err)
+	}
+	assertFindBy(th, tbl, "email", "bob@example.com")
+	assertFindBy(th, tbl, "email", "bob@example.org", "u2")
+
+	tbl2, err := TableFromObj(c0.Connection, tbl.ObjRef, defs...)
+	if err != nil {
+		th.Fatal(err)
+	}
+	assertFindBy(th, tbl2, "name", "alice", "u1", "u2")
+
+	if err = tbl2.Delete([]byte("u1")); err != nil {
+		th.Fatal(err)
+	}
+	assertFindBy(th, tbl, "name", "alice", "u2")
+	if value, err := tbl.Find([]byte("u1")); err != nil {
+		th.Fatal(err)
+	} else if value != nil {
+		th.Fatal("Found deleted row")
+	}
+}
+
+func TestReferences(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	c0 := th.CreateConnections(1)[0]
+	customers, err := NewEmptyTable(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	// orders are "customer,item", and lines "order,quantity".
+	orders, err := NewEmptyTable(c0.Connection, IndexDef{Name: "customer", Extract: field(0)})
+	if err != nil {
+		th.Fatal(err)
+	}
+	lines, err := NewEmptyTable(c0.Connection, IndexDef{Name: "order", Extract: field(0)})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if err = orders.AddReference("missing", customers, Restrict); err == nil {
+		th.Fatal("Expected an error for a missing index")
+	}
+	if err = orders.AddReference("customer", customers, Restrict); err != nil {
+		th.Fatal(err)
+	} else if err = lines.AddReference("order", orders, Cascade); err != nil {
+		th.Fatal(err)
+	}
+
+	if err = orders.Insert([]byte("o1"), []byte("c1,apples")); err != ErrMissingReference {
+		th.Fatal(fmt.Sprintf("Expected ErrMissingReference. Got %v", err))
+	}
+	for _, kv := range [][2]string{{"c1", "alice"}, {"c2", "bob"}} {
+		if err = customers.Insert([]byte(kv[0]), []byte(kv[1])); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for _, kv := range [][2]string{{"o1", "c1,apples"}, {"o2", "c1,pears"}} {
+		if err = orders.Insert([]byte(kv[0]), []byte(kv[1])); err != nil {
+			th.Fatal(err)
+		}
+	}
+	for _, kv := range [][2]string{{"l1", "o1,3"}, {"l2", "o1,4"}, {"l3", "o2,1"}} {
+		if err = lines.Insert([]byte(kv[0]), []byte(kv[1])); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if err = orders.Update([]byte("o2"), []byte("c3,pears")); err != ErrMissingReference {
+		th.Fatal(fmt.Sprintf("Expected ErrMissingReference. Got %v", err))
+	}
+	if err = orders.Update([]byte("o2"), []byte("c2,pears")); err != nil {
+		th.Fatal(err)
+	}
+
+	// c1 has an order, so cannot be deleted.
+	if err = customers.Delete([]byte("c1")); err != ErrReferenced {
+		th.Fatal(fmt.Sprintf("Expected ErrReferenced. Got %v", err))
+	}
+	// deleting o1 deletes its lines too.
+	if err = orders.Delete([]byte("o1")); err != nil {
+		th.Fatal(err)
+	}
+	assertFindBy(th, lines, "order", "o1")
+	assertFindBy(th, lines, "order", "o2", "l3")
+	if value, err := lines.Find([]byte("l1")); err != nil || value != nil {
+		th.Fatal(fmt.Sprintf("Expected l1 to be deleted. Got %q, %v", value, err))
+	}
+	if err = customers.Delete([]byte("c1")); err != nil {
+		th.Fatal(err)
+	}
+	// a restricted delete part way down a cascade aborts it all.
+	notes, err := NewEmptyTable(c0.Connection, IndexDef{Name: "line", Extract: field(0)})
+	if err != nil {
+		th.Fatal(err)
+	} else if err = notes.AddReference("line", lines, Restrict); err != nil {
+		th.Fatal(err)
+	} else if err = notes.Insert([]byte("n1"), []byte("l3,fragile")); err != nil {
+		th.Fatal(err)
+	}
+	if err = orders.Delete([]byte("o2")); err != ErrReferenced {
+		th.Fatal(fmt.Sprintf("Expected ErrReferenced. Got %v", err))
+	}
+	assertFindBy(th, orders, "customer", "c2", "o2")
+	assertFindBy(th, lines, "order", "o2", "l3")
+	if err = notes.Delete([]byte("n1")); err != nil {
+		th.Fatal(err)
+	} else if err = orders.Delete([]byte("o2")); err != nil {
+		th.Fatal(err)
+	}
+	assertFindBy(th, lines, "order", "o2")
+}
@@ -0,0 +1,44 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a TimeSeries. Its first
+// reference is to a BTree from the start of each bucket (ordenc
+// encoded) to the bucket Object. The rest are to BTrees of the
+// Aggregates of each of the Rollups, in the same order, from the
+// start of each window to the Aggregate Object. All times are in
+// nanoseconds since the Unix epoch.
+type Root struct {
+	// The span of each bucket.
+	BucketWidth int64
+	// How long samples are kept for, or 0 for ever.
+	Retention int64
+	Rollups   []Rollup
+}
+
+type Rollup struct {
+	// The span of each window.
+	Window int64
+	// How long the Aggregates are kept for, or 0 for ever.
+	Retention int64
+}
+
+// Bucket is the value of a bucket Object.
+type Bucket struct {
+	// The samples of the bucket, in time order.
+	Samples []Sample
+}
+
+type Sample struct {
+	Time  int64
+	Value float64
+}
+
+// Aggregate is the value of an Object holding the aggregate of the
+// samples of a window.
+type Aggregate struct {
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+}
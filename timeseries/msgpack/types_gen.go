@@ -0,0 +1,929 @@
+package msgpack
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Aggregate) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Count":
+			z.Count, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "Sum":
+			z.Sum, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Sum")
+				return
+			}
+		case "Min":
+			z.Min, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Min")
+				return
+			}
+		case "Max":
+			z.Max, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Max")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Aggregate) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "Count"
+	err = en.Append(0x84, 0xa5, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Count)
+	if err != nil {
+		err = msgp.WrapError(err, "Count")
+		return
+	}
+	// write "Sum"
+	err = en.Append(0xa3, 0x53, 0x75, 0x6d)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Sum)
+	if err != nil {
+		err = msgp.WrapError(err, "Sum")
+		return
+	}
+	// write "Min"
+	err = en.Append(0xa3, 0x4d, 0x69, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Min)
+	if err != nil {
+		err = msgp.WrapError(err, "Min")
+		return
+	}
+	// write "Max"
+	err = en.Append(0xa3, 0x4d, 0x61, 0x78)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Max)
+	if err != nil {
+		err = msgp.WrapError(err, "Max")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Aggregate) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "Count"
+	o = append(o, 0x84, 0xa5, 0x43, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt64(o, z.Count)
+	// string "Sum"
+	o = append(o, 0xa3, 0x53, 0x75, 0x6d)
+	o = msgp.AppendFloat64(o, z.Sum)
+	// string "Min"
+	o = append(o, 0xa3, 0x4d, 0x69, 0x6e)
+	o = msgp.AppendFloat64(o, z.Min)
+	// string "Max"
+	o = append(o, 0xa3, 0x4d, 0x61, 0x78)
+	o = msgp.AppendFloat64(o, z.Max)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Aggregate) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Count":
+			z.Count, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "Sum":
+			z.Sum, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Sum")
+				return
+			}
+		case "Min":
+			z.Min, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Min")
+				return
+			}
+		case "Max":
+			z.Max, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Max")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Aggregate) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Int64Size + 4 + msgp.Float64Size + 4 + msgp.Float64Size + 4 + msgp.Float64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Bucket) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Samples":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Samples")
+				return
+			}
+			if cap(z.Samples) >= int(zb0002) {
+				z.Samples = (z.Samples)[:zb0002]
+			} else {
+				z.Samples = make([]Sample, zb0002)
+			}
+			for za0001 := range z.Samples {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Samples", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Samples", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Time":
+						z.Samples[za0001].Time, err = dc.ReadInt64()
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001, "Time")
+							return
+						}
+					case "Value":
+						z.Samples[za0001].Value, err = dc.ReadFloat64()
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001, "Value")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Bucket) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Samples"
+	err = en.Append(0x81, 0xa7, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Samples)))
+	if err != nil {
+		err = msgp.WrapError(err, "Samples")
+		return
+	}
+	for za0001 := range z.Samples {
+		// map header, size 2
+		// write "Time"
+		err = en.Append(0x82, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Samples[za0001].Time)
+		if err != nil {
+			err = msgp.WrapError(err, "Samples", za0001, "Time")
+			return
+		}
+		// write "Value"
+		err = en.Append(0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteFloat64(z.Samples[za0001].Value)
+		if err != nil {
+			err = msgp.WrapError(err, "Samples", za0001, "Value")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Bucket) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Samples"
+	o = append(o, 0x81, 0xa7, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Samples)))
+	for za0001 := range z.Samples {
+		// map header, size 2
+		// string "Time"
+		o = append(o, 0x82, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+		o = msgp.AppendInt64(o, z.Samples[za0001].Time)
+		// string "Value"
+		o = append(o, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+		o = msgp.AppendFloat64(o, z.Samples[za0001].Value)
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Bucket) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Samples":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Samples")
+				return
+			}
+			if cap(z.Samples) >= int(zb0002) {
+				z.Samples = (z.Samples)[:zb0002]
+			} else {
+				z.Samples = make([]Sample, zb0002)
+			}
+			for za0001 := range z.Samples {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Samples", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Samples", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Time":
+						z.Samples[za0001].Time, bts, err = msgp.ReadInt64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001, "Time")
+							return
+						}
+					case "Value":
+						z.Samples[za0001].Value, bts, err = msgp.ReadFloat64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001, "Value")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Samples", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Bucket) Msgsize() (s int) {
+	s = 1 + 8 + msgp.ArrayHeaderSize + (len(z.Samples) * (12 + msgp.Int64Size + msgp.Float64Size))
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Rollup) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Window":
+			z.Window, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Window")
+				return
+			}
+		case "Retention":
+			z.Retention, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Retention")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Rollup) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Window"
+	err = en.Append(0x82, 0xa6, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Window)
+	if err != nil {
+		err = msgp.WrapError(err, "Window")
+		return
+	}
+	// write "Retention"
+	err = en.Append(0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Retention)
+	if err != nil {
+		err = msgp.WrapError(err, "Retention")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Rollup) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Window"
+	o = append(o, 0x82, 0xa6, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77)
+	o = msgp.AppendInt64(o, z.Window)
+	// string "Retention"
+	o = append(o, 0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendInt64(o, z.Retention)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Rollup) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Window":
+			z.Window, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Window")
+				return
+			}
+		case "Retention":
+			z.Retention, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Retention")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Rollup) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Int64Size + 10 + msgp.Int64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Root) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "BucketWidth":
+			z.BucketWidth, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "BucketWidth")
+				return
+			}
+		case "Retention":
+			z.Retention, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Retention")
+				return
+			}
+		case "Rollups":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Rollups")
+				return
+			}
+			if cap(z.Rollups) >= int(zb0002) {
+				z.Rollups = (z.Rollups)[:zb0002]
+			} else {
+				z.Rollups = make([]Rollup, zb0002)
+			}
+			for za0001 := range z.Rollups {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Rollups", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Rollups", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Window":
+						z.Rollups[za0001].Window, err = dc.ReadInt64()
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001, "Window")
+							return
+						}
+					case "Retention":
+						z.Rollups[za0001].Retention, err = dc.ReadInt64()
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001, "Retention")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Root) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "BucketWidth"
+	err = en.Append(0x83, 0xab, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x57, 0x69, 0x64, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.BucketWidth)
+	if err != nil {
+		err = msgp.WrapError(err, "BucketWidth")
+		return
+	}
+	// write "Retention"
+	err = en.Append(0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Retention)
+	if err != nil {
+		err = msgp.WrapError(err, "Retention")
+		return
+	}
+	// write "Rollups"
+	err = en.Append(0xa7, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Rollups)))
+	if err != nil {
+		err = msgp.WrapError(err, "Rollups")
+		return
+	}
+	for za0001 := range z.Rollups {
+		// map header, size 2
+		// write "Window"
+		err = en.Append(0x82, 0xa6, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Rollups[za0001].Window)
+		if err != nil {
+			err = msgp.WrapError(err, "Rollups", za0001, "Window")
+			return
+		}
+		// write "Retention"
+		err = en.Append(0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Rollups[za0001].Retention)
+		if err != nil {
+			err = msgp.WrapError(err, "Rollups", za0001, "Retention")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Root) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "BucketWidth"
+	o = append(o, 0x83, 0xab, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x57, 0x69, 0x64, 0x74, 0x68)
+	o = msgp.AppendInt64(o, z.BucketWidth)
+	// string "Retention"
+	o = append(o, 0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendInt64(o, z.Retention)
+	// string "Rollups"
+	o = append(o, 0xa7, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Rollups)))
+	for za0001 := range z.Rollups {
+		// map header, size 2
+		// string "Window"
+		o = append(o, 0x82, 0xa6, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77)
+		o = msgp.AppendInt64(o, z.Rollups[za0001].Window)
+		// string "Retention"
+		o = append(o, 0xa9, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e)
+		o = msgp.AppendInt64(o, z.Rollups[za0001].Retention)
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Root) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "BucketWidth":
+			z.BucketWidth, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BucketWidth")
+				return
+			}
+		case "Retention":
+			z.Retention, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Retention")
+				return
+			}
+		case "Rollups":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Rollups")
+				return
+			}
+			if cap(z.Rollups) >= int(zb0002) {
+				z.Rollups = (z.Rollups)[:zb0002]
+			} else {
+				z.Rollups = make([]Rollup, zb0002)
+			}
+			for za0001 := range z.Rollups {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Rollups", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Rollups", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Window":
+						z.Rollups[za0001].Window, bts, err = msgp.ReadInt64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001, "Window")
+							return
+						}
+					case "Retention":
+						z.Rollups[za0001].Retention, bts, err = msgp.ReadInt64Bytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001, "Retention")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rollups", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Root) Msgsize() (s int) {
+	s = 1 + 12 + msgp.Int64Size + 10 + msgp.Int64Size + 8 + msgp.ArrayHeaderSize + (len(z.Rollups) * (18 + msgp.Int64Size + msgp.Int64Size))
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Sample) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Time":
+			z.Time, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Time")
+				return
+			}
+		case "Value":
+			z.Value, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Sample) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Time"
+	err = en.Append(0x82, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Time)
+	if err != nil {
+		err = msgp.WrapError(err, "Time")
+		return
+	}
+	// write "Value"
+	err = en.Append(0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Value)
+	if err != nil {
+		err = msgp.WrapError(err, "Value")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Sample) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Time"
+	o = append(o, 0x82, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+	o = msgp.AppendInt64(o, z.Time)
+	// string "Value"
+	o = append(o, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
+	o = msgp.AppendFloat64(o, z.Value)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Sample) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Time":
+			z.Time, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Time")
+				return
+			}
+		case "Value":
+			z.Value, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Value")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Sample) Msgsize() (s int) {
+	s = 1 + 5 + msgp.Int64Size + 6 + msgp.Float64Size
+	return
+}
@@ -0,0 +1,449 @@
+// A TimeSeries holds samples, each a value at a time, for metrics and
+// telemetry. Samples are grouped into buckets, each spanning a fixed
+// width of time, and the buckets are found through a BTree from the
+// start of each bucket, so appending rewrites only one bucket, and
+// reading a range of time reads only the buckets spanning it.
+//
+// A TimeSeries may also keep Rollups: the count, sum, minimum and
+// maximum of the samples in each window of a fixed width, maintained
+// as each sample is appended, so that long ranges can be summarised
+// without reading every sample. Samples and the Aggregates of each
+// Rollup have their own retention, after which Prune removes them, so
+// Aggregates can outlive the samples they summarise.
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/btree"
+	"goshawkdb.io/collections/ordenc"
+	"goshawkdb.io/collections/ordered"
+	mp "goshawkdb.io/collections/timeseries/msgpack"
+	"sort"
+	"time"
+)
+
+// ErrNoSuchRollup is returned by Aggregates when the TimeSeries has no
+// Rollup of the given window.
+var ErrNoSuchRollup = errors.New("TimeSeries has no such Rollup")
+
+// The number of buckets or windows Prune removes in each transaction.
+const pruneBatch = 16
+
+// A Sample is a value at a time.
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// A Rollup is the aggregation of samples into windows of a fixed
+// width, kept for a fixed time (for ever if Retention is 0).
+type Rollup struct {
+	Window    time.Duration
+	Retention time.Duration
+}
+
+// An Aggregate summarises the samples in the window which starts at
+// Start.
+type Aggregate struct {
+	Start time.Time
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Returns the mean of the samples in the window.
+func (a Aggregate) Avg() float64 {
+	return a.Sum / float64(a.Count)
+}
+
+type TimeSeries struct {
+	// The connection used to create this TimeSeries object. As with
+	// LHash, you should not use the same TimeSeries object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the TimeSeries.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty TimeSeries, with buckets of the given
+// width, keeping samples for retention (for ever if retention is 0),
+// and maintaining the given Rollups, each of which must have a
+// different Window. This creates new GoshawkDB Objects and initialises
+// them for use as a TimeSeries. Wider buckets mean fewer Objects, but
+// more to rewrite on each Append.
+func NewEmptyTimeSeries(conn *client.Connection, bucketWidth, retention time.Duration, rollups ...Rollup) (*TimeSeries, error) {
+	if bucketWidth <= 0 {
+		return nil, errors.New("TimeSeries bucket width must be positive")
+	} else if retention < 0 {
+		return nil, errors.New("TimeSeries retention must not be negative")
+	}
+	root := &mp.Root{BucketWidth: int64(bucketWidth), Retention: int64(retention)}
+	for idx, r := range rollups {
+		if r.Window <= 0 || r.Retention < 0 {
+			return nil, fmt.Errorf("Invalid TimeSeries Rollup %v", r)
+		}
+		for _, other := range rollups[:idx] {
+			if other.Window == r.Window {
+				return nil, fmt.Errorf("Duplicate TimeSeries Rollup window %v", r.Window)
+			}
+		}
+		root.Rollups = append(root.Rollups, mp.Rollup{Window: int64(r.Window), Retention: int64(r.Retention)})
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		refs := make([]client.ObjectRef, 1+len(rollups))
+		for idx := range refs {
+			t, err := btree.NewEmptyBTree(conn)
+			if err != nil {
+				return nil, err
+			}
+			refs[idx] = t.ObjRef
+		}
+		value, err := root.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, refs...)
+		if err != nil {
+			return nil, err
+		}
+		return &TimeSeries{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*TimeSeries), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a TimeSeries object from an existing given GoshawkDB Object.
+// As with LHashFromObj, no initialisation is done.
+func TimeSeriesFromObj(conn *client.Connection, objRef client.ObjectRef) *TimeSeries {
+	return &TimeSeries{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the TimeSeries within a single transaction.
+type state struct {
+	root    *mp.Root
+	buckets *btree.BTree
+	// the Aggregates of each Rollup, in the same order as
+	// root.Rollups.
+	rollups []*btree.BTree
+}
+
+func (ts *TimeSeries) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(ts.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.BucketWidth <= 0 || len(refs) != 1+len(root.Rollups) {
+		return nil, fmt.Errorf("TimeSeries root %v is corrupt", obj)
+	}
+	s := &state{root: root, buckets: btree.BTreeFromObj(ts.Conn, refs[0])}
+	for _, ref := range refs[1:] {
+		s.rollups = append(s.rollups, btree.BTreeFromObj(ts.Conn, ref))
+	}
+	return s, nil
+}
+
+// start returns the start of the span of the given width which
+// includes t, in nanoseconds since the Unix epoch.
+func start(t, width int64) int64 {
+	rem := t % width
+	if rem < 0 {
+		rem += width
+	}
+	return t - rem
+}
+
+func key(start int64) []byte {
+	return ordenc.AppendInt64(nil, start)
+}
+
+func decodeKey(key []byte) (int64, error) {
+	start, _, err := ordenc.DecodeInt64(key)
+	return start, err
+}
+
+func readBucket(objRef client.ObjectRef) (*mp.Bucket, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	bucket := new(mp.Bucket)
+	if _, err = bucket.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+func readAggregate(objRef client.ObjectRef) (*mp.Aggregate, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	agg := new(mp.Aggregate)
+	if _, err = agg.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// Append a sample of value at time t to the TimeSeries, updating the
+// Aggregate of its window in every Rollup. Samples need not be
+// appended in time order, and there may be several at the same time.
+func (ts *TimeSeries) Append(t time.Time, value float64) error {
+	_, _, err := ts.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ts.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		nanos := t.UnixNano()
+		k := key(start(nanos, s.root.BucketWidth))
+		bucket := new(mp.Bucket)
+		bucketObj, err := s.buckets.Find(k)
+		if err != nil {
+			return nil, err
+		} else if bucketObj != nil {
+			if bucket, err = readBucket(*bucketObj); err != nil {
+				return nil, err
+			}
+		}
+		samples := bucket.Samples
+		// after any samples at the same time.
+		idx := sort.Search(len(samples), func(i int) bool { return samples[i].Time > nanos })
+		samples = append(samples, mp.Sample{})
+		copy(samples[idx+1:], samples[idx:])
+		samples[idx] = mp.Sample{Time: nanos, Value: value}
+		bucket.Samples = samples
+		encoded, err := bucket.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		if bucketObj == nil {
+			created, err := txn.CreateObject(encoded)
+			if err != nil {
+				return nil, err
+			}
+			if err = s.buckets.Put(k, created); err != nil {
+				return nil, err
+			}
+		} else if err = bucketObj.Set(encoded); err != nil {
+			return nil, err
+		}
+		for idx, r := range s.root.Rollups {
+			if err = s.aggregate(txn, idx, start(nanos, r.Window), value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// aggregate adds value to the Aggregate of the window starting at
+// windowStart of the idx'th Rollup.
+func (s *state) aggregate(txn *client.Txn, idx int, windowStart int64, value float64) error {
+	k := key(windowStart)
+	aggObj, err := s.rollups[idx].Find(k)
+	if err != nil {
+		return err
+	}
+	agg := &mp.Aggregate{Min: value, Max: value}
+	if aggObj != nil {
+		if agg, err = readAggregate(*aggObj); err != nil {
+			return err
+		}
+	}
+	agg.Count++
+	agg.Sum += value
+	if value < agg.Min {
+		agg.Min = value
+	}
+	if value > agg.Max {
+		agg.Max = value
+	}
+	encoded, err := agg.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if aggObj != nil {
+		return aggObj.Set(encoded)
+	}
+	created, err := txn.CreateObject(encoded)
+	if err != nil {
+		return err
+	}
+	return s.rollups[idx].Put(k, created)
+}
+
+// Returns the samples from time from (inclusive) to time to
+// (exclusive), in time order. Only the buckets spanning the range are
+// read.
+func (ts *TimeSeries) Range(from, to time.Time) ([]Sample, error) {
+	var samples []Sample
+	_, _, err := ts.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		samples = samples[:0]
+		s, err := ts.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		fromNanos, toNanos := from.UnixNano(), to.UnixNano()
+		lo, hi := ordered.Inclusive(key(start(fromNanos, s.root.BucketWidth))), ordered.Exclusive(key(toNanos))
+		return nil, s.buckets.Range(lo, hi, false, func(k []byte, bucketObj client.ObjectRef) error {
+			bucket, err := readBucket(bucketObj)
+			if err != nil {
+				return err
+			}
+			for _, sample := range bucket.Samples {
+				if sample.Time >= fromNanos && sample.Time < toNanos {
+					samples = append(samples, Sample{Time: time.Unix(0, sample.Time), Value: sample.Value})
+				}
+			}
+			return nil
+		})
+	})
+	if err == nil {
+		return samples, nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the Aggregates of the Rollup with the given window, for the
+// windows which start from time from (inclusive) to time to
+// (exclusive), in time order. Windows with no samples are omitted. If
+// the TimeSeries has no Rollup with the given window, ErrNoSuchRollup
+// is returned.
+func (ts *TimeSeries) Aggregates(window time.Duration, from, to time.Time) ([]Aggregate, error) {
+	var aggs []Aggregate
+	_, _, err := ts.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		aggs = aggs[:0]
+		s, err := ts.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		idx := s.rollup(window)
+		if idx == -1 {
+			return nil, ErrNoSuchRollup
+		}
+		lo, hi := ordered.Inclusive(key(from.UnixNano())), ordered.Exclusive(key(to.UnixNano()))
+		return nil, s.rollups[idx].Range(lo, hi, false, func(k []byte, aggObj client.ObjectRef) error {
+			windowStart, err := decodeKey(k)
+			if err != nil {
+				return err
+			}
+			agg, err := readAggregate(aggObj)
+			if err != nil {
+				return err
+			}
+			aggs = append(aggs, Aggregate{
+				Start: time.Unix(0, windowStart),
+				Count: agg.Count,
+				Sum:   agg.Sum,
+				Min:   agg.Min,
+				Max:   agg.Max,
+			})
+			return nil
+		})
+	})
+	if err == nil {
+		return aggs, nil
+	} else {
+		return nil, err
+	}
+}
+
+// rollup returns the index of the Rollup with the given window, or -1
+// if there is none.
+func (s *state) rollup(window time.Duration) int {
+	for idx, r := range s.root.Rollups {
+		if r.Window == int64(window) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// errBatchFull stops the Range over the entries to prune once a batch
+// is full.
+var errBatchFull = errors.New("Batch full")
+
+// Remove the samples, and the Aggregates of each Rollup, which have
+// passed their retention at time now, returning the number of buckets
+// and windows removed. Only whole buckets and windows are removed, so
+// samples are kept until the end of their bucket has passed its
+// retention, and likewise Aggregates. As there may be many to remove,
+// Prune runs as many transactions as it needs, each removing a
+// bounded number of buckets and windows.
+func (ts *TimeSeries) Prune(now time.Time) (int, error) {
+	total := 0
+	for {
+		res, _, err := ts.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			s, err := ts.read(txn)
+			if err != nil {
+				return nil, err
+			}
+			removed, err := prune(s.buckets, now.UnixNano(), s.root.BucketWidth, s.root.Retention, pruneBatch)
+			if err != nil {
+				return nil, err
+			}
+			for idx, r := range s.root.Rollups {
+				if removed == pruneBatch {
+					break
+				}
+				n, err := prune(s.rollups[idx], now.UnixNano(), r.Window, r.Retention, pruneBatch-removed)
+				if err != nil {
+					return nil, err
+				}
+				removed += n
+			}
+			return removed, nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += res.(int)
+		if res.(int) < pruneBatch {
+			return total, nil
+		}
+	}
+}
+
+// prune removes up to max of the spans of the given width from t
+// which ended at least retention before now, returning the number
+// removed. Nothing is removed if retention is 0.
+func prune(t *btree.BTree, now, width, retention int64, max int) (int, error) {
+	if retention == 0 {
+		return 0, nil
+	}
+	// a span has passed its retention if start+width+retention <= now.
+	var keys [][]byte
+	hi := ordered.Inclusive(key(now - retention - width))
+	err := t.Range(ordered.Bound{}, hi, false, func(k []byte, v client.ObjectRef) error {
+		keys = append(keys, k)
+		if len(keys) == max {
+			return errBatchFull
+		}
+		return nil
+	})
+	if err != nil && err != errBatchFull {
+		return 0, err
+	}
+	for _, k := range keys {
+		if err = t.Remove(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
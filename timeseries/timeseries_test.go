@@ -0,0 +1,124 @@
+package timeseries
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+var epoch = time.Unix(1500000000, 0)
+
+func at(seconds int) time.Time {
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+func createEmpty(th *tests.TestHelper, bucketWidth, retention time.Duration, rollups ...Rollup) *TimeSeries {
+	c0 := th.CreateConnections(1)[0]
+	ts, err := NewEmptyTimeSeries(c0.Connection, bucketWidth, retention, rollups...)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return ts
+}
+
+// assertRange checks the samples from from to to, formatted as
+// "seconds:value".
+func assertRange(th *tests.TestHelper, ts *TimeSeries, from, to int, expected ...string) {
+	samples, err := ts.Range(at(from), at(to))
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(samples))
+	for idx, s := range samples {
+		got[idx] = fmt.Sprintf("%v:%v", int(s.Time.Sub(epoch)/time.Second), s.Value)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Range from %v to %v: expected %v. Got %v", from, to, expected, got)
+	}
+}
+
+// assertAggregates checks the Aggregates of window from from to to,
+// formatted as "seconds:count/min/max/avg".
+func assertAggregates(th *tests.TestHelper, ts *TimeSeries, window time.Duration, from, to int, expected ...string) {
+	aggs, err := ts.Aggregates(window, at(from), at(to))
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(aggs))
+	for idx, a := range aggs {
+		got[idx] = fmt.Sprintf("%v:%v/%v/%v/%v", int(a.Start.Sub(epoch)/time.Second), a.Count, a.Min, a.Max, a.Avg())
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Aggregates of %v from %v to %v: expected %v. Got %v", window, from, to, expected, got)
+	}
+}
+
+func TestAppendRange(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	if _, err := NewEmptyTimeSeries(nil, 0, 0); err == nil {
+		th.Fatal("Expected an error for a bucket width of 0")
+	}
+	if _, err := NewEmptyTimeSeries(nil, time.Second, 0, Rollup{Window: time.Minute}, Rollup{Window: time.Minute}); err == nil {
+		th.Fatal("Expected an error for duplicate Rollup windows")
+	}
+
+	ts := createEmpty(th, 10*time.Second, 0)
+	assertRange(th, ts, -100, 100)
+	// out of order, before the epoch, and at the same time.
+	for _, s := range []struct {
+		seconds int
+		value   float64
+	}{{5, 1}, {25, 2}, {-3, 3}, {12, 4}, {5, 5}, {10, 6}, {-10, 7}} {
+		if err := ts.Append(at(s.seconds), s.value); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertRange(th, ts, -100, 100, "-10:7", "-3:3", "5:1", "5:5", "10:6", "12:4", "25:2")
+	assertRange(th, ts, 5, 12, "5:1", "5:5", "10:6")
+	assertRange(th, ts, -3, 6, "-3:3", "5:1", "5:5")
+	assertRange(th, ts, 13, 25)
+	if _, err := ts.Aggregates(time.Minute, at(0), at(100)); err != ErrNoSuchRollup {
+		th.Fatalf("Expected ErrNoSuchRollup. Got %v", err)
+	}
+}
+
+func TestRollupsPrune(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	ts := createEmpty(th, time.Second, time.Minute,
+		Rollup{Window: 10 * time.Second, Retention: 2 * time.Minute},
+		Rollup{Window: time.Minute})
+	for seconds := 0; seconds < 120; seconds++ {
+		if err := ts.Append(at(seconds), float64(seconds%10)); err != nil {
+			th.Fatal(err)
+		}
+	}
+	assertAggregates(th, ts, 10*time.Second, 0, 30, "0:10/0/9/4.5", "10:10/0/9/4.5", "20:10/0/9/4.5")
+	assertAggregates(th, ts, time.Minute, 0, 1000, "0:60/0/9/4.5", "60:60/0/9/4.5")
+	if err := ts.Append(at(61), 100); err != nil {
+		th.Fatal(err)
+	}
+	assertAggregates(th, ts, 10*time.Second, 60, 70, "60:11/0/100/13.181818181818182")
+
+	// samples ending at least a minute before 150s, so before 90s, go,
+	// and windows of 10s ending at least two minutes before, so before
+	// 30s; the minute windows are kept for ever.
+	removed, err := ts.Prune(at(150))
+	if err != nil {
+		th.Fatal(err)
+	} else if removed != 90+3 {
+		th.Fatalf("Expected 93 removed. Got %v", removed)
+	}
+	assertRange(th, ts, 0, 91, "90:0")
+	assertAggregates(th, ts, 10*time.Second, 0, 40, "30:10/0/9/4.5")
+	assertAggregates(th, ts, time.Minute, 0, 1000, "0:60/0/9/4.5", "60:61/0/100/6.065573770491803")
+	if removed, err = ts.Prune(at(150)); err != nil {
+		th.Fatal(err)
+	} else if removed != 0 {
+		th.Fatalf("Expected nothing more removed. Got %v", removed)
+	}
+}
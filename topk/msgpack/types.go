@@ -0,0 +1,19 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a TopK. Its references are
+// to the LHash of Counters by item, and to the BTree of Counters in
+// order of count.
+type Root struct {
+	// The number of Counters kept.
+	K int64
+}
+
+// Counter is the value of the Object of the counter of an item.
+type Counter struct {
+	Count uint64
+	// The most by which Count may overestimate the item's count: the
+	// count of the item whose counter it replaced.
+	Error uint64
+}
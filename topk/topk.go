@@ -0,0 +1,288 @@
+// A TopK tracks the most frequent items in a stream of items, the
+// heavy hitters, using a fixed number of counters, whatever the number
+// of distinct items, with the Space-Saving algorithm: an item with a
+// counter has its counter incremented, and an item without one takes
+// over the counter with the lowest count, inheriting that count as
+// the bound on its error. So every count is an overestimate by at
+// most its error, and every item which occurs more often than the
+// lowest count is guaranteed a counter. Many frontends can Offer items
+// to the same TopK, which is updated transactionally, to maintain one
+// shared leaderboard.
+//
+// The counters are held in an LHash by item, and in a BTree in order
+// of count, so finding the counter of an item, or the counter with the
+// lowest count, does not read every counter.
+package topk
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/btree"
+	"goshawkdb.io/collections/linearhash"
+	"goshawkdb.io/collections/ordenc"
+	"goshawkdb.io/collections/ordered"
+	mp "goshawkdb.io/collections/topk/msgpack"
+)
+
+// An Item is an item counted by a TopK, with its estimated count.
+type Item struct {
+	Key   []byte
+	Count uint64
+	// The most by which Count may overestimate the number of times the
+	// item has been offered. The item has been offered at least
+	// Count-Error times.
+	Error uint64
+}
+
+type TopK struct {
+	// The connection used to create this TopK object. As with LHash,
+	// you should not use the same TopK object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the TopK.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty TopK, keeping k counters. This creates new
+// GoshawkDB Objects and initialises them for use as a TopK. The more
+// counters, the more accurate the counts, and the more items are
+// tracked: to find the top n items reliably, k should be several
+// times n.
+func NewEmptyTopK(conn *client.Connection, k int) (*TopK, error) {
+	if k < 1 {
+		return nil, errors.New("A TopK must have at least one counter")
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		counters, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		order, err := btree.NewEmptyBTree(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{K: int64(k)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, counters.ObjRef, order.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &TopK{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*TopK), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a TopK object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func TopKFromObj(conn *client.Connection, objRef client.ObjectRef) *TopK {
+	return &TopK{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the TopK within a single transaction.
+type state struct {
+	root     *mp.Root
+	counters *linearhash.LHash
+	order    *btree.BTree
+}
+
+func (tk *TopK) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(tk.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.K < 1 || len(refs) != 2 {
+		return nil, fmt.Errorf("TopK root %v is corrupt", obj)
+	}
+	return &state{root: root, counters: linearhash.LHashFromObj(tk.Conn, refs[0]), order: btree.BTreeFromObj(tk.Conn, refs[1])}, nil
+}
+
+// counter is the counter of an item within a single transaction.
+type counter struct {
+	key    []byte
+	objRef client.ObjectRef
+	c      *mp.Counter
+}
+
+func readCounter(key []byte, objRef client.ObjectRef) (*counter, error) {
+	value, err := objRef.Value()
+	if err != nil {
+		return nil, err
+	}
+	c := new(mp.Counter)
+	if _, err = c.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return &counter{key: key, objRef: objRef, c: c}, nil
+}
+
+func (c *counter) write() error {
+	value, err := c.c.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return c.objRef.Set(value)
+}
+
+// orderKey returns the key of the counter in the BTree in order of
+// count: the count, then the item.
+func (c *counter) orderKey() []byte {
+	return append(ordenc.AppendUint64(nil, c.c.Count), c.key...)
+}
+
+func (c *counter) item() Item {
+	return Item{Key: c.key, Count: c.c.Count, Error: c.c.Error}
+}
+
+// errFound stops a Range once Offer has the counter with the lowest
+// count.
+var errFound = errors.New("Found")
+
+// Count an occurrence of item.
+func (tk *TopK) Offer(item []byte) error {
+	_, _, err := tk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := tk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := s.counters.Find(item)
+		if err != nil {
+			return nil, err
+		} else if objRef != nil {
+			c, err := readCounter(item, *objRef)
+			if err != nil {
+				return nil, err
+			} else if err = s.order.Remove(c.orderKey()); err != nil {
+				return nil, err
+			}
+			c.c.Count++
+			return nil, s.put(c)
+		}
+		size, err := s.order.Size()
+		if err != nil {
+			return nil, err
+		} else if size < s.root.K {
+			objRef, err := txn.CreateObject(nil)
+			if err != nil {
+				return nil, err
+			}
+			c := &counter{key: item, objRef: objRef, c: &mp.Counter{Count: 1}}
+			if err = s.counters.Put(item, objRef); err != nil {
+				return nil, err
+			}
+			return nil, s.put(c)
+		}
+		// take over the counter with the lowest count.
+		var minKey []byte
+		var minRef client.ObjectRef
+		err = s.order.Range(ordered.Bound{}, ordered.Bound{}, false, func(k []byte, v client.ObjectRef) error {
+			minKey, minRef = k, v
+			return errFound
+		})
+		if err != errFound {
+			return nil, err
+		}
+		_, evicted, err := ordenc.DecodeUint64(minKey)
+		if err != nil {
+			return nil, err
+		}
+		c, err := readCounter(evicted, minRef)
+		if err != nil {
+			return nil, err
+		} else if err = s.order.Remove(minKey); err != nil {
+			return nil, err
+		} else if err = s.counters.Remove(evicted); err != nil {
+			return nil, err
+		} else if err = s.counters.Put(item, c.objRef); err != nil {
+			return nil, err
+		}
+		c.key, c.c.Error = item, c.c.Count
+		c.c.Count++
+		return nil, s.put(c)
+	})
+	return err
+}
+
+// put writes c, and adds it to the BTree in order of count.
+func (s *state) put(c *counter) error {
+	if err := c.write(); err != nil {
+		return err
+	}
+	return s.order.Put(c.orderKey(), c.objRef)
+}
+
+// Returns up to n of the items with the highest counts, highest
+// first. Items with equal counts are in reverse key order.
+func (tk *TopK) Top(n int) ([]Item, error) {
+	res, _, err := tk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := tk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		var items []Item
+		if n <= 0 {
+			return items, nil
+		}
+		err = s.order.Range(ordered.Bound{}, ordered.Bound{}, true, func(k []byte, v client.ObjectRef) error {
+			_, key, err := ordenc.DecodeUint64(k)
+			if err != nil {
+				return err
+			}
+			c, err := readCounter(key, v)
+			if err != nil {
+				return err
+			}
+			items = append(items, c.item())
+			if len(items) == n {
+				return errFound
+			}
+			return nil
+		})
+		if err != nil && err != errFound {
+			return nil, err
+		}
+		return items, nil
+	})
+	if err == nil {
+		return res.([]Item), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the estimated count of item, and whether it has a counter.
+// An item without a counter has been offered no more times than the
+// lowest count of the items with counters.
+func (tk *TopK) Estimate(item []byte) (Item, bool, error) {
+	res, _, err := tk.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := tk.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := s.counters.Find(item)
+		if err != nil || objRef == nil {
+			return (*counter)(nil), err
+		}
+		return readCounter(item, *objRef)
+	})
+	if err != nil {
+		return Item{}, false, err
+	} else if c := res.(*counter); c != nil {
+		return c.item(), true, nil
+	}
+	return Item{Key: item}, false, nil
+}
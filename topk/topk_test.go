@@ -0,0 +1,100 @@
+package topk
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"math/rand"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper, k int) *TopK {
+	c0 := th.CreateConnections(1)[0]
+	tk, err := NewEmptyTopK(c0.Connection, k)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return tk
+}
+
+func offer(th *tests.TestHelper, tk *TopK, items ...string) {
+	for _, item := range items {
+		if err := tk.Offer([]byte(item)); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	tk := createEmpty(th, 2)
+	if items, err := tk.Top(5); err != nil || len(items) != 0 {
+		th.Fatalf("Expected no items. Got %v, %v", items, err)
+	}
+	offer(th, tk, "a", "a", "b", "c")
+	// c takes over the counter of b.
+	items, err := tk.Top(5)
+	if err != nil {
+		th.Fatal(err)
+	} else if fmt.Sprint(items) != "[{[99] 2 1} {[97] 2 0}]" {
+		th.Fatalf("Unexpected items: %v", items)
+	}
+	if item, found, err := tk.Estimate([]byte("b")); err != nil || found {
+		th.Fatalf("Expected b not to be counted. Got %v, %v, %v", item, found, err)
+	}
+	offer(th, tk, "c")
+	if item, found, err := tk.Estimate([]byte("c")); err != nil || !found || item.Count != 3 || item.Error != 1 {
+		th.Fatalf("Expected c to be counted 3 times. Got %v, %v, %v", item, found, err)
+	}
+	if items, err = tk.Top(1); err != nil || len(items) != 1 || string(items[0].Key) != "c" {
+		th.Fatalf("Expected c. Got %v, %v", items, err)
+	}
+}
+
+func TestHeavyHitters(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	tk := createEmpty(th, 10)
+	var stream []string
+	counts := map[string]int{"hot1": 100, "hot2": 60, "hot3": 40}
+	for item, count := range counts {
+		for i := 0; i < count; i++ {
+			stream = append(stream, item)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		stream = append(stream, fmt.Sprintf("cold%03d", i))
+	}
+	rng := rand.New(rand.NewSource(0))
+	rng.Shuffle(len(stream), func(i, j int) { stream[i], stream[j] = stream[j], stream[i] })
+	offer(th, tk, stream...)
+
+	items, err := tk.Top(3)
+	if err != nil {
+		th.Fatal(err)
+	} else if len(items) != 3 {
+		th.Fatalf("Expected 3 items. Got %v", items)
+	}
+	for idx, item := range items {
+		if expected := fmt.Sprintf("hot%d", idx+1); string(item.Key) != expected {
+			th.Fatalf("Expected %v at %v. Got %q", expected, idx, item.Key)
+		}
+		count := uint64(counts[string(item.Key)])
+		if item.Count < count || item.Count-item.Error > count {
+			th.Fatalf("Item %q was offered %v times, but counted %v with error %v", item.Key, count, item.Count, item.Error)
+		}
+	}
+	if items, err = tk.Top(100); err != nil || len(items) != 10 {
+		th.Fatalf("Expected 10 items. Got %v, %v", len(items), err)
+	}
+	total := uint64(0)
+	for _, item := range items {
+		total += item.Count
+	}
+	// Space-Saving conserves the total count.
+	if total != uint64(len(stream)) {
+		th.Fatalf("Expected total count %v. Got %v", len(stream), total)
+	}
+}
@@ -0,0 +1,15 @@
+package msgpack
+
+//go:generate msgp
+
+// Node is the value of a node Object of a Trie. Its first reference is
+// to the value of the node's key, or to the node itself if the key is
+// not in the Trie. The rest are the children, in the same order as
+// Labels.
+type Node struct {
+	// The bytes of the key which this node adds to its parent's. Empty
+	// only for the root.
+	Prefix []byte
+	// The first byte of the Prefix of each child, in increasing order.
+	Labels []byte
+}
@@ -0,0 +1,376 @@
+// A Trie is a map from byte string keys to Objects, like an LHash,
+// but organised by key prefix, as a radix tree: each node Object adds
+// a run of bytes to the key of its parent, so that all the keys with a
+// given prefix are found under a single node. ForEachPrefix and
+// DeletePrefix then only visit the keys with that prefix, which suits
+// hierarchical keys such as URL paths.
+//
+// Each Put or Remove rewrites only the nodes along the path to its key
+// whose contents change.
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/trie/msgpack"
+	"sort"
+)
+
+type Trie struct {
+	// The connection used to create this Trie object. As with LHash,
+	// you should not use the same Trie object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which is the root node of the
+	// Trie.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty Trie. This creates a new GoshawkDB Object
+// and initialises it for use as a Trie.
+func NewEmptyTrie(conn *client.Connection) (*Trie, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		n, err := createNode(txn, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &Trie{Conn: conn, ObjRef: n.objRef}, nil
+	})
+	if err == nil {
+		return res.(*Trie), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Trie object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func TrieFromObj(conn *client.Connection, objRef client.ObjectRef) *Trie {
+	return &Trie{Conn: conn, ObjRef: objRef}
+}
+
+// node is the in-memory form of a node Object.
+type node struct {
+	objRef   client.ObjectRef
+	prefix   []byte
+	value    *client.ObjectRef
+	labels   []byte
+	children []client.ObjectRef
+}
+
+// createNode creates a node, with no value or children, for the given
+// prefix.
+func createNode(txn *client.Txn, prefix []byte) (*node, error) {
+	objRef, err := txn.CreateObject(nil)
+	if err != nil {
+		return nil, err
+	}
+	n := &node{objRef: objRef, prefix: prefix}
+	return n, n.write()
+}
+
+func readNode(objRef client.ObjectRef) (*node, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	n := new(mp.Node)
+	if _, err = n.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1+len(n.Labels) {
+		return nil, fmt.Errorf("Trie node %v is corrupt", objRef)
+	}
+	result := &node{objRef: objRef, prefix: n.Prefix, labels: n.Labels, children: refs[1:]}
+	if !refs[0].ReferencesSameAs(objRef) {
+		result.value = &refs[0]
+	}
+	return result, nil
+}
+
+func (n *node) write() error {
+	value, err := (&mp.Node{Prefix: n.prefix, Labels: n.labels}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	refs := make([]client.ObjectRef, 1, 1+len(n.children))
+	refs[0] = n.objRef
+	if n.value != nil {
+		refs[0] = *n.value
+	}
+	return n.objRef.Set(value, append(refs, n.children...)...)
+}
+
+// child returns the index of the child whose prefix starts with label,
+// and whether there is one; if not, the index is where it would go.
+func (n *node) child(label byte) (int, bool) {
+	idx := sort.Search(len(n.labels), func(i int) bool { return n.labels[i] >= label })
+	return idx, idx < len(n.labels) && n.labels[idx] == label
+}
+
+func (n *node) addChild(idx int, c *node) {
+	n.labels = append(n.labels, 0)
+	copy(n.labels[idx+1:], n.labels[idx:])
+	n.labels[idx] = c.prefix[0]
+	n.children = append(n.children, c.objRef)
+	copy(n.children[idx+1:], n.children[idx:])
+	n.children[idx] = c.objRef
+}
+
+func (n *node) removeChild(idx int) {
+	n.labels = append(n.labels[:idx], n.labels[idx+1:]...)
+	n.children = append(n.children[:idx], n.children[idx+1:]...)
+}
+
+// step is a node on the path to a key, and the index of the next node
+// of the path among its children.
+type step struct {
+	n   *node
+	idx int
+}
+
+// walk follows key down from the root, returning the path of nodes
+// whose keys are prefixes of key, and the remainder of key not
+// matched by the last of them. If the remainder is not empty, the last
+// step's idx is that of the child whose label is the first byte of the
+// remainder, if there is one.
+func (t *Trie) walk(txn *client.Txn, key []byte) ([]step, []byte, error) {
+	objRef, err := txn.GetObject(t.ObjRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := readNode(objRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	var path []step
+	for {
+		key = key[len(n.prefix):]
+		if len(key) == 0 {
+			return append(path, step{n: n}), key, nil
+		}
+		idx, found := n.child(key[0])
+		path = append(path, step{n: n, idx: idx})
+		if !found {
+			return path, key, nil
+		}
+		c, err := readNode(n.children[idx])
+		if err != nil {
+			return nil, nil, err
+		} else if !bytes.HasPrefix(key, c.prefix) {
+			return path, key, nil
+		}
+		n = c
+	}
+}
+
+// Search the Trie for the given key, returning the value, or nil if
+// the key is not present.
+func (t *Trie) Get(key []byte) (*client.ObjectRef, error) {
+	res, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		path, rest, err := t.walk(txn, key)
+		if err != nil {
+			return nil, err
+		} else if len(rest) != 0 {
+			return (*client.ObjectRef)(nil), nil
+		}
+		return path[len(path)-1].n.value, nil
+	})
+	if err == nil {
+		return res.(*client.ObjectRef), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Idempotently add the given key and value to the Trie.
+func (t *Trie) Put(key []byte, value client.ObjectRef) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		path, rest, err := t.walk(txn, key)
+		if err != nil {
+			return nil, err
+		}
+		last := path[len(path)-1]
+		if len(rest) == 0 {
+			last.n.value = &value
+			return nil, last.n.write()
+		}
+		if _, found := last.n.child(rest[0]); !found {
+			leaf, err := createNode(txn, rest)
+			if err != nil {
+				return nil, err
+			}
+			leaf.value = &value
+			if err = leaf.write(); err != nil {
+				return nil, err
+			}
+			last.n.addChild(last.idx, leaf)
+			return nil, last.n.write()
+		}
+		// the child's prefix diverges from rest, so split the edge.
+		c, err := readNode(last.n.children[last.idx])
+		if err != nil {
+			return nil, err
+		}
+		common := 0
+		for common < len(c.prefix) && common < len(rest) && c.prefix[common] == rest[common] {
+			common++
+		}
+		mid, err := createNode(txn, rest[:common])
+		if err != nil {
+			return nil, err
+		}
+		c.prefix = c.prefix[common:]
+		if err = c.write(); err != nil {
+			return nil, err
+		}
+		mid.addChild(0, c)
+		if common == len(rest) {
+			mid.value = &value
+		} else {
+			leaf, err := createNode(txn, rest[common:])
+			if err != nil {
+				return nil, err
+			}
+			leaf.value = &value
+			if err = leaf.write(); err != nil {
+				return nil, err
+			}
+			idx, _ := mid.child(leaf.prefix[0])
+			mid.addChild(idx, leaf)
+		}
+		if err = mid.write(); err != nil {
+			return nil, err
+		}
+		last.n.children[last.idx] = mid.objRef
+		return nil, last.n.write()
+	})
+	return err
+}
+
+// Idempotently remove any entry with the given key from the Trie.
+func (t *Trie) Remove(key []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		path, rest, err := t.walk(txn, key)
+		if err != nil || len(rest) != 0 || path[len(path)-1].n.value == nil {
+			return nil, err
+		}
+		last := path[len(path)-1].n
+		last.value = nil
+		return nil, tidy(path)
+	})
+	return err
+}
+
+// Remove every entry whose key starts with prefix. Only the nodes with
+// such keys are visited, and the whole removal is a single
+// transaction.
+func (t *Trie) DeletePrefix(prefix []byte) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		path, rest, err := t.walk(txn, prefix)
+		if err != nil {
+			return nil, err
+		}
+		last := path[len(path)-1]
+		if len(rest) == 0 {
+			// every key under the last node starts with prefix.
+			last.n.value = nil
+			last.n.labels, last.n.children = nil, nil
+			return nil, tidy(path)
+		} else if _, found := last.n.child(rest[0]); !found {
+			return nil, nil
+		}
+		c, err := readNode(last.n.children[last.idx])
+		if err != nil {
+			return nil, err
+		} else if !bytes.HasPrefix(c.prefix, rest) {
+			return nil, nil
+		}
+		// prefix ends part way along the edge to c.
+		last.n.removeChild(last.idx)
+		return nil, tidy(path)
+	})
+	return err
+}
+
+// tidy writes the last node of path, which has changed, and then
+// removes it if it has no value or children, and merges it into its
+// only child if it has no value, repeating up the path as nodes are
+// removed. The root is never removed or merged.
+func tidy(path []step) error {
+	for idx := len(path) - 1; idx > 0; idx-- {
+		n, parent := path[idx].n, path[idx-1]
+		if n.value != nil || len(n.children) > 1 {
+			return n.write()
+		} else if len(n.children) == 1 {
+			c, err := readNode(n.children[0])
+			if err != nil {
+				return err
+			}
+			c.prefix = append(append([]byte(nil), n.prefix...), c.prefix...)
+			if err = c.write(); err != nil {
+				return err
+			}
+			parent.n.children[parent.idx] = c.objRef
+			return parent.n.write()
+		}
+		parent.n.removeChild(parent.idx)
+	}
+	return path[0].n.write()
+}
+
+// Iterate over the entries whose keys start with prefix, in key order.
+// Only the nodes with such keys are visited. As with LHash.ForEach,
+// the iteration is done within a single transaction, which may
+// restart, in which case entries may be supplied again. An error
+// returned by f stops the iteration and is returned.
+func (t *Trie) ForEachPrefix(prefix []byte, f func(key []byte, value client.ObjectRef) error) error {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		path, rest, err := t.walk(txn, prefix)
+		if err != nil {
+			return nil, err
+		}
+		last := path[len(path)-1]
+		key := append([]byte(nil), prefix[:len(prefix)-len(rest)]...)
+		if len(rest) == 0 {
+			return nil, forEach(last.n, key, f)
+		} else if _, found := last.n.child(rest[0]); !found {
+			return nil, nil
+		}
+		c, err := readNode(last.n.children[last.idx])
+		if err != nil {
+			return nil, err
+		} else if !bytes.HasPrefix(c.prefix, rest) {
+			return nil, nil
+		}
+		return nil, forEach(c, append(key, c.prefix...), f)
+	})
+	return err
+}
+
+// Iterate over every entry in the Trie, in key order. See
+// ForEachPrefix.
+func (t *Trie) ForEach(f func(key []byte, value client.ObjectRef) error) error {
+	return t.ForEachPrefix(nil, f)
+}
+
+// forEach calls f with the entries of the subtree of n, whose key is
+// key, in key order.
+func forEach(n *node, key []byte, f func(key []byte, value client.ObjectRef) error) error {
+	if n.value != nil {
+		if err := f(key, *n.value); err != nil {
+			return err
+		}
+	}
+	for _, objRef := range n.children {
+		c, err := readNode(objRef)
+		if err != nil {
+			return err
+		}
+		childKey := append(append([]byte(nil), key...), c.prefix...)
+		if err = forEach(c, childKey, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
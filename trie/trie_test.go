@@ -0,0 +1,151 @@
+package trie
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/tests"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *Trie {
+	c0 := th.CreateConnections(1)[0]
+	t, err := NewEmptyTrie(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return t
+}
+
+// putAll puts each key with a value Object holding the key.
+func putAll(th *tests.TestHelper, t *Trie, keys ...string) {
+	for _, key := range keys {
+		_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			value, err := txn.CreateObject([]byte(key))
+			if err != nil {
+				return nil, err
+			}
+			return nil, t.Put([]byte(key), value)
+		})
+		if err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+// assertPrefix checks that exactly the given keys, in order, have the
+// prefix, each with its own value.
+func assertPrefix(th *tests.TestHelper, t *Trie, prefix string, expected ...string) {
+	var got []string
+	err := t.ForEachPrefix([]byte(prefix), func(key []byte, value client.ObjectRef) error {
+		v, err := value.Value()
+		if err != nil {
+			return err
+		} else if string(v) != string(key) {
+			return fmt.Errorf("Key %s has value %s", key, v)
+		}
+		got = append(got, string(key))
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("Expected keys %v with prefix %q. Got %v", expected, prefix, got)
+	}
+}
+
+func assertGet(th *tests.TestHelper, t *Trie, key string, present bool) {
+	_, _, err := t.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		value, err := t.Get([]byte(key))
+		if err != nil {
+			return nil, err
+		} else if (value != nil) != present {
+			th.Fatalf("Expected %q present: %v. Got %v", key, present, value)
+		} else if value != nil {
+			if v, err := value.Value(); err != nil || string(v) != key {
+				th.Fatalf("Expected value %q. Got %s %v", key, v, err)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+var keys = []string{"", "/", "/a", "/a/b", "/a/b/c", "/a/bc", "/ab", "/b", "/b/a", "x", "xyz"}
+
+func TestPutGetRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	tr := createEmpty(th)
+	assertPrefix(th, tr, "")
+	assertGet(th, tr, "/a", false)
+	// insert in reverse, so that edges are split.
+	reversed := append([]string(nil), keys...)
+	sort.Sort(sort.Reverse(sort.StringSlice(reversed)))
+	putAll(th, tr, reversed...)
+	assertPrefix(th, tr, "", keys...)
+	for _, key := range keys {
+		assertGet(th, tr, key, true)
+	}
+	for _, key := range []string{"/a/", "/a/bcd", "xy", "/c"} {
+		assertGet(th, tr, key, false)
+	}
+	// putting again replaces the value.
+	putAll(th, tr, "/a")
+	assertPrefix(th, tr, "/a", "/a", "/a/b", "/a/b/c", "/a/bc", "/ab")
+
+	for idx, key := range keys {
+		if err := tr.Remove([]byte(key)); err != nil {
+			th.Fatal(err)
+		}
+		assertGet(th, tr, key, false)
+		assertPrefix(th, tr, "", keys[idx+1:]...)
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	tr := createEmpty(th)
+	putAll(th, tr, keys...)
+	withPrefix := func(prefix string) []string {
+		var result []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				result = append(result, key)
+			}
+		}
+		return result
+	}
+	for _, prefix := range []string{"", "/", "/a", "/a/", "/a/b", "/a/b/", "/c", "x", "xy", "xyzz"} {
+		assertPrefix(th, tr, prefix, withPrefix(prefix)...)
+	}
+
+	// ending part way along an edge, at a node, and matching nothing.
+	if err := tr.DeletePrefix([]byte("xy")); err != nil {
+		th.Fatal(err)
+	}
+	assertPrefix(th, tr, "", "", "/", "/a", "/a/b", "/a/b/c", "/a/bc", "/ab", "/b", "/b/a", "x")
+	if err := tr.DeletePrefix([]byte("/a/b")); err != nil {
+		th.Fatal(err)
+	}
+	assertPrefix(th, tr, "", "", "/", "/a", "/ab", "/b", "/b/a", "x")
+	if err := tr.DeletePrefix([]byte("/c")); err != nil {
+		th.Fatal(err)
+	}
+	assertPrefix(th, tr, "", "", "/", "/a", "/ab", "/b", "/b/a", "x")
+	putAll(th, tr, "/a/b")
+	assertPrefix(th, tr, "/a", "/a", "/a/b", "/ab")
+	if err := tr.DeletePrefix(nil); err != nil {
+		th.Fatal(err)
+	}
+	assertPrefix(th, tr, "")
+	putAll(th, tr, "/a")
+	assertPrefix(th, tr, "", "/a")
+}
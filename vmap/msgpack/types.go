@@ -0,0 +1,27 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a VMap. Its only reference
+// is to an LHash from each key to the key's history Object.
+type Root struct {
+	// The number of versions kept of each key, or 0 for all of them.
+	Depth int64
+}
+
+// History is the value of a history Object. Its references are to
+// the values of the versions, in the same order as Versions, with the
+// history Object itself in place of the value of a deletion.
+type History struct {
+	// The number the next version of the key will have.
+	Next uint64
+	// The versions kept, newest first.
+	Versions []Version
+}
+
+type Version struct {
+	Number uint64
+	// When the version was made, in nanoseconds since the Unix epoch.
+	Time    int64
+	Deleted bool
+}
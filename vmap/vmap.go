@@ -0,0 +1,392 @@
+// A VMap is a map from keys to values which keeps the history of each
+// key: every Put makes a new version of the key, numbered from 0, and
+// Remove makes a deletion, so earlier values can still be read with
+// GetAt and History, for auditing. The number of versions kept of each
+// key may be bounded, and old versions can be pruned explicitly, by
+// count or by age.
+//
+// Each key has its own history Object, found through an LHash, which
+// references the value of each version, so making a version rewrites
+// only the history Object of the key, and adding a key the LHash.
+package vmap
+
+import (
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/linearhash"
+	mp "goshawkdb.io/collections/vmap/msgpack"
+	"time"
+)
+
+// A Version is a version of a key of a VMap.
+type Version struct {
+	Number uint64
+	// When the version was made.
+	Time time.Time
+	// Nil if the version is a deletion.
+	Value   []byte
+	Deleted bool
+}
+
+type VMap struct {
+	// The connection used to create this VMap object. As with LHash,
+	// you should not use the same VMap object from multiple
+	// connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the VMap.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty VMap, keeping up to depth versions of each
+// key (all of them if depth is 0 or less). This creates new GoshawkDB
+// Objects and initialises them for use as a VMap.
+func NewEmptyVMap(conn *client.Connection, depth int) (*VMap, error) {
+	if depth < 0 {
+		depth = 0
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		keys, err := linearhash.NewEmptyLHash(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{Depth: int64(depth)}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, keys.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &VMap{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*VMap), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a VMap object from an existing given GoshawkDB Object. As
+// with LHashFromObj, no initialisation is done.
+func VMapFromObj(conn *client.Connection, objRef client.ObjectRef) *VMap {
+	return &VMap{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the VMap within a single transaction.
+type state struct {
+	root *mp.Root
+	keys *linearhash.LHash
+}
+
+func (m *VMap) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(m.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if root.Depth < 0 || len(refs) != 1 {
+		return nil, fmt.Errorf("VMap root %v is corrupt", obj)
+	}
+	return &state{root: root, keys: linearhash.LHashFromObj(m.Conn, refs[0])}, nil
+}
+
+// history is the history of a key within a single transaction.
+type history struct {
+	objRef client.ObjectRef
+	h      *mp.History
+	// the values of h.Versions.
+	values []client.ObjectRef
+}
+
+// history returns the history of key, or nil if it has none.
+func (s *state) history(key []byte) (*history, error) {
+	objRef, err := s.keys.Find(key)
+	if err != nil || objRef == nil {
+		return nil, err
+	}
+	return readHistory(*objRef)
+}
+
+func readHistory(objRef client.ObjectRef) (*history, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	h := new(mp.History)
+	if _, err = h.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != len(h.Versions) {
+		return nil, fmt.Errorf("VMap history %v is corrupt", objRef)
+	}
+	return &history{objRef: objRef, h: h, values: refs}, nil
+}
+
+func (h *history) write() error {
+	value, err := h.h.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return h.objRef.Set(value, h.values...)
+}
+
+// push adds a new version with the given value (the history Object
+// for a deletion), dropping the oldest versions beyond depth.
+func (h *history) push(value client.ObjectRef, deleted bool, depth int64) {
+	v := mp.Version{Number: h.h.Next, Time: time.Now().UnixNano(), Deleted: deleted}
+	h.h.Next++
+	h.h.Versions = append([]mp.Version{v}, h.h.Versions...)
+	h.values = append([]client.ObjectRef{value}, h.values...)
+	if depth > 0 && int64(len(h.values)) > depth {
+		h.h.Versions, h.values = h.h.Versions[:depth], h.values[:depth]
+	}
+}
+
+// truncate drops all but the newest n versions, returning the number
+// dropped.
+func (h *history) truncate(n int) int {
+	if n >= len(h.values) {
+		return 0
+	}
+	dropped := len(h.values) - n
+	h.h.Versions, h.values = h.h.Versions[:n], h.values[:n]
+	return dropped
+}
+
+// current returns whether the key currently has a value.
+func (h *history) current() bool {
+	return h != nil && len(h.h.Versions) > 0 && !h.h.Versions[0].Deleted
+}
+
+// Put value as the new version of key, returning its number. If the
+// VMap has a bounded depth, the oldest version of key may be dropped.
+func (m *VMap) Put(key, value []byte) (uint64, error) {
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		h, err := s.history(key)
+		if err != nil {
+			return nil, err
+		} else if h == nil {
+			objRef, err := txn.CreateObject(nil)
+			if err != nil {
+				return nil, err
+			}
+			if err = s.keys.Put(key, objRef); err != nil {
+				return nil, err
+			}
+			h = &history{objRef: objRef, h: new(mp.History)}
+		}
+		valueObj, err := txn.CreateObject(value)
+		if err != nil {
+			return nil, err
+		}
+		h.push(valueObj, false, s.root.Depth)
+		return h.h.Versions[0].Number, h.write()
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Remove key, by making a deletion its new version, and return
+// whether it had a value. Its earlier versions are kept.
+func (m *VMap) Remove(key []byte) (bool, error) {
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		h, err := s.history(key)
+		if err != nil {
+			return nil, err
+		} else if !h.current() {
+			return false, nil
+		}
+		h.push(h.objRef, true, s.root.Depth)
+		return true, h.write()
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// Returns the current value of key, and whether it has one.
+func (m *VMap) Get(key []byte) ([]byte, bool, error) {
+	return m.get(key, func(h *history) int {
+		if h.current() {
+			return 0
+		}
+		return -1
+	})
+}
+
+// Returns the value of the given version of key, and whether there is
+// such a version which is not a deletion and has not been dropped.
+func (m *VMap) GetAt(key []byte, version uint64) ([]byte, bool, error) {
+	return m.get(key, func(h *history) int {
+		for idx, v := range h.h.Versions {
+			if v.Number == version && !v.Deleted {
+				return idx
+			}
+		}
+		return -1
+	})
+}
+
+// get returns the value of the version of key chosen by choose, which
+// returns its position within the history, or -1 if there is none.
+func (m *VMap) get(key []byte, choose func(h *history) int) ([]byte, bool, error) {
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		h, err := s.history(key)
+		if err != nil || h == nil {
+			return nil, err
+		}
+		idx := choose(h)
+		if idx == -1 {
+			return nil, nil
+		}
+		return h.values[idx].Value()
+	})
+	if err != nil {
+		return nil, false, err
+	} else if res == nil {
+		return nil, false, nil
+	}
+	return res.([]byte), true, nil
+}
+
+// Returns the versions of key which have been kept, newest first.
+func (m *VMap) History(key []byte) ([]Version, error) {
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		h, err := s.history(key)
+		if err != nil || h == nil {
+			return []Version(nil), err
+		}
+		versions := make([]Version, len(h.h.Versions))
+		for idx, v := range h.h.Versions {
+			versions[idx] = Version{Number: v.Number, Time: time.Unix(0, v.Time), Deleted: v.Deleted}
+			if !v.Deleted {
+				if versions[idx].Value, err = h.values[idx].Value(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return versions, nil
+	})
+	if err == nil {
+		return res.([]Version), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Drop all but the newest keep versions of key, returning the number
+// dropped. If keep is 0 or less, the whole history of key is dropped,
+// and the next version of key will again be numbered 0.
+func (m *VMap) Prune(key []byte, keep int) (int, error) {
+	if keep < 0 {
+		keep = 0
+	}
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		h, err := s.history(key)
+		if err != nil || h == nil {
+			return 0, err
+		}
+		dropped := h.truncate(keep)
+		if keep == 0 {
+			return dropped, s.keys.Remove(key)
+		} else if dropped == 0 {
+			return 0, nil
+		}
+		return dropped, h.write()
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Drop, from every key, the versions which had been superseded by
+// before, returning the number dropped. The version of each key which
+// was current at before is kept, unless it is a deletion, so every
+// version needed by GetAt for a time from before onwards is kept. A
+// key whose history is left empty is removed altogether. As with
+// LHash.ForEach, this is done within a single transaction.
+func (m *VMap) PruneBefore(before time.Time) (int, error) {
+	var emptied [][]byte
+	res, _, err := m.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		emptied = emptied[:0]
+		s, err := m.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		dropped := 0
+		err = s.keys.ForEach(func(key []byte, objRef client.ObjectRef) error {
+			h, err := readHistory(objRef)
+			if err != nil {
+				return err
+			}
+			keep := len(h.h.Versions)
+			for idx, v := range h.h.Versions {
+				if v.Time <= before.UnixNano() {
+					keep = idx + 1
+					if v.Deleted {
+						keep = idx
+					}
+					break
+				}
+			}
+			n := h.truncate(keep)
+			if n == 0 {
+				return nil
+			}
+			dropped += n
+			if keep == 0 {
+				emptied = append(emptied, key)
+				return nil
+			}
+			return h.write()
+		})
+		if err != nil {
+			return nil, err
+		}
+		// removed only once the iteration is over.
+		for _, key := range emptied {
+			if err = s.keys.Remove(key); err != nil {
+				return nil, err
+			}
+		}
+		return dropped, nil
+	})
+	if err == nil {
+		return res.(int), nil
+	} else {
+		return 0, err
+	}
+}
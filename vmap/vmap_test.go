@@ -0,0 +1,156 @@
+package vmap
+
+import (
+	"fmt"
+	"goshawkdb.io/tests"
+	"testing"
+	"time"
+)
+
+func createEmpty(th *tests.TestHelper, depth int) *VMap {
+	c0 := th.CreateConnections(1)[0]
+	m, err := NewEmptyVMap(c0.Connection, depth)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return m
+}
+
+func put(th *tests.TestHelper, m *VMap, key, value string, expected uint64) {
+	if version, err := m.Put([]byte(key), []byte(value)); err != nil {
+		th.Fatal(err)
+	} else if version != expected {
+		th.Fatalf("Put of %v: expected version %v. Got %v", key, expected, version)
+	}
+}
+
+func assertGet(th *tests.TestHelper, m *VMap, key, expected string, expectedFound bool) {
+	value, found, err := m.Get([]byte(key))
+	if err != nil {
+		th.Fatal(err)
+	} else if found != expectedFound || string(value) != expected {
+		th.Fatalf("Get of %v: expected %v (%v). Got %s (%v)", key, expected, expectedFound, value, found)
+	}
+}
+
+func assertGetAt(th *tests.TestHelper, m *VMap, key string, version uint64, expected string, expectedFound bool) {
+	value, found, err := m.GetAt([]byte(key), version)
+	if err != nil {
+		th.Fatal(err)
+	} else if found != expectedFound || string(value) != expected {
+		th.Fatalf("GetAt of %v@%v: expected %v (%v). Got %s (%v)", key, version, expected, expectedFound, value, found)
+	}
+}
+
+// assertHistory checks the History of key, formatted as
+// "number:value", or "number:-" for a deletion.
+func assertHistory(th *tests.TestHelper, m *VMap, key string, expected ...string) {
+	versions, err := m.History([]byte(key))
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(versions))
+	var last time.Time
+	for idx, v := range versions {
+		if v.Deleted {
+			got[idx] = fmt.Sprintf("%v:-", v.Number)
+		} else {
+			got[idx] = fmt.Sprintf("%v:%s", v.Number, v.Value)
+		}
+		if idx > 0 && v.Time.After(last) {
+			th.Fatalf("History of %v is not newest first: %v", key, versions)
+		}
+		last = v.Time
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		th.Fatalf("History of %v: expected %v. Got %v", key, expected, got)
+	}
+}
+
+func TestPutGetRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	m := createEmpty(th, 0)
+	assertGet(th, m, "a", "", false)
+	assertHistory(th, m, "a")
+	put(th, m, "a", "a0", 0)
+	put(th, m, "a", "a1", 1)
+	put(th, m, "b", "b0", 0)
+	put(th, m, "a", "", 2)
+	assertGet(th, m, "a", "", true)
+	assertGetAt(th, m, "a", 1, "a1", true)
+	assertGetAt(th, m, "a", 3, "", false)
+
+	if removed, err := m.Remove([]byte("a")); err != nil {
+		th.Fatal(err)
+	} else if !removed {
+		th.Fatal("Expected a to be removed")
+	}
+	if removed, err := m.Remove([]byte("a")); err != nil {
+		th.Fatal(err)
+	} else if removed {
+		th.Fatal("Expected a to be removed already")
+	}
+	assertGet(th, m, "a", "", false)
+	assertGetAt(th, m, "a", 3, "", false)
+	assertGetAt(th, m, "a", 0, "a0", true)
+	put(th, m, "a", "a4", 4)
+	assertHistory(th, m, "a", "4:a4", "3:-", "2:", "1:a1", "0:a0")
+	assertHistory(th, m, "b", "0:b0")
+
+	// a bounded depth drops the oldest versions.
+	m = createEmpty(th, 2)
+	for version := uint64(0); version < 4; version++ {
+		put(th, m, "a", fmt.Sprint("a", version), version)
+	}
+	assertHistory(th, m, "a", "3:a3", "2:a2")
+	assertGetAt(th, m, "a", 1, "", false)
+}
+
+func TestPrune(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	m := createEmpty(th, 0)
+	for version := uint64(0); version < 4; version++ {
+		put(th, m, "a", fmt.Sprint("a", version), version)
+	}
+	if dropped, err := m.Prune([]byte("a"), 2); err != nil {
+		th.Fatal(err)
+	} else if dropped != 2 {
+		th.Fatalf("Expected 2 dropped. Got %v", dropped)
+	}
+	assertHistory(th, m, "a", "3:a3", "2:a2")
+	if dropped, err := m.Prune([]byte("a"), 0); err != nil {
+		th.Fatal(err)
+	} else if dropped != 2 {
+		th.Fatalf("Expected 2 dropped. Got %v", dropped)
+	}
+	assertHistory(th, m, "a")
+	put(th, m, "a", "a0", 0)
+
+	put(th, m, "b", "b0", 0)
+	put(th, m, "c", "c0", 0)
+	put(th, m, "c", "c1", 1)
+	if _, err := m.Remove([]byte("c")); err != nil {
+		th.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	put(th, m, "a", "a1", 1)
+	put(th, m, "d", "d0", 0)
+
+	// a0 and b0 were current at before, and c had been removed.
+	if dropped, err := m.PruneBefore(before); err != nil {
+		th.Fatal(err)
+	} else if dropped != 3 {
+		th.Fatalf("Expected 3 dropped. Got %v", dropped)
+	}
+	assertHistory(th, m, "a", "1:a1", "0:a0")
+	assertHistory(th, m, "b", "0:b0")
+	assertHistory(th, m, "c")
+	assertHistory(th, m, "d", "0:d0")
+	put(th, m, "c", "c0", 0)
+}
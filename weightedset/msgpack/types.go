@@ -0,0 +1,20 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a WeightedSet. Its only
+// reference is to the top node of the tree.
+type Root struct {
+	// The number of items.
+	Count int64
+}
+
+// Node is the value of a node of the tree. If Leaf, Keys and Weights
+// are the items and their weights, and the node has no references.
+// Otherwise, its references are to its children, Keys are the lowest
+// keys under each child, and Weights the total weights under each.
+type Node struct {
+	Leaf    bool
+	Keys    [][]byte
+	Weights []uint64
+}
@@ -0,0 +1,533 @@
+// A WeightedSet holds items, each a key with a weight, from which
+// items can be picked at random with probability proportional to their
+// weights, such as the variants of an A/B test, or the backends of a
+// service, shared by every service routing between them.
+//
+// The items are held in a B-tree like tree, ordered by key, each node
+// of which records the total weight under each of its children. So
+// setting a weight, or picking an item, reads only the nodes on the
+// path to the item, rather than every item, and a change of weight is
+// seen by every picker as soon as it commits.
+package weightedset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	mp "goshawkdb.io/collections/weightedset/msgpack"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ErrOverflow is returned by Set if the total weight of the
+// WeightedSet would exceed math.MaxInt64. The WeightedSet is left
+// unchanged.
+var ErrOverflow = errors.New("WeightedSet total weight overflow")
+
+// The most children of each node of the tree.
+const order = 64
+
+type WeightedSet struct {
+	// The connection used to create this WeightedSet object. As with
+	// LHash, you should not use the same WeightedSet object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the WeightedSet.
+	ObjRef client.ObjectRef
+}
+
+// Create a brand new empty WeightedSet. This creates new GoshawkDB
+// Objects and initialises them for use as a WeightedSet.
+func NewEmptyWeightedSet(conn *client.Connection) (*WeightedSet, error) {
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		top, err := createNode(txn, &mp.Node{Leaf: true})
+		if err != nil {
+			return nil, err
+		}
+		value, err := (&mp.Root{}).MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, top.objRef)
+		if err != nil {
+			return nil, err
+		}
+		return &WeightedSet{Conn: conn, ObjRef: objRef}, nil
+	})
+	if err == nil {
+		return res.(*WeightedSet), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a WeightedSet object from an existing given GoshawkDB Object.
+// As with LHashFromObj, no initialisation is done.
+func WeightedSetFromObj(conn *client.Connection, objRef client.ObjectRef) *WeightedSet {
+	return &WeightedSet{Conn: conn, ObjRef: objRef}
+}
+
+// state is the state of the WeightedSet within a single transaction.
+type state struct {
+	objRef client.ObjectRef
+	root   *mp.Root
+	top    *node
+}
+
+func (ws *WeightedSet) read(txn *client.Txn) (*state, error) {
+	obj, err := txn.GetObject(ws.ObjRef)
+	if err != nil {
+		return nil, err
+	}
+	value, refs, err := obj.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	if _, err = root.UnmarshalMsg(value); err != nil {
+		return nil, err
+	} else if len(refs) != 1 {
+		return nil, fmt.Errorf("WeightedSet root %v is corrupt", obj)
+	}
+	top, err := readNode(refs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &state{objRef: obj, root: root, top: top}, nil
+}
+
+func (s *state) write() error {
+	value, err := s.root.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return s.objRef.Set(value, s.top.objRef)
+}
+
+// node is a node of the tree within a single transaction.
+type node struct {
+	objRef client.ObjectRef
+	n      *mp.Node
+	refs   []client.ObjectRef
+}
+
+func createNode(txn *client.Txn, n *mp.Node, refs ...client.ObjectRef) (*node, error) {
+	value, err := n.MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	objRef, err := txn.CreateObject(value, refs...)
+	if err != nil {
+		return nil, err
+	}
+	return &node{objRef: objRef, n: n, refs: refs}, nil
+}
+
+func readNode(objRef client.ObjectRef) (*node, error) {
+	value, refs, err := objRef.ValueReferences()
+	if err != nil {
+		return nil, err
+	}
+	n := new(mp.Node)
+	if _, err = n.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	children := len(n.Keys)
+	if n.Leaf {
+		children = 0
+	}
+	if len(n.Weights) != len(n.Keys) || len(refs) != children {
+		return nil, fmt.Errorf("WeightedSet node %v is corrupt", objRef)
+	}
+	return &node{objRef: objRef, n: n, refs: refs}, nil
+}
+
+func (n *node) write() error {
+	value, err := n.n.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return n.objRef.Set(value, n.refs...)
+}
+
+// total returns the total weight under n.
+func (n *node) total() uint64 {
+	total := uint64(0)
+	for _, w := range n.n.Weights {
+		total += w
+	}
+	return total
+}
+
+// search returns the index of the first key of n not less than key,
+// and whether it is key.
+func (n *node) search(key []byte) (int, bool) {
+	keys := n.n.Keys
+	idx := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i], key) >= 0 })
+	return idx, idx < len(keys) && bytes.Equal(keys[idx], key)
+}
+
+// childIndex returns the index of the child of n under which key
+// belongs: the last whose lowest key is not greater than key.
+func (n *node) childIndex(key []byte) int {
+	keys := n.n.Keys
+	idx := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i], key) > 0 })
+	if idx == 0 {
+		return 0
+	}
+	return idx - 1
+}
+
+// splice replaces drop children of n, from the idx'th, with children.
+func (n *node) splice(idx, drop int, children []*node) {
+	keys := append([][]byte(nil), n.n.Keys[:idx]...)
+	weights := append([]uint64(nil), n.n.Weights[:idx]...)
+	refs := append([]client.ObjectRef(nil), n.refs[:idx]...)
+	for _, child := range children {
+		keys = append(keys, child.n.Keys[0])
+		weights = append(weights, child.total())
+		refs = append(refs, child.objRef)
+	}
+	n.n.Keys = append(keys, n.n.Keys[idx+drop:]...)
+	n.n.Weights = append(weights, n.n.Weights[idx+drop:]...)
+	n.refs = append(refs, n.refs[idx+drop:]...)
+}
+
+// splitIfFull writes n, first splitting it into as many nodes as
+// needed to have no more than order entries each, and returns them,
+// starting with n itself.
+func (n *node) splitIfFull(txn *client.Txn) ([]*node, error) {
+	count := len(n.n.Keys)
+	parts := (count + order - 1) / order
+	if parts <= 1 {
+		return []*node{n}, n.write()
+	}
+	keys, weights, refs := n.n.Keys, n.n.Weights, n.refs
+	nodes := make([]*node, parts)
+	for part := range nodes {
+		lo, hi := part*count/parts, (part+1)*count/parts
+		piece := &mp.Node{Leaf: n.n.Leaf, Keys: keys[lo:hi], Weights: weights[lo:hi]}
+		var pieceRefs []client.ObjectRef
+		if !n.n.Leaf {
+			pieceRefs = refs[lo:hi]
+		}
+		if part == 0 {
+			n.n, n.refs = piece, pieceRefs
+			if err := n.write(); err != nil {
+				return nil, err
+			}
+			nodes[part] = n
+		} else {
+			created, err := createNode(txn, piece, pieceRefs...)
+			if err != nil {
+				return nil, err
+			}
+			nodes[part] = created
+		}
+	}
+	return nodes, nil
+}
+
+// Returns the number of items in the WeightedSet.
+func (ws *WeightedSet) Len() (int64, error) {
+	res, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.root.Count, nil
+	})
+	if err == nil {
+		return res.(int64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Returns the total weight of the items in the WeightedSet.
+func (ws *WeightedSet) Total() (uint64, error) {
+	res, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.top.total(), nil
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// weight returns the weight of key, or 0 if it is not present.
+func (s *state) weight(key []byte) (uint64, error) {
+	n := s.top
+	for !n.n.Leaf {
+		if len(n.refs) == 0 {
+			return 0, nil
+		}
+		var err error
+		if n, err = readNode(n.refs[n.childIndex(key)]); err != nil {
+			return 0, err
+		}
+	}
+	if idx, found := n.search(key); found {
+		return n.n.Weights[idx], nil
+	}
+	return 0, nil
+}
+
+// Returns the weight of the item with the given key, or 0 if there is
+// no such item.
+func (ws *WeightedSet) Weight(key []byte) (uint64, error) {
+	res, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return s.weight(key)
+	})
+	if err == nil {
+		return res.(uint64), nil
+	} else {
+		return 0, err
+	}
+}
+
+// Set the weight of the item with the given key, adding the item if
+// it is not present. A weight of 0 removes the item. ErrOverflow is
+// returned if the total weight would exceed math.MaxInt64.
+func (ws *WeightedSet) Set(key []byte, weight uint64) error {
+	if weight == 0 {
+		_, err := ws.Remove(key)
+		return err
+	}
+	_, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		old, err := s.weight(key)
+		if err != nil {
+			return nil, err
+		} else if old == weight {
+			return nil, nil
+		} else if others := s.top.total() - old; weight > math.MaxInt64-others {
+			return nil, ErrOverflow
+		}
+		nodes, err := s.set(txn, s.top, key, weight)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) > 1 {
+			// the top node split, so grow a level above it.
+			top := &node{n: &mp.Node{}}
+			top.splice(0, 0, nodes)
+			if s.top, err = createNode(txn, top.n, top.refs...); err != nil {
+				return nil, err
+			}
+		}
+		if old == 0 {
+			s.root.Count++
+		} else if len(nodes) == 1 {
+			return nil, nil
+		}
+		return nil, s.write()
+	})
+	return err
+}
+
+// set sets the weight of key under n, returning the nodes which
+// replace n in its parent.
+func (s *state) set(txn *client.Txn, n *node, key []byte, weight uint64) ([]*node, error) {
+	if n.n.Leaf {
+		idx, found := n.search(key)
+		if found {
+			n.n.Weights[idx] = weight
+		} else {
+			n.n.Keys = append(n.n.Keys[:idx], append([][]byte{key}, n.n.Keys[idx:]...)...)
+			n.n.Weights = append(n.n.Weights[:idx], append([]uint64{weight}, n.n.Weights[idx:]...)...)
+		}
+		return n.splitIfFull(txn)
+	}
+	idx := n.childIndex(key)
+	child, err := readNode(n.refs[idx])
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.set(txn, child, key, weight)
+	if err != nil {
+		return nil, err
+	}
+	n.splice(idx, 1, nodes)
+	return n.splitIfFull(txn)
+}
+
+// Remove the item with the given key, returning whether there was one.
+func (ws *WeightedSet) Remove(key []byte) (bool, error) {
+	res, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		removed, err := s.remove(s.top, key)
+		if err != nil || !removed {
+			return removed, err
+		}
+		// shrink the tree while the top node has just one child.
+		for !s.top.n.Leaf && len(s.top.refs) == 1 {
+			if s.top, err = readNode(s.top.refs[0]); err != nil {
+				return nil, err
+			}
+		}
+		if !s.top.n.Leaf && len(s.top.refs) == 0 {
+			s.top.n.Leaf = true
+			if err = s.top.write(); err != nil {
+				return nil, err
+			}
+		}
+		s.root.Count--
+		return true, s.write()
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// remove removes key from under n, returning whether it was present.
+func (s *state) remove(n *node, key []byte) (bool, error) {
+	if n.n.Leaf {
+		idx, found := n.search(key)
+		if !found {
+			return false, nil
+		}
+		n.n.Keys = append(n.n.Keys[:idx], n.n.Keys[idx+1:]...)
+		n.n.Weights = append(n.n.Weights[:idx], n.n.Weights[idx+1:]...)
+		return true, n.write()
+	} else if len(n.refs) == 0 {
+		return false, nil
+	}
+	idx := n.childIndex(key)
+	child, err := readNode(n.refs[idx])
+	if err != nil {
+		return false, err
+	}
+	removed, err := s.remove(child, key)
+	if err != nil || !removed {
+		return removed, err
+	}
+	if len(child.n.Keys) == 0 {
+		n.splice(idx, 1, nil)
+	} else {
+		n.splice(idx, 1, []*node{child})
+		if err = n.merge(idx, child); err != nil {
+			return false, err
+		}
+	}
+	return true, n.write()
+}
+
+// merge merges child, the idx'th child of n, with a neighbour, if it
+// has fewer than a quarter of order entries and they fit in one node,
+// so that the tree stays shallow.
+func (n *node) merge(idx int, child *node) error {
+	if len(child.n.Keys) >= order/4 || len(n.refs) < 2 {
+		return nil
+	}
+	left, right := child, (*node)(nil)
+	var err error
+	if idx+1 < len(n.refs) {
+		if right, err = readNode(n.refs[idx+1]); err != nil {
+			return err
+		}
+	} else {
+		idx--
+		right = child
+		if left, err = readNode(n.refs[idx]); err != nil {
+			return err
+		}
+	}
+	if len(left.n.Keys)+len(right.n.Keys) > order {
+		return nil
+	}
+	left.n.Keys = append(left.n.Keys, right.n.Keys...)
+	left.n.Weights = append(left.n.Weights, right.n.Weights...)
+	left.refs = append(left.refs, right.refs...)
+	if err = left.write(); err != nil {
+		return err
+	}
+	n.splice(idx, 2, []*node{left})
+	return nil
+}
+
+// Pick an item at random, using rng, with probability proportional to
+// its weight, returning its key, or nil if the WeightedSet is empty.
+func (ws *WeightedSet) Pick(rng *rand.Rand) ([]byte, error) {
+	res, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		total := s.top.total()
+		if total == 0 {
+			return []byte(nil), nil
+		}
+		target := uint64(rng.Int63n(int64(total)))
+		for n := s.top; ; {
+			idx := 0
+			for ; idx < len(n.n.Weights)-1 && target >= n.n.Weights[idx]; idx++ {
+				target -= n.n.Weights[idx]
+			}
+			if n.n.Leaf {
+				return n.n.Keys[idx], nil
+			} else if n, err = readNode(n.refs[idx]); err != nil {
+				return nil, err
+			}
+		}
+	})
+	if err == nil {
+		return res.([]byte), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Iterate over the items in the WeightedSet in key order, with their
+// weights. As with LHash.ForEach, the iteration is done within a
+// single transaction, which may restart, in which case items may be
+// supplied again. An error returned by f stops the iteration and is
+// returned.
+func (ws *WeightedSet) ForEach(f func(key []byte, weight uint64) error) error {
+	_, _, err := ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return nil, forEach(s.top, f)
+	})
+	return err
+}
+
+func forEach(n *node, f func(key []byte, weight uint64) error) error {
+	for idx, key := range n.n.Keys {
+		if n.n.Leaf {
+			if err := f(key, n.n.Weights[idx]); err != nil {
+				return err
+			}
+			continue
+		}
+		child, err := readNode(n.refs[idx])
+		if err != nil {
+			return err
+		} else if err = forEach(child, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package weightedset
+
+import (
+	"bytes"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/modelcheck"
+	"goshawkdb.io/tests"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func createEmpty(th *tests.TestHelper) *WeightedSet {
+	c0 := th.CreateConnections(1)[0]
+	ws, err := NewEmptyWeightedSet(c0.Connection)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return ws
+}
+
+// assertItems checks that ws holds exactly the items of model, in key
+// order, and that every node records the right keys and weights.
+func assertItems(th *tests.TestHelper, ws *WeightedSet, model map[string]uint64) {
+	total := uint64(0)
+	for _, weight := range model {
+		total += weight
+	}
+	if got, err := ws.Total(); err != nil {
+		th.Fatal(err)
+	} else if got != total {
+		th.Fatalf("Expected total %v. Got %v", total, got)
+	}
+	if length, err := ws.Len(); err != nil {
+		th.Fatal(err)
+	} else if length != int64(len(model)) {
+		th.Fatalf("Expected %v items. Got %v", len(model), length)
+	}
+	var last []byte
+	count := 0
+	err := ws.ForEach(func(key []byte, weight uint64) error {
+		if last != nil && bytes.Compare(last, key) >= 0 {
+			return fmt.Errorf("Key %q after %q", key, last)
+		} else if model[string(key)] != weight {
+			return fmt.Errorf("Key %q: expected weight %v. Got %v", key, model[string(key)], weight)
+		}
+		last = key
+		count++
+		return nil
+	})
+	if err != nil {
+		th.Fatal(err)
+	} else if count != len(model) {
+		th.Fatalf("Expected %v items. Got %v", len(model), count)
+	}
+	_, _, err = ws.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		s, err := ws.read(txn)
+		if err != nil {
+			return nil, err
+		}
+		return nil, check(s.top)
+	})
+	if err != nil {
+		th.Fatal(err)
+	}
+}
+
+// check checks the keys and weights n records for its children.
+func check(n *node) error {
+	if len(n.n.Keys) > order {
+		return fmt.Errorf("Node has %v entries", len(n.n.Keys))
+	} else if n.n.Leaf {
+		return nil
+	}
+	for idx, ref := range n.refs {
+		child, err := readNode(ref)
+		if err != nil {
+			return err
+		} else if len(child.n.Keys) == 0 {
+			return fmt.Errorf("Node has an empty child")
+		} else if !bytes.Equal(child.n.Keys[0], n.n.Keys[idx]) || child.total() != n.n.Weights[idx] {
+			return fmt.Errorf("Child has lowest key %q and weight %v, but node records %q and %v", child.n.Keys[0], child.total(), n.n.Keys[idx], n.n.Weights[idx])
+		} else if err = check(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSetRemove(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	ws := createEmpty(th)
+	model := make(map[string]uint64)
+	assertItems(th, ws, model)
+	rng := rand.New(rand.NewSource(0))
+	// enough items for several levels of the tree.
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("item%05d", rng.Intn(2000))
+		weight := uint64(rng.Intn(100))
+		if err := ws.Set([]byte(key), weight); err != nil {
+			th.Fatal(err)
+		}
+		if weight == 0 {
+			delete(model, key)
+		} else {
+			model[key] = weight
+		}
+	}
+	assertItems(th, ws, model)
+	for key, weight := range model {
+		if got, err := ws.Weight([]byte(key)); err != nil {
+			th.Fatal(err)
+		} else if got != weight {
+			th.Fatalf("Key %v: expected weight %v. Got %v", key, weight, got)
+		}
+		break
+	}
+	if weight, err := ws.Weight([]byte("missing")); err != nil || weight != 0 {
+		th.Fatalf("Expected no weight. Got %v, %v", weight, err)
+	}
+	if err := ws.Set([]byte("huge"), math.MaxInt64); err != ErrOverflow {
+		th.Fatalf("Expected ErrOverflow. Got %v", err)
+	}
+	for key := range model {
+		if removed, err := ws.Remove([]byte(key)); err != nil || !removed {
+			th.Fatalf("Expected to remove %v. Got %v, %v", key, removed, err)
+		}
+		delete(model, key)
+		if len(model)%200 == 0 {
+			assertItems(th, ws, model)
+		}
+	}
+	if removed, err := ws.Remove([]byte("item00000")); err != nil || removed {
+		th.Fatalf("Expected nothing to remove. Got %v, %v", removed, err)
+	}
+	if err := ws.Set([]byte("huge"), math.MaxInt64); err != nil {
+		th.Fatal(err)
+	}
+	assertItems(th, ws, map[string]uint64{"huge": math.MaxInt64})
+}
+
+func TestPick(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	ws := createEmpty(th)
+	rng := rand.New(rand.NewSource(0))
+	if key, err := ws.Pick(rng); err != nil || key != nil {
+		th.Fatalf("Expected nothing to pick. Got %q, %v", key, err)
+	}
+	weights := map[string]uint64{"a": 1, "b": 3, "c": 6}
+	for i := 0; i < 200; i++ {
+		weights[fmt.Sprintf("zero%03d", i)] = 1
+	}
+	for key, weight := range weights {
+		if err := ws.Set([]byte(key), weight); err != nil {
+			th.Fatal(err)
+		}
+	}
+	// the padding items are removed again, leaving a, b and c.
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("zero%03d", i)
+		if err := ws.Set([]byte(key), 0); err != nil {
+			th.Fatal(err)
+		}
+		delete(weights, key)
+	}
+	counts := make(map[string]int)
+	const picks = 5000
+	for i := 0; i < picks; i++ {
+		key, err := ws.Pick(rng)
+		if err != nil {
+			th.Fatal(err)
+		}
+		counts[string(key)]++
+	}
+	for key, weight := range weights {
+		expected := float64(picks) * float64(weight) / 10
+		if got := float64(counts[key]); math.Abs(got-expected) > expected/5 {
+			th.Fatalf("Key %v: expected about %v picks. Got %v", key, expected, got)
+		}
+	}
+	if len(counts) != len(weights) {
+		th.Fatalf("Expected only %v to be picked. Got %v", weights, counts)
+	}
+}
+
+// wsOp sets the weight of a key, or removes it if remove.
+type wsOp struct {
+	key    string
+	weight uint64
+	remove bool
+}
+
+func (op wsOp) String() string {
+	if op.remove {
+		return fmt.Sprintf("Remove(%v)", op.key)
+	}
+	return fmt.Sprintf("Set(%v, %v)", op.key, op.weight)
+}
+
+type wsSystem struct {
+	ws    *WeightedSet
+	model map[string]uint64
+}
+
+func (sys *wsSystem) Apply(op modelcheck.Op) error {
+	o := op.(wsOp)
+	if o.remove {
+		removed, err := sys.ws.Remove([]byte(o.key))
+		if err != nil {
+			return err
+		} else if _, found := sys.model[o.key]; removed != found {
+			return fmt.Errorf("Expected removed %v. Got %v", found, removed)
+		}
+		delete(sys.model, o.key)
+		return nil
+	}
+	if err := sys.ws.Set([]byte(o.key), o.weight); err != nil {
+		return err
+	} else if o.weight == 0 {
+		delete(sys.model, o.key)
+	} else {
+		sys.model[o.key] = o.weight
+	}
+	return nil
+}
+
+func (sys *wsSystem) Check() error {
+	total := uint64(0)
+	for _, weight := range sys.model {
+		total += weight
+	}
+	if got, err := sys.ws.Total(); err != nil {
+		return err
+	} else if got != total {
+		return fmt.Errorf("Expected total %v. Got %v", total, got)
+	}
+	count := 0
+	err := sys.ws.ForEach(func(key []byte, weight uint64) error {
+		if sys.model[string(key)] != weight {
+			return fmt.Errorf("Key %q: expected weight %v. Got %v", key, sys.model[string(key)], weight)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	} else if count != len(sys.model) {
+		return fmt.Errorf("Expected %v items. Got %v", len(sys.model), count)
+	}
+	return nil
+}
+
+func TestModel(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	conn := th.CreateConnections(1)[0].Connection
+	spec := modelcheck.Spec{
+		New: func() (modelcheck.System, error) {
+			ws, err := NewEmptyWeightedSet(conn)
+			if err != nil {
+				return nil, err
+			}
+			return &wsSystem{ws: ws, model: make(map[string]uint64)}, nil
+		},
+		Gen: func(rng *rand.Rand, sys modelcheck.System) modelcheck.Op {
+			// mostly sets, so the tree grows several levels.
+			key := fmt.Sprintf("item%03d", rng.Intn(300))
+			if rng.Intn(4) == 0 {
+				return wsOp{key: key, remove: true}
+			}
+			return wsOp{key: key, weight: uint64(rng.Intn(10))}
+		},
+	}
+	if err := modelcheck.Run(spec, modelcheck.Config{Seed: 1, Runs: 3, Ops: 400, Logf: th.Logf}); err != nil {
+		th.Fatal(err)
+	}
+}
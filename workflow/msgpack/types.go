@@ -0,0 +1,22 @@
+package msgpack
+
+//go:generate msgp
+
+// Root is the value of the root Object of a Workflows. Its references
+// are to the root of the Table of Instances, which has an index of
+// their states, and to the BTree of their timeouts.
+type Root struct {
+	// The names of the states of the Definition, in order.
+	States []string
+}
+
+// Instance is the value of an instance of a workflow, in the Table.
+type Instance struct {
+	State string
+	Data  []byte
+	// The number of transitions made.
+	Transitions uint64
+	// When the timeout of the state expires, in nanoseconds since the
+	// Unix epoch, or 0 if the state has no timeout.
+	Deadline int64
+}
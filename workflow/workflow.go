@@ -0,0 +1,505 @@
+// Workflows holds the instances of a long-running workflow, or saga:
+// each instance is in one of the states of a Definition, and moves
+// between states only by the transitions of the Definition, in
+// response to events. A transition may have a guard, which decides
+// whether the transition is allowed, and an action, which derives the
+// new data of the instance, and each transition is applied in a
+// single transaction, so an instance is never seen part way through
+// one.
+//
+// A state may have a timeout, after which an instance still in the
+// state is sent the state's timeout event by FireTimeouts. The
+// instances are held in a Table, with an index of their states, so
+// that the instances in a state can be found, and their timeouts are
+// held in a BTree ordered by deadline, so that FireTimeouts reads only
+// the timeouts which have expired.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"goshawkdb.io/client"
+	"goshawkdb.io/collections/btree"
+	"goshawkdb.io/collections/ordenc"
+	"goshawkdb.io/collections/ordered"
+	"goshawkdb.io/collections/table"
+	mp "goshawkdb.io/collections/workflow/msgpack"
+	"time"
+)
+
+var (
+	// ErrExists is returned by Start if the instance already exists.
+	ErrExists = errors.New("Workflow instance already exists")
+	// ErrNotFound is returned by Fire if the instance does not exist.
+	ErrNotFound = errors.New("Workflow instance not found")
+	// ErrNoTransition is returned by Fire if the Definition has no
+	// transition for the event from the instance's state.
+	ErrNoTransition = errors.New("No transition for the event from the instance's state")
+	// ErrGuardRejected is returned by Fire if the guard of every
+	// transition for the event from the instance's state rejects it.
+	ErrGuardRejected = errors.New("Transition rejected by its guard")
+)
+
+// The name of the index of the states of the instances.
+const stateIndex = "state"
+
+// The number of timeouts FireTimeouts fires in each transaction.
+const timeoutBatch = 16
+
+// A State is a state of a workflow.
+type State struct {
+	Name string
+	// If positive, an instance which has been in the state for Timeout
+	// is sent TimeoutEvent by FireTimeouts.
+	Timeout      time.Duration
+	TimeoutEvent string
+}
+
+// A Transition moves an instance from one state to another in
+// response to an event. Guard and Action are called within the
+// transaction applying the transition, which may restart, so they may
+// be called more than once, and must not have side effects.
+type Transition struct {
+	From  string
+	Event string
+	To    string
+	// If not nil, the transition is made only if Guard returns true.
+	Guard func(i *Instance, payload []byte) bool
+	// If not nil, returns the Data of the instance once the transition
+	// is made. Otherwise, the Data is unchanged.
+	Action func(i *Instance, payload []byte) ([]byte, error)
+}
+
+// A Definition declares the states and transitions of a workflow. As
+// guards and actions cannot be stored in GoshawkDB, every user of a
+// Workflows must supply the same Definition.
+type Definition struct {
+	// The states of the workflow, each with a different Name. Instances
+	// start in the first.
+	States []State
+	// The transitions of the workflow. If several are for the same
+	// event from the same state, the first whose guard allows it is
+	// made.
+	Transitions []Transition
+}
+
+func (def *Definition) validate() error {
+	if len(def.States) == 0 {
+		return errors.New("Workflow Definition has no states")
+	}
+	names := make(map[string]bool, len(def.States))
+	for _, s := range def.States {
+		if names[s.Name] {
+			return fmt.Errorf("Duplicate Workflow state %q", s.Name)
+		} else if s.Timeout < 0 || (s.Timeout > 0 && s.TimeoutEvent == "") {
+			return fmt.Errorf("Invalid timeout of Workflow state %q", s.Name)
+		}
+		names[s.Name] = true
+	}
+	for _, t := range def.Transitions {
+		if !names[t.From] || !names[t.To] {
+			return fmt.Errorf("Workflow transition from %q to %q on %q refers to an unknown state", t.From, t.To, t.Event)
+		}
+	}
+	return nil
+}
+
+func (def *Definition) state(name string) *State {
+	for idx := range def.States {
+		if def.States[idx].Name == name {
+			return &def.States[idx]
+		}
+	}
+	return nil
+}
+
+// An Instance is an instance of a workflow.
+type Instance struct {
+	ID    []byte
+	State string
+	Data  []byte
+	// The number of transitions made.
+	Transitions uint64
+	// When the timeout of the state expires, or the zero Time if the
+	// state has no timeout.
+	Deadline time.Time
+}
+
+func newInstance(id []byte, inst *mp.Instance) *Instance {
+	i := &Instance{ID: id, State: inst.State, Data: inst.Data, Transitions: inst.Transitions}
+	if inst.Deadline != 0 {
+		i.Deadline = time.Unix(0, inst.Deadline)
+	}
+	return i
+}
+
+type Workflows struct {
+	// The connection used to create this Workflows object. As with
+	// LHash, you should not use the same Workflows object from
+	// multiple connections.
+	Conn *client.Connection
+	// The underlying Object in GoshawkDB which holds the root data for
+	// the Workflows.
+	ObjRef    client.ObjectRef
+	def       *Definition
+	instances *table.Table
+	timeouts  *btree.BTree
+}
+
+// Create a brand new empty Workflows, of instances of the given
+// Definition. This creates new GoshawkDB Objects and initialises them
+// for use as a Workflows.
+func NewEmptyWorkflows(conn *client.Connection, def *Definition) (*Workflows, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	root := new(mp.Root)
+	for _, s := range def.States {
+		root.States = append(root.States, s.Name)
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		instances, err := table.NewEmptyTable(conn, table.IndexDef{Name: stateIndex, Extract: extractState})
+		if err != nil {
+			return nil, err
+		}
+		timeouts, err := btree.NewEmptyBTree(conn)
+		if err != nil {
+			return nil, err
+		}
+		value, err := root.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		objRef, err := txn.CreateObject(value, instances.ObjRef, timeouts.ObjRef)
+		if err != nil {
+			return nil, err
+		}
+		return &Workflows{Conn: conn, ObjRef: objRef, def: def, instances: instances, timeouts: timeouts}, nil
+	})
+	if err == nil {
+		return res.(*Workflows), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Create a Workflows object from an existing given GoshawkDB Object.
+// As with TableFromObj, the root is read: def must declare the same
+// states, in the same order, as the Definition the Workflows was
+// created with.
+func WorkflowsFromObj(conn *client.Connection, objRef client.ObjectRef, def *Definition) (*Workflows, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	res, _, err := conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		obj, err := txn.GetObject(objRef)
+		if err != nil {
+			return nil, err
+		}
+		value, refs, err := obj.ValueReferences()
+		if err != nil {
+			return nil, err
+		}
+		root := new(mp.Root)
+		if _, err = root.UnmarshalMsg(value); err != nil {
+			return nil, err
+		} else if len(refs) != 2 {
+			return nil, fmt.Errorf("Workflows root %v is corrupt", obj)
+		}
+		if len(root.States) != len(def.States) {
+			return nil, fmt.Errorf("Workflows has %v states but %v were declared", len(root.States), len(def.States))
+		}
+		for idx, name := range root.States {
+			if def.States[idx].Name != name {
+				return nil, fmt.Errorf("Workflows state %v is %q, but %q was declared", idx, name, def.States[idx].Name)
+			}
+		}
+		instances, err := table.TableFromObj(conn, refs[0], table.IndexDef{Name: stateIndex, Extract: extractState})
+		if err != nil {
+			return nil, err
+		}
+		return &Workflows{Conn: conn, ObjRef: obj, def: def, instances: instances, timeouts: btree.BTreeFromObj(conn, refs[1])}, nil
+	})
+	if err == nil {
+		return res.(*Workflows), nil
+	} else {
+		return nil, err
+	}
+}
+
+// extractState derives the index key of an instance: its state.
+func extractState(key, value []byte) ([][]byte, error) {
+	inst, err := decodeInstance(value)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{[]byte(inst.State)}, nil
+}
+
+func decodeInstance(value []byte) (*mp.Instance, error) {
+	inst := new(mp.Instance)
+	if _, err := inst.UnmarshalMsg(value); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// find returns the instance with the given ID, or nil if there is none.
+func (w *Workflows) find(id []byte) (*mp.Instance, error) {
+	value, err := w.instances.Find(id)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return decodeInstance(value)
+}
+
+// timeoutKey returns the key in the BTree of timeouts of the timeout
+// of the instance with the given ID: the deadline, then the ID.
+func timeoutKey(deadline int64, id []byte) []byte {
+	return append(ordenc.AppendInt64(nil, deadline), id...)
+}
+
+// enter moves inst, which has the given ID, into the named state at
+// now, replacing the timeout of its old state with that of the new.
+func (w *Workflows) enter(id []byte, inst *mp.Instance, state string, now time.Time) error {
+	if inst.Deadline != 0 {
+		if err := w.timeouts.Remove(timeoutKey(inst.Deadline, id)); err != nil {
+			return err
+		}
+		inst.Deadline = 0
+	}
+	inst.State = state
+	if s := w.def.state(state); s.Timeout > 0 {
+		inst.Deadline = now.Add(s.Timeout).UnixNano()
+		// the key holds the ID, so the root is just a placeholder.
+		return w.timeouts.Put(timeoutKey(inst.Deadline, id), w.ObjRef)
+	}
+	return nil
+}
+
+// Start a new instance with the given ID and Data, in the first state
+// of the Definition. Returns ErrExists if there is already an instance
+// with the ID.
+func (w *Workflows) Start(id, data []byte) (*Instance, error) {
+	res, _, err := w.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		inst, err := w.find(id)
+		if err != nil {
+			return nil, err
+		} else if inst != nil {
+			return nil, ErrExists
+		}
+		inst = &mp.Instance{Data: data}
+		if err = w.enter(id, inst, w.def.States[0].Name, time.Now()); err != nil {
+			return nil, err
+		}
+		value, err := inst.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = w.instances.Insert(id, value); err != nil {
+			return nil, err
+		}
+		return newInstance(id, inst), nil
+	})
+	if err == nil {
+		return res.(*Instance), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Send the event, with the given payload, to the instance with the
+// given ID, making the first transition for the event from its state
+// whose guard allows it, and returning the instance once the
+// transition is made. Entering a state, even the state the instance is
+// already in, restarts the state's timeout. Returns ErrNotFound,
+// ErrNoTransition or ErrGuardRejected if no transition is made, or the
+// error of the transition's action.
+func (w *Workflows) Fire(id []byte, event string, payload []byte) (*Instance, error) {
+	res, _, err := w.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		inst, err := w.find(id)
+		if err != nil {
+			return nil, err
+		} else if inst == nil {
+			return nil, ErrNotFound
+		}
+		if err = w.fire(id, inst, event, payload, time.Now()); err != nil {
+			return nil, err
+		}
+		return newInstance(id, inst), nil
+	})
+	if err == nil {
+		return res.(*Instance), nil
+	} else {
+		return nil, err
+	}
+}
+
+// fire makes the transition of inst, which has the given ID, for the
+// event, and writes inst.
+func (w *Workflows) fire(id []byte, inst *mp.Instance, event string, payload []byte, now time.Time) error {
+	guarded := false
+	for idx := range w.def.Transitions {
+		t := &w.def.Transitions[idx]
+		if t.From != inst.State || t.Event != event {
+			continue
+		}
+		i := newInstance(id, inst)
+		if t.Guard != nil && !t.Guard(i, payload) {
+			guarded = true
+			continue
+		}
+		if t.Action != nil {
+			data, err := t.Action(i, payload)
+			if err != nil {
+				return err
+			}
+			inst.Data = data
+		}
+		if err := w.enter(id, inst, t.To, now); err != nil {
+			return err
+		}
+		inst.Transitions++
+		return w.write(id, inst)
+	}
+	if guarded {
+		return ErrGuardRejected
+	}
+	return ErrNoTransition
+}
+
+func (w *Workflows) write(id []byte, inst *mp.Instance) error {
+	value, err := inst.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return w.instances.Update(id, value)
+}
+
+// Returns the instance with the given ID, or nil if there is none.
+func (w *Workflows) Get(id []byte) (*Instance, error) {
+	res, _, err := w.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		inst, err := w.find(id)
+		if err != nil || inst == nil {
+			return (*Instance)(nil), err
+		}
+		return newInstance(id, inst), nil
+	})
+	if err == nil {
+		return res.(*Instance), nil
+	} else {
+		return nil, err
+	}
+}
+
+// Returns the instances in the named state, in no particular order.
+func (w *Workflows) InState(state string) ([]*Instance, error) {
+	if w.def.state(state) == nil {
+		return nil, fmt.Errorf("Workflow has no state %q", state)
+	}
+	rows, err := w.instances.FindBy(stateIndex, []byte(state))
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]*Instance, len(rows))
+	for idx, row := range rows {
+		inst, err := decodeInstance(row.Value)
+		if err != nil {
+			return nil, err
+		}
+		instances[idx] = newInstance(row.Key, inst)
+	}
+	return instances, nil
+}
+
+// Remove the instance with the given ID, and its timeout, returning
+// whether there was one. Instances are never removed otherwise, even
+// once in a state with no transitions from it.
+func (w *Workflows) Remove(id []byte) (bool, error) {
+	res, _, err := w.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+		inst, err := w.find(id)
+		if err != nil || inst == nil {
+			return false, err
+		}
+		if inst.Deadline != 0 {
+			if err = w.timeouts.Remove(timeoutKey(inst.Deadline, id)); err != nil {
+				return nil, err
+			}
+		}
+		return true, w.instances.Delete(id)
+	})
+	if err == nil {
+		return res.(bool), nil
+	} else {
+		return false, err
+	}
+}
+
+// errBatchFull stops a Range once FireTimeouts has a full batch.
+var errBatchFull = errors.New("Batch full")
+
+// Send the timeout event of its state to every instance whose timeout
+// has expired by now, returning the number of timeouts fired. The
+// transitions are made as if at now, so the timeouts of the states
+// they enter expire relative to now. If no transition is made for the
+// timeout event, the instance stays in its state with no timeout. As
+// with TimeSeries.Prune, the timeouts are fired in batches, each in a
+// single transaction, so that a large backlog does not make for a
+// large transaction. Call FireTimeouts periodically, from any number
+// of connections: each timeout is fired only once.
+func (w *Workflows) FireTimeouts(now time.Time) (int, error) {
+	total := 0
+	for {
+		res, _, err := w.Conn.RunTransaction(func(txn *client.Txn) (interface{}, error) {
+			var keys [][]byte
+			// every key with a deadline up to now is before this.
+			hi := ordered.Exclusive(ordenc.AppendInt64(nil, now.UnixNano()+1))
+			err := w.timeouts.Range(ordered.Bound{}, hi, false, func(k []byte, v client.ObjectRef) error {
+				keys = append(keys, k)
+				if len(keys) == timeoutBatch {
+					return errBatchFull
+				}
+				return nil
+			})
+			if err != nil && err != errBatchFull {
+				return nil, err
+			}
+			for _, k := range keys {
+				if err = w.timeout(k, now); err != nil {
+					return nil, err
+				}
+			}
+			return len(keys), nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += res.(int)
+		if res.(int) < timeoutBatch {
+			return total, nil
+		}
+	}
+}
+
+// timeout fires the timeout with the given key.
+func (w *Workflows) timeout(key []byte, now time.Time) error {
+	_, id, err := ordenc.DecodeInt64(key)
+	if err != nil {
+		return err
+	}
+	inst, err := w.find(id)
+	if err != nil {
+		return err
+	} else if inst == nil {
+		return fmt.Errorf("Workflows timeout refers to missing instance %q", id)
+	}
+	err = w.fire(id, inst, w.def.state(inst.State).TimeoutEvent, nil, now)
+	if err == ErrNoTransition || err == ErrGuardRejected {
+		if err = w.timeouts.Remove(key); err != nil {
+			return err
+		}
+		inst.Deadline = 0
+		return w.write(id, inst)
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package workflow
+
+import (
+	"goshawkdb.io/tests"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// order is a workflow of orders, the Data of which is the amount due.
+var order = &Definition{
+	States: []State{
+		{Name: "pending", Timeout: time.Hour, TimeoutEvent: "expire"},
+		{Name: "paid", Timeout: 2 * time.Hour, TimeoutEvent: "remind"},
+		{Name: "shipped"},
+		{Name: "cancelled"},
+	},
+	Transitions: []Transition{
+		{From: "pending", Event: "pay", To: "paid",
+			Guard: func(i *Instance, payload []byte) bool {
+				return string(payload) == string(i.Data)
+			},
+			Action: func(i *Instance, payload []byte) ([]byte, error) {
+				return []byte("0"), nil
+			}},
+		{From: "pending", Event: "expire", To: "cancelled"},
+		{From: "pending", Event: "cancel", To: "cancelled"},
+		{From: "paid", Event: "ship", To: "shipped"},
+	},
+}
+
+func createEmpty(th *tests.TestHelper) *Workflows {
+	c0 := th.CreateConnections(1)[0]
+	w, err := NewEmptyWorkflows(c0.Connection, order)
+	if err != nil {
+		th.Fatal(err)
+	}
+	return w
+}
+
+func start(th *tests.TestHelper, w *Workflows, count int) {
+	for i := 0; i < count; i++ {
+		if _, err := w.Start([]byte(strconv.Itoa(i)), []byte("10")); err != nil {
+			th.Fatal(err)
+		}
+	}
+}
+
+func assertInState(th *tests.TestHelper, w *Workflows, state string, ids ...string) {
+	instances, err := w.InState(state)
+	if err != nil {
+		th.Fatal(err)
+	}
+	got := make([]string, len(instances))
+	for idx, i := range instances {
+		if i.State != state {
+			th.Fatalf("Expected instance %q in state %v. Got %v", i.ID, state, i.State)
+		}
+		got[idx] = string(i.ID)
+	}
+	sort.Strings(got)
+	sort.Strings(ids)
+	if len(got) != len(ids) {
+		th.Fatalf("Expected %v in state %v. Got %v", ids, state, got)
+	}
+	for idx := range ids {
+		if got[idx] != ids[idx] {
+			th.Fatalf("Expected %v in state %v. Got %v", ids, state, got)
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	w := createEmpty(th)
+	start(th, w, 3)
+	if _, err := w.Start([]byte("0"), nil); err != ErrExists {
+		th.Fatalf("Expected ErrExists. Got %v", err)
+	}
+	if _, err := w.Fire([]byte("0"), "pay", []byte("5")); err != ErrGuardRejected {
+		th.Fatalf("Expected ErrGuardRejected. Got %v", err)
+	}
+	if _, err := w.Fire([]byte("0"), "ship", nil); err != ErrNoTransition {
+		th.Fatalf("Expected ErrNoTransition. Got %v", err)
+	}
+	if _, err := w.Fire([]byte("9"), "pay", nil); err != ErrNotFound {
+		th.Fatalf("Expected ErrNotFound. Got %v", err)
+	}
+	i, err := w.Fire([]byte("0"), "pay", []byte("10"))
+	if err != nil {
+		th.Fatal(err)
+	} else if i.State != "paid" || string(i.Data) != "0" || i.Transitions != 1 || i.Deadline.IsZero() {
+		th.Fatalf("Unexpected instance after pay: %v", i)
+	}
+	if _, err = w.Fire([]byte("1"), "cancel", nil); err != nil {
+		th.Fatal(err)
+	}
+	assertInState(th, w, "pending", "2")
+	assertInState(th, w, "paid", "0")
+	assertInState(th, w, "cancelled", "1")
+	if i, err = w.Fire([]byte("0"), "ship", nil); err != nil {
+		th.Fatal(err)
+	} else if i.State != "shipped" || i.Transitions != 2 || !i.Deadline.IsZero() {
+		th.Fatalf("Unexpected instance after ship: %v", i)
+	}
+	if i, err = w.Get([]byte("0")); err != nil {
+		th.Fatal(err)
+	} else if i.State != "shipped" {
+		th.Fatalf("Expected shipped. Got %v", i.State)
+	}
+	if _, err = w.InState("lost"); err == nil {
+		th.Fatal("Expected an error for an unknown state")
+	}
+
+	w2, err := WorkflowsFromObj(w.Conn, w.ObjRef, order)
+	if err != nil {
+		th.Fatal(err)
+	}
+	if removed, err := w2.Remove([]byte("2")); err != nil || !removed {
+		th.Fatalf("Expected to remove 2. Got %v, %v", removed, err)
+	}
+	if i, err = w2.Get([]byte("2")); err != nil || i != nil {
+		th.Fatalf("Expected no instance. Got %v, %v", i, err)
+	}
+	assertInState(th, w2, "pending")
+	if _, err = WorkflowsFromObj(w.Conn, w.ObjRef, &Definition{States: order.States[:2]}); err == nil {
+		th.Fatal("Expected an error for a different Definition")
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	th := tests.NewTestHelper(t)
+	defer th.Shutdown()
+
+	w := createEmpty(th)
+	start(th, w, 40)
+	for i := 0; i < 10; i++ {
+		if _, err := w.Fire([]byte(strconv.Itoa(i)), "pay", []byte("10")); err != nil {
+			th.Fatal(err)
+		}
+	}
+	if fired, err := w.FireTimeouts(time.Now()); err != nil || fired != 0 {
+		th.Fatalf("Expected no timeouts. Got %v, %v", fired, err)
+	}
+	// the pending orders expire, but the paid ones have longer.
+	later := time.Now().Add(90 * time.Minute)
+	if fired, err := w.FireTimeouts(later); err != nil || fired != 30 {
+		th.Fatalf("Expected 30 timeouts. Got %v, %v", fired, err)
+	}
+	if instances, err := w.InState("cancelled"); err != nil || len(instances) != 30 {
+		th.Fatalf("Expected 30 cancelled. Got %v, %v", len(instances), err)
+	}
+	// remind has no transition, so the paid orders just lose their
+	// timeout.
+	if fired, err := w.FireTimeouts(later.Add(time.Hour)); err != nil || fired != 10 {
+		th.Fatalf("Expected 10 timeouts. Got %v, %v", fired, err)
+	}
+	paid, err := w.InState("paid")
+	if err != nil || len(paid) != 10 {
+		th.Fatalf("Expected 10 paid. Got %v, %v", len(paid), err)
+	}
+	for _, i := range paid {
+		if !i.Deadline.IsZero() {
+			th.Fatalf("Expected no deadline. Got %v", i.Deadline)
+		}
+	}
+	if fired, err := w.FireTimeouts(later.Add(24 * time.Hour)); err != nil || fired != 0 {
+		th.Fatalf("Expected no timeouts. Got %v, %v", fired, err)
+	}
+}